@@ -19,6 +19,7 @@ package main
 import (
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -27,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/bls"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/vote"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
@@ -260,6 +262,40 @@ The keyfile is assumed to contain an unencrypted private key in hexadecimal form
 				},
 				Description: `ronin account generatebls [--secret]`,
 			},
+			{
+				Name:   "exportvoteprotection",
+				Usage:  "Export the history of the signed finality votes",
+				Action: utils.MigrateFlags(voteProtectionExport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.BlsProtectionPathFlag,
+				},
+				ArgsUsage: "<file>",
+				Description: `
+    ronin account exportvoteprotection <file>
+
+Exports the history of the finality votes signed by the node, used to refuse
+the conflicting votes, in the vote protection interchange format. Import it
+into the node taking over the BLS key before it starts voting.
+`,
+			},
+			{
+				Name:   "importvoteprotection",
+				Usage:  "Import a history of signed finality votes",
+				Action: utils.MigrateFlags(voteProtectionImport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.BlsProtectionPathFlag,
+				},
+				ArgsUsage: "<file>",
+				Description: `
+    ronin account importvoteprotection <file>
+
+Merges a history of signed finality votes in the vote protection interchange
+format into the history of the node. The highest signed vote of a key is never
+lowered by the import. The node must be stopped.
+`,
+			},
 		},
 	}
 )
@@ -598,6 +634,54 @@ func blsAccountCheck(ctx *cli.Context) error {
 	return nil
 }
 
+// loadVoteProtection opens the vote protection history of the node
+func loadVoteProtection(ctx *cli.Context) *vote.VoteProtection {
+	path := ctx.GlobalString(utils.BlsProtectionPathFlag.Name)
+	if path == "" {
+		stack, _ := makeConfigNode(ctx)
+		path = stack.ResolvePath(vote.DefaultProtectionFile)
+		stack.Close()
+	}
+	protection, err := vote.NewVoteProtection(path)
+	if err != nil {
+		utils.Fatalf("Failed to load vote protection history, err %s", err)
+	}
+	return protection
+}
+
+func voteProtectionExport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := json.MarshalIndent(loadVoteProtection(ctx).Export(), "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode vote protection history, err %s", err)
+	}
+	if err := ioutil.WriteFile(ctx.Args().First(), blob, 0600); err != nil {
+		utils.Fatalf("Failed to write vote protection history, err %s", err)
+	}
+	return nil
+}
+
+func voteProtectionImport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read vote protection history, err %s", err)
+	}
+	var interchange vote.ProtectionInterchange
+	if err := json.Unmarshal(blob, &interchange); err != nil {
+		utils.Fatalf("Failed to decode vote protection history, err %s", err)
+	}
+	if err := loadVoteProtection(ctx).Import(&interchange); err != nil {
+		utils.Fatalf("Failed to import vote protection history, err %s", err)
+	}
+	fmt.Printf("Imported the signed votes of %d keys\n", len(interchange.Data))
+	return nil
+}
+
 func blsAccountGenerate(ctx *cli.Context) error {
 	secretKey, err := blst.RandKey()
 	if err != nil {
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	exportSystemStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(exportSystemState),
+		Name:      "export-system-state",
+		Usage:     "Export the state of the system contracts at the checkpoint blocks",
+		ArgsUsage: "<filename> <blockNumFirst> <blockNumLast>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The export-system-state command writes the accounts and the full storage of the
+system contracts (validator set, staking, slash indicator, profile and finality
+tracking) at every checkpoint block in the range to the file, one JSON object
+per checkpoint. If the file ends with .gz, the output is gzipped.
+
+Each account is exported with its Merkle proof against the state root of the
+checkpoint block, so the archive can be verified with only the block headers
+(see verify-system-state). The state of the checkpoint blocks must be available
+in the local database, which usually requires an archive node.`,
+	}
+	verifySystemStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifySystemState),
+		Name:      "verify-system-state",
+		Usage:     "Verify a system contract state archive against the block headers",
+		ArgsUsage: "<filename>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-system-state command checks the archive written by export-system-state.
+The account proofs are verified against the state roots, the storage of each
+account is verified against its storage root and the state roots are checked
+against the block headers in the local database.`,
+	}
+)
+
+// systemAccount is the state of a system contract at a checkpoint block
+type systemAccount struct {
+	Name        string                        `json:"name"`
+	Address     common.Address                `json:"address"`
+	Nonce       uint64                        `json:"nonce"`
+	Balance     *hexutil.Big                  `json:"balance"`
+	CodeHash    common.Hash                   `json:"codeHash"`
+	StorageRoot common.Hash                   `json:"storageRoot"`
+	Proof       []hexutil.Bytes               `json:"proof"`
+	Storage     map[common.Hash]hexutil.Bytes `json:"storage"` // Hashed slot to RLP encoded value
+}
+
+// checkpointState is the state of the system contracts at a checkpoint block
+type checkpointState struct {
+	Number    uint64          `json:"number"`
+	Hash      common.Hash     `json:"hash"`
+	StateRoot common.Hash     `json:"stateRoot"`
+	Accounts  []systemAccount `json:"accounts"`
+}
+
+// systemContracts returns the system contracts with their names in the chain config
+func systemContracts(contracts *params.ConsortiumV2Contracts) ([]string, []common.Address) {
+	var (
+		names     []string
+		addresses []common.Address
+	)
+	value, typ := reflect.ValueOf(contracts).Elem(), reflect.TypeOf(contracts).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		address := value.Field(i).Interface().(common.Address)
+		if address == (common.Address{}) {
+			continue
+		}
+		names = append(names, typ.Field(i).Tag.Get("json"))
+		addresses = append(addresses, address)
+	}
+	return names, addresses
+}
+
+// isCheckpoint returns whether the block at number is a checkpoint block
+func isCheckpoint(config *params.ChainConfig, number uint64) bool {
+	epoch := config.Consortium.Epoch
+	if config.IsConsortiumV2(new(big.Int).SetUint64(number)) {
+		epoch = config.Consortium.EpochV2
+	}
+	return epoch != 0 && number%epoch == 0
+}
+
+func exportSystemState(ctx *cli.Context) error {
+	if len(ctx.Args()) != 3 {
+		utils.Fatalf("This command requires 3 arguments.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if first > last {
+		utils.Fatalf("Export error: first block number %d is larger than last %d\n", first, last)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	chainConfig := rawdb.ReadChainConfig(db, rawdb.ReadCanonicalHash(db, 0))
+	if chainConfig == nil || chainConfig.Consortium == nil || chainConfig.ConsortiumV2Contracts == nil {
+		utils.Fatalf("The chain has no consortium system contracts")
+	}
+	names, addresses := systemContracts(chainConfig.ConsortiumV2Contracts)
+
+	fn := ctx.Args().First()
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	encoder := json.NewEncoder(writer)
+	stateDatabase := state.NewDatabase(db)
+
+	exported := 0
+	for number := first; number <= last; number++ {
+		if !isCheckpoint(chainConfig, number) {
+			continue
+		}
+		hash := rawdb.ReadCanonicalHash(db, number)
+		header := rawdb.ReadHeader(db, hash, number)
+		if header == nil {
+			return fmt.Errorf("header for block %d not found", number)
+		}
+		statedb, err := state.New(header.Root, stateDatabase, nil)
+		if err != nil {
+			return fmt.Errorf("state of block %d is not available: %v", number, err)
+		}
+
+		checkpoint := checkpointState{
+			Number:    number,
+			Hash:      hash,
+			StateRoot: header.Root,
+		}
+		for i, address := range addresses {
+			if !statedb.Exist(address) {
+				continue
+			}
+			account, err := dumpSystemAccount(statedb, names[i], address)
+			if err != nil {
+				return fmt.Errorf("failed to export %s at block %d: %v", names[i], number, err)
+			}
+			checkpoint.Accounts = append(checkpoint.Accounts, *account)
+		}
+		if err := encoder.Encode(&checkpoint); err != nil {
+			return err
+		}
+		exported++
+		log.Info("Exported system contract state", "number", number, "hash", hash, "accounts", len(checkpoint.Accounts))
+	}
+	log.Info("Exported system contract state archive", "file", fn, "checkpoints", exported)
+	return nil
+}
+
+// dumpSystemAccount returns the account with its proof and all its storage slots
+func dumpSystemAccount(statedb *state.StateDB, name string, address common.Address) (*systemAccount, error) {
+	proof, err := statedb.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	account := &systemAccount{
+		Name:     name,
+		Address:  address,
+		Nonce:    statedb.GetNonce(address),
+		Balance:  (*hexutil.Big)(statedb.GetBalance(address)),
+		CodeHash: statedb.GetCodeHash(address),
+		Storage:  make(map[common.Hash]hexutil.Bytes),
+	}
+	for _, node := range proof {
+		account.Proof = append(account.Proof, node)
+	}
+
+	storageTrie := statedb.StorageTrie(address)
+	account.StorageRoot = storageTrie.Hash()
+	it := trie.NewIterator(storageTrie.NodeIterator(nil))
+	for it.Next() {
+		account.Storage[common.BytesToHash(it.Key)] = common.CopyBytes(it.Value)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return account, nil
+}
+
+func verifySystemState(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	fn := ctx.Args().First()
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	decoder := json.NewDecoder(reader)
+	verified := 0
+	for {
+		var checkpoint checkpointState
+		if err := decoder.Decode(&checkpoint); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		header := rawdb.ReadHeader(db, checkpoint.Hash, checkpoint.Number)
+		if header == nil {
+			return fmt.Errorf("header for block %d (%x) not found", checkpoint.Number, checkpoint.Hash)
+		}
+		if header.Root != checkpoint.StateRoot {
+			return fmt.Errorf("state root mismatch at block %d, header %x archive %x", checkpoint.Number, header.Root, checkpoint.StateRoot)
+		}
+		for _, account := range checkpoint.Accounts {
+			if err := verifySystemAccount(checkpoint.StateRoot, &account); err != nil {
+				return fmt.Errorf("invalid %s at block %d: %v", account.Name, checkpoint.Number, err)
+			}
+		}
+		verified++
+	}
+	log.Info("Verified system contract state archive", "file", fn, "checkpoints", verified)
+	return nil
+}
+
+// verifySystemAccount verifies the account proof against the state root and the
+// storage slots against the storage root in the proven account
+func verifySystemAccount(root common.Hash, account *systemAccount) error {
+	proofDb := memorydb.New()
+	for _, node := range account.Proof {
+		if err := proofDb.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+	value, err := trie.VerifyProof(root, crypto.Keccak256(account.Address.Bytes()), proofDb)
+	if err != nil {
+		return err
+	}
+	var proven types.StateAccount
+	if err := rlp.DecodeBytes(value, &proven); err != nil {
+		return err
+	}
+	if proven.Nonce != account.Nonce || proven.Balance.Cmp(account.Balance.ToInt()) != 0 ||
+		common.BytesToHash(proven.CodeHash) != account.CodeHash || proven.Root != account.StorageRoot {
+		return fmt.Errorf("account mismatch with the proof")
+	}
+
+	// The stack trie requires the slots to be inserted in order
+	slots := make([]common.Hash, 0, len(account.Storage))
+	for slot := range account.Storage {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i][:], slots[j][:]) < 0
+	})
+	storageTrie := trie.NewStackTrie(nil)
+	for _, slot := range slots {
+		if err := storageTrie.TryUpdate(slot[:], account.Storage[slot]); err != nil {
+			return err
+		}
+	}
+	if hash := storageTrie.Hash(); hash != account.StorageRoot {
+		return fmt.Errorf("storage root mismatch, exp %x got %x", account.StorageRoot, hash)
+	}
+	return nil
+}
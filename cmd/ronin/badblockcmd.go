@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	replayTraceFlag = cli.BoolFlag{
+		Name:  "trace",
+		Usage: "Print the JSON opcode trace of the block execution to stderr",
+	}
+	replayTraceMemoryFlag = cli.BoolFlag{
+		Name:  "trace.memory",
+		Usage: "Include the EVM memory in the opcode trace",
+	}
+	replayTraceNoStorageFlag = cli.BoolFlag{
+		Name:  "trace.nostorage",
+		Usage: "Exclude the contract storage from the opcode trace",
+	}
+
+	replayBadBlockCommand = cli.Command{
+		Action:    utils.MigrateFlags(replayBadBlock),
+		Name:      "replay-bad-block",
+		Usage:     "Re-execute a persisted bad block bundle against the local database",
+		ArgsUsage: "<bundlePath>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			replayTraceFlag,
+			replayTraceMemoryFlag,
+			replayTraceNoStorageFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The replay-bad-block command loads a bad block bundle written by the node when
+a block fails consensus or execution verification (see --badblock.bundledir),
+verifies the block header and re-executes the block on top of its parent state.
+
+The parent state must still be available in the local database. Use --trace to
+print the opcode level trace of the execution.`,
+	}
+)
+
+// replayBadBlock re-runs the verification and execution of the block in a bad
+// block bundle, reporting the first error encountered.
+func replayBadBlock(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	bundle, err := core.ReadBadBlockBundle(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read bad block bundle: %v", err)
+	}
+	block := bundle.Block
+	log.Info("Loaded bad block bundle", "number", block.Number(), "hash", block.Hash(),
+		"parent", bundle.ParentHash, "reported", bundle.Error)
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	chainConfig, genesisHash, err := core.SetupGenesisBlock(db, nil, false)
+	if err != nil {
+		utils.Fatalf("Failed to load chain config: %v", err)
+	}
+	parent := rawdb.ReadHeader(db, bundle.ParentHash, bundle.ParentNumber)
+	if parent == nil {
+		utils.Fatalf("Parent block %d (%x) is not available in the local database", bundle.ParentNumber, bundle.ParentHash)
+	}
+	if bundle.ParentRoot != (common.Hash{}) && bundle.ParentRoot != parent.Root {
+		log.Warn("Parent state root mismatch", "bundle", bundle.ParentRoot, "local", parent.Root)
+	}
+
+	// The engine reads the system contracts through the API over the replay
+	// chain, the chain is set once it is created as in eth.New
+	backend := &replayBackend{}
+	engine := ethconfig.CreateConsensusEngine(stack, chainConfig, &ethconfig.Defaults.Ethash, nil, false, db, ethapi.NewPublicBlockChainAPI(backend), genesisHash)
+	chain, err := core.NewBlockChain(db, nil, chainConfig, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		utils.Fatalf("Failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+	backend.chain = chain
+
+	if err := engine.VerifyHeader(chain, block.Header(), true); err != nil {
+		fmt.Printf("Header verification failed: %v\n", err)
+		return err
+	}
+	if err := chain.Validator().ValidateBody(block); err != nil {
+		fmt.Printf("Body verification failed: %v\n", err)
+		return err
+	}
+
+	statedb, err := state.New(parent.Root, state.NewDatabase(db), nil)
+	if err != nil {
+		utils.Fatalf("Parent state %x is not available: %v", parent.Root, err)
+	}
+	var vmConfig vm.Config
+	if ctx.Bool(replayTraceFlag.Name) {
+		vmConfig.Debug = true
+		vmConfig.Tracer = logger.NewJSONLogger(&logger.Config{
+			EnableMemory:   ctx.Bool(replayTraceMemoryFlag.Name),
+			DisableStorage: ctx.Bool(replayTraceNoStorageFlag.Name),
+		}, os.Stderr)
+	}
+	receipts, _, _, usedGas, err := core.NewStateProcessor(chainConfig, chain, engine).Process(block, statedb, vmConfig)
+	if err != nil {
+		fmt.Printf("Block execution failed: %v\n", err)
+		return err
+	}
+	if err := chain.Validator().ValidateState(block, statedb, receipts, usedGas); err != nil {
+		fmt.Printf("State verification failed: %v\n", err)
+		return err
+	}
+	fmt.Printf("Block %d (%x) replayed without error, gas used %d\n", block.NumberU64(), block.Hash(), usedGas)
+	return nil
+}
+
+// replayBackend is the API backend of the consensus engine over the replay
+// chain. It only serves the header, state and call methods the engine reads the
+// system contracts with, the other methods of ethapi.Backend are unavailable.
+type replayBackend struct {
+	ethapi.Backend
+	chain *core.BlockChain
+}
+
+func (b *replayBackend) ChainConfig() *params.ChainConfig {
+	return b.chain.Config()
+}
+
+func (b *replayBackend) Engine() consensus.Engine {
+	return b.chain.Engine()
+}
+
+func (b *replayBackend) RPCGasCap() uint64 {
+	return ethconfig.Defaults.RPCGasCap
+}
+
+func (b *replayBackend) RPCEVMTimeout() time.Duration {
+	return ethconfig.Defaults.RPCEVMTimeout
+}
+
+func (b *replayBackend) CurrentHeader() *types.Header {
+	return b.chain.CurrentHeader()
+}
+
+func (b *replayBackend) CurrentBlock() *types.Block {
+	return b.chain.CurrentBlock()
+}
+
+func (b *replayBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	switch number {
+	case rpc.PendingBlockNumber, rpc.LatestBlockNumber:
+		return b.chain.CurrentHeader(), nil
+	case rpc.FinalizedBlockNumber:
+		if block := b.chain.FinalizedBlock(); block != nil {
+			return block.Header(), nil
+		}
+		return nil, errors.New("header not found")
+	}
+	return b.chain.GetHeaderByNumber(uint64(number)), nil
+}
+
+func (b *replayBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.chain.GetHeaderByHash(hash), nil
+}
+
+func (b *replayBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+	if number, ok := blockNrOrHash.Number(); ok {
+		return b.HeaderByNumber(ctx, number)
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header := b.chain.GetHeaderByHash(hash)
+		if header == nil {
+			return nil, errors.New("header for hash not found")
+		}
+		if blockNrOrHash.RequireCanonical && b.chain.GetCanonicalHash(header.Number.Uint64()) != hash {
+			return nil, errors.New("hash is not currently canonical")
+		}
+		return header, nil
+	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
+func (b *replayBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.StateAndHeaderByNumberOrHash(ctx, rpc.BlockNumberOrHashWithNumber(number))
+}
+
+func (b *replayBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*state.StateDB, *types.Header, error) {
+	header, err := b.HeaderByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, errors.New("header not found")
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return statedb, header, err
+}
+
+func (b *replayBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
+	if header := b.chain.GetHeaderByHash(hash); header != nil {
+		return b.chain.GetTd(hash, header.Number.Uint64())
+	}
+	return nil
+}
+
+func (b *replayBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmConfig *vm.Config) (*vm.EVM, func() error, error) {
+	if vmConfig == nil {
+		vmConfig = b.chain.GetVMConfig()
+	}
+	txContext := core.NewEVMTxContext(msg)
+	context := core.NewEVMBlockContext(header, b.chain, nil)
+	return vm.NewEVM(context, txContext, state, b.chain.Config(), *vmConfig), func() error { return nil }, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestReplayBackendCall(t *testing.T) {
+	var (
+		contract = common.HexToAddress("0x1000")
+		// Returns 42 in a 32 bytes word
+		code    = common.FromHex("0x602a60005260206000f3")
+		genesis = &core.Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   core.GenesisAlloc{contract: {Balance: new(big.Int), Code: code}},
+		}
+	)
+	db := rawdb.NewMemoryDatabase()
+	genesis.MustCommit(db)
+
+	backend := &replayBackend{}
+	api := ethapi.NewPublicBlockChainAPI(backend)
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+	backend.chain = chain
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	result, err := api.GetCode(context.Background(), contract, latest)
+	if err != nil || !bytes.Equal(result, code) {
+		t.Fatalf("Code mismatch, got %x err %v", result, err)
+	}
+	result, err = api.Call(context.Background(), ethapi.TransactionArgs{To: &contract}, rpc.BlockNumberOrHashWithHash(chain.Genesis().Hash(), false), nil)
+	if err != nil {
+		t.Fatalf("Failed to call contract: %v", err)
+	}
+	if new(big.Int).SetBytes(result).Uint64() != 42 {
+		t.Fatalf("Call result mismatch, got %x", result)
+	}
+	header, err := api.GetHeader(context.Background(), 0)
+	if err != nil || header.Hash() != chain.Genesis().Hash() {
+		t.Fatalf("Header mismatch, got %v err %v", header, err)
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -50,6 +51,7 @@ var (
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
 			utils.ForceOverrideChainConfigFlag,
+			utils.ChainPresetFlag,
 		},
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
@@ -57,7 +59,8 @@ The init command initializes a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
 
-It expects the genesis file as argument.`,
+It expects the genesis file as argument. With --chain.preset, the chain
+configuration of the genesis file is replaced by the built-in one.`,
 	}
 	dumpGenesisCommand = cli.Command{
 		Action:    utils.MigrateFlags(dumpGenesis),
@@ -202,6 +205,17 @@ func initGenesis(ctx *cli.Context) error {
 	if err := json.NewDecoder(file).Decode(genesis); err != nil {
 		utils.Fatalf("invalid genesis file: %v", err)
 	}
+	var preset *params.ChainPreset
+	if ctx.IsSet(utils.ChainPresetFlag.Name) {
+		if preset, err = params.LookupChainPreset(ctx.String(utils.ChainPresetFlag.Name)); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		genesis.Config = preset.Config
+		// Check the genesis before anything is written to the databases
+		if hash := genesis.ToBlock(nil).Hash(); preset.GenesisHash != (common.Hash{}) && preset.GenesisHash != hash {
+			utils.Fatalf("Genesis %x mismatches the %s genesis %x", hash, preset.Name, preset.GenesisHash)
+		}
+	}
 	var overrideChainConfig bool
 	if ctx.IsSet(utils.ForceOverrideChainConfigFlag.Name) {
 		overrideChainConfig = ctx.Bool(utils.ForceOverrideChainConfigFlag.Name)
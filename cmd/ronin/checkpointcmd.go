@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	exportCheckpointsCommand = cli.Command{
+		Action:    utils.MigrateFlags(exportCheckpoints),
+		Name:      "export-checkpoints",
+		Usage:     "Export the signed epoch checkpoints of the canonical chain",
+		ArgsUsage: "<filename> <blockNumFirst> <blockNumLast>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The export-checkpoints command writes the epoch checkpoints in the range to the
+file. Each checkpoint holds the hash and number of the epoch block, the hash of
+its validator set and the aggregated finality signature of the validators in the
+child block, along with the consensus snapshot of the epoch block.
+
+The snapshots are read from the chain database, the checkpoints must be verified
+by the local node and the snapshots must not be in the mmap snapshot store.`,
+	}
+	importCheckpointsCommand = cli.Command{
+		Action:    utils.MigrateFlags(importCheckpoints),
+		Name:      "import-checkpoints",
+		Usage:     "Bootstrap a node from a file of signed epoch checkpoints",
+		ArgsUsage: "<filename>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The import-checkpoints command verifies the finality signatures of the checkpoints
+written by export-checkpoints and trusts the highest one. The node syncs the chain
+without verifying the headers up to the trusted checkpoint and drops the peers
+serving another block at its number. The node must be initialised with the
+genesis block of the chain first (see init).
+
+The checkpoints are only as trusted as the validator sets in the file, it must
+come from a trusted source.`,
+	}
+)
+
+// checkpointFile is the portable file of the signed epoch checkpoints
+type checkpointFile struct {
+	ChainID     *big.Int         `json:"chainId"`
+	Genesis     common.Hash      `json:"genesis"`
+	Checkpoints []*v2.Checkpoint `json:"checkpoints"`
+}
+
+func exportCheckpoints(ctx *cli.Context) error {
+	if len(ctx.Args()) != 3 {
+		utils.Fatalf("This command requires 3 arguments.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if first > last {
+		utils.Fatalf("Export error: first block number %d is larger than last %d\n", first, last)
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	chainConfig := rawdb.ReadChainConfig(db, genesis)
+	if chainConfig == nil || chainConfig.Consortium == nil || chainConfig.ConsortiumV2Block == nil {
+		utils.Fatalf("The chain is not a consortium v2 chain")
+	}
+	var (
+		file  = checkpointFile{ChainID: chainConfig.ChainID, Genesis: genesis}
+		epoch = v2.EpochLength(chainConfig)
+	)
+	for number := first - first%epoch; number <= last; number += epoch {
+		if number < first || number == 0 || !chainConfig.IsShillin(new(big.Int).SetUint64(number)) {
+			continue
+		}
+		checkpoint, err := v2.ReadCheckpoint(db, chainConfig, number)
+		if err != nil {
+			return err
+		}
+		if err := v2.VerifyCheckpoint(chainConfig, checkpoint); err != nil {
+			return fmt.Errorf("invalid checkpoint %d: %v", number, err)
+		}
+		file.Checkpoints = append(file.Checkpoints, checkpoint)
+		log.Info("Exported checkpoint", "number", number, "hash", checkpoint.Hash, "validators", len(checkpoint.Snapshot.ValidatorsWithBlsPub))
+	}
+	if len(file.Checkpoints) == 0 {
+		utils.Fatalf("No epoch block after Shillin in the range")
+	}
+	blob, err := json.MarshalIndent(&file, "", "  ")
+	if err != nil {
+		return err
+	}
+	fn := ctx.Args().First()
+	if err := os.WriteFile(fn, blob, 0644); err != nil {
+		return err
+	}
+	log.Info("Exported checkpoints", "file", fn, "checkpoints", len(file.Checkpoints))
+	return nil
+}
+
+func importCheckpoints(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	blob, err := os.ReadFile(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	var file checkpointFile
+	if err := json.Unmarshal(blob, &file); err != nil {
+		utils.Fatalf("Invalid checkpoint file: %v", err)
+	}
+	if len(file.Checkpoints) == 0 {
+		utils.Fatalf("The checkpoint file has no checkpoint")
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		utils.Fatalf("The node has no genesis block, initialise it first with the init command")
+	}
+	chainConfig := rawdb.ReadChainConfig(db, genesis)
+	if chainConfig == nil || chainConfig.Consortium == nil || chainConfig.ConsortiumV2Block == nil {
+		utils.Fatalf("The chain is not a consortium v2 chain")
+	}
+	if file.Genesis != genesis || file.ChainID == nil || file.ChainID.Cmp(chainConfig.ChainID) != 0 {
+		utils.Fatalf("The checkpoints are of another chain, genesis %x chain id %v", file.Genesis, file.ChainID)
+	}
+
+	var trusted *v2.Checkpoint
+	for _, checkpoint := range file.Checkpoints {
+		if err := v2.VerifyCheckpoint(chainConfig, checkpoint); err != nil {
+			utils.Fatalf("Invalid checkpoint %d: %v", checkpoint.Number, err)
+		}
+		if trusted == nil || checkpoint.Number > trusted.Number {
+			trusted = checkpoint
+		}
+	}
+	if hash := rawdb.ReadCanonicalHash(db, trusted.Number); hash != (common.Hash{}) && hash != trusted.Hash {
+		utils.Fatalf("The local chain has block %x at the checkpoint number %d", hash, trusted.Number)
+	}
+	if err := v2.WriteTrustedCheckpoint(db, trusted); err != nil {
+		return err
+	}
+	log.Info("Imported trusted checkpoint", "number", trusted.Number, "hash", trusted.Hash, "verified", len(file.Checkpoints))
+	return nil
+}
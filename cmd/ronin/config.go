@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"os/signal"
 	"reflect"
+	"syscall"
 	"unicode"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -35,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/eth/catalyst"
 	"github.com/ethereum/go-ethereum/eth/ethconfig"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
@@ -175,6 +178,11 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 	// store internal transaction is enabled flag
 	rawdb.WriteStoreInternalTransactionsEnabled(backend.ChainDb(), ctx.GlobalBool(utils.StoreInternalTransactions.Name))
 
+	// Reload the validator settings of the config file on SIGHUP
+	if file := ctx.GlobalString(configFileFlag.Name); file != "" && eth != nil {
+		go reloadValidatorSettings(ctx, file, eth)
+	}
+
 	// Configure catalyst.
 	if ctx.GlobalBool(utils.CatalystFlag.Name) {
 		if eth == nil {
@@ -204,9 +212,58 @@ func makeFullNode(ctx *cli.Context) (*node.Node, ethapi.Backend) {
 			utils.RegisterEthStatsService(stack, backend, cfg.Ethstats.URL, coinbase)
 		}
 	}
+	// Add the firehose streaming exporter if requested
+	if ctx.GlobalIsSet(utils.FirehoseEndpointFlag.Name) {
+		if eth == nil {
+			utils.Fatalf("Firehose does not work in light client mode.")
+		}
+		utils.RegisterFirehoseService(stack, eth, ctx.GlobalString(utils.FirehoseEndpointFlag.Name))
+	}
 	return stack, backend
 }
 
+// reloadValidatorSettings applies the validator settings of the config file each
+// time the node receives SIGHUP. As when the node starts, the settings given by
+// the command line flags take precedence over the config file.
+func reloadValidatorSettings(ctx *cli.Context, file string, backend *eth.Ethereum) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	for range sighup {
+		log.Info("Reloading validator settings", "file", file)
+		cfg := gethConfig{
+			Eth:     ethconfig.Defaults,
+			Node:    defaultNodeConfig(),
+			Metrics: metrics.DefaultConfig,
+		}
+		if err := loadConfig(file, &cfg); err != nil {
+			log.Error("Failed to reload config file", "err", err)
+			continue
+		}
+		settings := eth.ValidatorSettings{
+			VoteAssemblyWindow:     &cfg.Node.VoteAssemblyWindow,
+			AllowedFutureBlockTime: &cfg.Eth.AllowedFutureBlockTime,
+			DisableVoting:          &cfg.Eth.DisableVoting,
+			DisableSealing:         &cfg.Eth.DisableSealing,
+		}
+		if ctx.GlobalIsSet(utils.VoteAssemblyWindow.Name) {
+			settings.VoteAssemblyWindow = nil
+		}
+		if ctx.GlobalIsSet(utils.AllowedFutureBlockTimeFlag.Name) {
+			settings.AllowedFutureBlockTime = nil
+		}
+		if ctx.GlobalIsSet(utils.DisableVotingFlag.Name) {
+			settings.DisableVoting = nil
+		}
+		if ctx.GlobalIsSet(utils.DisableSealingFlag.Name) {
+			settings.DisableSealing = nil
+		}
+		if err := backend.UpdateValidatorSettings(settings); err != nil {
+			log.Error("Failed to reload validator settings", "err", err)
+		}
+	}
+}
+
 // dumpConfig is the dumpconfig command.
 func dumpConfig(ctx *cli.Context) error {
 	_, cfg := makeConfigNode(ctx)
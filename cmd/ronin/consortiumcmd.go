@@ -0,0 +1,215 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium"
+	"github.com/ethereum/go-ethereum/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	rebuildSnapshotBlockFlag = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Epoch block number of the snapshot to rebuild",
+	}
+
+	backfillStatsFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block number whose epoch is indexed",
+	}
+	backfillStatsToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block number whose epoch is indexed (default = head)",
+	}
+
+	replayFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block number to replay",
+	}
+	replayToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block number to replay (default = from)",
+	}
+	replayTraceOutputFlag = cli.StringFlag{
+		Name:  "trace.output",
+		Usage: "File to write the JSON lines trace of the consensus steps to (default = stdout)",
+	}
+
+	consortiumCommand = cli.Command{
+		Name:        "consortium",
+		Usage:       "A set of commands on the consortium consensus data",
+		Category:    "BLOCKCHAIN COMMANDS",
+		Description: "",
+		Subcommands: []cli.Command{
+			{
+				Name:      "rebuild-snapshot",
+				Usage:     "Rebuild a lost consensus snapshot from the system contract state",
+				ArgsUsage: "",
+				Action:    utils.MigrateFlags(rebuildSnapshot),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					rebuildSnapshotBlockFlag,
+				},
+				Description: `
+ronin consortium rebuild-snapshot --block <number>
+reconstructs the consensus snapshot at the canonical epoch block and writes it
+to the snapshot store. The validator set is read from the validator and profile
+contracts at the state before the previous epoch block and checked against the
+validators in its header, the recent signers and the justified block are
+recovered from the headers.
+
+It is meant for the nodes whose snapshot entries are lost while the chain and
+its state are intact, the state before the previous epoch block must be
+available. The node must not be running.`,
+			},
+			{
+				Name:      "backfill-stats",
+				Usage:     "Index the finality and participation statistics of the historical epochs",
+				ArgsUsage: "",
+				Action:    utils.MigrateFlags(backfillStats),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					backfillStatsFromFlag,
+					backfillStatsToFlag,
+				},
+				Description: `
+ronin consortium backfill-stats --from <number> [--to <number>]
+walks the canonical headers of the epochs of the block range and indexes, for
+each epoch, the blocks including finality votes and, for each validator, the
+blocks it sealed, in turn or not, and the blocks including its finality vote.
+The epochs already indexed are replaced, the epochs before consortium v2 are
+skipped and the epoch of the head is indexed up to the head.
+
+The statistics are served by the consortium_getEpochStats and
+consortium_getValidatorStats RPCs. The node must not be running.`,
+			},
+			{
+				Name:      "replay",
+				Usage:     "Re-run the consensus verification and finalization of a block range with a trace",
+				ArgsUsage: "",
+				Action:    utils.MigrateFlags(replayBlocks),
+				Category:  "BLOCKCHAIN COMMANDS",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					replayFromFlag,
+					replayToFlag,
+					replayTraceOutputFlag,
+				},
+				Description: `
+ronin consortium replay --from <number> [--to <number>] [--trace.output <file>]
+re-runs the header verification, the body validation, the execution including
+the Finalize system transactions and the state validation of the canonical
+blocks of the range, starting from empty in-memory consensus caches. Each step
+is logged and written as a JSON line: the snapshot lookups and where they are
+found, the backoff of the block time, the seal and finality signature checks,
+the checkpoint validators and the system transactions with their errors.
+
+The replay stops at the first failing block, the trace is meant to be attached
+to the bug reports. The state before the first block must be available. The
+node must not be running.`,
+			},
+		},
+	}
+)
+
+func rebuildSnapshot(ctx *cli.Context) error {
+	if !ctx.IsSet(rebuildSnapshotBlockFlag.Name) {
+		utils.Fatalf("The epoch block number is required, see --%s", rebuildSnapshotBlockFlag.Name)
+	}
+	number := ctx.Uint64(rebuildSnapshotBlockFlag.Name)
+
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	// The engine reads the system contracts through the API backend of the node,
+	// the node is not started so it does not sync nor serve peers meanwhile
+	_, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	if eth == nil {
+		utils.Fatalf("The snapshots can not be rebuilt in light client mode")
+	}
+	engine, ok := eth.Engine().(*consortium.Consortium)
+	if !ok {
+		utils.Fatalf("The chain is not a consortium chain")
+	}
+	snap, err := engine.RebuildSnapshot(eth.BlockChain(), number)
+	if err != nil {
+		utils.Fatalf("Failed to rebuild the snapshot at %d: %v", number, err)
+	}
+	log.Info("Rebuilt the consensus snapshot", "number", snap.Number, "hash", snap.Hash, "recents", len(snap.Recents))
+	return nil
+}
+
+func replayBlocks(ctx *cli.Context) error {
+	if !ctx.IsSet(replayFromFlag.Name) {
+		utils.Fatalf("The first block number is required, see --%s", replayFromFlag.Name)
+	}
+	from, to := ctx.Uint64(replayFromFlag.Name), ctx.Uint64(replayFromFlag.Name)
+	if ctx.IsSet(replayToFlag.Name) {
+		to = ctx.Uint64(replayToFlag.Name)
+	}
+	out := os.Stdout
+	if path := ctx.String(replayTraceOutputFlag.Name); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			utils.Fatalf("Failed to create the trace file: %v", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	_, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	if eth == nil {
+		utils.Fatalf("The blocks can not be replayed in light client mode")
+	}
+	engine, ok := eth.Engine().(*consortium.Consortium)
+	if !ok {
+		utils.Fatalf("The chain is not a consortium chain")
+	}
+	start := time.Now()
+	replayed, err := engine.ReplayBlocks(eth.BlockChain(), from, to, out)
+	if err != nil {
+		utils.Fatalf("Failed to replay block %d: %v", from+uint64(replayed), err)
+	}
+	log.Info("Replayed the blocks", "from", from, "to", to, "blocks", replayed, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func backfillStats(ctx *cli.Context) error {
+	if !ctx.IsSet(backfillStatsFromFlag.Name) {
+		utils.Fatalf("The first block number is required, see --%s", backfillStatsFromFlag.Name)
+	}
+	stack, cfg := makeConfigNode(ctx)
+	defer stack.Close()
+
+	_, eth := utils.RegisterEthService(stack, &cfg.Eth)
+	if eth == nil {
+		utils.Fatalf("The stats can not be indexed in light client mode")
+	}
+	engine, ok := eth.Engine().(*consortium.Consortium)
+	if !ok {
+		utils.Fatalf("The chain is not a consortium chain")
+	}
+	from, to := ctx.Uint64(backfillStatsFromFlag.Name), eth.BlockChain().CurrentHeader().Number.Uint64()
+	if ctx.IsSet(backfillStatsToFlag.Name) && ctx.Uint64(backfillStatsToFlag.Name) < to {
+		to = ctx.Uint64(backfillStatsToFlag.Name)
+	}
+	start := time.Now()
+	indexed, err := engine.BackfillStats(eth.BlockChain(), from, to)
+	if err != nil {
+		utils.Fatalf("Failed to index the stats after %d epochs: %v", indexed, err)
+	}
+	log.Info("Indexed the consortium stats", "from", from, "to", to, "epochs", indexed, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
@@ -18,6 +18,8 @@ package main
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -32,10 +34,14 @@ import (
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
 	"github.com/ethereum/go-ethereum/console/prompt"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vote"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/mmapdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/trie"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -69,6 +75,9 @@ Remove blockchain and state databases`,
 			dbDumpFreezerIndex,
 			dbImportCmd,
 			dbExportCmd,
+			dbBackupCmd,
+			dbRestoreCmd,
+			dbMigrateSnapshotsCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -233,6 +242,60 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "Exports the specified chain data to an RLP encoded stream, optionally gzip-compressed.",
 	}
+	dbBackupCmd = cli.Command{
+		Action:    utils.MigrateFlags(backupConsensusData),
+		Name:      "backup",
+		Usage:     "Backs up the consensus safety data into an RLP dump. If the <dumpfile> has .gz suffix, gzip compression will be used.",
+		ArgsUsage: "<dumpfile>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.BlsProtectionPathFlag,
+		},
+		Description: `
+The backup command exports the consensus safety data of the node: the highest
+finality vote marker, the consortium snapshots of the database, of the mmap
+snapshot store and of the snapshot archive, and the vote protection history.
+Together with the BLS wallet, the backup lets a validator relocate its node
+without double voting.
+
+The node must be stopped while backing up so that the backup is consistent.`,
+	}
+	dbRestoreCmd = cli.Command{
+		Action:    utils.MigrateFlags(restoreConsensusData),
+		Name:      "restore",
+		Usage:     "Restores the consensus safety data from a backup created by 'db backup'.",
+		ArgsUsage: "<dumpfile>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.BlsProtectionPathFlag,
+		},
+		Description: `
+The restore command imports the consensus safety data from a backup. The highest
+finality vote marker is never lowered, the higher one between the local database
+and the backup is kept, and the vote protection history is merged into the local
+one. The snapshots of the mmap store are restored into the database, which the
+engine falls back to, and the archived snapshots missing locally are appended to
+the snapshot archive.`,
+	}
+	dbMigrateSnapshotsCmd = cli.Command{
+		Action: utils.MigrateFlags(migrateSnapshots),
+		Name:   "migrate-snapshots",
+		Usage:  "Upgrades the consortium snapshots to the current snapshot format",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+		},
+		Description: `
+The migrate-snapshots command rewrites the consortium snapshots of the chain
+database written by the previous releases in the current snapshot format. The
+snapshots are also migrated in memory when the engine loads them, the command
+avoids migrating them again on every load. The snapshots of the mmap snapshot
+store and the ones written by the consortium v1 engine are only migrated on load.
+
+The node must be stopped while migrating.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -639,6 +702,227 @@ func (iter *snapshotIterator) Release() {
 	iter.storage.Release()
 }
 
+var (
+	// backupArchivePrefix + item (uint64 big endian) -> archived snapshot, only
+	// used in the consensus backups
+	backupArchivePrefix = []byte("backup-snapshot-archive-")
+
+	// backupVoteProtectionKey -> vote protection interchange, only used in the
+	// consensus backups
+	backupVoteProtectionKey = []byte("backup-vote-protection")
+)
+
+// consensusIterator iterates over the consensus safety data: the highest finality
+// vote marker, the consortium snapshots of the database and of the snapshot store,
+// the archived snapshots and the vote protection history.
+type consensusIterator struct {
+	init      bool
+	db        ethdb.Database
+	snapshots ethdb.Iterator
+
+	store     *mmapdb.Database // Optional
+	storeKeys [][]byte
+
+	archive  *v2.SnapshotArchive // Optional
+	archived uint64              // Next archive item to export
+
+	protection []byte // Vote protection interchange, nil if exported or empty
+}
+
+// newConsensusIterator opens the snapshot store, the snapshot archive and the
+// vote protection history at protectionPath of the node if they exist
+func newConsensusIterator(stack *node.Node, db ethdb.Database, protectionPath string) (*consensusIterator, error) {
+	iter := &consensusIterator{db: db}
+	if dir := stack.ResolvePath(v2.SnapshotStoreDir); common.FileExist(dir) {
+		store, err := mmapdb.New(dir)
+		if err != nil {
+			return nil, err
+		}
+		if iter.storeKeys, err = store.Keys(); err != nil {
+			store.Close()
+			return nil, err
+		}
+		iter.store = store
+	}
+	if dir := stack.ResolvePath(v2.SnapshotArchiveDir); common.FileExist(dir) {
+		archive, err := v2.OpenSnapshotArchive(dir)
+		if err != nil {
+			iter.Release()
+			return nil, err
+		}
+		iter.archive = archive
+	}
+	protection, err := vote.NewVoteProtection(protectionPath)
+	if err != nil {
+		iter.Release()
+		return nil, err
+	}
+	if interchange := protection.Export(); len(interchange.Data) > 0 {
+		if iter.protection, err = json.Marshal(interchange); err != nil {
+			iter.Release()
+			return nil, err
+		}
+	}
+	iter.snapshots = db.NewIterator(rawdb.ConsortiumSnapshotPrefix, nil)
+	return iter, nil
+}
+
+func (iter *consensusIterator) Next() (byte, []byte, []byte, bool) {
+	if !iter.init {
+		iter.init = true
+		if blob, err := iter.db.Get(rawdb.HighestFinalityVoteKey); err == nil && len(blob) > 0 {
+			return utils.OpBatchAdd, rawdb.HighestFinalityVoteKey, blob, true
+		}
+	}
+	for iter.snapshots.Next() {
+		key := iter.snapshots.Key()
+		if isConsortiumSnapshotKey(key) {
+			return utils.OpBatchAdd, key, iter.snapshots.Value(), true
+		}
+	}
+	for len(iter.storeKeys) > 0 {
+		key := iter.storeKeys[0]
+		iter.storeKeys = iter.storeKeys[1:]
+		if !isConsortiumSnapshotKey(key) {
+			continue
+		}
+		if blob, err := iter.store.Get(key); err == nil {
+			return utils.OpBatchAdd, key, blob, true
+		}
+	}
+	if iter.archive != nil && iter.archived < iter.archive.Items() {
+		blob, err := iter.archive.Retrieve(iter.archived)
+		if err != nil {
+			log.Error("Failed to read archived snapshot", "item", iter.archived, "err", err)
+			return 0, nil, nil, false
+		}
+		key := append(common.CopyBytes(backupArchivePrefix), encodeItem(iter.archived)...)
+		iter.archived++
+		return utils.OpBatchAdd, key, blob, true
+	}
+	if iter.protection != nil {
+		blob := iter.protection
+		iter.protection = nil
+		return utils.OpBatchAdd, backupVoteProtectionKey, blob, true
+	}
+	return 0, nil, nil, false
+}
+
+func (iter *consensusIterator) Release() {
+	if iter.snapshots != nil {
+		iter.snapshots.Release()
+	}
+	if iter.store != nil {
+		iter.store.Close()
+	}
+	if iter.archive != nil {
+		iter.archive.Close()
+	}
+}
+
+// isConsortiumSnapshotKey returns whether the key is the key of a consortium snapshot
+func isConsortiumSnapshotKey(key []byte) bool {
+	return bytes.HasPrefix(key, rawdb.ConsortiumSnapshotPrefix) && len(key) == (len(rawdb.ConsortiumSnapshotPrefix)+common.HashLength)
+}
+
+func encodeItem(item uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, item)
+	return enc
+}
+
+// consensusRestoreDB imports a consensus backup into the database, the archived
+// snapshots missing locally are appended to the snapshot archive and the vote
+// protection history is kept aside to be merged into the local one.
+type consensusRestoreDB struct {
+	ethdb.Database
+	stack *node.Node
+
+	archive    *v2.SnapshotArchive // Opened on the first archived snapshot
+	protection []byte
+}
+
+func (db *consensusRestoreDB) NewBatch() ethdb.Batch {
+	return &consensusRestoreBatch{Batch: db.Database.NewBatch(), db: db}
+}
+
+// restoreArchived appends the archived snapshot of the item to the local archive
+// if it is the next one, the items already archived locally are skipped.
+func (db *consensusRestoreDB) restoreArchived(item uint64, blob []byte) error {
+	if db.archive == nil {
+		archive, err := v2.OpenSnapshotArchive(db.stack.ResolvePath(v2.SnapshotArchiveDir))
+		if err != nil {
+			return err
+		}
+		db.archive = archive
+	}
+	switch items := db.archive.Items(); {
+	case item < items:
+		return nil
+	case item > items:
+		return fmt.Errorf("missing archived snapshot %d before %d", items, item)
+	}
+	return db.archive.Append([][]byte{blob})
+}
+
+func (db *consensusRestoreDB) closeArchive() error {
+	if db.archive != nil {
+		return db.archive.Close()
+	}
+	return nil
+}
+
+type consensusRestoreBatch struct {
+	ethdb.Batch
+	db *consensusRestoreDB
+}
+
+func (b *consensusRestoreBatch) Put(key []byte, value []byte) error {
+	switch {
+	case bytes.Equal(key, backupVoteProtectionKey):
+		b.db.protection = common.CopyBytes(value)
+		return nil
+	case bytes.HasPrefix(key, backupArchivePrefix) && len(key) == len(backupArchivePrefix)+8:
+		return b.db.restoreArchived(binary.BigEndian.Uint64(key[len(backupArchivePrefix):]), value)
+	}
+	return b.Batch.Put(key, value)
+}
+
+// importConsensusBackup imports the consensus backup into the database, the
+// snapshot archive of the node and the vote protection history at protectionPath
+func importConsensusBackup(stack *node.Node, db ethdb.Database, file string, protectionPath string, stop chan struct{}) error {
+	restoreDB := &consensusRestoreDB{Database: db, stack: stack}
+	err := utils.ImportLDBData(restoreDB, file, 0, stop)
+	if closeErr := restoreDB.closeArchive(); err == nil {
+		err = closeErr
+	}
+	if err != nil || restoreDB.protection == nil {
+		return err
+	}
+	var interchange vote.ProtectionInterchange
+	if err := json.Unmarshal(restoreDB.protection, &interchange); err != nil {
+		return fmt.Errorf("invalid vote protection history: %w", err)
+	}
+	protection, err := vote.NewVoteProtection(protectionPath)
+	if err != nil {
+		return err
+	}
+	// The import never lowers the highest signed vote of a key
+	if err := protection.Import(&interchange); err != nil {
+		return err
+	}
+	log.Info("Restored vote protection history", "keys", len(interchange.Data))
+	return nil
+}
+
+// voteProtectionPath returns the path of the vote protection history of the node
+func voteProtectionPath(ctx *cli.Context, stack *node.Node) string {
+	if path := ctx.GlobalString(utils.BlsProtectionPathFlag.Name); path != "" {
+		return path
+	}
+	return stack.ResolvePath(vote.DefaultProtectionFile)
+}
+
 // chainExporters defines the export scheme for all exportable chain data.
 var chainExporters = map[string]func(db ethdb.Database) utils.ChainDataIterator{
 	"preimage": func(db ethdb.Database) utils.ChainDataIterator {
@@ -685,3 +969,86 @@ func exportChaindata(ctx *cli.Context) error {
 	db := utils.MakeChainDatabase(ctx, stack, true)
 	return utils.ExportChaindata(ctx.Args().Get(1), kind, exporter(db), stop)
 }
+
+func backupConsensusData(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during db backup, stopping at next batch")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	if highest := rawdb.ReadHighestFinalityVote(db); highest != nil {
+		log.Info("Backing up highest finality vote", "number", *highest)
+	}
+	iter, err := newConsensusIterator(stack, db, voteProtectionPath(ctx, stack))
+	if err != nil {
+		return err
+	}
+	return utils.ExportChaindata(ctx.Args().Get(0), "consensus", iter, stop)
+}
+
+func migrateSnapshots(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	start := time.Now()
+	migrated, err := v2.MigrateSnapshots(db)
+	if err != nil {
+		return err
+	}
+	log.Info("Migrated consortium snapshots", "count", migrated, "version", v2.SnapshotVersion, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+func restoreConsensusData(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during db restore, stopping at next batch")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	localHighest := rawdb.ReadHighestFinalityVote(db)
+	if err := importConsensusBackup(stack, db, ctx.Args().Get(0), voteProtectionPath(ctx, stack), stop); err != nil {
+		return err
+	}
+	// Never lower the highest finality vote, otherwise the validator may vote for
+	// a block at the height it has already voted
+	restoredHighest := rawdb.ReadHighestFinalityVote(db)
+	if localHighest != nil && (restoredHighest == nil || *restoredHighest < *localHighest) {
+		rawdb.WriteHighestFinalityVote(db, *localHighest)
+		restoredHighest = localHighest
+	}
+	if restoredHighest != nil {
+		log.Info("Restored highest finality vote", "number", *restoredHighest)
+	}
+	return nil
+}
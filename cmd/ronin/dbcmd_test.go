@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vote"
+	"github.com/ethereum/go-ethereum/ethdb/mmapdb"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+func TestConsensusBackupRestore(t *testing.T) {
+	var (
+		snapshotKey = append(common.CopyBytes(rawdb.ConsortiumSnapshotPrefix), common.Hash{0x1}.Bytes()...)
+		storeKey    = append(common.CopyBytes(rawdb.ConsortiumSnapshotPrefix), common.Hash{0x2}.Bytes()...)
+		publicKey   = types.BLSPublicKey{0x1}
+	)
+
+	// Fill the consensus data of the source node
+	source, err := node.New(&node.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create node, err %s", err)
+	}
+	defer source.Close()
+	db := rawdb.NewMemoryDatabase()
+	rawdb.WriteHighestFinalityVote(db, 100)
+	db.Put(snapshotKey, []byte("snapshot"))
+
+	store, err := mmapdb.New(source.ResolvePath(v2.SnapshotStoreDir))
+	if err != nil {
+		t.Fatalf("Failed to open snapshot store, err %s", err)
+	}
+	store.Put(storeKey, []byte("stored snapshot"))
+	store.Close()
+
+	archive, err := v2.OpenSnapshotArchive(source.ResolvePath(v2.SnapshotArchiveDir))
+	if err != nil {
+		t.Fatalf("Failed to open snapshot archive, err %s", err)
+	}
+	archive.Append([][]byte{[]byte("archived 0"), nil, []byte("archived 2")})
+	archive.Close()
+
+	protectionPath := source.ResolvePath(vote.DefaultProtectionFile)
+	protection, _ := vote.NewVoteProtection(protectionPath)
+	if err := protection.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 99, TargetNumber: 100}); err != nil {
+		t.Fatalf("Failed to record vote, err %s", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "consensus.rlp")
+	iter, err := newConsensusIterator(source, db, protectionPath)
+	if err != nil {
+		t.Fatalf("Failed to create consensus iterator, err %s", err)
+	}
+	if err := utils.ExportChaindata(file, "consensus", iter, make(chan struct{})); err != nil {
+		t.Fatalf("Failed to back up, err %s", err)
+	}
+
+	// Restore the backup on a node which has already archived the first snapshot
+	target, err := node.New(&node.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to create node, err %s", err)
+	}
+	defer target.Close()
+	archive, err = v2.OpenSnapshotArchive(target.ResolvePath(v2.SnapshotArchiveDir))
+	if err != nil {
+		t.Fatalf("Failed to open snapshot archive, err %s", err)
+	}
+	archive.Append([][]byte{[]byte("archived 0")})
+	archive.Close()
+
+	restored := rawdb.NewMemoryDatabase()
+	protectionPath = target.ResolvePath(vote.DefaultProtectionFile)
+	if err := importConsensusBackup(target, restored, file, protectionPath, make(chan struct{})); err != nil {
+		t.Fatalf("Failed to restore, err %s", err)
+	}
+
+	if highest := rawdb.ReadHighestFinalityVote(restored); highest == nil || *highest != 100 {
+		t.Fatalf("Highest finality vote mismatch, got %v", highest)
+	}
+	for key, want := range map[string]string{string(snapshotKey): "snapshot", string(storeKey): "stored snapshot"} {
+		if blob, err := restored.Get([]byte(key)); err != nil || string(blob) != want {
+			t.Fatalf("Snapshot mismatch, exp %q got %q err %v", want, blob, err)
+		}
+	}
+	if has, _ := restored.Has(backupVoteProtectionKey); has {
+		t.Fatalf("Vote protection history is written to the database")
+	}
+
+	archive, err = v2.OpenSnapshotArchive(target.ResolvePath(v2.SnapshotArchiveDir))
+	if err != nil {
+		t.Fatalf("Failed to open snapshot archive, err %s", err)
+	}
+	defer archive.Close()
+	if items := archive.Items(); items != 3 {
+		t.Fatalf("Archived snapshots mismatch, exp 3 got %d", items)
+	}
+	for item, want := range [][]byte{[]byte("archived 0"), nil, []byte("archived 2")} {
+		if blob, err := archive.Retrieve(uint64(item)); err != nil || !bytes.Equal(blob, want) {
+			t.Fatalf("Archived snapshot %d mismatch, exp %q got %q err %v", item, want, blob, err)
+		}
+	}
+
+	// The restored history refuses a conflicting vote
+	protection, err = vote.NewVoteProtection(protectionPath)
+	if err != nil {
+		t.Fatalf("Failed to load vote protection, err %s", err)
+	}
+	if err := protection.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 99, TargetNumber: 100, TargetHash: common.Hash{0x1}}); err == nil {
+		t.Fatalf("Expect the restored history to refuse the double vote")
+	}
+}
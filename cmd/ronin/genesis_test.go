@@ -92,3 +92,21 @@ func TestCustomGenesis(t *testing.T) {
 		geth.ExpectExit()
 	}
 }
+
+func TestInitPresetGenesisMismatch(t *testing.T) {
+	datadir := tmpdir(t)
+	defer os.RemoveAll(datadir)
+
+	json := filepath.Join(datadir, "genesis.json")
+	if err := ioutil.WriteFile(json, []byte(customGenesisTests[0].genesis), 0600); err != nil {
+		t.Fatalf("failed to write genesis file: %v", err)
+	}
+	geth := runGeth(t, "--datadir", datadir, "init", "--chain.preset", "ronin-mainnet", json)
+	geth.ExpectRegexp("Fatal: Genesis [0-9a-f]+ mismatches the ronin-mainnet genesis [0-9a-f]+\n")
+	geth.ExpectExit()
+
+	// Nothing is written to the databases on mismatch
+	if _, err := os.Stat(filepath.Join(datadir, "ronin", "chaindata")); !os.IsNotExist(err) {
+		t.Fatalf("chain database written on genesis mismatch: %v", err)
+	}
+}
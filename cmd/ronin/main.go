@@ -18,6 +18,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -41,7 +42,13 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/pyroscope-io/client/pyroscope"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	// Force-load the tracer engines to trigger registration
 	_ "github.com/ethereum/go-ethereum/eth/tracers/js"
@@ -96,6 +103,7 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolSponsoredSlotsFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
@@ -133,11 +141,13 @@ var (
 		utils.MinerGasReserveFlag,
 		utils.MinerGasPriceFlag,
 		utils.MinerEtherbaseFlag,
+		utils.MinerExtraSignersFlag,
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
 		utils.MinerNoVerifyFlag,
 		utils.MinerBlockProduceLeftoverFlag,
 		utils.MinerBlockSizeReserveFlag,
+		utils.MinerOptimisticSealFlag,
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
@@ -149,13 +159,17 @@ var (
 		utils.DeveloperFlag,
 		utils.DeveloperPeriodFlag,
 		utils.DeveloperGasLimitFlag,
+		utils.DeveloperConsortiumFlag,
+		utils.DeveloperAccountsFlag,
 		utils.RopstenFlag,
 		utils.SepoliaFlag,
 		utils.RinkebyFlag,
 		utils.GoerliFlag,
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
+		utils.ChainPresetFlag,
 		utils.EthStatsURLFlag,
+		utils.FirehoseEndpointFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
@@ -167,13 +181,66 @@ var (
 		utils.CatalystFlag,
 		utils.MonitorDoubleSign,
 		utils.MonitorFinalityVoteFlag,
+		utils.MonitorFinalityStallWebhookFlag,
+		utils.MonitorFinalityStallThresholdFlag,
+		utils.MonitorChainFreezeEpochsFlag,
+		utils.MonitorChainFreezeWebhookFlag,
+		utils.MonitorFinalityExportFlag,
+		utils.MonitorAuditLogFlag,
+		utils.MonitorInactivityFlag,
+		utils.MonitorInactivityThresholdFlag,
+		utils.MonitorInactivityWebhookFlag,
+		utils.MonitorInactivityCommandFlag,
+		utils.MonitorScoresFlag,
+		utils.MonitorScoresWindowFlag,
+		utils.MonitorScoresInTurnWeightFlag,
+		utils.MonitorScoresFinalityWeightFlag,
+		utils.MonitorScoresMissedEpochWeightFlag,
+		utils.MonitorVoteCanaryWebhookFlag,
+		utils.MonitorNotifyWebhookFlag,
+		utils.MonitorNotifyEventsFlag,
+		utils.MonitorNotifyHardforkDistanceFlag,
+		utils.SlashDoubleSignReportFlag,
+		utils.SlashDoubleSignGasCapFlag,
+		utils.SlashDoubleSignDryRunFlag,
+		utils.SigningLeaseFileFlag,
+		utils.SigningLeaseHolderFlag,
+		utils.SigningLeaseDurationFlag,
+		utils.SigningLeaseTakeoverFlag,
+		utils.MinSigningPeersFlag,
+		utils.MinSigningRoninPeersFlag,
+		utils.WatchdogIntervalFlag,
+		utils.WatchdogDiskLatencyFlag,
+		utils.WatchdogMinPeersFlag,
+		utils.SealBacklogThresholdFlag,
+		utils.DisableSealingFlag,
+		utils.BadBlockBundleDirFlag,
+		utils.AllowedFutureBlockTimeFlag,
+		utils.MmapSnapshotStoreFlag,
+		utils.SnapshotArchiveFlag,
+		utils.SnapshotCacheFlag,
+		utils.SealerCacheFlag,
+		utils.CheckpointIntervalFlag,
+		utils.ValidatorSetOverrideFlag,
+		utils.ShadowSealerFlag,
+		utils.ConsortiumVerifyFlag,
 		utils.StoreInternalTransactions,
 		utils.MaxCurVoteAmountPerBlock,
 		utils.EnableFastFinality,
 		utils.EnableFastFinalitySign,
 		utils.BlsPasswordPath,
 		utils.BlsWalletPath,
+		utils.BlsRemoteSignerFlag,
+		utils.BlsProtectionPathFlag,
+		utils.VoteAssemblyWindow,
+		utils.DisableVotingFlag,
+		utils.AllowJustifiedRewindFlag,
+		utils.FeaturesFlag,
 		utils.DisableRoninProtocol,
+		utils.BlockLatencyReportFlag,
+		utils.ValidatorPeeringFlag,
+		utils.ValidatorPeeringAllowFlag,
+		utils.ValidatorPeeringDenyFlag,
 		utils.AdditionalChainEventFlag,
 	}
 
@@ -196,6 +263,7 @@ var (
 		utils.WSPathPrefixFlag,
 		utils.WSReadBufferFlag,
 		utils.WSWriteBufferFlag,
+		utils.RPCJWTSecretFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 		utils.InsecureUnlockAllowedFlag,
@@ -234,6 +302,13 @@ var (
 		utils.PyroscopeMutexProfileFraction,
 	}
 
+	tracingFlags = []cli.Flag{
+		utils.TracingEnableFlag,
+		utils.TracingEndpointFlag,
+		utils.TracingInsecureFlag,
+		utils.TracingServiceNameFlag,
+	}
+
 	mockFlags = []cli.Flag{
 		utils.MockValidatorsFlag,
 		utils.MockBlsPublicKeysFlag,
@@ -255,6 +330,17 @@ func init() {
 		removedbCommand,
 		dumpCommand,
 		dumpGenesisCommand,
+		// See badblockcmd.go:
+		replayBadBlockCommand,
+		// See auditcmd.go:
+		exportSystemStateCommand,
+		verifySystemStateCommand,
+		exportCheckpointsCommand,
+		importCheckpointsCommand,
+		// See shadowforkcmd.go:
+		shadowForkCommand,
+		// See consortiumcmd.go:
+		consortiumCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -286,6 +372,7 @@ func init() {
 	app.Flags = append(app.Flags, debug.Flags...)
 	app.Flags = append(app.Flags, metricsFlags...)
 	app.Flags = append(app.Flags, pyroscopeFlags...)
+	app.Flags = append(app.Flags, tracingFlags...)
 	app.Flags = append(app.Flags, mockFlags...)
 
 	app.Before = func(ctx *cli.Context) error {
@@ -322,6 +409,12 @@ func prepare(ctx *cli.Context) {
 	case ctx.GlobalIsSet(utils.DeveloperFlag.Name):
 		log.Info("Starting Geth in ephemeral dev mode...")
 
+	case ctx.GlobalIsSet(utils.DeveloperConsortiumFlag.Name):
+		log.Info("Starting Ronin in ephemeral consortium dev mode...")
+
+	case ctx.GlobalIsSet(utils.ChainPresetFlag.Name):
+		log.Info("Starting Ronin with a built-in chain configuration...", "preset", ctx.GlobalString(utils.ChainPresetFlag.Name))
+
 	case !ctx.GlobalIsSet(utils.NetworkIdFlag.Name):
 		log.Info("Starting Geth on Ethereum mainnet...")
 	}
@@ -332,7 +425,7 @@ func prepare(ctx *cli.Context) {
 	// If we're a full node on mainnet without --cache specified, bump default cache allowance
 	if ctx.GlobalString(utils.SyncModeFlag.Name) != "light" && !ctx.GlobalIsSet(utils.CacheFlag.Name) && !ctx.GlobalIsSet(utils.NetworkIdFlag.Name) {
 		// Make sure we're not on any supported preconfigured testnet either
-		if !ctx.GlobalIsSet(utils.RopstenFlag.Name) && !ctx.GlobalIsSet(utils.RinkebyFlag.Name) && !ctx.GlobalIsSet(utils.GoerliFlag.Name) && !ctx.GlobalIsSet(utils.DeveloperFlag.Name) {
+		if !ctx.GlobalIsSet(utils.RopstenFlag.Name) && !ctx.GlobalIsSet(utils.RinkebyFlag.Name) && !ctx.GlobalIsSet(utils.GoerliFlag.Name) && !ctx.GlobalIsSet(utils.DeveloperFlag.Name) && !ctx.GlobalIsSet(utils.DeveloperConsortiumFlag.Name) {
 			// Nope, we're really on mainnet. Bump that cache up!
 			log.Info("Bumping default cache on mainnet", "provided", ctx.GlobalInt(utils.CacheFlag.Name), "updated", 4096)
 			ctx.GlobalSet(utils.CacheFlag.Name, strconv.Itoa(4096))
@@ -365,6 +458,13 @@ func geth(ctx *cli.Context) error {
 		}
 		defer profiler.Stop()
 	}
+	if ctx.GlobalBool(utils.TracingEnableFlag.Name) {
+		provider, err := setupTracing(ctx)
+		if err != nil {
+			return err
+		}
+		defer provider.Shutdown(context.Background())
+	}
 	prepare(ctx)
 	stack, backend := makeFullNode(ctx)
 	defer stack.Close()
@@ -420,6 +520,31 @@ func setupPyroScopeProfiler(ctx *cli.Context) (*pyroscope.Profiler, error) {
 	})
 }
 
+// setupTracing registers the tracer provider exporting the spans to the OTLP
+// endpoint in batches
+func setupTracing(ctx *cli.Context) (*sdktrace.TracerProvider, error) {
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(ctx.GlobalString(utils.TracingEndpointFlag.Name)),
+	}
+	if ctx.GlobalBool(utils.TracingInsecureFlag.Name) {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", ctx.GlobalString(utils.TracingServiceNameFlag.Name)),
+			attribute.String("service.version", params.VersionWithMeta),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	log.Info("OpenTelemetry tracing enabled", "endpoint", ctx.GlobalString(utils.TracingEndpointFlag.Name))
+	return provider, nil
+}
+
 // startNode boots up the system node and all registered protocols, after which
 // it unlocks any requested accounts, and starts the RPC/IPC interfaces and the
 // miner.
@@ -501,7 +626,7 @@ func startNode(ctx *cli.Context, stack *node.Node, backend ethapi.Backend) {
 	}
 
 	// Start auxiliary services if enabled
-	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
+	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) || ctx.GlobalBool(utils.DeveloperConsortiumFlag.Name) {
 		// Mining only makes sense if a full Ethereum node is running
 		if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support mining")
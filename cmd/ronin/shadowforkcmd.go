@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/bls"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	shadowForkAtFlag = cli.Uint64Flag{
+		Name:  "at",
+		Usage: "Checkpoint block number to fork the chain at",
+	}
+	shadowForkChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain id of the shadow fork, it must differ from the chain id of the original chain",
+	}
+	shadowForkValidatorsFlag = cli.IntFlag{
+		Name:  "validators",
+		Usage: "Number of validators of the shadow fork",
+		Value: 3,
+	}
+	shadowForkOverrideFlag = cli.StringFlag{
+		Name:  "override",
+		Usage: "Comma separated fork schedule overrides, e.g. trippBlock=100,mikoBlock=50",
+	}
+	shadowForkKeysFlag = cli.StringFlag{
+		Name:  "keys",
+		Usage: "Directory to write the keys of the shadow fork validators to",
+		Value: "shadowfork",
+	}
+
+	shadowForkCommand = cli.Command{
+		Action:    utils.MigrateFlags(shadowFork),
+		Name:      "shadowfork",
+		Usage:     "Turn the local chain into an isolated shadow fork for upgrade rehearsal",
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			shadowForkAtFlag,
+			shadowForkChainIDFlag,
+			shadowForkValidatorsFlag,
+			shadowForkOverrideFlag,
+			shadowForkKeysFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+    ronin shadowfork --at <block> --chainid <id> [--validators <n>] [--override <forks>]
+
+The shadowfork command rewinds the local chain to the checkpoint block and turns
+it into a shadow fork that mirrors the state of the original chain:
+
+- the chain id and the fork schedule of the stored chain config are rewritten,
+  the fork overrides are the JSON names of the fork blocks in the chain config.
+- new validator keys (ECDSA keystore and BLS wallet) are generated in the keys
+  directory, one sub directory per validator.
+- the validator set of the checkpoint is replaced with the new validators.
+
+The data directory is modified in place, so run the command on a copy of it. The
+validator nodes of the shadow fork start from copies of the rewritten data
+directory with the printed flags, which run the consortium engine with the new
+validators in mock mode and without peer discovery. The separate snapshot store
+(--consortium.mmapsnapshots) must stay disabled on the shadow fork nodes.`,
+	}
+)
+
+// shadowForkValidator is a generated validator of the shadow fork
+type shadowForkValidator struct {
+	Address      common.Address `json:"address"`
+	BlsPublicKey string         `json:"blsPublicKey"`
+	Dir          string         `json:"dir"`
+}
+
+// shadowForkSummary is written next to the validator keys to describe the fork
+type shadowForkSummary struct {
+	Number     uint64                `json:"number"`
+	Hash       common.Hash           `json:"hash"`
+	ChainID    uint64                `json:"chainId"`
+	Validators []shadowForkValidator `json:"validators"`
+}
+
+func shadowFork(ctx *cli.Context) error {
+	if !ctx.IsSet(shadowForkAtFlag.Name) || !ctx.IsSet(shadowForkChainIDFlag.Name) {
+		utils.Fatalf("Both --%s and --%s are required", shadowForkAtFlag.Name, shadowForkChainIDFlag.Name)
+	}
+	var (
+		at      = ctx.Uint64(shadowForkAtFlag.Name)
+		chainID = ctx.Uint64(shadowForkChainIDFlag.Name)
+		count   = ctx.Int(shadowForkValidatorsFlag.Name)
+		keysDir = ctx.String(shadowForkKeysFlag.Name)
+	)
+	if count <= 0 {
+		utils.Fatalf("The shadow fork needs at least one validator")
+	}
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+
+	config := chain.Config()
+	number := new(big.Int).SetUint64(at)
+	if config.Consortium == nil || !config.IsConsortiumV2(number) || !config.IsShillin(number) {
+		utils.Fatalf("The shadow fork must start after the Shillin hardfork of a consortium chain")
+	}
+	if at%config.Consortium.EpochV2 != 0 {
+		utils.Fatalf("Block %d is not a checkpoint block, the epoch is %d", at, config.Consortium.EpochV2)
+	}
+	if config.ChainID != nil && config.ChainID.Uint64() == chainID {
+		utils.Fatalf("The shadow fork must have another chain id than the original chain")
+	}
+	if head := chain.CurrentBlock().NumberU64(); head < at {
+		utils.Fatalf("Block %d is ahead of the local chain head %d", at, head)
+	}
+	header := chain.GetHeaderByNumber(at)
+	if header == nil {
+		utils.Fatalf("Block %d is missing from the local chain", at)
+	}
+
+	newConfig, err := applyForkOverrides(config, ctx.String(shadowForkOverrideFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid fork overrides: %v", err)
+	}
+	newConfig.ChainID = new(big.Int).SetUint64(chainID)
+	if compatErr := config.CheckCompatible(newConfig, at); compatErr != nil {
+		utils.Fatalf("Invalid fork overrides: %v", compatErr)
+	}
+
+	// Generate the keys first so that the chain is left untouched on failure
+	var (
+		validators []finality.ValidatorWithBlsPub
+		summary    = shadowForkSummary{Number: at, Hash: header.Hash(), ChainID: chainID}
+	)
+	for i := 0; i < count; i++ {
+		dir, err := filepath.Abs(filepath.Join(keysDir, fmt.Sprintf("validator%d", i)))
+		if err != nil {
+			utils.Fatalf("Failed to resolve keys directory: %v", err)
+		}
+		validator, err := generateShadowForkValidator(dir)
+		if err != nil {
+			utils.Fatalf("Failed to generate validator keys: %v", err)
+		}
+		validators = append(validators, validator)
+		summary.Validators = append(summary.Validators, shadowForkValidator{
+			Address:      validator.Address,
+			BlsPublicKey: hex.EncodeToString(validator.BlsPublicKey.Marshal()),
+			Dir:          dir,
+		})
+	}
+
+	if err := chain.SetHead(at); err != nil {
+		utils.Fatalf("Failed to rewind the chain: %v", err)
+	}
+	chain.Stop()
+
+	if err := v2.WriteShadowForkSnapshot(db, at, header.Hash(), validators); err != nil {
+		utils.Fatalf("Failed to write the shadow fork snapshot: %v", err)
+	}
+	rawdb.WriteChainConfig(db, rawdb.ReadCanonicalHash(db, 0), newConfig)
+	log.Info("Rewrote the chain into a shadow fork", "number", at, "hash", header.Hash(), "chainid", chainID, "validators", count)
+
+	blob, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(keysDir, "shadowfork.json"), blob, 0600); err != nil {
+		utils.Fatalf("Failed to write the shadow fork summary: %v", err)
+	}
+	printShadowForkFlags(summary)
+	return nil
+}
+
+// applyForkOverrides returns a copy of the config with the fork blocks set by
+// the overrides, the overrides are comma separated name=block pairs where name
+// is the JSON field of the fork block in the chain config.
+func applyForkOverrides(config *params.ChainConfig, overrides string) (*params.ChainConfig, error) {
+	blob, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, err
+	}
+	for _, override := range strings.Split(overrides, ",") {
+		if override = strings.TrimSpace(override); override == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(override, "=")
+		if !ok || !strings.HasSuffix(name, "Block") {
+			return nil, fmt.Errorf("invalid override %q", override)
+		}
+		block, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block of override %q", override)
+		}
+		fields[name] = json.RawMessage(strconv.FormatUint(block, 10))
+	}
+	if blob, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+
+	// Reject the names which are not fork blocks of the config
+	decoder := json.NewDecoder(bytes.NewReader(blob))
+	decoder.DisallowUnknownFields()
+	newConfig := new(params.ChainConfig)
+	if err := decoder.Decode(newConfig); err != nil {
+		return nil, err
+	}
+	if err := newConfig.CheckConfigForkOrder(); err != nil {
+		return nil, err
+	}
+	return newConfig, nil
+}
+
+// generateShadowForkValidator creates the ECDSA keystore and the BLS wallet of a
+// validator in dir, both protected by a random password stored in dir.
+func generateShadowForkValidator(dir string) (finality.ValidatorWithBlsPub, error) {
+	var validator finality.ValidatorWithBlsPub
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return validator, err
+	}
+	var entropy [16]byte
+	if _, err := rand.Read(entropy[:]); err != nil {
+		return validator, err
+	}
+	password := hex.EncodeToString(entropy[:])
+	passwordPath := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordPath, []byte(password), 0600); err != nil {
+		return validator, err
+	}
+
+	ks := keystore.NewKeyStore(filepath.Join(dir, "keystore"), keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.NewAccount(password)
+	if err != nil {
+		return validator, err
+	}
+
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		return validator, err
+	}
+	blsDir := filepath.Join(dir, "bls_keystore")
+	if err := os.MkdirAll(blsDir, 0700); err != nil {
+		return validator, err
+	}
+	wallet, err := bls.New(blsDir, passwordPath)
+	if err != nil {
+		return validator, err
+	}
+	km, err := bls.NewKeyManager(context.Background(), wallet)
+	if err != nil {
+		return validator, err
+	}
+	err = km.ImportKeypairs(
+		context.Background(),
+		[][]byte{secretKey.Marshal()},
+		[][]byte{secretKey.PublicKey().Marshal()},
+	)
+	if err != nil {
+		return validator, err
+	}
+
+	validator.Address = account.Address
+	validator.BlsPublicKey = secretKey.PublicKey()
+	return validator, nil
+}
+
+// printShadowForkFlags prints the flags to start the validator nodes of the
+// shadow fork
+func printShadowForkFlags(summary shadowForkSummary) {
+	var (
+		addresses  []string
+		publicKeys []string
+	)
+	for _, validator := range summary.Validators {
+		addresses = append(addresses, validator.Address.Hex())
+		publicKeys = append(publicKeys, validator.BlsPublicKey)
+	}
+	base := fmt.Sprintf("--networkid %d --nodiscover --%s %s --%s %s",
+		summary.ChainID,
+		utils.MockValidatorsFlag.Name, strings.Join(addresses, ","),
+		utils.MockBlsPublicKeysFlag.Name, strings.Join(publicKeys, ","),
+	)
+
+	fmt.Printf("Shadow fork at block %d (%x) with chain id %d\n\n", summary.Number, summary.Hash, summary.ChainID)
+	fmt.Println("Start each validator from its own copy of the data directory with:")
+	for _, validator := range summary.Validators {
+		fmt.Printf("\n  %s --mine --%s %s --%s %s --%s %s --%s %s --%s --%s --%s %s --%s %s\n",
+			base,
+			utils.MinerEtherbaseFlag.Name, validator.Address.Hex(),
+			utils.KeyStoreDirFlag.Name, filepath.Join(validator.Dir, "keystore"),
+			utils.UnlockedAccountFlag.Name, validator.Address.Hex(),
+			utils.PasswordFileFlag.Name, filepath.Join(validator.Dir, "password"),
+			utils.EnableFastFinality.Name,
+			utils.EnableFastFinalitySign.Name,
+			utils.BlsWalletPath.Name, filepath.Join(validator.Dir, "bls_keystore"),
+			utils.BlsPasswordPath.Name, filepath.Join(validator.Dir, "password"),
+		)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestApplyForkOverrides(t *testing.T) {
+	config := *params.TestChainConfig
+	config.TrippBlock = nil
+
+	newConfig, err := applyForkOverrides(&config, "trippBlock=100, shillinBlock=50")
+	if err != nil {
+		t.Fatalf("Failed to apply overrides, err %s", err)
+	}
+	if newConfig.TrippBlock == nil || newConfig.TrippBlock.Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("Tripp block mismatch, expect %d got %v", 100, newConfig.TrippBlock)
+	}
+	if newConfig.ShillinBlock == nil || newConfig.ShillinBlock.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("Shillin block mismatch, expect %d got %v", 50, newConfig.ShillinBlock)
+	}
+	if config.TrippBlock != nil {
+		t.Fatalf("The original config is modified")
+	}
+
+	for _, overrides := range []string{"tripp=100", "trippBlock", "trippBlock=abc", "unknownBlock=1"} {
+		if _, err := applyForkOverrides(&config, overrides); err == nil {
+			t.Fatalf("Expect error on overrides %q", overrides)
+		}
+	}
+}
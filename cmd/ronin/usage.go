@@ -41,6 +41,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.USBFlag,
 			utils.SmartCardDaemonPathFlag,
 			utils.NetworkIdFlag,
+			utils.ChainPresetFlag,
 			utils.MainnetFlag,
 			utils.GoerliFlag,
 			utils.RinkebyFlag,
@@ -51,14 +52,56 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.GCModeFlag,
 			utils.TxLookupLimitFlag,
 			utils.EthStatsURLFlag,
+			utils.FirehoseEndpointFlag,
 			utils.IdentityFlag,
 			utils.LightKDFFlag,
 			utils.WhitelistFlag,
 			utils.ForceOverrideChainConfigFlag,
 			utils.MonitorDoubleSign,
 			utils.MonitorFinalityVoteFlag,
+			utils.MonitorFinalityStallWebhookFlag,
+			utils.MonitorFinalityStallThresholdFlag,
+			utils.MonitorChainFreezeEpochsFlag,
+			utils.MonitorChainFreezeWebhookFlag,
+			utils.MonitorFinalityExportFlag,
+			utils.MonitorAuditLogFlag,
+			utils.MonitorInactivityFlag,
+			utils.MonitorInactivityThresholdFlag,
+			utils.MonitorInactivityWebhookFlag,
+			utils.MonitorInactivityCommandFlag,
+			utils.MonitorScoresFlag,
+			utils.MonitorScoresWindowFlag,
+			utils.MonitorScoresInTurnWeightFlag,
+			utils.MonitorScoresFinalityWeightFlag,
+			utils.MonitorScoresMissedEpochWeightFlag,
+			utils.MonitorVoteCanaryWebhookFlag,
+			utils.MonitorNotifyWebhookFlag,
+			utils.MonitorNotifyEventsFlag,
+			utils.MonitorNotifyHardforkDistanceFlag,
+			utils.SlashDoubleSignReportFlag,
+			utils.SlashDoubleSignGasCapFlag,
+			utils.SlashDoubleSignDryRunFlag,
+			utils.SigningLeaseFileFlag,
+			utils.SigningLeaseHolderFlag,
+			utils.SigningLeaseDurationFlag,
+			utils.SigningLeaseTakeoverFlag,
+			utils.BadBlockBundleDirFlag,
+			utils.AllowedFutureBlockTimeFlag,
+			utils.MmapSnapshotStoreFlag,
+			utils.SnapshotArchiveFlag,
+			utils.SnapshotCacheFlag,
+			utils.SealerCacheFlag,
+			utils.CheckpointIntervalFlag,
+			utils.ValidatorSetOverrideFlag,
+			utils.ShadowSealerFlag,
+			utils.ConsortiumVerifyFlag,
 			utils.StoreInternalTransactions,
+			utils.FeaturesFlag,
 			utils.DisableRoninProtocol,
+			utils.BlockLatencyReportFlag,
+			utils.ValidatorPeeringFlag,
+			utils.ValidatorPeeringAllowFlag,
+			utils.ValidatorPeeringDenyFlag,
 			utils.AdditionalChainEventFlag,
 		},
 	},
@@ -82,6 +125,8 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.DeveloperFlag,
 			utils.DeveloperPeriodFlag,
 			utils.DeveloperGasLimitFlag,
+			utils.DeveloperConsortiumFlag,
+			utils.DeveloperAccountsFlag,
 		},
 	},
 	{
@@ -111,6 +156,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolSponsoredSlotsFlag,
 		},
 	},
 	{
@@ -157,6 +203,7 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.WSAllowedOriginsFlag,
 			utils.WSReadBufferFlag,
 			utils.WSWriteBufferFlag,
+			utils.RPCJWTSecretFlag,
 			utils.GraphQLEnabledFlag,
 			utils.GraphQLCORSDomainFlag,
 			utils.GraphQLVirtualHostsFlag,
@@ -196,11 +243,20 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.MinerGasLimitFlag,
 			utils.MinerGasReserveFlag,
 			utils.MinerEtherbaseFlag,
+			utils.MinerExtraSignersFlag,
 			utils.MinerExtraDataFlag,
 			utils.MinerRecommitIntervalFlag,
 			utils.MinerNoVerifyFlag,
 			utils.MinerBlockProduceLeftoverFlag,
 			utils.MinerBlockSizeReserveFlag,
+			utils.MinerOptimisticSealFlag,
+			utils.MinSigningPeersFlag,
+			utils.MinSigningRoninPeersFlag,
+			utils.WatchdogIntervalFlag,
+			utils.WatchdogDiskLatencyFlag,
+			utils.WatchdogMinPeersFlag,
+			utils.SealBacklogThresholdFlag,
+			utils.DisableSealingFlag,
 		},
 	},
 	{
@@ -245,6 +301,10 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.PyroscopeMutexProfileFraction,
 		},
 	},
+	{
+		Name:  "TRACING",
+		Flags: tracingFlags,
+	},
 	{
 		Name: "MISC",
 		Flags: []cli.Flag{
@@ -262,6 +322,11 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			utils.EnableFastFinalitySign,
 			utils.BlsPasswordPath,
 			utils.BlsWalletPath,
+			utils.BlsRemoteSignerFlag,
+			utils.BlsProtectionPathFlag,
+			utils.VoteAssemblyWindow,
+			utils.DisableVotingFlag,
+			utils.AllowJustifiedRewindFlag,
 		},
 	},
 	{
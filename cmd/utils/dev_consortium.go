@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/bls"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// setDeveloperConsortium configures the single validator consortium v2 developer
+// chain. The first account of the keystore is the validator, the following ones
+// are the pre-funded developer accounts, the missing accounts are created. The
+// BLS key of the validator is kept in the keystore directory, next to its
+// secp256k1 key, so a developer chain in a data directory can be restarted.
+func setDeveloperConsortium(ctx *cli.Context, stack *node.Node, ks *keystore.KeyStore, cfg *ethconfig.Config) {
+	var passphrase string
+	if list := MakePasswordList(ctx); len(list) > 0 {
+		// Just take the first value, all the developer accounts share it
+		passphrase = list[0]
+	}
+	// setEtherbase has been called above, configuring the miner address from command line flags.
+	var (
+		developers = ks.Accounts()
+		validator  accounts.Account
+	)
+	if cfg.Miner.Etherbase != (common.Address{}) {
+		validator = accounts.Account{Address: cfg.Miner.Etherbase}
+	} else if len(developers) > 0 {
+		validator = developers[0]
+	} else {
+		account, err := ks.NewAccount(passphrase)
+		if err != nil {
+			Fatalf("Failed to create developer validator account: %v", err)
+		}
+		validator = account
+	}
+	var faucets []common.Address
+	for _, developer := range developers {
+		if len(faucets) == ctx.GlobalInt(DeveloperAccountsFlag.Name) {
+			break
+		}
+		if developer.Address != validator.Address {
+			faucets = append(faucets, developer.Address)
+		}
+	}
+	for len(faucets) < ctx.GlobalInt(DeveloperAccountsFlag.Name) {
+		account, err := ks.NewAccount(passphrase)
+		if err != nil {
+			Fatalf("Failed to create developer account: %v", err)
+		}
+		faucets = append(faucets, account.Address)
+	}
+	for _, address := range append([]common.Address{validator.Address}, faucets...) {
+		if err := ks.Unlock(accounts.Account{Address: address}, passphrase); err != nil {
+			Fatalf("Failed to unlock developer account: %v", err)
+		}
+	}
+	cfg.Miner.Etherbase = validator.Address
+
+	// Generate the BLS key of the validator and vote with it, unless a BLS wallet is given
+	nodeConfig := stack.Config()
+	if !ctx.GlobalIsSet(BlsWalletPath.Name) {
+		nodeConfig.BlsWalletPath = filepath.Join(stack.KeyStoreDir(), "bls", "wallet")
+	}
+	if !ctx.GlobalIsSet(BlsPasswordPath.Name) {
+		nodeConfig.BlsPasswordPath = filepath.Join(stack.KeyStoreDir(), "bls", "password")
+	}
+	publicKey, err := developerBlsPublicKey(nodeConfig.BlsWalletPath, nodeConfig.BlsPasswordPath)
+	if err != nil {
+		Fatalf("Failed to load developer validator BLS key: %v", err)
+	}
+	// The validator set is not read from the system contracts, the developer
+	// chain has no contract deployed at the genesis
+	if err := consortiumCommon.SetMockValidators(validator.Address.Hex(), hex.EncodeToString(publicKey.Marshal())); err != nil {
+		Fatalf("Failed to set developer validator: %v", err)
+	}
+	log.Info("Using developer validator", "address", validator.Address, "blsPublicKey", hex.EncodeToString(publicKey.Marshal()))
+	for _, faucet := range faucets {
+		log.Info("Using developer account", "address", faucet)
+	}
+
+	// Create a new developer genesis block or reuse existing one
+	cfg.Genesis = core.DeveloperConsortiumGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), ctx.GlobalUint64(DeveloperGasLimitFlag.Name), validator.Address, faucets)
+	if ctx.GlobalIsSet(DataDirFlag.Name) {
+		// Check if we have an already initialized chain and fall back to
+		// that if so. Otherwise we need to generate a new genesis spec.
+		chaindb := MakeChainDatabase(ctx, stack, false)
+		if rawdb.ReadCanonicalHash(chaindb, 0) != (common.Hash{}) {
+			cfg.Genesis = nil // fallback to db content
+		}
+		chaindb.Close()
+	}
+}
+
+// developerBlsPublicKey returns the public key of the first BLS key in the
+// wallet. The wallet, its password and the key are generated if missing.
+func developerBlsPublicKey(walletPath, passwordPath string) (blsCommon.PublicKey, error) {
+	if err := os.MkdirAll(walletPath, 0700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(passwordPath); os.IsNotExist(err) {
+		var entropy [16]byte
+		if _, err := rand.Read(entropy[:]); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(passwordPath, []byte(hex.EncodeToString(entropy[:])), 0600); err != nil {
+			return nil, err
+		}
+	}
+	wallet, err := bls.New(walletPath, passwordPath)
+	if err != nil {
+		return nil, err
+	}
+	km, err := bls.NewKeyManager(context.Background(), wallet)
+	if err != nil {
+		return nil, err
+	}
+	publicKeys, err := km.FetchValidatingPublicKeys(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKeys) > 0 {
+		return blst.PublicKeyFromBytes(publicKeys[0][:])
+	}
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		return nil, err
+	}
+	err = km.ImportKeypairs(
+		context.Background(),
+		[][]byte{secretKey.Marshal()},
+		[][]byte{secretKey.PublicKey().Marshal()},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return secretKey.PublicKey(), nil
+}
@@ -51,8 +51,10 @@ import (
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethstats"
+	"github.com/ethereum/go-ethereum/firehose"
 	"github.com/ethereum/go-ethereum/graphql"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/internal/flags"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/log"
@@ -172,6 +174,15 @@ var (
 		Usage: "Initial block gas limit",
 		Value: 11500000,
 	}
+	DeveloperConsortiumFlag = cli.BoolFlag{
+		Name:  "dev.consortium",
+		Usage: "Ephemeral single validator consortium v2 network with pre-funded developer accounts, mining enabled",
+	}
+	DeveloperAccountsFlag = cli.IntFlag{
+		Name:  "dev.accounts",
+		Usage: "Number of pre-funded developer accounts besides the validator in consortium developer mode",
+		Value: 10,
+	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
 		Usage: "Custom node name",
@@ -239,6 +250,194 @@ var (
 		Name:  "monitor.finalityvote",
 		Usage: "Enable finality vote monitoring",
 	}
+	MonitorFinalityStallWebhookFlag = cli.StringFlag{
+		Name:  "monitor.finalitystall.webhook",
+		Usage: "Webhook URL notified when the finality stalls and when it recovers",
+	}
+	MonitorFinalityStallThresholdFlag = cli.Uint64Flag{
+		Name:  "monitor.finalitystall.threshold",
+		Usage: "Number of blocks the finalized block falls behind the head before the finality is considered stalled",
+		Value: ethconfig.Defaults.FinalityStallThreshold,
+	}
+	MonitorChainFreezeEpochsFlag = cli.Uint64Flag{
+		Name:  "monitor.chainfreeze.epochs",
+		Usage: "Refuse the transactions sent through RPC when no block has been finalized for this number of epochs (0 = disabled)",
+	}
+	MonitorChainFreezeWebhookFlag = cli.StringFlag{
+		Name:  "monitor.chainfreeze.webhook",
+		Usage: "Webhook URL notified when the chain freezes and unfreezes (requires --monitor.chainfreeze.epochs)",
+	}
+	MonitorFinalityExportFlag = cli.StringFlag{
+		Name:  "monitor.finalityexport",
+		Usage: "Comma separated list of sink urls the finality data of the finalized blocks is exported to, http(s):// webhooks or file:// JSON lines files",
+	}
+	MonitorInactivityFlag = cli.BoolFlag{
+		Name:  "monitor.inactivity",
+		Usage: "Enable tracking the missed in-turn slots of the validators",
+	}
+	MonitorInactivityThresholdFlag = cli.Uint64Flag{
+		Name:  "monitor.inactivity.threshold",
+		Usage: "Number of consecutive in-turn slots the local validator misses before alerting (0 = disabled)",
+		Value: ethconfig.Defaults.InactivityThreshold,
+	}
+	MonitorInactivityWebhookFlag = cli.StringFlag{
+		Name:  "monitor.inactivity.webhook",
+		Usage: "Webhook URL notified when the local validator misses too many in-turn slots",
+	}
+	MonitorInactivityCommandFlag = cli.StringFlag{
+		Name:  "monitor.inactivity.command",
+		Usage: "Command run when the local validator misses too many in-turn slots, e.g. to enable the maintenance mode",
+	}
+	MonitorScoresFlag = cli.BoolFlag{
+		Name:  "monitor.scores",
+		Usage: "Enable scoring the performance of the validators (consortium_getValidatorScores)",
+	}
+	MonitorScoresWindowFlag = cli.Uint64Flag{
+		Name:  "monitor.scores.window",
+		Usage: "Number of the last completed epochs the validators are scored over",
+		Value: ethconfig.Defaults.ValidatorScore.Window,
+	}
+	MonitorScoresInTurnWeightFlag = cli.Float64Flag{
+		Name:  "monitor.scores.weight.inturn",
+		Usage: "Weight of the ratio of the in-turn slots sealed by the validator in the score",
+		Value: ethconfig.Defaults.ValidatorScore.InTurnWeight,
+	}
+	MonitorScoresFinalityWeightFlag = cli.Float64Flag{
+		Name:  "monitor.scores.weight.finality",
+		Usage: "Weight of the ratio of the finality voted blocks including the validator's vote in the score",
+		Value: ethconfig.Defaults.ValidatorScore.FinalityWeight,
+	}
+	MonitorScoresMissedEpochWeightFlag = cli.Float64Flag{
+		Name:  "monitor.scores.weight.missedepoch",
+		Usage: "Weight of the ratio of the epochs the validator seals blocks in, out of those it has in-turn slots in, in the score",
+		Value: ethconfig.Defaults.ValidatorScore.MissedEpochWeight,
+	}
+	MonitorVoteCanaryWebhookFlag = cli.StringFlag{
+		Name:  "monitor.votecanary.webhook",
+		Usage: "Webhook URL notified when the periodic self-test of the BLS vote key fails",
+	}
+	MonitorNotifyWebhookFlag = cli.StringFlag{
+		Name:  "monitor.notify.webhook",
+		Usage: "Comma separated list of webhook URLs notified of the validator events: missed turn, finality vote not included, equivocation, epoch wrap-up failure and imminent hardfork",
+	}
+	MonitorNotifyEventsFlag = cli.StringFlag{
+		Name:  "monitor.notify.events",
+		Usage: "Comma separated list of the validator events notified to the webhooks (default = all)",
+	}
+	MonitorNotifyHardforkDistanceFlag = cli.Uint64Flag{
+		Name:  "monitor.notify.hardfork.distance",
+		Usage: "Number of blocks before a hardfork block the webhooks are notified (0 = disabled)",
+		Value: ethconfig.Defaults.HardforkNotifyDistance,
+	}
+	MonitorAuditLogFlag = cli.StringFlag{
+		Name:  "monitor.auditlog",
+		Usage: "File the consensus audit records of the imported blocks are appended to as JSON lines: sealer, in-turn validator, backoff, finality votes and verification timings (relative to datadir)",
+	}
+	SlashDoubleSignReportFlag = cli.BoolFlag{
+		Name:  "slash.doublesign.report",
+		Usage: "Report the detected double signs to the slash indicator contract in the sealed blocks (implies --monitor.doublesign)",
+	}
+	SlashDoubleSignGasCapFlag = cli.Uint64Flag{
+		Name:  "slash.doublesign.gascap",
+		Usage: "Gas limit of the double sign report transaction",
+		Value: ethconfig.Defaults.SlashReportGasCap,
+	}
+	SlashDoubleSignDryRunFlag = cli.BoolFlag{
+		Name:  "slash.doublesign.dryrun",
+		Usage: "Only log the crafted double sign reports without including them in the sealed blocks",
+	}
+	SigningLeaseFileFlag = cli.StringFlag{
+		Name:  "standby.leasefile",
+		Usage: "Lease file shared by the primary and standby nodes of a validator, only the lease holder seals and votes",
+	}
+	SigningLeaseHolderFlag = cli.StringFlag{
+		Name:  "standby.holder",
+		Usage: "Identity of this node in the signing lease (default = hostname)",
+	}
+	SigningLeaseDurationFlag = cli.DurationFlag{
+		Name:  "standby.leaseduration",
+		Usage: "Duration of the signing lease, a standby node takes over after the lease is not renewed for this duration",
+		Value: ethconfig.Defaults.SigningLeaseDuration,
+	}
+	SigningLeaseTakeoverFlag = cli.BoolFlag{
+		Name:  "standby.takeover",
+		Usage: "Take over the signing lease when the validator misses more than --monitor.inactivity.threshold consecutive in-turn slots (requires --monitor.inactivity with a non-zero threshold)",
+	}
+	MinSigningPeersFlag = cli.IntFlag{
+		Name:  "miner.minpeers",
+		Usage: "Minimum number of connected peers before sealing blocks and voting (0 = disabled)",
+	}
+	MinSigningRoninPeersFlag = cli.IntFlag{
+		Name:  "miner.minroninpeers",
+		Usage: "Minimum number of connected peers running the ronin protocol before sealing blocks and voting (0 = disabled)",
+	}
+	WatchdogIntervalFlag = cli.DurationFlag{
+		Name:  "miner.watchdog",
+		Usage: "Interval of the probes of the disk latency, the peer count and the head state, the node declines to seal while unhealthy (0 = disabled)",
+	}
+	WatchdogDiskLatencyFlag = cli.DurationFlag{
+		Name:  "miner.watchdog.disklatency",
+		Usage: "Maximum latency of a synced disk write before the node is unhealthy (0 = not probed)",
+		Value: ethconfig.Defaults.WatchdogDiskLatency,
+	}
+	WatchdogMinPeersFlag = cli.IntFlag{
+		Name:  "miner.watchdog.minpeers",
+		Usage: "Minimum number of connected peers before the node is unhealthy (0 = not probed)",
+		Value: ethconfig.Defaults.WatchdogMinPeers,
+	}
+	SealBacklogThresholdFlag = cli.IntFlag{
+		Name:  "miner.backlogthreshold",
+		Usage: "Number of pending transactions above which the in-turn validator seals without waiting for the finality votes and the out-of-turn validators back off further (0 = disabled)",
+	}
+	DisableSealingFlag = cli.BoolFlag{
+		Name:  "miner.disablesealing",
+		Usage: "Skip sealing the blocks while still voting, it can be changed at runtime with admin_setValidatorSettings or by reloading the config file with SIGHUP",
+	}
+	BadBlockBundleDirFlag = DirectoryFlag{
+		Name:  "badblock.bundledir",
+		Usage: "Directory to persist bad block bundles for replaying (relative to datadir, empty to disable)",
+		Value: DirectoryString(ethconfig.Defaults.BadBlockBundleDir),
+	}
+	AllowedFutureBlockTimeFlag = cli.DurationFlag{
+		Name:  "consortium.allowedfutureblock",
+		Usage: "Maximum duration a block time can be ahead of the local clock to tolerate clock drift (max 1s)",
+	}
+	MmapSnapshotStoreFlag = cli.BoolFlag{
+		Name:  "consortium.mmapsnapshots",
+		Usage: "Store the consortium snapshots in a memory mapped file outside of the chain database",
+	}
+	SnapshotArchiveFlag = cli.BoolFlag{
+		Name:  "consortium.snapshotarchive",
+		Usage: "Move the finalized consortium checkpoint snapshots to an append-only ancient table outside of the chain database",
+	}
+	SnapshotCacheFlag = cli.IntFlag{
+		Name:  "consortium.snapshotcache",
+		Usage: "Number of recent consortium snapshots kept in memory (larger on archive nodes, smaller on memory-constrained validators)",
+		Value: ethconfig.Defaults.ConsortiumCache.Snapshots,
+	}
+	SealerCacheFlag = cli.IntFlag{
+		Name:  "consortium.sealercache",
+		Usage: "Number of recent block sealers kept in memory",
+		Value: ethconfig.Defaults.ConsortiumCache.Sealers,
+	}
+	CheckpointIntervalFlag = cli.Uint64Flag{
+		Name:  "consortium.checkpointinterval",
+		Usage: "Number of epochs between the consortium checkpoint snapshots persisted to the database",
+		Value: ethconfig.Defaults.ConsortiumCache.CheckpointInterval,
+	}
+	ValidatorSetOverrideFlag = cli.StringFlag{
+		Name:  "consortium.validatoroverride",
+		Usage: "JSON file of an emergency validator set replacing the validator contract result at a checkpoint block (chain recovery only, every node must use the same file)",
+	}
+	ShadowSealerFlag = cli.StringFlag{
+		Name:  "consortium.shadowsealer",
+		Usage: "Validator address to produce the in-turn blocks of without signing nor broadcasting them, logging what they would have been (rehearsal of validator onboarding)",
+	}
+	ConsortiumVerifyFlag = cli.StringFlag{
+		Name:  "consortium.verify",
+		Usage: `Header verification mode ("full" or "light", light skips the finality signatures below the finality checkpoint relayed by the trusted peers, RPC followers behind trusted validators only)`,
+		Value: ethconfig.Defaults.ConsortiumVerify,
+	}
 	StoreInternalTransactions = cli.BoolFlag{
 		Name:  "internaltxs",
 		Usage: "Enable storing internal transactions to db",
@@ -256,6 +455,10 @@ var (
 		Usage: "Megabytes of memory allocated to bloom-filter for pruning",
 		Value: 2048,
 	}
+	ChainPresetFlag = cli.StringFlag{
+		Name:  "chain.preset",
+		Usage: "Built-in chain configuration of a well-known network (ronin-mainnet, saigon-testnet, local-devnet), replacing the configuration of the genesis",
+	}
 	OverrideArrowGlacierFlag = cli.Uint64Flag{
 		Name:  "override.arrowglacier",
 		Usage: "Manually specify Arrow Glacier fork-block, overriding the bundled setting",
@@ -395,6 +598,11 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: ethconfig.Defaults.TxPool.Lifetime,
 	}
+	TxPoolSponsoredSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.sponsoredslots",
+		Usage: "Number of slots of the sponsored transaction lane, kept apart from the global slots and queue (0 = no lane)",
+		Value: ethconfig.Defaults.TxPool.SponsoredSlots,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -476,6 +684,10 @@ var (
 		Usage: "Public address for block mining rewards (default = first account)",
 		Value: "0",
 	}
+	MinerExtraSignersFlag = cli.StringFlag{
+		Name:  "miner.extrasigners",
+		Usage: "Comma separated list of additional sealing accounts, the account in the validator set is used to seal blocks",
+	}
 	MinerExtraDataFlag = cli.StringFlag{
 		Name:  "miner.extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
@@ -499,6 +711,10 @@ var (
 		Usage: "Reserved block size when committing transactions to block",
 		Value: ethconfig.Defaults.Miner.BlockSizeReserve,
 	}
+	MinerOptimisticSealFlag = cli.BoolFlag{
+		Name:  "miner.optimisticseal",
+		Usage: "Include the transactions arriving while the sealer waits for the finality votes",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -547,6 +763,10 @@ var (
 		Name:  "ethstats",
 		Usage: "Reporting URL of a ethstats service (nodename:secret@host:port)",
 	}
+	FirehoseEndpointFlag = cli.StringFlag{
+		Name:  "firehose",
+		Usage: "Unix socket path the blocks, receipts and finality decisions are streamed to as protobuf messages (relative to datadir)",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -651,6 +871,10 @@ var (
 		Usage: "WriteBuffer for Websocket Server default 1024 bytes",
 		Value: 1024,
 	}
+	RPCJWTSecretFlag = cli.StringFlag{
+		Name:  "rpc.jwtsecret",
+		Usage: "Path to a hex encoded JWT secret authenticating the HTTP and WS-RPC calls, generated if missing (only the read-only methods are allowed without the 'operator' scope)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -876,6 +1100,28 @@ var (
 		Value: "http://localhost:4040",
 	}
 
+	TracingEnableFlag = cli.BoolFlag{
+		Name:  "tracing.enabled",
+		Usage: "Enable OpenTelemetry tracing of the consensus engine",
+	}
+
+	TracingEndpointFlag = cli.StringFlag{
+		Name:  "tracing.endpoint",
+		Usage: "OTLP/HTTP endpoint (host:port) the traces are exported to",
+		Value: "localhost:4318",
+	}
+
+	TracingInsecureFlag = cli.BoolFlag{
+		Name:  "tracing.insecure",
+		Usage: "Export the traces over plain HTTP instead of HTTPS",
+	}
+
+	TracingServiceNameFlag = cli.StringFlag{
+		Name:  "tracing.service",
+		Usage: "Service name of the traces",
+		Value: "ronin",
+	}
+
 	MaxCurVoteAmountPerBlock = cli.IntFlag{
 		Name:  "votepool.maxcurvoteperblock",
 		Usage: "The maximum finality vote per current block",
@@ -904,11 +1150,61 @@ var (
 		Value: "bls_keystore",
 	}
 
+	BlsRemoteSignerFlag = cli.StringFlag{
+		Name:  "finality.remotesigner",
+		Usage: "The path to the socket of the vote signer process holding the bls key, instead of the bls wallet",
+	}
+
+	BlsProtectionPathFlag = cli.StringFlag{
+		Name:  "finality.protectionpath",
+		Usage: "The path to the history of the signed finality votes used to refuse the conflicting votes (default = inside the datadir)",
+	}
+
+	VoteAssemblyWindow = cli.DurationFlag{
+		Name:  "finality.voteassemblywindow",
+		Usage: "Maximum duration to wait for the finality votes to reach quorum before releasing the block, the block is not delayed (0 = disabled, max 1s)",
+	}
+
+	DisableVotingFlag = cli.BoolFlag{
+		Name:  "finality.disablevoting",
+		Usage: "Skip voting for the finality while still sealing the blocks, it can be changed at runtime with admin_setValidatorSettings or by reloading the config file with SIGHUP",
+	}
+
+	AllowJustifiedRewindFlag = cli.BoolFlag{
+		Name:  "finality.allowrewind",
+		Usage: "Allow reorging the chain below the justified block (disaster recovery only)",
+	}
+
+	FeaturesFlag = cli.StringFlag{
+		Name:  "features",
+		Usage: "Comma separated list of feature flags to set at startup (name=true|false, see admin.features)",
+	}
+
 	DisableRoninProtocol = cli.BoolFlag{
 		Name:  "ronin.disable",
 		Usage: "Disable ronin p2p protocol",
 	}
 
+	BlockLatencyReportFlag = cli.BoolFlag{
+		Name:  "ronin.latency.report",
+		Usage: "Report the observed block propagation latencies to the ronin peers",
+	}
+
+	ValidatorPeeringFlag = cli.BoolFlag{
+		Name:  "ronin.validatorpeering",
+		Usage: "Prove the local validator to the ronin peers and protect the connections to the other validators",
+	}
+
+	ValidatorPeeringAllowFlag = cli.StringFlag{
+		Name:  "ronin.validatorpeering.allow",
+		Usage: "Comma separated validator addresses whose connections are protected (default = all validators)",
+	}
+
+	ValidatorPeeringDenyFlag = cli.StringFlag{
+		Name:  "ronin.validatorpeering.deny",
+		Usage: "Comma separated validator addresses whose connections are never protected",
+	}
+
 	AdditionalChainEventFlag = cli.BoolFlag{
 		Name:  "additionalchainevent.enable",
 		Usage: "Enable additional chain event",
@@ -1070,6 +1366,18 @@ func SplitAndTrim(input string) (ret []string) {
 	return ret
 }
 
+// parseValidatorAddresses parses the comma separated validator addresses
+func parseValidatorAddresses(input string) []common.Address {
+	var addresses []common.Address
+	for _, address := range SplitAndTrim(input) {
+		if !common.IsHexAddress(address) {
+			Fatalf("Invalid validator address %q", address)
+		}
+		addresses = append(addresses, common.HexToAddress(address))
+	}
+	return addresses
+}
+
 // setHTTP creates the HTTP RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func setHTTP(ctx *cli.Context, cfg *node.Config) {
@@ -1251,6 +1559,18 @@ func setEtherbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *ethconfig.Config
 			Fatalf("No etherbase configured")
 		}
 	}
+	if ctx.GlobalIsSet(MinerExtraSignersFlag.Name) {
+		if ks == nil {
+			Fatalf("No keystore configured for extra signers")
+		}
+		for _, signer := range SplitAndTrim(ctx.GlobalString(MinerExtraSignersFlag.Name)) {
+			account, err := MakeAddress(ks, signer)
+			if err != nil {
+				Fatalf("Invalid miner extra signer: %v", err)
+			}
+			cfg.Miner.ExtraSigners = append(cfg.Miner.ExtraSigners, account.Address)
+		}
+	}
 }
 
 // MakePasswordList reads password lines from the file specified by the global --password flag.
@@ -1334,7 +1654,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
-	if ctx.GlobalBool(DeveloperFlag.Name) || ctx.GlobalBool(CatalystFlag.Name) {
+	if ctx.GlobalBool(DeveloperFlag.Name) || ctx.GlobalBool(DeveloperConsortiumFlag.Name) || ctx.GlobalBool(CatalystFlag.Name) {
 		// --dev mode can't use p2p networking.
 		cfg.MaxPeers = 0
 		cfg.ListenAddr = ""
@@ -1363,7 +1683,10 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
-	if ctx.GlobalIsSet(DeveloperFlag.Name) {
+	if ctx.GlobalIsSet(RPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(RPCJWTSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(DeveloperFlag.Name) || ctx.GlobalIsSet(DeveloperConsortiumFlag.Name) {
 		cfg.UseLightweightKDF = true
 	}
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
@@ -1378,6 +1701,10 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(InsecureUnlockAllowedFlag.Name) {
 		cfg.InsecureUnlockAllowed = ctx.GlobalBool(InsecureUnlockAllowedFlag.Name)
 	}
+	if ctx.GlobalIsSet(DeveloperConsortiumFlag.Name) {
+		// The developer accounts are unlocked to send transactions over RPC
+		cfg.EnableSigningMethods = true
+	}
 	if ctx.GlobalIsSet(EnableSigningMethodsFlag.Name) {
 		cfg.EnableSigningMethods = ctx.GlobalBool(EnableSigningMethodsFlag.Name)
 	}
@@ -1389,6 +1716,15 @@ func setFastFinality(ctx *cli.Context, cfg *node.Config) {
 	cfg.EnableFastFinalitySign = ctx.GlobalBool(EnableFastFinalitySign.Name)
 	cfg.BlsPasswordPath = ctx.GlobalString(BlsPasswordPath.Name)
 	cfg.BlsWalletPath = ctx.GlobalString(BlsWalletPath.Name)
+	cfg.BlsRemoteSigner = ctx.GlobalString(BlsRemoteSignerFlag.Name)
+	cfg.BlsProtectionPath = ctx.GlobalString(BlsProtectionPathFlag.Name)
+	cfg.VoteAssemblyWindow = ctx.GlobalDuration(VoteAssemblyWindow.Name)
+
+	// The developer validator votes for the finality of its own blocks
+	if ctx.GlobalBool(DeveloperConsortiumFlag.Name) {
+		cfg.EnableFastFinality = true
+		cfg.EnableFastFinalitySign = true
+	}
 }
 
 func setSmartCard(ctx *cli.Context, cfg *node.Config) {
@@ -1415,7 +1751,7 @@ func setDataDir(ctx *cli.Context, cfg *node.Config) {
 	switch {
 	case ctx.GlobalIsSet(DataDirFlag.Name):
 		cfg.DataDir = ctx.GlobalString(DataDirFlag.Name)
-	case ctx.GlobalBool(DeveloperFlag.Name):
+	case ctx.GlobalBool(DeveloperFlag.Name), ctx.GlobalBool(DeveloperConsortiumFlag.Name):
 		cfg.DataDir = "" // unless explicitly requested, use memory databases
 	case ctx.GlobalBool(RopstenFlag.Name) && cfg.DataDir == node.DefaultDataDir():
 		// Maintain compatibility with older Geth configurations storing the
@@ -1499,6 +1835,9 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolSponsoredSlotsFlag.Name) {
+		cfg.SponsoredSlots = ctx.GlobalUint64(TxPoolSponsoredSlotsFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1552,6 +1891,9 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 		cfg.BlockProduceLeftOver = ctx.GlobalDuration(MinerBlockProduceLeftoverFlag.Name)
 	}
 	cfg.BlockSizeReserve = ctx.GlobalUint64(MinerBlockSizeReserveFlag.Name)
+	if ctx.GlobalIsSet(MinerOptimisticSealFlag.Name) {
+		cfg.OptimisticSeal = ctx.GlobalBool(MinerOptimisticSealFlag.Name)
+	}
 	if ctx.GlobalIsSet(LegacyMinerGasTargetFlag.Name) {
 		log.Warn("The generic --miner.gastarget flag is deprecated and will be removed in the future!")
 	}
@@ -1624,9 +1966,11 @@ func CheckExclusive(ctx *cli.Context, args ...interface{}) {
 // SetEthConfig applies eth-related command line flags to the config.
 func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	// Avoid conflicting network flags
-	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, RopstenFlag, RinkebyFlag, GoerliFlag, SepoliaFlag)
+	CheckExclusive(ctx, MainnetFlag, DeveloperFlag, DeveloperConsortiumFlag, RopstenFlag, RinkebyFlag, GoerliFlag, SepoliaFlag, ChainPresetFlag)
 	CheckExclusive(ctx, LightServeFlag, SyncModeFlag, "light")
-	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
+	CheckExclusive(ctx, DeveloperFlag, ExternalSignerFlag)           // Can't use both ephemeral unlocked and external signer
+	CheckExclusive(ctx, DeveloperConsortiumFlag, ExternalSignerFlag) // Can't use both ephemeral unlocked and external signer
+	CheckExclusive(ctx, DeveloperConsortiumFlag, MockValidatorsFlag) // The developer validator is the only mock validator
 	if ctx.GlobalString(GCModeFlag.Name) == "archive" && ctx.GlobalUint64(TxLookupLimitFlag.Name) != 0 {
 		ctx.GlobalSet(TxLookupLimitFlag.Name, "0")
 		log.Warn("Disable transaction unindexing for archive node")
@@ -1759,6 +2103,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(DisableRoninProtocol.Name) {
 		cfg.DisableRoninProtocol = ctx.GlobalBool(DisableRoninProtocol.Name)
 	}
+	if ctx.GlobalIsSet(BlockLatencyReportFlag.Name) {
+		cfg.ReportBlockLatency = ctx.GlobalBool(BlockLatencyReportFlag.Name)
+	}
+	if ctx.GlobalIsSet(ChainPresetFlag.Name) {
+		preset, err := params.LookupChainPreset(ctx.GlobalString(ChainPresetFlag.Name))
+		if err != nil {
+			Fatalf("%v", err)
+		}
+		cfg.ChainPreset = preset.Name
+		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
+			cfg.NetworkId = preset.Config.ChainID.Uint64()
+		}
+	}
 	// Override any default configs for hard coded networks.
 	switch {
 	case ctx.GlobalBool(MainnetFlag.Name):
@@ -1838,6 +2195,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) {
 			cfg.Miner.GasPrice = big.NewInt(1)
 		}
+	case ctx.GlobalBool(DeveloperConsortiumFlag.Name):
+		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
+			cfg.NetworkId = 1337
+		}
+		cfg.SyncMode = downloader.FullSync
+		setDeveloperConsortium(ctx, stack, ks, cfg)
+		if !ctx.GlobalIsSet(MinerGasPriceFlag.Name) {
+			cfg.Miner.GasPrice = big.NewInt(1)
+		}
 	default:
 		if cfg.NetworkId == 1 {
 			SetDNSDiscoveryDefaults(cfg, params.MainnetGenesisHash)
@@ -1855,6 +2221,172 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalBool(MonitorFinalityVoteFlag.Name) {
 		cfg.EnableMonitorFinalityVote = true
 	}
+
+	if ctx.GlobalIsSet(MonitorFinalityStallWebhookFlag.Name) {
+		cfg.FinalityStallWebhook = ctx.GlobalString(MonitorFinalityStallWebhookFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorFinalityStallThresholdFlag.Name) {
+		cfg.FinalityStallThreshold = ctx.GlobalUint64(MonitorFinalityStallThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorChainFreezeEpochsFlag.Name) {
+		cfg.ChainFreezeEpochs = ctx.GlobalUint64(MonitorChainFreezeEpochsFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorChainFreezeWebhookFlag.Name) {
+		cfg.ChainFreezeWebhook = ctx.GlobalString(MonitorChainFreezeWebhookFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(MonitorAuditLogFlag.Name) {
+		cfg.AuditLogFile = ctx.GlobalString(MonitorAuditLogFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorFinalityExportFlag.Name) {
+		cfg.FinalityExportURLs = SplitAndTrim(ctx.GlobalString(MonitorFinalityExportFlag.Name))
+	}
+
+	if ctx.GlobalBool(MonitorInactivityFlag.Name) {
+		cfg.EnableInactivityTracker = true
+	}
+	if ctx.GlobalIsSet(MonitorInactivityThresholdFlag.Name) {
+		cfg.InactivityThreshold = ctx.GlobalUint64(MonitorInactivityThresholdFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorInactivityWebhookFlag.Name) {
+		cfg.InactivityWebhook = ctx.GlobalString(MonitorInactivityWebhookFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorInactivityCommandFlag.Name) {
+		cfg.InactivityCommand = ctx.GlobalString(MonitorInactivityCommandFlag.Name)
+	}
+	if ctx.GlobalBool(MonitorScoresFlag.Name) {
+		cfg.EnableValidatorScores = true
+	}
+	if ctx.GlobalIsSet(MonitorScoresWindowFlag.Name) {
+		cfg.ValidatorScore.Window = ctx.GlobalUint64(MonitorScoresWindowFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorScoresInTurnWeightFlag.Name) {
+		cfg.ValidatorScore.InTurnWeight = ctx.GlobalFloat64(MonitorScoresInTurnWeightFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorScoresFinalityWeightFlag.Name) {
+		cfg.ValidatorScore.FinalityWeight = ctx.GlobalFloat64(MonitorScoresFinalityWeightFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorScoresMissedEpochWeightFlag.Name) {
+		cfg.ValidatorScore.MissedEpochWeight = ctx.GlobalFloat64(MonitorScoresMissedEpochWeightFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorVoteCanaryWebhookFlag.Name) {
+		cfg.VoteCanaryWebhook = ctx.GlobalString(MonitorVoteCanaryWebhookFlag.Name)
+	}
+	if ctx.GlobalIsSet(MonitorNotifyWebhookFlag.Name) {
+		cfg.ValidatorNotifyWebhooks = SplitAndTrim(ctx.GlobalString(MonitorNotifyWebhookFlag.Name))
+	}
+	if ctx.GlobalIsSet(MonitorNotifyEventsFlag.Name) {
+		cfg.ValidatorNotifyEvents = SplitAndTrim(ctx.GlobalString(MonitorNotifyEventsFlag.Name))
+	}
+	if ctx.GlobalIsSet(MonitorNotifyHardforkDistanceFlag.Name) {
+		cfg.HardforkNotifyDistance = ctx.GlobalUint64(MonitorNotifyHardforkDistanceFlag.Name)
+	}
+
+	if ctx.GlobalBool(SlashDoubleSignReportFlag.Name) {
+		cfg.EnableSlashDoubleSignReport = true
+	}
+	if ctx.GlobalIsSet(SlashDoubleSignGasCapFlag.Name) {
+		cfg.SlashReportGasCap = ctx.GlobalUint64(SlashDoubleSignGasCapFlag.Name)
+	}
+	if ctx.GlobalBool(SlashDoubleSignDryRunFlag.Name) {
+		cfg.SlashReportDryRun = true
+	}
+
+	if ctx.GlobalIsSet(SigningLeaseFileFlag.Name) {
+		cfg.SigningLeaseFile = ctx.GlobalString(SigningLeaseFileFlag.Name)
+	}
+	if ctx.GlobalIsSet(SigningLeaseHolderFlag.Name) {
+		cfg.SigningLeaseHolder = ctx.GlobalString(SigningLeaseHolderFlag.Name)
+	}
+	if ctx.GlobalIsSet(SigningLeaseDurationFlag.Name) {
+		cfg.SigningLeaseDuration = ctx.GlobalDuration(SigningLeaseDurationFlag.Name)
+	}
+	if ctx.GlobalBool(SigningLeaseTakeoverFlag.Name) {
+		cfg.SigningLeaseTakeover = true
+	}
+	if ctx.GlobalIsSet(MinSigningPeersFlag.Name) {
+		cfg.MinSigningPeers = ctx.GlobalInt(MinSigningPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinSigningRoninPeersFlag.Name) {
+		cfg.MinSigningRoninPeers = ctx.GlobalInt(MinSigningRoninPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(WatchdogIntervalFlag.Name) {
+		cfg.WatchdogInterval = ctx.GlobalDuration(WatchdogIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(WatchdogDiskLatencyFlag.Name) {
+		cfg.WatchdogDiskLatency = ctx.GlobalDuration(WatchdogDiskLatencyFlag.Name)
+	}
+	if ctx.GlobalIsSet(WatchdogMinPeersFlag.Name) {
+		cfg.WatchdogMinPeers = ctx.GlobalInt(WatchdogMinPeersFlag.Name)
+	}
+	if ctx.GlobalIsSet(SealBacklogThresholdFlag.Name) {
+		cfg.SealBacklogThreshold = ctx.GlobalInt(SealBacklogThresholdFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(BadBlockBundleDirFlag.Name) {
+		cfg.BadBlockBundleDir = ctx.GlobalString(BadBlockBundleDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(AllowedFutureBlockTimeFlag.Name) {
+		cfg.AllowedFutureBlockTime = ctx.GlobalDuration(AllowedFutureBlockTimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(DisableVotingFlag.Name) {
+		cfg.DisableVoting = ctx.GlobalBool(DisableVotingFlag.Name)
+	}
+	if ctx.GlobalIsSet(DisableSealingFlag.Name) {
+		cfg.DisableSealing = ctx.GlobalBool(DisableSealingFlag.Name)
+	}
+	if ctx.GlobalBool(MmapSnapshotStoreFlag.Name) {
+		cfg.MmapSnapshotStore = true
+	}
+	if ctx.GlobalBool(SnapshotArchiveFlag.Name) {
+		cfg.SnapshotArchive = true
+	}
+	if ctx.GlobalIsSet(SnapshotCacheFlag.Name) {
+		cfg.ConsortiumCache.Snapshots = ctx.GlobalInt(SnapshotCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(SealerCacheFlag.Name) {
+		cfg.ConsortiumCache.Sealers = ctx.GlobalInt(SealerCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(CheckpointIntervalFlag.Name) {
+		cfg.ConsortiumCache.CheckpointInterval = ctx.GlobalUint64(CheckpointIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(ValidatorSetOverrideFlag.Name) {
+		cfg.ValidatorSetOverrideFile = ctx.GlobalString(ValidatorSetOverrideFlag.Name)
+	}
+	if ctx.GlobalIsSet(ShadowSealerFlag.Name) {
+		address := ctx.GlobalString(ShadowSealerFlag.Name)
+		if !common.IsHexAddress(address) {
+			Fatalf("Invalid shadow sealer address %q", address)
+		}
+		cfg.ShadowSealer = common.HexToAddress(address)
+	}
+	if ctx.GlobalBool(ValidatorPeeringFlag.Name) {
+		cfg.ValidatorPeering = true
+	}
+	if ctx.GlobalIsSet(ValidatorPeeringAllowFlag.Name) {
+		cfg.ValidatorPeeringAllowlist = parseValidatorAddresses(ctx.GlobalString(ValidatorPeeringAllowFlag.Name))
+	}
+	if ctx.GlobalIsSet(ValidatorPeeringDenyFlag.Name) {
+		cfg.ValidatorPeeringDenylist = parseValidatorAddresses(ctx.GlobalString(ValidatorPeeringDenyFlag.Name))
+	}
+	if ctx.GlobalIsSet(ConsortiumVerifyFlag.Name) {
+		switch mode := ctx.GlobalString(ConsortiumVerifyFlag.Name); mode {
+		case ethconfig.ConsortiumVerifyFull, ethconfig.ConsortiumVerifyLight:
+			cfg.ConsortiumVerify = mode
+		default:
+			Fatalf("Invalid consortium verification mode %q, want %q or %q", mode, ethconfig.ConsortiumVerifyFull, ethconfig.ConsortiumVerifyLight)
+		}
+	}
+
+	if ctx.GlobalBool(AllowJustifiedRewindFlag.Name) {
+		cfg.AllowJustifiedRewind = true
+	}
+
+	if ctx.GlobalIsSet(FeaturesFlag.Name) {
+		if err := features.Configure(ctx.GlobalString(FeaturesFlag.Name)); err != nil {
+			Fatalf("Invalid features: %v", err)
+		}
+	}
 }
 
 // SetDNSDiscoveryDefaults configures DNS discovery with the given URL if
@@ -1907,6 +2439,16 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 	}
 }
 
+// RegisterFirehoseService configures the streaming exporter of the chain data
+// and adds it to the given node.
+func RegisterFirehoseService(stack *node.Node, backend *eth.Ethereum, endpoint string) {
+	// The reorged blocks are only sent with the additional chain events
+	backend.BlockChain().EnableAdditionalChainEvent()
+	if err := firehose.New(stack, backend.BlockChain(), endpoint); err != nil {
+		Fatalf("Failed to register the firehose service: %v", err)
+	}
+}
+
 // RegisterGraphQLService is a utility function to construct a new service and register it against a node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.Config) {
 	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
@@ -2032,7 +2574,7 @@ func MakeGenesis(ctx *cli.Context) *core.Genesis {
 		genesis = core.DefaultRinkebyGenesisBlock()
 	case ctx.GlobalBool(GoerliFlag.Name):
 		genesis = core.DefaultGoerliGenesisBlock()
-	case ctx.GlobalBool(DeveloperFlag.Name):
+	case ctx.GlobalBool(DeveloperFlag.Name), ctx.GlobalBool(DeveloperConsortiumFlag.Name):
 		Fatalf("Developer chains are ephemeral")
 	}
 	return genesis
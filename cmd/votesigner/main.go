@@ -0,0 +1,56 @@
+// votesigner holds the BLS key of a validator and signs the finality votes of
+// its node over a local socket, so the key is isolated from the node process.
+//
+// The signer should run as a different user than the node, without network
+// access, the socket being the only channel shared with the node. The node is
+// started with --finality.remotesigner pointing to the socket.
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/vote"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func main() {
+	var (
+		walletPath   = flag.String("wallet", "bls_keystore", "path to the bls wallet holding the secret key")
+		passwordPath = flag.String("password", "bls_password", "path to the bls wallet password file")
+		socketPath   = flag.String("socket", "votesigner.ipc", "path to the socket the node connects to")
+		protection   = flag.String("protection", vote.DefaultProtectionFile, "path to the vote protection history file")
+		verbosity    = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-5)")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	log.Root().SetHandler(glogger)
+
+	signer, err := vote.NewVoteSigner(*passwordPath, *walletPath)
+	if err != nil {
+		utils.Fatalf("Failed to open the bls wallet: %v", err)
+	}
+	service, err := vote.NewVoteSignerService(signer, *protection)
+	if err != nil {
+		utils.Fatalf("Failed to create the vote signer: %v", err)
+	}
+	listener, server, err := rpc.StartIPCEndpoint(*socketPath, service.APIs())
+	if err != nil {
+		utils.Fatalf("Failed to listen on %s: %v", *socketPath, err)
+	}
+	log.Info("Vote signer started", "socket", listener.Addr())
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	<-sigc
+
+	log.Info("Vote signer stopping")
+	listener.Close()
+	server.Stop()
+}
@@ -171,3 +171,26 @@ type FastFinalityPoSA interface {
 type VotePool interface {
 	FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteEnvelope
 }
+
+// AggregatedVotePool is implemented by the vote pools which keep the finality
+// votes aggregated by quorum
+type AggregatedVotePool interface {
+	VotePool
+
+	// FetchAggregatedVoteByBlockHash returns the verified aggregated vote with
+	// the most voters for the block, nil if there is none
+	FetchAggregatedVoteByBlockHash(blockHash common.Hash) *types.AggregatedVote
+}
+
+// VoteAggregator is implemented by the fast finality engines which aggregate
+// the finality votes of a quorum of validators, so that the quorum can be
+// relayed in place of the individual votes
+type VoteAggregator interface {
+	// AggregateVotes aggregates the votes for the same vote data, it returns nil
+	// if the voters do not reach the quorum. The votes are assumed to be verified.
+	AggregateVotes(chain ChainHeaderReader, votes []*types.VoteEnvelope) (*types.AggregatedVote, error)
+
+	// VerifyAggregatedVote checks the aggregated signature of the voters and that
+	// they reach the quorum
+	VerifyAggregatedVote(chain ChainHeaderReader, vote *types.AggregatedVote) error
+}
@@ -0,0 +1,82 @@
+package consortium
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// maxCheckpointAncestry is the maximum number of blocks walked back from a
+// trusted checkpoint which is not canonical yet to find the verified header, the
+// headers further below are fully verified
+const maxCheckpointAncestry = 2048
+
+// errCheckpointMismatch is returned if a header at the number of the trusted
+// checkpoint is not the checkpoint block
+var errCheckpointMismatch = errors.New("header mismatch with the trusted checkpoint")
+
+// SetTrustedCheckpoint makes the engine trust the checkpoint block and its
+// ancestors, the ancestors of the checkpoint are not verified and any other
+// header at the checkpoint number is rejected. It must be set before the engine
+// verifies any block.
+func (c *Consortium) SetTrustedCheckpoint(number uint64, hash common.Hash) {
+	c.checkpointNumber, c.checkpointHash = number, hash
+}
+
+// verifyTrustedCheckpoint reports whether the header is covered by the trusted
+// checkpoint, in which case the error is the result of its verification. The
+// headers below the checkpoint are only covered if they are its ancestors, as
+// found in the chain or in the batch of headers being verified, the other
+// headers must be fully verified.
+func (c *Consortium) verifyTrustedCheckpoint(chain consensus.ChainHeaderReader, header *types.Header, headers []*types.Header) (bool, error) {
+	number := header.Number.Uint64()
+	if c.checkpointHash == (common.Hash{}) || number > c.checkpointNumber {
+		return false, nil
+	}
+	if number == c.checkpointNumber {
+		if header.Hash() != c.checkpointHash {
+			return true, errCheckpointMismatch
+		}
+		return true, nil
+	}
+	return c.isCheckpointAncestor(chain, header, headers), nil
+}
+
+// isCheckpointAncestor reports whether the header is an ancestor of the trusted
+// checkpoint. Once the checkpoint is canonical its ancestors are the canonical
+// headers, otherwise the ancestry is walked back by parent hash.
+func (c *Consortium) isCheckpointAncestor(chain consensus.ChainHeaderReader, header *types.Header, headers []*types.Header) bool {
+	target := header.Number.Uint64()
+	if checkpoint := chain.GetHeaderByNumber(c.checkpointNumber); checkpoint != nil && checkpoint.Hash() == c.checkpointHash {
+		canonical := chain.GetHeaderByNumber(target)
+		return canonical != nil && canonical.Hash() == header.Hash()
+	}
+	if c.checkpointNumber-target > maxCheckpointAncestry {
+		return false
+	}
+	number, hash := c.checkpointNumber, c.checkpointHash
+	for number > target {
+		ancestor := lookupHeader(chain, headers, number, hash)
+		if ancestor == nil {
+			return false
+		}
+		number, hash = number-1, ancestor.ParentHash
+	}
+	return hash == header.Hash()
+}
+
+// lookupHeader returns the header with the number and hash from the contiguous
+// ascending headers or the chain, nil if it is unknown
+func lookupHeader(chain consensus.ChainHeaderReader, headers []*types.Header, number uint64, hash common.Hash) *types.Header {
+	if len(headers) > 0 {
+		first := headers[0].Number.Uint64()
+		if number >= first && number-first < uint64(len(headers)) {
+			if header := headers[number-first]; header.Hash() == hash {
+				return header
+			}
+		}
+	}
+	return chain.GetHeader(hash, number)
+}
@@ -0,0 +1,81 @@
+package consortium
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// checkpointChain is a chain of headers, the canonical ones are indexed by number
+type checkpointChain struct {
+	consensus.ChainHeaderReader
+
+	headers   map[common.Hash]*types.Header
+	canonical map[uint64]*types.Header
+}
+
+func (chain *checkpointChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header, ok := chain.headers[hash]; ok && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+
+func (chain *checkpointChain) GetHeaderByNumber(number uint64) *types.Header {
+	return chain.canonical[number]
+}
+
+func TestTrustedCheckpointAncestry(t *testing.T) {
+	// The canonical headers from 0 to 4 and a side chain header at 2
+	var headers []*types.Header
+	for i := 0; i < 5; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+	fork := &types.Header{Number: big.NewInt(2), ParentHash: headers[1].Hash(), Extra: []byte{0x1}}
+	chain := &checkpointChain{headers: make(map[common.Hash]*types.Header), canonical: make(map[uint64]*types.Header)}
+
+	c := &Consortium{}
+	c.SetTrustedCheckpoint(3, headers[3].Hash())
+
+	// The checkpoint is only known in the batch of headers being verified
+	for i, header := range headers[:3] {
+		if trusted, err := c.verifyTrustedCheckpoint(chain, header, headers); !trusted || err != nil {
+			t.Fatalf("Expect the ancestor %d to be trusted, got %v %v", i, trusted, err)
+		}
+		if trusted, _ := c.verifyTrustedCheckpoint(chain, header, nil); trusted {
+			t.Fatalf("Expect the header %d with the unknown ancestry to be verified", i)
+		}
+	}
+	if trusted, _ := c.verifyTrustedCheckpoint(chain, fork, headers); trusted {
+		t.Fatalf("Expect the side chain header to be verified")
+	}
+	if trusted, err := c.verifyTrustedCheckpoint(chain, fork, append(headers[:2:2], fork)); trusted {
+		t.Fatalf("Expect the side chain header to be verified, got %v", err)
+	}
+	if trusted, err := c.verifyTrustedCheckpoint(chain, &types.Header{Number: big.NewInt(3)}, nil); !trusted || err != errCheckpointMismatch {
+		t.Fatalf("Expect error %v at the checkpoint number, got %v", errCheckpointMismatch, err)
+	}
+	if trusted, _ := c.verifyTrustedCheckpoint(chain, headers[4], headers); trusted {
+		t.Fatalf("Expect the header above the checkpoint to be verified")
+	}
+
+	// Once the checkpoint is canonical, its ancestors are the canonical headers
+	for _, header := range headers {
+		chain.headers[header.Hash()] = header
+		chain.canonical[header.Number.Uint64()] = header
+	}
+	chain.headers[fork.Hash()] = fork
+	if trusted, _ := c.verifyTrustedCheckpoint(chain, headers[1], nil); !trusted {
+		t.Fatalf("Expect the canonical ancestor to be trusted")
+	}
+	if trusted, _ := c.verifyTrustedCheckpoint(chain, fork, nil); trusted {
+		t.Fatalf("Expect the side chain header to be verified")
+	}
+}
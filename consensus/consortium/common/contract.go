@@ -15,22 +15,77 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus"
-	finalityTracking "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/finality_tracking"
 	"github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/profile"
-	roninValidatorSet "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/ronin_validator_set"
 	slashIndicator "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/slash_indicator"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	chainParams "github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	lru "github.com/hashicorp/golang-lru"
 )
 
-var errMethodUnimplemented = errors.New("method is unimplemented")
+const (
+	blsPublicKeyCacheSize = 256 // Number of (validator, block) BLS public keys to keep in memory
+
+	systemAccessListCacheSize = 32 // Number of system call access lists to keep in memory
+)
+
+var (
+	errMethodUnimplemented = errors.New("method is unimplemented")
+	errUnpinnedBlock       = errors.New("read outside of the pinned block")
+)
+
+// systemAccessLists are the access lists recorded by the last execution of the
+// system calls, by contract and method. The state accessed by the next call of a
+// method is mostly the same, it is prefetched before the call is executed. The
+// system transactions do not carry the access lists as that would change their
+// hash and their gas usage.
+var systemAccessLists, _ = lru.New(systemAccessListCacheSize)
+
+// systemCallKey is the contract and the method selector of a system call
+type systemCallKey struct {
+	contract common.Address
+	selector [4]byte
+}
+
+func newSystemCallKey(tx *types.Transaction) systemCallKey {
+	key := systemCallKey{contract: *tx.To()}
+	copy(key.selector[:], tx.Data())
+	return key
+}
+
+// pubkeyChangedTopic is the topic of the event emitted by the profile contract
+// when a candidate changes its BLS public key
+var pubkeyChangedTopic = func() common.Hash {
+	parsed, err := profile.ProfileMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	return parsed.Events["PubkeyChanged"].ID
+}()
+
+// gasLimitSelector is the selector of the gasLimit() view of the gas limit
+// governance contract, see params.ConsortiumConfig.GasLimitContract
+var gasLimitSelector = crypto.Keccak256([]byte("gasLimit()"))[:4]
+
+// ErrNoGasLimitContract is returned if the chain has no gas limit governance
+// contract
+var ErrNoGasLimitContract = errors.New("no gas limit governance contract")
+
+// blsPublicKeyCacheKey is the key of a cached BLS public key, the key is cached
+// per block as read from its state, so a read racing with the import of a key
+// change cannot cache the stale key for the later blocks
+type blsPublicKeyCacheKey struct {
+	validator common.Address
+	number    uint64
+	hash      common.Hash // Hash of the block if the reads are pinned, empty otherwise
+}
 
 // getTransactionOpts is a helper function that creates TransactOpts with GasPrice equals 0
 func getTransactionOpts(from common.Address, nonce uint64, chainId *big.Int, signTxFn SignerTxFn) *bind.TransactOpts {
@@ -60,65 +115,81 @@ type ContractInteraction interface {
 	SubmitBlockReward(opts *ApplyTransactOpts) error
 	Slash(opts *ApplyTransactOpts, spoiledValidator common.Address) error
 	FinalityReward(opts *ApplyTransactOpts, votedValidators []common.Address) error
+	SlashDoubleSign(opts *ApplyTransactOpts, evidence *DoubleSignEvidence) error
 	GetBlsPublicKey(blockNumber *big.Int, validator common.Address) (blsCommon.PublicKey, error)
+	GetGasLimitTarget(blockNumber *big.Int) (uint64, error)
+	InvalidateBlsPublicKeys(receipts []*types.Receipt)
+	PurgeCaches()
+	Pin(number *big.Int, hash common.Hash) ContractInteraction
 }
 
 // ContractIntegrator is a contract facing to interact with smart contract that supports DPoS
 type ContractIntegrator struct {
-	chainId             *big.Int
-	signer              types.Signer
-	roninValidatorSetSC *roninValidatorSet.RoninValidatorSet
-	slashIndicatorSC    *slashIndicator.SlashIndicator
-	profileSC           *profile.Profile
-	finalityTrackingSC  *finalityTracking.FinalityTracking
-	signTxFn            SignerTxFn
-	coinbase            common.Address
+	chainId   *big.Int
+	signer    types.Signer
+	contracts map[uint64]*systemContracts // Bindings of the system contracts keyed by ABI version
+	signTxFn  SignerTxFn
+	coinbase  common.Address
+	config    *chainParams.ChainConfig
+	backend   bind.ContractBackend
+
+	profileAddress common.Address
+	blsPublicKeys  *lru.Cache  // Recent BLS public keys keyed by (validator, block)
+	pinned         common.Hash // Hash of the block the reads are pinned to, empty if not pinned
 }
 
 // NewContractIntegrator creates new ContractIntegrator with custom backend and signTxFn
 func NewContractIntegrator(config *chainParams.ChainConfig, backend bind.ContractBackend, signTxFn SignerTxFn, coinbase common.Address) (*ContractIntegrator, error) {
-	// Create Ronin Validator Set smart contract
-	roninValidatorSetSC, err := roninValidatorSet.NewRoninValidatorSet(config.ConsortiumV2Contracts.RoninValidatorSet, backend)
+	// Create the bindings of the system contracts versions used by the chain
+	contracts, err := newSystemContracts(config, backend)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create Slash Indicator smart contract
-	slashIndicatorSC, err := slashIndicator.NewSlashIndicator(config.ConsortiumV2Contracts.SlashIndicator, backend)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create Profile contract instance
-	profileSC, err := profile.NewProfile(config.ConsortiumV2Contracts.ProfileContract, backend)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create Finality Tracking contract instance
-	finalityTrackingSC, err := finalityTracking.NewFinalityTracking(config.ConsortiumV2Contracts.FinalityTracking, backend)
-	if err != nil {
-		return nil, err
-	}
+	blsPublicKeys, _ := lru.New(blsPublicKeyCacheSize)
 
 	return &ContractIntegrator{
-		chainId:             config.ChainID,
-		roninValidatorSetSC: roninValidatorSetSC,
-		slashIndicatorSC:    slashIndicatorSC,
-		profileSC:           profileSC,
-		finalityTrackingSC:  finalityTrackingSC,
-		signTxFn:            signTxFn,
-		signer:              types.LatestSignerForChainID(config.ChainID),
-		coinbase:            coinbase,
+		chainId:        config.ChainID,
+		contracts:      contracts,
+		signTxFn:       signTxFn,
+		signer:         types.LatestSignerForChainID(config.ChainID),
+		coinbase:       coinbase,
+		config:         config,
+		backend:        backend,
+		profileAddress: config.ConsortiumV2Contracts.ProfileContract,
+		blsPublicKeys:  blsPublicKeys,
 	}, nil
 }
 
+// contractsAt returns the bindings of the system contracts version at the block,
+// the nil block number is the current head of the backend as for the reads
+func (c *ContractIntegrator) contractsAt(blockNumber *big.Int) (*systemContracts, error) {
+	if c.config.Consortium == nil {
+		return c.contracts[chainParams.DefaultSystemContractsVersion], nil
+	}
+	if blockNumber == nil {
+		head, err := c.backend.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if head == nil {
+			return nil, errors.New("head block not found")
+		}
+		blockNumber = head.Number
+	}
+	return c.contracts[c.config.Consortium.SystemContractsVersionAt(blockNumber)], nil
+}
+
 // GetValidators retrieves top validators addresses
 func (c *ContractIntegrator) GetValidators(blockNumber *big.Int) ([]common.Address, error) {
 	callOpts := bind.CallOpts{
 		BlockNumber: blockNumber,
 	}
-	addresses, err := c.roninValidatorSetSC.GetBlockProducers(&callOpts)
+	contracts, err := c.contractsAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := contracts.validatorSet.GetBlockProducers(&callOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +199,11 @@ func (c *ContractIntegrator) GetValidators(blockNumber *big.Int) ([]common.Addre
 // WrapUpEpoch distributes rewards to validators and updates validators set
 func (c *ContractIntegrator) WrapUpEpoch(opts *ApplyTransactOpts) error {
 	nonce := opts.State.GetNonce(c.coinbase)
-	tx, err := c.roninValidatorSetSC.WrapUpEpoch(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn))
+	contracts, err := c.contractsAt(opts.Header.Number)
+	if err != nil {
+		return err
+	}
+	tx, err := contracts.validatorSet.WrapUpEpoch(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn))
 	if err != nil {
 		return err
 	}
@@ -163,7 +238,11 @@ func (c *ContractIntegrator) SubmitBlockReward(opts *ApplyTransactOpts) error {
 	opts.State.AddBalance(coinbase, balance)
 
 	nonce := opts.State.GetNonce(c.coinbase)
-	tx, err := c.roninValidatorSetSC.SubmitBlockReward(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn))
+	contracts, err := c.contractsAt(opts.Header.Number)
+	if err != nil {
+		return err
+	}
+	tx, err := contracts.validatorSet.SubmitBlockReward(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn))
 	if err != nil {
 		return err
 	}
@@ -195,7 +274,11 @@ func (c *ContractIntegrator) SubmitBlockReward(opts *ApplyTransactOpts) error {
 // and calls the slash method corresponding
 func (c *ContractIntegrator) Slash(opts *ApplyTransactOpts, spoiledValidator common.Address) error {
 	nonce := opts.State.GetNonce(c.coinbase)
-	tx, err := c.slashIndicatorSC.SlashUnavailability(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn), spoiledValidator)
+	contracts, err := c.contractsAt(opts.Header.Number)
+	if err != nil {
+		return err
+	}
+	tx, err := contracts.slashIndicator.SlashUnavailability(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn), spoiledValidator)
 	if err != nil {
 		return err
 	}
@@ -223,7 +306,47 @@ func (c *ContractIntegrator) Slash(opts *ApplyTransactOpts, spoiledValidator com
 
 func (c *ContractIntegrator) FinalityReward(opts *ApplyTransactOpts, votedValidators []common.Address) error {
 	nonce := opts.State.GetNonce(c.coinbase)
-	tx, err := c.finalityTrackingSC.RecordFinality(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn), votedValidators)
+	contracts, err := c.contractsAt(opts.Header.Number)
+	if err != nil {
+		return err
+	}
+	tx, err := contracts.finalityTracking.RecordFinality(getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn), votedValidators)
+	if err != nil {
+		return err
+	}
+
+	msg := types.NewMessage(
+		opts.Header.Coinbase,
+		tx.To(),
+		opts.State.GetNonce(opts.Header.Coinbase),
+		tx.Value(),
+		tx.Gas(),
+		big.NewInt(0),
+		big.NewInt(0),
+		big.NewInt(0),
+		tx.Data(),
+		tx.AccessList(),
+		false,
+	)
+
+	if err = ApplyTransaction(msg, opts); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SlashDoubleSign submits a transaction to the SlashIndicatorSC that reports the two
+// conflicting headers sealed by the same validator at the same height
+func (c *ContractIntegrator) SlashDoubleSign(opts *ApplyTransactOpts, evidence *DoubleSignEvidence) error {
+	nonce := opts.State.GetNonce(c.coinbase)
+	transactOpts := getTransactionOpts(c.coinbase, nonce, c.chainId, c.signTxFn)
+	transactOpts.GasLimit = evidence.GasLimit
+	contracts, err := c.contractsAt(opts.Header.Number)
+	if err != nil {
+		return err
+	}
+	tx, err := contracts.slashIndicator.SlashDoubleSign(transactOpts, evidence.Validator, evidence.Header1, evidence.Header2)
 	if err != nil {
 		return err
 	}
@@ -246,31 +369,172 @@ func (c *ContractIntegrator) FinalityReward(opts *ApplyTransactOpts, votedValida
 		return err
 	}
 
+	log.Info("Reported double sign", "block", opts.Header.Number, "validator", evidence.Validator)
 	return nil
 }
 
+// NewDoubleSignEvidence encodes the conflicting headers into a DoubleSignEvidence
+// which is reported with the given gas limit
+func NewDoubleSignEvidence(header1, header2 *types.Header, chainId *big.Int, gasLimit uint64) (*DoubleSignEvidence, error) {
+	if header1.Number.Cmp(header2.Number) != 0 || header1.Coinbase != header2.Coinbase || header1.Hash() == header2.Hash() {
+		return nil, errors.New("headers are not a double sign proof")
+	}
+	encodedHeader1, err := vm.EncodeDoubleSignHeader(header1, chainId)
+	if err != nil {
+		return nil, err
+	}
+	encodedHeader2, err := vm.EncodeDoubleSignHeader(header2, chainId)
+	if err != nil {
+		return nil, err
+	}
+	return &DoubleSignEvidence{
+		Validator: header1.Coinbase,
+		Header1:   encodedHeader1,
+		Header2:   encodedHeader2,
+		GasLimit:  gasLimit,
+	}, nil
+}
+
+// DecodeDoubleSignEvidence extracts the DoubleSignEvidence from a slashDoubleSign
+// transaction, it returns false if the transaction is not a slashDoubleSign call
+func DecodeDoubleSignEvidence(config *chainParams.ChainConfig, tx *types.Transaction) (*DoubleSignEvidence, bool) {
+	if tx.To() == nil || *tx.To() != config.ConsortiumV2Contracts.SlashIndicator || len(tx.Data()) < 4 {
+		return nil, false
+	}
+	slashIndicatorABI, err := slashIndicator.SlashIndicatorMetaData.GetAbi()
+	if err != nil {
+		return nil, false
+	}
+	method, err := slashIndicatorABI.MethodById(tx.Data()[:4])
+	if err != nil || method.Name != "slashDoubleSign" {
+		return nil, false
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil || len(args) != 3 {
+		return nil, false
+	}
+	validator, ok1 := args[0].(common.Address)
+	header1, ok2 := args[1].([]byte)
+	header2, ok3 := args[2].([]byte)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, false
+	}
+	return &DoubleSignEvidence{
+		Validator: validator,
+		Header1:   header1,
+		Header2:   header2,
+		GasLimit:  tx.Gas(),
+	}, true
+}
+
+// GetBlsPublicKey returns the BLS public key of the validator at the block. The
+// keys are cached per block, the cached keys of a validator are dropped when its
+// key changes (see InvalidateBlsPublicKeys).
 func (c *ContractIntegrator) GetBlsPublicKey(blockNumber *big.Int, validator common.Address) (blsCommon.PublicKey, error) {
+	if blockNumber == nil {
+		return nil, errors.New("missing block number")
+	}
+	key := blsPublicKeyCacheKey{validator: validator, number: blockNumber.Uint64(), hash: c.pinned}
+	if cached, ok := c.blsPublicKeys.Get(key); ok {
+		return cached.(blsCommon.PublicKey), nil
+	}
 	callOpts := bind.CallOpts{
 		BlockNumber: blockNumber,
 	}
-	validatorProfile, err := c.profileSC.GetId2Profile(&callOpts, validator)
+	contracts, err := c.contractsAt(blockNumber)
 	if err != nil {
 		return nil, err
 	}
-	blsPublicKey, err := blst.PublicKeyFromBytes(validatorProfile.Pubkey)
+	pubkey, err := contracts.profile.BlsPublicKey(&callOpts, validator)
 	if err != nil {
 		return nil, err
 	}
+	blsPublicKey, err := blst.PublicKeyFromBytes(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	c.blsPublicKeys.Add(key, blsPublicKey)
 
 	return blsPublicKey, nil
 }
 
+// GetGasLimitTarget reads the target block gas limit from the gas limit
+// governance contract at the block number
+func (c *ContractIntegrator) GetGasLimitTarget(blockNumber *big.Int) (uint64, error) {
+	if c.config.Consortium == nil || c.config.Consortium.GasLimitContract == (common.Address{}) {
+		return 0, ErrNoGasLimitContract
+	}
+	contract := c.config.Consortium.GasLimitContract
+	output, err := c.backend.CallContract(context.Background(), ethereum.CallMsg{To: &contract, Data: gasLimitSelector}, blockNumber)
+	if err != nil {
+		return 0, err
+	}
+	if len(output) != common.HashLength {
+		return 0, fmt.Errorf("invalid gas limit output length %d", len(output))
+	}
+	limit := new(big.Int).SetBytes(output)
+	if !limit.IsUint64() {
+		return 0, fmt.Errorf("gas limit %v overflows", limit)
+	}
+	return limit.Uint64(), nil
+}
+
+// InvalidateBlsPublicKeys drops the cached BLS public keys of the validators
+// whose key is changed by the profile contract in the receipts
+func (c *ContractIntegrator) InvalidateBlsPublicKeys(receipts []*types.Receipt) {
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			if l.Address != c.profileAddress || len(l.Topics) < 2 || l.Topics[0] != pubkeyChangedTopic {
+				continue
+			}
+			validator := common.BytesToAddress(l.Topics[1].Bytes())
+			for _, key := range c.blsPublicKeys.Keys() {
+				if key.(blsPublicKeyCacheKey).validator == validator {
+					c.blsPublicKeys.Remove(key)
+				}
+			}
+			log.Debug("Invalidated cached BLS public key", "validator", validator, "block", l.BlockNumber)
+		}
+	}
+}
+
+// PurgeCaches drops all the cached BLS public keys, so that they are read from
+// the contracts again
+func (c *ContractIntegrator) PurgeCaches() {
+	c.blsPublicKeys.Purge()
+}
+
+// Pin returns a contract integrator whose reads at the block number are served
+// from the state of the block hash, so that the reads of one logical operation
+// (e.g. collecting the validators and their BLS public keys of an epoch) are
+// consistent even if the head advances or reorgs in the middle of it. The
+// caches are shared with the unpinned contract integrator.
+func (c *ContractIntegrator) Pin(number *big.Int, hash common.Hash) ContractInteraction {
+	backend, ok := c.backend.(*ConsortiumBackend)
+	if !ok {
+		return c
+	}
+	pinned, err := NewContractIntegrator(c.config, backend.Pin(number, hash), c.signTxFn, c.coinbase)
+	if err != nil {
+		log.Warn("Failed to pin contract integrator", "number", number, "hash", hash, "err", err)
+		return c
+	}
+	pinned.blsPublicKeys = c.blsPublicKeys
+	pinned.pinned = hash
+	return pinned
+}
+
 // ApplyMessageOpts is the collection of options to fine tune a contract call request.
 type ApplyMessageOpts struct {
 	State       *state.StateDB
 	Header      *types.Header
 	ChainConfig *chainParams.ChainConfig
 	EVMContext  *vm.BlockContext
+
+	// The system transactions applied with the same options share the signer and
+	// the EVM instance, they are created when the first transaction is applied
+	signer types.Signer
+	evm    *vm.EVM
 }
 
 // ApplyTransactOpts is the collection of authorization data required to create a
@@ -369,17 +633,44 @@ func ApplyTransaction(msg types.Message, opts *ApplyTransactOpts) (err error) {
 	return nil
 }
 
-// applyMessage creates new evm and applies a transaction to the current state
+// applyMessage applies a transaction to the current state, the evm is created on
+// the first call and reused by the next calls with the same opts
 func applyMessage(
 	opts *ApplyMessageOpts,
 	tx *types.Transaction,
 ) (uint64, error) {
 	// Create a new context to be used in the EVM environment
 	opts.EVMContext.CurrentTransaction = tx
-	from, _ := types.Sender(types.MakeSigner(opts.ChainConfig, opts.Header.Number), tx)
-	// Create a new environment which holds all relevant information
-	// about the transaction and calling mechanisms.
-	vmenv := vm.NewEVM(*opts.EVMContext, vm.TxContext{Origin: from, GasPrice: big.NewInt(0)}, opts.State, opts.ChainConfig, vm.Config{})
+	if opts.signer == nil {
+		opts.signer = types.MakeSigner(opts.ChainConfig, opts.Header.Number)
+	}
+	from, _ := types.Sender(opts.signer, tx)
+	txContext := vm.TxContext{Origin: from, GasPrice: big.NewInt(0)}
+	if opts.evm == nil {
+		// Create a new environment which holds all relevant information
+		// about the transaction and calling mechanisms.
+		opts.evm = vm.NewEVM(*opts.EVMContext, txContext, opts.State, opts.ChainConfig, vm.Config{})
+	} else {
+		opts.evm.Reset(txContext, opts.State)
+		opts.evm.Context.CurrentTransaction = tx
+		opts.evm.Context.Counter = 0
+	}
+	vmenv := opts.evm
+
+	// Prefetch the state accessed by the last call of the method and record the
+	// state accessed by this one, unless the whole block is being recorded
+	key := newSystemCallKey(tx)
+	if list, ok := systemAccessLists.Get(key); ok {
+		opts.State.Prefetch(list.(types.AccessList))
+	}
+	if opts.State.AccessSet() == nil {
+		access := state.NewAccessSet()
+		opts.State.SetAccessSet(access)
+		defer func() {
+			opts.State.SetAccessSet(nil)
+			systemAccessLists.Add(key, access.AccessList())
+		}()
+	}
 	// Apply the transaction to the current State (included in the env)
 	ret, returnGas, err := vmenv.Call(
 		vm.AccountRef(from),
@@ -409,11 +700,10 @@ func NewConsortiumBackend(ee *ethapi.PublicBlockChainAPI) *ConsortiumBackend {
 // CodeAt returns the code of the given account. This is needed to differentiate
 // between contract internal errors and the local chain being out of sync.
 func (b *ConsortiumBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
-	blkNumber := rpc.LatestBlockNumber
-	if blockNumber != nil {
-		blkNumber = rpc.BlockNumber(blockNumber.Int64())
-	}
-	block := rpc.BlockNumberOrHashWithNumber(blkNumber)
+	return b.codeAt(ctx, contract, blockNumberOrLatest(blockNumber))
+}
+
+func (b *ConsortiumBackend) codeAt(ctx context.Context, contract common.Address, block rpc.BlockNumberOrHash) ([]byte, error) {
 	result, err := b.GetCode(ctx, contract, block)
 	if err != nil {
 		return nil, err
@@ -425,11 +715,10 @@ func (b *ConsortiumBackend) CodeAt(ctx context.Context, contract common.Address,
 // CallContract executes an Ethereum contract call with the specified data as the
 // input.
 func (b *ConsortiumBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
-	blkNumber := rpc.LatestBlockNumber
-	if blockNumber != nil {
-		blkNumber = rpc.BlockNumber(blockNumber.Int64())
-	}
-	block := rpc.BlockNumberOrHashWithNumber(blkNumber)
+	return b.callContract(ctx, call, blockNumberOrLatest(blockNumber))
+}
+
+func (b *ConsortiumBackend) callContract(ctx context.Context, call ethereum.CallMsg, block rpc.BlockNumberOrHash) ([]byte, error) {
 	gas := (hexutil.Uint64)(uint64(math.MaxUint64 / 2))
 	data := (hexutil.Bytes)(call.Data)
 
@@ -445,10 +734,24 @@ func (b *ConsortiumBackend) CallContract(ctx context.Context, call ethereum.Call
 	return result, nil
 }
 
+// blockNumberOrLatest returns the block at the number, or the latest block if
+// the number is nil
+func blockNumberOrLatest(blockNumber *big.Int) rpc.BlockNumberOrHash {
+	blkNumber := rpc.LatestBlockNumber
+	if blockNumber != nil {
+		blkNumber = rpc.BlockNumber(blockNumber.Int64())
+	}
+	return rpc.BlockNumberOrHashWithNumber(blkNumber)
+}
+
 // HeaderByNumber returns a block header from the current canonical chain. If
 // number is nil, the latest known header is returned.
 func (b *ConsortiumBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
-	return b.GetHeader(ctx, rpc.BlockNumber(number.Int64()))
+	blockNumber := rpc.LatestBlockNumber
+	if number != nil {
+		blockNumber = rpc.BlockNumber(number.Int64())
+	}
+	return b.GetHeader(ctx, blockNumber)
 }
 
 // PendingCodeAt returns the code of the given account in the pending state.
@@ -504,3 +807,129 @@ func (b *ConsortiumBackend) FilterLogs(ctx context.Context, query ethereum.Filte
 func (b *ConsortiumBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	return nil, errMethodUnimplemented
 }
+
+// Pin returns a backend whose contract reads are pinned to the block
+func (b *ConsortiumBackend) Pin(number *big.Int, hash common.Hash) *PinnedBackend {
+	return &PinnedBackend{
+		ConsortiumBackend: b,
+		number:            number.Uint64(),
+		hash:              hash,
+	}
+}
+
+// PinnedBackend is a ConsortiumBackend whose contract reads are served from the
+// state of a block looked up by hash rather than by number, so the reads are
+// not torn when the canonical block at the number changes. Reads at other
+// blocks are rejected.
+type PinnedBackend struct {
+	*ConsortiumBackend
+
+	number uint64
+	hash   common.Hash
+}
+
+// block returns the pinned block if the read is at the pinned block number or
+// at the latest block
+func (b *PinnedBackend) block(blockNumber *big.Int) (rpc.BlockNumberOrHash, error) {
+	if blockNumber != nil && (!blockNumber.IsUint64() || blockNumber.Uint64() != b.number) {
+		return rpc.BlockNumberOrHash{}, fmt.Errorf("%w: pinned %d, read at %d", errUnpinnedBlock, b.number, blockNumber)
+	}
+	return rpc.BlockNumberOrHashWithHash(b.hash, false), nil
+}
+
+// HeaderByNumber returns the header at the number, the header at the pinned
+// block number if number is nil.
+func (b *PinnedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil {
+		number = new(big.Int).SetUint64(b.number)
+	}
+	return b.ConsortiumBackend.HeaderByNumber(ctx, number)
+}
+
+// CodeAt returns the code of the given account at the pinned block.
+func (b *PinnedBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	block, err := b.block(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return b.codeAt(ctx, contract, block)
+}
+
+// CallContract executes an Ethereum contract call at the pinned block.
+func (b *PinnedBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	block, err := b.block(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return b.callContract(ctx, call, block)
+}
+
+// StateBackend is a ConsortiumBackend whose contract reads are served from a
+// state which is not in the chain, e.g. the state of a simulated block. The
+// reads run on a copy of the state so they do not change it, reads at other
+// blocks are rejected.
+type StateBackend struct {
+	bind.ContractBackend
+
+	config  *chainParams.ChainConfig
+	context vm.BlockContext
+	state   *state.StateDB
+}
+
+// NewStateBackend creates a backend reading the contracts from the state of the
+// block in the context, the other methods are served by the backend
+func NewStateBackend(backend bind.ContractBackend, config *chainParams.ChainConfig, context vm.BlockContext, statedb *state.StateDB) *StateBackend {
+	return &StateBackend{
+		ContractBackend: backend,
+		config:          config,
+		context:         context,
+		state:           statedb,
+	}
+}
+
+// checkBlock returns an error if the read is neither at the block of the state
+// nor at the latest block
+func (b *StateBackend) checkBlock(blockNumber *big.Int) error {
+	if blockNumber != nil && blockNumber.Cmp(b.context.BlockNumber) != 0 {
+		return fmt.Errorf("%w: pinned %d, read at %d", errUnpinnedBlock, b.context.BlockNumber, blockNumber)
+	}
+	return nil
+}
+
+// HeaderByNumber returns the header of the block of the state, built from the
+// block context, if number is nil or its number. The other headers are served
+// by the backend.
+func (b *StateBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number != nil && number.Cmp(b.context.BlockNumber) != 0 {
+		return b.ContractBackend.HeaderByNumber(ctx, number)
+	}
+	return &types.Header{
+		Coinbase:   b.context.Coinbase,
+		Difficulty: b.context.Difficulty,
+		Number:     new(big.Int).Set(b.context.BlockNumber),
+		GasLimit:   b.context.GasLimit,
+		Time:       b.context.Time,
+		BaseFee:    b.context.BaseFee,
+	}, nil
+}
+
+// CodeAt returns the code of the given account in the state.
+func (b *StateBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if err := b.checkBlock(blockNumber); err != nil {
+		return nil, err
+	}
+	return b.state.GetCode(contract), nil
+}
+
+// CallContract executes an Ethereum contract call on a copy of the state.
+func (b *StateBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := b.checkBlock(blockNumber); err != nil {
+		return nil, err
+	}
+	if call.To == nil {
+		return nil, errors.New("missing contract address")
+	}
+	evm := vm.NewEVM(b.context, vm.TxContext{Origin: call.From, GasPrice: big.NewInt(0)}, b.state.Copy(), b.config, vm.Config{})
+	result, _, err := evm.StaticCall(vm.AccountRef(call.From), *call.To, call.Data, uint64(math.MaxUint64/2))
+	return result, err
+}
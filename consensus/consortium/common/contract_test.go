@@ -0,0 +1,365 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	slashIndicator "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/slash_indicator"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	chainParams "github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+func TestDoubleSignEvidence(t *testing.T) {
+	chainId := big.NewInt(2021)
+	config := &chainParams.ChainConfig{
+		ChainID: chainId,
+		ConsortiumV2Contracts: &chainParams.ConsortiumV2Contracts{
+			SlashIndicator: common.HexToAddress("0x1"),
+		},
+	}
+	header1 := &types.Header{
+		Coinbase:   common.HexToAddress("0x2"),
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(7),
+		Extra:      make([]byte, 97),
+	}
+	header2 := types.CopyHeader(header1)
+	header2.Time = 1
+
+	if _, err := NewDoubleSignEvidence(header1, header1, chainId, 100000); err == nil {
+		t.Fatalf("Expect error when creating evidence from the same header")
+	}
+	evidence, err := NewDoubleSignEvidence(header1, header2, chainId, 100000)
+	if err != nil {
+		t.Fatalf("Failed to create double sign evidence, err %s", err)
+	}
+
+	slashIndicatorABI, err := slashIndicator.SlashIndicatorMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to load slash indicator abi, err %s", err)
+	}
+	data, err := slashIndicatorABI.Pack("slashDoubleSign", evidence.Validator, evidence.Header1, evidence.Header2)
+	if err != nil {
+		t.Fatalf("Failed to pack slashDoubleSign, err %s", err)
+	}
+	tx := types.NewTransaction(0, config.ConsortiumV2Contracts.SlashIndicator, common.Big0, evidence.GasLimit, common.Big0, data)
+	decoded, ok := DecodeDoubleSignEvidence(config, tx)
+	if !ok {
+		t.Fatalf("Failed to decode double sign evidence")
+	}
+	if decoded.Validator != evidence.Validator || decoded.GasLimit != evidence.GasLimit ||
+		!bytes.Equal(decoded.Header1, evidence.Header1) || !bytes.Equal(decoded.Header2, evidence.Header2) {
+		t.Fatalf("Evidence mismatch, exp %+v got %+v", evidence, decoded)
+	}
+
+	tx = types.NewTransaction(0, common.HexToAddress("0x3"), common.Big0, evidence.GasLimit, common.Big0, data)
+	if _, ok := DecodeDoubleSignEvidence(config, tx); ok {
+		t.Fatalf("Expect no evidence from the transaction to other contract")
+	}
+}
+
+// systemTxsPerBlock is the number of system transactions in an epoch block:
+// FinalityReward, Slash, SubmitBlockReward and WrapUpEpoch
+const systemTxsPerBlock = 4
+
+func TestInvalidateBlsPublicKeys(t *testing.T) {
+	profileAddress := common.HexToAddress("0x1")
+	validator1, validator2 := common.HexToAddress("0x2"), common.HexToAddress("0x3")
+	blsPublicKeys, _ := lru.New(blsPublicKeyCacheSize)
+	contract := &ContractIntegrator{
+		profileAddress: profileAddress,
+		blsPublicKeys:  blsPublicKeys,
+	}
+	for number := uint64(0); number < 2; number++ {
+		blsPublicKeys.Add(blsPublicKeyCacheKey{validator: validator1, number: number}, nil)
+		blsPublicKeys.Add(blsPublicKeyCacheKey{validator: validator2, number: number}, nil)
+	}
+
+	// Logs from other contracts or with other topics are ignored
+	contract.InvalidateBlsPublicKeys([]*types.Receipt{{Logs: []*types.Log{
+		{Address: common.HexToAddress("0x4"), Topics: []common.Hash{pubkeyChangedTopic, validator1.Hash()}},
+		{Address: profileAddress, Topics: []common.Hash{crypto.Keccak256Hash([]byte("Other()")), validator1.Hash()}},
+	}}})
+	if blsPublicKeys.Len() != 4 {
+		t.Fatalf("Expect 4 cached keys, got %d", blsPublicKeys.Len())
+	}
+
+	contract.InvalidateBlsPublicKeys([]*types.Receipt{{Logs: []*types.Log{
+		{Address: profileAddress, Topics: []common.Hash{pubkeyChangedTopic, validator1.Hash()}},
+	}}})
+	if blsPublicKeys.Len() != 2 {
+		t.Fatalf("Expect 2 cached keys, got %d", blsPublicKeys.Len())
+	}
+	for number := uint64(0); number < 2; number++ {
+		if blsPublicKeys.Contains(blsPublicKeyCacheKey{validator: validator1, number: number}) {
+			t.Fatalf("Expect the key of validator1 at block %d to be invalidated", number)
+		}
+		if !blsPublicKeys.Contains(blsPublicKeyCacheKey{validator: validator2, number: number}) {
+			t.Fatalf("Expect the key of validator2 at block %d to be kept", number)
+		}
+	}
+}
+
+func TestPubkeyChangedTopic(t *testing.T) {
+	if want := crypto.Keccak256Hash([]byte("PubkeyChanged(address,bytes)")); pubkeyChangedTopic != want {
+		t.Fatalf("Expect topic %s, got %s", want, pubkeyChangedTopic)
+	}
+}
+
+func TestPinnedBackendBlock(t *testing.T) {
+	hash := common.HexToHash("0x1")
+	backend := (&ConsortiumBackend{}).Pin(big.NewInt(10), hash)
+
+	for _, number := range []*big.Int{nil, big.NewInt(10)} {
+		block, err := backend.block(number)
+		if err != nil {
+			t.Fatalf("Failed to resolve pinned block at %v, err %s", number, err)
+		}
+		if blockHash, ok := block.Hash(); !ok || blockHash != hash || block.RequireCanonical {
+			t.Fatalf("Expect read at %v to be pinned to %s, got %+v", number, hash, block)
+		}
+	}
+	for _, number := range []*big.Int{big.NewInt(9), big.NewInt(-1)} {
+		if _, err := backend.block(number); !errors.Is(err, errUnpinnedBlock) {
+			t.Fatalf("Expect read at %v to be rejected, got %v", number, err)
+		}
+	}
+}
+
+func TestSystemContractsVersions(t *testing.T) {
+	versionTwo := &systemContracts{}
+	systemContractsVersions[2] = func(*chainParams.ConsortiumV2Contracts, bind.ContractBackend) (*systemContracts, error) {
+		return versionTwo, nil
+	}
+	defer delete(systemContractsVersions, 2)
+
+	config := &chainParams.ChainConfig{
+		ChainID:               big.NewInt(2021),
+		Consortium:            &chainParams.ConsortiumConfig{},
+		ConsortiumV2Contracts: &chainParams.ConsortiumV2Contracts{},
+	}
+	config.Consortium.SystemContractsVersions = []chainParams.SystemContractsVersion{{Block: big.NewInt(10), Version: 2}}
+	contract, err := NewContractIntegrator(config, nil, nil, common.Address{})
+	if err != nil {
+		t.Fatalf("Failed to create contract integrator, err %s", err)
+	}
+	if len(contract.contracts) != 2 {
+		t.Fatalf("Expect bindings of 2 versions, got %d", len(contract.contracts))
+	}
+	if bindings, _ := contract.contractsAt(big.NewInt(9)); bindings != contract.contracts[chainParams.DefaultSystemContractsVersion] || bindings.validatorSet == nil {
+		t.Fatalf("Expect the default bindings before the switch")
+	}
+	if bindings, _ := contract.contractsAt(big.NewInt(10)); bindings != versionTwo {
+		t.Fatalf("Expect the bindings of version 2 from the switch")
+	}
+
+	// The nil block number is the head of the backend
+	for number, version := range map[int64]uint64{9: chainParams.DefaultSystemContractsVersion, 10: 2} {
+		backend := NewStateBackend(nil, config, vm.BlockContext{BlockNumber: big.NewInt(number)}, nil)
+		contract, err := NewContractIntegrator(config, backend, nil, common.Address{})
+		if err != nil {
+			t.Fatalf("Failed to create contract integrator, err %s", err)
+		}
+		if bindings, err := contract.contractsAt(nil); err != nil || bindings != contract.contracts[version] {
+			t.Fatalf("Expect the bindings at the head %d, err %v", number, err)
+		}
+	}
+
+	config.Consortium.SystemContractsVersions = []chainParams.SystemContractsVersion{{Block: big.NewInt(10), Version: 3}}
+	if _, err := NewContractIntegrator(config, nil, nil, common.Address{}); err == nil {
+		t.Fatalf("Expect error when the version has no bindings")
+	}
+}
+
+func BenchmarkApplySystemTransactions(b *testing.B) {
+	b.Run("SharedEVM", func(b *testing.B) { benchmarkApplySystemTransactions(b, true) })
+	b.Run("NewEVM", func(b *testing.B) { benchmarkApplySystemTransactions(b, false) })
+}
+
+func benchmarkApplySystemTransactions(b *testing.B, shareEVM bool) {
+	key, _ := crypto.GenerateKey()
+	coinbase := crypto.PubkeyToAddress(key.PublicKey)
+	chainConfig := chainParams.TestChainConfig
+	signer := types.NewEIP155Signer(chainConfig.ChainID)
+	signTxFn := func(_ accounts.Account, tx *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+		return types.SignTx(tx, signer, key)
+	}
+
+	// The contract increases the storage slot 0 on every call
+	contract := common.HexToAddress("0xaa")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, common.FromHex("0x600160005401600055"))
+
+	header := &types.Header{
+		Coinbase:   coinbase,
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(7),
+		GasLimit:   100000000,
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var (
+			txs         []*types.Transaction
+			receipts    []*types.Receipt
+			usedGas     uint64
+			internalTxs []*types.InternalTransaction
+		)
+		evmContext := vm.BlockContext{
+			CanTransfer:          core.CanTransfer,
+			Transfer:             core.Transfer,
+			Coinbase:             coinbase,
+			BlockNumber:          header.Number,
+			Difficulty:           header.Difficulty,
+			GasLimit:             header.GasLimit,
+			InternalTransactions: &internalTxs,
+		}
+		opts := &ApplyTransactOpts{
+			ApplyMessageOpts: &ApplyMessageOpts{
+				State:       statedb,
+				Header:      header,
+				ChainConfig: chainConfig,
+				EVMContext:  &evmContext,
+			},
+			Txs:      &txs,
+			Receipts: &receipts,
+			UsedGas:  &usedGas,
+			Mining:   true,
+			Signer:   signer,
+			SignTxFn: signTxFn,
+		}
+		for j := 0; j < systemTxsPerBlock; j++ {
+			if !shareEVM {
+				opts.evm = nil
+			}
+			msg := types.NewMessage(coinbase, &contract, statedb.GetNonce(coinbase), common.Big0, 100000,
+				common.Big0, common.Big0, common.Big0, nil, nil, false)
+			if err := ApplyTransaction(msg, opts); err != nil {
+				b.Fatalf("Failed to apply system transaction, err %s", err)
+			}
+		}
+	}
+}
+
+func TestSystemAccessList(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	coinbase := crypto.PubkeyToAddress(key.PublicKey)
+	chainConfig := chainParams.TestChainConfig
+	signer := types.NewEIP155Signer(chainConfig.ChainID)
+	signTxFn := func(_ accounts.Account, tx *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+		return types.SignTx(tx, signer, key)
+	}
+
+	// The contract increases the storage slot 0 on every call
+	contract := common.HexToAddress("0xab")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, common.FromHex("0x600160005401600055"))
+
+	var (
+		txs         []*types.Transaction
+		receipts    []*types.Receipt
+		usedGas     uint64
+		internalTxs []*types.InternalTransaction
+		header      = &types.Header{Coinbase: coinbase, Number: big.NewInt(1), Difficulty: big.NewInt(7), GasLimit: 100000000}
+	)
+	opts := &ApplyTransactOpts{
+		ApplyMessageOpts: &ApplyMessageOpts{
+			State:       statedb,
+			Header:      header,
+			ChainConfig: chainConfig,
+			EVMContext: &vm.BlockContext{
+				CanTransfer:          core.CanTransfer,
+				Transfer:             core.Transfer,
+				Coinbase:             coinbase,
+				BlockNumber:          header.Number,
+				Difficulty:           header.Difficulty,
+				GasLimit:             header.GasLimit,
+				InternalTransactions: &internalTxs,
+			},
+		},
+		Txs:      &txs,
+		Receipts: &receipts,
+		UsedGas:  &usedGas,
+		Mining:   true,
+		Signer:   signer,
+		SignTxFn: signTxFn,
+	}
+	msg := types.NewMessage(coinbase, &contract, 0, common.Big0, 100000, common.Big0, common.Big0, common.Big0, nil, nil, false)
+	if err := ApplyTransaction(msg, opts); err != nil {
+		t.Fatalf("Failed to apply system transaction, err %s", err)
+	}
+	if statedb.AccessSet() != nil {
+		t.Fatalf("The access recording is left enabled")
+	}
+
+	// The slot accessed by the call is recorded for the next call of the method
+	list, ok := systemAccessLists.Get(newSystemCallKey(txs[0]))
+	if !ok {
+		t.Fatalf("Missing access list of the system call")
+	}
+	var slots []common.Hash
+	for _, tuple := range list.(types.AccessList) {
+		if tuple.Address == contract {
+			slots = tuple.StorageKeys
+		}
+	}
+	if len(slots) != 1 || slots[0] != (common.Hash{}) {
+		t.Fatalf("Access list mismatch, expect slot 0 of %s, got %+v", contract, list)
+	}
+
+	// The recording of the whole block is not interrupted
+	access := state.NewAccessSet()
+	statedb.SetAccessSet(access)
+	msg = types.NewMessage(coinbase, &contract, 1, common.Big0, 100000, common.Big0, common.Big0, common.Big0, nil, nil, false)
+	if err := ApplyTransaction(msg, opts); err != nil {
+		t.Fatalf("Failed to apply system transaction, err %s", err)
+	}
+	if statedb.AccessSet() != access || len(access.Writes) == 0 {
+		t.Fatalf("The block access recording is replaced")
+	}
+}
+
+func TestStateBackend(t *testing.T) {
+	// The contract returns the storage slot 0
+	contract := common.HexToAddress("0xab")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(contract, common.FromHex("0x60005460005260206000f3"))
+	statedb.SetState(contract, common.Hash{}, common.HexToHash("0x2a"))
+
+	blockContext := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		BlockNumber: big.NewInt(10),
+		Difficulty:  common.Big1,
+	}
+	backend := NewStateBackend(nil, chainParams.TestChainConfig, blockContext, statedb)
+	for _, number := range []*big.Int{nil, big.NewInt(10)} {
+		output, err := backend.CallContract(context.Background(), ethereum.CallMsg{To: &contract}, number)
+		if err != nil {
+			t.Fatalf("Failed to call contract at %v, err %s", number, err)
+		}
+		if common.BytesToHash(output) != common.HexToHash("0x2a") {
+			t.Fatalf("Output mismatch, exp %s got %x", common.HexToHash("0x2a"), output)
+		}
+	}
+	if _, err := backend.CallContract(context.Background(), ethereum.CallMsg{To: &contract}, big.NewInt(9)); !errors.Is(err, errUnpinnedBlock) {
+		t.Fatalf("Expect read at another block to be rejected, got %v", err)
+	}
+	code, err := backend.CodeAt(context.Background(), contract, nil)
+	if err != nil || !bytes.Equal(code, statedb.GetCode(contract)) {
+		t.Fatalf("Code mismatch, got %x err %v", code, err)
+	}
+}
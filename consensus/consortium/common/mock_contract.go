@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
 	"github.com/ethereum/go-ethereum/log"
@@ -80,3 +81,20 @@ func (contract *MockContract) FinalityReward(*ApplyTransactOpts, []common.Addres
 func (contract *MockContract) GetBlsPublicKey(_ *big.Int, addr common.Address) (blsCommon.PublicKey, error) {
 	return Validators.GetPublicKey(addr)
 }
+
+func (contract *MockContract) GetGasLimitTarget(*big.Int) (uint64, error) {
+	return 0, ErrNoGasLimitContract
+}
+
+func (contract *MockContract) InvalidateBlsPublicKeys([]*types.Receipt) {}
+
+func (contract *MockContract) PurgeCaches() {}
+
+func (contract *MockContract) Pin(*big.Int, common.Hash) ContractInteraction {
+	return contract
+}
+
+func (contract *MockContract) SlashDoubleSign(*ApplyTransactOpts, *DoubleSignEvidence) error {
+	log.Info("SlashDoubleSign")
+	return nil
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// DefaultSealersCacheSize is the default number of recent block sealers to keep
+// in memory
+const DefaultSealersCacheSize = 8192
+
+// Sealers caches the sealers recovered from the signature of the recent headers
+// by SealerKey. It is shared by the v1 and v2 engines, their snapshots, the
+// standalone verifiers and the APIs of the process, so the sealer of a header is
+// recovered once during the sync. The seal hash of a header, hence its sealer,
+// only depends on the fork at the header number and on the chain ID since v2.
+var Sealers, _ = lru.New(DefaultSealersCacheSize)
+
+// SealerKey is the key of a sealer in the Sealers cache. The v2 seal hash commits
+// to the chain ID, so the same header has another sealer on another chain of the
+// process, e.g. a test or a replayed chain.
+type SealerKey struct {
+	ChainID uint64      // Chain ID of the seal hash, zero for the v1 headers
+	Hash    common.Hash // Hash of the header
+}
+
+// NewSealerKey returns the key of the sealer of the header hash whose seal hash
+// commits to the chain ID, nil for the v1 headers
+func NewSealerKey(chainID *big.Int, hash common.Hash) SealerKey {
+	key := SealerKey{Hash: hash}
+	if chainID != nil {
+		key.ChainID = chainID.Uint64()
+	}
+	return key
+}
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	finalityTracking "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/finality_tracking"
+	"github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/profile"
+	roninValidatorSet "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/ronin_validator_set"
+	slashIndicator "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/slash_indicator"
+	"github.com/ethereum/go-ethereum/core/types"
+	chainParams "github.com/ethereum/go-ethereum/params"
+)
+
+// validatorSetContract is the part of the validator set contract called by the engine
+type validatorSetContract interface {
+	GetBlockProducers(opts *bind.CallOpts) ([]common.Address, error)
+	CurrentPeriod(opts *bind.CallOpts) (*big.Int, error)
+	WrapUpEpoch(opts *bind.TransactOpts) (*types.Transaction, error)
+	SubmitBlockReward(opts *bind.TransactOpts) (*types.Transaction, error)
+}
+
+// slashIndicatorContract is the part of the slash indicator contract called by the engine
+type slashIndicatorContract interface {
+	SlashUnavailability(opts *bind.TransactOpts, validator common.Address) (*types.Transaction, error)
+	SlashDoubleSign(opts *bind.TransactOpts, validator common.Address, header1 []byte, header2 []byte) (*types.Transaction, error)
+}
+
+// profileContract is the part of the profile contract called by the engine
+type profileContract interface {
+	// BlsPublicKey returns the encoded BLS public key of the validator
+	BlsPublicKey(opts *bind.CallOpts, validator common.Address) ([]byte, error)
+}
+
+// finalityTrackingContract is the part of the finality tracking contract called by the engine
+type finalityTrackingContract interface {
+	RecordFinality(opts *bind.TransactOpts, voters []common.Address) (*types.Transaction, error)
+}
+
+// systemContracts are the bindings of one ABI version of the system contracts
+type systemContracts struct {
+	validatorSet     validatorSetContract
+	slashIndicator   slashIndicatorContract
+	profile          profileContract
+	finalityTracking finalityTrackingContract
+}
+
+// systemContractsVersions creates the bindings of each supported ABI version. A
+// contract upgrade which changes the signature of a method called by the engine
+// adds the bindings of its version here, ahead of the upgrade, and the chains
+// switch to it at the upgrade block with params.ConsortiumConfig.SystemContractsVersions.
+var systemContractsVersions = map[uint64]func(contracts *chainParams.ConsortiumV2Contracts, backend bind.ContractBackend) (*systemContracts, error){
+	1: newSystemContractsV1,
+}
+
+// newSystemContracts creates the bindings of all the ABI versions the chain
+// config schedules, keyed by version
+func newSystemContracts(config *chainParams.ChainConfig, backend bind.ContractBackend) (map[uint64]*systemContracts, error) {
+	versions := []uint64{chainParams.DefaultSystemContractsVersion}
+	if config.Consortium != nil {
+		for _, override := range config.Consortium.SystemContractsVersions {
+			versions = append(versions, override.Version)
+		}
+	}
+	contracts := make(map[uint64]*systemContracts)
+	for _, version := range versions {
+		if _, ok := contracts[version]; ok {
+			continue
+		}
+		newContracts, ok := systemContractsVersions[version]
+		if !ok {
+			return nil, fmt.Errorf("unsupported system contracts version %d", version)
+		}
+		bindings, err := newContracts(config.ConsortiumV2Contracts, backend)
+		if err != nil {
+			return nil, err
+		}
+		contracts[version] = bindings
+	}
+	return contracts, nil
+}
+
+// newSystemContractsV1 creates the bindings of the system contracts deployed at
+// consortium v2
+func newSystemContractsV1(contracts *chainParams.ConsortiumV2Contracts, backend bind.ContractBackend) (*systemContracts, error) {
+	// Create Ronin Validator Set smart contract
+	roninValidatorSetSC, err := roninValidatorSet.NewRoninValidatorSet(contracts.RoninValidatorSet, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Slash Indicator smart contract
+	slashIndicatorSC, err := slashIndicator.NewSlashIndicator(contracts.SlashIndicator, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Profile contract instance
+	profileSC, err := profile.NewProfile(contracts.ProfileContract, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Finality Tracking contract instance
+	finalityTrackingSC, err := finalityTracking.NewFinalityTracking(contracts.FinalityTracking, backend)
+	if err != nil {
+		return nil, err
+	}
+
+	return &systemContracts{
+		validatorSet:     roninValidatorSetSC,
+		slashIndicator:   slashIndicatorSC,
+		profile:          profileV1{profileSC},
+		finalityTracking: finalityTrackingSC,
+	}, nil
+}
+
+// profileV1 reads the BLS public key from the candidate profile of version 1
+type profileV1 struct {
+	*profile.Profile
+}
+
+func (p profileV1) BlsPublicKey(opts *bind.CallOpts, validator common.Address) ([]byte, error) {
+	candidate, err := p.GetId2Profile(opts, validator)
+	if err != nil {
+		return nil, err
+	}
+	return candidate.Pubkey, nil
+}
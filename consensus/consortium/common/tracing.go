@@ -0,0 +1,33 @@
+package common
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of the consortium engine, the spans are dropped
+// unless a tracer provider is registered (see --tracing.enabled)
+var tracer = otel.Tracer("github.com/ethereum/go-ethereum/consensus/consortium")
+
+// StartSpan starts the span of the operation on the block at number. The
+// checkpoint blocks are tagged so that the epoch boundaries can be filtered.
+func StartSpan(ctx context.Context, name string, number uint64, epoch uint64, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.Int64("block.number", int64(number)),
+		attribute.Bool("block.checkpoint", epoch != 0 && number%epoch == 0),
+	)
+	return tracer.Start(ctx, "consortium."+name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends the span, recording the error if any
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -28,3 +28,13 @@ type BaseSnapshot struct {
 type ConsortiumAdapter interface {
 	GetSnapshot(chain consensus.ChainHeaderReader, number uint64, parents []*types.Header) *BaseSnapshot
 }
+
+// DoubleSignEvidence is the proof that a validator has sealed two different headers
+// at the same height. The headers are encoded in the format accepted by the double
+// sign verification precompiled contract.
+type DoubleSignEvidence struct {
+	Validator common.Address
+	Header1   []byte
+	Header2   []byte
+	GasLimit  uint64 // Gas limit of the slashing report transaction
+}
@@ -0,0 +1,173 @@
+// Package errcode defines the errors of the consortium consensus engine along
+// with stable numeric codes. The codes are sent as the data of the JSON-RPC
+// errors, so the clients can tell the failures apart without matching the
+// messages. A code is never reused nor renumbered once released.
+package errcode
+
+import "errors"
+
+// Code is the stable numeric code of a consensus error
+type Code int
+
+// Header errors, shared by consortium v1 and v2
+const (
+	UnknownBlock             Code = 1000
+	MissingVanity            Code = 1001
+	MissingSignature         Code = 1002
+	ExtraValidators          Code = 1003
+	InvalidSpanValidators    Code = 1004
+	InvalidMixDigest         Code = 1005
+	InvalidUncleHash         Code = 1006
+	InvalidDifficulty        Code = 1007
+	InvalidCheckpointSigners Code = 1008
+	RecentlySigned           Code = 1009
+	WrongDifficulty          Code = 1010
+	VanityTooLong            Code = 1011
+)
+
+// Finality errors, consortium v2 only
+const (
+	InvalidHasFinalityVote              Code = 2000
+	MissingHasFinalityVote              Code = 2001
+	MissingFinalityVoteBitSet           Code = 2002
+	MissingFinalitySignature            Code = 2003
+	NotEnoughFinalityVote               Code = 2004
+	FinalitySignatureVerificationFailed Code = 2005
+	InvalidFinalityVotedBitSet          Code = 2006
+	UnauthorizedFinalityVoter           Code = 2007
+	InvalidTargetNumber                 Code = 2008
+	InvalidVoteVersion                  Code = 2009
+	InvalidVoteChainID                  Code = 2010
+	InvalidVoteSource                   Code = 2011
+	InvalidVoteEpoch                    Code = 2012
+)
+
+var (
+	// ErrUnknownBlock is returned when the list of signers is requested for a block
+	// that is not part of the local blockchain.
+	ErrUnknownBlock = New(UnknownBlock, "unknown block")
+
+	// ErrMissingVanity is returned if a block's extra-data section is shorter than
+	// 32 bytes, which is required to store the signer vanity.
+	ErrMissingVanity = New(MissingVanity, "extra-data 32 byte vanity prefix missing")
+
+	// ErrMissingSignature is returned if a block's extra-data section doesn't seem
+	// to contain a 65 byte secp256k1 signature.
+	ErrMissingSignature = New(MissingSignature, "extra-data 65 byte signature suffix missing")
+
+	// ErrExtraValidators is returned if non-sprint-end block contain validator data in
+	// their extra-data fields.
+	ErrExtraValidators = New(ExtraValidators, "non-sprint-end block contains extra validator list")
+
+	// ErrInvalidSpanValidators is returned if a block contains an
+	// invalid list of validators (i.e. non divisible by 20 bytes).
+	ErrInvalidSpanValidators = New(InvalidSpanValidators, "invalid validator list on sprint end block")
+
+	// ErrInvalidMixDigest is returned if a block's mix digest is non-zero.
+	ErrInvalidMixDigest = New(InvalidMixDigest, "non-zero mix digest")
+
+	// ErrInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	ErrInvalidUncleHash = New(InvalidUncleHash, "non empty uncle hash")
+
+	// ErrInvalidDifficulty is returned if the difficulty of a block neither 1 or 2.
+	ErrInvalidDifficulty = New(InvalidDifficulty, "invalid difficulty")
+
+	// ErrInvalidCheckpointSigners is returned if a checkpoint block contains an
+	// invalid list of signers (i.e. non divisible by 20 bytes).
+	ErrInvalidCheckpointSigners = New(InvalidCheckpointSigners, "invalid signer list on checkpoint block")
+
+	// ErrRecentlySigned is returned if a header is signed by an authorized entity
+	// that already signed a header recently, thus is temporarily not allowed to.
+	ErrRecentlySigned = New(RecentlySigned, "signed recently, must wait for others")
+
+	// ErrWrongDifficulty is returned if the difficulty of a block doesn't match the
+	// turn of the signer.
+	ErrWrongDifficulty = New(WrongDifficulty, "wrong difficulty")
+
+	// ErrVanityTooLong is returned if the vanity content does not fit in the
+	// 32 byte vanity prefix and would overflow into the structured fields
+	ErrVanityTooLong = New(VanityTooLong, "vanity content exceeds 32 bytes")
+)
+
+var (
+	// ErrInvalidHasFinalityVote is returned if a block's extra-data contains invalid
+	// has finality vote byte
+	ErrInvalidHasFinalityVote = New(InvalidHasFinalityVote, "invalid has finality vote byte")
+
+	// ErrMissingHasFinalityVote is returned if a block's extra-data section does not seem
+	// to include 1 byte to determine if the extra data has the finality votes
+	ErrMissingHasFinalityVote = New(MissingHasFinalityVote, "extra-data 1 byte has finality votes missing")
+
+	// ErrMissingFinalityVoteBitSet is returned if a block's extra-data section does not seem
+	// to include 8 bytes of finality vote bitset
+	ErrMissingFinalityVoteBitSet = New(MissingFinalityVoteBitSet, "extra-data 8 bytes finality votes bitset missing")
+
+	// ErrMissingFinalitySignature is returned if a block's extra-data section does not seem
+	// to include finality signature
+	ErrMissingFinalitySignature = New(MissingFinalitySignature, "extra-data finality signature missing")
+
+	// ErrNotEnoughFinalityVote is returned if the number of finality votes is under
+	// the threshold
+	ErrNotEnoughFinalityVote = New(NotEnoughFinalityVote, "not enough finality vote")
+
+	// ErrFinalitySignatureVerificationFailed is returned if the finality signature verification
+	// failed
+	ErrFinalitySignatureVerificationFailed = New(FinalitySignatureVerificationFailed, "failed to verify finality signature")
+
+	// ErrInvalidFinalityVotedBitSet is returned if the voted validator in bit set is not in
+	// snapshot validator set
+	ErrInvalidFinalityVotedBitSet = New(InvalidFinalityVotedBitSet, "invalid finality voted bit set")
+
+	// ErrUnauthorizedFinalityVoter is returned if finality voter is not in validator set
+	ErrUnauthorizedFinalityVoter = New(UnauthorizedFinalityVoter, "unauthorized finality voter")
+
+	// ErrInvalidTargetNumber is returned if the vote contains invalid
+	// target number
+	ErrInvalidTargetNumber = New(InvalidTargetNumber, "invalid target number in vote")
+
+	// ErrInvalidVoteVersion is returned if the vote is a v1 vote after the
+	// Tripp hardfork or a v2 vote before
+	ErrInvalidVoteVersion = New(InvalidVoteVersion, "invalid vote version")
+
+	// ErrInvalidVoteChainID is returned if the vote is signed for another
+	// network
+	ErrInvalidVoteChainID = New(InvalidVoteChainID, "invalid chain id in vote")
+
+	// ErrInvalidVoteSource is returned if the vote source is not the justified
+	// block as of the target block
+	ErrInvalidVoteSource = New(InvalidVoteSource, "invalid source in vote")
+
+	// ErrInvalidVoteEpoch is returned if the vote contains invalid target
+	// epoch
+	ErrInvalidVoteEpoch = New(InvalidVoteEpoch, "invalid target epoch in vote")
+)
+
+// Error is a consensus error with a stable code. It implements rpc.DataError,
+// the code is the data of the JSON-RPC error.
+type Error struct {
+	code Code
+	msg  string
+}
+
+// New creates a consensus error with the code and message
+func New(code Code, msg string) *Error {
+	return &Error{code: code, msg: msg}
+}
+
+// Error returns the message of the error
+func (e *Error) Error() string { return e.msg }
+
+// Code returns the stable code of the error
+func (e *Error) Code() Code { return e.code }
+
+// ErrorData returns the code as the data of the JSON-RPC error
+func (e *Error) ErrorData() interface{} { return e.code }
+
+// CodeOf returns the code of the first consensus error in the chain of err
+func CodeOf(err error) (Code, bool) {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.code, true
+	}
+	return 0, false
+}
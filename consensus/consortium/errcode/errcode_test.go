@@ -0,0 +1,70 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var allErrors = []*Error{
+	ErrUnknownBlock, ErrMissingVanity, ErrMissingSignature, ErrExtraValidators,
+	ErrInvalidSpanValidators, ErrInvalidMixDigest, ErrInvalidUncleHash,
+	ErrInvalidDifficulty, ErrInvalidCheckpointSigners, ErrRecentlySigned,
+	ErrWrongDifficulty, ErrVanityTooLong,
+	ErrInvalidHasFinalityVote, ErrMissingHasFinalityVote, ErrMissingFinalityVoteBitSet,
+	ErrMissingFinalitySignature, ErrNotEnoughFinalityVote, ErrFinalitySignatureVerificationFailed,
+	ErrInvalidFinalityVotedBitSet, ErrUnauthorizedFinalityVoter, ErrInvalidTargetNumber,
+	ErrInvalidVoteVersion, ErrInvalidVoteChainID, ErrInvalidVoteSource, ErrInvalidVoteEpoch,
+}
+
+func TestUniqueCodes(t *testing.T) {
+	codes := make(map[Code]*Error)
+	for _, err := range allErrors {
+		if other, ok := codes[err.Code()]; ok {
+			t.Fatalf("Code %d is used by both %q and %q", err.Code(), other, err)
+		}
+		codes[err.Code()] = err
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	if code, ok := CodeOf(ErrNotEnoughFinalityVote); !ok || code != NotEnoughFinalityVote {
+		t.Fatalf("Expect code %d, got %d %v", NotEnoughFinalityVote, code, ok)
+	}
+	wrapped := fmt.Errorf("block 100: %w", ErrInvalidFinalityVotedBitSet)
+	if code, ok := CodeOf(wrapped); !ok || code != InvalidFinalityVotedBitSet {
+		t.Fatalf("Expect code %d, got %d %v", InvalidFinalityVotedBitSet, code, ok)
+	}
+	if _, ok := CodeOf(errors.New("other")); ok {
+		t.Fatalf("Expect no code for an uncoded error")
+	}
+}
+
+type testService struct{}
+
+func (testService) Fail() error { return ErrNotEnoughFinalityVote }
+
+func TestRPCErrorData(t *testing.T) {
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", testService{}); err != nil {
+		t.Fatalf("Failed to register service, err: %s", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "test_fail")
+	if err == nil || err.Error() != ErrNotEnoughFinalityVote.Error() {
+		t.Fatalf("Expect error %q, got %v", ErrNotEnoughFinalityVote, err)
+	}
+	dataErr, ok := err.(rpc.DataError)
+	if !ok {
+		t.Fatalf("Expect rpc.DataError, got %#v", err)
+	}
+	// The JSON number is decoded as a float64 by the client
+	if data, ok := dataErr.ErrorData().(float64); !ok || Code(data) != NotEnoughFinalityVote {
+		t.Fatalf("Expect error data %d, got %#v", NotEnoughFinalityVote, dataErr.ErrorData())
+	}
+}
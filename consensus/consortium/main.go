@@ -1,9 +1,12 @@
 package consortium
 
 import (
+	"io"
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus"
 	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
 	v1 "github.com/ethereum/go-ethereum/consensus/consortium/v1"
@@ -24,6 +27,9 @@ type Consortium struct {
 	chainConfig *params.ChainConfig
 	v1          *v1.Consortium
 	v2          *v2.Consortium
+
+	checkpointNumber uint64      // Number of the trusted checkpoint block, see SetTrustedCheckpoint
+	checkpointHash   common.Hash // Hash of the trusted checkpoint block, zero if none
 }
 
 // New creates a Consortium proxy that decides what Consortium version will be called
@@ -50,6 +56,9 @@ func (c *Consortium) Author(header *types.Header) (common.Address, error) {
 
 // VerifyHeader checks whether a header conforms to the consensus rules.
 func (c *Consortium) VerifyHeader(chain consensus.ChainHeaderReader, header *types.Header, seal bool) error {
+	if trusted, err := c.verifyTrustedCheckpoint(chain, header, nil); trusted {
+		return err
+	}
 	if c.chainConfig.IsConsortiumV2(header.Number) {
 		return c.v2.VerifyHeader(chain, header, seal)
 	}
@@ -60,27 +69,17 @@ func (c *Consortium) VerifyHeader(chain consensus.ChainHeaderReader, header *typ
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
 // method returns a quit channel to abort the operations and a results channel to
 // retrieve the async verifications (the order is that of the input slice).
+//
+// The headers are checked in order as each header depends on the snapshot of its
+// parent, the finality signatures are verified apart by a pool of workers, see
+// verifyHeadersPipeline.
 func (c *Consortium) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
 	abort := make(chan struct{})
 	results := make(chan error, len(headers))
 
-	go func() {
-		for i, header := range headers {
-			var err error
-			if c.chainConfig.IsConsortiumV2(header.Number) {
-				err = c.v2.VerifyHeaderAndParents(chain, header, headers[:i])
-			} else {
-				err = c.v1.VerifyHeaderAndParents(chain, header, headers[:i])
-			}
-
-			select {
-			case <-abort:
-				return
-			case results <- err:
-			}
-		}
-	}()
-
+	if len(headers) > 0 {
+		go c.verifyHeadersPipeline(chain, headers, abort, results)
+	}
 	return abort, results
 }
 
@@ -143,9 +142,10 @@ func (c *Consortium) SealHash(header *types.Header) common.Hash {
 	return c.v1.SealHash(header)
 }
 
-// Close implements consensus.Engine. It's a noop for Consortium as there are no background threads.
+// Close implements consensus.Engine. It stops the background loops of the v2
+// engine, see v2.Consortium.Close
 func (c *Consortium) Close() error {
-	return nil
+	return c.v2.Close()
 }
 
 // APIs doesn't need to check whether the current block is v1 or v2 because in proxy/server.go create empty
@@ -229,6 +229,181 @@ func (c *Consortium) SetVotePool(votePool consensus.VotePool) {
 	c.v2.SetVotePool(votePool)
 }
 
+// AddSealGuard adds a check that must pass before sealing a block, it is only
+// applied on v2
+func (c *Consortium) AddSealGuard(guard func() error) {
+	c.v2.AddSealGuard(guard)
+}
+
+// SetSnapshotStore stores the v2 snapshots in the store instead of the chain database
+func (c *Consortium) SetSnapshotStore(store v2.SnapshotStore) {
+	c.v2.SetSnapshotStore(store)
+}
+
+// SetSnapshotArchive moves the finalized v2 snapshots to the archive, see
+// v2.Consortium.SetSnapshotArchive
+func (c *Consortium) SetSnapshotArchive(archive *v2.SnapshotArchive) {
+	c.v2.SetSnapshotArchive(archive)
+}
+
+// StartSnapshotArchiving is only available on v2, see v2.Consortium.StartSnapshotArchiving
+func (c *Consortium) StartSnapshotArchiving(chain *core.BlockChain) {
+	c.v2.StartSnapshotArchiving(chain)
+}
+
+// SetAllowedFutureBlockTime sets the tolerated clock drift on both v1 and v2
+func (c *Consortium) SetAllowedFutureBlockTime(drift time.Duration) {
+	c.v1.SetAllowedFutureBlockTime(drift)
+	c.v2.SetAllowedFutureBlockTime(drift)
+}
+
+// AllowedFutureBlockTime returns the tolerated clock drift, it is the same on v1 and v2
+func (c *Consortium) AllowedFutureBlockTime() time.Duration {
+	return c.v2.AllowedFutureBlockTime()
+}
+
+// SetClock is only applied on v2, see v2.Consortium.SetClock
+func (c *Consortium) SetClock(clock mclock.Clock, epoch time.Time) {
+	c.v2.SetClock(clock, epoch)
+}
+
+// SetVanity is only applied on v2, v1 keeps the vanity from the extra data of
+// the miner, see v2.Consortium.SetVanity
+func (c *Consortium) SetVanity(content []byte) error {
+	return c.v2.SetVanity(content)
+}
+
+// RebuildSnapshot is only available on v2, see v2.Consortium.RebuildSnapshot
+func (c *Consortium) RebuildSnapshot(chain consensus.ChainHeaderReader, number uint64) (*v2.Snapshot, error) {
+	return c.v2.RebuildSnapshot(chain, number)
+}
+
+// BackfillStats is only available on v2, see v2.Consortium.BackfillStats
+func (c *Consortium) BackfillStats(chain consensus.ChainHeaderReader, from, to uint64) (int, error) {
+	return c.v2.BackfillStats(chain, from, to)
+}
+
+// ReplayBlocks re-runs the verification and the execution of the blocks, only
+// the consensus steps of v2 are traced, see v2.Consortium.ReplayBlocks
+func (c *Consortium) ReplayBlocks(chain *core.BlockChain, first, last uint64, w io.Writer) (int, error) {
+	return c.v2.ReplayBlocks(chain, first, last, w)
+}
+
+// SetValidatorSetOverride is only applied on v2, see v2.Consortium.SetValidatorSetOverride
+func (c *Consortium) SetValidatorSetOverride(override *v2.ValidatorSetOverride) error {
+	return c.v2.SetValidatorSetOverride(override)
+}
+
+// SetCacheConfig is only applied on v2, the sealer cache is shared with v1, see
+// v2.Consortium.SetCacheConfig
+func (c *Consortium) SetCacheConfig(config v2.CacheConfig) error {
+	return c.v2.SetCacheConfig(config)
+}
+
+// SetVoteAssemblyWindow is only applied on v2 since v1 doesn't have finality vote
+func (c *Consortium) SetVoteAssemblyWindow(window time.Duration) {
+	c.v2.SetVoteAssemblyWindow(window)
+}
+
+// VoteAssemblyWindow returns the vote assembly window of v2
+func (c *Consortium) VoteAssemblyWindow() time.Duration {
+	return c.v2.VoteAssemblyWindow()
+}
+
+// SetSealBacklog is only applied on v2, see v2.Consortium.SetSealBacklog
+func (c *Consortium) SetSealBacklog(threshold int, pendingFn func() int) {
+	c.v2.SetSealBacklog(threshold, pendingFn)
+}
+
+// SetLightVerification is only applied on v2, see v2.Consortium.SetLightVerification
+func (c *Consortium) SetLightVerification(enabled bool) {
+	c.v2.SetLightVerification(enabled)
+}
+
+// SetTrustedFinality is only applied on v2, see v2.Consortium.SetTrustedFinality
+func (c *Consortium) SetTrustedFinality(number uint64, hash common.Hash) {
+	c.v2.SetTrustedFinality(number, hash)
+}
+
+// SignValidatorProof is only available on v2, see v2.Consortium.SignValidatorProof
+func (c *Consortium) SignValidatorProof(data []byte) (common.Address, []byte, error) {
+	return c.v2.SignValidatorProof(data)
+}
+
+// SetVoteWaitHandler is only applied on v2, see v2.Consortium.SetVoteWaitHandler
+func (c *Consortium) SetVoteWaitHandler(fn func(header *types.Header)) {
+	c.v2.SetVoteWaitHandler(fn)
+}
+
+// StartInactivityTracker tracks the missed in-turn slots of the v2 validators
+func (c *Consortium) StartInactivityTracker(chain *core.BlockChain, threshold uint64, alertFn v2.InactivityAlertFn) {
+	c.v2.StartInactivityTracker(chain, threshold, alertFn)
+}
+
+// StartScoreTracker scores the performance of the v2 validators
+func (c *Consortium) StartScoreTracker(chain *core.BlockChain, config v2.ScoreConfig) {
+	c.v2.StartScoreTracker(chain, config)
+}
+
+// StartShadowSealing is only available on v2, see v2.Consortium.StartShadowSealing
+func (c *Consortium) StartShadowSealing(chain *core.BlockChain, validator common.Address) {
+	c.v2.StartShadowSealing(chain, validator)
+}
+
+// GetValidatorUptime returns the sealing record of the v2 validator tracked by
+// the inactivity tracker, false if the tracker is not started
+func (c *Consortium) GetValidatorUptime(validator common.Address) (*v2.ValidatorUptime, bool) {
+	return c.v2.GetValidatorUptime(validator)
+}
+
+// GetValidatorScores returns the ranking of the v2 validators tracked by the
+// score tracker, false if the tracker is not started
+func (c *Consortium) GetValidatorScores() (*v2.ValidatorScores, bool) {
+	return c.v2.GetValidatorScores()
+}
+
+// SpoiledValidator returns the in-turn v2 validator that does not seal the
+// header, it always returns false before Consortium v2
+func (c *Consortium) SpoiledValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool) {
+	if c.chainConfig.IsConsortiumV2(header.Number) {
+		return c.v2.SpoiledValidator(chain, header)
+	}
+	return common.Address{}, false
+}
+
+// InTurnValidator returns the in-turn v2 validator of the header, it always
+// returns false before Consortium v2
+func (c *Consortium) InTurnValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool) {
+	if c.chainConfig.IsConsortiumV2(header.Number) {
+		return c.v2.InTurnValidator(chain, header)
+	}
+	return common.Address{}, false
+}
+
+// VerificationTiming returns the durations of the verification of the recent v2
+// header with the hash
+func (c *Consortium) VerificationTiming(hash common.Hash) (header, signatures time.Duration, ok bool) {
+	timing, ok := c.v2.VerificationTiming(hash)
+	return timing.Header, timing.Signatures, ok
+}
+
+// SetWrapUpFailureHandler is only applied on v2 since v1 doesn't have system contract
+func (c *Consortium) SetWrapUpFailureHandler(fn v2.WrapUpFailureFn) {
+	c.v2.SetWrapUpFailureHandler(fn)
+}
+
+// EnableDoubleSignReport is only available on v2 since v1 doesn't have system contract
+func (c *Consortium) EnableDoubleSignReport(gasCap uint64, dryRun bool) {
+	c.v2.EnableDoubleSignReport(gasCap, dryRun)
+}
+
+// ReportDoubleSign only reports the double sign from v2 since v1 doesn't have system contract
+func (c *Consortium) ReportDoubleSign(header1, header2 *types.Header) {
+	if c.chainConfig.IsConsortiumV2(header1.Number) {
+		c.v2.ReportDoubleSign(header1, header2)
+	}
+}
+
 // IsActiveValidatorAt always returns false before Shillin
 func (c *Consortium) IsActiveValidatorAt(chain consensus.ChainHeaderReader, header *types.Header) bool {
 	if c.chainConfig.IsShillin(header.Number) {
@@ -238,6 +413,16 @@ func (c *Consortium) IsActiveValidatorAt(chain consensus.ChainHeaderReader, head
 	return false
 }
 
+// AggregateVotes is only applied on v2 since v1 doesn't have finality vote
+func (c *Consortium) AggregateVotes(chain consensus.ChainHeaderReader, votes []*types.VoteEnvelope) (*types.AggregatedVote, error) {
+	return c.v2.AggregateVotes(chain, votes)
+}
+
+// VerifyAggregatedVote is only applied on v2 since v1 doesn't have finality vote
+func (c *Consortium) VerifyAggregatedVote(chain consensus.ChainHeaderReader, vote *types.AggregatedVote) error {
+	return c.v2.VerifyAggregatedVote(chain, vote)
+}
+
 // VerifyVote check if the finality voter is in the validator set, it assumes the signature is
 // already verified
 func (c *Consortium) VerifyVote(chain consensus.ChainHeaderReader, vote *types.VoteEnvelope) error {
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core"
@@ -49,10 +50,11 @@ import (
 )
 
 const (
-	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
-	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inmemorySnapshots = 128 // Number of recent vote snapshots to keep in memory
 
 	wiggleTime = 1000 * time.Millisecond // Random delay (per signer) to allow concurrent signers
+
+	maxAllowedFutureBlockTime = time.Second // Maximum tolerated clock drift of the block time
 )
 
 // Consortium proof-of-authority protocol constants.
@@ -101,7 +103,7 @@ type Consortium struct {
 	db          ethdb.Database           // Database to store and retrieve snapshot checkpoints
 
 	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
-	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+	signatures *lru.Cache    // Sealers of recent blocks shared by the process, see consortiumCommon.Sealers
 
 	proposals map[common.Address]bool // Current list of proposals we are pushing
 
@@ -117,6 +119,8 @@ type Consortium struct {
 
 	getSCValidators    func() ([]common.Address, error) // Get the list of validator from contract
 	getFenixValidators func() ([]common.Address, error) // Get the validator list from Ronin Validator contract of Fenix hardfork
+
+	allowedFutureBlockTime int64 // Tolerated clock drift of the block time, accessed atomically
 }
 
 // New creates a Consortium proof-of-authority consensus engine with the initial
@@ -129,14 +133,13 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database, ethAPI *ethapi.Publ
 	}
 	// Allocate the snapshot caches and create the engine
 	recents, _ := lru.NewARC(inmemorySnapshots)
-	signatures, _ := lru.NewARC(inmemorySignatures)
 
 	consortium := Consortium{
 		chainConfig: chainConfig,
 		config:      &consortiumConfig,
 		db:          db,
 		recents:     recents,
-		signatures:  signatures,
+		signatures:  consortiumCommon.Sealers,
 		ethAPI:      ethAPI,
 		proposals:   make(map[common.Address]bool),
 		signer:      types.NewEIP155Signer(chainConfig.ChainID),
@@ -160,6 +163,20 @@ func (c *Consortium) SetGetFenixValidators(fn func() ([]common.Address, error))
 	c.getFenixValidators = fn
 }
 
+// SetAllowedFutureBlockTime sets the maximum duration a block time can be ahead
+// of the local clock, the drift is capped at maxAllowedFutureBlockTime
+func (c *Consortium) SetAllowedFutureBlockTime(drift time.Duration) {
+	if drift > maxAllowedFutureBlockTime {
+		drift = maxAllowedFutureBlockTime
+	}
+	atomic.StoreInt64(&c.allowedFutureBlockTime, int64(drift))
+}
+
+// futureBlockTime returns the tolerated clock drift of the block time
+func (c *Consortium) futureBlockTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.allowedFutureBlockTime))
+}
+
 // Author implements consensus.Engine, returning the Ethereum address recovered
 // from the signature in the header's extra-data section.
 func (c *Consortium) Author(header *types.Header) (common.Address, error) {
@@ -203,7 +220,7 @@ func (c *Consortium) VerifyHeaderAndParents(chain consensus.ChainHeaderReader, h
 	number := header.Number.Uint64()
 
 	// Don't waste time checking blocks from the future
-	if header.Time > uint64(time.Now().Unix()) {
+	if time.Unix(int64(header.Time), 0).After(time.Now().Add(c.futureBlockTime())) {
 		return consensus.ErrFutureBlock
 	}
 	// Nonces must be 0x00..0
@@ -652,7 +669,7 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 	}
 
 	// Sweet, the protocol permits us to sign the block, wait for our time
-	delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) // nolint: gosimple
+	delay := time.Unix(int64(header.Time), 0).Sub(time.Now()) - c.futureBlockTime() // nolint: gosimple
 	if !c.signerInTurn(signer, number, validators) {
 		// It's not our turn explicitly to sign, delay it a bit
 		wiggle := time.Duration(len(validators)/2+1) * wiggleTime
@@ -798,10 +815,10 @@ func (c *Consortium) initContract(coinbase common.Address, signTxFn consortiumCo
 }
 
 // ecrecover extracts the Ethereum account address from a signed header.
-func Ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+func Ecrecover(header *types.Header, sigcache *lru.Cache) (common.Address, error) {
 	// If the signature's already cached, return that
-	hash := header.Hash()
-	if address, known := sigcache.Get(hash); known {
+	key := consortiumCommon.NewSealerKey(nil, header.Hash())
+	if address, known := sigcache.Get(key); known {
 		return address.(common.Address), nil
 	}
 	// Retrieve the signature from the header extra-data
@@ -818,7 +835,7 @@ func Ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 	var signer common.Address
 	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
 
-	sigcache.Add(hash, signer)
+	sigcache.Add(key, signer)
 	return signer, nil
 }
 
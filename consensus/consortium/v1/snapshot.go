@@ -32,7 +32,7 @@ import (
 // Snapshot is the state of the authorization voting at a given point in time.
 type Snapshot struct {
 	config   *params.ConsortiumConfig // Consensus engine parameters to fine tune behavior
-	sigcache *lru.ARCCache            // Cache of recent block signatures to speed up ecrecover
+	sigcache *lru.Cache               // Cache of recent block signatures to speed up ecrecover
 
 	Number     uint64                      `json:"number"`     // Block number where the snapshot was created
 	Hash       common.Hash                 `json:"hash"`       // Block hash where the snapshot was created
@@ -44,7 +44,7 @@ type Snapshot struct {
 // newSnapshot creates a new snapshot with the specified startup parameters. This
 // method does not initialize the set of recent signers, so only ever use if for
 // the genesis block.
-func newSnapshot(config *params.ConsortiumConfig, sigcache *lru.ARCCache, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
+func newSnapshot(config *params.ConsortiumConfig, sigcache *lru.Cache, number uint64, hash common.Hash, signers []common.Address) *Snapshot {
 	snap := &Snapshot{
 		config:     config,
 		sigcache:   sigcache,
@@ -64,7 +64,7 @@ func newSnapshot(config *params.ConsortiumConfig, sigcache *lru.ARCCache, number
 }
 
 // loadSnapshot loads an existing snapshot from the database.
-func loadSnapshot(config *params.ConsortiumConfig, sigcache *lru.ARCCache, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
+func loadSnapshot(config *params.ConsortiumConfig, sigcache *lru.Cache, db ethdb.Database, hash common.Hash) (*Snapshot, error) {
 	blob, err := db.Get(append([]byte("consortium-"), hash[:]...))
 	if err != nil {
 		return nil, err
@@ -1,10 +1,20 @@
 package v2
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
 	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 )
 
 type consortiumV2Api struct {
@@ -78,3 +88,578 @@ func (api *consortiumV2Api) GetFinalityVoteAtHash(hash common.Hash) (*finalityVo
 
 	return &vote, nil
 }
+
+// consortiumApi is the v2 api served under the consortium namespace
+type consortiumApi struct {
+	chain      consensus.ChainHeaderReader
+	consortium *Consortium
+}
+
+// GetValidatorUptime returns the number of sealed blocks and missed in-turn slots
+// of the validator since the inactivity tracker started
+func (api *consortiumApi) GetValidatorUptime(validator common.Address) (*ValidatorUptime, error) {
+	uptime, ok := api.consortium.GetValidatorUptime(validator)
+	if !ok {
+		return nil, errors.New("validator inactivity tracker is not enabled")
+	}
+	return uptime, nil
+}
+
+type epochValidators struct {
+	Epoch           uint64                         `json:"epoch"`
+	CheckpointBlock uint64                         `json:"checkpointBlock"`
+	Validators      []finality.ValidatorWithBlsPub `json:"validators"`
+}
+
+// GetValidatorsAtEpoch returns the validators with their BLS public keys that are
+// picked at the checkpoint block of the epoch. The validators are read from the
+// contracts at the parent state of the checkpoint block so that state must be
+// available, which requires an archive node for old epochs.
+func (api *consortiumApi) GetValidatorsAtEpoch(epoch uint64) (*epochValidators, error) {
+	checkpointBlock := epoch * api.consortium.config.EpochV2
+	if checkpointBlock <= api.consortium.forkedBlock {
+		return nil, errors.New("epoch is before consortium v2")
+	}
+	header := api.chain.GetHeaderByNumber(checkpointBlock)
+	if header == nil {
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+
+	validators, err := api.consortium.getCheckpointValidatorsFromContract(header)
+	if err != nil {
+		return nil, err
+	}
+	return &epochValidators{
+		Epoch:           epoch,
+		CheckpointBlock: checkpointBlock,
+		Validators:      validators,
+	}, nil
+}
+
+// SimulateEpoch dry-runs the wrap up of the current epoch on top of the head
+// state with the state overrides applied, like the overrides of eth_call, and
+// returns the validators the next checkpoint block would pick. It allows to
+// check an upgrade of the validator contracts against the live state before
+// it is scheduled.
+func (api *consortiumApi) SimulateEpoch(overrides *ethapi.StateOverride) (*EpochSimulation, error) {
+	reader, ok := api.chain.(stateReader)
+	if !ok {
+		return nil, errors.New("chain state is not available")
+	}
+	head := api.chain.CurrentHeader()
+	statedb, err := reader.StateAt(head.Root)
+	if err != nil {
+		return nil, err
+	}
+	return api.consortium.SimulateEpoch(api.chain, head, statedb, overrides)
+}
+
+type blsKeyChange struct {
+	Validator common.Address `json:"validator"`
+	OldKey    hexutil.Bytes  `json:"oldKey"`
+	NewKey    hexutil.Bytes  `json:"newKey"`
+}
+
+type validatorSetDiff struct {
+	EpochA     uint64           `json:"epochA"`
+	EpochB     uint64           `json:"epochB"`
+	Added      []common.Address `json:"added"`
+	Removed    []common.Address `json:"removed"`
+	BlsChanged []blsKeyChange   `json:"blsChanged"`
+}
+
+// epochSnapshot returns the snapshot at the checkpoint block of the epoch
+func (api *consortiumApi) epochSnapshot(epoch uint64) (*Snapshot, error) {
+	checkpointBlock := epoch * api.consortium.config.EpochV2
+	if checkpointBlock <= api.consortium.forkedBlock {
+		return nil, errors.New("epoch is before consortium v2")
+	}
+	header := api.chain.GetHeaderByNumber(checkpointBlock)
+	if header == nil {
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+	return api.consortium.snapshot(api.chain, checkpointBlock, header.Hash(), nil)
+}
+
+// GetValidatorSetProof returns the checkpoint that introduced the validator set
+// in effect at the checkpoint block of the epoch, with the finality votes for the
+// checkpoint. The proofs are only recorded in the snapshots stored by this version
+// of the engine.
+func (api *consortiumApi) GetValidatorSetProof(epoch uint64) (*ValidatorSetProof, error) {
+	snap, err := api.epochSnapshot(epoch)
+	if err != nil {
+		return nil, err
+	}
+	if snap.ValidatorSetProof == nil {
+		return nil, errors.New("validator set proof is not recorded in the snapshot")
+	}
+	return snap.ValidatorSetProof, nil
+}
+
+// snapshotBlsKeys returns the BLS public keys of the validators in the snapshot,
+// the keys are only available after Shillin
+func snapshotBlsKeys(snap *Snapshot) map[common.Address][]byte {
+	keys := make(map[common.Address][]byte, len(snap.ValidatorsWithBlsPub))
+	for _, validator := range snap.ValidatorsWithBlsPub {
+		if validator.BlsPublicKey != nil {
+			keys[validator.Address] = validator.BlsPublicKey.Marshal()
+		}
+	}
+	return keys
+}
+
+// GetValidatorSetDiff returns the validators added to and removed from the
+// validator set, and the validators whose BLS public key changed, from the
+// checkpoint block of epochA to the checkpoint block of epochB. The validator
+// sets are read from the stored snapshots.
+func (api *consortiumApi) GetValidatorSetDiff(epochA, epochB uint64) (*validatorSetDiff, error) {
+	snapA, err := api.epochSnapshot(epochA)
+	if err != nil {
+		return nil, err
+	}
+	snapB, err := api.epochSnapshot(epochB)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &validatorSetDiff{
+		EpochA:     epochA,
+		EpochB:     epochB,
+		Added:      make([]common.Address, 0),
+		Removed:    make([]common.Address, 0),
+		BlsChanged: make([]blsKeyChange, 0),
+	}
+	keysA, keysB := snapshotBlsKeys(snapA), snapshotBlsKeys(snapB)
+	for _, validator := range snapB.validators() {
+		if !snapA.inInValidatorSet(validator) {
+			diff.Added = append(diff.Added, validator)
+			continue
+		}
+		oldKey, newKey := keysA[validator], keysB[validator]
+		if oldKey != nil && newKey != nil && !bytes.Equal(oldKey, newKey) {
+			diff.BlsChanged = append(diff.BlsChanged, blsKeyChange{
+				Validator: validator,
+				OldKey:    oldKey,
+				NewKey:    newKey,
+			})
+		}
+	}
+	for _, validator := range snapA.validators() {
+		if !snapB.inInValidatorSet(validator) {
+			diff.Removed = append(diff.Removed, validator)
+		}
+	}
+	return diff, nil
+}
+
+type headerVanity struct {
+	Raw  hexutil.Bytes `json:"raw"`            // Content of the vanity prefix without the zero padding
+	Text string        `json:"text,omitempty"` // Content as text if it is valid UTF-8
+}
+
+// GetVanityAtHash returns the content of the vanity prefix of the block header,
+// see Consortium.SetVanity
+func (api *consortiumApi) GetVanityAtHash(hash common.Hash) (*headerVanity, error) {
+	header := api.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+	extraData, err := finality.DecodeExtra(header.Extra, api.consortium.chainConfig.IsShillin(header.Number))
+	if err != nil {
+		return nil, err
+	}
+
+	content := extraData.VanityContent()
+	vanity := &headerVanity{Raw: content}
+	if utf8.Valid(content) {
+		vanity.Text = string(content)
+	}
+	return vanity, nil
+}
+
+// maxMissedBlocksRange is the maximum number of blocks scanned by GetMissedBlocks
+// and GetBlockSigners
+const maxMissedBlocksRange = 10000
+
+// blockRange checks the size of the block range and clamps it to the consortium
+// v2 blocks of the chain
+func (api *consortiumApi) blockRange(from, to uint64) (uint64, uint64, error) {
+	if from > to {
+		return 0, 0, errors.New("invalid block range")
+	}
+	if to-from >= maxMissedBlocksRange {
+		return 0, 0, fmt.Errorf("block range exceeds the limit of %d blocks", maxMissedBlocksRange)
+	}
+	if from <= api.consortium.forkedBlock {
+		from = api.consortium.forkedBlock + 1
+	}
+	if head := api.chain.CurrentHeader().Number.Uint64(); to > head {
+		to = head
+	}
+	return from, to, nil
+}
+
+// sealer returns the validator sealing the header, recovered from its signature
+// unless the sync has already recovered it
+func (api *consortiumApi) sealer(header *types.Header) (common.Address, error) {
+	return verifier.Ecrecover(header, api.consortium.signatures, api.consortium.chainConfig.ChainID)
+}
+
+type missedBlock struct {
+	Number   uint64         `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	SealedBy common.Address `json:"sealedBy"`
+	Delay    uint64         `json:"delay"` // Seconds the block is sealed after the in-turn slot
+}
+
+// GetMissedBlocks returns the blocks in the range [from, to] at which the validator
+// was in turn but did not seal. As in the slashing rule, the slots at which the
+// validator was not allowed to seal because it has signed recently are not counted.
+func (api *consortiumApi) GetMissedBlocks(validator common.Address, from, to uint64) ([]missedBlock, error) {
+	from, to, err := api.blockRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	missedBlocks := make([]missedBlock, 0)
+	for number := from; number <= to; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, consortiumCommon.ErrUnknownBlock
+		}
+		parent := api.chain.GetHeader(header.ParentHash, number-1)
+		if parent == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+		snap, err := api.consortium.snapshot(api.chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		if spoiledVal, spoiled := api.consortium.spoiledValidator(snap, header); !spoiled || spoiledVal != validator {
+			continue
+		}
+
+		sealer, err := api.sealer(header)
+		if err != nil {
+			return nil, err
+		}
+		var delay uint64
+		if expected := parent.Time + api.consortium.config.Period; header.Time > expected {
+			delay = header.Time - expected
+		}
+		missedBlocks = append(missedBlocks, missedBlock{
+			Number:   number,
+			Hash:     header.Hash(),
+			SealedBy: sealer,
+			Delay:    delay,
+		})
+	}
+	return missedBlocks, nil
+}
+
+type blockSigner struct {
+	Number    uint64          `json:"number"`
+	Hash      common.Hash     `json:"hash"`
+	InTurn    common.Address  `json:"inTurn"`             // Validator in turn at the slot
+	Sealer    common.Address  `json:"sealer"`             // Validator sealing the block
+	OutOfTurn bool            `json:"outOfTurn"`          // The block is sealed out of turn
+	MissedBy  *common.Address `json:"missedBy,omitempty"` // Validator the missed slot is attributed to
+}
+
+// GetBlockSigners returns, for the blocks in the range [from, to], the in-turn
+// validator and the sealer of the block, computed from the snapshots and the
+// block difficulty. When a block is sealed out of turn, the missed slot is
+// attributed to the in-turn validator, unless it was not allowed to seal because
+// it has signed recently, as in the slashing rule.
+func (api *consortiumApi) GetBlockSigners(from, to uint64) ([]blockSigner, error) {
+	from, to, err := api.blockRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := make([]blockSigner, 0)
+	for number := from; number <= to; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, consortiumCommon.ErrUnknownBlock
+		}
+		snap, err := api.consortium.snapshot(api.chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		sealer, err := api.sealer(header)
+		if err != nil {
+			return nil, err
+		}
+		signer := blockSigner{
+			Number:    number,
+			Hash:      header.Hash(),
+			InTurn:    snap.supposeValidator(),
+			Sealer:    sealer,
+			OutOfTurn: header.Difficulty.Cmp(diffInTurn) != 0,
+		}
+		if spoiledVal, spoiled := api.consortium.spoiledValidator(snap, header); spoiled {
+			signer.MissedBy = &spoiledVal
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
+// defaultChainHealthBlocks is the number of blocks summarized by GetChainHealth
+// by default
+const defaultChainHealthBlocks = 200
+
+type chainHealth struct {
+	Head                 uint64  `json:"head"`
+	Validators           int     `json:"validators"`           // Number of validators at the head
+	Blocks               uint64  `json:"blocks"`               // Number of blocks summarized, up to the head
+	FinalityVoters       int     `json:"finalityVoters"`       // Number of validators whose finality votes are included in the blocks
+	AverageBlockInterval float64 `json:"averageBlockInterval"` // Seconds between the blocks
+	OutOfTurnRatio       float64 `json:"outOfTurnRatio"`       // Ratio of the blocks sealed out of turn
+	FinalizedBlock       uint64  `json:"finalizedBlock"`
+	FinalizedBlockAge    uint64  `json:"finalizedBlockAge"` // Seconds since the time of the finalized block
+}
+
+// GetChainHealth summarizes the health of the chain over the last blocks (200
+// by default): the validators, the finality voters, the block interval, the
+// out-of-turn sealing and the age of the finalized block.
+func (api *consortiumApi) GetChainHealth(blocks *uint64) (*chainHealth, error) {
+	n := uint64(defaultChainHealthBlocks)
+	if blocks != nil {
+		n = *blocks
+	}
+	if n == 0 {
+		return nil, errors.New("invalid number of blocks")
+	}
+	head := api.chain.CurrentHeader()
+	headNumber := head.Number.Uint64()
+	if n > headNumber {
+		n = headNumber
+	}
+	from, to, err := api.blockRange(headNumber-n+1, headNumber)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, errors.New("chain health is only available on consortium v2")
+	}
+
+	snap, err := api.consortium.snapshot(api.chain, headNumber, head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	health := &chainHealth{
+		Head:       headNumber,
+		Validators: len(snap.validators()),
+		Blocks:     to - from + 1,
+	}
+
+	var (
+		outOfTurn uint64
+		voters    = make(map[common.Address]struct{})
+	)
+	for number := from; number <= to; number++ {
+		header := api.chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, consortiumCommon.ErrUnknownBlock
+		}
+		if header.Difficulty.Cmp(diffInTurn) != 0 {
+			outOfTurn++
+		}
+		if !api.consortium.chainConfig.IsShillin(header.Number) {
+			continue
+		}
+		extraData, err := finality.DecodeExtra(header.Extra, true)
+		if err != nil {
+			return nil, err
+		}
+		if extraData.HasFinalityVote == 0 {
+			continue
+		}
+		// The votes are checked against the validators of the parent snapshot
+		snap, err := api.consortium.snapshot(api.chain, number-1, header.ParentHash, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, position := range extraData.FinalityVotedValidators.Indices() {
+			if position < len(snap.ValidatorsWithBlsPub) {
+				voters[snap.ValidatorsWithBlsPub[position].Address] = struct{}{}
+			}
+		}
+	}
+	health.FinalityVoters = len(voters)
+	health.OutOfTurnRatio = float64(outOfTurn) / float64(health.Blocks)
+	if start := api.chain.GetHeaderByNumber(from - 1); start != nil {
+		health.AverageBlockInterval = float64(head.Time-start.Time) / float64(health.Blocks)
+	}
+
+	finalizedNumber, finalizedHash := api.consortium.GetFinalizedBlock(api.chain, headNumber, head.Hash())
+	if finalized := api.chain.GetHeader(finalizedHash, finalizedNumber); finalizedNumber != 0 && finalized != nil {
+		health.FinalizedBlock = finalizedNumber
+		if now := uint64(api.consortium.now().Unix()); now > finalized.Time {
+			health.FinalizedBlockAge = now - finalized.Time
+		}
+	}
+	return health, nil
+}
+
+type nextBlockTime struct {
+	Number          uint64         `json:"number"`
+	InTurnValidator common.Address `json:"inTurnValidator"`
+	InTurnSealable  bool           `json:"inTurnSealable"` // False if the in-turn validator has signed recently
+	Time            uint64         `json:"time"`           // Expected time of the next block
+	OutOfTurnTime   uint64         `json:"outOfTurnTime"`  // Earliest time of the next block sealed out of turn, zero if none can be
+
+	// The wrap-up block, the last block of the epoch, runs the heavy epoch
+	// system transactions and the epoch block carries the new validator set.
+	// Their times are estimated assuming the blocks are sealed in turn.
+	NextWrapUpBlock uint64 `json:"nextWrapUpBlock"`
+	NextWrapUpTime  uint64 `json:"nextWrapUpTime"`
+	NextEpochBlock  uint64 `json:"nextEpochBlock"`
+	NextEpochTime   uint64 `json:"nextEpochTime"`
+}
+
+// EstimateNextBlockTime estimates the time of the next block and of the next
+// epoch boundary from the snapshot at the head, the block period and, since
+// Buba, the backoff of the out-of-turn validators included in the header time.
+func (api *consortiumApi) EstimateNextBlockTime() (*nextBlockTime, error) {
+	var (
+		c      = api.consortium
+		head   = api.chain.CurrentHeader()
+		number = head.Number.Uint64() + 1
+	)
+	if !c.chainConfig.IsConsortiumV2(new(big.Int).SetUint64(number)) {
+		return nil, errors.New("next block time is only available on consortium v2")
+	}
+	snap, err := c.snapshot(api.chain, number-1, head.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The block time can't be before the current time, see computeHeaderTime
+	now := uint64(c.now().Unix())
+	blockTime := func(backoff uint64) uint64 {
+		if t := head.Time + c.config.Period + backoff; t > now {
+			return t
+		}
+		return now
+	}
+
+	inTurn := snap.supposeValidator()
+	estimate := &nextBlockTime{
+		Number:          number,
+		InTurnValidator: inTurn,
+		InTurnSealable:  !snap.IsRecentlySigned(inTurn),
+	}
+	var (
+		outOfTurnBackoff uint64
+		outOfTurn        bool
+		isBuba           = c.chainConfig.IsBuba(new(big.Int).SetUint64(number))
+	)
+	for _, validator := range snap.validators() {
+		if validator == inTurn || snap.IsRecentlySigned(validator) {
+			continue
+		}
+		var backoff uint64
+		if isBuba {
+			backoff = backOffTime(&types.Header{Number: new(big.Int).SetUint64(number), Coinbase: validator}, snap, c.chainConfig)
+		}
+		if !outOfTurn || backoff < outOfTurnBackoff {
+			outOfTurnBackoff, outOfTurn = backoff, true
+		}
+	}
+	if outOfTurn {
+		estimate.OutOfTurnTime = blockTime(outOfTurnBackoff)
+	}
+	if estimate.InTurnSealable || !outOfTurn {
+		estimate.Time = blockTime(0)
+	} else {
+		estimate.Time = estimate.OutOfTurnTime
+	}
+
+	epoch := c.config.EpochV2
+	estimate.NextEpochBlock = (number + epoch - 1) / epoch * epoch
+	estimate.NextWrapUpBlock = estimate.NextEpochBlock - 1
+	if estimate.NextWrapUpBlock < number {
+		estimate.NextWrapUpBlock += epoch
+	}
+	estimate.NextEpochTime = estimate.Time + (estimate.NextEpochBlock-number)*c.config.Period
+	estimate.NextWrapUpTime = estimate.Time + (estimate.NextWrapUpBlock-number)*c.config.Period
+	return estimate, nil
+}
+
+// maxStatsEpochRange is the maximum number of epochs aggregated by GetValidatorStats
+const maxStatsEpochRange = 1000
+
+// GetEpochStats returns the indexed finality and participation statistics of the
+// epoch, see `ronin consortium backfill-stats`
+func (api *consortiumApi) GetEpochStats(epoch uint64) (*EpochStats, error) {
+	stats, err := api.consortium.readEpochStats(epoch)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("epoch %d is not indexed", epoch)
+	}
+	return stats, nil
+}
+
+type validatorStats struct {
+	Validator     common.Address `json:"validator"`
+	FromEpoch     uint64         `json:"fromEpoch"`
+	ToEpoch       uint64         `json:"toEpoch"`
+	IndexedEpochs uint64         `json:"indexedEpochs"` // The epochs which are not indexed are skipped
+
+	// Totals of the indexed epochs
+	Blocks              uint64 `json:"blocks"`
+	FinalityVotedBlocks uint64 `json:"finalityVotedBlocks"`
+	Sealed              uint64 `json:"sealed"`
+	SealedInTurn        uint64 `json:"sealedInTurn"`
+	FinalityVotes       uint64 `json:"finalityVotes"`
+	MissedInTurn        uint64 `json:"missedInTurn"`
+}
+
+// GetValidatorStats aggregates the indexed statistics of the validator over the
+// epochs in the range [fromEpoch, toEpoch]
+func (api *consortiumApi) GetValidatorStats(validator common.Address, fromEpoch, toEpoch uint64) (*validatorStats, error) {
+	if fromEpoch > toEpoch {
+		return nil, errors.New("invalid epoch range")
+	}
+	if toEpoch-fromEpoch >= maxStatsEpochRange {
+		return nil, fmt.Errorf("epoch range exceeds the limit of %d epochs", maxStatsEpochRange)
+	}
+	result := &validatorStats{Validator: validator, FromEpoch: fromEpoch, ToEpoch: toEpoch}
+	for epoch := fromEpoch; epoch <= toEpoch; epoch++ {
+		stats, err := api.consortium.readEpochStats(epoch)
+		if err != nil {
+			return nil, err
+		}
+		if stats == nil {
+			continue
+		}
+		result.IndexedEpochs++
+		result.Blocks += stats.Blocks
+		result.FinalityVotedBlocks += stats.FinalityVotedBlocks
+		for _, val := range stats.Validators {
+			if val.Address == validator {
+				result.Sealed += val.Sealed
+				result.SealedInTurn += val.SealedInTurn
+				result.FinalityVotes += val.FinalityVotes
+				result.MissedInTurn += val.MissedInTurn
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetValidatorScores returns the validators ranked by their performance score
+// over the completed epochs of the scoring window, the best first
+func (api *consortiumApi) GetValidatorScores() (*ValidatorScores, error) {
+	scores, ok := api.consortium.GetValidatorScores()
+	if !ok {
+		return nil, errors.New("validator scoring is not enabled")
+	}
+	return scores, nil
+}
@@ -0,0 +1,93 @@
+package v2
+
+import (
+	"errors"
+
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// CacheConfig is the sizes of the in-memory caches of the engine and the
+// persistence policy of its checkpoint snapshots. The archive nodes serving old
+// blocks benefit from larger caches, the memory-constrained validators from
+// smaller ones.
+type CacheConfig struct {
+	Snapshots          int    `json:"snapshots"`          // Number of recent snapshots kept in memory
+	Sealers            int    `json:"sealers"`            // Number of recent block sealers kept in memory, shared by the process
+	CheckpointInterval uint64 `json:"checkpointInterval"` // Number of epochs between the checkpoint snapshots persisted to the database
+}
+
+// DefaultCacheConfig keeps the cache sizes of the engine and persists the
+// checkpoint snapshot of every epoch
+var DefaultCacheConfig = CacheConfig{
+	Snapshots:          inmemorySnapshots,
+	Sealers:            consortiumCommon.DefaultSealersCacheSize,
+	CheckpointInterval: 1,
+}
+
+var errInvalidCacheConfig = errors.New("cache sizes and checkpoint interval must be positive")
+
+// SetCacheConfig resizes the in-memory caches and changes the checkpoint
+// interval, it can be called at runtime. The snapshots which are not persisted
+// are rebuilt from the headers since the last persisted one, a larger interval
+// trades fewer database writes for longer rebuilds after a restart or a deep
+// reorg. The snapshots persisted before are still used.
+func (c *Consortium) SetCacheConfig(config CacheConfig) error {
+	if config.Snapshots <= 0 || config.Sealers <= 0 || config.CheckpointInterval == 0 {
+		return errInvalidCacheConfig
+	}
+	c.recents.Resize(config.Snapshots)
+	c.signatures.Resize(config.Sealers)
+	c.cacheConfig.Store(config)
+	log.Info("Updated consortium cache config", "snapshots", config.Snapshots, "sealers", config.Sealers,
+		"checkpointInterval", config.CheckpointInterval)
+	return nil
+}
+
+// CacheConfig returns the current sizes of the in-memory caches and the
+// checkpoint interval
+func (c *Consortium) CacheConfig() CacheConfig {
+	if config, ok := c.cacheConfig.Load().(CacheConfig); ok {
+		return config
+	}
+	return DefaultCacheConfig
+}
+
+// persistCheckpoint returns whether the checkpoint snapshot at the epoch block
+// number is persisted to the database
+func (c *Consortium) persistCheckpoint(number uint64) bool {
+	return (number/c.config.EpochV2)%c.CacheConfig().CheckpointInterval == 0
+}
+
+// cacheConfigArgs is the cache config to apply, the omitted fields are kept
+type cacheConfigArgs struct {
+	Snapshots          *int    `json:"snapshots"`
+	Sealers            *int    `json:"sealers"`
+	CheckpointInterval *uint64 `json:"checkpointInterval"`
+}
+
+// GetSnapshotCache returns the sizes of the in-memory caches of the engine and
+// the checkpoint interval
+func (api *consortiumAdminApi) GetSnapshotCache() CacheConfig {
+	return api.consortium.CacheConfig()
+}
+
+// SetSnapshotCache resizes the in-memory caches of the engine and changes the
+// checkpoint interval, the omitted fields are kept. The applied config is
+// returned.
+func (api *consortiumAdminApi) SetSnapshotCache(args cacheConfigArgs) (CacheConfig, error) {
+	config := api.consortium.CacheConfig()
+	if args.Snapshots != nil {
+		config.Snapshots = *args.Snapshots
+	}
+	if args.Sealers != nil {
+		config.Sealers = *args.Sealers
+	}
+	if args.CheckpointInterval != nil {
+		config.CheckpointInterval = *args.CheckpointInterval
+	}
+	if err := api.consortium.SetCacheConfig(config); err != nil {
+		return CacheConfig{}, err
+	}
+	return config, nil
+}
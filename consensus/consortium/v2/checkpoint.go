@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// errCheckpointNotEpoch is returned if the checkpoint is not an epoch block
+	// after Shillin
+	errCheckpointNotEpoch = errors.New("checkpoint is not an epoch block after Shillin")
+
+	// errCheckpointNotVoted is returned if the child of the checkpoint block does
+	// not include the finality votes for it
+	errCheckpointNotVoted = errors.New("checkpoint has no finality votes")
+
+	// errCheckpointSnapshot is returned if the snapshot of the checkpoint does not
+	// match the checkpoint block
+	errCheckpointSnapshot = errors.New("invalid checkpoint snapshot")
+
+	// errCheckpointValidatorSet is returned if the validator set of the snapshot
+	// does not match the validator set hash of the checkpoint
+	errCheckpointValidatorSet = errors.New("checkpoint validator set hash mismatch")
+)
+
+// Checkpoint is a signed epoch checkpoint of the canonical chain. The aggregated
+// finality signature is the one included in the child of the checkpoint block, it
+// is signed by the validator set of the snapshot at the checkpoint block, which is
+// shipped along so that a node can be bootstrapped from the checkpoint.
+type Checkpoint struct {
+	Number                  uint64                      `json:"number"`
+	Hash                    common.Hash                 `json:"hash"`
+	ValidatorSetHash        common.Hash                 `json:"validatorSetHash"`
+	FinalityVotedValidators finality.FinalityVoteBitSet `json:"finalityVotedValidators"`
+	AggregatedFinalityVotes hexutil.Bytes               `json:"aggregatedFinalityVotes"`
+	PreviousKeyVoters       finality.FinalityVoteBitSet `json:"previousKeyVoters,omitempty"` // Voters that signed with their previous BLS key
+	Snapshot                *Snapshot                   `json:"snapshot"`
+}
+
+// EpochLength returns the number of blocks between the epoch blocks of the chain
+func EpochLength(chainConfig *params.ChainConfig) uint64 {
+	if chainConfig.Consortium == nil || chainConfig.Consortium.EpochV2 == 0 {
+		return epochLength
+	}
+	return chainConfig.Consortium.EpochV2
+}
+
+// isCheckpointEpoch returns whether the block at number can be a checkpoint
+func isCheckpointEpoch(chainConfig *params.ChainConfig, number uint64) bool {
+	return number != 0 && number%EpochLength(chainConfig) == 0 && chainConfig.IsShillin(new(big.Int).SetUint64(number))
+}
+
+// ValidatorSetHash returns the hash of the validators with their BLS public keys
+// in order
+func ValidatorSetHash(validators []finality.ValidatorWithBlsPub) common.Hash {
+	var blob []byte
+	for _, validator := range validators {
+		blob = append(blob, validator.Address[:]...)
+		if validator.BlsPublicKey != nil {
+			blob = append(blob, validator.BlsPublicKey.Marshal()...)
+		}
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// ReadCheckpoint returns the checkpoint of the canonical epoch block at number.
+// The snapshot of the epoch block is read from the database, it is stored by the
+// engine when the block is verified.
+func ReadCheckpoint(db ethdb.Database, chainConfig *params.ChainConfig, number uint64) (*Checkpoint, error) {
+	if !isCheckpointEpoch(chainConfig, number) {
+		return nil, fmt.Errorf("%w: block %d", errCheckpointNotEpoch, number)
+	}
+	hash := rawdb.ReadCanonicalHash(db, number)
+	if hash == (common.Hash{}) {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+	child := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, number+1), number+1)
+	if child == nil {
+		return nil, fmt.Errorf("child of block %d not found", number)
+	}
+	extraData, err := finality.DecodeExtra(child.Extra, true)
+	if err != nil {
+		return nil, err
+	}
+	if extraData.HasFinalityVote != 1 {
+		return nil, fmt.Errorf("%w: block %d", errCheckpointNotVoted, number)
+	}
+	snap, err := loadSnapshot(chainConfig.Consortium, nil, db, hash, nil, chainConfig)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot of block %d not found: %v", number, err)
+	}
+	return &Checkpoint{
+		Number:                  number,
+		Hash:                    hash,
+		ValidatorSetHash:        ValidatorSetHash(snap.ValidatorsWithBlsPub),
+		FinalityVotedValidators: extraData.FinalityVotedValidators,
+		AggregatedFinalityVotes: extraData.AggregatedFinalityVotes.Marshal(),
+		PreviousKeyVoters:       extraData.PreviousKeyVoters,
+		Snapshot:                snap,
+	}, nil
+}
+
+// VerifyCheckpoint verifies the snapshot of the checkpoint against the validator
+// set hash and the aggregated finality signature against the validator set. The
+// checkpoint is only as trusted as the validator set, which is not verified
+// against the chain.
+func VerifyCheckpoint(chainConfig *params.ChainConfig, checkpoint *Checkpoint) error {
+	if !isCheckpointEpoch(chainConfig, checkpoint.Number) {
+		return fmt.Errorf("%w: block %d", errCheckpointNotEpoch, checkpoint.Number)
+	}
+	snap := checkpoint.Snapshot
+	if snap == nil || snap.Version != SnapshotVersion || snap.Number != checkpoint.Number || snap.Hash != checkpoint.Hash ||
+		len(snap.ValidatorsWithBlsPub) == 0 {
+		return errCheckpointSnapshot
+	}
+	if hash := ValidatorSetHash(snap.ValidatorsWithBlsPub); hash != checkpoint.ValidatorSetHash {
+		return fmt.Errorf("%w: have %x, want %x", errCheckpointValidatorSet, hash, checkpoint.ValidatorSetHash)
+	}
+	signature, err := blst.SignatureFromBytes(checkpoint.AggregatedFinalityVotes)
+	if err != nil {
+		return err
+	}
+	digest := types.NewVoteData(chainConfig, checkpoint.Number, checkpoint.Hash, snap.JustifiedBlockNumber, snap.JustifiedBlockHash).Hash()
+	// The votes are included in the child of the checkpoint block
+	threshold := chainConfig.Consortium.FinalityThreshold(new(big.Int).SetUint64(checkpoint.Number+1), len(snap.ValidatorsWithBlsPub))
+	return verifier.VerifyRotatedFinalitySignatures(snap.ValidatorsWithBlsPub, snap.previousBlsKeys(), checkpoint.FinalityVotedValidators, checkpoint.PreviousKeyVoters, signature, digest, threshold)
+}
+
+// WriteTrustedCheckpoint stores the snapshot of the checkpoint and marks the
+// checkpoint block as trusted, so that the chain can be synced from scratch
+// without verifying the headers below it. The checkpoint must be verified.
+func WriteTrustedCheckpoint(db ethdb.KeyValueWriter, checkpoint *Checkpoint) error {
+	if err := checkpoint.Snapshot.store(db); err != nil {
+		return err
+	}
+	rawdb.WriteTrustedCheckpoint(db, checkpoint.Number, checkpoint.Hash)
+	return nil
+}
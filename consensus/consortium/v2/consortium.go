@@ -1,16 +1,16 @@
 package v2
 
 import (
-	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
-	"math"
 	"math/big"
 	"math/rand"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -19,9 +19,11 @@ import (
 	"github.com/common-nighthawk/go-figure"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus"
 	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
 	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
 	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -29,27 +31,35 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/chaos"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
-	"golang.org/x/crypto/sha3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 const (
-	inmemorySnapshots  = 128  // Number of recent vote snapshots to keep in memory
-	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+	inmemorySnapshots = 128  // Number of recent vote snapshots to keep in memory
+	verifiedHeaders   = 4096 // Number of recent verified headers to keep in memory
 
 	wiggleTime          = 1000 * time.Millisecond // Random delay (per signer) to allow concurrent signers
 	unSealableValidator = -1
 
-	finalityRatio                  float64 = 2.0 / 3
-	assemblingFinalityVoteDuration         = 1 * time.Second
+	maxAllowedFutureBlockTime = time.Second // Maximum tolerated clock drift of the block time
+
+	assemblingFinalityVoteDuration = 1 * time.Second
+	finalityVotePollInterval       = 50 * time.Millisecond
+
+	backlogOutOfTurnBackoff = 500 * time.Millisecond // Extra delay of the out-of-turn validators under tx backlog
 )
 
+// lateFinalityVotes gates re-assembling the finality votes that arrive after signing
+var lateFinalityVotes = features.Register("finality.latevotes", "Re-assemble the finality votes that arrive after signing a block", true, true)
+
 // Consortium delegated proof-of-stake protocol constants.
 var (
 	epochLength = uint64(30000) // Default number of blocks after which to checkpoint
@@ -62,7 +72,7 @@ var (
 
 var (
 	// errUnauthorizedValidator is returned if a header is signed by a non-authorized entity.
-	errUnauthorizedValidator = errors.New("unauthorized validator")
+	errUnauthorizedValidator = verifier.ErrUnauthorizedValidator
 
 	// errOutOfRangeChain is returned if an authorization list is attempted to
 	// be modified via out-of-range or non-contiguous headers.
@@ -77,7 +87,7 @@ var (
 	errRecentlySigned = errors.New("recently signed")
 
 	// errCoinBaseMisMatch is returned if a header's coinbase do not match with signature
-	errCoinBaseMisMatch = errors.New("coinbase do not match with signature")
+	errCoinBaseMisMatch = verifier.ErrCoinBaseMisMatch
 
 	// errMismatchingEpochValidators is returned if a sprint block contains a
 	// list of validators different from the one the local node calculated.
@@ -93,14 +103,17 @@ type Consortium struct {
 	genesisHash common.Hash
 	db          ethdb.Database // Database to store and retrieve snapshot checkpoints
 
-	recents    *lru.ARCCache // Snapshots for recent block to speed up reorgs
-	signatures *lru.ARCCache // Signatures of recent blocks to speed up mining
+	recents    *lru.Cache    // Snapshots for recent block to speed up reorgs
+	signatures *lru.Cache    // Sealers of recent blocks shared by the process, see consortiumCommon.Sealers
+	verified   *lru.ARCCache // Recent headers passing the verification, see verifiedHeaderKey
+	timings    *lru.ARCCache // Verification timings of the recent headers, see VerificationTiming
 
-	lock     sync.RWMutex              // Protects the below 4 fields
-	val      common.Address            // Ethereum address of the signing key
-	signFn   consortiumCommon.SignerFn // Signer function to authorize hashes with
-	signTxFn consortiumCommon.SignerTxFn
-	contract consortiumCommon.ContractInteraction
+	lock        sync.RWMutex              // Protects the below 5 fields
+	val         common.Address            // Ethereum address of the signing key
+	signFn      consortiumCommon.SignerFn // Signer function to authorize hashes with
+	signTxFn    consortiumCommon.SignerTxFn
+	contract    consortiumCommon.ContractInteraction
+	sealingKeys []*sealingKey // All the authorized keys, the val is selected from them
 
 	signer types.Signer
 	ethAPI *ethapi.PublicBlockChainAPI
@@ -108,7 +121,46 @@ type Consortium struct {
 	fakeDiff bool
 	v1       consortiumCommon.ConsortiumAdapter
 
-	votePool consensus.VotePool
+	votePool           consensus.VotePool
+	voteAssemblyWindow time.Duration // Maximum delay of the block waiting for the finality vote quorum
+
+	vanity [finality.ExtraVanity]byte // Vanity prefix of the sealed blocks, see SetVanity
+
+	allowedFutureBlockTime int64 // Tolerated clock drift of the block time, accessed atomically
+
+	snapshotStore   SnapshotStore    // Optional store of the snapshots separate from db
+	snapshotArchive *SnapshotArchive // Optional archive of the finalized checkpoint snapshots
+
+	doubleSignReporter *doubleSignReporter
+	sealGuards         []func() error // Checks that must pass before sealing a block
+	inactivityTracker  *inactivityTracker
+	scoreTracker       *scoreTracker
+
+	sealBacklogThreshold int        // Number of pending txs above which the seal delay adapts to the backlog
+	pendingTxsFn         func() int // Number of pending txs in the local pool
+
+	epochPrefetched uint64 // Number of the last wrap up epoch block whose state is prefetched (atomic)
+
+	validatorSetOverride *ValidatorSetOverride // Emergency checkpoint validators replacing the contract result
+
+	clock      mclock.Clock // Clock of the sealing timers, the system clock if nil, see SetClock
+	clockEpoch time.Time    // Wall time at the zero of the clock
+
+	sealingStats sealingStats // Latency of the in-turn blocks, see adaptiveWiggleDelay
+
+	wrapUpFailureFn WrapUpFailureFn            // Optional, called when the epoch wrap-up fails
+	voteWaitFn      func(header *types.Header) // Optional, called when the sealer starts waiting for the finality votes
+
+	lightVerification lightVerification // Light verification mode of the followers, see SetLightVerification
+
+	cacheConfig  atomic.Value // CacheConfig of the caches and the checkpoint interval, see SetCacheConfig
+	replayTracer atomic.Value // *replayTracer of the replayed blocks, see ReplayBlocks
+
+	gasLimitTarget atomic.Value // gasLimitTarget of the current epoch, see targetGasLimit
+
+	quit      chan struct{}  // Closed when the engine is closed to stop the background loops
+	closeOnce sync.Once      // Ensures quit is closed once
+	wg        sync.WaitGroup // Background loops, see StartInactivityTracker
 }
 
 // New creates a Consortium delegated proof-of-stake consensus engine
@@ -126,8 +178,9 @@ func New(
 	}
 
 	// Allocate the snapshot caches and create the engine
-	recents, _ := lru.NewARC(inmemorySnapshots)
-	signatures, _ := lru.NewARC(inmemorySignatures)
+	recents, _ := lru.New(inmemorySnapshots)
+	verified, _ := lru.NewARC(verifiedHeaders)
+	timings, _ := lru.NewARC(verifiedHeaders)
 
 	consortium := Consortium{
 		chainConfig: chainConfig,
@@ -136,10 +189,13 @@ func New(
 		db:          db,
 		ethAPI:      ethAPI,
 		recents:     recents,
-		signatures:  signatures,
+		signatures:  consortiumCommon.Sealers,
+		verified:    verified,
+		timings:     timings,
 		signer:      types.NewEIP155Signer(chainConfig.ChainID),
 		v1:          v1,
 		forkedBlock: chainConfig.ConsortiumV2Block.Uint64(),
+		quit:        make(chan struct{}),
 	}
 	err := consortium.initContract(common.Address{}, nil)
 	if err != nil {
@@ -226,6 +282,9 @@ func (c *Consortium) VerifyVote(chain consensus.ChainHeaderReader, vote *types.V
 	if err != nil {
 		return err
 	}
+	if err := verifyVoteData(vote.Data, c.voteData(vote.Data.TargetNumber, vote.Data.TargetHash, snap)); err != nil {
+		return err
+	}
 
 	publicKey, err := blst.PublicKeyFromBytes(vote.PublicKey[:])
 	if err != nil {
@@ -238,6 +297,34 @@ func (c *Consortium) VerifyVote(chain consensus.ChainHeaderReader, vote *types.V
 	return nil
 }
 
+// voteData returns the vote data for the target block, snap is the snapshot at
+// the target block whose justified block is the source
+func (c *Consortium) voteData(number uint64, hash common.Hash, snap *Snapshot) *types.VoteData {
+	return types.NewVoteData(c.chainConfig, number, hash, snap.JustifiedBlockNumber, snap.JustifiedBlockHash)
+}
+
+// verifyVoteData checks that the vote v2 fields of the vote data match the
+// expected ones, so that a vote signed for another network or another fork of
+// the chain is rejected
+func verifyVoteData(data, expected *types.VoteData) error {
+	if data.IsV2() != expected.IsV2() {
+		return finality.ErrInvalidVoteVersion
+	}
+	if !expected.IsV2() {
+		return nil
+	}
+	if data.ChainID.Cmp(expected.ChainID) != 0 {
+		return finality.ErrInvalidVoteChainID
+	}
+	if data.SourceNumber != expected.SourceNumber || data.SourceHash != expected.SourceHash {
+		return finality.ErrInvalidVoteSource
+	}
+	if data.TargetEpoch != expected.TargetEpoch {
+		return finality.ErrInvalidVoteEpoch
+	}
+	return nil
+}
+
 // verifyFinalitySignatures verifies the finality signatures in the block header
 func (c *Consortium) verifyFinalitySignatures(
 	chain consensus.ChainHeaderReader,
@@ -247,35 +334,48 @@ func (c *Consortium) verifyFinalitySignatures(
 	parentHash common.Hash,
 	parents []*types.Header,
 ) error {
-	snap, err := c.snapshot(chain, parentNumber, parentHash, parents)
+	verifySignatures, err := c.finalitySignaturesCheck(context.Background(), chain, finalityVotedValidators, nil, finalitySignatures, parentNumber, parentHash, parents)
 	if err != nil {
 		return err
 	}
+	return verifySignatures()
+}
 
-	votedValidatorPositions := finalityVotedValidators.Indices()
-	if len(votedValidatorPositions) < int(math.Floor(finalityRatio*float64(len(snap.ValidatorsWithBlsPub))))+1 {
-		return finality.ErrNotEnoughFinalityVote
-	}
-
-	voteData := types.VoteData{
-		TargetNumber: parentNumber,
-		TargetHash:   parentHash,
+// finalitySignaturesCheck returns the verification of the finality signatures in
+// the block header against the validator set of the parent snapshot, the snapshot
+// is retrieved before returning so the verification can run apart
+func (c *Consortium) finalitySignaturesCheck(
+	ctx context.Context,
+	chain consensus.ChainHeaderReader,
+	finalityVotedValidators finality.FinalityVoteBitSet,
+	previousKeyVoters finality.FinalityVoteBitSet,
+	finalitySignatures blsCommon.Signature,
+	parentNumber uint64,
+	parentHash common.Hash,
+	parents []*types.Header,
+) (func() error, error) {
+	snap, err := c.snapshotContext(ctx, chain, parentNumber, parentHash, parents)
+	if err != nil {
+		return nil, err
 	}
-	digest := voteData.Hash()
 
-	// verify aggregated signature
-	var publicKeys []blsCommon.PublicKey
-	for _, position := range votedValidatorPositions {
-		if position >= len(snap.ValidatorsWithBlsPub) {
-			return finality.ErrInvalidFinalityVotedBitSet
+	var (
+		validators = snap.ValidatorsWithBlsPub
+		previous   = snap.previousBlsKeys()
+		digest     = c.voteData(parentNumber, parentHash, snap).Hash()
+		threshold  = c.config.FinalityThreshold(new(big.Int).SetUint64(parentNumber+1), len(validators))
+	)
+	return func() error {
+		_, span := consortiumCommon.StartSpan(ctx, "verifyFinalitySignatures", parentNumber+1, c.config.EpochV2)
+		defer span.End()
+
+		err := verifier.VerifyRotatedFinalitySignatures(validators, previous, finalityVotedValidators, previousKeyVoters, finalitySignatures, digest, threshold)
+		if tracer := c.tracer(); tracer != nil {
+			tracer.trace(parentNumber+1, "finalitySignatures", err, "voters", len(finalityVotedValidators.Indices()),
+				"validators", len(validators), "threshold", threshold, "digest", digest)
 		}
-		publicKeys = append(publicKeys, snap.ValidatorsWithBlsPub[position].BlsPublicKey)
-	}
-	if !finalitySignatures.FastAggregateVerify(publicKeys, digest) {
-		return finality.ErrFinalitySignatureVerificationFailed
-	}
-
-	return nil
+		return err
+	}, nil
 }
 
 // VerifyHeaderAndParents checks whether a header conforms to the consensus rules.The
@@ -283,57 +383,154 @@ func (c *Consortium) verifyFinalitySignatures(
 // looking those up from the database. This is useful for concurrently verifying
 // a batch of new headers.
 func (c *Consortium) VerifyHeaderAndParents(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) error {
+	verifySignatures, err := c.VerifyHeaderAndParentsDeferred(context.Background(), chain, header, parents)
+	if err != nil {
+		return err
+	}
+	return verifySignatures()
+}
+
+// VerifyHeaderAndParentsDeferred is VerifyHeaderAndParents with the verification
+// of the finality signatures, the BLS aggregate check dominating the cost of the
+// header verification, returned to be run apart. The header is only valid if both
+// the returned error and the error of the returned verification are nil. The
+// spans of the verification are children of the span in the context.
+func (c *Consortium) VerifyHeaderAndParentsDeferred(ctx context.Context, chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) (func() error, error) {
 	if header.Number == nil {
-		return consortiumCommon.ErrUnknownBlock
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+	// The same header is verified by the fetcher, the downloader and the block
+	// import, only the successful verifications are cached as the failures may
+	// be transient, e.g. a future block or an unknown ancestor. The light verified
+	// headers are not cached as their finality signatures are not checked.
+	var key verifiedHeaderKey
+	if c.verified != nil {
+		key = verifiedHeaderKey{hash: header.Hash(), epoch: c.config.EpochV2}
+		if c.verified.Contains(key) {
+			return noSignatures, nil
+		}
+	}
+	start := time.Now()
+	verifySignatures, light, err := c.verifyHeaderAndParents(ctx, chain, header, parents)
+	if err != nil {
+		return nil, err
+	}
+	headerTime := time.Since(start)
+	return func() error {
+		start := time.Now()
+		if err := verifySignatures(); err != nil {
+			return err
+		}
+		if c.verified != nil && !light {
+			c.verified.Add(key, struct{}{})
+		}
+		if c.timings != nil {
+			c.timings.Add(header.Hash(), VerificationTiming{Header: headerTime, Signatures: time.Since(start)})
+		}
+		return nil
+	}, nil
+}
+
+// VerificationTiming is the duration of the first successful verification of a
+// header, the finality signatures are verified apart from the other rules.
+type VerificationTiming struct {
+	Header     time.Duration
+	Signatures time.Duration
+}
+
+// VerificationTiming returns the verification timing of the recently verified
+// header with the hash
+func (c *Consortium) VerificationTiming(hash common.Hash) (VerificationTiming, bool) {
+	if c.timings == nil {
+		return VerificationTiming{}, false
 	}
+	timing, ok := c.timings.Get(hash)
+	if !ok {
+		return VerificationTiming{}, false
+	}
+	return timing.(VerificationTiming), true
+}
+
+// noSignatures is the verification of a header without finality signatures
+func noSignatures() error { return nil }
+
+// verifiedHeaderKey is the key of a verified header in the cache, the header
+// hash commits to the parent and the seal, the epoch is the engine config the
+// extra data is verified against.
+type verifiedHeaderKey struct {
+	hash  common.Hash
+	epoch uint64
+}
+
+// verifyHeaderAndParents checks the header except its finality signatures, whose
+// verification is returned along with whether they are skipped by the light
+// verification
+func (c *Consortium) verifyHeaderAndParents(ctx context.Context, chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) (func() error, bool, error) {
 	number := header.Number.Uint64()
 
 	isShillin := c.chainConfig.IsShillin(header.Number)
 	extraData, err := finality.DecodeExtra(header.Extra, isShillin)
 	if err != nil {
-		return err
+		return nil, false, err
 	}
 
 	// Check extra data
 	isEpoch := number%c.config.EpochV2 == 0 || c.chainConfig.IsOnConsortiumV2(header.Number)
 
 	if !isEpoch && len(extraData.CheckpointValidators) != 0 {
-		return consortiumCommon.ErrExtraValidators
+		return nil, false, consortiumCommon.ErrExtraValidators
 	}
 
+	// The voters beyond the 64 bits bit set are only encoded from Venoki
+	if isShillin && extraData.HasFinalityVote == 1 && !c.chainConfig.IsVenoki(header.Number) {
+		if _, ok := extraData.FinalityVotedValidators.Uint64(); !ok {
+			return nil, false, finality.ErrInvalidFinalityVotedBitSet
+		}
+	}
+
+	verifySignatures, light := noSignatures, false
 	if isShillin && extraData.HasFinalityVote == 1 {
-		if err := c.verifyFinalitySignatures(
+		light = c.skipFinalitySignatures(chain, header, parents)
+	}
+	if isShillin && extraData.HasFinalityVote == 1 && !light {
+		verifySignatures, err = c.finalitySignaturesCheck(
+			ctx,
 			chain,
 			extraData.FinalityVotedValidators,
+			extraData.PreviousKeyVoters,
 			extraData.AggregatedFinalityVotes,
 			header.Number.Uint64()-1,
 			header.ParentHash,
 			parents,
-		); err != nil {
-			return err
+		)
+		if err != nil {
+			return nil, false, err
 		}
 	}
 
 	// Ensure that the mix digest is zero as we don't have fork protection currently
 	if header.MixDigest != (common.Hash{}) {
-		return consortiumCommon.ErrInvalidMixDigest
+		return nil, false, consortiumCommon.ErrInvalidMixDigest
 	}
 	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
 	if header.UncleHash != uncleHash {
-		return consortiumCommon.ErrInvalidUncleHash
+		return nil, false, consortiumCommon.ErrInvalidUncleHash
 	}
 	// Ensure that the block's difficulty is meaningful (may not be correct at this point)
 	if number > 0 {
 		if header.Difficulty == nil {
-			return consortiumCommon.ErrInvalidDifficulty
+			return nil, false, consortiumCommon.ErrInvalidDifficulty
 		}
 	}
 	// If all checks passed, validate any special fields for hard forks
 	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
-		return err
+		return nil, false, err
 	}
 	// All basic checks passed, verify cascading fields
-	return c.verifyCascadingFields(chain, header, parents)
+	if err := c.verifyCascadingFields(chain, header, parents); err != nil {
+		return nil, false, err
+	}
+	return verifySignatures, light, nil
 }
 
 // verifyCascadingFields verifies all the header fields that are not standalone,
@@ -382,15 +579,34 @@ func (c *Consortium) verifyCascadingFields(chain consensus.ChainHeaderReader, he
 	}
 
 	// All basic checks passed, verify the seal and return
-	return c.verifySeal(chain, header, parents, snap)
+	err = c.verifySeal(chain, header, parents, snap)
+	if tracer := c.tracer(); tracer != nil {
+		signer, _ := verifier.Ecrecover(header, c.signatures, c.chainConfig.ChainID)
+		tracer.trace(number, "seal", err, "signer", signer, "inturn", snap.inturn(header.Coinbase),
+			"recentlySigned", snap.IsRecentlySigned(header.Coinbase), "difficulty", header.Difficulty)
+	}
+	if err != nil {
+		return err
+	}
+	c.recordSealing(header, parent)
+	return nil
 }
 
 // snapshot retrieves the authorization snapshot at a given point in time.
 func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	return c.snapshotContext(context.Background(), chain, number, hash, parents)
+}
+
+// snapshotContext is snapshot traced as a child of the span in the context
+func (c *Consortium) snapshotContext(ctx context.Context, chain consensus.ChainHeaderReader, number uint64, hash common.Hash, parents []*types.Header) (*Snapshot, error) {
+	_, span := consortiumCommon.StartSpan(ctx, "snapshot", number, c.config.EpochV2)
+	defer span.End()
+
 	// Search for a snapshot in memory or on disk for checkpoints
 	var (
 		headers    []*types.Header
 		snap       *Snapshot
+		source     string // Where the base snapshot is found, only traced
 		cpyParents = make([]*types.Header, len(parents))
 	)
 	// NOTE(linh): We must copy parents before going to the loop because parents are modified.
@@ -400,7 +616,7 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 	for snap == nil {
 		// If an in-memory snapshot was found, use that
 		if s, ok := c.recents.Get(hash); ok {
-			snap = s.(*Snapshot)
+			snap, source = s.(*Snapshot), "memory"
 			break
 		}
 
@@ -410,7 +626,7 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 			if err != nil {
 				return nil, err
 			}
-			snap = newSnapshot(c.chainConfig, c.config, c.signatures, number, hash, validators, nil, c.ethAPI)
+			snap, source = newSnapshot(c.chainConfig, c.config, c.signatures, number, hash, validators, nil, c.ethAPI), "genesis"
 			break
 		}
 
@@ -419,9 +635,10 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 			var (
 				err error
 			)
-			snap, err = loadSnapshot(c.config, c.signatures, c.db, hash, c.ethAPI, c.chainConfig)
+			snap, err = c.readSnapshot(number, hash)
 			if err == nil {
 				log.Trace("Loaded snapshot from disk", "number", number, "hash", hash.Hex())
+				source = "disk"
 				break
 			}
 
@@ -446,22 +663,24 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 			// 	So the final result must be [998: addressN - 1,999: addressN]
 			snap.Recents = consortiumCommon.RemoveOutdatedRecents(snapV1.Recents, number)
 
-			if err := snap.store(c.db); err != nil {
+			if err := snap.store(c.snapshotDB()); err != nil {
 				return nil, err
 			}
 			log.Info("Stored checkpoint snapshot to disk", "number", number, "hash", hash)
 			figure.NewColorFigure("Welcome to DPOS", "", "green", true).Print()
+			source = "fork"
 			break
 		}
 
 		// If an on-disk checkpoint snapshot can be found, use that
 		if number%c.config.EpochV2 == 0 {
 			var err error
-			snap, err = loadSnapshot(c.config, c.signatures, c.db, hash, c.ethAPI, c.chainConfig)
+			snap, err = c.readSnapshot(number, hash)
 			if err != nil {
 				log.Debug("Load snapshot failed", "number", number, "hash", hash.Hex())
 			} else {
 				log.Trace("Loaded snapshot from disk", "number", number, "hash", hash.Hex())
+				source = "disk"
 				break
 			}
 		}
@@ -497,6 +716,7 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 		headers[i], headers[len(headers)-1-i] = headers[len(headers)-1-i], headers[i]
 	}
 
+	base := snap.Number
 	snap, err := snap.apply(headers, chain, cpyParents, c.chainConfig.ChainID)
 	if err != nil {
 		return nil, err
@@ -504,12 +724,18 @@ func (c *Consortium) snapshot(chain consensus.ChainHeaderReader, number uint64,
 	c.recents.Add(snap.Hash, snap)
 
 	// If we've generated a new checkpoint snapshot, save to disk
-	if snap.Number%c.config.EpochV2 == 0 && len(headers) > 0 {
-		if err = snap.store(c.db); err != nil {
+	stored := false
+	if snap.Number%c.config.EpochV2 == 0 && len(headers) > 0 && c.persistCheckpoint(snap.Number) {
+		if err = snap.store(c.snapshotDB()); err != nil {
 			return nil, err
 		}
+		stored = true
 		log.Trace("Stored snapshot to disk", "number", snap.Number, "hash", snap.Hash)
 	}
+	if tracer := c.tracer(); tracer != nil {
+		tracer.trace(snap.Number+1, "snapshot", nil, "snapshot", snap.Number, "hash", snap.Hash, "source", source, "base", base,
+			"applied", len(headers), "stored", stored, "validators", len(snap.validators()))
+	}
 	log.Trace("Checking snapshot data", "number", snap.Number, "validators", snap.validators())
 	return snap, err
 }
@@ -535,7 +761,7 @@ func (c *Consortium) verifySeal(chain consensus.ChainHeaderReader, header *types
 	}
 
 	// Resolve the authorization key and check against validators
-	signer, err := ecrecover(header, c.signatures, c.chainConfig.ChainID)
+	signer, err := verifier.Ecrecover(header, c.signatures, c.chainConfig.ChainID)
 	if err != nil {
 		return err
 	}
@@ -588,9 +814,6 @@ func backOffTime(header *types.Header, snapshot *Snapshot, chainConfig *params.C
 		}
 	}
 
-	source := rand.NewSource(header.Number.Int64())
-	rand := rand.New(source)
-
 	// Every validator that can seal a block may have different delay
 	// The delay equals to their random delayMultiplier * wiggleTime.
 	// The delayMultiplier is random in range [1, numOfSealableValidators]
@@ -598,9 +821,18 @@ func backOffTime(header *types.Header, snapshot *Snapshot, chainConfig *params.C
 	for i := range delayMultiplier {
 		delayMultiplier[i] = i + 1
 	}
-	rand.Shuffle(len(delayMultiplier), func(i, j int) {
-		delayMultiplier[i], delayMultiplier[j] = delayMultiplier[j], delayMultiplier[i]
-	})
+	// In deterministic block time mode, the delayMultiplier is position + 1
+	if !isDeterministicBlockTime(chainConfig) {
+		seed := header.Number.Int64()
+		if chainConfig.IsAaron(header.Number) {
+			seed = backOffSeed(header.Number.Uint64(), snapshot.validators())
+		}
+		source := rand.NewSource(seed)
+		rand := rand.New(source)
+		rand.Shuffle(len(delayMultiplier), func(i, j int) {
+			delayMultiplier[i], delayMultiplier[j] = delayMultiplier[j], delayMultiplier[i]
+		})
+	}
 
 	if chainConfig.IsOlek(new(big.Int).SetUint64(snapshot.Number + 1)) {
 		return uint64((int(initialDelay) + (delayMultiplier[position]-1)*int(wiggleTime)) / int(time.Second))
@@ -609,6 +841,27 @@ func backOffTime(header *types.Header, snapshot *Snapshot, chainConfig *params.C
 	}
 }
 
+// backOffSeed returns the seed of the out-of-turn delay permutation from Aaron.
+// Mixing the validator set in rotates the validators getting the short backoffs
+// across the epochs instead of repeating the same order at each block number.
+// The seed is fixed before the epoch starts, the sealer of the parent cannot
+// grind it with its vanity, timestamp or transactions as it could a seed taken
+// from the parent hash.
+func backOffSeed(number uint64, validators []common.Address) int64 {
+	blob := make([]byte, 8, 8+len(validators)*common.AddressLength)
+	binary.BigEndian.PutUint64(blob, number)
+	for _, validator := range validators {
+		blob = append(blob, validator[:]...)
+	}
+	return int64(binary.BigEndian.Uint64(crypto.Keccak256(blob)[:8]))
+}
+
+// isDeterministicBlockTime returns true if the out-of-turn sealing delay must
+// not be randomized
+func isDeterministicBlockTime(chainConfig *params.ChainConfig) bool {
+	return chainConfig.Consortium != nil && chainConfig.Consortium.DeterministicBlockTime
+}
+
 func (c *Consortium) computeHeaderTime(header, parent *types.Header, snapshot *Snapshot) uint64 {
 	headerTime := parent.Time + c.config.Period
 
@@ -616,21 +869,31 @@ func (c *Consortium) computeHeaderTime(header, parent *types.Header, snapshot *S
 		headerTime += backOffTime(header, snapshot, c.chainConfig)
 	}
 
-	if headerTime < uint64(time.Now().Unix()) {
-		headerTime = uint64(time.Now().Unix())
+	if now := uint64(c.now().Unix()); headerTime < now {
+		headerTime = now
 	}
 	return headerTime
 }
 
 func (c *Consortium) verifyHeaderTime(header, parent *types.Header, snapshot *Snapshot) error {
-	if header.Time > uint64(time.Now().Unix()) {
+	if time.Unix(int64(header.Time), 0).After(c.now().Add(c.futureBlockTime())) {
 		return consensus.ErrFutureBlock
 	}
 
 	if c.chainConfig.IsBuba(header.Number) {
-		expectedHeaderTime := parent.Time + c.config.Period + backOffTime(header, snapshot, c.chainConfig)
+		backOff := backOffTime(header, snapshot, c.chainConfig)
+		expectedHeaderTime := parent.Time + c.config.Period + backOff
+
+		var err error
 		if header.Time < expectedHeaderTime {
-			return consensus.ErrFutureBlock
+			err = consensus.ErrFutureBlock
+		}
+		if tracer := c.tracer(); tracer != nil {
+			tracer.trace(header.Number.Uint64(), "backoff", err, "coinbase", header.Coinbase, "inturn", snapshot.inturn(header.Coinbase),
+				"parentTime", parent.Time, "period", c.config.Period, "backoff", backOff, "expected", expectedHeaderTime, "time", header.Time)
+		}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -641,8 +904,25 @@ func (c *Consortium) getCheckpointValidatorsFromContract(
 	header *types.Header,
 ) ([]finality.ValidatorWithBlsPub, error) {
 
+	if validators, ok := c.overriddenCheckpointValidators(header.Number.Uint64()); ok {
+		return validators, nil
+	}
+
 	parentBlockNumber := new(big.Int).Sub(header.Number, common.Big1)
 	_, _, _, contract := c.readSignerAndContract()
+	// Pin the reads to the parent block so the validators and their BLS public
+	// keys are read from the same state even if the head changes meanwhile
+	contract = contract.Pin(parentBlockNumber, header.ParentHash)
+	return c.readCheckpointValidators(contract, header.Number)
+}
+
+// readCheckpointValidators reads the validators of the checkpoint block, with
+// their BLS public keys from Shillin, from the contracts at its parent block
+func (c *Consortium) readCheckpointValidators(
+	contract consortiumCommon.ContractInteraction,
+	number *big.Int,
+) ([]finality.ValidatorWithBlsPub, error) {
+	parentBlockNumber := new(big.Int).Sub(number, common.Big1)
 	newValidators, err := contract.GetValidators(parentBlockNumber)
 	if err != nil {
 		return nil, err
@@ -654,7 +934,7 @@ func (c *Consortium) getCheckpointValidatorsFromContract(
 		filteredValidators  []common.Address = newValidators
 	)
 
-	isShillin := c.chainConfig.IsShillin(header.Number)
+	isShillin := c.chainConfig.IsShillin(number)
 	if isShillin {
 		// The filteredValidators shares the same underlying array with newValidators
 		// See more: https://github.com/golang/go/wiki/SliceTricks#filtering-without-allocating
@@ -687,21 +967,32 @@ func (c *Consortium) getCheckpointValidatorsFromContract(
 // Prepare implements consensus.Engine, preparing all the consensus fields of the
 // header for running the transactions on top.
 func (c *Consortium) Prepare(chain consensus.ChainHeaderReader, header *types.Header) error {
-	coinbase, _, _, _ := c.readSignerAndContract()
-	header.Coinbase = coinbase
-	header.Nonce = types.BlockNonce{}
-
 	number := header.Number.Uint64()
 	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return err
 	}
 
+	c.selectSealingKey(snap)
+	coinbase, _, _, _ := c.readSignerAndContract()
+	header.Coinbase = coinbase
+	c.prefetchEpochState(chain, header, snap)
+	return c.prepareHeader(chain, header, snap, coinbase)
+}
+
+// prepareHeader prepares the consensus fields of the header sealed by coinbase on
+// top of the snapshot of its parent
+func (c *Consortium) prepareHeader(chain consensus.ChainHeaderReader, header *types.Header, snap *Snapshot, coinbase common.Address) error {
+	number := header.Number.Uint64()
+	header.Coinbase = coinbase
+	header.Nonce = types.BlockNonce{}
+
 	// Set the correct difficulty
 	header.Difficulty = CalcDifficulty(snap, coinbase)
 
 	isShillin := c.chainConfig.IsShillin(header.Number)
 	var extraData finality.HeaderExtraData
+	extraData.Vanity = c.sealingVanity()
 
 	if number%c.config.EpochV2 == 0 || c.chainConfig.IsOnConsortiumV2(big.NewInt(int64(number))) {
 		checkpointValidator, err := c.getCheckpointValidatorsFromContract(header)
@@ -726,11 +1017,14 @@ func (c *Consortium) Prepare(chain consensus.ChainHeaderReader, header *types.He
 		return consensus.ErrUnknownAncestor
 	}
 
+	_, _, _, contract := c.readSignerAndContract()
+	c.applyGasLimitTarget(header, parent, contract)
+
 	header.Time = c.computeHeaderTime(header, parent, snap)
 	return nil
 }
 
-func (c *Consortium) processSystemTransactions(chain consensus.ChainHeaderReader, header *types.Header,
+func (c *Consortium) processSystemTransactions(chain consensus.ChainHeaderReader, header *types.Header, contract consortiumCommon.ContractInteraction,
 	transactOpts *consortiumCommon.ApplyTransactOpts, isFinalizeAndAssemble bool) error {
 
 	snap, err := c.snapshot(chain, header.Number.Uint64()-1, header.ParentHash, nil)
@@ -738,8 +1032,6 @@ func (c *Consortium) processSystemTransactions(chain consensus.ChainHeaderReader
 		return err
 	}
 
-	_, _, _, contract := c.readSignerAndContract()
-
 	// If the parent's block includes the finality votes, distribute reward for the voters
 	if c.chainConfig.IsShillin(new(big.Int).Sub(header.Number, common.Big1)) {
 		parentHeader := chain.GetHeaderByHash(header.ParentHash)
@@ -760,62 +1052,120 @@ func (c *Consortium) processSystemTransactions(chain consensus.ChainHeaderReader
 				votedValidators = append(votedValidators, parentSnap.ValidatorsWithBlsPub[position].Address)
 			}
 
-			if err := contract.FinalityReward(transactOpts, votedValidators); err != nil {
+			err = contract.FinalityReward(transactOpts, votedValidators)
+			c.traceSystemTx(header, "finalityReward", err, "voters", len(votedValidators))
+			if err != nil {
 				log.Error("Failed to finality reward validator", "err", err)
 				return err
 			}
 		}
 	}
 
-	if header.Difficulty.Cmp(diffInTurn) != 0 {
-		spoiledVal := snap.supposeValidator()
-		signedRecently := false
-		if c.chainConfig.IsOlek(header.Number) {
-			signedRecently = snap.IsRecentlySigned(spoiledVal)
-		} else {
-			for _, recent := range snap.Recents {
-				if recent == spoiledVal {
-					signedRecently = true
-					break
-				}
-			}
+	if spoiledVal, spoiled := c.spoiledValidator(snap, header); spoiled {
+		if !isFinalizeAndAssemble {
+			log.Info("Slash validator", "number", header.Number, "spoiled", spoiledVal)
 		}
-		if !signedRecently {
-			if !isFinalizeAndAssemble {
-				log.Info("Slash validator", "number", header.Number, "spoiled", spoiledVal)
-			}
-			if err := contract.Slash(transactOpts, spoiledVal); err != nil {
-				// it is possible that slash validator failed because of the slash channel is disabled.
-				log.Error("Failed to slash validator", "block hash", header.Hash(), "address", spoiledVal)
-				return err
-			}
+		err := contract.Slash(transactOpts, spoiledVal)
+		c.traceSystemTx(header, "slash", err, "validator", spoiledVal)
+		if err != nil {
+			// it is possible that slash validator failed because of the slash channel is disabled.
+			log.Error("Failed to slash validator", "block hash", header.Hash(), "address", spoiledVal)
+			return err
 		}
 	}
 
 	// Previously, we call WrapUpEpoch before SubmitBlockReward which is the wrong order.
 	// We create a hardfork here to fix the contract call order.
 	if c.chainConfig.IsPuffy(header.Number) {
-		if err := contract.SubmitBlockReward(transactOpts); err != nil {
+		err := contract.SubmitBlockReward(transactOpts)
+		c.traceSystemTx(header, "submitBlockReward", err)
+		if err != nil {
 			log.Error("Failed to submit block reward", "err", err)
 			return err
 		}
 	}
 
 	if header.Number.Uint64()%c.config.EpochV2 == c.config.EpochV2-1 {
-		if err := contract.WrapUpEpoch(transactOpts); err != nil {
+		err := contract.WrapUpEpoch(transactOpts)
+		c.traceSystemTx(header, "wrapUpEpoch", err)
+		if err != nil {
 			log.Error("Failed to wrap up epoch", "err", err)
+			if c.wrapUpFailureFn != nil {
+				c.wrapUpFailureFn(header, isFinalizeAndAssemble, err)
+			}
 			return err
 		}
 	}
 
 	if !c.chainConfig.IsPuffy(header.Number) {
-		if err := contract.SubmitBlockReward(transactOpts); err != nil {
+		err := contract.SubmitBlockReward(transactOpts)
+		c.traceSystemTx(header, "submitBlockReward", err)
+		if err != nil {
 			log.Error("Failed to submit block reward", "err", err)
 			return err
 		}
 	}
 
-	return nil
+	return c.processDoubleSignReports(chain, contract, transactOpts, isFinalizeAndAssemble)
+}
+
+// traceSystemTx traces the system transaction calling the method of the system
+// contracts in the replayed block, see ReplayBlocks
+func (c *Consortium) traceSystemTx(header *types.Header, method string, err error, ctx ...interface{}) {
+	if tracer := c.tracer(); tracer != nil {
+		tracer.trace(header.Number.Uint64(), "systemTx", err, append([]interface{}{"method", method}, ctx...)...)
+	}
+}
+
+// SpoiledValidator returns the in-turn validator that does not seal the header,
+// see spoiledValidator
+func (c *Consortium) SpoiledValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return common.Address{}, false
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		log.Debug("Failed to get snapshot for spoiled validator", "number", number, "err", err)
+		return common.Address{}, false
+	}
+	return c.spoiledValidator(snap, header)
+}
+
+// InTurnValidator returns the validator whose turn it is to seal the header
+func (c *Consortium) InTurnValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return common.Address{}, false
+	}
+	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	if err != nil {
+		log.Debug("Failed to get snapshot for in-turn validator", "number", number, "err", err)
+		return common.Address{}, false
+	}
+	return snap.supposeValidator(), true
+}
+
+// spoiledValidator returns the in-turn validator that does not seal the header. It
+// returns false if the header is sealed in turn or the in-turn validator is not
+// allowed to seal because it has signed recently.
+func (c *Consortium) spoiledValidator(snap *Snapshot, header *types.Header) (common.Address, bool) {
+	if header.Difficulty.Cmp(diffInTurn) == 0 {
+		return common.Address{}, false
+	}
+	spoiledVal := snap.supposeValidator()
+	signedRecently := false
+	if c.chainConfig.IsOlek(header.Number) {
+		signedRecently = snap.IsRecentlySigned(spoiledVal)
+	} else {
+		for _, recent := range snap.Recents {
+			if recent == spoiledVal {
+				signedRecently = true
+				break
+			}
+		}
+	}
+	return spoiledVal, !signedRecently
 }
 
 // Finalize implements consensus.Engine that calls three methods from smart contracts:
@@ -824,7 +1174,11 @@ func (c *Consortium) processSystemTransactions(chain consensus.ChainHeaderReader
 // - SubmitBlockRewards of the current block
 func (c *Consortium) Finalize(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs *[]*types.Transaction,
 	uncles []*types.Header, receipts *[]*types.Receipt, systemTxs *[]*types.Transaction, internalTxs *[]*types.InternalTransaction, usedGas *uint64) error {
-	_, _, signTxFn, _ := c.readSignerAndContract()
+	_, span := consortiumCommon.StartSpan(context.Background(), "Finalize", header.Number.Uint64(), c.config.EpochV2,
+		attribute.Int("txs", len(*txs)))
+	defer span.End()
+
+	_, _, signTxFn, contract := c.readSignerAndContract()
 	evmContext := core.NewEVMBlockContext(header, consortiumCommon.ChainContext{Chain: chain, Consortium: c}, &header.Coinbase, chain.OpEvents()...)
 	transactOpts := &consortiumCommon.ApplyTransactOpts{
 		ApplyMessageOpts: &consortiumCommon.ApplyMessageOpts{
@@ -859,26 +1213,28 @@ func (c *Consortium) Finalize(chain consensus.ChainHeaderReader, header *types.H
 			return err
 		}
 
-		if len(checkpointValidator) != len(extraData.CheckpointValidators) {
-			return errMismatchingEpochValidators
-		}
-
-		for i := range checkpointValidator {
-			if checkpointValidator[i].Address != extraData.CheckpointValidators[i].Address {
-				return errMismatchingEpochValidators
-			}
-
-			if isShillin {
-				if !checkpointValidator[i].BlsPublicKey.Equals(extraData.CheckpointValidators[i].BlsPublicKey) {
-					return errMismatchingEpochValidators
-				}
+		divergences := diffCheckpointValidators(extraData.CheckpointValidators, checkpointValidator, isShillin)
+		if tracer := c.tracer(); tracer != nil {
+			var err error
+			if len(divergences) > 0 {
+				err = errMismatchingEpochValidators
 			}
+			tracer.trace(header.Number.Uint64(), "checkpointValidators", err, "validators", len(checkpointValidator),
+				"divergences", len(divergences))
+		}
+		if len(divergences) > 0 {
+			checkpointDivergenceMeter.Mark(1)
+			logCheckpointDivergence(header, divergences)
+			return errMismatchingEpochValidators
 		}
 	}
 
-	if err := c.processSystemTransactions(chain, header, transactOpts, false); err != nil {
+	if err := c.processSystemTransactions(chain, header, contract, transactOpts, false); err != nil {
 		return err
 	}
+	if contract != nil {
+		contract.InvalidateBlsPublicKeys(*receipts)
+	}
 	if len(*transactOpts.EVMContext.InternalTransactions) > 0 {
 		*internalTxs = append(*internalTxs, *transactOpts.EVMContext.InternalTransactions...)
 	}
@@ -894,6 +1250,15 @@ func (c *Consortium) Finalize(chain consensus.ChainHeaderReader, header *types.H
 // - SubmitBlockRewards of the current block
 func (c *Consortium) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB,
 	txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, []*types.Receipt, error) {
+	_, _, signTxFn, contract := c.readSignerAndContract()
+	return c.finalizeAndAssemble(chain, header, state, txs, receipts, signTxFn, contract)
+}
+
+// finalizeAndAssemble applies the system transactions signed with signTxFn on
+// top of the transactions and assembles the block
+func (c *Consortium) finalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB,
+	txs []*types.Transaction, receipts []*types.Receipt, signTxFn consortiumCommon.SignerTxFn,
+	contract consortiumCommon.ContractInteraction) (*types.Block, []*types.Receipt, error) {
 	// No block rewards in PoA, so the state remains as is and uncles are dropped
 	if txs == nil {
 		txs = make([]*types.Transaction, 0)
@@ -901,7 +1266,6 @@ func (c *Consortium) FinalizeAndAssemble(chain consensus.ChainHeaderReader, head
 	if receipts == nil {
 		receipts = make([]*types.Receipt, 0)
 	}
-	_, _, signTxFn, _ := c.readSignerAndContract()
 	evmContext := core.NewEVMBlockContext(header, consortiumCommon.ChainContext{Chain: chain, Consortium: c}, &header.Coinbase, chain.OpEvents()...)
 	transactOpts := &consortiumCommon.ApplyTransactOpts{
 		ApplyMessageOpts: &consortiumCommon.ApplyMessageOpts{
@@ -921,9 +1285,12 @@ func (c *Consortium) FinalizeAndAssemble(chain consensus.ChainHeaderReader, head
 		SignTxFn:    signTxFn,
 	}
 
-	if err := c.processSystemTransactions(chain, header, transactOpts, true); err != nil {
+	if err := c.processSystemTransactions(chain, header, contract, transactOpts, true); err != nil {
 		return nil, nil, err
 	}
+	if contract != nil {
+		contract.InvalidateBlsPublicKeys(receipts)
+	}
 
 	// should not happen. Once happen, stop the node is better than broadcast the block
 	if header.GasLimit < header.GasUsed {
@@ -948,21 +1315,84 @@ func (c *Consortium) FinalizeAndAssemble(chain consensus.ChainHeaderReader, head
 	return blk, *transactOpts.Receipts, nil
 }
 
-// Authorize injects a private key into the consensus engine to mint new blocks with
+// sealingKey is an authorized key that the engine can seal blocks with
+type sealingKey struct {
+	val      common.Address
+	signFn   consortiumCommon.SignerFn
+	signTxFn consortiumCommon.SignerTxFn
+}
+
+// Authorize injects a private key into the consensus engine to mint new blocks with.
+// It can be called multiple times to register multiple keys, the first registered
+// key is used until another key is found in the validator set while preparing
+// a new block.
 func (c *Consortium) Authorize(signer common.Address, signFn consortiumCommon.SignerFn, signTxFn consortiumCommon.SignerTxFn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	c.val = signer
-	c.signFn = signFn
-	c.signTxFn = signTxFn
+	key := &sealingKey{val: signer, signFn: signFn, signTxFn: signTxFn}
+	replaced := false
+	for i, sealingKey := range c.sealingKeys {
+		if sealingKey.val == signer {
+			c.sealingKeys[i] = key
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		c.sealingKeys = append(c.sealingKeys, key)
+	}
+
+	if len(c.sealingKeys) == 1 || c.val == signer {
+		c.useSealingKey(key)
+	}
+}
 
-	err := c.initContract(signer, signTxFn)
+// SignValidatorProof signs the data with the current sealing key to prove the
+// node is run by the validator to its peers, see ronin.ValidatorProofPacket.
+func (c *Consortium) SignValidatorProof(data []byte) (common.Address, []byte, error) {
+	val, signFn, _, _ := c.readSignerAndContract()
+	if signFn == nil {
+		return common.Address{}, nil, errors.New("no sealing key is authorized")
+	}
+	signature, err := signFn(accounts.Account{Address: val}, accounts.MimetypeConsortium, data)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	return val, signature, nil
+}
+
+// useSealingKey sets the key as the current signing key, the caller must hold
+// the c.lock
+func (c *Consortium) useSealingKey(key *sealingKey) {
+	c.val = key.val
+	c.signFn = key.signFn
+	c.signTxFn = key.signTxFn
+
+	err := c.initContract(key.val, key.signTxFn)
 	if err != nil {
 		log.Error("Failed to init system contract caller", "err", err)
 	}
 }
 
+// selectSealingKey switches the signing key to the first authorized key in the
+// validator set of the snapshot when the current key is not in that set
+func (c *Consortium) selectSealingKey(snap *Snapshot) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.sealingKeys) <= 1 || snap.inInValidatorSet(c.val) {
+		return
+	}
+	for _, key := range c.sealingKeys {
+		if snap.inInValidatorSet(key.val) {
+			log.Info("Switch sealing key", "from", c.val, "to", key.val, "number", snap.Number+1)
+			c.useSealingKey(key)
+			return
+		}
+	}
+}
+
 // Seal implements consensus.Engine, attempting to create a sealed block using
 // the local signing credentials.
 func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block, results chan<- *types.Block, stop <-chan struct{}) error {
@@ -973,6 +1403,16 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 	if number == 0 {
 		return consortiumCommon.ErrUnknownBlock
 	}
+	ctx, span := consortiumCommon.StartSpan(context.Background(), "Seal", number, c.config.EpochV2,
+		attribute.Int("txs", len(block.Transactions())))
+	// The span covers the sealing in the background, it is ended there once the
+	// sealing is started
+	sealing := false
+	defer func() {
+		if !sealing {
+			span.End()
+		}
+	}()
 	// For 0-period chains, refuse to seal empty blocks (no reward but would spin sealing)
 	if c.config.Period == 0 && len(block.Transactions()) == 0 {
 		log.Info("Sealing paused, waiting for transactions")
@@ -981,7 +1421,16 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 	// Don't hold the val fields for the entire sealing procedure
 	val, signFn, _, _ := c.readSignerAndContract()
 
-	snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+	c.lock.RLock()
+	sealGuards := c.sealGuards
+	c.lock.RUnlock()
+	for _, guard := range sealGuards {
+		if err := guard(); err != nil {
+			return err
+		}
+	}
+
+	snap, err := c.snapshotContext(ctx, chain, number-1, header.ParentHash, nil)
 	if err != nil {
 		return err
 	}
@@ -997,30 +1446,64 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 	}
 
 	// Sweet, the protocol permits us to sign the block, wait for our time
-	// After the Buba hardfork, the delay is included in header time already
-	delay := time.Until(time.Unix(int64(header.Time), 0))
+	// After the Buba hardfork, the delay is included in header time already.
+	// The block is released up to the allowed drift early, the peers tolerate it.
+	delay := time.Unix(int64(header.Time), 0).Sub(c.now()) - c.futureBlockTime()
+	inTurn := header.Difficulty.Cmp(diffInTurn) == 0
 	if !c.chainConfig.IsBuba(block.Number()) {
-		if header.Difficulty.Cmp(diffInTurn) != 0 {
+		if !inTurn {
 			// It's not our turn explicitly to sign, delay it a bit
 			wiggle := time.Duration(len(snap.validators())/2+1) * wiggleTime
-			delay += time.Duration(rand.Int63n(int64(wiggle))) + wiggleTime // delay for 0.5s more
+			if isDeterministicBlockTime(c.chainConfig) {
+				// The validator is checked above, it is not sealable if the
+				// recent signers are inconsistent with the validator set
+				position, _ := snap.sealableValidators(val)
+				if position == unSealableValidator {
+					return consortiumCommon.ErrRecentlySigned
+				}
+				delay += time.Duration(position+1) * wiggleTime
+			} else {
+				delay += time.Duration(rand.Int63n(int64(wiggle))) + wiggleTime // delay for 0.5s more
+			}
 
 			log.Trace("Out-of-turn signing requested", "wiggle", common.PrettyDuration(wiggle))
 		}
 	}
+	// Under tx backlog, the in-turn validator releases the block without waiting
+	// for the finality votes and the out-of-turn validators back off further so
+	// that the full in-turn block wins over their blocks.
+	backlog := c.underTxBacklog()
+	if backlog && !inTurn {
+		delay += backlogOutOfTurnBackoff
+	}
+	if !inTurn {
+		delay += c.adaptiveWiggleDelay()
+	}
+	delay += chaos.SealDelay()
 	log.Info("Sealing block with", "number", number, "delay", delay, "headerDifficulty", header.Difficulty, "val", val.Hex(), "txs", len(block.Transactions()))
 
-	// Wait until sealing is terminated or delay timeout.
+	// Wait until sealing is terminated or delay timeout. The finality votes are
+	// waited for in the vote assembly window before the release of the block.
 	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
+	release := c.now().Add(delay)
+	sealing = true
 	go func() {
+		defer span.End()
+
 		select {
 		case <-stop:
 			return
-		case <-time.After(delay - assemblingFinalityVoteDuration):
-			c.assembleFinalityVote(header, snap)
+		case <-c.after(delay - assemblingFinalityVoteDuration - c.VoteAssemblyWindow()):
+			// The vote assembly and the signing after the sealing delay
+			_, signSpan := consortiumCommon.StartSpan(ctx, "SealSign", number, c.config.EpochV2)
+			if !c.assembleFinalityVote(header, snap) && !(backlog && inTurn) && !c.waitFinalityVote(header, snap, release, stop) {
+				signSpan.End()
+				return
+			}
 
 			// Sign all the things!
-			sig, err := signFn(accounts.Account{Address: val}, accounts.MimetypeConsortium, consortiumRLP(header, c.chainConfig.ChainID))
+			sig, err := signFn(accounts.Account{Address: val}, accounts.MimetypeConsortium, verifier.ConsortiumRLP(header, c.chainConfig.ChainID))
+			consortiumCommon.EndSpan(signSpan, err)
 			if err != nil {
 				log.Error("Failed to seal block", "err", err)
 				return
@@ -1028,17 +1511,29 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 			copy(header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:], sig)
 		}
 
-		delay = time.Until(time.Unix(int64(header.Time), 0))
+		delay = time.Unix(int64(header.Time), 0).Sub(c.now()) - c.futureBlockTime()
 		select {
 		case <-stop:
 			return
-		case <-time.After(delay):
+		case <-c.after(delay):
+		}
+
+		// Include the votes that arrive after signing, the block is signed again
+		// if there are more votes
+		if lateFinalityVotes.Enabled() && c.assembleFinalityVote(header, snap) {
+			sig, err := signFn(accounts.Account{Address: val}, accounts.MimetypeConsortium, verifier.ConsortiumRLP(header, c.chainConfig.ChainID))
+			if err != nil {
+				log.Error("Failed to seal block", "err", err)
+				return
+			}
+			copy(header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:], sig)
+			log.Debug("Re-assembled late finality votes", "number", number)
 		}
 
 		select {
 		case results <- block.WithSeal(header):
 		default:
-			log.Warn("Sealing result is not read by miner", "sealhash", calculateSealHash(header, c.chainConfig.ChainID))
+			log.Warn("Sealing result is not read by miner", "sealhash", verifier.SealHash(header, c.chainConfig.ChainID))
 		}
 	}()
 
@@ -1061,14 +1556,21 @@ func (c *Consortium) SealHash(header *types.Header) common.Hash {
 		extraData, _ := finality.DecodeExtra(copyHeader.Extra, true)
 		extraData.HasFinalityVote = 0
 		copyHeader.Extra = extraData.Encode(true)
-		return calculateSealHash(copyHeader, c.chainConfig.ChainID)
+		return verifier.SealHash(copyHeader, c.chainConfig.ChainID)
 	} else {
-		return calculateSealHash(header, c.chainConfig.ChainID)
+		return verifier.SealHash(header, c.chainConfig.ChainID)
 	}
 }
 
-// Close implements consensus.Engine. It's a noop for Consortium as there are no background threads.
+// Close implements consensus.Engine. It stops the background loops started on
+// the chain and waits for them to exit.
 func (c *Consortium) Close() error {
+	c.closeOnce.Do(func() {
+		if c.quit != nil {
+			close(c.quit)
+		}
+	})
+	c.wg.Wait()
 	return nil
 }
 
@@ -1081,6 +1583,18 @@ func (c *Consortium) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 			Service:   &consortiumV2Api{chain: chain, consortium: c},
 			Public:    false,
 		},
+		{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   &consortiumApi{chain: chain, consortium: c},
+			Public:    false,
+		},
+		{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   &consortiumAdminApi{chain: chain, consortium: c},
+			Public:    false,
+		},
 	}
 }
 
@@ -1109,15 +1623,19 @@ func CalcDifficulty(snap *Snapshot, signer common.Address) *big.Int {
 
 // initContract creates NewContractIntegrator instance
 func (c *Consortium) initContract(coinbase common.Address, signTxFn consortiumCommon.SignerTxFn) error {
-	if consortiumCommon.Validators != nil {
-		c.contract = &consortiumCommon.MockContract{}
-		return nil
-	}
 	var err error
-	c.contract, err = consortiumCommon.NewContractIntegrator(c.chainConfig, consortiumCommon.NewConsortiumBackend(c.ethAPI), signTxFn, coinbase)
+	c.contract, err = c.newContract(coinbase, signTxFn)
 	return err
 }
 
+// newContract creates the system contracts caller of the validator coinbase
+func (c *Consortium) newContract(coinbase common.Address, signTxFn consortiumCommon.SignerTxFn) (consortiumCommon.ContractInteraction, error) {
+	if consortiumCommon.Validators != nil {
+		return &consortiumCommon.MockContract{}, nil
+	}
+	return consortiumCommon.NewContractIntegrator(c.chainConfig, consortiumCommon.NewConsortiumBackend(c.ethAPI), signTxFn, coinbase)
+}
+
 func (c *Consortium) readSignerAndContract() (
 	common.Address,
 	consortiumCommon.SignerFn,
@@ -1184,12 +1702,17 @@ func (c *Consortium) GetJustifiedBlock(chain consensus.ChainHeaderReader, blockN
 // snapshot (N)
 // So here when including the vote for header.Number - 1 into header.Number, the
 // snapshot provided must be at header.Number - 1
-func (c *Consortium) assembleFinalityVote(header *types.Header, snap *Snapshot) {
+//
+// The assembled votes in header are only replaced when there are more votes in
+// the vote pool. It returns true if the header is updated.
+func (c *Consortium) assembleFinalityVote(header *types.Header, snap *Snapshot) bool {
 	if c.chainConfig.IsShillin(header.Number) {
 		var (
 			signatures              []blsCommon.Signature
 			finalityVotedValidators finality.FinalityVoteBitSet
-			finalityThreshold       int = int(math.Floor(finalityRatio*float64(len(snap.ValidatorsWithBlsPub)))) + 1
+			previousKeyVoters       finality.FinalityVoteBitSet
+			finalityThreshold       int = c.config.FinalityThreshold(header.Number, len(snap.ValidatorsWithBlsPub))
+			maxVoters                   = finality.MaxFinalityVoters(c.chainConfig.IsVenoki(header.Number))
 		)
 
 		// We assume the signature has been verified in vote pool
@@ -1197,48 +1720,183 @@ func (c *Consortium) assembleFinalityVote(header *types.Header, snap *Snapshot)
 		if c.votePool != nil {
 			votes := c.votePool.FetchVoteByBlockHash(header.ParentHash)
 			if len(votes) >= finalityThreshold {
-				for _, vote := range votes {
-					publicKey, err := blst.PublicKeyFromBytes(vote.PublicKey[:])
-					if err != nil {
-						log.Warn("Malformed public key from vote pool", "err", err)
-						continue
-					}
-					authorized := false
-					for valPosition, validator := range snap.ValidatorsWithBlsPub {
-						if publicKey.Equals(validator.BlsPublicKey) {
-							signature, err := blst.SignatureFromBytes(vote.Signature[:])
-							if err != nil {
-								log.Warn("Malformed signature from vote pool", "err", err)
-								break
-							}
-							signatures = append(signatures, signature)
-							finalityVotedValidators.SetBit(valPosition)
-							authorized = true
-							break
+				finalityVotedValidators, previousKeyVoters, signatures = aggregateVotes(votes, snap, maxVoters)
+			}
+
+			// The vote aggregated by a peer is used if it has more voters, so the
+			// late validators do not need to collect the individual votes
+			if pool, ok := c.votePool.(consensus.AggregatedVotePool); ok {
+				if vote := pool.FetchAggregatedVoteByBlockHash(header.ParentHash); vote != nil {
+					votedValidators := finality.NewFinalityVoteBitSet(uint64(vote.VotedValidators))
+					if len(votedValidators.Indices()) > len(finalityVotedValidators.Indices()) {
+						signature, err := blst.SignatureFromBytes(vote.Signature[:])
+						if err != nil {
+							log.Warn("Malformed aggregated signature from vote pool", "err", err)
+						} else {
+							finalityVotedValidators, signatures = votedValidators, []blsCommon.Signature{signature}
+							previousKeyVoters = finality.NewFinalityVoteBitSet(uint64(vote.PreviousKeyVoters))
 						}
 					}
-					if !authorized {
-						log.Warn("Unauthorized voter's signature from vote pool", "publicKey", hex.EncodeToString(publicKey.Marshal()))
-					}
 				}
+			}
 
-				bitSetCount := len(finalityVotedValidators.Indices())
-				if bitSetCount >= finalityThreshold {
-					extraData, err := finality.DecodeExtra(header.Extra, true)
-					if err != nil {
-						// This should not happen
-						log.Error("Failed to decode header extra data", "err", err)
-						return
-					}
-					extraData.HasFinalityVote = 1
-					extraData.FinalityVotedValidators = finalityVotedValidators
-					extraData.AggregatedFinalityVotes = blst.AggregateSignatures(signatures)
-					header.Extra = extraData.Encode(true)
+			bitSetCount := len(finalityVotedValidators.Indices())
+			if bitSetCount >= finalityThreshold {
+				extraData, err := finality.DecodeExtra(header.Extra, true)
+				if err != nil {
+					// This should not happen
+					log.Error("Failed to decode header extra data", "err", err)
+					return false
+				}
+				if extraData.HasFinalityVote == 1 && len(extraData.FinalityVotedValidators.Indices()) >= bitSetCount {
+					return false
 				}
+				extraData.HasFinalityVote = 1
+				extraData.FinalityVotedValidators = finalityVotedValidators
+				extraData.PreviousKeyVoters = previousKeyVoters
+				extraData.AggregatedFinalityVotes = blst.AggregateSignatures(signatures)
+				header.Extra = extraData.Encode(true)
+				return true
 			}
 		}
 	}
+	return false
+}
+
+// aggregateVotes returns the positions of the voters in the validator set of the
+// snapshot, the positions of the voters that signed with their previous BLS key
+// and their signatures, the votes of unknown voters and of the voters at a
+// position beyond maxVoters are skipped. The votes are verified by the vote pool
+// on arrival, a voter with a rotated BLS key is aggregated with its current key
+// vote if any, otherwise with its previous key vote.
+func aggregateVotes(votes []*types.VoteEnvelope, snap *Snapshot, maxVoters int) (finality.FinalityVoteBitSet, finality.FinalityVoteBitSet, []blsCommon.Signature) {
+	// The signature of each voter and whether it is by the previous key
+	type voterSignature struct {
+		signature blsCommon.Signature
+		previous  bool
+	}
+	voters := make(map[int]voterSignature)
+	for _, vote := range votes {
+		publicKey, err := blst.PublicKeyFromBytes(vote.PublicKey[:])
+		if err != nil {
+			log.Warn("Malformed public key from vote pool", "err", err)
+			continue
+		}
+		// The voter may sign with its previous key in the rotation overlap
+		valPosition := snap.blsPublicKeyPosition(publicKey)
+		if valPosition < 0 {
+			log.Warn("Unauthorized voter's signature from vote pool", "publicKey", hex.EncodeToString(publicKey.Marshal()))
+			continue
+		}
+		if valPosition >= maxVoters {
+			continue
+		}
+		signature, err := blst.SignatureFromBytes(vote.Signature[:])
+		if err != nil {
+			log.Warn("Malformed signature from vote pool", "err", err)
+			continue
+		}
+		previous := !snap.ValidatorsWithBlsPub[valPosition].BlsPublicKey.Equals(publicKey)
+		if voter, ok := voters[valPosition]; ok && (previous || !voter.previous) {
+			continue
+		}
+		voters[valPosition] = voterSignature{signature: signature, previous: previous}
+	}
+
+	positions := make([]int, 0, len(voters))
+	for position := range voters {
+		positions = append(positions, position)
+	}
+	sort.Ints(positions)
 
+	var (
+		signatures        []blsCommon.Signature
+		votedValidators   finality.FinalityVoteBitSet
+		previousKeyVoters finality.FinalityVoteBitSet
+	)
+	for _, position := range positions {
+		voter := voters[position]
+		signatures = append(signatures, voter.signature)
+		votedValidators.SetBit(position)
+		if voter.previous {
+			previousKeyVoters.SetBit(position)
+		}
+	}
+	return votedValidators, previousKeyVoters, signatures
+}
+
+// AggregateVotes implements consensus.VoteAggregator, the votes are aggregated
+// against the validator set of the snapshot at the target block as in
+// assembleFinalityVote
+func (c *Consortium) AggregateVotes(chain consensus.ChainHeaderReader, votes []*types.VoteEnvelope) (*types.AggregatedVote, error) {
+	if len(votes) == 0 {
+		return nil, nil
+	}
+	data := votes[0].Data
+	snap, err := c.snapshot(chain, data.TargetNumber, data.TargetHash, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the votes for the same data can be aggregated
+	dataHash := data.Hash()
+	sameData := make([]*types.VoteEnvelope, 0, len(votes))
+	for _, vote := range votes {
+		if vote.Data.Hash() == dataHash {
+			sameData = append(sameData, vote)
+		}
+	}
+	// The aggregated votes relay a 64 bits bit set, see types.AggregatedVote
+	votedValidators, previousKeyVoters, signatures := aggregateVotes(sameData, snap, finality.MaxFinalityVoters(false))
+	threshold := c.config.FinalityThreshold(new(big.Int).SetUint64(data.TargetNumber+1), len(snap.ValidatorsWithBlsPub))
+	if len(votedValidators.Indices()) < threshold {
+		return nil, nil
+	}
+
+	bits, _ := votedValidators.Uint64()
+	previousBits, _ := previousKeyVoters.Uint64()
+	aggregated := &types.AggregatedVote{
+		VotedValidators:   types.ValidatorsBitSet(bits),
+		Data:              data,
+		PreviousKeyVoters: types.ValidatorsBitSet(previousBits),
+	}
+	copy(aggregated.Signature[:], blst.AggregateSignatures(signatures).Marshal())
+	return aggregated, nil
+}
+
+// VerifyAggregatedVote implements consensus.VoteAggregator, the aggregated vote
+// is verified as the finality votes included in the child of the target block
+func (c *Consortium) VerifyAggregatedVote(chain consensus.ChainHeaderReader, vote *types.AggregatedVote) error {
+	header := chain.GetHeaderByHash(vote.Data.TargetHash)
+	if header == nil {
+		return errors.New("header not found")
+	}
+	if header.Number.Uint64() != vote.Data.TargetNumber {
+		return finality.ErrInvalidTargetNumber
+	}
+
+	snap, err := c.snapshot(chain, vote.Data.TargetNumber, vote.Data.TargetHash, nil)
+	if err != nil {
+		return err
+	}
+	expected := c.voteData(vote.Data.TargetNumber, vote.Data.TargetHash, snap)
+	if err := verifyVoteData(vote.Data, expected); err != nil {
+		return err
+	}
+	signature, err := blst.SignatureFromBytes(vote.Signature[:])
+	if err != nil {
+		return err
+	}
+	threshold := c.config.FinalityThreshold(new(big.Int).Add(header.Number, common.Big1), len(snap.ValidatorsWithBlsPub))
+	return verifier.VerifyRotatedFinalitySignatures(
+		snap.ValidatorsWithBlsPub,
+		snap.previousBlsKeys(),
+		finality.NewFinalityVoteBitSet(uint64(vote.VotedValidators)),
+		finality.NewFinalityVoteBitSet(uint64(vote.PreviousKeyVoters)),
+		signature,
+		expected.Hash(),
+		threshold,
+	)
 }
 
 // GetFinalizedBlock gets the fast finality finalized block
@@ -1301,6 +1959,222 @@ func (c *Consortium) GetFinalizedBlock(
 	}
 }
 
+// AddSealGuard adds a check that must pass before the engine seals a block
+func (c *Consortium) AddSealGuard(guard func() error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sealGuards = append(c.sealGuards, guard)
+}
+
+// WrapUpFailureFn is called when the epoch wrap-up of the header fails, sealing
+// is true if the header is assembled by this node
+type WrapUpFailureFn func(header *types.Header, sealing bool, err error)
+
+// SetWrapUpFailureHandler sets the function called when the epoch wrap-up fails.
+// It must be set before the engine verifies or seals any block.
+func (c *Consortium) SetWrapUpFailureHandler(fn WrapUpFailureFn) {
+	c.wrapUpFailureFn = fn
+}
+
+// SnapshotStoreDir is the directory of the optional snapshot store in the data
+// directory of the node
+const SnapshotStoreDir = "consortium-snapshots"
+
+// SnapshotStore is the store of the checkpoint snapshots
+type SnapshotStore interface {
+	ethdb.KeyValueReader
+	ethdb.KeyValueWriter
+}
+
+// SetSnapshotStore stores the new snapshots in the store instead of the chain
+// database, the snapshots already in the chain database are still read. It must
+// be set before the engine verifies or seals any block.
+func (c *Consortium) SetSnapshotStore(store SnapshotStore) {
+	c.snapshotStore = store
+}
+
+// snapshotDB returns the store that the new snapshots are written to
+func (c *Consortium) snapshotDB() ethdb.KeyValueWriter {
+	if c.snapshotStore != nil {
+		return c.snapshotStore
+	}
+	return c.db
+}
+
+// readSnapshot loads the snapshot from the snapshot store, falling back to the
+// chain database and then to the snapshot archive
+func (c *Consortium) readSnapshot(number uint64, hash common.Hash) (*Snapshot, error) {
+	if c.snapshotStore != nil {
+		if snap, err := loadSnapshot(c.config, c.signatures, c.snapshotStore, hash, c.ethAPI, c.chainConfig); err == nil {
+			return snap, nil
+		}
+	}
+	snap, err := loadSnapshot(c.config, c.signatures, c.db, hash, c.ethAPI, c.chainConfig)
+	if err != nil && c.snapshotArchive != nil {
+		if archived, archiveErr := c.readArchivedSnapshot(number, hash); archiveErr == nil {
+			return archived, nil
+		}
+	}
+	return snap, err
+}
+
+// SetAllowedFutureBlockTime sets the maximum duration a block time can be ahead
+// of the local clock, the drift is capped at maxAllowedFutureBlockTime. It can be
+// changed while the engine is running.
+func (c *Consortium) SetAllowedFutureBlockTime(drift time.Duration) {
+	if drift > maxAllowedFutureBlockTime {
+		log.Warn("Allowed future block time is capped", "provided", drift, "updated", maxAllowedFutureBlockTime)
+		drift = maxAllowedFutureBlockTime
+	}
+	atomic.StoreInt64(&c.allowedFutureBlockTime, int64(drift))
+}
+
+// AllowedFutureBlockTime returns the tolerated clock drift of the block time
+func (c *Consortium) AllowedFutureBlockTime() time.Duration {
+	return c.futureBlockTime()
+}
+
+// futureBlockTime returns the tolerated clock drift of the block time
+func (c *Consortium) futureBlockTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.allowedFutureBlockTime))
+}
+
+// SetVoteAssemblyWindow sets the maximum duration the sealer waits for the
+// finality votes to reach quorum. The sealing starts the window earlier and the
+// wait ends at the release of the block, so the block is never delayed. The
+// window is capped at wiggleTime.
+func (c *Consortium) SetVoteAssemblyWindow(window time.Duration) {
+	if window > wiggleTime {
+		log.Warn("Vote assembly window is capped", "provided", window, "updated", wiggleTime)
+		window = wiggleTime
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.voteAssemblyWindow = window
+}
+
+// SetVanity sets the content of the 32 byte vanity prefix of the blocks sealed
+// from now on, e.g. the client version or an operator tag. The content longer
+// than the prefix is rejected as it would overwrite the structured fields.
+func (c *Consortium) SetVanity(content []byte) error {
+	vanity, err := finality.NewVanity(content)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.vanity = vanity
+	return nil
+}
+
+// sealingVanity returns the vanity prefix of the blocks to seal
+func (c *Consortium) sealingVanity() [finality.ExtraVanity]byte {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.vanity
+}
+
+// VoteAssemblyWindow returns the maximum duration the sealer waits for the
+// finality votes to reach quorum before the release of the block
+func (c *Consortium) VoteAssemblyWindow() time.Duration {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.voteAssemblyWindow
+}
+
+// SetSealBacklog adapts the seal delay to the tx backlog when the number of
+// pending txs returned by pendingFn exceeds the threshold, 0 disables it.
+func (c *Consortium) SetSealBacklog(threshold int, pendingFn func() int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sealBacklogThreshold = threshold
+	c.pendingTxsFn = pendingFn
+}
+
+// SetVoteWaitHandler sets the function called when the sealer starts waiting
+// for the finality votes of the parent to reach quorum. The function must not
+// block, the miner uses it to pull the newly arrived txs into the block while
+// waiting as the finality votes are only merged into the header at seal time.
+func (c *Consortium) SetVoteWaitHandler(fn func(header *types.Header)) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.voteWaitFn = fn
+}
+
+// underTxBacklog reports whether the pending txs exceed the backlog threshold
+func (c *Consortium) underTxBacklog() bool {
+	c.lock.RLock()
+	threshold, pendingFn := c.sealBacklogThreshold, c.pendingTxsFn
+	c.lock.RUnlock()
+	if threshold <= 0 || pendingFn == nil {
+		return false
+	}
+	return pendingFn() > threshold
+}
+
+// waitFinalityVote polls the vote pool until the finality votes reach quorum or
+// the block is released, so the wait never delays the block past its slot or
+// the backoff of the sealer. It returns false if the sealing is stopped.
+func (c *Consortium) waitFinalityVote(header *types.Header, snap *Snapshot, release time.Time, stop <-chan struct{}) bool {
+	c.lock.RLock()
+	window := c.voteAssemblyWindow
+	c.lock.RUnlock()
+	if window == 0 || c.votePool == nil || !c.chainConfig.IsShillin(header.Number) {
+		return true
+	}
+
+	c.lock.RLock()
+	voteWaitFn := c.voteWaitFn
+	c.lock.RUnlock()
+	if voteWaitFn != nil {
+		voteWaitFn(header)
+	}
+
+	// A single timer is pending at a time, the simulated networks rely on it
+	for {
+		wait := release.Sub(c.now())
+		if wait <= 0 {
+			log.Debug("Finality votes do not reach quorum", "number", header.Number)
+			return true
+		}
+		if wait > finalityVotePollInterval {
+			wait = finalityVotePollInterval
+		}
+		select {
+		case <-stop:
+			return false
+		case <-c.after(wait):
+			if c.assembleFinalityVote(header, snap) {
+				return true
+			}
+		}
+	}
+}
+
+// SetClock makes the engine read the time and run the sealing timers on the
+// clock, the wall time is the epoch plus the time of the clock. It is meant for
+// the simulated networks on a virtual clock and must be set before the engine
+// verifies or seals any block.
+func (c *Consortium) SetClock(clock mclock.Clock, epoch time.Time) {
+	c.clock, c.clockEpoch = clock, epoch
+}
+
+// now returns the wall time of the engine clock
+func (c *Consortium) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clockEpoch.Add(time.Duration(c.clock.Now()))
+}
+
+// after waits for the duration to elapse on the engine clock
+func (c *Consortium) after(d time.Duration) <-chan mclock.AbsTime {
+	if c.clock == nil {
+		return mclock.System{}.After(d)
+	}
+	return c.clock.After(d)
+}
+
 // SetVotePool sets the finality vote pool to be used by consensus
 // engine
 func (c *Consortium) SetVotePool(votePool consensus.VotePool) {
@@ -1336,76 +2210,3 @@ func (c *Consortium) GetActiveValidatorAt(
 
 	return snap.ValidatorsWithBlsPub
 }
-
-// ecrecover extracts the Ronin account address from a signed header.
-func ecrecover(header *types.Header, sigcache *lru.ARCCache, chainId *big.Int) (common.Address, error) {
-	// If the signature's already cached, return that
-	hash := header.Hash()
-	if address, known := sigcache.Get(hash); known {
-		return address.(common.Address), nil
-	}
-	// Retrieve the signature from the header extra-data
-	if len(header.Extra) < consortiumCommon.ExtraSeal {
-		return common.Address{}, consortiumCommon.ErrMissingSignature
-	}
-	signature := header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:]
-
-	// Recover the public key and the Ethereum address
-	pubkey, err := crypto.Ecrecover(calculateSealHash(header, chainId).Bytes(), signature)
-	if err != nil {
-		return common.Address{}, err
-	}
-	var signer common.Address
-	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
-
-	sigcache.Add(hash, signer)
-	return signer, nil
-}
-
-// calculateSealHash returns the hash of a block prior to it being sealed.
-func calculateSealHash(header *types.Header, chainId *big.Int) (hash common.Hash) {
-	hasher := sha3.NewLegacyKeccak256()
-	encodeSigHeader(hasher, header, chainId)
-	hasher.Sum(hash[:0])
-	return hash
-}
-
-// consortiumRLP returns the rlp bytes which needs to be signed for the proof-of-authority
-// sealing. The RLP to sign consists of the entire header apart from the 65 byte signature
-// contained at the end of the extra data.
-//
-// Note, the method requires the extra data to be at least 65 bytes, otherwise it
-// panics. This is done to avoid accidentally using both forms (signature present
-// or not), which could be abused to produce different hashes for the same header.
-func consortiumRLP(header *types.Header, chainId *big.Int) []byte {
-	b := new(bytes.Buffer)
-	encodeSigHeader(b, header, chainId)
-	return b.Bytes()
-}
-
-// encodeSigHeader encodes the whole header with chainId.
-// chainID was introduced in EIP-155 to prevent replay attacks between the main ETH and ETC chains,
-// which both have a networkID of 1
-func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
-	err := rlp.Encode(w, []interface{}{
-		chainId,
-		header.ParentHash,
-		header.UncleHash,
-		header.Coinbase,
-		header.Root,
-		header.TxHash,
-		header.ReceiptHash,
-		header.Bloom,
-		header.Difficulty,
-		header.Number,
-		header.GasLimit,
-		header.GasUsed,
-		header.Time,
-		header.Extra[:len(header.Extra)-consortiumCommon.ExtraSeal], // Yes, this will panic if extra is too short
-		header.MixDigest,
-		header.Nonce,
-	})
-	if err != nil {
-		panic("can't encode: " + err.Error())
-	}
-}
@@ -2,23 +2,33 @@ package v2
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/consensus"
 	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	slashIndicator "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/slash_indicator"
 	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality/finalitytest"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/params"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -168,6 +178,135 @@ func TestBackoffTimeOlek(t *testing.T) {
 	}
 }
 
+// In deterministic block time mode, the delay only depends on the
+// position of the validator in the sealable validators
+func TestBackoffTimeDeterministic(t *testing.T) {
+	const NUM_OF_VALIDATORS = 21
+
+	c := Consortium{
+		chainConfig: &params.ChainConfig{
+			BubaBlock: big.NewInt(0),
+			OlekBlock: big.NewInt(0),
+			Consortium: &params.ConsortiumConfig{
+				DeterministicBlockTime: true,
+			},
+		},
+	}
+
+	validators := make([]common.Address, NUM_OF_VALIDATORS)
+	for i := 0; i < NUM_OF_VALIDATORS; i++ {
+		validators = append(validators, common.BigToAddress(big.NewInt(int64(i))))
+	}
+
+	snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, validators, nil, nil)
+	for i := 0; i <= 10; i++ {
+		snap.Recents[uint64(i)] = common.BigToAddress(big.NewInt(int64(i)))
+	}
+
+	// delay - position is the same for all out-of-turn validators
+	var baseDelay int64 = -1
+	for i := 0; i < NUM_OF_VALIDATORS; i++ {
+		val := common.BigToAddress(big.NewInt(int64(i)))
+		position, _ := snap.sealableValidators(val)
+		if position == unSealableValidator || snap.inturn(val) {
+			continue
+		}
+
+		header := &types.Header{
+			Coinbase: val,
+			Number:   new(big.Int).SetUint64(snap.Number + 1),
+		}
+		delay := backOffTime(header, snap, c.chainConfig)
+		// The block number does not affect the delay
+		header.Number = new(big.Int).SetUint64(snap.Number + 100)
+		if otherDelay := backOffTime(header, snap, c.chainConfig); otherDelay != delay {
+			t.Fatalf("Delay changes with block number, exp %d got %d", delay, otherDelay)
+		}
+
+		if baseDelay == -1 {
+			baseDelay = int64(delay) - int64(position)
+		} else if int64(delay) != baseDelay+int64(position) {
+			t.Fatalf("Delay mismatch at position %d, exp %d got %d", position, baseDelay+int64(position), delay)
+		}
+	}
+}
+
+// From Aaron, the delays are a permutation seeded by the validator set and the
+// block number, so the out-of-turn order changes across the epochs and cannot be
+// ground by the parent sealer
+func TestBackoffTimeAaron(t *testing.T) {
+	const NUM_OF_VALIDATORS = 21
+
+	chainConfig := &params.ChainConfig{
+		BubaBlock:  big.NewInt(0),
+		OlekBlock:  big.NewInt(0),
+		AaronBlock: big.NewInt(12),
+	}
+
+	newSnap := func(last common.Address) *Snapshot {
+		var validators []common.Address
+		for i := 0; i < NUM_OF_VALIDATORS-1; i++ {
+			validators = append(validators, common.BigToAddress(big.NewInt(int64(i))))
+		}
+		snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, append(validators, last), nil, nil)
+		for i := 0; i <= 5; i++ {
+			snap.Recents[uint64(i)] = common.BigToAddress(big.NewInt(int64(i)))
+		}
+		return snap
+	}
+	// delays returns the delays of the out-of-turn validators shared by the
+	// validator sets
+	delays := func(snap *Snapshot, number uint64, parentHash common.Hash) []uint64 {
+		var (
+			result []uint64
+			seen   = make(map[uint64]bool)
+		)
+		for i := 0; i < NUM_OF_VALIDATORS-1; i++ {
+			val := common.BigToAddress(big.NewInt(int64(i)))
+			position, _ := snap.sealableValidators(val)
+			if position == unSealableValidator || snap.inturn(val) {
+				continue
+			}
+			header := &types.Header{
+				Coinbase:   val,
+				Number:     new(big.Int).SetUint64(number),
+				ParentHash: parentHash,
+			}
+			delay := backOffTime(header, snap, chainConfig)
+			if seen[delay] {
+				t.Fatalf("More than 1 validator have the same delay, delay %d", delay)
+			}
+			seen[delay] = true
+			result = append(result, delay)
+		}
+		return result
+	}
+	snap := newSnap(common.BigToAddress(big.NewInt(100)))
+
+	// The parent hash does not affect the delays
+	for _, number := range []uint64{11, 12} {
+		if !reflect.DeepEqual(delays(snap, number, common.Hash{0x1}), delays(snap, number, common.Hash{0x2})) {
+			t.Fatalf("Expect the delays at %d not to depend on the parent hash", number)
+		}
+	}
+
+	// From Aaron, the delays rotate with the validator set
+	base := delays(snap, 12, common.Hash{})
+	rotated := false
+	for i := 101; i < 110 && !rotated; i++ {
+		rotated = !reflect.DeepEqual(base, delays(newSnap(common.BigToAddress(big.NewInt(int64(i)))), 12, common.Hash{}))
+	}
+	if !rotated {
+		t.Fatalf("Expect the delays to change with the validator set from Aaron")
+	}
+
+	// The deterministic block time mode is not affected by Aaron
+	chainConfig.Consortium = &params.ConsortiumConfig{DeterministicBlockTime: true}
+	if !reflect.DeepEqual(delays(snap, 12, common.Hash{}), delays(newSnap(common.BigToAddress(big.NewInt(101))), 12, common.Hash{})) {
+		t.Fatalf("Expect the delays to not depend on the validator set in deterministic block time mode")
+	}
+}
+
 // When validator is in recent list we expect the minimum delay is
 // 1s before Olek and 0s after Olek
 func TestBackoffTimeInturnValidatorInRecentList(t *testing.T) {
@@ -270,6 +409,16 @@ func TestVerifyBlockHeaderTime(t *testing.T) {
 	}
 
 	parentHeader.Time = now - 10
+	header.Time = now + 1
+	if err := c.verifyHeaderTime(header, parentHeader, snap); !errors.Is(err, consensus.ErrFutureBlock) {
+		t.Error("Expect future block error when block's timestamp is higher than current timestamp")
+	}
+	c.SetAllowedFutureBlockTime(time.Second)
+	if err := c.verifyHeaderTime(header, parentHeader, snap); err != nil {
+		t.Errorf("Expect successful verification within the allowed drift, got %s", err)
+	}
+	c.SetAllowedFutureBlockTime(0)
+
 	header.Time = now - 9
 	if err := c.verifyHeaderTime(header, parentHeader, snap); err != nil {
 		t.Errorf("Expect successful verification, got %s", err)
@@ -286,6 +435,38 @@ func TestVerifyBlockHeaderTime(t *testing.T) {
 	}
 }
 
+func TestSelectSealingKey(t *testing.T) {
+	c := Consortium{
+		chainConfig: &params.ChainConfig{
+			ConsortiumV2Contracts: &params.ConsortiumV2Contracts{},
+		},
+	}
+
+	oldKey := common.BigToAddress(big.NewInt(1))
+	newKey := common.BigToAddress(big.NewInt(2))
+	c.Authorize(oldKey, nil, nil)
+	c.Authorize(newKey, nil, nil)
+	if val, _, _, _ := c.readSignerAndContract(); val != oldKey {
+		t.Fatalf("Expect the first authorized key is used, exp %s got %s", oldKey, val)
+	}
+
+	// The current key is in the validator set, keep using it
+	validators := []common.Address{oldKey, newKey}
+	snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, validators, nil, nil)
+	c.selectSealingKey(snap)
+	if val, _, _, _ := c.readSignerAndContract(); val != oldKey {
+		t.Fatalf("Expect the current key is kept, exp %s got %s", oldKey, val)
+	}
+
+	// The current key is rotated out of the validator set
+	validators = []common.Address{common.BigToAddress(big.NewInt(3)), newKey}
+	snap = newSnapshot(nil, nil, nil, 20, common.Hash{}, validators, nil, nil)
+	c.selectSealingKey(snap)
+	if val, _, _, _ := c.readSignerAndContract(); val != newKey {
+		t.Fatalf("Expect the key in validator set is selected, exp %s got %s", newKey, val)
+	}
+}
+
 func TestExtraDataEncode(t *testing.T) {
 	extraData := finality.HeaderExtraData{}
 	data := extraData.Encode(false)
@@ -466,7 +647,7 @@ func TestExtraDataDecode(t *testing.T) {
 
 	rawBytes = []byte{}
 	rawBytes = append(rawBytes, bytes.Repeat([]byte{0x00}, consortiumCommon.ExtraVanity)...)
-	rawBytes = append(rawBytes, byte(0x02))
+	rawBytes = append(rawBytes, byte(0x04))
 	rawBytes = binary.LittleEndian.AppendUint64(rawBytes, 0)
 	rawBytes = append(rawBytes, signature.Marshal()...)
 	rawBytes = append(rawBytes, common.Address{0x1}.Bytes()...)
@@ -527,6 +708,132 @@ func TestExtraDataDecode(t *testing.T) {
 	}
 }
 
+func TestExtraDataVanity(t *testing.T) {
+	if _, err := finality.NewVanity(bytes.Repeat([]byte{0x1}, finality.ExtraVanity+1)); !errors.Is(err, finality.ErrVanityTooLong) {
+		t.Fatalf("Expect error %v, got %v", finality.ErrVanityTooLong, err)
+	}
+
+	vanity, err := finality.NewVanity([]byte("ronin/v2.7.0"))
+	if err != nil {
+		t.Fatalf("Failed to create vanity, err %s", err)
+	}
+	extraData := finality.HeaderExtraData{
+		Vanity: vanity,
+		CheckpointValidators: []finality.ValidatorWithBlsPub{
+			{
+				Address: common.Address{0x1},
+			},
+		},
+	}
+	decoded, err := finality.DecodeExtra(extraData.Encode(false), false)
+	if err != nil {
+		t.Fatalf("Failed to decode extra data, err %s", err)
+	}
+	if content := decoded.VanityContent(); string(content) != "ronin/v2.7.0" {
+		t.Fatalf("Mismatch vanity content, have %q expect %q", content, "ronin/v2.7.0")
+	}
+	if len(decoded.CheckpointValidators) != 1 || decoded.CheckpointValidators[0].Address != (common.Address{0x1}) {
+		t.Fatalf("Vanity overwrites the checkpoint validators, got %v", decoded.CheckpointValidators)
+	}
+}
+
+// From Venoki, the finality votes of more than 64 validators are encoded with a
+// length prefixed bit set
+func TestExtraDataLargeFinalityVoteBitSet(t *testing.T) {
+	set, err := finalitytest.NewValidatorSet(100)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	var (
+		chainConfig = &params.ChainConfig{
+			ChainID:      big.NewInt(2021),
+			ShillinBlock: common.Big0,
+			VenokiBlock:  big.NewInt(100),
+			Consortium:   &params.ConsortiumConfig{EpochV2: 200},
+		}
+		parentHash = common.Hash{0x1}
+		positions  []int
+		quorum     = set.Quorum(chainConfig, big.NewInt(11))
+	)
+	for i := 0; i < quorum-1; i++ {
+		positions = append(positions, i)
+	}
+	positions = append(positions, 99)
+
+	rawExtra := finalitytest.NewExtraDataBuilder(chainConfig).WithFinalityVotes(set, 10, parentHash, finalitytest.Source{}, positions...).Encode()
+	if rawExtra[finality.ExtraVanity] != 2 || rawExtra[finality.ExtraVanity+1] != 13 {
+		t.Fatalf("Expect the length prefixed bit set of 13 bytes, got %v", rawExtra[finality.ExtraVanity:finality.ExtraVanity+2])
+	}
+	extraData, err := finality.DecodeExtra(rawExtra, true)
+	if err != nil {
+		t.Fatalf("Failed to decode extra data, err %s", err)
+	}
+	if extraData.HasFinalityVote != 1 || !reflect.DeepEqual(extraData.FinalityVotedValidators.Indices(), positions) {
+		t.Fatalf("Expect voters %v, got %v", positions, extraData.FinalityVotedValidators.Indices())
+	}
+	if _, ok := extraData.FinalityVotedValidators.Uint64(); ok {
+		t.Fatalf("Expect the bit set not to fit in 64 bits")
+	}
+	if encoded := extraData.Encode(true); !bytes.Equal(encoded, rawExtra) {
+		t.Fatalf("Expect the same encoding after decoding")
+	}
+	digest := (&types.VoteData{TargetNumber: 10, TargetHash: parentHash}).Hash()
+	if err := verifier.VerifyFinalitySignatures(set.WithBlsPub(), extraData.FinalityVotedValidators, extraData.AggregatedFinalityVotes, digest, quorum); err != nil {
+		t.Fatalf("Failed to verify finality signatures, err %s", err)
+	}
+
+	// The voters in the 64 bits bit set keep the encoding before Venoki
+	small := finalitytest.NewExtraDataBuilder(chainConfig).WithFinalityVotes(set, 10, parentHash, finalitytest.Source{}, 0, 63).Encode()
+	if small[finality.ExtraVanity] != 1 {
+		t.Fatalf("Expect the 8 bytes bit set, got has finality vote byte %d", small[finality.ExtraVanity])
+	}
+	if binary.LittleEndian.Uint64(small[finality.ExtraVanity+1:]) != 1<<63|1 {
+		t.Fatalf("Mismatch 8 bytes bit set %x", small[finality.ExtraVanity+1:finality.ExtraVanity+9])
+	}
+
+	// The length prefixed bit set must not fit in 8 bytes nor end with a zero byte
+	for _, bitSet := range [][]byte{{0x1, 0, 0, 0, 0, 0, 0, 0x1}, {0x1, 0, 0, 0, 0, 0, 0, 0, 0x1, 0}} {
+		raw := append(bytes.Repeat([]byte{0x00}, finality.ExtraVanity), 2, byte(len(bitSet)))
+		raw = append(raw, bitSet...)
+		raw = append(raw, extraData.AggregatedFinalityVotes.Marshal()...)
+		raw = append(raw, bytes.Repeat([]byte{0x00}, finality.ExtraSeal)...)
+		if _, err := finality.DecodeExtra(raw, true); !errors.Is(err, finality.ErrInvalidFinalityVotedBitSet) {
+			t.Fatalf("Expect error %v, got %v", finality.ErrInvalidFinalityVotedBitSet, err)
+		}
+	}
+
+	// The bit set is encoded in JSON as a number when it fits in 64 bits
+	for _, bitSet := range []finality.FinalityVoteBitSet{finality.NewFinalityVoteBitSet(0b101), extraData.FinalityVotedValidators} {
+		blob, err := json.Marshal(bitSet)
+		if err != nil {
+			t.Fatalf("Failed to encode bit set, err %s", err)
+		}
+		var decoded finality.FinalityVoteBitSet
+		if err := json.Unmarshal(blob, &decoded); err != nil {
+			t.Fatalf("Failed to decode bit set, err %s", err)
+		}
+		if !bytes.Equal(decoded, bitSet) {
+			t.Fatalf("Mismatch bit set after JSON round trip, exp %v got %v", bitSet.Indices(), decoded.Indices())
+		}
+	}
+	if blob, _ := json.Marshal(finality.NewFinalityVoteBitSet(0b101)); string(blob) != "5" {
+		t.Fatalf("Expect the bit set to be encoded as 5, got %s", blob)
+	}
+
+	// Before Venoki, the voters beyond the 64 bits bit set are neither
+	// aggregated nor accepted
+	snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, 10, parentHash, nil, set.WithBlsPub(), nil)
+	votedValidators, _, signatures := aggregateVotes(set.Votes(chainConfig, 10, parentHash, finalitytest.Source{}, positions...), snap, finality.MaxFinalityVoters(false))
+	if len(signatures) != 64 || len(votedValidators.Indices()) != 64 || votedValidators.Has(99) {
+		t.Fatalf("Expect the voters beyond 64 to be skipped, got %v", votedValidators.Indices())
+	}
+	c := &Consortium{chainConfig: chainConfig, config: chainConfig.Consortium}
+	header := &types.Header{Number: big.NewInt(11), ParentHash: parentHash, Extra: rawExtra}
+	if _, _, err := c.verifyHeaderAndParents(context.Background(), nil, header, nil); !errors.Is(err, finality.ErrInvalidFinalityVotedBitSet) {
+		t.Fatalf("Expect error %v, got %v", finality.ErrInvalidFinalityVotedBitSet, err)
+	}
+}
+
 func TestVerifyFinalitySignature(t *testing.T) {
 	const numValidator = 3
 	var err error
@@ -561,7 +868,7 @@ func TestVerifyFinalitySignature(t *testing.T) {
 	}
 
 	snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, nil, valWithBlsPub, nil)
-	recents, _ := lru.NewARC(inmemorySnapshots)
+	recents, _ := lru.New(inmemorySnapshots)
 	c := Consortium{
 		chainConfig: &params.ChainConfig{
 			ShillinBlock: big.NewInt(0),
@@ -581,7 +888,7 @@ func TestVerifyFinalitySignature(t *testing.T) {
 		t.Errorf("Expect error %v have %v", finality.ErrNotEnoughFinalityVote, err)
 	}
 
-	votedBitSet = finality.FinalityVoteBitSet(0)
+	votedBitSet = nil
 	votedBitSet.SetBit(0)
 	votedBitSet.SetBit(1)
 	votedBitSet.SetBit(3)
@@ -590,7 +897,7 @@ func TestVerifyFinalitySignature(t *testing.T) {
 		t.Errorf("Expect error %v have %v", finality.ErrInvalidFinalityVotedBitSet, err)
 	}
 
-	votedBitSet = finality.FinalityVoteBitSet(0)
+	votedBitSet = nil
 	votedBitSet.SetBit(0)
 	votedBitSet.SetBit(1)
 	votedBitSet.SetBit(2)
@@ -604,7 +911,7 @@ func TestVerifyFinalitySignature(t *testing.T) {
 		t.Errorf("Expect error %v have %v", finality.ErrFinalitySignatureVerificationFailed, err)
 	}
 
-	votedBitSet = finality.FinalityVoteBitSet(0)
+	votedBitSet = nil
 	votedBitSet.SetBit(0)
 	votedBitSet.SetBit(1)
 	votedBitSet.SetBit(2)
@@ -619,7 +926,7 @@ func TestVerifyFinalitySignature(t *testing.T) {
 		t.Errorf("Expect error %v have %v", finality.ErrFinalitySignatureVerificationFailed, err)
 	}
 
-	votedBitSet = finality.FinalityVoteBitSet(0)
+	votedBitSet = nil
 	votedBitSet.SetBit(0)
 	votedBitSet.SetBit(1)
 	votedBitSet.SetBit(2)
@@ -634,6 +941,56 @@ func TestVerifyFinalitySignature(t *testing.T) {
 	}
 }
 
+func TestLightVerification(t *testing.T) {
+	// The canonical headers from 0 to 3 and a side chain header at 2
+	var headers []*types.Header
+	for i := 0; i < 4; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i))}
+		if i > 0 {
+			header.ParentHash = headers[i-1].Hash()
+		}
+		headers = append(headers, header)
+	}
+	fork := &types.Header{Number: big.NewInt(2), ParentHash: headers[1].Hash(), Extra: []byte{0x1}}
+
+	c := Consortium{}
+	c.SetTrustedFinality(3, headers[3].Hash())
+	if c.skipFinalitySignatures(nil, headers[1], headers) {
+		t.Fatalf("Expect the finality signatures to be verified when the light verification is disabled")
+	}
+
+	c.SetLightVerification(true)
+	if !c.skipFinalitySignatures(nil, headers[3], headers) || !c.skipFinalitySignatures(nil, headers[1], headers) {
+		t.Fatalf("Expect the finality signatures to be skipped at or below the trusted checkpoint")
+	}
+	if c.skipFinalitySignatures(nil, fork, headers) {
+		t.Fatalf("Expect the finality signatures to be verified on the side chain")
+	}
+	if c.skipFinalitySignatures(nil, headers[1], nil) {
+		t.Fatalf("Expect the finality signatures to be verified with the unknown ancestry")
+	}
+
+	// The checkpoint is never lowered
+	c.SetTrustedFinality(2, fork.Hash())
+	if number, hash, enabled := c.TrustedFinality(); number != 3 || hash != headers[3].Hash() || !enabled {
+		t.Fatalf("Expect trusted checkpoint 3 %s, got %d %s", headers[3].Hash(), number, hash)
+	}
+	headers = append(headers, &types.Header{Number: big.NewInt(4), ParentHash: headers[3].Hash()})
+	c.SetTrustedFinality(4, headers[4].Hash())
+	if !c.skipFinalitySignatures(nil, headers[3], headers) {
+		t.Fatalf("Expect the finality signatures to be skipped below the raised checkpoint")
+	}
+	c.SetTrustedFinality(5, common.Hash{0x1})
+	if c.skipFinalitySignatures(nil, headers[3], headers) {
+		t.Fatalf("Expect the finality signatures to be verified below the unknown checkpoint")
+	}
+
+	c.SetLightVerification(false)
+	if c.skipFinalitySignatures(nil, headers[1], headers) {
+		t.Fatalf("Expect the finality signatures to be verified after disabling the light verification")
+	}
+}
+
 func TestSnapshotValidatorWithBlsKey(t *testing.T) {
 	secretKey, err := blst.RandKey()
 	if err != nil {
@@ -674,8 +1031,80 @@ func TestSnapshotValidatorWithBlsKey(t *testing.T) {
 	}
 }
 
+func TestSnapshotValidatorSetProof(t *testing.T) {
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate secret key, err: %s", err)
+	}
+	chainConfig := &params.ChainConfig{
+		ShillinBlock: big.NewInt(0),
+	}
+	validators := []finality.ValidatorWithBlsPub{
+		{
+			Address:      common.Address{0x1},
+			BlsPublicKey: secretKey.PublicKey(),
+		},
+	}
+	checkpoint := &types.Header{Number: big.NewInt(200)}
+
+	// The child of the checkpoint carries no finality vote
+	child := &types.Header{
+		Number:     big.NewInt(201),
+		ParentHash: checkpoint.Hash(),
+		Extra:      (&finality.HeaderExtraData{}).Encode(true),
+	}
+	proof, err := newValidatorSetProof(chainConfig, checkpoint, child, validators)
+	if err != nil {
+		t.Fatalf("Failed to create proof, err: %s", err)
+	}
+	if proof.CheckpointNumber != 200 || proof.CheckpointHash != checkpoint.Hash() || proof.ValidatorSetHash != ValidatorSetHash(validators) {
+		t.Fatalf("Mismatch checkpoint in proof, got %+v", proof)
+	}
+	if len(proof.AggregatedFinalityVotes) != 0 {
+		t.Fatalf("Expect no finality votes, got %x", proof.AggregatedFinalityVotes)
+	}
+
+	digest := [32]byte{}
+	signature := secretKey.Sign(digest[:])
+	extraData := finality.HeaderExtraData{
+		HasFinalityVote:         1,
+		FinalityVotedValidators: finality.NewFinalityVoteBitSet(1),
+		AggregatedFinalityVotes: signature,
+	}
+	child.Extra = extraData.Encode(true)
+	proof, err = newValidatorSetProof(chainConfig, checkpoint, child, validators)
+	if err != nil {
+		t.Fatalf("Failed to create proof, err: %s", err)
+	}
+	if bits, _ := proof.FinalityVotedValidators.Uint64(); bits != 1 || !bytes.Equal(proof.AggregatedFinalityVotes, signature.Marshal()) {
+		t.Fatalf("Mismatch finality votes in proof, got %+v", proof)
+	}
+
+	// The proof is stored along with the snapshot
+	snap := newSnapshot(nil, nil, nil, 400, common.Hash{0x2}, nil, validators, nil)
+	snap.ValidatorSetProof = proof
+	db := rawdb.NewMemoryDatabase()
+	if err := snap.store(db); err != nil {
+		t.Fatalf("Failed to store snapshot, err: %s", err)
+	}
+	savedSnap, err := loadSnapshot(nil, nil, db, common.Hash{0x2}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot, err: %s", err)
+	}
+	if !reflect.DeepEqual(savedSnap.ValidatorSetProof, proof) {
+		t.Fatalf("Mismatch stored proof, have %+v expect %+v", savedSnap.ValidatorSetProof, proof)
+	}
+	if cpy := savedSnap.copy(); cpy.ValidatorSetProof != savedSnap.ValidatorSetProof {
+		t.Fatalf("Snapshot copy drops the proof")
+	}
+}
+
 type mockContract struct {
 	validators map[common.Address]blsCommon.PublicKey
+	gasLimit   uint64 // Target gas limit of the governance contract, none if 0
+	gasReads   int
+
+	doubleSigns []*consortiumCommon.DoubleSignEvidence // Reported double signs
 }
 
 func (contract *mockContract) WrapUpEpoch(opts *consortiumCommon.ApplyTransactOpts) error {
@@ -694,6 +1123,15 @@ func (contract *mockContract) FinalityReward(opts *consortiumCommon.ApplyTransac
 	return nil
 }
 
+func (contract *mockContract) SlashDoubleSign(opts *consortiumCommon.ApplyTransactOpts, evidence *consortiumCommon.DoubleSignEvidence) error {
+	contract.doubleSigns = append(contract.doubleSigns, evidence)
+	// The received system transaction is consumed like by ApplyTransaction
+	if opts.ReceivedTxs != nil && len(*opts.ReceivedTxs) > 0 {
+		*opts.ReceivedTxs = (*opts.ReceivedTxs)[1:]
+	}
+	return nil
+}
+
 func (contract *mockContract) GetValidators(*big.Int) ([]common.Address, error) {
 	var validatorAddresses []common.Address
 	for address := range contract.validators {
@@ -714,6 +1152,22 @@ func (contract *mockContract) GetBlsPublicKey(_ *big.Int, address common.Address
 	}
 }
 
+func (contract *mockContract) GetGasLimitTarget(*big.Int) (uint64, error) {
+	contract.gasReads++
+	if contract.gasLimit == 0 {
+		return 0, consortiumCommon.ErrNoGasLimitContract
+	}
+	return contract.gasLimit, nil
+}
+
+func (contract *mockContract) InvalidateBlsPublicKeys([]*types.Receipt) {}
+
+func (contract *mockContract) PurgeCaches() {}
+
+func (contract *mockContract) Pin(*big.Int, common.Hash) consortiumCommon.ContractInteraction {
+	return contract
+}
+
 func TestGetCheckpointValidatorFromContract(t *testing.T) {
 	var err error
 	secretKeys := make([]blsCommon.SecretKey, 3)
@@ -767,6 +1221,49 @@ func TestGetCheckpointValidatorFromContract(t *testing.T) {
 	}
 }
 
+func TestDiffCheckpointValidators(t *testing.T) {
+	secretKeys := make([]blsCommon.SecretKey, 2)
+	for i := range secretKeys {
+		var err error
+		secretKeys[i], err = blst.RandKey()
+		if err != nil {
+			t.Fatalf("Failed to generate secret key, err: %s", err)
+		}
+	}
+	header := []finality.ValidatorWithBlsPub{
+		{Address: common.Address{0x1}, BlsPublicKey: secretKeys[0].PublicKey()},
+		{Address: common.Address{0x2}, BlsPublicKey: secretKeys[1].PublicKey()},
+	}
+
+	if divergences := diffCheckpointValidators(header, header, true); len(divergences) != 0 {
+		t.Fatalf("Expect no divergence, got %d", len(divergences))
+	}
+
+	// The second validator has another key in the contracts and a third one is added
+	contract := []finality.ValidatorWithBlsPub{
+		{Address: common.Address{0x1}, BlsPublicKey: secretKeys[0].PublicKey()},
+		{Address: common.Address{0x2}, BlsPublicKey: secretKeys[0].PublicKey()},
+		{Address: common.Address{0x3}, BlsPublicKey: secretKeys[1].PublicKey()},
+	}
+	divergences := diffCheckpointValidators(header, contract, true)
+	if len(divergences) != 2 {
+		t.Fatalf("Expect 2 divergences, got %d", len(divergences))
+	}
+	if divergences[0].Index != 1 || *divergences[0].HeaderAddress != *divergences[0].ContractAddress ||
+		!bytes.Equal(divergences[0].HeaderBlsKey, secretKeys[1].PublicKey().Marshal()) ||
+		!bytes.Equal(divergences[0].ContractBlsKey, secretKeys[0].PublicKey().Marshal()) {
+		t.Fatalf("Key divergence mismatch, got %+v", divergences[0])
+	}
+	if divergences[1].Index != 2 || divergences[1].HeaderAddress != nil || *divergences[1].ContractAddress != (common.Address{0x3}) {
+		t.Fatalf("Missing validator divergence mismatch, got %+v", divergences[1])
+	}
+
+	// The keys are not compared before Shillin
+	if divergences := diffCheckpointValidators(header, contract[:2], false); len(divergences) != 0 {
+		t.Fatalf("Expect no divergence without comparing the keys, got %d", len(divergences))
+	}
+}
+
 type mockVotePool struct {
 	vote []*types.VoteEnvelope
 }
@@ -814,6 +1311,7 @@ func TestAssembleFinalityVote(t *testing.T) {
 		chainConfig: &params.ChainConfig{
 			ShillinBlock: big.NewInt(0),
 		},
+		config:   &params.ConsortiumConfig{},
 		votePool: &mock,
 	}
 
@@ -841,16 +1339,16 @@ func TestAssembleFinalityVote(t *testing.T) {
 		t.Fatal("Missing finality vote in header")
 	}
 
-	bitSet := finality.FinalityVoteBitSet(0)
+	var bitSet finality.FinalityVoteBitSet
 	for i := 0; i < 9; i++ {
 		bitSet.SetBit(i)
 	}
 
-	if uint64(bitSet) != uint64(extraData.FinalityVotedValidators) {
+	if !bytes.Equal(bitSet, extraData.FinalityVotedValidators) {
 		t.Fatalf(
-			"Mismatch voted validator, expect %d have %d",
-			uint64(bitSet),
-			uint64(extraData.FinalityVotedValidators),
+			"Mismatch voted validator, expect %v have %v",
+			bitSet.Indices(),
+			extraData.FinalityVotedValidators.Indices(),
 		)
 	}
 
@@ -899,7 +1397,7 @@ func TestVerifyVote(t *testing.T) {
 	}
 
 	snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, nil, valWithBlsPub, nil)
-	recents, _ := lru.NewARC(inmemorySnapshots)
+	recents, _ := lru.New(inmemorySnapshots)
 	c := Consortium{
 		chainConfig: &params.ChainConfig{
 			ShillinBlock: big.NewInt(0),
@@ -972,3 +1470,1230 @@ func TestVerifyVote(t *testing.T) {
 		t.Errorf("Expect sucessful verification have %s", err)
 	}
 }
+
+func TestVerifyVoteV2(t *testing.T) {
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate secret key, err %s", err)
+	}
+	valWithBlsPub := []finality.ValidatorWithBlsPub{
+		{
+			Address:      common.BigToAddress(big.NewInt(0)),
+			BlsPublicKey: secretKey.PublicKey(),
+		},
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, nil, true)
+	if _, err := chain.InsertChain(bs[:]); err != nil {
+		panic(err)
+	}
+
+	snap := newSnapshot(nil, nil, nil, 1, bs[0].Hash(), nil, valWithBlsPub, nil)
+	snap.JustifiedBlockNumber = 0
+	snap.JustifiedBlockHash = genesis.Hash()
+	recents, _ := lru.New(inmemorySnapshots)
+	c := Consortium{
+		chainConfig: &params.ChainConfig{
+			ChainID:      big.NewInt(2021),
+			ShillinBlock: big.NewInt(0),
+			TrippBlock:   big.NewInt(0),
+			Consortium: &params.ConsortiumConfig{
+				EpochV2: 300,
+			},
+		},
+		config: &params.ConsortiumConfig{
+			EpochV2: 300,
+		},
+		recents: recents,
+	}
+	c.recents.Add(snap.Hash, snap)
+
+	newVote := func(voteData *types.VoteData) *types.VoteEnvelope {
+		return &types.VoteEnvelope{
+			RawVoteEnvelope: types.RawVoteEnvelope{
+				PublicKey: types.BLSPublicKey(secretKey.PublicKey().Marshal()),
+				Signature: types.BLSSignature(secretKey.Sign(voteData.Hash().Bytes()).Marshal()),
+				Data:      voteData,
+			},
+		}
+	}
+	validVoteData := func() *types.VoteData {
+		return types.NewVoteData(c.chainConfig, 1, bs[0].Hash(), 0, genesis.Hash())
+	}
+
+	// v1 vote after the Tripp hardfork
+	err = c.VerifyVote(chain, newVote(&types.VoteData{TargetNumber: 1, TargetHash: bs[0].Hash()}))
+	if !errors.Is(err, finality.ErrInvalidVoteVersion) {
+		t.Errorf("Expect error %v have %v", finality.ErrInvalidVoteVersion, err)
+	}
+
+	// vote signed for another network
+	voteData := validVoteData()
+	voteData.ChainID = big.NewInt(2020)
+	err = c.VerifyVote(chain, newVote(voteData))
+	if !errors.Is(err, finality.ErrInvalidVoteChainID) {
+		t.Errorf("Expect error %v have %v", finality.ErrInvalidVoteChainID, err)
+	}
+
+	// vote with another source
+	voteData = validVoteData()
+	voteData.SourceHash = common.Hash{0x1}
+	err = c.VerifyVote(chain, newVote(voteData))
+	if !errors.Is(err, finality.ErrInvalidVoteSource) {
+		t.Errorf("Expect error %v have %v", finality.ErrInvalidVoteSource, err)
+	}
+
+	// vote with another epoch
+	voteData = validVoteData()
+	voteData.TargetEpoch = 1
+	err = c.VerifyVote(chain, newVote(voteData))
+	if !errors.Is(err, finality.ErrInvalidVoteEpoch) {
+		t.Errorf("Expect error %v have %v", finality.ErrInvalidVoteEpoch, err)
+	}
+
+	// sucessful case
+	err = c.VerifyVote(chain, newVote(validVoteData()))
+	if err != nil {
+		t.Errorf("Expect sucessful verification have %s", err)
+	}
+}
+
+func TestUnderTxBacklog(t *testing.T) {
+	c := Consortium{}
+	if c.underTxBacklog() {
+		t.Fatalf("Expect no backlog when disabled")
+	}
+
+	pending := 0
+	c.SetSealBacklog(100, func() int { return pending })
+	for _, test := range []struct {
+		pending int
+		backlog bool
+	}{
+		{0, false},
+		{100, false},
+		{101, true},
+	} {
+		pending = test.pending
+		if backlog := c.underTxBacklog(); backlog != test.backlog {
+			t.Fatalf("Backlog mismatch with %d pending txs, expect %t got %t", test.pending, test.backlog, backlog)
+		}
+	}
+
+	c.SetSealBacklog(0, func() int { return pending })
+	if c.underTxBacklog() {
+		t.Fatalf("Expect no backlog when disabled")
+	}
+}
+
+func TestVerifiedHeaderCache(t *testing.T) {
+	verified, _ := lru.NewARC(verifiedHeaders)
+	c := Consortium{
+		chainConfig: &params.ChainConfig{},
+		config:      &params.ConsortiumConfig{EpochV2: 200},
+		verified:    verified,
+	}
+	// The extra data is too short to be decoded so the verification fails
+	header := &types.Header{Number: big.NewInt(1)}
+	if err := c.VerifyHeaderAndParents(nil, header, nil); err == nil {
+		t.Fatalf("Expect error on invalid header")
+	}
+	if verified.Len() != 0 {
+		t.Fatalf("Expect failed verification not to be cached")
+	}
+
+	verified.Add(verifiedHeaderKey{hash: header.Hash(), epoch: 200}, struct{}{})
+	if err := c.VerifyHeaderAndParents(nil, header, nil); err != nil {
+		t.Fatalf("Expect cached header to pass verification, err %s", err)
+	}
+
+	// The cache entry does not apply to another epoch config
+	c.config = &params.ConsortiumConfig{EpochV2: 100}
+	if err := c.VerifyHeaderAndParents(nil, header, nil); err == nil {
+		t.Fatalf("Expect error on invalid header with another epoch")
+	}
+}
+
+func TestSimulateEpoch(t *testing.T) {
+	c := Consortium{config: &params.ConsortiumConfig{EpochV2: 200}}
+	for _, tt := range []struct{ number, wrapUp uint64 }{{0, 199}, {197, 199}, {198, 199}, {199, 399}, {200, 399}} {
+		if wrapUp := c.nextWrapUpEpoch(tt.number); wrapUp != tt.wrapUp {
+			t.Fatalf("Wrap up block after %d mismatch, exp %d got %d", tt.number, tt.wrapUp, wrapUp)
+		}
+	}
+
+	// The invalid overrides are rejected before the simulation
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	slots := map[common.Hash]common.Hash{}
+	overrides := ethapi.StateOverride{common.HexToAddress("0xab"): {State: &slots, StateDiff: &slots}}
+	if _, err := c.SimulateEpoch(nil, &types.Header{Number: big.NewInt(1)}, statedb, &overrides); err == nil {
+		t.Fatalf("Expect error on the overrides with both state and state diff")
+	}
+}
+
+// prefetchChain is a chain without the headers able to open the state
+type prefetchChain struct {
+	consensus.ChainHeaderReader
+}
+
+func (chain *prefetchChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+func (chain *prefetchChain) StateAt(common.Hash) (*state.StateDB, error) {
+	return nil, errors.New("no state")
+}
+
+func TestPrefetchEpochState(t *testing.T) {
+	val := common.BigToAddress(big.NewInt(1))
+	other := common.BigToAddress(big.NewInt(2))
+	c := Consortium{
+		config:   &params.ConsortiumConfig{EpochV2: 200},
+		val:      val,
+		contract: &mockContract{},
+	}
+	// The validator at (number + 1) % 2 in the sorted set is in-turn to seal the next block
+	snap := newSnapshot(nil, nil, nil, 197, common.Hash{}, []common.Address{val, other}, nil, nil)
+	chain := &prefetchChain{}
+
+	// The next block does not wrap up the epoch
+	c.prefetchEpochState(chain, &types.Header{Number: big.NewInt(197)}, snap)
+	if c.epochPrefetched != 0 {
+		t.Fatalf("Expect no prefetch before a non epoch block, got %d", c.epochPrefetched)
+	}
+
+	// The local validator is not in-turn to seal the epoch block
+	c.prefetchEpochState(chain, &types.Header{Number: big.NewInt(198)}, snap)
+	if c.epochPrefetched != 0 {
+		t.Fatalf("Expect no prefetch when not in-turn, got %d", c.epochPrefetched)
+	}
+
+	c.val = other
+	c.prefetchEpochState(chain, &types.Header{Number: big.NewInt(198)}, snap)
+	if c.epochPrefetched != 199 {
+		t.Fatalf("Expect the epoch block to be prefetched, exp %d got %d", 199, c.epochPrefetched)
+	}
+}
+
+func TestValidatorSetOverride(t *testing.T) {
+	c := Consortium{
+		chainConfig: &params.ChainConfig{ShillinBlock: common.Big0},
+		config:      &params.ConsortiumConfig{EpochV2: 200},
+		contract:    &mockContract{},
+	}
+	key, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate secret key, err: %s", err)
+	}
+	validators := []finality.ValidatorWithBlsPub{
+		{Address: common.Address{0x2}, BlsPublicKey: key.PublicKey()},
+		{Address: common.Address{0x1}, BlsPublicKey: key.PublicKey()},
+	}
+
+	if err := c.SetValidatorSetOverride(&ValidatorSetOverride{Block: 201, Validators: validators}); !errors.Is(err, errOverrideNotCheckpoint) {
+		t.Fatalf("Expect error %s, got %v", errOverrideNotCheckpoint, err)
+	}
+	if err := c.SetValidatorSetOverride(&ValidatorSetOverride{Block: 400}); !errors.Is(err, errOverrideEmpty) {
+		t.Fatalf("Expect error %s, got %v", errOverrideEmpty, err)
+	}
+	duplicated := append(validators, validators[0])
+	if err := c.SetValidatorSetOverride(&ValidatorSetOverride{Block: 400, Validators: duplicated}); !errors.Is(err, errOverrideDuplicate) {
+		t.Fatalf("Expect error %s, got %v", errOverrideDuplicate, err)
+	}
+	if err := c.SetValidatorSetOverride(&ValidatorSetOverride{Block: 400, Validators: validators}); err != nil {
+		t.Fatalf("Failed to set validator set override, err %s", err)
+	}
+
+	checkpointValidators, err := c.getCheckpointValidatorsFromContract(&types.Header{Number: big.NewInt(400)})
+	if err != nil {
+		t.Fatalf("Failed to get checkpoint validators, err %s", err)
+	}
+	if len(checkpointValidators) != 2 || checkpointValidators[0].Address != validators[1].Address || checkpointValidators[1].Address != validators[0].Address {
+		t.Fatalf("Expect the sorted override validators, got %+v", checkpointValidators)
+	}
+
+	// The contract result is used at the other checkpoints
+	checkpointValidators, err = c.getCheckpointValidatorsFromContract(&types.Header{Number: big.NewInt(600)})
+	if err != nil {
+		t.Fatalf("Failed to get checkpoint validators, err %s", err)
+	}
+	if len(checkpointValidators) != 0 {
+		t.Fatalf("Expect the contract validators, got %+v", checkpointValidators)
+	}
+}
+
+func TestSnapshotMigration(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+
+	// A snapshot written before the versioning
+	legacy := []byte(`{"number":10,"hash":"0x0200000000000000000000000000000000000000000000000000000000000000","validators":{"0x0100000000000000000000000000000000000000":{}},"recents":{"10":"0x0100000000000000000000000000000000000000"}}`)
+	legacyKey := append(common.CopyBytes(rawdb.ConsortiumSnapshotPrefix), common.Hash{0x2}.Bytes()...)
+	db.Put(legacyKey, legacy)
+
+	// A snapshot written by the v1 engine, the recents before 18 are outdated
+	v1Snapshot := []byte(`{"number":20,"hash":"0x0300000000000000000000000000000000000000000000000000000000000000","signerSet":{"0x0100000000000000000000000000000000000000":{},"0x0200000000000000000000000000000000000000":{}},"signerList":["0x0100000000000000000000000000000000000000","0x0200000000000000000000000000000000000000"],"recents":{"15":"0x0100000000000000000000000000000000000000","19":"0x0100000000000000000000000000000000000000","20":"0x0200000000000000000000000000000000000000"}}`)
+	v1Key := append(common.CopyBytes(rawdb.ConsortiumSnapshotPrefix), common.Hash{0x3}.Bytes()...)
+	db.Put(v1Key, v1Snapshot)
+
+	snap, err := loadSnapshot(nil, nil, db, common.Hash{0x3}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to load v1 snapshot, err: %s", err)
+	}
+	if snap.Version != SnapshotVersion {
+		t.Fatalf("Expect version %d, got %d", SnapshotVersion, snap.Version)
+	}
+	if len(snap.validators()) != 2 {
+		t.Fatalf("Expect 2 validators, got %d", len(snap.validators()))
+	}
+	if len(snap.Recents) != 2 {
+		t.Fatalf("Expect 2 recents, got %v", snap.Recents)
+	}
+
+	migrated, err := MigrateSnapshots(db)
+	if err != nil {
+		t.Fatalf("Failed to migrate snapshots, err: %s", err)
+	}
+	if migrated != 1 {
+		t.Fatalf("Expect 1 migrated snapshot, got %d", migrated)
+	}
+	// The v1 snapshot is still readable by the v1 engine
+	if blob, _ := db.Get(v1Key); !bytes.Equal(blob, v1Snapshot) {
+		t.Fatalf("Expect v1 snapshot to be untouched, got %s", blob)
+	}
+	blob, _ := db.Get(legacyKey)
+	if _, changed, err := MigrateSnapshot(blob); err != nil || changed {
+		t.Fatalf("Expect migrated snapshot to be up to date, changed %v err %v", changed, err)
+	}
+	snap, err = loadSnapshot(nil, nil, db, common.Hash{0x2}, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to load migrated snapshot, err: %s", err)
+	}
+	if snap.Number != 10 || !snap.inInValidatorSet(common.Address{0x1}) || snap.Recents[10] != (common.Address{0x1}) {
+		t.Fatalf("Migrated snapshot is corrupted")
+	}
+
+	// The snapshots of a newer engine are refused
+	if _, _, err := MigrateSnapshot([]byte(`{"version":100}`)); !errors.Is(err, errUnknownSnapshotVersion) {
+		t.Fatalf("Expect error %v, got %v", errUnknownSnapshotVersion, err)
+	}
+	if len(snapshotMigrations) != SnapshotVersion {
+		t.Fatalf("Expect %d snapshot migrations, got %d", SnapshotVersion, len(snapshotMigrations))
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	set, err := finalitytest.NewValidatorSet(4)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err: %s", err)
+	}
+	var (
+		db          = rawdb.NewMemoryDatabase()
+		chainConfig = &params.ChainConfig{
+			ChainID:      big.NewInt(2020),
+			ShillinBlock: common.Big0,
+			Consortium:   &params.ConsortiumConfig{EpochV2: 100},
+		}
+		header = &types.Header{Number: big.NewInt(200), Extra: finalitytest.NewExtraDataBuilder(chainConfig).WithCheckpointValidators(set).Encode()}
+	)
+	child := &types.Header{
+		Number:     big.NewInt(201),
+		ParentHash: header.Hash(),
+		Extra:      finalitytest.NewExtraDataBuilder(chainConfig).WithFinalityVotes(set, 200, header.Hash(), finalitytest.Source{}, 0, 1, 2).Encode(),
+	}
+	for _, header := range []*types.Header{header, child} {
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+	}
+	snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, 200, header.Hash(), nil, set.WithBlsPub(), nil)
+	if err := snap.store(db); err != nil {
+		t.Fatalf("Failed to store snapshot, err: %s", err)
+	}
+
+	if _, err := ReadCheckpoint(db, chainConfig, 201); !errors.Is(err, errCheckpointNotEpoch) {
+		t.Fatalf("Expect error %v, got %v", errCheckpointNotEpoch, err)
+	}
+	checkpoint, err := ReadCheckpoint(db, chainConfig, 200)
+	if err != nil {
+		t.Fatalf("Failed to read checkpoint, err: %s", err)
+	}
+	blob, err := json.Marshal(checkpoint)
+	if err != nil {
+		t.Fatalf("Failed to encode checkpoint, err: %s", err)
+	}
+	var decoded Checkpoint
+	if err := json.Unmarshal(blob, &decoded); err != nil {
+		t.Fatalf("Failed to decode checkpoint, err: %s", err)
+	}
+	if err := VerifyCheckpoint(chainConfig, &decoded); err != nil {
+		t.Fatalf("Failed to verify checkpoint, err: %s", err)
+	}
+
+	// The validator set and the finality signature are bound to the checkpoint
+	tampered := decoded
+	tampered.ValidatorSetHash = common.Hash{0x1}
+	if err := VerifyCheckpoint(chainConfig, &tampered); !errors.Is(err, errCheckpointValidatorSet) {
+		t.Fatalf("Expect error %v, got %v", errCheckpointValidatorSet, err)
+	}
+	tampered = decoded
+	tampered.FinalityVotedValidators = finality.NewFinalityVoteBitSet(0b1011)
+	if err := VerifyCheckpoint(chainConfig, &tampered); !errors.Is(err, finality.ErrFinalitySignatureVerificationFailed) {
+		t.Fatalf("Expect error %v, got %v", finality.ErrFinalitySignatureVerificationFailed, err)
+	}
+
+	// A fresh node trusts the checkpoint and loads its snapshot
+	fresh := rawdb.NewMemoryDatabase()
+	if err := WriteTrustedCheckpoint(fresh, &decoded); err != nil {
+		t.Fatalf("Failed to write trusted checkpoint, err: %s", err)
+	}
+	if number, hash, ok := rawdb.ReadTrustedCheckpoint(fresh); !ok || number != 200 || hash != header.Hash() {
+		t.Fatalf("Expect trusted checkpoint 200 %x, got %d %x", header.Hash(), number, hash)
+	}
+	loaded, err := loadSnapshot(chainConfig.Consortium, nil, fresh, header.Hash(), nil, chainConfig)
+	if err != nil {
+		t.Fatalf("Failed to load checkpoint snapshot, err: %s", err)
+	}
+	if ValidatorSetHash(loaded.ValidatorsWithBlsPub) != checkpoint.ValidatorSetHash {
+		t.Fatalf("Checkpoint snapshot is corrupted")
+	}
+}
+
+func TestAdaptiveWiggle(t *testing.T) {
+	c := &Consortium{config: &params.ConsortiumConfig{Period: 3}}
+	parent := &types.Header{Number: big.NewInt(10), Time: 100}
+	inTurn := &types.Header{Number: big.NewInt(11), Time: 103, Difficulty: diffInTurn}
+	outOfTurn := &types.Header{Number: big.NewInt(11), Time: 105, Difficulty: diffNoTurn}
+
+	// The out-of-turn blocks do not change the latency
+	c.SetClock(new(mclock.Simulated), time.Unix(110, 0))
+	c.recordSealing(outOfTurn, parent)
+	if multiplier := c.sealingStats.wiggleMultiplier(); multiplier != 1 {
+		t.Fatalf("Wiggle multiplier mismatch, expect %v got %v", 1, multiplier)
+	}
+
+	// The in-turn block arriving half of wiggleTime late
+	c.SetClock(new(mclock.Simulated), time.Unix(103, 0).Add(wiggleTime/2))
+	c.recordSealing(inTurn, parent)
+	if multiplier := c.sealingStats.wiggleMultiplier(); multiplier != 1.5 {
+		t.Fatalf("Wiggle multiplier mismatch, expect %v got %v", 1.5, multiplier)
+	}
+	if delay := c.adaptiveWiggleDelay(); delay != 0 {
+		t.Fatalf("Expect no delay when the adaptive wiggle is disabled, got %v", delay)
+	}
+	if _, err := features.Set("consortium.adaptivewiggle", true); err != nil {
+		t.Fatalf("Failed to enable adaptive wiggle, err %s", err)
+	}
+	defer features.Set("consortium.adaptivewiggle", false)
+	if delay := c.adaptiveWiggleDelay(); delay != wiggleTime/2 {
+		t.Fatalf("Adaptive wiggle delay mismatch, expect %v got %v", wiggleTime/2, delay)
+	}
+
+	// The multiplier is capped however late the blocks are
+	c.SetClock(new(mclock.Simulated), time.Unix(103, 0).Add(30*time.Second))
+	for i := 0; i < 10; i++ {
+		c.recordSealing(inTurn, parent)
+	}
+	if multiplier := c.sealingStats.wiggleMultiplier(); multiplier != maxWiggleMultiplier {
+		t.Fatalf("Wiggle multiplier mismatch, expect %v got %v", maxWiggleMultiplier, multiplier)
+	}
+
+	// The blocks received while syncing are not observed
+	c.SetClock(new(mclock.Simulated), time.Unix(103, 0).Add(sealingLatencyMax+time.Second))
+	samples := c.sealingStats.samples
+	c.recordSealing(inTurn, parent)
+	if c.sealingStats.samples != samples {
+		t.Fatalf("Expect the stale block not to be observed")
+	}
+}
+
+// headersByNumber is a chain reader only serving the canonical headers by number
+type headersByNumber struct {
+	consensus.ChainHeaderReader
+	headers map[uint64]*types.Header
+}
+
+func (chain *headersByNumber) GetHeaderByNumber(number uint64) *types.Header {
+	return chain.headers[number]
+}
+
+func TestSnapshotArchive(t *testing.T) {
+	set, err := finalitytest.NewValidatorSet(3)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err: %s", err)
+	}
+	archive, err := OpenSnapshotArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open snapshot archive, err: %s", err)
+	}
+	defer archive.Close()
+
+	chainConfig := &params.ChainConfig{
+		ShillinBlock: common.Big0,
+		Consortium:   &params.ConsortiumConfig{EpochV2: 100},
+	}
+	c := &Consortium{
+		chainConfig:     chainConfig,
+		config:          chainConfig.Consortium,
+		forkedBlock:     151,
+		db:              rawdb.NewMemoryDatabase(),
+		snapshotArchive: archive,
+	}
+	chain := &headersByNumber{headers: make(map[uint64]*types.Header)}
+	for number := uint64(200); number <= 500; number += 100 {
+		header := &types.Header{Number: new(big.Int).SetUint64(number)}
+		chain.headers[number] = header
+		// The snapshot at 300 is never stored
+		if number == 300 {
+			continue
+		}
+		snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, number, header.Hash(), nil, set.WithBlsPub(), nil)
+		snap.Recents[number-1] = common.Address{0x1}
+		snap.Recents[number] = common.Address{0x2}
+		snap.JustifiedBlockNumber = number - 1
+		snap.JustifiedBlockHash = common.Hash{0x3}
+		snap.ValidatorSetProof = &ValidatorSetProof{CheckpointNumber: number - 100, FinalityVotedValidators: finality.NewFinalityVoteBitSet(0b11)}
+		if err := snap.store(c.db); err != nil {
+			t.Fatalf("Failed to store snapshot, err: %s", err)
+		}
+	}
+
+	// The snapshots are archived once their next epoch block is finalized
+	archived, err := c.ArchiveSnapshots(chain, 500)
+	if err != nil {
+		t.Fatalf("Failed to archive snapshots, err: %s", err)
+	}
+	if archived != 3 {
+		t.Fatalf("Expect 3 archived epochs, got %d", archived)
+	}
+	if archived, _ := c.ArchiveSnapshots(chain, 500); archived != 0 {
+		t.Fatalf("Expect no more archived epochs, got %d", archived)
+	}
+
+	for _, number := range []uint64{200, 400} {
+		hash := chain.headers[number].Hash()
+		if _, err := loadSnapshot(c.config, nil, c.db, hash, nil, chainConfig); err == nil {
+			t.Fatalf("Expect the archived snapshot %d to be deleted from the database", number)
+		}
+		snap, err := c.readSnapshot(number, hash)
+		if err != nil {
+			t.Fatalf("Failed to read archived snapshot %d, err: %s", number, err)
+		}
+		if snap.Number != number || snap.Hash != hash || snap.Version != SnapshotVersion {
+			t.Fatalf("Mismatch archived snapshot header, got %d %x", snap.Number, snap.Hash)
+		}
+		if ValidatorSetHash(snap.ValidatorsWithBlsPub) != ValidatorSetHash(set.WithBlsPub()) {
+			t.Fatalf("Mismatch archived validator set")
+		}
+		if len(snap.Recents) != 2 || snap.Recents[number-1] != (common.Address{0x1}) || snap.Recents[number] != (common.Address{0x2}) {
+			t.Fatalf("Mismatch archived recents, got %v", snap.Recents)
+		}
+		if snap.JustifiedBlockNumber != number-1 || snap.JustifiedBlockHash != (common.Hash{0x3}) {
+			t.Fatalf("Mismatch archived justified block, got %d %x", snap.JustifiedBlockNumber, snap.JustifiedBlockHash)
+		}
+		if snap.ValidatorSetProof == nil || snap.ValidatorSetProof.CheckpointNumber != number-100 || !bytes.Equal(snap.ValidatorSetProof.FinalityVotedValidators, finality.NewFinalityVoteBitSet(0b11)) {
+			t.Fatalf("Mismatch archived proof, got %+v", snap.ValidatorSetProof)
+		}
+	}
+	// The missing and the non-canonical snapshots are not found
+	if _, err := c.readSnapshot(300, chain.headers[300].Hash()); err == nil {
+		t.Fatalf("Expect the missing snapshot not to be found")
+	}
+	if _, err := c.readSnapshot(200, common.Hash{0x4}); err == nil {
+		t.Fatalf("Expect the non-canonical snapshot not to be found")
+	}
+	// The snapshot whose next epoch block is not finalized stays in the database
+	if _, err := loadSnapshot(c.config, nil, c.db, chain.headers[500].Hash(), nil, chainConfig); err != nil {
+		t.Fatalf("Expect the snapshot 500 to stay in the database, err: %s", err)
+	}
+}
+
+func TestCloseStopsLoops(t *testing.T) {
+	archive, err := OpenSnapshotArchive(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open snapshot archive, err: %s", err)
+	}
+	defer archive.Close()
+
+	db := rawdb.NewMemoryDatabase()
+	(&core.Genesis{
+		Config:  params.TestChainConfig,
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	c := &Consortium{
+		chainConfig:     params.TestChainConfig,
+		config:          &params.ConsortiumConfig{EpochV2: 100, Period: 3},
+		db:              db,
+		snapshotArchive: archive,
+		quit:            make(chan struct{}),
+	}
+	c.StartInactivityTracker(chain, 0, nil)
+	c.StartScoreTracker(chain, DefaultScoreConfig)
+	c.StartShadowSealing(chain, common.Address{0x1})
+	c.StartSnapshotArchiving(chain)
+
+	// The chain is still running, the loops must exit on Close alone
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not wait for the loops to exit")
+	}
+	// Close is idempotent
+	c.Close()
+}
+
+func TestBlsKeyRotation(t *testing.T) {
+	set, err := finalitytest.NewValidatorSet(4)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err: %s", err)
+	}
+	newKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create BLS key, err: %s", err)
+	}
+	unknownKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create BLS key, err: %s", err)
+	}
+	// The validator 1 rotates its BLS key
+	rotated := make(finalitytest.ValidatorSet, len(set))
+	copy(rotated, set)
+	rotated[1] = &finalitytest.Validator{Address: set[1].Address, Key: set[1].Key, BlsKey: newKey}
+
+	chainConfig := &params.ChainConfig{
+		ShillinBlock: common.Big0,
+		OrcaBlock:    big.NewInt(100),
+		Consortium:   &params.ConsortiumConfig{EpochV2: 200, BlsKeyRotationOverlap: 10},
+	}
+	snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, 102, common.Hash{0x1}, nil, set.WithBlsPub(), nil)
+	snap.rotateBlsKeys(102, rotated.WithBlsPub())
+	snap.ValidatorsWithBlsPub = rotated.WithBlsPub()
+	if len(snap.PreviousBlsKeys) != 1 || snap.PreviousBlsKeys[0].Address != set[1].Address || snap.PreviousBlsKeysUntil != 112 {
+		t.Fatalf("Expect the previous key of validator 1 until 112, got %v until %d", snap.PreviousBlsKeys, snap.PreviousBlsKeysUntil)
+	}
+	for _, key := range []blsCommon.PublicKey{set[1].BlsKey.PublicKey(), rotated[1].BlsKey.PublicKey()} {
+		if position := snap.blsPublicKeyPosition(key); position != 1 {
+			t.Fatalf("Expect position 1, got %d", position)
+		}
+	}
+	if snap.inBlsPublicKeySet(unknownKey.PublicKey()) {
+		t.Fatalf("Expect unknown key not to be authorized")
+	}
+
+	// The validator 1 votes with both keys, only one vote is aggregated
+	votes := append(set.Votes(chainConfig, 102, common.Hash{0x1}, finalitytest.Source{}, 0, 1), rotated.Votes(chainConfig, 102, common.Hash{0x1}, finalitytest.Source{}, 1, 2)...)
+	votedValidators, previousKeyVoters, signatures := aggregateVotes(votes, snap, finality.MaxFinalityVoters(false))
+	if indices := votedValidators.Indices(); len(indices) != 3 || len(signatures) != 3 {
+		t.Fatalf("Expect 3 voters, got %v and %d signatures", indices, len(signatures))
+	}
+	if indices := previousKeyVoters.Indices(); len(indices) != 0 {
+		t.Fatalf("Expect the current key vote to be aggregated, got previous key voters %v", indices)
+	}
+
+	// The previous keys are archived with the snapshot
+	c := &Consortium{chainConfig: chainConfig, config: chainConfig.Consortium}
+	blob, err := encodeArchivedSnapshot(snap)
+	if err != nil {
+		t.Fatalf("Failed to encode snapshot, err: %s", err)
+	}
+	decoded, err := c.decodeArchivedSnapshot(blob)
+	if err != nil {
+		t.Fatalf("Failed to decode snapshot, err: %s", err)
+	}
+	if len(decoded.PreviousBlsKeys) != 1 || !decoded.PreviousBlsKeys[0].BlsPublicKey.Equals(set[1].BlsKey.PublicKey()) || decoded.PreviousBlsKeysUntil != 112 {
+		t.Fatalf("Archived previous keys mismatch, got %v until %d", decoded.PreviousBlsKeys, decoded.PreviousBlsKeysUntil)
+	}
+
+	// The previous key is rejected after the overlap
+	snap.Number = 113
+	if snap.inBlsPublicKeySet(set[1].BlsKey.PublicKey()) {
+		t.Fatalf("Expect previous key to be rejected after the overlap")
+	}
+	if !snap.inBlsPublicKeySet(rotated[1].BlsKey.PublicKey()) {
+		t.Fatalf("Expect new key to be authorized")
+	}
+
+	// No key is recorded before Orca
+	snap = newSnapshot(chainConfig, chainConfig.Consortium, nil, 98, common.Hash{0x1}, nil, set.WithBlsPub(), nil)
+	snap.rotateBlsKeys(98, rotated.WithBlsPub())
+	if snap.PreviousBlsKeys != nil {
+		t.Fatalf("Expect no previous keys before Orca, got %v", snap.PreviousBlsKeys)
+	}
+
+	// No key is recorded without the overlap
+	chainConfig.Consortium.BlsKeyRotationOverlap = 0
+	snap = newSnapshot(chainConfig, chainConfig.Consortium, nil, 102, common.Hash{0x1}, nil, set.WithBlsPub(), nil)
+	snap.rotateBlsKeys(102, rotated.WithBlsPub())
+	if snap.PreviousBlsKeys != nil {
+		t.Fatalf("Expect no previous keys without overlap, got %v", snap.PreviousBlsKeys)
+	}
+}
+
+// The aggregated votes of the rotated voters are accepted by the verifier with
+// the voters that signed with their previous key
+func TestAggregateRotatedVotes(t *testing.T) {
+	set, err := finalitytest.NewValidatorSet(10)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err: %s", err)
+	}
+	// The validators 0 to 5 rotate their BLS keys
+	rotated := make(finalitytest.ValidatorSet, len(set))
+	copy(rotated, set)
+	for i := 0; i < 6; i++ {
+		newKey, err := blst.RandKey()
+		if err != nil {
+			t.Fatalf("Failed to create BLS key, err: %s", err)
+		}
+		rotated[i] = &finalitytest.Validator{Address: set[i].Address, Key: set[i].Key, BlsKey: newKey}
+	}
+	chainConfig := &params.ChainConfig{
+		ShillinBlock: common.Big0,
+		OrcaBlock:    common.Big0,
+		Consortium:   &params.ConsortiumConfig{EpochV2: 200, BlsKeyRotationOverlap: 10},
+	}
+	snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, 102, common.Hash{0x1}, nil, set.WithBlsPub(), nil)
+	snap.rotateBlsKeys(102, rotated.WithBlsPub())
+	snap.ValidatorsWithBlsPub = rotated.WithBlsPub()
+
+	var (
+		hash      = common.Hash{0x1}
+		digest    = types.NewVoteData(chainConfig, 102, hash, 0, common.Hash{}).Hash()
+		threshold = chainConfig.Consortium.FinalityThreshold(big.NewInt(103), len(set))
+		source    = finalitytest.Source{}
+	)
+	tests := []struct {
+		name      string
+		votes     []*types.VoteEnvelope
+		positions []int
+		previous  []int
+	}{
+		{
+			// 3 rotated voters with the previous key, 3 with the current key
+			name:      "mixed",
+			votes:     append(set.Votes(chainConfig, 102, hash, source, 0, 1, 2, 6, 7, 8, 9), rotated.Votes(chainConfig, 102, hash, source, 3, 4, 5)...),
+			positions: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			previous:  []int{0, 1, 2},
+		},
+		{
+			// 5 rotated voters with the previous key, 1 with the current key
+			name:      "previous",
+			votes:     append(set.Votes(chainConfig, 102, hash, source, 0, 1, 2, 3, 4, 6, 7, 8, 9), rotated.Votes(chainConfig, 102, hash, source, 5)...),
+			positions: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+			previous:  []int{0, 1, 2, 3, 4},
+		},
+		{
+			// The rotated voters vote with both keys, the current keys are kept
+			name:      "both",
+			votes:     append(set.Votes(chainConfig, 102, hash, source, 0, 1, 2, 3, 4, 5, 6), rotated.Votes(chainConfig, 102, hash, source, 0, 1, 2, 3, 4, 5)...),
+			positions: []int{0, 1, 2, 3, 4, 5, 6},
+		},
+	}
+	for _, test := range tests {
+		votedValidators, previousKeyVoters, signatures := aggregateVotes(test.votes, snap, finality.MaxFinalityVoters(false))
+		if indices := votedValidators.Indices(); !reflect.DeepEqual(indices, test.positions) || len(signatures) != len(test.positions) {
+			t.Fatalf("%s: expect voters %v, got %v and %d signatures", test.name, test.positions, indices, len(signatures))
+		}
+		if indices := previousKeyVoters.Indices(); !reflect.DeepEqual(indices, test.previous) {
+			t.Fatalf("%s: expect previous key voters %v, got %v", test.name, test.previous, indices)
+		}
+		signature := blst.AggregateSignatures(signatures)
+		err := verifier.VerifyRotatedFinalitySignatures(snap.ValidatorsWithBlsPub, snap.previousBlsKeys(), votedValidators, previousKeyVoters, signature, digest, threshold)
+		if err != nil {
+			t.Fatalf("%s: failed to verify aggregated votes, err: %s", test.name, err)
+		}
+
+		// The previous key voters round trip through the header extra data
+		extraData := &finality.HeaderExtraData{
+			HasFinalityVote:         1,
+			FinalityVotedValidators: votedValidators,
+			PreviousKeyVoters:       previousKeyVoters,
+			AggregatedFinalityVotes: signature,
+		}
+		decoded, err := finality.DecodeExtra(extraData.Encode(true), true)
+		if err != nil {
+			t.Fatalf("%s: failed to decode extra data, err: %s", test.name, err)
+		}
+		if !reflect.DeepEqual(decoded.FinalityVotedValidators.Indices(), test.positions) || !reflect.DeepEqual(decoded.PreviousKeyVoters.Indices(), test.previous) {
+			t.Fatalf("%s: mismatch decoded voters %v and previous key voters %v", test.name, decoded.FinalityVotedValidators.Indices(), decoded.PreviousKeyVoters.Indices())
+		}
+	}
+
+	// The previous key voters must be a non empty subset of the voters
+	for _, bitSets := range [][2][]byte{{{0x1}, {0x2}}, {{0x3}, {}}, {{0x3}, {0x1, 0}}} {
+		raw := append(bytes.Repeat([]byte{0x00}, finality.ExtraVanity), 3, byte(len(bitSets[0])))
+		raw = append(raw, bitSets[0]...)
+		raw = append(raw, byte(len(bitSets[1])))
+		raw = append(raw, bitSets[1]...)
+		raw = append(raw, bytes.Repeat([]byte{0x00}, params.BLSSignatureLength+finality.ExtraSeal)...)
+		if _, err := finality.DecodeExtra(raw, true); !errors.Is(err, finality.ErrInvalidFinalityVotedBitSet) {
+			t.Fatalf("Expect error %v for bit sets %x, got %v", finality.ErrInvalidFinalityVotedBitSet, bitSets, err)
+		}
+	}
+}
+
+// currentHeaderChain is a chain reader only serving its head
+type currentHeaderChain struct {
+	consensus.ChainHeaderReader
+	head *types.Header
+}
+
+func (chain *currentHeaderChain) CurrentHeader() *types.Header {
+	return chain.head
+}
+
+func TestEstimateNextBlockTime(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		ConsortiumV2Block: common.Big0,
+		BubaBlock:         common.Big0,
+		OlekBlock:         common.Big0,
+		Consortium:        &params.ConsortiumConfig{Period: 3, EpochV2: 100, DeterministicBlockTime: true},
+	}
+	recents, _ := lru.New(inmemorySnapshots)
+	c := &Consortium{
+		chainConfig: chainConfig,
+		config:      chainConfig.Consortium,
+		recents:     recents,
+	}
+	c.SetClock(new(mclock.Simulated), time.Unix(0, 0))
+
+	head := &types.Header{Number: big.NewInt(98), Time: 1000, Difficulty: diffInTurn}
+	validators := []common.Address{{0x1}, {0x2}, {0x3}}
+	snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, 98, head.Hash(), validators, nil, nil)
+	c.recents.Add(snap.Hash, snap)
+	api := &consortiumApi{chain: &currentHeaderChain{head: head}, consortium: c}
+
+	estimate, err := api.EstimateNextBlockTime()
+	if err != nil {
+		t.Fatalf("Failed to estimate next block time, err %s", err)
+	}
+	if estimate.Number != 99 || estimate.InTurnValidator != (common.Address{0x1}) || !estimate.InTurnSealable {
+		t.Fatalf("Next block mismatch, got %+v", estimate)
+	}
+	// The first sealable out-of-turn validator backs off 2 seconds
+	if estimate.Time != 1003 || estimate.OutOfTurnTime != 1005 {
+		t.Fatalf("Expect time 1003 and out-of-turn time 1005, got %d %d", estimate.Time, estimate.OutOfTurnTime)
+	}
+	if estimate.NextWrapUpBlock != 99 || estimate.NextWrapUpTime != 1003 || estimate.NextEpochBlock != 100 || estimate.NextEpochTime != 1006 {
+		t.Fatalf("Epoch boundary mismatch, got %+v", estimate)
+	}
+
+	// The in-turn validator has signed recently, the next block is sealed out of
+	// turn without the initial delay
+	snap.Recents[98] = common.Address{0x1}
+	estimate, err = api.EstimateNextBlockTime()
+	if err != nil {
+		t.Fatalf("Failed to estimate next block time, err %s", err)
+	}
+	if estimate.InTurnSealable || estimate.Time != 1003 || estimate.OutOfTurnTime != 1003 {
+		t.Fatalf("Expect out-of-turn block at 1003, got %+v", estimate)
+	}
+
+	// The block time is not before the current time
+	c.SetClock(new(mclock.Simulated), time.Unix(2000, 0))
+	estimate, err = api.EstimateNextBlockTime()
+	if err != nil {
+		t.Fatalf("Failed to estimate next block time, err %s", err)
+	}
+	if estimate.Time != 2000 || estimate.NextEpochTime != 2003 {
+		t.Fatalf("Expect time 2000 and epoch time 2003, got %+v", estimate)
+	}
+}
+
+type statsChain struct {
+	consensus.ChainHeaderReader
+	headers []*types.Header
+}
+
+func (chain *statsChain) CurrentHeader() *types.Header {
+	return chain.headers[len(chain.headers)-1]
+}
+
+func (chain *statsChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(chain.headers)) {
+		return nil
+	}
+	return chain.headers[number]
+}
+
+func TestBackfillStats(t *testing.T) {
+	chainConfig := &params.ChainConfig{
+		ConsortiumV2Block: common.Big0,
+		ShillinBlock:      common.Big0,
+		Consortium:        &params.ConsortiumConfig{Period: 3, EpochV2: 4},
+	}
+	recents, _ := lru.New(inmemorySnapshots)
+	signatures, _ := lru.New(inmemorySnapshots)
+	c := &Consortium{
+		chainConfig: chainConfig,
+		config:      chainConfig.Consortium,
+		recents:     recents,
+		signatures:  signatures,
+		db:          rawdb.NewMemoryDatabase(),
+	}
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate secret key, err: %s", err)
+	}
+	validators := []finality.ValidatorWithBlsPub{{Address: common.Address{0x1}}, {Address: common.Address{0x2}}}
+	sealers := []common.Address{{0x1}, {0x1}, {0x1}, {0x1}, {0x1}, {0x2}, {0x1}}
+	votes := map[int][]int{5: {0, 1}, 6: {1}}
+
+	// Blocks 4, 5, 6 of the epoch 1 are sealed, the headers before it are not
+	// counted as the epoch 0 contains the consortium v2 fork block
+	chain := &statsChain{}
+	for number, sealer := range sealers {
+		extraData := &finality.HeaderExtraData{}
+		if positions, ok := votes[number]; ok {
+			extraData.HasFinalityVote = 1
+			extraData.AggregatedFinalityVotes = secretKey.Sign([]byte("vote"))
+			for _, position := range positions {
+				extraData.FinalityVotedValidators.SetBit(position)
+			}
+		}
+		header := &types.Header{Number: big.NewInt(int64(number)), Difficulty: diffNoTurn, Extra: extraData.Encode(true)}
+		if number == 4 {
+			header.Difficulty = diffInTurn
+		}
+		if number > 0 {
+			header.ParentHash = chain.headers[number-1].Hash()
+		}
+		chain.headers = append(chain.headers, header)
+		c.signatures.Add(consortiumCommon.NewSealerKey(chainConfig.ChainID, header.Hash()), sealer)
+		snap := newSnapshot(chainConfig, chainConfig.Consortium, nil, uint64(number), header.Hash(), nil, validators, nil)
+		c.recents.Add(snap.Hash, snap)
+	}
+
+	indexed, err := c.BackfillStats(chain, 0, 100)
+	if err != nil {
+		t.Fatalf("Failed to backfill stats, err %s", err)
+	}
+	if indexed != 1 {
+		t.Fatalf("Expect 1 indexed epoch, got %d", indexed)
+	}
+	api := &consortiumApi{chain: chain, consortium: c}
+	if _, err := api.GetEpochStats(0); err == nil {
+		t.Fatalf("Expect error for the epoch not indexed")
+	}
+	stats, err := api.GetEpochStats(1)
+	if err != nil {
+		t.Fatalf("Failed to get epoch stats, err %s", err)
+	}
+	if stats.FirstBlock != 4 || stats.LastBlock != 6 || stats.Blocks != 3 || stats.FinalityVotedBlocks != 2 {
+		t.Fatalf("Epoch stats mismatch, got %+v", stats)
+	}
+	expected := []ValidatorStats{
+		{Address: common.Address{0x1}, Sealed: 2, SealedInTurn: 1, FinalityVotes: 1, MissedInTurn: 1},
+		{Address: common.Address{0x2}, Sealed: 1, FinalityVotes: 2, MissedInTurn: 1},
+	}
+	if !reflect.DeepEqual(stats.Validators, expected) {
+		t.Fatalf("Validator stats mismatch, expect %+v got %+v", expected, stats.Validators)
+	}
+
+	validatorStats, err := api.GetValidatorStats(common.Address{0x2}, 0, 1)
+	if err != nil {
+		t.Fatalf("Failed to get validator stats, err %s", err)
+	}
+	if validatorStats.IndexedEpochs != 1 || validatorStats.Blocks != 3 || validatorStats.Sealed != 1 || validatorStats.FinalityVotes != 2 {
+		t.Fatalf("Validator stats mismatch, got %+v", validatorStats)
+	}
+	if _, err := api.GetValidatorStats(common.Address{0x2}, 0, maxStatsEpochRange); err == nil {
+		t.Fatalf("Expect error for too large epoch range")
+	}
+}
+
+func TestValidatorScores(t *testing.T) {
+	epochs := []*EpochStats{
+		{
+			FinalityVotedBlocks: 4,
+			Validators: []ValidatorStats{
+				{Address: common.Address{0x1}, Sealed: 2, SealedInTurn: 2, FinalityVotes: 4},
+				{Address: common.Address{0x2}, MissedInTurn: 2, FinalityVotes: 2},
+			},
+		},
+		{
+			FinalityVotedBlocks: 4,
+			Validators: []ValidatorStats{
+				{Address: common.Address{0x1}, Sealed: 4, SealedInTurn: 2, FinalityVotes: 4},
+				{Address: common.Address{0x2}, Sealed: 1, SealedInTurn: 1, MissedInTurn: 1},
+			},
+		},
+	}
+	scores := scoreValidators(epochs, ScoreConfig{InTurnWeight: 1, FinalityWeight: 1, MissedEpochWeight: 2})
+	expected := []ValidatorScore{
+		{Rank: 1, Validator: common.Address{0x1}, Score: 1, InTurnRatio: 1, FinalityRatio: 1, Epochs: 2},
+		{Rank: 2, Validator: common.Address{0x2}, Score: 0.375, InTurnRatio: 0.25, FinalityRatio: 0.25, Epochs: 2, MissedEpochs: 1},
+	}
+	if !reflect.DeepEqual(scores, expected) {
+		t.Fatalf("Validator scores mismatch, expect %+v got %+v", expected, scores)
+	}
+
+	// Only the last completed epoch is scored with a window of 1 epoch
+	chainConfig := &params.ChainConfig{
+		ConsortiumV2Block: common.Big0,
+		Consortium:        &params.ConsortiumConfig{Period: 3, EpochV2: 4},
+	}
+	signatures, _ := lru.New(inmemorySnapshots)
+	c := &Consortium{
+		chainConfig: chainConfig,
+		config:      chainConfig.Consortium,
+		signatures:  signatures,
+	}
+	if _, ok := c.GetValidatorScores(); ok {
+		t.Fatalf("Expect no scores without the tracker")
+	}
+	tracker := &scoreTracker{config: ScoreConfig{Window: 1, InTurnWeight: 1}, epochs: make(map[uint64]*EpochStats)}
+	c.scoreTracker = tracker
+
+	chain := &statsChain{}
+	extend := func(head uint64) {
+		for number := uint64(len(chain.headers)); number <= head; number++ {
+			header := &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: diffInTurn}
+			chain.headers = append(chain.headers, header)
+			c.signatures.Add(consortiumCommon.NewSealerKey(c.chainConfig.ChainID, header.Hash()), common.Address{0x1})
+		}
+		c.trackScores(chain, tracker, chain.CurrentHeader())
+	}
+	for _, test := range []struct {
+		head     uint64
+		from, to uint64
+	}{
+		{head: 8, from: 1, to: 1},
+		{head: 11, from: 1, to: 1},
+		{head: 12, from: 2, to: 2},
+	} {
+		extend(test.head)
+		result, ok := c.GetValidatorScores()
+		if !ok {
+			t.Fatalf("Expect scores with the tracker")
+		}
+		if result.FromEpoch != test.from || result.ToEpoch != test.to || len(tracker.epochs) != 1 {
+			t.Fatalf("Expect epochs [%d, %d] at head %d, got [%d, %d]", test.from, test.to, test.head, result.FromEpoch, result.ToEpoch)
+		}
+		if len(result.Scores) != 1 || result.Scores[0].Validator != (common.Address{0x1}) || result.Scores[0].Score != 1 {
+			t.Fatalf("Validator scores mismatch at head %d, got %+v", test.head, result.Scores)
+		}
+	}
+}
+
+func TestSnapshotCacheConfig(t *testing.T) {
+	recents, _ := lru.New(inmemorySnapshots)
+	signatures, _ := lru.New(inmemorySnapshots)
+	c := &Consortium{
+		config:     &params.ConsortiumConfig{EpochV2: 200},
+		recents:    recents,
+		signatures: signatures,
+	}
+	api := &consortiumAdminApi{consortium: c}
+
+	if config := api.GetSnapshotCache(); config != DefaultCacheConfig {
+		t.Fatalf("Cache config mismatch, expect %+v got %+v", DefaultCacheConfig, config)
+	}
+	if !c.persistCheckpoint(200) || !c.persistCheckpoint(400) {
+		t.Fatalf("Expect the checkpoint of every epoch to be persisted by default")
+	}
+	for i := 0; i < 10; i++ {
+		recents.Add(i, i)
+	}
+
+	// The omitted fields are kept, the caches are resized
+	snapshots, interval := 4, uint64(3)
+	config, err := api.SetSnapshotCache(cacheConfigArgs{Snapshots: &snapshots, CheckpointInterval: &interval})
+	if err != nil {
+		t.Fatalf("Failed to set snapshot cache, err: %s", err)
+	}
+	want := CacheConfig{Snapshots: 4, Sealers: DefaultCacheConfig.Sealers, CheckpointInterval: 3}
+	if config != want || c.CacheConfig() != want {
+		t.Fatalf("Cache config mismatch, expect %+v got %+v", want, c.CacheConfig())
+	}
+	if recents.Len() != 4 {
+		t.Fatalf("Snapshot cache length mismatch, expect %d got %d", 4, recents.Len())
+	}
+	if c.persistCheckpoint(200) || c.persistCheckpoint(400) || !c.persistCheckpoint(600) {
+		t.Fatalf("Expect the checkpoint of every 3 epochs to be persisted")
+	}
+
+	zero := 0
+	if _, err := api.SetSnapshotCache(cacheConfigArgs{Sealers: &zero}); err == nil {
+		t.Fatalf("Expect error for empty sealer cache")
+	}
+	if c.CacheConfig() != want {
+		t.Fatalf("Expect the cache config to be kept on error, got %+v", c.CacheConfig())
+	}
+}
+
+func TestReplayTrace(t *testing.T) {
+	validators := make([]common.Address, 3)
+	for i := range validators {
+		validators[i] = common.BigToAddress(big.NewInt(int64(i)))
+	}
+	snap := newSnapshot(nil, nil, nil, 10, common.Hash{}, validators, nil, nil)
+	c := Consortium{
+		chainConfig: &params.ChainConfig{BubaBlock: big.NewInt(0)},
+		config:      &params.ConsortiumConfig{Period: 3},
+	}
+	header := &types.Header{Coinbase: snap.supposeValidator(), Number: big.NewInt(11), Time: 103}
+	parent := &types.Header{Number: big.NewInt(10), Time: 100}
+
+	// No step is traced out of a replay
+	if err := c.verifyHeaderTime(header, parent, snap); err != nil {
+		t.Fatalf("Expect successful verification, got %s", err)
+	}
+	var out bytes.Buffer
+	c.replayTracer.Store(&replayTracer{enc: json.NewEncoder(&out)})
+	if err := c.verifyHeaderTime(header, parent, snap); err != nil {
+		t.Fatalf("Expect successful verification, got %s", err)
+	}
+	header.Time = 102
+	if err := c.verifyHeaderTime(header, parent, snap); !errors.Is(err, consensus.ErrFutureBlock) {
+		t.Fatalf("Expect future block error, got %v", err)
+	}
+
+	dec := json.NewDecoder(&out)
+	var steps []ReplayStep
+	for dec.More() {
+		var step ReplayStep
+		if err := dec.Decode(&step); err != nil {
+			t.Fatalf("Failed to decode the trace: %v", err)
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("Expect 2 traced steps, got %d", len(steps))
+	}
+	for i, step := range steps {
+		if step.Number != 11 || step.Step != "backoff" || step.Fields["inturn"] != true || step.Fields["expected"] != float64(103) {
+			t.Errorf("Unexpected step %d: %+v", i, step)
+		}
+	}
+	if steps[0].Error != "" || steps[1].Error != consensus.ErrFutureBlock.Error() {
+		t.Errorf("Unexpected step errors %q and %q", steps[0].Error, steps[1].Error)
+	}
+}
+
+func TestGasLimitTarget(t *testing.T) {
+	contract := &mockContract{gasLimit: 100_000_000}
+	c := Consortium{
+		chainConfig: &params.ChainConfig{},
+		config:      &params.ConsortiumConfig{EpochV2: 200},
+	}
+	parent := &types.Header{Number: big.NewInt(199), GasLimit: 90_000_000}
+	header := &types.Header{Number: big.NewInt(200), GasLimit: parent.GasLimit}
+
+	// The miner gas limit is kept without governance contract
+	c.applyGasLimitTarget(header, parent, contract)
+	if header.GasLimit != parent.GasLimit || contract.gasReads != 0 {
+		t.Fatalf("Expect the miner gas limit, got %d with %d reads", header.GasLimit, contract.gasReads)
+	}
+
+	c.config.GasLimitContract = common.HexToAddress("0x1")
+	c.applyGasLimitTarget(header, parent, contract)
+	if header.GasLimit != core.CalcGasLimit(parent.GasLimit, contract.gasLimit) || header.GasLimit <= parent.GasLimit {
+		t.Fatalf("Expect the gas limit to move towards the target, got %d", header.GasLimit)
+	}
+
+	// The target is read once per epoch
+	contract.gasLimit = 50_000_000
+	parent, header = header, &types.Header{Number: big.NewInt(201), GasLimit: header.GasLimit}
+	c.applyGasLimitTarget(header, parent, contract)
+	if contract.gasReads != 1 || header.GasLimit <= parent.GasLimit {
+		t.Fatalf("Expect the target of the epoch, got %d with %d reads", header.GasLimit, contract.gasReads)
+	}
+	parent, header = header, &types.Header{Number: big.NewInt(400), GasLimit: header.GasLimit}
+	c.applyGasLimitTarget(header, parent, contract)
+	if contract.gasReads != 2 || header.GasLimit >= parent.GasLimit {
+		t.Fatalf("Expect the new target of the next epoch, got %d with %d reads", header.GasLimit, contract.gasReads)
+	}
+
+	// The miner gas limit is used until the next epoch if the target can not be read
+	contract.gasLimit = 0
+	parent, header = header, &types.Header{Number: big.NewInt(600), GasLimit: header.GasLimit}
+	c.applyGasLimitTarget(header, parent, contract)
+	if header.GasLimit != parent.GasLimit || c.targetGasLimit(&types.Header{Number: big.NewInt(601)}, contract) != 0 || contract.gasReads != 3 {
+		t.Fatalf("Expect the miner gas limit, got %d with %d reads", header.GasLimit, contract.gasReads)
+	}
+}
+
+func TestDoubleSignReportFork(t *testing.T) {
+	c := Consortium{
+		chainConfig: &params.ChainConfig{
+			ChainID:      big.NewInt(2021),
+			RubiconBlock: big.NewInt(10),
+			ConsortiumV2Contracts: &params.ConsortiumV2Contracts{
+				SlashIndicator: common.HexToAddress("0x1"),
+			},
+		},
+	}
+	c.EnableDoubleSignReport(100000, false)
+	header1 := &types.Header{
+		Coinbase:   common.HexToAddress("0x2"),
+		Number:     big.NewInt(5),
+		Difficulty: big.NewInt(7),
+		Extra:      make([]byte, 97),
+	}
+	header2 := types.CopyHeader(header1)
+	header2.Time = 1
+	c.ReportDoubleSign(header1, header2)
+
+	// Before Rubicon, the queued report is not included in the sealed block
+	contract := &mockContract{}
+	opts := &consortiumCommon.ApplyTransactOpts{ApplyMessageOpts: &consortiumCommon.ApplyMessageOpts{
+		Header: &types.Header{Number: big.NewInt(9)},
+	}}
+	if err := c.processDoubleSignReports(nil, contract, opts, true); err != nil {
+		t.Fatalf("Failed to process double sign reports, err: %s", err)
+	}
+	if len(contract.doubleSigns) != 0 {
+		t.Fatalf("Expect no report before Rubicon, got %d", len(contract.doubleSigns))
+	}
+
+	// Before Rubicon, a received report is left over so the block is rejected
+	slashIndicatorABI, err := slashIndicator.SlashIndicatorMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to load slash indicator abi, err: %s", err)
+	}
+	evidence := c.getDoubleSignReporter().pending[0].evidence
+	data, err := slashIndicatorABI.Pack("slashDoubleSign", evidence.Validator, evidence.Header1, evidence.Header2)
+	if err != nil {
+		t.Fatalf("Failed to pack slashDoubleSign, err: %s", err)
+	}
+	receivedTxs := []*types.Transaction{
+		types.NewTransaction(0, c.chainConfig.ConsortiumV2Contracts.SlashIndicator, common.Big0, evidence.GasLimit, common.Big0, data),
+	}
+	opts.ReceivedTxs = &receivedTxs
+	if err := c.processDoubleSignReports(nil, contract, opts, false); err != nil {
+		t.Fatalf("Failed to process double sign reports, err: %s", err)
+	}
+	if len(contract.doubleSigns) != 0 || len(receivedTxs) != 1 {
+		t.Fatalf("Expect the received report to be left before Rubicon, got %d applied", len(contract.doubleSigns))
+	}
+
+	// From Rubicon, the report is included and applied
+	opts.Header = &types.Header{Number: big.NewInt(10)}
+	if err := c.processDoubleSignReports(nil, contract, opts, false); err != nil {
+		t.Fatalf("Failed to process double sign reports, err: %s", err)
+	}
+	if len(contract.doubleSigns) != 1 || len(receivedTxs) != 0 {
+		t.Fatalf("Expect the received report to be applied from Rubicon, got %d applied", len(contract.doubleSigns))
+	}
+	header3, header4 := types.CopyHeader(header1), types.CopyHeader(header2)
+	header3.Number, header4.Number = big.NewInt(6), big.NewInt(6)
+	c.ReportDoubleSign(header3, header4)
+	opts.ReceivedTxs = nil
+	if err := c.processDoubleSignReports(nil, contract, opts, true); err != nil {
+		t.Fatalf("Failed to process double sign reports, err: %s", err)
+	}
+	if len(contract.doubleSigns) != 2 {
+		t.Fatalf("Expect the queued report to be included from Rubicon, got %d", len(contract.doubleSigns))
+	}
+}
@@ -0,0 +1,139 @@
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// checkpointDivergenceMeter counts the checkpoint blocks whose validators
+// mismatch the ones read from the contracts
+var checkpointDivergenceMeter = metrics.NewRegisteredMeter("consortium/checkpoint/divergence", nil)
+
+// ValidatorDivergence is a position at which the checkpoint validators in the
+// header differ from the ones read from the contracts. The address of a side
+// is omitted if it has no validator at the position, the BLS public keys are
+// only set when they are compared, i.e. from the Shillin hardfork.
+type ValidatorDivergence struct {
+	Index           int             `json:"index"`
+	HeaderAddress   *common.Address `json:"headerAddress,omitempty"`
+	ContractAddress *common.Address `json:"contractAddress,omitempty"`
+	HeaderBlsKey    hexutil.Bytes   `json:"headerBlsKey,omitempty"`
+	ContractBlsKey  hexutil.Bytes   `json:"contractBlsKey,omitempty"`
+}
+
+// diffCheckpointValidators compares the checkpoint validators in the header with
+// the ones read from the contracts position by position, the BLS public keys are
+// compared if compareKeys is set.
+func diffCheckpointValidators(header, contract []finality.ValidatorWithBlsPub, compareKeys bool) []ValidatorDivergence {
+	var divergences []ValidatorDivergence
+	for i := 0; i < len(header) || i < len(contract); i++ {
+		divergence := ValidatorDivergence{Index: i}
+		if i < len(header) {
+			divergence.HeaderAddress = &header[i].Address
+			if compareKeys && header[i].BlsPublicKey != nil {
+				divergence.HeaderBlsKey = header[i].BlsPublicKey.Marshal()
+			}
+		}
+		if i < len(contract) {
+			divergence.ContractAddress = &contract[i].Address
+			if compareKeys && contract[i].BlsPublicKey != nil {
+				divergence.ContractBlsKey = contract[i].BlsPublicKey.Marshal()
+			}
+		}
+		if divergence.HeaderAddress == nil || divergence.ContractAddress == nil ||
+			*divergence.HeaderAddress != *divergence.ContractAddress ||
+			!bytes.Equal(divergence.HeaderBlsKey, divergence.ContractBlsKey) {
+			divergences = append(divergences, divergence)
+		}
+	}
+	return divergences
+}
+
+// logCheckpointDivergence logs each position at which the checkpoint validators
+// of the header diverge from the ones read from the contracts
+func logCheckpointDivergence(header *types.Header, divergences []ValidatorDivergence) {
+	log.Error("Checkpoint validators diverge from the contracts", "number", header.Number, "hash", header.Hash(), "positions", len(divergences))
+	for _, divergence := range divergences {
+		ctx := []interface{}{"number", header.Number, "index", divergence.Index}
+		if divergence.HeaderAddress != nil {
+			ctx = append(ctx, "header", *divergence.HeaderAddress)
+		}
+		if divergence.ContractAddress != nil {
+			ctx = append(ctx, "contract", *divergence.ContractAddress)
+		}
+		if divergence.HeaderBlsKey != nil || divergence.ContractBlsKey != nil {
+			ctx = append(ctx, "headerBlsKey", divergence.HeaderBlsKey, "contractBlsKey", divergence.ContractBlsKey)
+		}
+		log.Error("Checkpoint validator divergence", ctx...)
+	}
+}
+
+// checkpointDivergence is the result of re-deriving the checkpoint validators of
+// a block from the contracts
+type checkpointDivergence struct {
+	Number      uint64                         `json:"number"`
+	Hash        common.Hash                    `json:"hash"`
+	Header      []finality.ValidatorWithBlsPub `json:"header"`
+	Contract    []finality.ValidatorWithBlsPub `json:"contract"`
+	Divergences []ValidatorDivergence          `json:"divergences"`
+}
+
+// consortiumAdminApi provides the administrative RPC of the consortium engine
+type consortiumAdminApi struct {
+	chain      consensus.ChainHeaderReader
+	consortium *Consortium
+}
+
+// RederiveCheckpointValidators drops the cached BLS public keys and reads the
+// checkpoint validators of the canonical epoch block again from the contracts at
+// its parent state, then compares them with the validators in the header. A
+// divergence that persists points to the contracts, e.g. a wrong validator set
+// or profile, if it goes away the cached keys were stale; a header whose
+// validators match no contract state is forged. The divergences are logged too.
+// The state of the parent block must be available.
+func (api *consortiumAdminApi) RederiveCheckpointValidators(number uint64) (*checkpointDivergence, error) {
+	if number%api.consortium.config.EpochV2 != 0 || !api.consortium.chainConfig.IsConsortiumV2(new(big.Int).SetUint64(number)) {
+		return nil, errors.New("block is not an epoch block after consortium v2")
+	}
+	header := api.chain.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+	isShillin := api.consortium.chainConfig.IsShillin(header.Number)
+	extraData, err := finality.DecodeExtra(header.Extra, isShillin)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, _, contract := api.consortium.readSignerAndContract()
+	contract.PurgeCaches()
+	validators, err := api.consortium.getCheckpointValidatorsFromContract(header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &checkpointDivergence{
+		Number:      number,
+		Hash:        header.Hash(),
+		Header:      extraData.CheckpointValidators,
+		Contract:    validators,
+		Divergences: diffCheckpointValidators(extraData.CheckpointValidators, validators, isShillin),
+	}
+	if len(result.Divergences) > 0 {
+		logCheckpointDivergence(header, result.Divergences)
+	} else {
+		result.Divergences = make([]ValidatorDivergence, 0)
+		log.Info("Checkpoint validators match the contracts", "number", number, "hash", result.Hash)
+	}
+	return result, nil
+}
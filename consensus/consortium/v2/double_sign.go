@@ -0,0 +1,180 @@
+package v2
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// reportedDoubleSignCacheSize is the number of recently reported double signs
+// kept to avoid reporting the same double sign twice
+const reportedDoubleSignCacheSize = 128
+
+type doubleSignKey struct {
+	validator common.Address
+	number    uint64
+}
+
+type pendingDoubleSign struct {
+	evidence    *consortiumCommon.DoubleSignEvidence
+	assembledAt uint64 // The latest block number that includes the report, 0 if not included yet
+}
+
+// doubleSignReporter keeps the detected double signs until the slashing report is
+// included in a block sealed by this node.
+type doubleSignReporter struct {
+	gasCap uint64
+	dryRun bool
+
+	lock     sync.Mutex
+	pending  []*pendingDoubleSign
+	reported *lru.Cache
+}
+
+// EnableDoubleSignReport lets the engine report the double signs passed to
+// ReportDoubleSign by including the slashDoubleSign system transaction in the
+// blocks it seals. The transaction gas limit is capped at gasCap. In dry run mode,
+// the evidence is only logged.
+func (c *Consortium) EnableDoubleSignReport(gasCap uint64, dryRun bool) {
+	reported, _ := lru.New(reportedDoubleSignCacheSize)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.doubleSignReporter = &doubleSignReporter{
+		gasCap:   gasCap,
+		dryRun:   dryRun,
+		reported: reported,
+	}
+}
+
+func (c *Consortium) getDoubleSignReporter() *doubleSignReporter {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.doubleSignReporter
+}
+
+// ReportDoubleSign queues the double sign evidence of 2 conflicting headers to be
+// reported in the next block sealed by this node. It is a no-op when the double
+// sign report is not enabled.
+func (c *Consortium) ReportDoubleSign(header1, header2 *types.Header) {
+	reporter := c.getDoubleSignReporter()
+	if reporter == nil {
+		return
+	}
+	evidence, err := consortiumCommon.NewDoubleSignEvidence(header1, header2, c.chainConfig.ChainID, reporter.gasCap)
+	if err != nil {
+		log.Error("Failed to create double sign evidence", "number", header1.Number, "err", err)
+		return
+	}
+
+	key := doubleSignKey{validator: evidence.Validator, number: header1.Number.Uint64()}
+	reporter.lock.Lock()
+	defer reporter.lock.Unlock()
+	if reporter.reported.Contains(key) {
+		return
+	}
+	reporter.reported.Add(key, struct{}{})
+
+	if reporter.dryRun {
+		log.Info("Crafted double sign report (dry run)", "validator", evidence.Validator, "number", header1.Number,
+			"hash1", header1.Hash(), "hash2", header2.Hash(), "gasLimit", evidence.GasLimit,
+			"header1", hexutil.Encode(evidence.Header1), "header2", hexutil.Encode(evidence.Header2))
+		return
+	}
+	reporter.pending = append(reporter.pending, &pendingDoubleSign{evidence: evidence})
+	log.Info("Queued double sign report", "validator", evidence.Validator, "number", header1.Number,
+		"hash1", header1.Hash(), "hash2", header2.Hash())
+}
+
+// assemble returns the evidences to include in the block being sealed and drops the
+// ones that are already included in a canonical block sealed by this node.
+func (reporter *doubleSignReporter) assemble(chain consensus.ChainHeaderReader, header *types.Header) []*consortiumCommon.DoubleSignEvidence {
+	reporter.lock.Lock()
+	defer reporter.lock.Unlock()
+
+	var (
+		remaining []*pendingDoubleSign
+		evidences []*consortiumCommon.DoubleSignEvidence
+	)
+	for _, pending := range reporter.pending {
+		if pending.assembledAt != 0 && pending.assembledAt < header.Number.Uint64() {
+			canonical := chain.GetHeaderByNumber(pending.assembledAt)
+			if canonical != nil && canonical.Coinbase == header.Coinbase {
+				continue
+			}
+		}
+		pending.assembledAt = header.Number.Uint64()
+		remaining = append(remaining, pending)
+		evidences = append(evidences, pending.evidence)
+	}
+	reporter.pending = remaining
+	return evidences
+}
+
+// remove drops the pending evidence which is already reported by another block producer
+func (reporter *doubleSignReporter) remove(evidence *consortiumCommon.DoubleSignEvidence) {
+	reporter.lock.Lock()
+	defer reporter.lock.Unlock()
+
+	var remaining []*pendingDoubleSign
+	for _, pending := range reporter.pending {
+		if pending.evidence.Validator == evidence.Validator &&
+			bytes.Equal(pending.evidence.Header1, evidence.Header1) &&
+			bytes.Equal(pending.evidence.Header2, evidence.Header2) {
+			continue
+		}
+		remaining = append(remaining, pending)
+	}
+	reporter.pending = remaining
+}
+
+// processDoubleSignReports applies the slashDoubleSign system transactions. When
+// sealing, the queued evidences are reported; otherwise, the reports included by
+// the block producer are applied so that the received system transactions match.
+// The reports are only part of the blocks from Rubicon, before it the evidences
+// stay queued and a block including a report is rejected.
+func (c *Consortium) processDoubleSignReports(chain consensus.ChainHeaderReader, contract consortiumCommon.ContractInteraction,
+	transactOpts *consortiumCommon.ApplyTransactOpts, isFinalizeAndAssemble bool) error {
+	if !c.chainConfig.IsRubicon(transactOpts.Header.Number) {
+		return nil
+	}
+	reporter := c.getDoubleSignReporter()
+
+	if !isFinalizeAndAssemble {
+		for transactOpts.ReceivedTxs != nil && len(*transactOpts.ReceivedTxs) > 0 {
+			evidence, ok := consortiumCommon.DecodeDoubleSignEvidence(c.chainConfig, (*transactOpts.ReceivedTxs)[0])
+			if !ok {
+				break
+			}
+			log.Info("Apply double sign report", "number", transactOpts.Header.Number, "validator", evidence.Validator)
+			err := contract.SlashDoubleSign(transactOpts, evidence)
+			c.traceSystemTx(transactOpts.Header, "slashDoubleSign", err, "validator", evidence.Validator)
+			if err != nil {
+				log.Error("Failed to apply double sign report", "validator", evidence.Validator, "err", err)
+				return err
+			}
+			if reporter != nil {
+				reporter.remove(evidence)
+			}
+		}
+		return nil
+	}
+
+	if reporter == nil {
+		return nil
+	}
+	for _, evidence := range reporter.assemble(chain, transactOpts.Header) {
+		// Do not fail the block sealing because of the report
+		if err := contract.SlashDoubleSign(transactOpts, evidence); err != nil {
+			log.Error("Failed to report double sign", "validator", evidence.Validator, "err", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+)
+
+// errSimulationBeforeV2 is returned if the simulated epoch is not wrapped up
+// by consortium v2
+var errSimulationBeforeV2 = errors.New("epoch simulation is before consortium v2")
+
+// EpochSimulation is the outcome of the wrap up of an epoch simulated on top of
+// a block
+type EpochSimulation struct {
+	Block           uint64                         `json:"block"`
+	WrapUpBlock     uint64                         `json:"wrapUpBlock"`
+	CheckpointBlock uint64                         `json:"checkpointBlock"`
+	Validators      []finality.ValidatorWithBlsPub `json:"validators"`
+}
+
+// SimulateEpoch dry-runs the wrap up of the epoch on top of the parent, on its
+// statedb with the overrides applied, e.g. an upgraded validator contract, and
+// returns the validators the next checkpoint block would pick. The system
+// transactions of the wrap up block are applied right on top of the parent as
+// sealed by the validator in turn at that block, the blocks in between are not
+// simulated. The statedb is modified.
+func (c *Consortium) SimulateEpoch(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB, overrides *ethapi.StateOverride) (*EpochSimulation, error) {
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
+	}
+	snap, err := c.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	validators := snap.validators()
+	if len(validators) == 0 {
+		return nil, errors.New("no validator in the snapshot")
+	}
+
+	// The checkpoint block follows the wrap up block
+	number := c.nextWrapUpEpoch(parent.Number.Uint64())
+	if number < c.forkedBlock {
+		return nil, errSimulationBeforeV2
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).SetUint64(number),
+		Coinbase:   validators[number%uint64(len(validators))],
+		Difficulty: diffInTurn,
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + (number-parent.Number.Uint64())*c.config.Period,
+	}
+	if c.chainConfig.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(c.chainConfig, parent)
+	}
+
+	evmContext := core.NewEVMBlockContext(header, consortiumCommon.ChainContext{Chain: chain, Consortium: c}, &header.Coinbase)
+	backend := consortiumCommon.NewStateBackend(consortiumCommon.NewConsortiumBackend(c.ethAPI), c.chainConfig, evmContext, statedb)
+	contract, err := consortiumCommon.NewContractIntegrator(c.chainConfig, backend, shadowSignTx, header.Coinbase)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+		usedGas  uint64
+	)
+	transactOpts := &consortiumCommon.ApplyTransactOpts{
+		ApplyMessageOpts: &consortiumCommon.ApplyMessageOpts{
+			State:       statedb,
+			Header:      header,
+			ChainConfig: c.chainConfig,
+			EVMContext:  &evmContext,
+		},
+		Txs:      &txs,
+		Receipts: &receipts,
+		UsedGas:  &usedGas,
+		Mining:   true,
+		Signer:   c.signer,
+		SignTxFn: shadowSignTx,
+	}
+	if err := contract.SubmitBlockReward(transactOpts); err != nil {
+		return nil, fmt.Errorf("failed to submit block reward: %w", err)
+	}
+	if err := contract.WrapUpEpoch(transactOpts); err != nil {
+		return nil, fmt.Errorf("failed to wrap up epoch: %w", err)
+	}
+	for i, receipt := range receipts {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return nil, fmt.Errorf("system transaction %d to %s reverted", i, txs[i].To())
+		}
+	}
+
+	checkpoint := new(big.Int).Add(header.Number, common.Big1)
+	checkpointValidators, ok := c.overriddenCheckpointValidators(checkpoint.Uint64())
+	if !ok {
+		checkpointValidators, err = c.readCheckpointValidators(contract, checkpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &EpochSimulation{
+		Block:           parent.Number.Uint64(),
+		WrapUpBlock:     number,
+		CheckpointBlock: checkpoint.Uint64(),
+		Validators:      checkpointValidators,
+	}, nil
+}
+
+// nextWrapUpEpoch returns the first block after the number wrapping up the epoch
+func (c *Consortium) nextWrapUpEpoch(number uint64) uint64 {
+	number++
+	return number + c.config.EpochV2 - 1 - number%c.config.EpochV2
+}
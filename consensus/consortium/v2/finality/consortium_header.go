@@ -5,9 +5,12 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
+	"math"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/consortium/errcode"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/crypto/bls/blst"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
@@ -22,50 +25,70 @@ const (
 var (
 	// ErrInvalidHasFinalityVote is returned if a block's extra-data contains invalid
 	// has finality vote byte
-	ErrInvalidHasFinalityVote = errors.New("invalid has finality vote byte")
+	ErrInvalidHasFinalityVote = errcode.ErrInvalidHasFinalityVote
 
 	// ErrMissingHasFinalityVote is returned if a block's extra-data section does not seem
 	// to include 1 byte to determine if the extra data has the finality votes
-	ErrMissingHasFinalityVote = errors.New("extra-data 1 byte has finality votes missing")
+	ErrMissingHasFinalityVote = errcode.ErrMissingHasFinalityVote
 
 	// ErrMissingFinalityVoteBitSet is returned if a block's extra-data section does not seem
-	// to include 8 bytes of finality vote bitset
-	ErrMissingFinalityVoteBitSet = errors.New("extra-data 8 bytes finality votes bitset missing")
+	// to include the finality vote bitset
+	ErrMissingFinalityVoteBitSet = errcode.ErrMissingFinalityVoteBitSet
 
 	// ErrMissingFinalitySignature is returned if a block's extra-data section does not seem
 	// to include finality signature
-	ErrMissingFinalitySignature = errors.New("extra-data finality signature missing")
+	ErrMissingFinalitySignature = errcode.ErrMissingFinalitySignature
 
 	// ErrMissingFinalitySignature is returned if the number of finality votes is under
 	// the threshold
-	ErrNotEnoughFinalityVote = errors.New("not enough finality vote")
+	ErrNotEnoughFinalityVote = errcode.ErrNotEnoughFinalityVote
 
 	// ErrFinalitySignatureVerificationFailed is returned if the finality signature verification
 	// failed
-	ErrFinalitySignatureVerificationFailed = errors.New("failed to verify finality signature")
+	ErrFinalitySignatureVerificationFailed = errcode.ErrFinalitySignatureVerificationFailed
 
 	// ErrInvalidFinalityVotedBitSet is returned if the voted validator in bit set is not in
 	// snapshot validator set
-	ErrInvalidFinalityVotedBitSet = errors.New("invalid finality voted bit set")
+	ErrInvalidFinalityVotedBitSet = errcode.ErrInvalidFinalityVotedBitSet
 
 	// ErrUnauthorizedFinalityVoter is returned if finality voter is not in validator set
-	ErrUnauthorizedFinalityVoter = errors.New("unauthorized finality voter")
+	ErrUnauthorizedFinalityVoter = errcode.ErrUnauthorizedFinalityVoter
 
 	// ErrMissingVanity is returned if a block's extra-data section is shorter than
 	// 32 bytes, which is required to store the signer vanity.
-	ErrMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
+	ErrMissingVanity = errcode.ErrMissingVanity
+
+	// ErrVanityTooLong is returned if the vanity content does not fit in the
+	// 32 byte vanity prefix and would overflow into the structured fields
+	ErrVanityTooLong = errcode.ErrVanityTooLong
 
 	// ErrMissingSignature is returned if a block's extra-data section doesn't seem
 	// to contain a 65 byte secp256k1 signature.
-	ErrMissingSignature = errors.New("extra-data 65 byte signature suffix missing")
+	ErrMissingSignature = errcode.ErrMissingSignature
 
 	// ErrInvalidSpanValidators is returned if a block contains an
 	// invalid list of validators (i.e. non divisible by 20 bytes).
-	ErrInvalidSpanValidators = errors.New("invalid validator list on sprint end block")
+	ErrInvalidSpanValidators = errcode.ErrInvalidSpanValidators
 
 	// ErrInvalidTargetNumber is returned if the vote contains invalid
 	// target number
-	ErrInvalidTargetNumber = errors.New("invalid target number in vote")
+	ErrInvalidTargetNumber = errcode.ErrInvalidTargetNumber
+
+	// ErrInvalidVoteVersion is returned if the vote is a v1 vote after the
+	// Tripp hardfork or a v2 vote before
+	ErrInvalidVoteVersion = errcode.ErrInvalidVoteVersion
+
+	// ErrInvalidVoteChainID is returned if the vote is signed for another
+	// network
+	ErrInvalidVoteChainID = errcode.ErrInvalidVoteChainID
+
+	// ErrInvalidVoteSource is returned if the vote source is not the justified
+	// block as of the target block
+	ErrInvalidVoteSource = errcode.ErrInvalidVoteSource
+
+	// ErrInvalidVoteEpoch is returned if the vote contains invalid target
+	// epoch
+	ErrInvalidVoteEpoch = errcode.ErrInvalidVoteEpoch
 )
 
 type ValidatorWithBlsPub struct {
@@ -124,49 +147,196 @@ func (validator CheckpointValidatorAscending) Swap(i, j int) {
 	validator[i], validator[j] = validator[j], validator[i]
 }
 
-type FinalityVoteBitSet uint64
+// FinalityVoteBitSet is the set of the positions of the finality voters in the
+// validator set, the validator at position i voted if the bit i%8 of the byte
+// i/8 is set. The first 8 bytes have the layout of the little endian uint64 bit
+// set encoded in the extra data before Venoki, which holds up to 64 voters. The
+// trailing zero bytes are trimmed so that the encoding is canonical.
+type FinalityVoteBitSet []byte
 
-const finalityVoteBitSetByteLength int = 8
+const (
+	// finalityVoteBitSetByteLength is the length of the bit set encoded in the
+	// extra data before Venoki
+	finalityVoteBitSetByteLength int = 8
+
+	// maxFinalityVoteBitSetByteLength is the maximum length of the length
+	// prefixed bit set encoded in the extra data from Venoki
+	maxFinalityVoteBitSetByteLength int = math.MaxUint8
+
+	// variableFinalityVoteBitSet is the has finality vote byte of the extra data
+	// whose finality votes have a length prefixed bit set, it is used when the
+	// voters do not fit in the 8 bytes bit set
+	variableFinalityVoteBitSet uint8 = 2
+
+	// rotatedFinalityVoteBitSet is the has finality vote byte of the extra data
+	// whose finality votes have a length prefixed bit set followed by the
+	// length prefixed bit set of the voters signing with their previous BLS
+	// key, it is used when some voters signed during a BLS key rotation
+	rotatedFinalityVoteBitSet uint8 = 3
+)
+
+// MaxFinalityVoters returns the number of validator positions the finality
+// vote bit set in the extra data can hold
+func MaxFinalityVoters(isVenoki bool) int {
+	if isVenoki {
+		return maxFinalityVoteBitSetByteLength * 8
+	}
+	return finalityVoteBitSetByteLength * 8
+}
+
+// NewFinalityVoteBitSet returns the bit set of the 64 bits bit set
+func NewFinalityVoteBitSet(bits uint64) FinalityVoteBitSet {
+	bitSet := FinalityVoteBitSet(binary.LittleEndian.AppendUint64(nil, bits))
+	return bitSet.trim()
+}
+
+func (bitSet FinalityVoteBitSet) trim() FinalityVoteBitSet {
+	length := len(bitSet)
+	for length > 0 && bitSet[length-1] == 0 {
+		length--
+	}
+	if length == 0 {
+		return nil
+	}
+	return bitSet[:length]
+}
 
 func (bitSet *FinalityVoteBitSet) Indices() []int {
 	var votedValidatorPositions []int
 
-	for i := 0; i < finalityVoteBitSetByteLength*8; i++ {
-		if uint64(*bitSet)&(1<<i) != 0 {
+	for i := 0; i < len(*bitSet)*8; i++ {
+		if bitSet.Has(i) {
 			votedValidatorPositions = append(votedValidatorPositions, i)
 		}
 	}
 	return votedValidatorPositions
 }
 
+// Has returns whether the bit at the index is set
+func (bitSet *FinalityVoteBitSet) Has(index int) bool {
+	if index < 0 || index >= len(*bitSet)*8 {
+		return false
+	}
+	return (*bitSet)[index/8]&(1<<(index%8)) != 0
+}
+
 func (bitSet *FinalityVoteBitSet) SetBit(index int) {
-	if index >= finalityVoteBitSetByteLength*8 {
+	if index < 0 || index >= maxFinalityVoteBitSetByteLength*8 {
 		return
 	}
 
-	*bitSet = FinalityVoteBitSet(uint64(*bitSet) | (1 << index))
+	if length := index/8 + 1; length > len(*bitSet) {
+		grown := make(FinalityVoteBitSet, length)
+		copy(grown, *bitSet)
+		*bitSet = grown
+	}
+	(*bitSet)[index/8] |= 1 << (index % 8)
+}
+
+// Uint64 returns the bit set as a 64 bits bit set, false if it has a voter
+// beyond the position 63
+func (bitSet *FinalityVoteBitSet) Uint64() (uint64, bool) {
+	trimmed := bitSet.trim()
+	if len(trimmed) > finalityVoteBitSetByteLength {
+		return 0, false
+	}
+	var bits [8]byte
+	copy(bits[:], trimmed)
+	return binary.LittleEndian.Uint64(bits[:]), true
+}
+
+// Big returns the bit set as an integer, it equals the 64 bits bit set if the
+// voters fit in it
+func (bitSet *FinalityVoteBitSet) Big() *big.Int {
+	trimmed := bitSet.trim()
+	bigEndian := make([]byte, len(trimmed))
+	for i, b := range trimmed {
+		bigEndian[len(trimmed)-1-i] = b
+	}
+	return new(big.Int).SetBytes(bigEndian)
+}
+
+// MarshalJSON encodes the bit set as a number if the voters fit in 64 bits as
+// before Venoki, otherwise as a hex string of its bytes
+func (bitSet FinalityVoteBitSet) MarshalJSON() ([]byte, error) {
+	if bits, ok := bitSet.Uint64(); ok {
+		return json.Marshal(bits)
+	}
+	return json.Marshal(hexutil.Bytes(bitSet.trim()))
+}
+
+// UnmarshalJSON decodes either encoding of MarshalJSON
+func (bitSet *FinalityVoteBitSet) UnmarshalJSON(input []byte) error {
+	if len(input) > 0 && input[0] == '"' {
+		var raw hexutil.Bytes
+		if err := json.Unmarshal(input, &raw); err != nil {
+			return err
+		}
+		*bitSet = FinalityVoteBitSet(raw).trim()
+		return nil
+	}
+	var bits uint64
+	if err := json.Unmarshal(input, &bits); err != nil {
+		return err
+	}
+	*bitSet = NewFinalityVoteBitSet(bits)
+	return nil
 }
 
 // HeaderExtraData represents the information in the extra data of header,
 // this helps to make the code more readable
 type HeaderExtraData struct {
-	Vanity                  [ExtraVanity]byte     // unused in Consortium, filled with zero
-	HasFinalityVote         uint8                 // determine if the header extra has the finality vote
+	Vanity                  [ExtraVanity]byte     // arbitrary content set by the sealer, see NewVanity
+	HasFinalityVote         uint8                 // determine if the header extra has the finality vote, the encoding of the bit set is chosen by Encode
 	FinalityVotedValidators FinalityVoteBitSet    // the bit set of validators that vote for finality
+	PreviousKeyVoters       FinalityVoteBitSet    // the subset of the voters that signed with their previous BLS key
 	AggregatedFinalityVotes blsCommon.Signature   // aggregated BLS signatures for finality vote
 	CheckpointValidators    []ValidatorWithBlsPub // validator addresses and BLS public key appended at checkpoint block
 	Seal                    [ExtraSeal]byte       // the sealing block signature
 }
 
+// NewVanity returns the vanity prefix holding the content, right padded with
+// zero. The content must fit in the prefix so that the structured fields of the
+// extra data are left untouched.
+func NewVanity(content []byte) ([ExtraVanity]byte, error) {
+	var vanity [ExtraVanity]byte
+	if len(content) > ExtraVanity {
+		return vanity, ErrVanityTooLong
+	}
+	copy(vanity[:], content)
+	return vanity, nil
+}
+
+// VanityContent returns the content of the vanity prefix without the zero
+// padding
+func (extraData *HeaderExtraData) VanityContent() []byte {
+	return bytes.TrimRight(extraData.Vanity[:], "\x00")
+}
+
 func (extraData *HeaderExtraData) Encode(isShillin bool) []byte {
 	var rawBytes []byte
 
 	rawBytes = append(rawBytes, extraData.Vanity[:]...)
 	if isShillin {
-		rawBytes = append(rawBytes, extraData.HasFinalityVote)
 		if extraData.HasFinalityVote == 1 {
-			rawBytes = binary.LittleEndian.AppendUint64(rawBytes, uint64(extraData.FinalityVotedValidators))
+			// The voters beyond the 64 bits bit set are only valid from Venoki
+			if previousKeyVoters := extraData.PreviousKeyVoters.trim(); len(previousKeyVoters) > 0 {
+				bitSet := extraData.FinalityVotedValidators.trim()
+				rawBytes = append(rawBytes, rotatedFinalityVoteBitSet, uint8(len(bitSet)))
+				rawBytes = append(rawBytes, bitSet...)
+				rawBytes = append(rawBytes, uint8(len(previousKeyVoters)))
+				rawBytes = append(rawBytes, previousKeyVoters...)
+			} else if bits, ok := extraData.FinalityVotedValidators.Uint64(); ok {
+				rawBytes = append(rawBytes, extraData.HasFinalityVote)
+				rawBytes = binary.LittleEndian.AppendUint64(rawBytes, bits)
+			} else {
+				bitSet := extraData.FinalityVotedValidators.trim()
+				rawBytes = append(rawBytes, variableFinalityVoteBitSet, uint8(len(bitSet)))
+				rawBytes = append(rawBytes, bitSet...)
+			}
 			rawBytes = append(rawBytes, extraData.AggregatedFinalityVotes.Marshal()...)
+		} else {
+			rawBytes = append(rawBytes, extraData.HasFinalityVote)
 		}
 	}
 	for _, validator := range extraData.CheckpointValidators {
@@ -180,6 +350,25 @@ func (extraData *HeaderExtraData) Encode(isShillin bool) []byte {
 	return rawBytes
 }
 
+// decodeFinalityVoteBitSet decodes the length prefixed bit set at the start of
+// the bytes and returns it with the number of bytes it takes. The bit set must
+// be non empty and without trailing zero bytes so that the encoding is
+// canonical.
+func decodeFinalityVoteBitSet(rawBytes []byte) (FinalityVoteBitSet, int, error) {
+	if len(rawBytes) < 1 {
+		return nil, 0, ErrMissingFinalityVoteBitSet
+	}
+	bitSetLength := int(rawBytes[0])
+	if len(rawBytes)-1 < bitSetLength {
+		return nil, 0, ErrMissingFinalityVoteBitSet
+	}
+	bitSet := rawBytes[1 : 1+bitSetLength]
+	if bitSetLength == 0 || bitSet[bitSetLength-1] == 0 {
+		return nil, 0, ErrInvalidFinalityVotedBitSet
+	}
+	return common.CopyBytes(bitSet), 1 + bitSetLength, nil
+}
+
 func DecodeExtra(rawBytes []byte, isShillin bool) (*HeaderExtraData, error) {
 	var (
 		extraData       HeaderExtraData
@@ -200,21 +389,56 @@ func DecodeExtra(rawBytes []byte, isShillin bool) (*HeaderExtraData, error) {
 			return nil, ErrMissingHasFinalityVote
 		}
 
-		extraData.HasFinalityVote = rawBytes[currentPosition]
+		hasFinalityVote := rawBytes[currentPosition]
 		currentPosition += 1
 
-		if extraData.HasFinalityVote != 1 && extraData.HasFinalityVote != 0 {
-			return nil, ErrInvalidHasFinalityVote
-		}
-
-		if extraData.HasFinalityVote == 1 {
+		switch hasFinalityVote {
+		case 0:
+		case 1:
 			if rawBytesLength-currentPosition < finalityVoteBitSetByteLength {
 				return nil, ErrMissingFinalityVoteBitSet
 			}
-			extraData.FinalityVotedValidators = FinalityVoteBitSet(
+			extraData.FinalityVotedValidators = NewFinalityVoteBitSet(
 				binary.LittleEndian.Uint64(rawBytes[currentPosition : currentPosition+finalityVoteBitSetByteLength]),
 			)
 			currentPosition += finalityVoteBitSetByteLength
+		case variableFinalityVoteBitSet:
+			bitSet, length, err := decodeFinalityVoteBitSet(rawBytes[currentPosition:])
+			if err != nil {
+				return nil, err
+			}
+			// The length prefixed bit set is only used for the voters beyond
+			// the 64 bits bit set
+			if len(bitSet) <= finalityVoteBitSetByteLength {
+				return nil, ErrInvalidFinalityVotedBitSet
+			}
+			extraData.FinalityVotedValidators = bitSet
+			currentPosition += length
+		case rotatedFinalityVoteBitSet:
+			bitSet, length, err := decodeFinalityVoteBitSet(rawBytes[currentPosition:])
+			if err != nil {
+				return nil, err
+			}
+			currentPosition += length
+			previousKeyVoters, length, err := decodeFinalityVoteBitSet(rawBytes[currentPosition:])
+			if err != nil {
+				return nil, err
+			}
+			currentPosition += length
+			// The previous key voters are a subset of the voters
+			for _, position := range previousKeyVoters.Indices() {
+				if !bitSet.Has(position) {
+					return nil, ErrInvalidFinalityVotedBitSet
+				}
+			}
+			extraData.FinalityVotedValidators = bitSet
+			extraData.PreviousKeyVoters = previousKeyVoters
+		default:
+			return nil, ErrInvalidHasFinalityVote
+		}
+
+		if hasFinalityVote != 0 {
+			extraData.HasFinalityVote = 1
 
 			if rawBytesLength-currentPosition < params.BLSSignatureLength {
 				return nil, ErrMissingFinalitySignature
@@ -0,0 +1,163 @@
+// Package finalitytest provides deterministic validator sets, BLS keys and finality
+// votes to test the fast finality verification of Ronin blocks. The keys are
+// derived from the validator index, they must never be used outside of tests.
+package finalitytest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Validator is a validator with its signing key and BLS key
+type Validator struct {
+	Address common.Address
+	Key     *ecdsa.PrivateKey
+	BlsKey  blsCommon.SecretKey
+}
+
+// ValidatorSet is a list of validators sorted by address in ascending order, the
+// same order as the validators in the checkpoint header and in the snapshot. The
+// position of a validator in the set is its bit in the finality vote bit set.
+type ValidatorSet []*Validator
+
+// NewValidatorSet returns a validator set of n validators, the same n always
+// returns the same validators.
+func NewValidatorSet(n int) (ValidatorSet, error) {
+	set := make(ValidatorSet, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("finalitytest validator %d", i))))
+		if err != nil {
+			return nil, err
+		}
+		blsKey, err := blst.SecretKeyFromBytes(common.LeftPadBytes(big.NewInt(int64(i+1)).Bytes(), params.BLSSecretKeyLength))
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, &Validator{
+			Address: crypto.PubkeyToAddress(key.PublicKey),
+			Key:     key,
+			BlsKey:  blsKey,
+		})
+	}
+	sort.Slice(set, func(i, j int) bool {
+		return bytes.Compare(set[i].Address[:], set[j].Address[:]) < 0
+	})
+	return set, nil
+}
+
+// Addresses returns the addresses of the validators
+func (set ValidatorSet) Addresses() []common.Address {
+	addresses := make([]common.Address, len(set))
+	for i, validator := range set {
+		addresses[i] = validator.Address
+	}
+	return addresses
+}
+
+// WithBlsPub returns the validators with their BLS public keys as in the
+// checkpoint header
+func (set ValidatorSet) WithBlsPub() []finality.ValidatorWithBlsPub {
+	validators := make([]finality.ValidatorWithBlsPub, len(set))
+	for i, validator := range set {
+		validators[i] = finality.ValidatorWithBlsPub{
+			Address:      validator.Address,
+			BlsPublicKey: validator.BlsKey.PublicKey(),
+		}
+	}
+	return validators
+}
+
+// Quorum returns the minimum number of votes to justify the parent of the block
+// at number, see params.ConsortiumConfig.FinalityThreshold
+func (set ValidatorSet) Quorum(config *params.ChainConfig, number *big.Int) int {
+	return config.Consortium.FinalityThreshold(number, len(set))
+}
+
+// Source is the justified block the finality votes are cast from, it is only
+// part of the vote data from Tripp
+type Source struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// Vote returns the finality vote of the validator at position for the block,
+// the vote data follows the hardforks of the config
+func (set ValidatorSet) Vote(config *params.ChainConfig, position int, number uint64, hash common.Hash, source Source) *types.VoteEnvelope {
+	voteData := types.NewVoteData(config, number, hash, source.Number, source.Hash)
+	digest := voteData.Hash()
+	return &types.VoteEnvelope{
+		RawVoteEnvelope: types.RawVoteEnvelope{
+			PublicKey: types.BLSPublicKey(set[position].BlsKey.PublicKey().Marshal()),
+			Signature: types.BLSSignature(set[position].BlsKey.Sign(digest[:]).Marshal()),
+			Data:      voteData,
+		},
+	}
+}
+
+// Votes returns the finality votes of the validators at positions for the block
+func (set ValidatorSet) Votes(config *params.ChainConfig, number uint64, hash common.Hash, source Source, positions ...int) []*types.VoteEnvelope {
+	votes := make([]*types.VoteEnvelope, len(positions))
+	for i, position := range positions {
+		votes[i] = set.Vote(config, position, number, hash, source)
+	}
+	return votes
+}
+
+// ExtraDataBuilder builds the extra data of a header under the chain config
+type ExtraDataBuilder struct {
+	config    *params.ChainConfig
+	extraData finality.HeaderExtraData
+}
+
+func NewExtraDataBuilder(config *params.ChainConfig) *ExtraDataBuilder {
+	return &ExtraDataBuilder{config: config}
+}
+
+// WithFinalityVotes includes the aggregated finality votes of the validators at
+// positions for the parent block. The votes are included even if they do not
+// reach quorum, to test the verification failure. The positions beyond 63 are
+// encoded in the variable length bit set, which is only valid from Venoki.
+func (builder *ExtraDataBuilder) WithFinalityVotes(set ValidatorSet, parentNumber uint64, parentHash common.Hash, source Source, positions ...int) *ExtraDataBuilder {
+	var (
+		bitSet     finality.FinalityVoteBitSet
+		signatures []blsCommon.Signature
+	)
+	digest := types.NewVoteData(builder.config, parentNumber, parentHash, source.Number, source.Hash).Hash()
+	for _, position := range positions {
+		bitSet.SetBit(position)
+		signatures = append(signatures, set[position].BlsKey.Sign(digest[:]))
+	}
+
+	builder.extraData.HasFinalityVote = 1
+	builder.extraData.FinalityVotedValidators = bitSet
+	builder.extraData.AggregatedFinalityVotes = blst.AggregateSignatures(signatures)
+	return builder
+}
+
+// WithCheckpointValidators includes the validator set as in the checkpoint header
+func (builder *ExtraDataBuilder) WithCheckpointValidators(set ValidatorSet) *ExtraDataBuilder {
+	builder.extraData.CheckpointValidators = set.WithBlsPub()
+	return builder
+}
+
+// Build returns the extra data, the seal is left empty
+func (builder *ExtraDataBuilder) Build() *finality.HeaderExtraData {
+	extraData := builder.extraData
+	return &extraData
+}
+
+// Encode returns the encoded extra data after Shillin, the seal is left empty
+func (builder *ExtraDataBuilder) Encode() []byte {
+	return builder.extraData.Encode(true)
+}
@@ -0,0 +1,102 @@
+package finalitytest
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestFinalityVotes(t *testing.T) {
+	set, err := NewValidatorSet(22)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	other, err := NewValidatorSet(22)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	for i := range set {
+		if set[i].Address != other[i].Address {
+			t.Fatalf("Validator set is not deterministic at %d, %s != %s", i, set[i].Address, other[i].Address)
+		}
+	}
+
+	// The votes of the blocks from 20 are v2 vote data, the quorum of the
+	// blocks from 30 is lowered to 1/2
+	config := &params.ChainConfig{
+		ChainID:      big.NewInt(2021),
+		ShillinBlock: common.Big0,
+		TrippBlock:   big.NewInt(20),
+		Consortium: &params.ConsortiumConfig{
+			EpochV2:         10,
+			FinalityQuorums: []params.FinalityQuorum{{Block: big.NewInt(30), Numerator: 1, Denominator: 2}},
+		},
+	}
+	if quorum := set.Quorum(config, big.NewInt(11)); quorum != 15 {
+		t.Fatalf("Quorum mismatch, exp %d got %d", 15, quorum)
+	}
+	if quorum := set.Quorum(config, big.NewInt(30)); quorum != 12 {
+		t.Fatalf("Quorum mismatch after override, exp %d got %d", 12, quorum)
+	}
+
+	var (
+		parentHash = common.HexToHash("0x1")
+		source     = Source{Number: 9, Hash: common.HexToHash("0x2")}
+		positions  = []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 1, 3, 5, 7}
+	)
+	for _, parentNumber := range []uint64{10, 25} {
+		rawExtra := NewExtraDataBuilder(config).
+			WithFinalityVotes(set, parentNumber, parentHash, source, positions...).
+			WithCheckpointValidators(set).
+			Encode()
+		extraData, err := finality.DecodeExtra(rawExtra, true)
+		if err != nil {
+			t.Fatalf("Failed to decode extra data, err %s", err)
+		}
+		if len(extraData.CheckpointValidators) != len(set) {
+			t.Fatalf("Checkpoint validators mismatch, exp %d got %d", len(set), len(extraData.CheckpointValidators))
+		}
+
+		voteData := types.NewVoteData(config, parentNumber, parentHash, source.Number, source.Hash)
+		if voteData.IsV2() != (parentNumber >= 20) {
+			t.Fatalf("Vote data of block %d is v2 %t", parentNumber, voteData.IsV2())
+		}
+		var publicKeys []blsCommon.PublicKey
+		for _, position := range extraData.FinalityVotedValidators.Indices() {
+			publicKeys = append(publicKeys, extraData.CheckpointValidators[position].BlsPublicKey)
+		}
+		if len(publicKeys) != len(positions) {
+			t.Fatalf("Voted validators mismatch, exp %d got %d", len(positions), len(publicKeys))
+		}
+		if !extraData.AggregatedFinalityVotes.FastAggregateVerify(publicKeys, voteData.Hash()) {
+			t.Fatalf("Failed to verify aggregated finality votes of block %d", parentNumber)
+		}
+
+		vote := set.Vote(config, 3, parentNumber, parentHash, source)
+		if err := vote.Verify(); err != nil {
+			t.Fatalf("Failed to verify vote, err %s", err)
+		}
+		if vote.Data.Hash() != voteData.Hash() {
+			t.Fatalf("Vote data mismatch of block %d", parentNumber)
+		}
+	}
+
+	// The voters beyond 63 are encoded in the variable length bit set
+	large, err := NewValidatorSet(70)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	rawExtra := NewExtraDataBuilder(config).WithFinalityVotes(large, 10, parentHash, source, 0, 69).Encode()
+	extraData, err := finality.DecodeExtra(rawExtra, true)
+	if err != nil {
+		t.Fatalf("Failed to decode extra data, err %s", err)
+	}
+	if _, ok := extraData.FinalityVotedValidators.Uint64(); ok || !extraData.FinalityVotedValidators.Has(69) {
+		t.Fatalf("Expect the voter 69 in the variable length bit set, got %v", extraData.FinalityVotedValidators.Indices())
+	}
+}
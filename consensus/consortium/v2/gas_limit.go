@@ -0,0 +1,58 @@
+package v2
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// gasLimitTarget is the target block gas limit read from the governance contract
+// for an epoch, 0 if it cannot be read
+type gasLimitTarget struct {
+	epoch uint64
+	limit uint64
+}
+
+// targetGasLimit returns the target gas limit of the governance contract for the
+// epoch of the header, 0 if there is none. It is read at the epoch block, or at
+// the first block prepared in the epoch, on top of the parent state and kept for
+// the rest of the epoch.
+func (c *Consortium) targetGasLimit(header *types.Header, contract consortiumCommon.ContractInteraction) uint64 {
+	if c.config.GasLimitContract == (common.Address{}) || contract == nil {
+		return 0
+	}
+	epoch := header.Number.Uint64() / c.config.EpochV2
+	if target, ok := c.gasLimitTarget.Load().(gasLimitTarget); ok && target.epoch == epoch {
+		return target.limit
+	}
+	parentNumber := new(big.Int).Sub(header.Number, common.Big1)
+	limit, err := contract.Pin(parentNumber, header.ParentHash).GetGasLimitTarget(parentNumber)
+	if err != nil {
+		// The miner gas ceiling is used until the next epoch
+		log.Warn("Failed to read the target gas limit", "number", header.Number, "contract", c.config.GasLimitContract, "err", err)
+		limit = 0
+	} else {
+		log.Info("Read the target gas limit", "epoch", epoch, "number", header.Number, "gaslimit", limit)
+	}
+	c.gasLimitTarget.Store(gasLimitTarget{epoch: epoch, limit: limit})
+	return limit
+}
+
+// applyGasLimitTarget moves the gas limit of the header sealed on top of parent
+// towards the target of the governance contract instead of the miner gas ceiling
+func (c *Consortium) applyGasLimitTarget(header, parent *types.Header, contract consortiumCommon.ContractInteraction) {
+	target := c.targetGasLimit(header, contract)
+	if target == 0 {
+		return
+	}
+	parentGasLimit := parent.GasLimit
+	if c.chainConfig.IsLondon(header.Number) && !c.chainConfig.IsLondon(parent.Number) {
+		parentGasLimit = parent.GasLimit * params.ElasticityMultiplier
+	}
+	header.GasLimit = core.CalcGasLimit(parentGasLimit, target)
+}
@@ -0,0 +1,180 @@
+package v2
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// inactivityTrackRange is the maximum number of headers tracked when the chain
+// head jumps, the older headers are skipped
+const inactivityTrackRange = 256
+
+// ValidatorUptime is the sealing record of a validator in the tracked block range
+type ValidatorUptime struct {
+	Validator         common.Address `json:"validator"`
+	Sealed            uint64         `json:"sealed"`
+	Missed            uint64         `json:"missed"` // Number of missed in-turn slots
+	ConsecutiveMissed uint64         `json:"consecutiveMissed"`
+	LastSealed        uint64         `json:"lastSealed"`
+	LastMissed        uint64         `json:"lastMissed"`
+	From              uint64         `json:"from"` // First tracked block
+	To                uint64         `json:"to"`   // Last tracked block
+}
+
+// InactivityAlertFn is called when the local validator misses more consecutive
+// in-turn slots than the alert threshold
+type InactivityAlertFn func(uptime ValidatorUptime)
+
+// inactivityTracker counts the sealed blocks and the missed in-turn slots of the
+// validators from the canonical headers.
+type inactivityTracker struct {
+	lock    sync.RWMutex
+	uptimes map[common.Address]*ValidatorUptime
+	from    uint64
+	to      uint64
+
+	threshold uint64
+	alertFn   InactivityAlertFn
+	alerted   bool
+}
+
+func (tracker *inactivityTracker) uptime(validator common.Address) *ValidatorUptime {
+	uptime, ok := tracker.uptimes[validator]
+	if !ok {
+		uptime = &ValidatorUptime{Validator: validator}
+		tracker.uptimes[validator] = uptime
+	}
+	return uptime
+}
+
+// StartInactivityTracker tracks the missed in-turn slots of the validators from
+// the new chain heads in the background until the engine is closed. alertFn is called when the
+// local validator misses more than threshold consecutive in-turn slots, the
+// alert is disabled if threshold is 0.
+func (c *Consortium) StartInactivityTracker(chain *core.BlockChain, threshold uint64, alertFn InactivityAlertFn) {
+	log.Info("Starting validator inactivity tracker", "threshold", threshold)
+	tracker := &inactivityTracker{
+		uptimes:   make(map[common.Address]*ValidatorUptime),
+		threshold: threshold,
+		alertFn:   alertFn,
+	}
+	c.lock.Lock()
+	c.inactivityTracker = tracker
+	c.lock.Unlock()
+
+	chainHeadCh := make(chan core.ChainHeadEvent, 10)
+	chainHeadSub := chain.SubscribeChainHeadEvent(chainHeadCh)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer chainHeadSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-chainHeadCh:
+				c.trackInactivity(chain, tracker, ev.Block.Header())
+			case <-chainHeadSub.Err():
+				return
+			case <-c.quit:
+				return
+			}
+		}
+	}()
+}
+
+// trackInactivity updates the tracker with the canonical headers up to head
+func (c *Consortium) trackInactivity(chain *core.BlockChain, tracker *inactivityTracker, head *types.Header) {
+	number := head.Number.Uint64()
+	if number <= c.forkedBlock {
+		return
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	// Skip the reorged heads at the tracked heights
+	if number <= tracker.to {
+		return
+	}
+	from := tracker.to + 1
+	if tracker.to == 0 || number-from >= inactivityTrackRange {
+		from = number
+	}
+	if from <= c.forkedBlock {
+		from = c.forkedBlock + 1
+	}
+	if tracker.from == 0 {
+		tracker.from = from
+	}
+
+	val, _, _, _ := c.readSignerAndContract()
+	for n := from; n <= number; n++ {
+		header := head
+		if n != number {
+			header = chain.GetHeaderByNumber(n)
+		}
+		if header == nil {
+			continue
+		}
+		snap, err := c.snapshot(chain, n-1, header.ParentHash, nil)
+		if err != nil {
+			log.Debug("Failed to get snapshot for inactivity tracking", "number", n, "err", err)
+			continue
+		}
+
+		sealer := tracker.uptime(header.Coinbase)
+		sealer.Sealed++
+		sealer.LastSealed = n
+		sealer.ConsecutiveMissed = 0
+
+		if spoiledVal, spoiled := c.spoiledValidator(snap, header); spoiled {
+			missed := tracker.uptime(spoiledVal)
+			missed.Missed++
+			missed.ConsecutiveMissed++
+			missed.LastMissed = n
+		}
+	}
+	tracker.to = number
+
+	local, ok := tracker.uptimes[val]
+	if !ok {
+		return
+	}
+	if local.ConsecutiveMissed == 0 {
+		tracker.alerted = false
+	}
+	if tracker.threshold > 0 && local.ConsecutiveMissed > tracker.threshold && !tracker.alerted {
+		tracker.alerted = true
+		log.Warn("Local validator misses in-turn slots", "validator", val, "consecutive", local.ConsecutiveMissed)
+		if tracker.alertFn != nil {
+			uptime := *local
+			uptime.From, uptime.To = tracker.from, tracker.to
+			go tracker.alertFn(uptime)
+		}
+	}
+}
+
+// GetValidatorUptime returns the sealing record of the validator since the
+// inactivity tracker started
+func (c *Consortium) GetValidatorUptime(validator common.Address) (*ValidatorUptime, bool) {
+	c.lock.RLock()
+	tracker := c.inactivityTracker
+	c.lock.RUnlock()
+	if tracker == nil {
+		return nil, false
+	}
+
+	tracker.lock.RLock()
+	defer tracker.lock.RUnlock()
+	uptime := ValidatorUptime{Validator: validator}
+	if tracked, ok := tracker.uptimes[validator]; ok {
+		uptime = *tracked
+	}
+	uptime.From, uptime.To = tracker.from, tracker.to
+	return &uptime, true
+}
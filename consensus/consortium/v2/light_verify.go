@@ -0,0 +1,110 @@
+package v2
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// lightVerifiedMeter counts the headers whose finality signatures are not
+// verified in the light verification mode
+var lightVerifiedMeter = metrics.NewRegisteredMeter("consortium/verify/light", nil)
+
+// maxTrustedAncestry is the maximum number of blocks walked back from the
+// trusted finality checkpoint to find the verified header, the headers further
+// below are fully verified
+const maxTrustedAncestry = 1024
+
+// lightVerification is the light verification mode of the RPC followers behind
+// trusted validators. The BLS aggregate check of the finality signatures, which
+// dominates the cost of the header verification, is skipped for the ancestors of
+// the finality checkpoint relayed by the trusted peers. The other rules,
+// including the seal of the validator, are still verified, and the headers that
+// are not known to be ancestors of the checkpoint, e.g. the chain tip or a side
+// chain, are fully verified.
+type lightVerification struct {
+	enabled uint32 // Whether the light verification is enabled (atomic)
+
+	lock   sync.RWMutex
+	number uint64      // Number of the trusted finality checkpoint
+	hash   common.Hash // Hash of the trusted finality checkpoint
+}
+
+// SetLightVerification enables or disables the light verification mode, the
+// trusted finality checkpoint is kept.
+func (c *Consortium) SetLightVerification(enabled bool) {
+	var flag uint32
+	if enabled {
+		flag = 1
+	}
+	atomic.StoreUint32(&c.lightVerification.enabled, flag)
+}
+
+// SetTrustedFinality raises the trusted finality checkpoint of the light
+// verification mode to the block finalized by a quorum of the validators as
+// relayed by a trusted peer, a lower checkpoint is ignored.
+func (c *Consortium) SetTrustedFinality(number uint64, hash common.Hash) {
+	c.lightVerification.lock.Lock()
+	defer c.lightVerification.lock.Unlock()
+
+	if number <= c.lightVerification.number {
+		return
+	}
+	c.lightVerification.number, c.lightVerification.hash = number, hash
+	log.Trace("Updated trusted finality checkpoint", "number", number, "hash", hash)
+}
+
+// TrustedFinality returns the trusted finality checkpoint and whether the light
+// verification is enabled.
+func (c *Consortium) TrustedFinality() (uint64, common.Hash, bool) {
+	c.lightVerification.lock.RLock()
+	defer c.lightVerification.lock.RUnlock()
+	return c.lightVerification.number, c.lightVerification.hash, atomic.LoadUint32(&c.lightVerification.enabled) == 1
+}
+
+// skipFinalitySignatures reports whether the finality signatures of the header
+// are not verified in the light verification mode, i.e. the header is the
+// trusted finality checkpoint or one of its ancestors. The ancestry is walked
+// back from the checkpoint in the parents, ascending as in
+// VerifyHeaderAndParents, or in the chain.
+func (c *Consortium) skipFinalitySignatures(chain consensus.ChainHeaderReader, header *types.Header, parents []*types.Header) bool {
+	number, hash, enabled := c.TrustedFinality()
+	if !enabled || header.Number.Uint64() > number || number-header.Number.Uint64() > maxTrustedAncestry {
+		return false
+	}
+	target := header.Number.Uint64()
+	for number > target {
+		ancestor := lookupHeader(chain, parents, number, hash)
+		if ancestor == nil {
+			return false
+		}
+		number, hash = number-1, ancestor.ParentHash
+	}
+	if hash != header.Hash() {
+		return false
+	}
+	lightVerifiedMeter.Mark(1)
+	return true
+}
+
+// lookupHeader returns the header with the number and hash from the parents or
+// the chain, nil if it is unknown
+func lookupHeader(chain consensus.ChainHeaderReader, parents []*types.Header, number uint64, hash common.Hash) *types.Header {
+	if len(parents) > 0 {
+		first := parents[0].Number.Uint64()
+		if number >= first && number-first < uint64(len(parents)) {
+			if parent := parents[number-first]; parent.Hash() == hash {
+				return parent
+			}
+		}
+	}
+	if chain == nil {
+		return nil
+	}
+	return chain.GetHeader(hash, number)
+}
@@ -0,0 +1,94 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// errOverrideNotCheckpoint is returned if the validator set override is not
+	// at a checkpoint block
+	errOverrideNotCheckpoint = errors.New("validator set override is not at a checkpoint block")
+
+	// errOverrideEmpty is returned if the validator set override has no validator
+	errOverrideEmpty = errors.New("validator set override has no validator")
+
+	// errOverrideDuplicate is returned if a validator is listed twice in the
+	// validator set override
+	errOverrideDuplicate = errors.New("duplicate validator in validator set override")
+)
+
+// ValidatorSetOverride is an emergency validator set which replaces the result of
+// the validator contract at a checkpoint block. It is only meant to recover the
+// chain from a bricked validator contract and must be adopted by all the nodes
+// at the same block, as an emergency hardfork.
+type ValidatorSetOverride struct {
+	Block      uint64                         `json:"block"`
+	Validators []finality.ValidatorWithBlsPub `json:"validators"`
+}
+
+// LoadValidatorSetOverride reads the validator set override from a JSON file
+func LoadValidatorSetOverride(path string) (*ValidatorSetOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var override ValidatorSetOverride
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("invalid validator set override %s: %w", path, err)
+	}
+	return &override, nil
+}
+
+// SetValidatorSetOverride makes the engine use the validator set of the override
+// as the checkpoint validators at the override block instead of reading them from
+// the validator contract. The validators are sorted as in the checkpoint header.
+// It must be set before the engine verifies or seals any block.
+func (c *Consortium) SetValidatorSetOverride(override *ValidatorSetOverride) error {
+	if override.Block == 0 || override.Block%c.config.EpochV2 != 0 {
+		return fmt.Errorf("%w: block %d, epoch %d", errOverrideNotCheckpoint, override.Block, c.config.EpochV2)
+	}
+	if len(override.Validators) == 0 {
+		return errOverrideEmpty
+	}
+	validators := make([]finality.ValidatorWithBlsPub, len(override.Validators))
+	copy(validators, override.Validators)
+	sort.Sort(finality.CheckpointValidatorAscending(validators))
+	for i := 1; i < len(validators); i++ {
+		if validators[i].Address == validators[i-1].Address {
+			return fmt.Errorf("%w: %s", errOverrideDuplicate, validators[i].Address)
+		}
+	}
+
+	log.Warn("!!! EMERGENCY VALIDATOR SET OVERRIDE ENABLED !!!")
+	log.Warn("The validator contract result is replaced at the checkpoint block", "block", override.Block, "validators", len(validators))
+	for _, validator := range validators {
+		log.Warn("Overridden checkpoint validator", "block", override.Block, "address", validator.Address)
+	}
+	log.Warn("Every node of the network must run with the same override, otherwise the chain splits")
+
+	c.validatorSetOverride = &ValidatorSetOverride{
+		Block:      override.Block,
+		Validators: validators,
+	}
+	return nil
+}
+
+// overriddenCheckpointValidators returns the validators of the override if the
+// number is the override block
+func (c *Consortium) overriddenCheckpointValidators(number uint64) ([]finality.ValidatorWithBlsPub, bool) {
+	override := c.validatorSetOverride
+	if override == nil || override.Block != number {
+		return nil, false
+	}
+	log.Warn("Using the emergency validator set override instead of the validator contract", "block", number)
+	validators := make([]finality.ValidatorWithBlsPub, len(override.Validators))
+	copy(validators, override.Validators)
+	return validators, true
+}
@@ -0,0 +1,110 @@
+package v2
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/features"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// epochStatePrefetch gates warming the state touched by the system transactions
+// of the epoch blocks sealed by the local validator
+var epochStatePrefetch = features.Register("consortium.epochprefetch", "Prefetch the state touched by the system transactions before sealing a wrap up epoch block", true, true)
+
+// stateReader is implemented by the chains able to open the state at a root,
+// e.g. core.BlockChain
+type stateReader interface {
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// isWrapUpEpoch returns true if the block at number wraps up the epoch
+func (c *Consortium) isWrapUpEpoch(number uint64) bool {
+	return number%c.config.EpochV2 == c.config.EpochV2-1
+}
+
+// prefetchEpochState warms the state touched by the system transactions of the
+// next block in the background if it wraps up the epoch and the local validator
+// is in-turn to seal it. The system transactions are applied on a throwaway copy
+// of the parent state of the header, so that the trie nodes and the snapshot
+// entries they read are in memory when the epoch block is finalized.
+func (c *Consortium) prefetchEpochState(chain consensus.ChainHeaderReader, header *types.Header, snap *Snapshot) {
+	number := header.Number.Uint64()
+	if !epochStatePrefetch.Enabled() || !c.isWrapUpEpoch(number+1) {
+		return
+	}
+	reader, ok := chain.(stateReader)
+	if !ok {
+		return
+	}
+	val, _, signTxFn, contract := c.readSignerAndContract()
+	if contract == nil {
+		return
+	}
+	validators := snap.validators()
+	if len(validators) == 0 || validators[(number+1)%uint64(len(validators))] != val {
+		return
+	}
+	// Prepare is called again on every recommit, prefetch only once per block
+	if prev := atomic.LoadUint64(&c.epochPrefetched); prev >= number+1 || !atomic.CompareAndSwapUint64(&c.epochPrefetched, prev, number+1) {
+		return
+	}
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return
+	}
+
+	go func() {
+		start := time.Now()
+		statedb, err := reader.StateAt(parent.Root)
+		if err != nil {
+			log.Debug("Failed to open state to prefetch epoch block", "number", number+1, "err", err)
+			return
+		}
+		next := &types.Header{
+			ParentHash: header.ParentHash,
+			Number:     new(big.Int).SetUint64(number + 1),
+			Coinbase:   val,
+			Difficulty: diffInTurn,
+			GasLimit:   header.GasLimit,
+			Time:       header.Time + c.config.Period,
+		}
+		var (
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+			usedGas  uint64
+		)
+		evmContext := core.NewEVMBlockContext(next, consortiumCommon.ChainContext{Chain: chain, Consortium: c}, &next.Coinbase)
+		transactOpts := &consortiumCommon.ApplyTransactOpts{
+			ApplyMessageOpts: &consortiumCommon.ApplyMessageOpts{
+				State:       statedb,
+				Header:      next,
+				ChainConfig: c.chainConfig,
+				EVMContext:  &evmContext,
+			},
+			Txs:      &txs,
+			Receipts: &receipts,
+			UsedGas:  &usedGas,
+			Mining:   true,
+			Signer:   c.signer,
+			SignTxFn: signTxFn,
+		}
+		if err := contract.SubmitBlockReward(transactOpts); err != nil {
+			log.Debug("Failed to prefetch block reward", "number", next.Number, "err", err)
+		}
+		if err := contract.WrapUpEpoch(transactOpts); err != nil {
+			log.Debug("Failed to prefetch wrap up epoch", "number", next.Number, "err", err)
+		}
+		if _, err := contract.GetValidators(parent.Number); err != nil {
+			log.Debug("Failed to prefetch validators", "number", parent.Number, "err", err)
+		}
+		log.Debug("Prefetched epoch block state", "number", next.Number, "elapsed", common.PrettyDuration(time.Since(start)))
+	}()
+}
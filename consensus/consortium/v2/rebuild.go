@@ -0,0 +1,107 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// errRebuildNotEpoch is returned if the snapshot to rebuild is not at an
+	// epoch block whose previous epoch block is after consortium v2
+	errRebuildNotEpoch = errors.New("snapshot to rebuild is not at an epoch block after consortium v2")
+
+	// errRebuildValidatorSet is returned if the validator set read from the
+	// system contracts does not match the one in the checkpoint header
+	errRebuildValidatorSet = errors.New("contract validator set mismatches the checkpoint header")
+)
+
+// RebuildSnapshot reconstructs the snapshot at the canonical epoch block number
+// and writes it to the snapshot store, for when the snapshots are lost but the
+// state is intact. The validator set is the one the previous epoch block checks
+// in: it is read from the validator and profile contracts at the state before
+// that block and must match the validators in its header. The recent signers and
+// the justified block are recovered from the headers before number.
+func (c *Consortium) RebuildSnapshot(chain consensus.ChainHeaderReader, number uint64) (*Snapshot, error) {
+	epoch := c.config.EpochV2
+	if number%epoch != 0 || number < epoch || !c.chainConfig.IsConsortiumV2(new(big.Int).SetUint64(number-epoch)) {
+		return nil, errRebuildNotEpoch
+	}
+	header := chain.GetHeaderByNumber(number)
+	checkpoint := chain.GetHeaderByNumber(number - epoch)
+	if header == nil || checkpoint == nil {
+		return nil, consortiumCommon.ErrUnknownBlock
+	}
+
+	validators, err := c.getCheckpointValidatorsFromContract(checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validators at %d: %v", checkpoint.Number.Uint64()-1, err)
+	}
+	isShillin := c.chainConfig.IsShillin(checkpoint.Number)
+	extraData, err := finality.DecodeExtra(checkpoint.Extra, isShillin)
+	if err != nil {
+		return nil, err
+	}
+	if ValidatorSetHash(validators) != ValidatorSetHash(extraData.CheckpointValidators) {
+		return nil, errRebuildValidatorSet
+	}
+
+	var snap *Snapshot
+	if isShillin {
+		snap = newSnapshot(c.chainConfig, c.config, c.signatures, number, header.Hash(), nil, validators, c.ethAPI)
+	} else {
+		addresses := make([]common.Address, len(validators))
+		for i, validator := range validators {
+			addresses[i] = validator.Address
+		}
+		snap = newSnapshot(c.chainConfig, c.config, c.signatures, number, header.Hash(), addresses, nil, c.ethAPI)
+	}
+	checkpointChild := chain.GetHeaderByNumber(checkpoint.Number.Uint64() + 1)
+	if snap.ValidatorSetProof, err = newValidatorSetProof(c.chainConfig, checkpoint, checkpointChild, validators); err != nil {
+		return nil, err
+	}
+
+	// The validator set is applied half of its size after the checkpoint, long
+	// before number, so the recent signers are the last len/2+1 sealers
+	limit := uint64(len(validators)/2 + 1)
+	for ancient := header; ancient.Number.Uint64()+limit > number; {
+		sealer, err := verifier.Ecrecover(ancient, c.signatures, c.chainConfig.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		snap.Recents[ancient.Number.Uint64()] = sealer
+		if ancient = chain.GetHeader(ancient.ParentHash, ancient.Number.Uint64()-1); ancient == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+	}
+
+	// The justified block is the parent of the last block with finality votes
+	for ancient := header; c.chainConfig.IsShillin(ancient.Number) && ancient.Number.Uint64() > c.forkedBlock; {
+		extraData, err := finality.DecodeExtra(ancient.Extra, true)
+		if err != nil {
+			return nil, err
+		}
+		if extraData.HasFinalityVote == 1 {
+			snap.JustifiedBlockNumber = ancient.Number.Uint64() - 1
+			snap.JustifiedBlockHash = ancient.ParentHash
+			break
+		}
+		if ancient = chain.GetHeader(ancient.ParentHash, ancient.Number.Uint64()-1); ancient == nil {
+			return nil, consensus.ErrUnknownAncestor
+		}
+	}
+
+	if err := snap.store(c.snapshotDB()); err != nil {
+		return nil, err
+	}
+	c.recents.Add(snap.Hash, snap)
+	log.Info("Rebuilt checkpoint snapshot", "number", number, "hash", snap.Hash, "validators", len(validators), "justified", snap.JustifiedBlockNumber)
+	return snap, nil
+}
@@ -0,0 +1,132 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errReplayRange is returned if the block range to replay is empty or starts at
+// the genesis block
+var errReplayRange = errors.New("invalid replay range")
+
+// ReplayStep is a step of the verification or the finalization of a replayed
+// block, the fields depend on the step. The steps of a block are in the order
+// they are run by the engine.
+type ReplayStep struct {
+	Number uint64                 `json:"number"`
+	Step   string                 `json:"step"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// replayTracer writes the steps of the replayed blocks as JSON lines and logs
+// them. It is only set on the engine by ReplayBlocks.
+type replayTracer struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+	err  error // First write error, the steps after it are only logged
+}
+
+// trace records the step of the block at number with the key value pairs of ctx
+func (t *replayTracer) trace(number uint64, step string, err error, ctx ...interface{}) {
+	entry := ReplayStep{Number: number, Step: step, Fields: make(map[string]interface{}, len(ctx)/2)}
+	for i := 0; i+1 < len(ctx); i += 2 {
+		entry.Fields[fmt.Sprint(ctx[i])] = ctx[i+1]
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		log.Warn("Replay step failed", append([]interface{}{"number", number, "step", step, "err", err}, ctx...)...)
+	} else {
+		log.Info("Replay step", append([]interface{}{"number", number, "step", step}, ctx...)...)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.err == nil {
+		t.err = t.enc.Encode(entry)
+	}
+}
+
+// tracer returns the tracer of the replayed blocks, nil if no replay is running
+func (c *Consortium) tracer() *replayTracer {
+	tracer, _ := c.replayTracer.Load().(*replayTracer)
+	return tracer
+}
+
+// ReplayBlocks re-runs the header verification, the body validation, the
+// execution including Finalize and the state validation of the canonical blocks
+// from first to last and writes the trace of the consensus steps to w: the
+// snapshot lookups, the backoff of the block time, the seal and finality
+// signature checks and the system transactions. The in-memory caches of the
+// engine are purged first so the replay does not depend on the blocks verified
+// before. It stops at the first failing block and returns the number of blocks
+// replayed without error.
+//
+// The state before first must be available. It is meant to be run offline, the
+// chain must not be importing blocks meanwhile.
+func (c *Consortium) ReplayBlocks(chain *core.BlockChain, first, last uint64, w io.Writer) (int, error) {
+	if first == 0 || first > last {
+		return 0, errReplayRange
+	}
+	tracer := &replayTracer{enc: json.NewEncoder(w)}
+	c.recents.Purge()
+	c.signatures.Purge()
+	if c.verified != nil {
+		c.verified.Purge()
+	}
+	c.replayTracer.Store(tracer)
+	defer c.replayTracer.Store((*replayTracer)(nil))
+
+	var (
+		engine    = chain.Engine()
+		processor = core.NewStateProcessor(chain.Config(), chain, engine)
+	)
+	for number := first; number <= last; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			return int(number - first), fmt.Errorf("block %d is not available", number)
+		}
+		header := block.Header()
+
+		err := engine.VerifyHeader(chain, header, true)
+		tracer.trace(number, "verifyHeader", err, "hash", block.Hash(), "coinbase", header.Coinbase,
+			"difficulty", header.Difficulty, "time", header.Time)
+		if err != nil {
+			return int(number - first), err
+		}
+		err = chain.Validator().ValidateBody(block)
+		tracer.trace(number, "validateBody", err, "txs", len(block.Transactions()))
+		if err != nil {
+			return int(number - first), err
+		}
+
+		parent := chain.GetHeader(block.ParentHash(), number-1)
+		if parent == nil {
+			return int(number - first), fmt.Errorf("parent of block %d is not available", number)
+		}
+		statedb, err := chain.StateAt(parent.Root)
+		if err != nil {
+			return int(number - first), fmt.Errorf("state of block %d is not available: %v", number-1, err)
+		}
+		receipts, _, _, usedGas, err := processor.Process(block, statedb, vm.Config{})
+		tracer.trace(number, "process", err, "receipts", len(receipts), "gasUsed", usedGas)
+		if err != nil {
+			return int(number - first), err
+		}
+		err = chain.Validator().ValidateState(block, statedb, receipts, usedGas)
+		tracer.trace(number, "validateState", err, "root", header.Root)
+		if err != nil {
+			return int(number - first), err
+		}
+	}
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	return int(last - first + 1), tracer.err
+}
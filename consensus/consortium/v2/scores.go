@@ -0,0 +1,247 @@
+package v2
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ScoreConfig is the scoring window and the weights of the validator
+// performance score
+type ScoreConfig struct {
+	Window uint64 // Number of the last completed epochs scored
+
+	InTurnWeight      float64 // Weight of the ratio of the in-turn slots sealed by the validator
+	FinalityWeight    float64 // Weight of the ratio of the finality voted blocks including the validator's vote
+	MissedEpochWeight float64 // Weight of the ratio of the epochs the validator sealed a block in
+}
+
+// DefaultScoreConfig scores the validators over a day of epochs of 200 blocks
+var DefaultScoreConfig = ScoreConfig{
+	Window:            144,
+	InTurnWeight:      0.4,
+	FinalityWeight:    0.4,
+	MissedEpochWeight: 0.2,
+}
+
+// ValidatorScore is the performance of a validator in the scoring window. A
+// missed epoch is an epoch in which the validator has in-turn slots but seals
+// no block. The score is the weighted average of the in-turn ratio, the
+// finality ratio and the ratio of the epochs which are not missed, from 0 to 1.
+type ValidatorScore struct {
+	Rank          int            `json:"rank"`
+	Validator     common.Address `json:"validator"`
+	Score         float64        `json:"score"`
+	InTurnRatio   float64        `json:"inTurnRatio"`
+	FinalityRatio float64        `json:"finalityRatio"`
+	Epochs        uint64         `json:"epochs"` // Scored epochs the validator takes part in
+	MissedEpochs  uint64         `json:"missedEpochs"`
+}
+
+// ValidatorScores is the ranking of the validators in the scoring window
+type ValidatorScores struct {
+	FromEpoch uint64           `json:"fromEpoch"`
+	ToEpoch   uint64           `json:"toEpoch"`
+	Scores    []ValidatorScore `json:"scores"` // Sorted by descending score
+}
+
+// scoreTracker keeps the statistics of the last completed epochs of the scoring
+// window
+type scoreTracker struct {
+	config ScoreConfig
+
+	lock   sync.RWMutex
+	epochs map[uint64]*EpochStats
+	last   uint64 // Last scored epoch, 0 if none
+}
+
+// StartScoreTracker scores the performance of the validators from the
+// statistics of the completed epochs of the new chain heads in the background
+// until the engine is closed. The default window and weights are used if they are not set.
+func (c *Consortium) StartScoreTracker(chain *core.BlockChain, config ScoreConfig) {
+	if config.Window == 0 {
+		config.Window = DefaultScoreConfig.Window
+	}
+	if config.InTurnWeight < 0 || config.FinalityWeight < 0 || config.MissedEpochWeight < 0 ||
+		config.InTurnWeight+config.FinalityWeight+config.MissedEpochWeight == 0 {
+		log.Warn("Invalid validator score weights, using the defaults", "inturn", config.InTurnWeight,
+			"finality", config.FinalityWeight, "missedepoch", config.MissedEpochWeight)
+		config.InTurnWeight = DefaultScoreConfig.InTurnWeight
+		config.FinalityWeight = DefaultScoreConfig.FinalityWeight
+		config.MissedEpochWeight = DefaultScoreConfig.MissedEpochWeight
+	}
+	log.Info("Starting validator score tracker", "window", config.Window, "inturn", config.InTurnWeight,
+		"finality", config.FinalityWeight, "missedepoch", config.MissedEpochWeight)
+	tracker := &scoreTracker{
+		config: config,
+		epochs: make(map[uint64]*EpochStats),
+	}
+	c.lock.Lock()
+	c.scoreTracker = tracker
+	c.lock.Unlock()
+
+	chainHeadCh := make(chan core.ChainHeadEvent, 10)
+	chainHeadSub := chain.SubscribeChainHeadEvent(chainHeadCh)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer chainHeadSub.Unsubscribe()
+
+		c.trackScores(chain, tracker, chain.CurrentHeader())
+		for {
+			select {
+			case ev := <-chainHeadCh:
+				c.trackScores(chain, tracker, ev.Block.Header())
+			case <-chainHeadSub.Err():
+				return
+			case <-c.quit:
+				return
+			}
+		}
+	}()
+}
+
+// trackScores computes the statistics of the epochs completed up to head which
+// are in the scoring window and drops the ones out of it
+func (c *Consortium) trackScores(chain consensus.ChainHeaderReader, tracker *scoreTracker, head *types.Header) {
+	current := head.Number.Uint64() / c.config.EpochV2
+	if current == 0 {
+		return
+	}
+	completed := current - 1
+	from := uint64(0)
+	if completed >= tracker.config.Window {
+		from = completed - tracker.config.Window + 1
+	}
+
+	tracker.lock.RLock()
+	last := tracker.last
+	tracker.lock.RUnlock()
+	if last >= completed {
+		return
+	}
+	if last >= from {
+		from = last + 1
+	}
+
+	// The tracker is only updated from the chain head loop, the statistics are
+	// computed out of the lock not to block the API
+	computed := make(map[uint64]*EpochStats)
+	for epoch := from; epoch <= completed; epoch++ {
+		stats, err := c.ComputeEpochStats(chain, epoch)
+		if err == errStatsBeforeV2 {
+			continue
+		}
+		if err != nil {
+			log.Debug("Failed to compute epoch stats for validator scoring", "epoch", epoch, "err", err)
+			return
+		}
+		computed[epoch] = stats
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	for epoch, stats := range computed {
+		tracker.epochs[epoch] = stats
+	}
+	for epoch := range tracker.epochs {
+		if epoch+tracker.config.Window <= completed {
+			delete(tracker.epochs, epoch)
+		}
+	}
+	tracker.last = completed
+}
+
+// scoreValidators ranks the validators by their performance in the epochs
+func scoreValidators(epochs []*EpochStats, config ScoreConfig) []ValidatorScore {
+	type totals struct {
+		sealedInTurn, missedInTurn    uint64
+		finalityVotes, finalityBlocks uint64
+		epochs, missedEpochs          uint64
+	}
+	validators := make(map[common.Address]*totals)
+	for _, stats := range epochs {
+		for _, val := range stats.Validators {
+			total, ok := validators[val.Address]
+			if !ok {
+				total = new(totals)
+				validators[val.Address] = total
+			}
+			total.sealedInTurn += val.SealedInTurn
+			total.missedInTurn += val.MissedInTurn
+			total.finalityVotes += val.FinalityVotes
+			total.finalityBlocks += stats.FinalityVotedBlocks
+			total.epochs++
+			if val.Sealed == 0 && val.MissedInTurn > 0 {
+				total.missedEpochs++
+			}
+		}
+	}
+
+	// A validator without in-turn slots or finality voted blocks misses none of them
+	ratio := func(done, total uint64) float64 {
+		if total == 0 {
+			return 1
+		}
+		return float64(done) / float64(total)
+	}
+	weights := config.InTurnWeight + config.FinalityWeight + config.MissedEpochWeight
+	scores := make([]ValidatorScore, 0, len(validators))
+	for address, total := range validators {
+		score := ValidatorScore{
+			Validator:     address,
+			InTurnRatio:   ratio(total.sealedInTurn, total.sealedInTurn+total.missedInTurn),
+			FinalityRatio: ratio(total.finalityVotes, total.finalityBlocks),
+			Epochs:        total.epochs,
+			MissedEpochs:  total.missedEpochs,
+		}
+		score.Score = (config.InTurnWeight*score.InTurnRatio +
+			config.FinalityWeight*score.FinalityRatio +
+			config.MissedEpochWeight*ratio(total.epochs-total.missedEpochs, total.epochs)) / weights
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return bytes.Compare(scores[i].Validator[:], scores[j].Validator[:]) < 0
+	})
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+	return scores
+}
+
+// GetValidatorScores returns the validators ranked by their performance score
+// in the epochs of the scoring window tracked so far
+func (c *Consortium) GetValidatorScores() (*ValidatorScores, bool) {
+	c.lock.RLock()
+	tracker := c.scoreTracker
+	c.lock.RUnlock()
+	if tracker == nil {
+		return nil, false
+	}
+
+	tracker.lock.RLock()
+	defer tracker.lock.RUnlock()
+	result := &ValidatorScores{}
+	epochs := make([]*EpochStats, 0, len(tracker.epochs))
+	for epoch, stats := range tracker.epochs {
+		if len(epochs) == 0 || epoch < result.FromEpoch {
+			result.FromEpoch = epoch
+		}
+		if epoch > result.ToEpoch {
+			result.ToEpoch = epoch
+		}
+		epochs = append(epochs, stats)
+	}
+	result.Scores = scoreValidators(epochs, tracker.config)
+	return result, true
+}
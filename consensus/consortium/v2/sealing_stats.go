@@ -0,0 +1,113 @@
+package v2
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/features"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxWiggleMultiplier bounds the adaptive wiggle, the out-of-turn validators
+	// wait at most (maxWiggleMultiplier-1) * wiggleTime more than the protocol delay
+	maxWiggleMultiplier = 2.0
+
+	// sealingLatencyMax is the maximum latency observed, a larger latency is most
+	// likely a block received while syncing
+	sealingLatencyMax = time.Minute
+
+	// sealingLatencyWeight is the weight of a new sample in the moving average of
+	// the latency
+	sealingLatencyWeight = 0.2
+)
+
+// adaptiveWiggle gates delaying the out-of-turn sealing further as the in-turn
+// blocks are observed to arrive late
+var adaptiveWiggle = features.Register("consortium.adaptivewiggle", "Adjust the out-of-turn sealing delay to the observed latency of the in-turn blocks (experimental)", false, true)
+
+var (
+	inTurnSealMeter    = metrics.NewRegisteredMeter("consortium/seal/inturn", nil)
+	outOfTurnSealMeter = metrics.NewRegisteredMeter("consortium/seal/outofturn", nil)
+
+	// Seconds the out-of-turn blocks are sealed after the in-turn slot
+	outOfTurnDelayHistogram = metrics.NewRegisteredHistogram("consortium/seal/outofturn/delay", nil, metrics.NewExpDecaySample(1028, 0.015))
+	// Milliseconds the in-turn blocks are verified after their header time
+	inTurnLatencyHistogram = metrics.NewRegisteredHistogram("consortium/seal/inturn/latency", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	wiggleMultiplierGauge = metrics.NewRegisteredGaugeFloat64("consortium/seal/wiggle", nil)
+)
+
+// sealingStats is the moving average of the latency of the in-turn blocks, the
+// zero value is ready to use
+type sealingStats struct {
+	lock    sync.Mutex
+	latency float64 // Moving average in milliseconds
+	samples uint64
+}
+
+// observe adds the latency of an in-turn block to the moving average
+func (stats *sealingStats) observe(latency time.Duration) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	ms := float64(latency.Milliseconds())
+	if stats.samples == 0 {
+		stats.latency = ms
+	} else {
+		stats.latency = sealingLatencyWeight*ms + (1-sealingLatencyWeight)*stats.latency
+	}
+	stats.samples++
+}
+
+// wiggleMultiplier returns the multiplier of wiggleTime the out-of-turn sealing
+// is delayed by, the in-turn block has one more wiggleTime to arrive for each
+// wiggleTime of observed latency, within [1, maxWiggleMultiplier]
+func (stats *sealingStats) wiggleMultiplier() float64 {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+
+	multiplier := 1 + stats.latency/float64(wiggleTime.Milliseconds())
+	if multiplier > maxWiggleMultiplier {
+		multiplier = maxWiggleMultiplier
+	}
+	return multiplier
+}
+
+// recordSealing accounts the verified header in the sealing statistics. The
+// out-of-turn blocks are counted with their delay after the in-turn slot, the
+// in-turn blocks with the latency after their header time they are verified at.
+func (c *Consortium) recordSealing(header, parent *types.Header) {
+	if header.Difficulty.Cmp(diffInTurn) != 0 {
+		outOfTurnSealMeter.Mark(1)
+		if slot := parent.Time + c.config.Period; header.Time > slot {
+			outOfTurnDelayHistogram.Update(int64(header.Time - slot))
+		} else {
+			outOfTurnDelayHistogram.Update(0)
+		}
+		return
+	}
+	inTurnSealMeter.Mark(1)
+
+	latency := c.now().Sub(time.Unix(int64(header.Time), 0))
+	if latency > sealingLatencyMax {
+		return
+	}
+	if latency < 0 {
+		latency = 0
+	}
+	inTurnLatencyHistogram.Update(latency.Milliseconds())
+	c.sealingStats.observe(latency)
+	wiggleMultiplierGauge.Update(c.sealingStats.wiggleMultiplier())
+}
+
+// adaptiveWiggleDelay returns the extra delay of the out-of-turn sealing in the
+// adaptive wiggle mode. The delay only postpones the release of the block, the
+// header time is unchanged, so the blocks are valid with or without the mode.
+func (c *Consortium) adaptiveWiggleDelay() time.Duration {
+	if !adaptiveWiggle.Enabled() {
+		return 0
+	}
+	return time.Duration((c.sealingStats.wiggleMultiplier() - 1) * float64(wiggleTime))
+}
@@ -0,0 +1,146 @@
+package v2
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// shadowSignTx leaves the system transactions of the shadow blocks unsigned, the
+// shadow validator has no key on the node
+func shadowSignTx(_ accounts.Account, tx *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+	return tx, nil
+}
+
+// ShadowSeal runs the block production of the validator on top of the parent
+// as if the node were the validator: the header is prepared, the system
+// transactions are applied on the statedb and the finality votes from the vote
+// pool are assembled. The block carries no user transaction and is neither
+// signed nor broadcast. It returns nil if the validator is not in turn at the
+// next block.
+func (c *Consortium) ShadowSeal(chain consensus.ChainHeaderReader, parent *types.Header, statedb *state.StateDB, validator common.Address) (*types.Block, error) {
+	snap, err := c.snapshot(chain, parent.Number.Uint64(), parent.Hash(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if !snap.inturn(validator) {
+		return nil, nil
+	}
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+	}
+	if c.chainConfig.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(c.chainConfig, parent)
+	}
+	if err := c.prepareHeader(chain, header, snap, validator); err != nil {
+		return nil, err
+	}
+	contract, err := c.newContract(validator, shadowSignTx)
+	if err != nil {
+		return nil, err
+	}
+	block, _, err := c.finalizeAndAssemble(chain, header, statedb, nil, nil, shadowSignTx, contract)
+	if err != nil {
+		return nil, err
+	}
+
+	// The votes are assembled in Seal, after the block is assembled
+	header = block.Header()
+	c.assembleFinalityVote(header, snap)
+	return block.WithSeal(header), nil
+}
+
+// StartShadowSealing shadow seals the blocks the validator is in turn at on top
+// of the new chain heads in the background until the engine is closed. The block is produced at
+// its slot time, so the finality votes of its parent have the time to arrive,
+// and what it would have been is logged. It is meant for rehearsing the
+// onboarding of a validator on a non-validator node.
+func (c *Consortium) StartShadowSealing(chain *core.BlockChain, validator common.Address) {
+	log.Info("Starting shadow sealing", "validator", validator)
+
+	chainHeadCh := make(chan core.ChainHeadEvent, 10)
+	chainHeadSub := chain.SubscribeChainHeadEvent(chainHeadCh)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer chainHeadSub.Unsubscribe()
+
+		var (
+			parent *types.Header
+			slot   = time.NewTimer(0)
+		)
+		defer slot.Stop()
+		<-slot.C
+
+		for {
+			select {
+			case ev := <-chainHeadCh:
+				if !slot.Stop() {
+					select {
+					case <-slot.C:
+					default:
+					}
+				}
+				// Skip the stale heads while the chain is syncing
+				delay := time.Until(time.Unix(int64(ev.Block.Time()+c.config.Period), 0))
+				if delay < -time.Duration(c.config.Period)*time.Second {
+					continue
+				}
+				parent = ev.Block.Header()
+				slot.Reset(delay)
+			case <-slot.C:
+				c.shadowSealOn(chain, parent, validator)
+			case <-chainHeadSub.Err():
+				return
+			case <-c.quit:
+				return
+			}
+		}
+	}()
+}
+
+// shadowSealOn shadow seals the block of the validator on top of the parent and
+// logs it
+func (c *Consortium) shadowSealOn(chain *core.BlockChain, parent *types.Header, validator common.Address) {
+	start := time.Now()
+	statedb, err := chain.StateAt(parent.Root)
+	if err != nil {
+		log.Warn("Failed to open state to shadow seal", "number", parent.Number.Uint64()+1, "err", err)
+		return
+	}
+	block, err := c.ShadowSeal(chain, parent, statedb, validator)
+	if err != nil {
+		log.Warn("Failed to shadow seal block", "number", parent.Number.Uint64()+1, "validator", validator, "err", err)
+		return
+	}
+	if block == nil {
+		log.Debug("Shadow validator is not in turn", "number", parent.Number.Uint64()+1, "validator", validator)
+		return
+	}
+	extraData, err := finality.DecodeExtra(block.Extra(), c.chainConfig.IsShillin(block.Number()))
+	if err != nil {
+		log.Warn("Failed to decode shadow block extra data", "number", block.Number(), "err", err)
+		return
+	}
+	var voters int
+	if extraData.HasFinalityVote == 1 {
+		voters = len(extraData.FinalityVotedValidators.Indices())
+	}
+	log.Info("Shadow sealed block", "number", block.Number(), "parent", parent.Hash(), "validator", validator,
+		"time", block.Time(), "systemTxs", len(block.Transactions()), "gasUsed", block.GasUsed(), "root", block.Root(),
+		"finalityVoters", voters, "checkpointValidators", len(extraData.CheckpointValidators),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,169 @@
+package simnet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality/finalitytest"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Node is a validator of the simulated network
+type Node struct {
+	Index     int
+	Validator *finalitytest.Validator
+	Engine    *consortium.Consortium
+	Chain     *core.BlockChain
+
+	pool      *votePool
+	online    bool
+	sealed    common.Hash // Parent of the last block sealed by the node
+	lastVoted uint64      // Number of the last block voted by the node
+}
+
+func newNode(network *Network, index int, validator *finalitytest.Validator) (*Node, error) {
+	db := rawdb.NewMemoryDatabase()
+	genesis := network.genesis.MustCommit(db)
+
+	engine := consortium.New(network.genesis.Config, db, nil, genesis.Hash())
+	engine.SetClock(network.Clock, epoch)
+	engine.SetVoteAssemblyWindow(network.config.VoteAssemblyWindow)
+	engine.Authorize(validator.Address, func(account accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(message), validator.Key)
+	}, nil)
+
+	chain, err := core.NewBlockChain(db, nil, network.genesis.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	pool := newVotePool()
+	engine.SetVotePool(pool)
+	return &Node{
+		Index:     index,
+		Validator: validator,
+		Engine:    engine,
+		Chain:     chain,
+		pool:      pool,
+		online:    true,
+	}, nil
+}
+
+// Online returns whether the node is online
+func (n *Node) Online() bool {
+	return n.online
+}
+
+// seal starts sealing a block on the head if it has not been sealed yet, the
+// block is empty. The seal is nil if the node cannot seal on the head.
+func (n *Node) seal() (*seal, error) {
+	parent := n.Chain.CurrentBlock()
+	if parent.Hash() == n.sealed {
+		return nil, nil
+	}
+	n.sealed = parent.Hash()
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if err := n.Engine.Prepare(n.Chain, header); err != nil {
+		return nil, fmt.Errorf("node %d failed to prepare block %d: %v", n.Index, header.Number, err)
+	}
+	statedb, err := n.Chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	block, _, err := n.Engine.FinalizeAndAssemble(n.Chain, header, statedb, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("node %d failed to assemble block %d: %v", n.Index, header.Number, err)
+	}
+	seal := &seal{
+		node:    n,
+		results: make(chan *types.Block, 1),
+		stop:    make(chan struct{}),
+	}
+	if err := n.Engine.Seal(n.Chain, block, seal.results, seal.stop); err != nil {
+		if errors.Is(err, consortiumCommon.ErrRecentlySigned) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("node %d failed to seal block %d: %v", n.Index, header.Number, err)
+	}
+	return seal, nil
+}
+
+// vote signs the finality vote for the head if the node is a validator at the
+// head and has not voted at its height yet
+func (n *Node) vote() *types.VoteEnvelope {
+	head := n.Chain.CurrentBlock().Header()
+	number := head.Number.Uint64()
+	if number <= n.lastVoted || !n.Engine.IsActiveValidatorAt(n.Chain, head) {
+		return nil
+	}
+	n.lastVoted = number
+
+	sourceNumber, sourceHash := n.Engine.GetJustifiedBlock(n.Chain, number, head.Hash())
+	data := types.NewVoteData(n.Chain.Config(), number, head.Hash(), sourceNumber, sourceHash)
+	digest := data.Hash()
+	vote := &types.VoteEnvelope{
+		RawVoteEnvelope: types.RawVoteEnvelope{
+			Data: data,
+		},
+	}
+	copy(vote.PublicKey[:], n.Validator.BlsKey.PublicKey().Marshal())
+	copy(vote.Signature[:], n.Validator.BlsKey.Sign(digest[:]).Marshal())
+	return vote
+}
+
+// receiveVote adds the vote to the pool if the engine accepts it
+func (n *Node) receiveVote(vote *types.VoteEnvelope) {
+	if err := n.Engine.VerifyVote(n.Chain, vote); err != nil {
+		return
+	}
+	n.pool.put(vote)
+}
+
+// votePool is the vote pool of a node, the votes are verified by the engine
+// before they are added
+type votePool struct {
+	lock  sync.RWMutex
+	votes map[common.Hash][]*types.VoteEnvelope // Votes by the target block hash
+}
+
+func newVotePool() *votePool {
+	return &votePool{
+		votes: make(map[common.Hash][]*types.VoteEnvelope),
+	}
+}
+
+// put adds the vote unless the voter has already voted for the target block
+func (p *votePool) put(vote *types.VoteEnvelope) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	hash := vote.Data.TargetHash
+	for _, known := range p.votes[hash] {
+		if known.PublicKey == vote.PublicKey {
+			return
+		}
+	}
+	p.votes[hash] = append(p.votes[hash], vote)
+}
+
+// FetchVoteByBlockHash implements consensus.VotePool
+func (p *votePool) FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteEnvelope {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return append([]*types.VoteEnvelope(nil), p.votes[blockHash]...)
+}
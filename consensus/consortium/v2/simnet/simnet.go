@@ -0,0 +1,314 @@
+// Package simnet runs a network of consortium v2 validators in process on a
+// virtual clock, so that the consensus rules (backoff, fast finality) can be
+// tested end-to-end without spawning real nodes.
+//
+// Each node has its own engine, chain database and vote pool. The validator set
+// is served by the mock contract, the finality votes are gossiped over a
+// simulated bus which can drop them, and the nodes can be taken offline. The
+// time only advances when the network runs, in ticks of the virtual clock.
+//
+// The mock validator set has no BLS public key until the validator set of the
+// first checkpoint block takes effect, the finality votes are only counted after
+// the first epoch. The mock validator set is global to the process, the networks
+// must not run in parallel.
+package simnet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality/finalitytest"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// settleTimeout is the real time to wait for the sealing goroutines to settle on
+// the virtual clock before the clock advances
+const settleTimeout = 10 * time.Second
+
+// epoch is the wall time at the zero of the virtual clock, it is in the past so
+// that the blocks are never in the future of the chain import
+var epoch = time.Unix(1_600_000_000, 0)
+
+// errNotSettled is returned if the sealing goroutines do not settle in time
+var errNotSettled = errors.New("sealing goroutines do not settle")
+
+// Config is the configuration of a simulated network
+type Config struct {
+	Validators         int           // Number of validators, all of them run a node
+	Period             uint64        // Block period in seconds
+	EpochV2            uint64        // Number of blocks between the checkpoint blocks
+	VoteAssemblyWindow time.Duration // Time to wait for the finality votes, see v2.Consortium.SetVoteAssemblyWindow
+	Tick               time.Duration // Step of the virtual clock
+}
+
+// DefaultConfig is a network of 4 validators with the period of the mainnet and
+// a short epoch, so that the finality starts early
+var DefaultConfig = Config{
+	Validators: 4,
+	Period:     3,
+	EpochV2:    10,
+	Tick:       50 * time.Millisecond,
+}
+
+// VoteFilter reports whether the vote from the node at index from is dropped
+// before reaching the node at index to
+type VoteFilter func(from, to int, vote *types.VoteEnvelope) bool
+
+// Network is a simulated network of consortium v2 validators
+type Network struct {
+	Clock *mclock.Simulated
+	Nodes []*Node
+
+	config  Config
+	genesis *core.Genesis
+	filter  VoteFilter
+	seals   []*seal
+}
+
+// seal is a block being sealed by the node
+type seal struct {
+	node    *Node
+	results chan *types.Block
+	stop    chan struct{}
+}
+
+// New creates a network of validators from the same genesis. The validators
+// are the deterministic validators of finalitytest, they are set as the mock
+// validator set of the process.
+func New(config Config) (*Network, error) {
+	if config.Validators == 0 || config.Period == 0 || config.Tick <= 0 {
+		return nil, fmt.Errorf("invalid simulated network config %+v", config)
+	}
+	validators, err := finalitytest.NewValidatorSet(config.Validators)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		addresses  = make([]string, len(validators))
+		publicKeys = make([]string, len(validators))
+	)
+	for i, validator := range validators {
+		addresses[i] = validator.Address.Hex()
+		publicKeys[i] = hex.EncodeToString(validator.BlsKey.PublicKey().Marshal())
+	}
+	if err := consortiumCommon.SetMockValidators(strings.Join(addresses, ","), strings.Join(publicKeys, ",")); err != nil {
+		return nil, err
+	}
+
+	genesis := core.DeveloperConsortiumGenesisBlock(config.Period, 30_000_000, validators[0].Address, validators[1:].Addresses())
+	genesis.Config.Consortium.EpochV2 = config.EpochV2
+	genesis.Timestamp = uint64(epoch.Unix())
+
+	network := &Network{
+		Clock:   new(mclock.Simulated),
+		config:  config,
+		genesis: genesis,
+	}
+	for i, validator := range validators {
+		node, err := newNode(network, i, validator)
+		if err != nil {
+			network.Stop()
+			return nil, err
+		}
+		network.Nodes = append(network.Nodes, node)
+	}
+	return network, nil
+}
+
+// Stop terminates the sealing and stops the chains of the nodes
+func (n *Network) Stop() {
+	for _, seal := range n.seals {
+		close(seal.stop)
+	}
+	n.seals = nil
+	for _, node := range n.Nodes {
+		node.Chain.Stop()
+	}
+}
+
+// SetVoteFilter drops the votes matching the filter, nil delivers all the votes
+func (n *Network) SetVoteFilter(filter VoteFilter) {
+	n.filter = filter
+}
+
+// SetOnline takes the node offline or back online. An offline node neither
+// seals, votes nor receives anything, it syncs the chain from the other nodes
+// when it is back online.
+func (n *Network) SetOnline(index int, online bool) error {
+	node := n.Nodes[index]
+	if node.online == online {
+		return nil
+	}
+	node.online = online
+	if online {
+		return n.sync(node)
+	}
+	return nil
+}
+
+// Now returns the wall time of the virtual clock
+func (n *Network) Now() time.Time {
+	return epoch.Add(time.Duration(n.Clock.Now()))
+}
+
+// Run advances the network by the duration of virtual time
+func (n *Network) Run(d time.Duration) error {
+	for end := n.Clock.Now().Add(d); n.Clock.Now() < end; {
+		if err := n.step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunUntil advances the network until all the online nodes are at least at the
+// block number, or fails after the duration of virtual time
+func (n *Network) RunUntil(number uint64, timeout time.Duration) error {
+	for end := n.Clock.Now().Add(timeout); ; {
+		reached := true
+		for _, node := range n.Nodes {
+			if node.online && node.Chain.CurrentBlock().NumberU64() < number {
+				reached = false
+			}
+		}
+		if reached {
+			return nil
+		}
+		if n.Clock.Now() >= end {
+			return fmt.Errorf("block %d not reached in %v", number, timeout)
+		}
+		if err := n.step(); err != nil {
+			return err
+		}
+	}
+}
+
+// step votes for and seals on the new heads, then advances the clock by a tick
+// and delivers the sealed blocks
+func (n *Network) step() error {
+	for _, node := range n.Nodes {
+		if !node.online {
+			continue
+		}
+		if vote := node.vote(); vote != nil {
+			n.gossip(node, vote)
+		}
+		seal, err := node.seal()
+		if err != nil {
+			return err
+		}
+		if seal != nil {
+			n.seals = append(n.seals, seal)
+		}
+	}
+
+	if err := n.settle(); err != nil {
+		return err
+	}
+	n.Clock.Run(n.config.Tick)
+	if err := n.settle(); err != nil {
+		return err
+	}
+
+	pending := n.seals[:0]
+	for _, seal := range n.seals {
+		select {
+		case block := <-seal.results:
+			if err := n.deliver(seal.node, block); err != nil {
+				return err
+			}
+		default:
+			pending = append(pending, seal)
+		}
+	}
+	n.seals = pending
+	return nil
+}
+
+// settle waits until each sealing goroutine waits on a timer of the virtual
+// clock or has delivered its block. The engine keeps at most one timer per seal,
+// so the goroutines are settled when the timers match the undelivered seals.
+func (n *Network) settle() error {
+	for deadline := time.Now().Add(settleTimeout); ; {
+		undelivered := 0
+		for _, seal := range n.seals {
+			if len(seal.results) == 0 {
+				undelivered++
+			}
+		}
+		if n.Clock.ActiveTimers() == undelivered {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errNotSettled
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// gossip delivers the vote of the node to the online nodes
+func (n *Network) gossip(from *Node, vote *types.VoteEnvelope) {
+	for _, to := range n.Nodes {
+		if !to.online || (n.filter != nil && n.filter(from.Index, to.Index, vote)) {
+			continue
+		}
+		to.receiveVote(vote)
+	}
+}
+
+// deliver imports the sealed block into the online nodes. The block is dropped
+// if the node is offline or has moved to another head meanwhile, as the miner
+// drops its stale work.
+func (n *Network) deliver(producer *Node, block *types.Block) error {
+	if !producer.online || producer.Chain.CurrentBlock().Hash() != block.ParentHash() {
+		return nil
+	}
+	for _, node := range n.Nodes {
+		if !node.online {
+			continue
+		}
+		if _, err := node.Chain.InsertChain(types.Blocks{block}); err != nil {
+			return fmt.Errorf("node %d failed to import block %d from node %d: %v", node.Index, block.NumberU64(), producer.Index, err)
+		}
+	}
+	return nil
+}
+
+// sync imports the chain of the online node with the highest total difficulty
+func (n *Network) sync(node *Node) error {
+	var (
+		best   *Node
+		bestTd *big.Int
+	)
+	for _, peer := range n.Nodes {
+		if peer == node || !peer.online {
+			continue
+		}
+		head := peer.Chain.CurrentBlock()
+		if td := peer.Chain.GetTd(head.Hash(), head.NumberU64()); bestTd == nil || td.Cmp(bestTd) > 0 {
+			best, bestTd = peer, td
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	var blocks types.Blocks
+	for block := best.Chain.CurrentBlock(); !node.Chain.HasBlock(block.Hash(), block.NumberU64()); {
+		blocks = append(types.Blocks{block}, blocks...)
+		block = best.Chain.GetBlockByHash(block.ParentHash())
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+	if _, err := node.Chain.InsertChain(blocks); err != nil {
+		return fmt.Errorf("node %d failed to sync from node %d: %v", node.Index, best.Index, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package simnet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func newTestNetwork(t *testing.T, config Config) *Network {
+	network, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create network: %v", err)
+	}
+	t.Cleanup(network.Stop)
+	return network
+}
+
+func hasFinalityVote(t *testing.T, header *types.Header) bool {
+	extraData, err := finality.DecodeExtra(header.Extra, true)
+	if err != nil {
+		t.Fatalf("Failed to decode extra data of block %d: %v", header.Number, err)
+	}
+	return extraData.HasFinalityVote == 1
+}
+
+// firstVoted is the first block which includes the finality votes, the BLS
+// public keys of the first checkpoint block are used after half of the validators
+// have sealed
+var firstVoted = DefaultConfig.EpochV2 + uint64(DefaultConfig.Validators)/2 + 1
+
+func TestFinality(t *testing.T) {
+	network := newTestNetwork(t, DefaultConfig)
+	if err := network.RunUntil(30, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	head := network.Nodes[0].Chain.CurrentBlock()
+	for _, node := range network.Nodes[1:] {
+		if hash := node.Chain.GetCanonicalHash(head.NumberU64()); hash != head.Hash() {
+			t.Fatalf("Node %d has block %x at %d, want %x", node.Index, hash, head.NumberU64(), head.Hash())
+		}
+	}
+	for number := uint64(1); number <= head.NumberU64(); number++ {
+		header := network.Nodes[0].Chain.GetHeaderByNumber(number)
+		if header.Difficulty.Uint64() != 7 {
+			t.Errorf("Block %d is sealed out of turn", number)
+		}
+		if number >= firstVoted && !hasFinalityVote(t, header) {
+			t.Errorf("Block %d has no finality vote", number)
+		}
+	}
+	finalized, _ := network.Nodes[0].Engine.GetFinalizedBlock(network.Nodes[0].Chain, head.NumberU64(), head.Hash())
+	if finalized+2 < head.NumberU64() {
+		t.Fatalf("Finalized block %d lags behind head %d", finalized, head.NumberU64())
+	}
+	// The blocks are released on time, the votes are assembled in the slot
+	if want := time.Unix(int64(head.Time()), 0); network.Now().After(want.Add(time.Duration(DefaultConfig.Period) * time.Second)) {
+		t.Fatalf("Network is late, now %v, head time %v", network.Now(), want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	network := newTestNetwork(t, DefaultConfig)
+	if err := network.SetOnline(0, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.RunUntil(24, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		chain      = network.Nodes[1].Chain
+		offline    = network.Nodes[0].Validator.Address
+		outOfTurns int
+		backoffs   int
+	)
+	for number := uint64(1); number <= 24; number++ {
+		header, parent := chain.GetHeaderByNumber(number), chain.GetHeaderByNumber(number-1)
+		if header.Coinbase == offline {
+			t.Fatalf("Block %d is sealed by the offline validator", number)
+		}
+		if header.Difficulty.Uint64() == 3 {
+			outOfTurns++
+		}
+		if header.Time > parent.Time+DefaultConfig.Period {
+			if header.Difficulty.Uint64() != 3 {
+				t.Errorf("In-turn block %d is delayed, time %d, parent time %d", number, header.Time, parent.Time)
+			}
+			backoffs++
+		}
+		// 3 votes of 4 validators still reach the quorum
+		if number >= firstVoted && !hasFinalityVote(t, header) {
+			t.Errorf("Block %d has no finality vote", number)
+		}
+	}
+	if outOfTurns == 0 || backoffs == 0 {
+		t.Fatalf("Missing backoff of the out-of-turn validators, %d out-of-turn blocks, %d delayed", outOfTurns, backoffs)
+	}
+
+	// The validator catches up when it is back
+	if err := network.SetOnline(0, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := network.RunUntil(30, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if head := network.Nodes[0].Chain.CurrentBlock(); chain.GetCanonicalHash(head.NumberU64()) != head.Hash() {
+		t.Fatalf("Validator is not on the canonical chain at %d", head.NumberU64())
+	}
+}
+
+func TestDroppedVotes(t *testing.T) {
+	config := DefaultConfig
+	config.VoteAssemblyWindow = time.Second
+	network := newTestNetwork(t, config)
+	network.SetVoteFilter(func(from, to int, vote *types.VoteEnvelope) bool {
+		return from != to
+	})
+	if err := network.RunUntil(16, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	chain := network.Nodes[0].Chain
+	for number := uint64(1); number <= 16; number++ {
+		if hasFinalityVote(t, chain.GetHeaderByNumber(number)) {
+			t.Errorf("Block %d has finality votes without the quorum", number)
+		}
+	}
+	head := chain.CurrentBlock()
+	if finalized, _ := network.Nodes[0].Engine.GetFinalizedBlock(chain, head.NumberU64(), head.Hash()); finalized != 0 {
+		t.Fatalf("Block %d is finalized without the votes", finalized)
+	}
+}
+
+func TestVoteWaitInSlot(t *testing.T) {
+	config := DefaultConfig
+	config.VoteAssemblyWindow = time.Second
+	network := newTestNetwork(t, config)
+	network.SetVoteFilter(func(from, to int, vote *types.VoteEnvelope) bool {
+		return from != to
+	})
+
+	// Without the quorum the in-turn blocks are still released in their slot,
+	// the block is delivered at most one tick after it is released
+	chain := network.Nodes[0].Chain
+	for head := chain.CurrentBlock(); head.NumberU64() < 16; {
+		if err := network.Run(config.Tick); err != nil {
+			t.Fatal(err)
+		}
+		if block := chain.CurrentBlock(); block.Hash() != head.Hash() {
+			head = block
+			if slot := time.Unix(int64(head.Time()), 0).Add(config.Tick); network.Now().After(slot) {
+				t.Fatalf("Block %d is delivered at %v, after its slot %v", head.NumberU64(), network.Now(), slot)
+			}
+			if head.Difficulty().Uint64() != 7 {
+				t.Errorf("Block %d is sealed out of turn", head.NumberU64())
+			}
+		}
+		if network.Now().After(epoch.Add(2 * time.Minute)) {
+			t.Fatalf("Block 16 not reached, head %d", head.NumberU64())
+		}
+	}
+}
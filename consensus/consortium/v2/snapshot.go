@@ -7,10 +7,12 @@ import (
 	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
 	v1 "github.com/ethereum/go-ethereum/consensus/consortium/v1"
 	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
 	"github.com/ethereum/go-ethereum/core/types"
 	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -24,8 +26,9 @@ type Snapshot struct {
 	chainConfig *params.ChainConfig
 	config      *params.ConsortiumConfig // Consensus engine parameters to fine tune behavior
 	ethAPI      *ethapi.PublicBlockChainAPI
-	sigCache    *lru.ARCCache // Cache of recent block signatures to speed up ecrecover
+	sigCache    *lru.Cache // Cache of recent block signatures to speed up ecrecover
 
+	Version    uint64                      `json:"version"`              // Version of the snapshot format, see SnapshotVersion
 	Number     uint64                      `json:"number"`               // Block number where the snapshot was created
 	Hash       common.Hash                 `json:"hash"`                 // Block hash where the snapshot was created
 	Validators map[common.Address]struct{} `json:"validators,omitempty"` // Set of authorized validators at this moment before Shillin
@@ -35,6 +38,53 @@ type Snapshot struct {
 	ValidatorsWithBlsPub []finality.ValidatorWithBlsPub `json:"validatorWithBlsPub,omitempty"`  // Array of sorted authorized validators and BLS public keys after Shillin
 	JustifiedBlockNumber uint64                         `json:"justifiedBlockNumber,omitempty"` // The justified block number
 	JustifiedBlockHash   common.Hash                    `json:"justifiedBlockHash,omitempty"`   // The justified block hash
+
+	// Checkpoint that introduced the validator set, nil in the snapshots stored
+	// before the proofs are recorded
+	ValidatorSetProof *ValidatorSetProof `json:"validatorSetProof,omitempty"`
+
+	// BLS public keys replaced at the last validator set transition, the votes
+	// signed with them are accepted up to the block PreviousBlsKeysUntil, see
+	// params.ConsortiumConfig.BlsKeyRotationOverlap
+	PreviousBlsKeys      []finality.ValidatorWithBlsPub `json:"previousBlsKeys,omitempty"`
+	PreviousBlsKeysUntil uint64                         `json:"previousBlsKeysUntil,omitempty"`
+}
+
+// ValidatorSetProof records the checkpoint header that introduced a validator
+// set and the finality votes for that header, included in its child. The votes
+// are signed by the previous validator set, so the lineage of the validator sets
+// can be audited from the genesis by following the proofs of the epochs.
+type ValidatorSetProof struct {
+	CheckpointNumber        uint64                      `json:"checkpointNumber"`
+	CheckpointHash          common.Hash                 `json:"checkpointHash"`
+	ValidatorSetHash        common.Hash                 `json:"validatorSetHash"`                           // See ValidatorSetHash
+	FinalityVotedValidators finality.FinalityVoteBitSet `json:"finalityVotedValidators,omitempty"`          // Indices in the previous validator set
+	AggregatedFinalityVotes hexutil.Bytes               `json:"aggregatedFinalityVotes,omitempty"`          // Empty if the checkpoint is not voted
+	PreviousKeyVoters       finality.FinalityVoteBitSet `json:"previousKeyVoters,omitempty" rlp:"optional"` // Voters that signed with their previous BLS key
+}
+
+// newValidatorSetProof creates the proof of the validator set introduced by the
+// checkpoint header. The child carries the finality votes for the checkpoint, it
+// is nil if the child is not known yet.
+func newValidatorSetProof(chainConfig *params.ChainConfig, checkpoint, child *types.Header, validators []finality.ValidatorWithBlsPub) (*ValidatorSetProof, error) {
+	proof := &ValidatorSetProof{
+		CheckpointNumber: checkpoint.Number.Uint64(),
+		CheckpointHash:   checkpoint.Hash(),
+		ValidatorSetHash: ValidatorSetHash(validators),
+	}
+	if child == nil || !chainConfig.IsShillin(child.Number) {
+		return proof, nil
+	}
+	extraData, err := finality.DecodeExtra(child.Extra, true)
+	if err != nil {
+		return nil, err
+	}
+	if extraData.HasFinalityVote == 1 {
+		proof.FinalityVotedValidators = extraData.FinalityVotedValidators
+		proof.AggregatedFinalityVotes = extraData.AggregatedFinalityVotes.Marshal()
+		proof.PreviousKeyVoters = extraData.PreviousKeyVoters
+	}
+	return proof, nil
 }
 
 // validatorsAscending implements the sort interface to allow sorting a list of addresses
@@ -50,7 +100,7 @@ func (s validatorsAscending) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func newSnapshot(
 	chainConfig *params.ChainConfig,
 	config *params.ConsortiumConfig,
-	sigcache *lru.ARCCache,
+	sigcache *lru.Cache,
 	number uint64,
 	hash common.Hash,
 	validators []common.Address,
@@ -62,6 +112,7 @@ func newSnapshot(
 		config:      config,
 		ethAPI:      ethAPI,
 		sigCache:    sigcache,
+		Version:     SnapshotVersion,
 		Number:      number,
 		Hash:        hash,
 		Recents:     make(map[uint64]common.Address),
@@ -83,8 +134,8 @@ func newSnapshot(
 // loadSnapshot loads an existing snapshot from the database.
 func loadSnapshot(
 	config *params.ConsortiumConfig,
-	sigcache *lru.ARCCache,
-	db ethdb.Database,
+	sigcache *lru.Cache,
+	db ethdb.KeyValueReader,
 	hash common.Hash,
 	ethAPI *ethapi.PublicBlockChainAPI,
 	chainConfig *params.ChainConfig,
@@ -97,6 +148,16 @@ func loadSnapshot(
 	if err := json.Unmarshal(blob, snap); err != nil {
 		return nil, err
 	}
+	// Upgrade the snapshots written by the previous versions of the engine
+	if snap.Version != SnapshotVersion {
+		if blob, _, err = MigrateSnapshot(blob); err != nil {
+			return nil, err
+		}
+		snap = new(Snapshot)
+		if err := json.Unmarshal(blob, snap); err != nil {
+			return nil, err
+		}
+	}
 	snap.config = config
 	snap.sigCache = sigcache
 	snap.ethAPI = ethAPI
@@ -106,7 +167,7 @@ func loadSnapshot(
 }
 
 // store inserts the snapshot into the database.
-func (s *Snapshot) store(db ethdb.Database) error {
+func (s *Snapshot) store(db ethdb.KeyValueWriter) error {
 	blob, err := json.Marshal(s)
 	if err != nil {
 		return err
@@ -114,6 +175,19 @@ func (s *Snapshot) store(db ethdb.Database) error {
 	return db.Put(append([]byte("consortium-"), s.Hash[:]...), blob)
 }
 
+// WriteShadowForkSnapshot stores the snapshot of a shadow fork at the checkpoint
+// block, replacing the validator set of the original chain. The blocks after the
+// checkpoint are sealed and verified against the new validators, the recent
+// signers and the justified block of the original chain are dropped.
+func WriteShadowForkSnapshot(db ethdb.KeyValueWriter, number uint64, hash common.Hash, validators []finality.ValidatorWithBlsPub) error {
+	sorted := make([]finality.ValidatorWithBlsPub, len(validators))
+	copy(sorted, validators)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Address[:], sorted[j].Address[:]) < 0
+	})
+	return newSnapshot(nil, nil, nil, number, hash, nil, sorted, nil).store(db)
+}
+
 // copy creates a deep copy of the snapshot.
 func (s *Snapshot) copy() *Snapshot {
 	cpy := &Snapshot{
@@ -121,11 +195,14 @@ func (s *Snapshot) copy() *Snapshot {
 		config:               s.config,
 		ethAPI:               s.ethAPI,
 		sigCache:             s.sigCache,
+		Version:              s.Version,
 		Number:               s.Number,
 		Hash:                 s.Hash,
 		Recents:              make(map[uint64]common.Address),
 		JustifiedBlockNumber: s.JustifiedBlockNumber,
 		JustifiedBlockHash:   s.JustifiedBlockHash,
+		ValidatorSetProof:    s.ValidatorSetProof, // Never modified, a new proof is created on transition
+		PreviousBlsKeysUntil: s.PreviousBlsKeysUntil,
 	}
 
 	if s.Validators != nil {
@@ -139,6 +216,10 @@ func (s *Snapshot) copy() *Snapshot {
 		cpy.ValidatorsWithBlsPub = make([]finality.ValidatorWithBlsPub, len(s.ValidatorsWithBlsPub))
 		copy(cpy.ValidatorsWithBlsPub, s.ValidatorsWithBlsPub)
 	}
+	if s.PreviousBlsKeys != nil {
+		cpy.PreviousBlsKeys = make([]finality.ValidatorWithBlsPub, len(s.PreviousBlsKeys))
+		copy(cpy.PreviousBlsKeys, s.PreviousBlsKeys)
+	}
 
 	for block, v := range s.Recents {
 		cpy.Recents[block] = v
@@ -189,7 +270,7 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 		if !snap.chainConfig.IsConsortiumV2(header.Number) {
 			validator, err = v1.Ecrecover(header, s.sigCache)
 		} else {
-			validator, err = ecrecover(header, s.sigCache, chainId)
+			validator, err = verifier.Ecrecover(header, s.sigCache, chainId)
 		}
 		if err != nil {
 			return nil, err
@@ -204,6 +285,11 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 		}
 		snap.Recents[number] = validator
 
+		// The votes signed with the replaced BLS public keys are no longer accepted
+		if snap.PreviousBlsKeys != nil && number > snap.PreviousBlsKeysUntil {
+			snap.PreviousBlsKeys, snap.PreviousBlsKeysUntil = nil, 0
+		}
+
 		if chain.Config().IsShillin(header.Number) {
 			extraData, err := finality.DecodeExtra(header.Extra, true)
 			if err != nil {
@@ -224,11 +310,19 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 
 		// Change the validator set base on the size of the validators set
 		if number > 0 && number%s.config.EpochV2 == uint64(len(snap.validators())/2) {
-			// Get the most recent checkpoint header
-			checkpointHeader := FindAncientHeader(header, uint64(len(snap.validators())/2), chain, parents)
+			// Get the most recent checkpoint header and its child, which includes the
+			// finality votes for the checkpoint
+			distance := uint64(len(snap.validators()) / 2)
+			checkpointHeader := FindAncientHeader(header, distance, chain, parents)
 			if checkpointHeader == nil {
 				return nil, consensus.ErrUnknownAncestor
 			}
+			var checkpointChild *types.Header
+			if distance > 0 {
+				if checkpointChild = FindAncientHeader(header, distance-1, chain, parents); checkpointChild == nil {
+					return nil, consensus.ErrUnknownAncestor
+				}
+			}
 
 			// this case is only happened in mock mode
 			if checkpointHeader.Number.Cmp(common.Big0) == 0 {
@@ -237,6 +331,15 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 					snap.Validators[validator] = struct{}{}
 				}
 				snap.ValidatorsWithBlsPub = nil
+				snap.PreviousBlsKeys, snap.PreviousBlsKeysUntil = nil, 0
+
+				var validators []finality.ValidatorWithBlsPub
+				for _, validator := range snap.validators() {
+					validators = append(validators, finality.ValidatorWithBlsPub{Address: validator})
+				}
+				if snap.ValidatorSetProof, err = newValidatorSetProof(chain.Config(), checkpointHeader, nil, validators); err != nil {
+					return nil, err
+				}
 			} else {
 				isShillin := chain.Config().IsShillin(checkpointHeader.Number)
 				// Get validator set from headers and use that for new validator set
@@ -253,7 +356,9 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 					}
 				}
 
+				snap.PreviousBlsKeys, snap.PreviousBlsKeysUntil = nil, 0
 				if isShillin {
+					snap.rotateBlsKeys(number, extraData.CheckpointValidators)
 					// The validator information in checkpoint header is already sorted,
 					// we don't need to sort here
 					snap.ValidatorsWithBlsPub = make([]finality.ValidatorWithBlsPub, len(extraData.CheckpointValidators))
@@ -266,6 +371,9 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 					}
 					snap.ValidatorsWithBlsPub = nil
 				}
+				if snap.ValidatorSetProof, err = newValidatorSetProof(chain.Config(), checkpointHeader, checkpointChild, extraData.CheckpointValidators); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
@@ -304,14 +412,65 @@ func (s *Snapshot) inInValidatorSet(address common.Address) bool {
 	return false
 }
 
+// inBlsPublicKeySet returns whether the public key is the BLS public key of a
+// validator, or its previous key within the rotation overlap
 func (s *Snapshot) inBlsPublicKeySet(publicKey blsCommon.PublicKey) bool {
-	for _, validator := range s.ValidatorsWithBlsPub {
+	return s.blsPublicKeyPosition(publicKey) >= 0
+}
+
+// blsPublicKeyPosition returns the position of the validator whose BLS public
+// key, or previous key within the rotation overlap, is the public key, -1 if
+// there is none
+func (s *Snapshot) blsPublicKeyPosition(publicKey blsCommon.PublicKey) int {
+	for position, validator := range s.ValidatorsWithBlsPub {
 		if validator.BlsPublicKey.Equals(publicKey) {
-			return true
+			return position
+		}
+	}
+	for _, replaced := range s.previousBlsKeys() {
+		if !replaced.BlsPublicKey.Equals(publicKey) {
+			continue
+		}
+		for position, validator := range s.ValidatorsWithBlsPub {
+			if validator.Address == replaced.Address {
+				return position
+			}
 		}
 	}
+	return -1
+}
 
-	return false
+// previousBlsKeys returns the BLS public keys replaced at the last validator
+// set transition if the snapshot is still in the rotation overlap
+func (s *Snapshot) previousBlsKeys() []finality.ValidatorWithBlsPub {
+	if s.Number > s.PreviousBlsKeysUntil {
+		return nil
+	}
+	return s.PreviousBlsKeys
+}
+
+// rotateBlsKeys records the BLS public keys of the current validators that are
+// replaced in the new validator set at the transition block number, if the
+// rotation overlap is enabled and Orca is active at the transition
+func (s *Snapshot) rotateBlsKeys(number uint64, validators []finality.ValidatorWithBlsPub) {
+	if s.config.BlsKeyRotationOverlap == 0 || !s.chainConfig.IsOrca(new(big.Int).SetUint64(number)) {
+		return
+	}
+	var previous []finality.ValidatorWithBlsPub
+	for _, current := range s.ValidatorsWithBlsPub {
+		if current.BlsPublicKey == nil {
+			continue
+		}
+		for _, validator := range validators {
+			if validator.Address == current.Address && validator.BlsPublicKey != nil && !validator.BlsPublicKey.Equals(current.BlsPublicKey) {
+				previous = append(previous, current)
+				break
+			}
+		}
+	}
+	if len(previous) > 0 {
+		s.PreviousBlsKeys, s.PreviousBlsKeysUntil = previous, number+s.config.BlsKeyRotationOverlap
+	}
 }
 
 // inturn returns if a validator at a given block height is in-turn or not.
@@ -0,0 +1,347 @@
+package v2
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// SnapshotArchiveDir is the directory of the snapshot archive in the data
+	// directory of the node
+	SnapshotArchiveDir = "consortium-snapshot-archive"
+
+	// maxArchivedSnapshots is the maximum number of snapshots moved to the
+	// archive in one run, so the first run on a long-running node does not stall
+	// the others
+	maxArchivedSnapshots = 1024
+)
+
+// errSnapshotNotArchived is returned if the snapshot is not in the archive
+var errSnapshotNotArchived = errors.New("snapshot not archived")
+
+// ancientTable is the append-only table the archived snapshots are stored in
+type ancientTable interface {
+	AppendRaw(blob []byte) (uint64, error)
+	Retrieve(item uint64) ([]byte, error)
+	Items() uint64
+	Sync() error
+	Close() error
+}
+
+// SnapshotArchive stores the finalized checkpoint snapshots, which never change,
+// in an append-only ancient table instead of the key-value store. The item i is
+// the canonical snapshot at the i-th epoch block after consortium v2, an empty
+// item stands for a snapshot which was never stored.
+type SnapshotArchive struct {
+	lock  sync.RWMutex
+	table ancientTable
+}
+
+// OpenSnapshotArchive opens the snapshot archive in the directory, creating it
+// if it does not exist
+func OpenSnapshotArchive(dir string) (*SnapshotArchive, error) {
+	table, err := rawdb.NewFreezerTable(dir, "snapshots", false)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotArchive{table: table}, nil
+}
+
+// Close closes the archive table
+func (a *SnapshotArchive) Close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.table.Close()
+}
+
+// Items returns the number of epochs in the archive
+func (a *SnapshotArchive) Items() uint64 {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.table.Items()
+}
+
+// Retrieve returns the encoded snapshot of the item, nil if the snapshot was
+// never stored
+func (a *SnapshotArchive) Retrieve(item uint64) ([]byte, error) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if item >= a.table.Items() {
+		return nil, errSnapshotNotArchived
+	}
+	return a.table.Retrieve(item)
+}
+
+// Append adds the encoded snapshots of the next epochs to the archive and
+// flushes them to disk
+func (a *SnapshotArchive) Append(blobs [][]byte) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for _, blob := range blobs {
+		if _, err := a.table.AppendRaw(blob); err != nil {
+			return err
+		}
+	}
+	return a.table.Sync()
+}
+
+// archivedRecent is a recent signer of an archived snapshot
+type archivedRecent struct {
+	Number uint64
+	Signer common.Address
+}
+
+// archivedValidator is a validator of an archived snapshot after Shillin
+type archivedValidator struct {
+	Address      common.Address
+	BlsPublicKey []byte
+}
+
+// archivedSnapshot is the compact binary encoding of the archived snapshots,
+// the addresses and keys are stored raw instead of hex-encoded JSON
+type archivedSnapshot struct {
+	Number               uint64
+	Hash                 common.Hash
+	Validators           []common.Address
+	Recents              []archivedRecent
+	BlsValidators        []archivedValidator
+	JustifiedBlockNumber uint64
+	JustifiedBlockHash   common.Hash
+	ValidatorSetProof    *ValidatorSetProof  `rlp:"nil"`
+	PreviousBlsKeys      []archivedValidator `rlp:"optional"`
+	PreviousBlsKeysUntil uint64              `rlp:"optional"`
+}
+
+// encodeArchivedSnapshot encodes the snapshot in the archive format
+func encodeArchivedSnapshot(snap *Snapshot) ([]byte, error) {
+	archived := archivedSnapshot{
+		Number:               snap.Number,
+		Hash:                 snap.Hash,
+		JustifiedBlockNumber: snap.JustifiedBlockNumber,
+		JustifiedBlockHash:   snap.JustifiedBlockHash,
+		ValidatorSetProof:    snap.ValidatorSetProof,
+		PreviousBlsKeysUntil: snap.PreviousBlsKeysUntil,
+	}
+	if snap.Validators != nil {
+		archived.Validators = snap.validators()
+	}
+	for number, signer := range snap.Recents {
+		archived.Recents = append(archived.Recents, archivedRecent{Number: number, Signer: signer})
+	}
+	sort.Slice(archived.Recents, func(i, j int) bool { return archived.Recents[i].Number < archived.Recents[j].Number })
+	for _, validator := range snap.ValidatorsWithBlsPub {
+		archived.BlsValidators = append(archived.BlsValidators, archivedValidator{
+			Address:      validator.Address,
+			BlsPublicKey: validator.BlsPublicKey.Marshal(),
+		})
+	}
+	for _, validator := range snap.PreviousBlsKeys {
+		archived.PreviousBlsKeys = append(archived.PreviousBlsKeys, archivedValidator{
+			Address:      validator.Address,
+			BlsPublicKey: validator.BlsPublicKey.Marshal(),
+		})
+	}
+	return rlp.EncodeToBytes(&archived)
+}
+
+// decodeArchivedSnapshot decodes the snapshot from the archive format
+func (c *Consortium) decodeArchivedSnapshot(blob []byte) (*Snapshot, error) {
+	var archived archivedSnapshot
+	if err := rlp.DecodeBytes(blob, &archived); err != nil {
+		return nil, err
+	}
+	validatorsWithBlsPub, err := decodeArchivedValidators(archived.BlsValidators)
+	if err != nil {
+		return nil, err
+	}
+	previousBlsKeys, err := decodeArchivedValidators(archived.PreviousBlsKeys)
+	if err != nil {
+		return nil, err
+	}
+	var validators []common.Address
+	if len(archived.Validators) > 0 {
+		validators = archived.Validators
+	}
+	snap := newSnapshot(c.chainConfig, c.config, c.signatures, archived.Number, archived.Hash, validators, validatorsWithBlsPub, c.ethAPI)
+	for _, recent := range archived.Recents {
+		snap.Recents[recent.Number] = recent.Signer
+	}
+	snap.JustifiedBlockNumber = archived.JustifiedBlockNumber
+	snap.JustifiedBlockHash = archived.JustifiedBlockHash
+	snap.ValidatorSetProof = archived.ValidatorSetProof
+	snap.PreviousBlsKeys = previousBlsKeys
+	snap.PreviousBlsKeysUntil = archived.PreviousBlsKeysUntil
+	return snap, nil
+}
+
+// decodeArchivedValidators decodes the BLS public keys of the validators, nil
+// if there is none
+func decodeArchivedValidators(archived []archivedValidator) ([]finality.ValidatorWithBlsPub, error) {
+	if len(archived) == 0 {
+		return nil, nil
+	}
+	validators := make([]finality.ValidatorWithBlsPub, len(archived))
+	for i, validator := range archived {
+		publicKey, err := blst.PublicKeyFromBytes(validator.BlsPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		validators[i] = finality.ValidatorWithBlsPub{Address: validator.Address, BlsPublicKey: publicKey}
+	}
+	return validators, nil
+}
+
+// SetSnapshotArchive moves the finalized checkpoint snapshots to the archive
+// when StartSnapshotArchiving runs and reads the archived snapshots from it. It
+// must be set before the engine verifies or seals any block.
+func (c *Consortium) SetSnapshotArchive(archive *SnapshotArchive) {
+	c.snapshotArchive = archive
+}
+
+// firstArchivedEpoch returns the number of the first epoch block after
+// consortium v2, which is the item 0 of the archive
+func (c *Consortium) firstArchivedEpoch() uint64 {
+	epoch := c.config.EpochV2
+	if c.forkedBlock == 0 {
+		return 0
+	}
+	return (c.forkedBlock - 1 + epoch - 1) / epoch * epoch
+}
+
+// readArchivedSnapshot loads the snapshot at the epoch block from the archive
+func (c *Consortium) readArchivedSnapshot(number uint64, hash common.Hash) (*Snapshot, error) {
+	first := c.firstArchivedEpoch()
+	if c.snapshotArchive == nil || number < first || number%c.config.EpochV2 != 0 {
+		return nil, errSnapshotNotArchived
+	}
+	blob, err := c.snapshotArchive.Retrieve((number - first) / c.config.EpochV2)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) == 0 {
+		return nil, errSnapshotNotArchived
+	}
+	snap, err := c.decodeArchivedSnapshot(blob)
+	if err != nil {
+		return nil, err
+	}
+	// Only the canonical snapshots are archived
+	if snap.Hash != hash {
+		return nil, errSnapshotNotArchived
+	}
+	return snap, nil
+}
+
+// ArchiveSnapshots moves the canonical checkpoint snapshots whose next epoch
+// block is finalized from the key-value store to the archive, the snapshots
+// which are not stored are recorded as empty items. It returns the number of
+// epochs archived, at most maxArchivedSnapshots.
+func (c *Consortium) ArchiveSnapshots(chain consensus.ChainHeaderReader, finalized uint64) (int, error) {
+	if c.snapshotArchive == nil {
+		return 0, nil
+	}
+	var (
+		epoch  = c.config.EpochV2
+		first  = c.firstArchivedEpoch()
+		next   = first + c.snapshotArchive.Items()*epoch
+		blobs  [][]byte
+		hashes []common.Hash
+	)
+	for ; next+epoch <= finalized && len(blobs) < maxArchivedSnapshots; next += epoch {
+		header := chain.GetHeaderByNumber(next)
+		if header == nil {
+			break
+		}
+		hash := header.Hash()
+		snap, err := c.readSnapshot(next, hash)
+		if err != nil {
+			blobs = append(blobs, nil)
+			hashes = append(hashes, common.Hash{})
+			continue
+		}
+		blob, err := encodeArchivedSnapshot(snap)
+		if err != nil {
+			return 0, err
+		}
+		blobs = append(blobs, blob)
+		hashes = append(hashes, hash)
+	}
+	if len(blobs) == 0 {
+		return 0, nil
+	}
+	if err := c.snapshotArchive.Append(blobs); err != nil {
+		return 0, err
+	}
+
+	// The snapshots are only deleted once they are flushed in the archive
+	for _, hash := range hashes {
+		if hash == (common.Hash{}) {
+			continue
+		}
+		key := append([]byte("consortium-"), hash[:]...)
+		if c.snapshotStore != nil {
+			if err := c.snapshotStore.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+		if err := c.db.Delete(key); err != nil {
+			return 0, err
+		}
+	}
+	return len(blobs), nil
+}
+
+// StartSnapshotArchiving archives the finalized checkpoint snapshots as the
+// chain head moves in the background until the engine is closed. The archive
+// must only be closed once the engine is closed.
+func (c *Consortium) StartSnapshotArchiving(chain *core.BlockChain) {
+	if c.snapshotArchive == nil {
+		return
+	}
+	log.Info("Starting consortium snapshot archiving", "archived", c.snapshotArchive.Items())
+
+	chainHeadCh := make(chan core.ChainHeadEvent, 10)
+	chainHeadSub := chain.SubscribeChainHeadEvent(chainHeadCh)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer chainHeadSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-chainHeadCh:
+				head := ev.Block.Header()
+				if !c.chainConfig.IsShillin(head.Number) {
+					continue
+				}
+				finalized, _ := c.GetFinalizedBlock(chain, head.Number.Uint64(), head.Hash())
+				if finalized == 0 {
+					continue
+				}
+				archived, err := c.ArchiveSnapshots(chain, finalized)
+				if err != nil {
+					log.Warn("Failed to archive consortium snapshots", "finalized", finalized, "err", err)
+					continue
+				}
+				if archived > 0 {
+					log.Info("Archived consortium snapshots", "count", archived, "finalized", finalized)
+				}
+			case <-chainHeadSub.Err():
+				return
+			case <-c.quit:
+				return
+			}
+		}
+	}()
+}
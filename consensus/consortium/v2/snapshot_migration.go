@@ -0,0 +1,150 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// SnapshotVersion is the version of the snapshot format written by the engine,
+// the snapshots of a lower version are migrated when they are loaded
+const SnapshotVersion = 1
+
+// errUnknownSnapshotVersion is returned if a snapshot is written by a newer
+// version of the engine
+var errUnknownSnapshotVersion = errors.New("unknown snapshot version")
+
+// snapshotMigration upgrades the fields of a snapshot blob to the next version
+type snapshotMigration func(fields map[string]json.RawMessage) error
+
+// snapshotMigrations holds the migration of each version to the next one, indexed
+// by the version it upgrades from. A change of the snapshot format appends a
+// migration and bumps SnapshotVersion.
+var snapshotMigrations = []snapshotMigration{
+	migrateSignerSet, // 0 -> 1
+}
+
+// MigrateSnapshot upgrades the snapshot blob to SnapshotVersion, it reports
+// whether the blob is changed
+func MigrateSnapshot(blob []byte) ([]byte, bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, false, err
+	}
+	var version uint64
+	if raw, ok := fields["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return nil, false, err
+		}
+	}
+	if version > SnapshotVersion {
+		return nil, false, fmt.Errorf("%w: version %d, supported %d", errUnknownSnapshotVersion, version, SnapshotVersion)
+	}
+	if version == SnapshotVersion {
+		return blob, false, nil
+	}
+	for ; version < SnapshotVersion; version++ {
+		if err := snapshotMigrations[version](fields); err != nil {
+			return nil, false, fmt.Errorf("failed to migrate snapshot from version %d: %w", version, err)
+		}
+	}
+	fields["version"] = json.RawMessage(strconv.FormatUint(SnapshotVersion, 10))
+	blob, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false, err
+	}
+	return blob, true, nil
+}
+
+// MigrateSnapshots rewrites the snapshots of the database in SnapshotVersion and
+// returns the number of rewritten snapshots. The snapshots written by the v1
+// engine are left untouched as the v1 engine still reads them, they are migrated
+// when the v2 engine loads them at the fork block.
+func MigrateSnapshots(db ethdb.KeyValueStore) (int, error) {
+	it := db.NewIterator(rawdb.ConsortiumSnapshotPrefix, nil)
+	defer it.Release()
+
+	var (
+		batch    = db.NewBatch()
+		migrated int
+	)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(rawdb.ConsortiumSnapshotPrefix)+common.HashLength {
+			continue
+		}
+		if isV1Snapshot(it.Value()) {
+			continue
+		}
+		blob, changed, err := MigrateSnapshot(it.Value())
+		if err != nil {
+			return migrated, fmt.Errorf("snapshot %x: %w", key[len(rawdb.ConsortiumSnapshotPrefix):], err)
+		}
+		if !changed {
+			continue
+		}
+		if err := batch.Put(common.CopyBytes(key), blob); err != nil {
+			return migrated, err
+		}
+		migrated++
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return migrated, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return migrated, err
+	}
+	return migrated, batch.Write()
+}
+
+// isV1Snapshot reports whether the snapshot blob is written by the v1 engine
+func isV1Snapshot(blob []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return false
+	}
+	_, ok := fields["signerSet"]
+	return ok
+}
+
+// migrateSignerSet upgrades the unversioned snapshots. The snapshots written by
+// the v2 engine already have the shape of version 1, only the ones written by the
+// v1 engine, which is read at the fork block, hold the validators in their signer
+// set and need the outdated recents of v1 to be cleaned up.
+func migrateSignerSet(fields map[string]json.RawMessage) error {
+	signerSet, ok := fields["signerSet"]
+	if !ok {
+		return nil
+	}
+	delete(fields, "signerSet")
+	delete(fields, "signerList")
+	fields["validators"] = signerSet
+
+	var (
+		number  uint64
+		recents map[uint64]common.Address
+	)
+	if err := json.Unmarshal(fields["number"], &number); err != nil {
+		return err
+	}
+	if raw, ok := fields["recents"]; ok {
+		if err := json.Unmarshal(raw, &recents); err != nil {
+			return err
+		}
+	}
+	blob, err := json.Marshal(consortiumCommon.RemoveOutdatedRecents(recents, number))
+	if err != nil {
+		return err
+	}
+	fields["recents"] = blob
+	return nil
+}
@@ -0,0 +1,165 @@
+package v2
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/verifier"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// errStatsBeforeV2 is returned if the statistics of an epoch that is not entirely
+// after consortium v2 are requested
+var errStatsBeforeV2 = errors.New("epoch is before consortium v2")
+
+// ValidatorStats is the participation of a validator in an epoch
+type ValidatorStats struct {
+	Address       common.Address `json:"address"`
+	Sealed        uint64         `json:"sealed"`                      // Blocks sealed by the validator
+	SealedInTurn  uint64         `json:"sealedInTurn"`                // Blocks sealed by the validator in turn
+	FinalityVotes uint64         `json:"finalityVotes"`               // Blocks including the finality vote of the validator
+	MissedInTurn  uint64         `json:"missedInTurn" rlp:"optional"` // In-turn slots of the validator sealed by another one
+}
+
+// EpochStats is the finality and participation statistics of an epoch, computed
+// from the canonical headers
+type EpochStats struct {
+	Epoch      uint64 `json:"epoch"`
+	FirstBlock uint64 `json:"firstBlock"`
+	LastBlock  uint64 `json:"lastBlock"` // Before the end of the epoch if it was not complete when indexed
+
+	Blocks              uint64           `json:"blocks"`
+	FinalityVotedBlocks uint64           `json:"finalityVotedBlocks"` // Blocks including the finality votes for their parent
+	Validators          []ValidatorStats `json:"validators"`          // Sorted by address
+}
+
+// ComputeEpochStats walks the canonical headers of the epoch up to the head and
+// computes its statistics from their seals, difficulties and finality votes. The
+// positions of the finality votes and the missed in-turn slots are resolved
+// against the snapshots, which are rebuilt from the headers if they are not
+// stored.
+func (c *Consortium) ComputeEpochStats(chain consensus.ChainHeaderReader, epoch uint64) (*EpochStats, error) {
+	first := epoch * c.config.EpochV2
+	if first <= c.forkedBlock {
+		return nil, errStatsBeforeV2
+	}
+	last := first + c.config.EpochV2 - 1
+	if head := chain.CurrentHeader().Number.Uint64(); last > head {
+		if first > head {
+			return nil, consortiumCommon.ErrUnknownBlock
+		}
+		last = head
+	}
+
+	stats := &EpochStats{Epoch: epoch, FirstBlock: first, LastBlock: last}
+	validators := make(map[common.Address]*ValidatorStats)
+	validatorStats := func(address common.Address) *ValidatorStats {
+		if _, ok := validators[address]; !ok {
+			validators[address] = &ValidatorStats{Address: address}
+		}
+		return validators[address]
+	}
+	for number := first; number <= last; number++ {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, consortiumCommon.ErrUnknownBlock
+		}
+		sealer, err := verifier.Ecrecover(header, c.signatures, c.chainConfig.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		stats.Blocks++
+		sealerStats := validatorStats(sealer)
+		sealerStats.Sealed++
+		if header.Difficulty.Cmp(diffInTurn) == 0 {
+			sealerStats.SealedInTurn++
+		} else {
+			snap, err := c.snapshot(chain, number-1, header.ParentHash, nil)
+			if err != nil {
+				return nil, err
+			}
+			if spoiledVal, spoiled := c.spoiledValidator(snap, header); spoiled {
+				validatorStats(spoiledVal).MissedInTurn++
+			}
+		}
+
+		if !c.chainConfig.IsShillin(header.Number) {
+			continue
+		}
+		extraData, err := finality.DecodeExtra(header.Extra, true)
+		if err != nil {
+			return nil, err
+		}
+		if extraData.HasFinalityVote != 1 {
+			continue
+		}
+		stats.FinalityVotedBlocks++
+		voters := c.GetActiveValidatorAt(chain, number-1, header.ParentHash)
+		for _, position := range extraData.FinalityVotedValidators.Indices() {
+			if position < len(voters) {
+				validatorStats(voters[position].Address).FinalityVotes++
+			}
+		}
+	}
+
+	stats.Validators = make([]ValidatorStats, 0, len(validators))
+	for _, validator := range validators {
+		stats.Validators = append(stats.Validators, *validator)
+	}
+	sort.Slice(stats.Validators, func(i, j int) bool {
+		return bytes.Compare(stats.Validators[i].Address[:], stats.Validators[j].Address[:]) < 0
+	})
+	return stats, nil
+}
+
+// BackfillStats computes the statistics of the epochs of the blocks from and to
+// and writes them to the stats index of the chain database, replacing the
+// indexed ones. The range is clamped to the head. It returns the number of
+// indexed epochs.
+func (c *Consortium) BackfillStats(chain consensus.ChainHeaderReader, from, to uint64) (int, error) {
+	if head := chain.CurrentHeader().Number.Uint64(); to > head {
+		to = head
+	}
+	if from > to {
+		return 0, errors.New("invalid block range")
+	}
+	indexed := 0
+	for epoch := from / c.config.EpochV2; epoch <= to/c.config.EpochV2; epoch++ {
+		stats, err := c.ComputeEpochStats(chain, epoch)
+		if err == errStatsBeforeV2 {
+			continue
+		}
+		if err != nil {
+			return indexed, err
+		}
+		data, err := rlp.EncodeToBytes(stats)
+		if err != nil {
+			return indexed, err
+		}
+		rawdb.WriteConsortiumStats(c.db, epoch, data)
+		indexed++
+		log.Info("Indexed consortium epoch stats", "epoch", epoch, "blocks", stats.Blocks, "finalityVoted", stats.FinalityVotedBlocks)
+	}
+	return indexed, nil
+}
+
+// readEpochStats returns the indexed statistics of the epoch, nil if the epoch
+// is not indexed
+func (c *Consortium) readEpochStats(epoch uint64) (*EpochStats, error) {
+	data := rawdb.ReadConsortiumStats(c.db, epoch)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	stats := new(EpochStats)
+	if err := rlp.DecodeBytes(data, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
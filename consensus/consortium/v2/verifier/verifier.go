@@ -0,0 +1,361 @@
+// Package verifier implements the verification of the Ronin consortium v2 block
+// headers without access to the chain database. The validator set authorizing
+// a header is provided by the caller, so bridges, indexers and light clients can
+// verify the headers they receive against the validator sets they track.
+package verifier
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW
+
+	diffInTurn = big.NewInt(7) // Block difficulty for in-turn signatures
+	diffNoTurn = big.NewInt(3) // Block difficulty for out-of-turn signatures
+)
+
+var (
+	// ErrUnauthorizedValidator is returned if a header is signed by a non-authorized entity.
+	ErrUnauthorizedValidator = errors.New("unauthorized validator")
+
+	// ErrCoinBaseMisMatch is returned if a header's coinbase do not match with signature
+	ErrCoinBaseMisMatch = errors.New("coinbase do not match with signature")
+)
+
+// ValidatorSet is the validator set authorizing the child of a block
+type ValidatorSet struct {
+	// Validators are sorted by address in ascending order, the BLS public keys
+	// are only required after Shillin
+	Validators []finality.ValidatorWithBlsPub
+
+	// Recents are the recent signers keyed by block number, the recently
+	// signed rule is not verified if it is nil
+	Recents map[uint64]common.Address
+
+	// JustifiedBlockNumber and JustifiedBlockHash are the justified block as of
+	// the block, they are the source of the finality votes after Tripp
+	JustifiedBlockNumber uint64
+	JustifiedBlockHash   common.Hash
+
+	// PreviousBlsKeys are the replaced BLS public keys by validator address
+	// still accepted for the finality votes in the key rotation overlap
+	PreviousBlsKeys []finality.ValidatorWithBlsPub
+}
+
+func (set *ValidatorSet) contains(address common.Address) bool {
+	for _, validator := range set.Validators {
+		if validator.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// inturn returns if a validator is in-turn to seal the block at number
+func (set *ValidatorSet) inturn(number uint64, validator common.Address) bool {
+	offset := number % uint64(len(set.Validators))
+	return set.Validators[offset].Address == validator
+}
+
+// recentlySigned returns if a validator is not allowed to seal the block at
+// number as it signed one of the recent blocks
+func (set *ValidatorSet) recentlySigned(number uint64, validator common.Address) bool {
+	for seen, recent := range set.Recents {
+		if recent == validator {
+			if limit := uint64(len(set.Validators)/2 + 1); seen > number-limit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidatorSetProvider provides the validator set authorizing the child of a
+// block, e.g. from the checkpoint headers tracked by a light client.
+type ValidatorSetProvider interface {
+	ValidatorSetAt(number uint64, hash common.Hash) (*ValidatorSet, error)
+}
+
+// Verifier verifies the consortium v2 headers against the validator sets from
+// a ValidatorSetProvider.
+type Verifier struct {
+	chainConfig *params.ChainConfig
+	config      *params.ConsortiumConfig
+	validators  ValidatorSetProvider
+	signatures  *lru.Cache // Sealers of recent blocks shared by the process, see consortiumCommon.Sealers
+}
+
+// New creates a verifier of the chain. The chain config must have the
+// consortium config.
+func New(chainConfig *params.ChainConfig, validators ValidatorSetProvider) *Verifier {
+	return &Verifier{
+		chainConfig: chainConfig,
+		config:      chainConfig.Consortium,
+		validators:  validators,
+		signatures:  consortiumCommon.Sealers,
+	}
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules given its
+// parent header. The header time is not verified, neither against the local
+// clock nor against the out-of-turn backoff, the caller decides which block
+// times it accepts.
+func (v *Verifier) VerifyHeader(header, parent *types.Header) error {
+	if header.Number == nil {
+		return consortiumCommon.ErrUnknownBlock
+	}
+	// Verifying the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return consortiumCommon.ErrUnknownBlock
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+
+	isShillin := v.chainConfig.IsShillin(header.Number)
+	extraData, err := finality.DecodeExtra(header.Extra, isShillin)
+	if err != nil {
+		return err
+	}
+	isEpoch := number%v.config.EpochV2 == 0 || v.chainConfig.IsOnConsortiumV2(header.Number)
+	if !isEpoch && len(extraData.CheckpointValidators) != 0 {
+		return consortiumCommon.ErrExtraValidators
+	}
+
+	// Ensure that the mix digest is zero as we don't have fork protection currently
+	if header.MixDigest != (common.Hash{}) {
+		return consortiumCommon.ErrInvalidMixDigest
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in PoA
+	if header.UncleHash != uncleHash {
+		return consortiumCommon.ErrInvalidUncleHash
+	}
+	if header.Difficulty == nil {
+		return consortiumCommon.ErrInvalidDifficulty
+	}
+	if err := misc.VerifyForkHashes(v.chainConfig, header, false); err != nil {
+		return err
+	}
+
+	// Verify that the gas limit is <= 2^63-1
+	capacity := uint64(0x7fffffffffffffff)
+	if header.GasLimit > capacity {
+		return fmt.Errorf("invalid gasLimit: have %v, max %v", header.GasLimit, capacity)
+	}
+	// Verify that the gasUsed is <= gasLimit
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
+	}
+	if err := misc.VerifyGaslimit(parent.GasLimit, header.GasLimit); err != nil {
+		return err
+	}
+
+	set, err := v.validators.ValidatorSetAt(number-1, header.ParentHash)
+	if err != nil {
+		return err
+	}
+	if len(set.Validators) == 0 {
+		return ErrUnauthorizedValidator
+	}
+	if isShillin && extraData.HasFinalityVote == 1 {
+		digest := types.NewVoteData(v.chainConfig, number-1, header.ParentHash, set.JustifiedBlockNumber, set.JustifiedBlockHash).Hash()
+		if err := VerifyRotatedFinalitySignatures(
+			set.Validators,
+			set.PreviousBlsKeys,
+			extraData.FinalityVotedValidators,
+			extraData.PreviousKeyVoters,
+			extraData.AggregatedFinalityVotes,
+			digest,
+			v.config.FinalityThreshold(header.Number, len(set.Validators)),
+		); err != nil {
+			return err
+		}
+	}
+	return v.verifySeal(header, set)
+}
+
+// verifySeal checks whether the signature contained in the header is from an
+// authorized validator with the difficulty of its turn
+func (v *Verifier) verifySeal(header *types.Header, set *ValidatorSet) error {
+	signer, err := Ecrecover(header, v.signatures, v.chainConfig.ChainID)
+	if err != nil {
+		return err
+	}
+	if signer != header.Coinbase {
+		return ErrCoinBaseMisMatch
+	}
+	if !set.contains(signer) {
+		return ErrUnauthorizedValidator
+	}
+
+	number := header.Number.Uint64()
+	if set.recentlySigned(number, signer) {
+		return consortiumCommon.ErrRecentlySigned
+	}
+	inturn := set.inturn(number, signer)
+	if inturn && header.Difficulty.Cmp(diffInTurn) != 0 {
+		return consortiumCommon.ErrWrongDifficulty
+	}
+	if !inturn && header.Difficulty.Cmp(diffNoTurn) != 0 {
+		return consortiumCommon.ErrWrongDifficulty
+	}
+	return nil
+}
+
+// VerifyFinalitySignatures verifies the aggregated finality signature of the
+// voted validators, as positions in the validators, on the vote digest. The
+// threshold is the minimum number of votes, see params.ConsortiumConfig.FinalityThreshold.
+func VerifyFinalitySignatures(
+	validators []finality.ValidatorWithBlsPub,
+	votedValidators finality.FinalityVoteBitSet,
+	signature blsCommon.Signature,
+	digest common.Hash,
+	threshold int,
+) error {
+	votedValidatorPositions := votedValidators.Indices()
+	if len(votedValidatorPositions) < threshold {
+		return finality.ErrNotEnoughFinalityVote
+	}
+
+	var publicKeys []blsCommon.PublicKey
+	for _, position := range votedValidatorPositions {
+		if position >= len(validators) || validators[position].BlsPublicKey == nil {
+			return finality.ErrInvalidFinalityVotedBitSet
+		}
+		publicKeys = append(publicKeys, validators[position].BlsPublicKey)
+	}
+	if !signature.FastAggregateVerify(publicKeys, digest) {
+		return finality.ErrFinalitySignatureVerificationFailed
+	}
+	return nil
+}
+
+// VerifyRotatedFinalitySignatures verifies the aggregated finality signature as
+// VerifyFinalitySignatures, the previous key voters are the voters that signed
+// with their previous BLS key in previous, which holds the replaced keys by
+// validator address.
+func VerifyRotatedFinalitySignatures(
+	validators []finality.ValidatorWithBlsPub,
+	previous []finality.ValidatorWithBlsPub,
+	votedValidators finality.FinalityVoteBitSet,
+	previousKeyVoters finality.FinalityVoteBitSet,
+	signature blsCommon.Signature,
+	digest common.Hash,
+	threshold int,
+) error {
+	positions := previousKeyVoters.Indices()
+	if len(positions) == 0 {
+		return VerifyFinalitySignatures(validators, votedValidators, signature, digest, threshold)
+	}
+	rotated := make([]finality.ValidatorWithBlsPub, len(validators))
+	copy(rotated, validators)
+	for _, position := range positions {
+		if !votedValidators.Has(position) || position >= len(validators) {
+			return finality.ErrInvalidFinalityVotedBitSet
+		}
+		var key blsCommon.PublicKey
+		for _, replaced := range previous {
+			if replaced.Address == validators[position].Address {
+				key = replaced.BlsPublicKey
+				break
+			}
+		}
+		if key == nil {
+			return finality.ErrInvalidFinalityVotedBitSet
+		}
+		rotated[position].BlsPublicKey = key
+	}
+	return VerifyFinalitySignatures(rotated, votedValidators, signature, digest, threshold)
+}
+
+// Ecrecover extracts the Ronin account address from a signed header.
+func Ecrecover(header *types.Header, sigcache *lru.Cache, chainId *big.Int) (common.Address, error) {
+	// If the signature's already cached, return that
+	key := consortiumCommon.NewSealerKey(chainId, header.Hash())
+	if address, known := sigcache.Get(key); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	if len(header.Extra) < consortiumCommon.ExtraSeal {
+		return common.Address{}, consortiumCommon.ErrMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:]
+
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.Ecrecover(SealHash(header, chainId).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	sigcache.Add(key, signer)
+	return signer, nil
+}
+
+// SealHash returns the hash of a block prior to it being sealed.
+func SealHash(header *types.Header, chainId *big.Int) (hash common.Hash) {
+	hasher := sha3.NewLegacyKeccak256()
+	encodeSigHeader(hasher, header, chainId)
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// ConsortiumRLP returns the rlp bytes which needs to be signed for the proof-of-authority
+// sealing. The RLP to sign consists of the entire header apart from the 65 byte signature
+// contained at the end of the extra data.
+//
+// Note, the method requires the extra data to be at least 65 bytes, otherwise it
+// panics. This is done to avoid accidentally using both forms (signature present
+// or not), which could be abused to produce different hashes for the same header.
+func ConsortiumRLP(header *types.Header, chainId *big.Int) []byte {
+	b := new(bytes.Buffer)
+	encodeSigHeader(b, header, chainId)
+	return b.Bytes()
+}
+
+// encodeSigHeader encodes the whole header with chainId.
+// chainID was introduced in EIP-155 to prevent replay attacks between the main ETH and ETC chains,
+// which both have a networkID of 1
+func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
+	err := rlp.Encode(w, []interface{}{
+		chainId,
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-consortiumCommon.ExtraSeal], // Yes, this will panic if extra is too short
+		header.MixDigest,
+		header.Nonce,
+	})
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+}
@@ -0,0 +1,166 @@
+package verifier
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality/finalitytest"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	blsCommon "github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type staticValidatorSet struct {
+	set *ValidatorSet
+}
+
+func (provider *staticValidatorSet) ValidatorSetAt(uint64, common.Hash) (*ValidatorSet, error) {
+	return provider.set, nil
+}
+
+func TestVerifyHeader(t *testing.T) {
+	validators, err := finalitytest.NewValidatorSet(3)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	chainConfig := &params.ChainConfig{
+		ChainID:      big.NewInt(2021),
+		ShillinBlock: common.Big0,
+		Consortium:   &params.ConsortiumConfig{EpochV2: 200},
+	}
+	provider := &staticValidatorSet{set: &ValidatorSet{Validators: validators.WithBlsPub()}}
+	v := New(chainConfig, provider)
+
+	parent := &types.Header{
+		Number:   big.NewInt(10),
+		GasLimit: 100000000,
+	}
+	// newHeader returns the header at 11 sealed by the validator at position
+	newHeader := func(position int, extra *finalitytest.ExtraDataBuilder) *types.Header {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			UncleHash:  uncleHash,
+			Coinbase:   validators[position].Address,
+			Number:     big.NewInt(11),
+			GasLimit:   parent.GasLimit,
+			Difficulty: diffNoTurn,
+			Extra:      extra.Encode(),
+		}
+		if position == 11%len(validators) {
+			header.Difficulty = diffInTurn
+		}
+		sig, err := crypto.Sign(SealHash(header, chainConfig.ChainID).Bytes(), validators[position].Key)
+		if err != nil {
+			t.Fatalf("Failed to seal header, err %s", err)
+		}
+		copy(header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:], sig)
+		return header
+	}
+
+	header := newHeader(0, finalitytest.NewExtraDataBuilder(chainConfig).WithFinalityVotes(validators, 10, parent.Hash(), finalitytest.Source{}, 0, 1, 2))
+	if err := v.VerifyHeader(header, parent); err != nil {
+		t.Fatalf("Failed to verify header, err %s", err)
+	}
+	if sealer, known := consortiumCommon.Sealers.Get(consortiumCommon.NewSealerKey(chainConfig.ChainID, header.Hash())); !known || sealer.(common.Address) != validators[0].Address {
+		t.Fatalf("Sealer cache, expect %s have %v", validators[0].Address, sealer)
+	}
+	// The same header sealed on another chain has another sealer
+	if _, known := consortiumCommon.Sealers.Get(consortiumCommon.NewSealerKey(big.NewInt(1), header.Hash())); known {
+		t.Fatalf("Sealer cache, expect no sealer on another chain")
+	}
+	if err := v.VerifyHeader(header, header); err == nil {
+		t.Fatalf("Expect error when verifying against another parent")
+	}
+
+	header = newHeader(1, finalitytest.NewExtraDataBuilder(chainConfig).WithFinalityVotes(validators, 10, parent.Hash(), finalitytest.Source{}, 1, 2))
+	if err := v.VerifyHeader(header, parent); !errors.Is(err, finality.ErrNotEnoughFinalityVote) {
+		t.Fatalf("Expect error %s, got %v", finality.ErrNotEnoughFinalityVote, err)
+	}
+
+	header = newHeader(2, finalitytest.NewExtraDataBuilder(chainConfig).WithCheckpointValidators(validators))
+	if err := v.VerifyHeader(header, parent); !errors.Is(err, consortiumCommon.ErrExtraValidators) {
+		t.Fatalf("Expect error %s, got %v", consortiumCommon.ErrExtraValidators, err)
+	}
+
+	header = newHeader(2, finalitytest.NewExtraDataBuilder(chainConfig))
+	header.Coinbase = validators[1].Address
+	if err := v.VerifyHeader(header, parent); !errors.Is(err, ErrCoinBaseMisMatch) {
+		t.Fatalf("Expect error %s, got %v", ErrCoinBaseMisMatch, err)
+	}
+
+	header = newHeader(2, finalitytest.NewExtraDataBuilder(chainConfig))
+	provider.set.Recents = map[uint64]common.Address{10: validators[2].Address}
+	if err := v.VerifyHeader(header, parent); !errors.Is(err, consortiumCommon.ErrRecentlySigned) {
+		t.Fatalf("Expect error %s, got %v", consortiumCommon.ErrRecentlySigned, err)
+	}
+
+	provider.set = &ValidatorSet{Validators: validators.WithBlsPub()[:2]}
+	if err := v.VerifyHeader(header, parent); !errors.Is(err, ErrUnauthorizedValidator) {
+		t.Fatalf("Expect error %s, got %v", ErrUnauthorizedValidator, err)
+	}
+}
+
+func TestVerifyRotatedFinalitySignatures(t *testing.T) {
+	validators, err := finalitytest.NewValidatorSet(4)
+	if err != nil {
+		t.Fatalf("Failed to create validator set, err %s", err)
+	}
+	// The validators 1 and 2 rotate their BLS keys
+	previousKeys := make([]blsCommon.SecretKey, 2)
+	for i := range previousKeys {
+		if previousKeys[i], err = blst.RandKey(); err != nil {
+			t.Fatalf("Failed to create BLS key, err %s", err)
+		}
+	}
+	previous := []finality.ValidatorWithBlsPub{
+		{Address: validators[1].Address, BlsPublicKey: previousKeys[0].PublicKey()},
+		{Address: validators[2].Address, BlsPublicKey: previousKeys[1].PublicKey()},
+	}
+
+	digest := common.Hash{0x1}
+	var votedValidators finality.FinalityVoteBitSet
+	for _, position := range []int{0, 1, 2} {
+		votedValidators.SetBit(position)
+	}
+	// The validator 1 signs with its previous key, the validator 2 with its new key
+	signature := blst.AggregateSignatures([]blsCommon.Signature{
+		validators[0].BlsKey.Sign(digest[:]),
+		previousKeys[0].Sign(digest[:]),
+		validators[2].BlsKey.Sign(digest[:]),
+	})
+	if err := VerifyFinalitySignatures(validators.WithBlsPub(), votedValidators, signature, digest, 3); !errors.Is(err, finality.ErrFinalitySignatureVerificationFailed) {
+		t.Fatalf("Expect error %s, got %v", finality.ErrFinalitySignatureVerificationFailed, err)
+	}
+	var previousKeyVoters finality.FinalityVoteBitSet
+	previousKeyVoters.SetBit(1)
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), previous, votedValidators, previousKeyVoters, signature, digest, 3); err != nil {
+		t.Fatalf("Failed to verify rotated finality signatures, err %s", err)
+	}
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), previous, votedValidators, nil, signature, digest, 3); !errors.Is(err, finality.ErrFinalitySignatureVerificationFailed) {
+		t.Fatalf("Expect error %s without previous key voters, got %v", finality.ErrFinalitySignatureVerificationFailed, err)
+	}
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), nil, votedValidators, previousKeyVoters, signature, digest, 3); !errors.Is(err, finality.ErrInvalidFinalityVotedBitSet) {
+		t.Fatalf("Expect error %s without previous keys, got %v", finality.ErrInvalidFinalityVotedBitSet, err)
+	}
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), previous, votedValidators, previousKeyVoters, signature, common.Hash{0x2}, 3); !errors.Is(err, finality.ErrFinalitySignatureVerificationFailed) {
+		t.Fatalf("Expect error %s for another digest, got %v", finality.ErrFinalitySignatureVerificationFailed, err)
+	}
+
+	// The validator 2 signed with its new key
+	previousKeyVoters.SetBit(2)
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), previous, votedValidators, previousKeyVoters, signature, digest, 3); !errors.Is(err, finality.ErrFinalitySignatureVerificationFailed) {
+		t.Fatalf("Expect error %s for the wrong previous key voters, got %v", finality.ErrFinalitySignatureVerificationFailed, err)
+	}
+	// The previous key voters must have voted
+	previousKeyVoters = nil
+	previousKeyVoters.SetBit(3)
+	if err := VerifyRotatedFinalitySignatures(validators.WithBlsPub(), previous, votedValidators, previousKeyVoters, signature, digest, 3); !errors.Is(err, finality.ErrInvalidFinalityVotedBitSet) {
+		t.Fatalf("Expect error %s for a previous key voter not voted, got %v", finality.ErrInvalidFinalityVotedBitSet, err)
+	}
+}
@@ -0,0 +1,132 @@
+package consortium
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	consortiumCommon "github.com/ethereum/go-ethereum/consensus/consortium/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// signatureWorkers is the number of workers verifying the finality signatures of
+// a header batch, the BLS aggregate check dominates the cost of the verification
+var signatureWorkers = runtime.NumCPU()
+
+// headerVerification is the verification of a header in the pipeline
+type headerVerification struct {
+	verifySignatures func() error // Deferred finality signatures check, nil if done
+	result           chan error   // Result of the verification, buffered
+}
+
+// verifyHeadersPipeline verifies the headers and sends the results in order. The
+// header lane checks the headers in order, deferring the finality signatures to
+// the signature lane, a pool of workers sized by the CPUs. Both lanes and the
+// results are bounded, so the header lane blocks when the signature lane or the
+// consumer of the results falls behind.
+//
+// The headers which are canonical and already finalized by the local chain were
+// verified when they were imported, they are not verified again, nor are the
+// headers covered by the trusted checkpoint.
+func (c *Consortium) verifyHeadersPipeline(chain consensus.ChainHeaderReader, headers []*types.Header, abort <-chan struct{}, results chan<- error) {
+	ctx, span := consortiumCommon.StartSpan(context.Background(), "VerifyHeaders", headers[0].Number.Uint64(), 0,
+		attribute.Int("headers", len(headers)))
+	defer span.End()
+
+	workers := signatureWorkers
+	if workers > len(headers) {
+		workers = len(headers)
+	}
+	var (
+		signatures = make(chan *headerVerification, workers)
+		ordered    = make(chan *headerVerification, 2*workers)
+		done       = make(chan struct{})
+	)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for verification := range signatures {
+				verification.result <- verification.verifySignatures()
+			}
+		}()
+	}
+	go func() {
+		defer close(done)
+		for verification := range ordered {
+			var err error
+			select {
+			case <-abort:
+				return
+			case err = <-verification.result:
+			}
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	defer func() {
+		close(signatures)
+		close(ordered)
+		<-done
+	}()
+
+	finalized := c.finalizedNumber(chain)
+	for i, header := range headers {
+		verification := &headerVerification{result: make(chan error, 1)}
+		if trusted, err := c.verifyTrustedCheckpoint(chain, header, headers); trusted {
+			verification.result <- err
+		} else if c.isFinalizedAncestor(chain, header, finalized) {
+			verification.result <- nil
+		} else if c.chainConfig.IsConsortiumV2(header.Number) {
+			headerCtx, headerSpan := consortiumCommon.StartSpan(ctx, "VerifyHeader", header.Number.Uint64(), c.chainConfig.Consortium.EpochV2)
+			verifySignatures, err := c.v2.VerifyHeaderAndParentsDeferred(headerCtx, chain, header, headers[:i])
+			consortiumCommon.EndSpan(headerSpan, err)
+			if err != nil {
+				verification.result <- err
+			} else {
+				verification.verifySignatures = verifySignatures
+			}
+		} else {
+			_, headerSpan := consortiumCommon.StartSpan(ctx, "VerifyHeader", header.Number.Uint64(), c.chainConfig.Consortium.Epoch)
+			err := c.v1.VerifyHeaderAndParents(chain, header, headers[:i])
+			consortiumCommon.EndSpan(headerSpan, err)
+			verification.result <- err
+		}
+
+		select {
+		case <-abort:
+			return
+		case ordered <- verification:
+		}
+		if verification.verifySignatures != nil {
+			select {
+			case <-abort:
+				return
+			case signatures <- verification:
+			}
+		}
+	}
+}
+
+// finalizedNumber returns the number of the finalized block of the local chain
+func (c *Consortium) finalizedNumber(chain consensus.ChainHeaderReader) uint64 {
+	head := chain.CurrentHeader()
+	if head == nil || !c.chainConfig.IsShillin(head.Number) {
+		return 0
+	}
+	number, _ := c.v2.GetFinalizedBlock(chain, head.Number.Uint64(), head.Hash())
+	return number
+}
+
+// isFinalizedAncestor reports whether the header is a canonical block at or below
+// the finalized block
+func (c *Consortium) isFinalizedAncestor(chain consensus.ChainHeaderReader, header *types.Header, finalized uint64) bool {
+	number := header.Number.Uint64()
+	if number == 0 || number > finalized {
+		return false
+	}
+	canonical := chain.GetHeaderByNumber(number)
+	return canonical != nil && canonical.Hash() == header.Hash()
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// BadBlockBundle packs a block that failed consensus or execution verification
+// together with the references to its parent state, so that the block can be
+// re-executed later against the same database for triage.
+type BadBlockBundle struct {
+	Block        *types.Block
+	ParentHash   common.Hash
+	ParentNumber uint64
+	ParentRoot   common.Hash // Empty if the parent header was not available locally
+	Error        string
+	Time         uint64 // Unix time the bundle was created
+}
+
+// badBlockBundleFileName returns the file name used to persist the bad block
+// bundle of the given block.
+func badBlockBundleFileName(block *types.Block) string {
+	return fmt.Sprintf("badblock-%d-%x.rlp", block.NumberU64(), block.Hash().Bytes()[:8])
+}
+
+// WriteBadBlockBundle RLP encodes the bundle into a new file in dir and returns
+// the path of the written file.
+func WriteBadBlockBundle(dir string, bundle *BadBlockBundle) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	blob, err := rlp.EncodeToBytes(bundle)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, badBlockBundleFileName(bundle.Block))
+	if err := os.WriteFile(path, blob, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ReadBadBlockBundle loads a bad block bundle from the file at path.
+func ReadBadBlockBundle(path string) (*BadBlockBundle, error) {
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bundle := new(BadBlockBundle)
+	if err := rlp.DecodeBytes(blob, bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Block == nil {
+		return nil, fmt.Errorf("bad block bundle %s contains no block", path)
+	}
+	return bundle, nil
+}
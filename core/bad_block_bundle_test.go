@@ -0,0 +1,45 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestBadBlockBundleRoundTrip(t *testing.T) {
+	header := &types.Header{
+		ParentHash: common.Hash{0x1},
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(7),
+		Extra:      []byte{0x1, 0x2, 0x3},
+	}
+	bundle := &BadBlockBundle{
+		Block:        types.NewBlockWithHeader(header),
+		ParentHash:   header.ParentHash,
+		ParentNumber: 9,
+		ParentRoot:   common.Hash{0x2},
+		Error:        "invalid merkle root",
+		Time:         1000,
+	}
+
+	path, err := WriteBadBlockBundle(t.TempDir(), bundle)
+	if err != nil {
+		t.Fatalf("Failed to write bad block bundle, err %s", err)
+	}
+	loaded, err := ReadBadBlockBundle(path)
+	if err != nil {
+		t.Fatalf("Failed to read bad block bundle, err %s", err)
+	}
+	if loaded.Block.Hash() != bundle.Block.Hash() {
+		t.Fatalf("Block hash mismatch, exp %s got %s", bundle.Block.Hash(), loaded.Block.Hash())
+	}
+	if loaded.ParentHash != bundle.ParentHash || loaded.ParentNumber != bundle.ParentNumber ||
+		loaded.ParentRoot != bundle.ParentRoot {
+		t.Fatalf("Parent reference mismatch, exp %+v got %+v", bundle, loaded)
+	}
+	if loaded.Error != bundle.Error || loaded.Time != bundle.Time {
+		t.Fatalf("Bundle metadata mismatch, exp %+v got %+v", bundle, loaded)
+	}
+}
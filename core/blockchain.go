@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	mrand "math/rand"
 	"sort"
@@ -136,6 +137,7 @@ type CacheConfig struct {
 	TrieTimeLimit       time.Duration // Time limit after which to flush the current in-memory trie to disk
 	SnapshotLimit       int           // Memory allowance (MB) to use for caching snapshot entries in memory
 	Preimages           bool          // Whether to store preimage of trie key to the disk
+	BadBlockBundleDir   string        // Directory to persist bad block bundles for replaying, disabled if empty
 
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
@@ -225,6 +227,8 @@ type BlockChain struct {
 	shouldPreserve             func(*types.Block) bool // Function used to determine whether should preserve the given block.
 	shouldStoreInternalTxs     bool
 	enableAdditionalChainEvent bool
+
+	protectJustified bool // Refuse to reorg below the justified block
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -434,7 +438,9 @@ func (bc *BlockChain) loadLatestDirtyAccounts() {
 	}
 }
 
-func (bc *BlockChain) StartDoubleSignMonitor() {
+// StartDoubleSignMonitor watches the new blocks for double sign, onDoubleSign is
+// called with the conflicting headers when it is not nil.
+func (bc *BlockChain) StartDoubleSignMonitor(onDoubleSign func(header1, header2 *types.Header)) {
 	log.Info("Starting double sign monitor")
 	doubleSignMonitor, err := monitor.NewDoubleSignMonitor()
 	if err != nil {
@@ -453,9 +459,13 @@ func (bc *BlockChain) StartDoubleSignMonitor() {
 	for {
 		select {
 		case ev := <-chainEventCh:
-			doubleSignMonitor.CheckDoubleSign(ev.Block.Header())
+			if header := doubleSignMonitor.CheckDoubleSign(ev.Block.Header()); header != nil && onDoubleSign != nil {
+				onDoubleSign(header, ev.Block.Header())
+			}
 		case ev := <-chainSideEventCh:
-			doubleSignMonitor.CheckDoubleSign(ev.Block.Header())
+			if header := doubleSignMonitor.CheckDoubleSign(ev.Block.Header()); header != nil && onDoubleSign != nil {
+				onDoubleSign(header, ev.Block.Header())
+			}
 		case <-chainEventSub.Err():
 			return
 		case <-chainSideEventSub.Err():
@@ -510,10 +520,173 @@ func (bc *BlockChain) StartFinalityVoteMonitor() {
 	}
 }
 
+// StartFinalityStallMonitor notifies the webhook at url when the finalized block
+// falls behind the chain head by threshold blocks and again when it recovers.
+func (bc *BlockChain) StartFinalityStallMonitor(url string, threshold uint64) {
+	log.Info("Starting finality stall monitor", "threshold", threshold)
+
+	consensus, ok := bc.engine.(consensus.FastFinalityPoSA)
+	if !ok {
+		log.Error("Not a fast finality consensus, stop finality stall monitor")
+		return
+	}
+	finalityStallMonitor, err := monitor.NewFinalityStallMonitor(bc, consensus, url, threshold)
+	if err != nil {
+		log.Error("Finality stall monitor creation failed", "err", err)
+		return
+	}
+
+	chainHeadCh := make(chan ChainHeadEvent, chainHeadChanSize)
+	chainHeadSub := bc.SubscribeChainHeadEvent(chainHeadCh)
+	defer chainHeadSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainHeadCh:
+			header := ev.Block.Header()
+			if bc.chainConfig.IsShillin(header.Number) {
+				finalityStallMonitor.CheckFinalityProgress(header)
+			}
+		case <-chainHeadSub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// StartChainFreeze checks the finality at the new chain heads to freeze and
+// unfreeze the chain for the RPC clients, see monitor.ChainFreeze
+func (bc *BlockChain) StartChainFreeze(freeze *monitor.ChainFreeze) {
+	log.Info("Starting chain freeze circuit breaker")
+
+	chainHeadCh := make(chan ChainHeadEvent, chainHeadChanSize)
+	chainHeadSub := bc.SubscribeChainHeadEvent(chainHeadCh)
+	defer chainHeadSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainHeadCh:
+			header := ev.Block.Header()
+			if bc.chainConfig.IsShillin(header.Number) {
+				freeze.CheckFinality(header)
+			}
+		case <-chainHeadSub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// StartValidatorWatcher checks the new chain heads for the events of the validator
+// operation, see monitor.ValidatorWatcher
+func (bc *BlockChain) StartValidatorWatcher(watcher *monitor.ValidatorWatcher) {
+	log.Info("Starting validator watcher")
+
+	chainHeadCh := make(chan ChainHeadEvent, chainHeadChanSize)
+	chainHeadSub := bc.SubscribeChainHeadEvent(chainHeadCh)
+	defer chainHeadSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainHeadCh:
+			watcher.CheckHead(ev.Block.Header())
+		case <-chainHeadSub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// StartAuditLog writes the consensus audit records of the imported canonical and
+// side blocks to the audit log until the chain is stopped.
+func (bc *BlockChain) StartAuditLog(auditLog *monitor.AuditLog) {
+	log.Info("Starting consensus audit log")
+	defer auditLog.Close()
+
+	chainCh := make(chan ChainEvent, chainHeadChanSize)
+	chainSub := bc.SubscribeChainEvent(chainCh)
+	defer chainSub.Unsubscribe()
+	chainSideCh := make(chan ChainSideEvent, chainHeadChanSize)
+	chainSideSub := bc.SubscribeChainSideEvent(chainSideCh)
+	defer chainSideSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			auditLog.Record(ev.Block)
+		case ev := <-chainSideCh:
+			auditLog.Record(ev.Block)
+		case <-chainSub.Err():
+			return
+		case <-chainSideSub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// StartFinalityExporter exports the finality data of the newly finalized blocks
+// to the sinks at urls.
+func (bc *BlockChain) StartFinalityExporter(urls []string) {
+	log.Info("Starting finality exporter", "sinks", len(urls))
+
+	consensus, ok := bc.engine.(consensus.FastFinalityPoSA)
+	if !ok {
+		log.Error("Not a fast finality consensus, stop finality exporter")
+		return
+	}
+	finalityExportMonitor, err := monitor.NewFinalityExportMonitor(bc, consensus, urls)
+	if err != nil {
+		log.Error("Finality exporter creation failed", "err", err)
+		return
+	}
+	go finalityExportMonitor.Start(bc.quit)
+
+	chainHeadCh := make(chan ChainHeadEvent, chainHeadChanSize)
+	chainHeadSub := bc.SubscribeChainHeadEvent(chainHeadCh)
+	defer chainHeadSub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-chainHeadCh:
+			header := ev.Block.Header()
+			if bc.chainConfig.IsShillin(header.Number) {
+				finalityExportMonitor.CheckFinalized(header)
+			}
+		case <-chainHeadSub.Err():
+			return
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
 func (bc *BlockChain) EnableAdditionalChainEvent() {
 	bc.enableAdditionalChainEvent = true
 }
 
+// EnableJustifiedProtection makes the fork choice refuse the chains which don't
+// include the block justified by the finality votes of the current head, see
+// reorgNeeded
+func (bc *BlockChain) EnableJustifiedProtection() {
+	bc.protectJustified = true
+}
+
+// includesBlock reports whether the block with the number and hash is the block
+// or one of its ancestors
+func (bc *BlockChain) includesBlock(block *types.Block, number uint64, hash common.Hash) bool {
+	if number > block.NumberU64() {
+		return false
+	}
+	maxNonCanonical := uint64(math.MaxUint64)
+	ancestor, _ := bc.GetAncestor(block.Hash(), block.NumberU64(), block.NumberU64()-number, &maxNonCanonical)
+	return ancestor == hash
+}
+
 // empty returns an indicator whether the blockchain is empty.
 // Note, it's a special case that we connect a non-empty ancient
 // database with an empty node, so that we can plugin the ancient
@@ -1369,9 +1542,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 // reorgNeeded determines if the external chain is better than the local chain so reorg is needed
 func (bc *BlockChain) reorgNeeded(localBlock *types.Block, localTd *big.Int, externBlock *types.Block, externTd *big.Int) bool {
 	if consensusEngine, ok := bc.engine.(consensus.FastFinalityPoSA); ok {
-		localJustifiedBlockNumber, _ := consensusEngine.GetJustifiedBlock(bc, localBlock.NumberU64(), localBlock.Hash())
+		localJustifiedBlockNumber, localJustifiedBlockHash := consensusEngine.GetJustifiedBlock(bc, localBlock.NumberU64(), localBlock.Hash())
 		externJustifiedBlockNumber, _ := consensusEngine.GetJustifiedBlock(bc, externBlock.NumberU64(), externBlock.Hash())
 
+		// The external chain reverting the local justified block is kept as a
+		// side chain whatever its justified block and difficulty are
+		if bc.protectJustified && localJustifiedBlockNumber > 0 &&
+			!bc.includesBlock(externBlock, localJustifiedBlockNumber, localJustifiedBlockHash) {
+			log.Error("Refuse to reorg below the justified block", "number", externBlock.NumberU64(), "hash", externBlock.Hash(),
+				"justified", localJustifiedBlockNumber, "justifiedHash", localJustifiedBlockHash)
+			return false
+		}
 		if externJustifiedBlockNumber > localJustifiedBlockNumber {
 			return true
 		} else if externJustifiedBlockNumber < localJustifiedBlockNumber {
@@ -2366,6 +2547,7 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 // reportBlock logs a bad block error.
 func (bc *BlockChain) reportBlock(block *types.Block, receipts types.Receipts, err error) {
 	rawdb.WriteBadBlock(bc.db, block)
+	bc.writeBadBlockBundle(block, err)
 
 	var receiptString string
 	for i, receipt := range receipts {
@@ -2387,6 +2569,30 @@ Error: %v
 `, bc.chainConfig, block.Coinbase().Hex(), block.Number(), block.Hash(), receiptString, err))
 }
 
+// writeBadBlockBundle persists the bad block with its parent state references
+// so it can be replayed later by the replay-bad-block command.
+func (bc *BlockChain) writeBadBlockBundle(block *types.Block, err error) {
+	if bc.cacheConfig.BadBlockBundleDir == "" {
+		return
+	}
+	bundle := &BadBlockBundle{
+		Block:        block,
+		ParentHash:   block.ParentHash(),
+		ParentNumber: block.NumberU64() - 1,
+		Error:        err.Error(),
+		Time:         uint64(time.Now().Unix()),
+	}
+	if parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1); parent != nil {
+		bundle.ParentRoot = parent.Root
+	}
+	path, writeErr := WriteBadBlockBundle(bc.cacheConfig.BadBlockBundleDir, bundle)
+	if writeErr != nil {
+		log.Error("Failed to write bad block bundle", "number", block.Number(), "hash", block.Hash(), "err", writeErr)
+		return
+	}
+	log.Info("Wrote bad block bundle", "number", block.Number(), "hash", block.Hash(), "path", path)
+}
+
 // InsertHeaderChain attempts to insert the given header chain in to the local
 // chain, possibly creating a reorg. If an error is returned, it will return the
 // index number of the failing header as well an error describing what went wrong.
@@ -32,6 +32,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -3604,3 +3605,83 @@ func TestSponsoredTxTransition(t *testing.T) {
 		t.Fatalf("Expect sender's balance %d, get %d", want.Uint64(), have.Uint64())
 	}
 }
+
+// justifyingEngine is a fast finality engine justifying the grandparent of each
+// block
+type justifyingEngine struct {
+	consensus.Engine
+}
+
+func (e *justifyingEngine) GetJustifiedBlock(chain consensus.ChainHeaderReader, blockNumber uint64, blockHash common.Hash) (uint64, common.Hash) {
+	for i := 0; i < 2 && blockNumber > 0; i++ {
+		header := chain.GetHeader(blockHash, blockNumber)
+		if header == nil {
+			return 0, common.Hash{}
+		}
+		blockNumber, blockHash = blockNumber-1, header.ParentHash
+	}
+	return blockNumber, blockHash
+}
+
+func (e *justifyingEngine) GetFinalizedBlock(chain consensus.ChainHeaderReader, blockNumber uint64, blockHash common.Hash) (uint64, common.Hash) {
+	return 0, common.Hash{}
+}
+
+func (e *justifyingEngine) IsActiveValidatorAt(chain consensus.ChainHeaderReader, header *types.Header) bool {
+	return false
+}
+
+func (e *justifyingEngine) VerifyVote(chain consensus.ChainHeaderReader, vote *types.VoteEnvelope) error {
+	return nil
+}
+
+func (e *justifyingEngine) SetVotePool(votePool consensus.VotePool) {}
+
+func (e *justifyingEngine) GetActiveValidatorAt(chain consensus.ChainHeaderReader, blockNumber uint64, blockHash common.Hash) []finality.ValidatorWithBlsPub {
+	return nil
+}
+
+func (e *justifyingEngine) IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
+	return false, nil
+}
+
+func (e *justifyingEngine) IsSystemContract(to *common.Address) bool {
+	return false
+}
+
+func TestJustifiedProtection(t *testing.T) {
+	for _, protect := range []bool{false, true} {
+		db := rawdb.NewMemoryDatabase()
+		genesis := (&Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+		chain, err := NewBlockChain(db, nil, params.TestChainConfig, &justifyingEngine{ethash.NewFaker()}, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		if protect {
+			chain.EnableJustifiedProtection()
+		}
+		blocks, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, nil, true)
+		if _, err := chain.InsertChain(blocks); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		// The fork from block 3 is longer, it reverts the justified block 8
+		fork, _ := GenerateChain(params.TestChainConfig, blocks[2], ethash.NewFaker(), db, 12, func(i int, b *BlockGen) {
+			b.SetCoinbase(common.Address{0x1})
+		}, true)
+		if _, err := chain.InsertChain(fork); err != nil {
+			t.Fatalf("failed to insert fork: %v", err)
+		}
+		head := chain.CurrentBlock().Hash()
+		if protect && head != blocks[len(blocks)-1].Hash() {
+			t.Fatalf("protected chain reorged to %x", head)
+		}
+		if !protect && head != fork[len(fork)-1].Hash() {
+			t.Fatalf("unprotected chain did not reorg, head %x", head)
+		}
+		// Rewinding is not refused, it is the fork choice only
+		if err := chain.SetHead(5); err != nil {
+			t.Fatalf("failed to rewind: %v", err)
+		}
+		chain.Stop()
+	}
+}
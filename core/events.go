@@ -34,6 +34,14 @@ type RemovedLogsEvent struct{ Logs []*types.Log }
 // NewVoteEvent is posted when a batch of votes enters the vote pool.
 type NewVoteEvent struct{ Vote *types.VoteEnvelope }
 
+// NewAggregatedVoteEvent is posted when a better aggregated vote for a block
+// enters the vote pool.
+type NewAggregatedVoteEvent struct{ Vote *types.AggregatedVote }
+
+// NewVoteEquivocationEvent is posted when a new proof of conflicting finality
+// votes enters the vote pool.
+type NewVoteEquivocationEvent struct{ Proof *types.VoteEquivocation }
+
 type ChainEvent struct {
 	Block                *types.Block
 	Hash                 common.Hash
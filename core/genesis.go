@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -44,6 +45,10 @@ import (
 
 var errGenesisNoConfig = errors.New("genesis has no chain configuration")
 
+// errGenesisNotWritten is returned if the chain preset is applied to a database
+// without genesis
+var errGenesisNotWritten = errors.New("genesis block is not written, initialise the database first")
+
 // Genesis specifies the header fields, state of a genesis block. It also defines hard
 // fork switch-over blocks through the chain configuration.
 type Genesis struct {
@@ -241,6 +246,36 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, override
 	return newcfg, stored, nil
 }
 
+// SetupChainPreset replaces the chain configuration of the stored genesis with
+// the built-in preset. The genesis must already be written, the preset must be
+// for the same genesis if the network has a fixed one and it is checked to be
+// compatible with the local chain as in SetupGenesisBlock.
+func SetupChainPreset(db ethdb.Database, preset *params.ChainPreset) (*params.ChainConfig, common.Hash, error) {
+	stored := rawdb.ReadCanonicalHash(db, 0)
+	if (stored == common.Hash{}) {
+		return preset.Config, common.Hash{}, errGenesisNotWritten
+	}
+	if preset.GenesisHash != (common.Hash{}) && preset.GenesisHash != stored {
+		return preset.Config, stored, &GenesisMismatchError{stored, preset.GenesisHash}
+	}
+	newcfg := preset.Config
+	if err := newcfg.CheckConfigForkOrder(); err != nil {
+		return newcfg, common.Hash{}, err
+	}
+	if storedcfg := rawdb.ReadChainConfig(db, stored); storedcfg != nil {
+		height := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadHeaderHash(db))
+		if height == nil {
+			return newcfg, stored, fmt.Errorf("missing block number for head header hash")
+		}
+		compatErr := storedcfg.CheckCompatible(newcfg, *height)
+		if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
+			return newcfg, stored, compatErr
+		}
+	}
+	rawdb.WriteChainConfig(db, stored, newcfg)
+	return newcfg, stored, nil
+}
+
 func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 	switch {
 	case g != nil:
@@ -453,6 +488,43 @@ func DeveloperGenesisBlock(period uint64, gasLimit uint64, faucet common.Address
 	}
 }
 
+// DeveloperConsortiumGenesisBlock returns the 'ronin --dev.consortium' genesis
+// block, a consortium v2 chain with all the Ronin hardforks up to Tripp active
+// from the genesis. The validator set is not read from the system contracts, the
+// caller must set the validator as the mock validator.
+func DeveloperConsortiumGenesisBlock(period uint64, gasLimit uint64, validator common.Address, faucets []common.Address) *Genesis {
+	// Override the default period of the local devnet to the user requested one
+	config := *params.LocalDevnetChainConfig
+	consortium := *config.Consortium
+	consortium.Period = period
+	config.Consortium = &consortium
+	config.ConsortiumV2Contracts = &params.ConsortiumV2Contracts{}
+
+	// Assemble and return the genesis with the precompiles, validator and faucets pre-funded
+	alloc := GenesisAlloc{
+		common.BytesToAddress([]byte{1}): {Balance: big.NewInt(1)}, // ECRecover
+		common.BytesToAddress([]byte{2}): {Balance: big.NewInt(1)}, // SHA256
+		common.BytesToAddress([]byte{3}): {Balance: big.NewInt(1)}, // RIPEMD
+		common.BytesToAddress([]byte{4}): {Balance: big.NewInt(1)}, // Identity
+		common.BytesToAddress([]byte{5}): {Balance: big.NewInt(1)}, // ModExp
+		common.BytesToAddress([]byte{6}): {Balance: big.NewInt(1)}, // ECAdd
+		common.BytesToAddress([]byte{7}): {Balance: big.NewInt(1)}, // ECScalarMul
+		common.BytesToAddress([]byte{8}): {Balance: big.NewInt(1)}, // ECPairing
+		common.BytesToAddress([]byte{9}): {Balance: big.NewInt(1)}, // BLAKE2b
+	}
+	balance := new(big.Int).Mul(big.NewInt(1_000_000_000), big.NewInt(params.Ether))
+	for _, faucet := range append([]common.Address{validator}, faucets...) {
+		alloc[faucet] = GenesisAccount{Balance: balance}
+	}
+	return &Genesis{
+		Config:     &config,
+		ExtraData:  new(finality.HeaderExtraData).Encode(true),
+		GasLimit:   gasLimit,
+		Difficulty: big.NewInt(7), // The in-turn difficulty, the miner never looks for a better parent than the genesis
+		Alloc:      alloc,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
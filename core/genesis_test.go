@@ -23,6 +23,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -213,3 +214,59 @@ func TestGenesis_Commit(t *testing.T) {
 		t.Errorf("inequal difficulty; stored: %v, genesisBlock: %v", stored, genesisBlock.Difficulty())
 	}
 }
+
+func TestDeveloperConsortiumGenesisBlock(t *testing.T) {
+	var (
+		validator = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		faucet    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	)
+	genesis := DeveloperConsortiumGenesisBlock(0, 11500000, validator, []common.Address{faucet})
+	if err := genesis.Config.CheckConfigForkOrder(); err != nil {
+		t.Fatalf("Invalid fork order, err %s", err)
+	}
+	if !genesis.Config.IsConsortiumV2(common.Big0) || !genesis.Config.IsShillin(common.Big0) || !genesis.Config.IsTripp(common.Big0) {
+		t.Fatalf("Expect consortium v2 forks at the genesis")
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	block, err := genesis.Commit(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := finality.DecodeExtra(block.Extra(), true); err != nil {
+		t.Fatalf("Failed to decode genesis extra data, err %s", err)
+	}
+	for _, address := range []common.Address{validator, faucet} {
+		if account, ok := genesis.Alloc[address]; !ok || account.Balance.Sign() <= 0 {
+			t.Fatalf("Expect %s to be pre-funded", address)
+		}
+	}
+}
+
+func TestSetupChainPreset(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	if _, _, err := SetupChainPreset(db, params.ChainPresets["local-devnet"]); err != errGenesisNotWritten {
+		t.Fatalf("Expect error %v, got %v", errGenesisNotWritten, err)
+	}
+
+	// The chain config of the genesis is replaced by the preset
+	genesis := DeveloperConsortiumGenesisBlock(0, 11500000, common.Address{0x1}, nil)
+	block := genesis.MustCommit(db)
+	config, hash, err := SetupChainPreset(db, params.ChainPresets["local-devnet"])
+	if err != nil {
+		t.Fatalf("Failed to setup chain preset, err %s", err)
+	}
+	if hash != block.Hash() || config != params.LocalDevnetChainConfig {
+		t.Fatalf("Mismatch chain preset, have %x %v", hash, config)
+	}
+	if stored := rawdb.ReadChainConfig(db, hash); stored.Consortium.Period != params.LocalDevnetChainConfig.Consortium.Period {
+		t.Fatalf("Expect the preset config to be stored, have period %d", stored.Consortium.Period)
+	}
+
+	// The preset of a network with another genesis is rejected
+	if _, _, err := SetupChainPreset(db, params.ChainPresets["ronin-mainnet"]); err == nil {
+		t.Fatalf("Expect the ronin-mainnet preset to be rejected on another genesis")
+	} else if _, ok := err.(*GenesisMismatchError); !ok {
+		t.Fatalf("Expect genesis mismatch error, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadConsortiumStats retrieves the encoded statistics of the consortium epoch,
+// nil if the epoch is not indexed
+func ReadConsortiumStats(db ethdb.KeyValueReader, epoch uint64) []byte {
+	data, _ := db.Get(consortiumStatsKey(epoch))
+	return data
+}
+
+// WriteConsortiumStats stores the encoded statistics of the consortium epoch
+func WriteConsortiumStats(db ethdb.KeyValueWriter, epoch uint64, data []byte) {
+	if err := db.Put(consortiumStatsKey(epoch), data); err != nil {
+		log.Crit("Failed to store consortium stats", "epoch", epoch, "err", err)
+	}
+}
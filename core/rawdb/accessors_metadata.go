@@ -143,7 +143,7 @@ func PopUncleanShutdownMarker(db ethdb.KeyValueStore) {
 func ReadHighestFinalityVote(db ethdb.KeyValueReader) *uint64 {
 	var highestFinalityVote uint64
 
-	enc, _ := db.Get(highestFinalityVoteKey)
+	enc, _ := db.Get(HighestFinalityVoteKey)
 	if len(enc) == 0 {
 		return nil
 	}
@@ -160,7 +160,39 @@ func WriteHighestFinalityVote(db ethdb.KeyValueStore, highestFinalityVote uint64
 	if err != nil {
 		log.Crit("Failed to encode highest finality vote", "err", err)
 	}
-	if err = db.Put(highestFinalityVoteKey, enc); err != nil {
+	if err = db.Put(HighestFinalityVoteKey, enc); err != nil {
 		log.Crit("Failed to store highest finality vote", "err", err)
 	}
 }
+
+// trustedCheckpoint is the number and hash of the trusted checkpoint block
+type trustedCheckpoint struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// ReadTrustedCheckpoint retrieves the number and hash of the checkpoint block the
+// chain is bootstrapped from, the headers below it are not verified
+func ReadTrustedCheckpoint(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	enc, _ := db.Get(trustedCheckpointKey)
+	if len(enc) == 0 {
+		return 0, common.Hash{}, false
+	}
+	var checkpoint trustedCheckpoint
+	if err := rlp.DecodeBytes(enc, &checkpoint); err != nil {
+		return 0, common.Hash{}, false
+	}
+	return checkpoint.Number, checkpoint.Hash, true
+}
+
+// WriteTrustedCheckpoint stores the number and hash of the checkpoint block the
+// chain is bootstrapped from
+func WriteTrustedCheckpoint(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	enc, err := rlp.EncodeToBytes(&trustedCheckpoint{Number: number, Hash: hash})
+	if err != nil {
+		log.Crit("Failed to encode trusted checkpoint", "err", err)
+	}
+	if err = db.Put(trustedCheckpointKey, enc); err != nil {
+		log.Crit("Failed to store trusted checkpoint", "err", err)
+	}
+}
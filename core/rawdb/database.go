@@ -395,8 +395,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey, highestFinalityVoteKey, storeInternalTxsEnabledKey,
-				snapshotSyncStatusKey,
+				uncleanShutdownKey, badBlockKey, HighestFinalityVoteKey, storeInternalTxsEnabledKey,
+				snapshotSyncStatusKey, trustedCheckpointKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
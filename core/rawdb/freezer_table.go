@@ -708,6 +708,26 @@ func (t *freezerTable) Sync() error {
 	return t.head.Sync()
 }
 
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+// AppendRaw writes the binary blob at the end of the table and returns its item
+// number. It is meant for the tables used on their own, outside of a freezer,
+// the caller must not append concurrently.
+func (t *freezerTable) AppendRaw(blob []byte) (uint64, error) {
+	batch := t.newBatch()
+	item := batch.curItem
+	if err := batch.AppendRaw(item, blob); err != nil {
+		return 0, err
+	}
+	if err := batch.commit(); err != nil {
+		return 0, err
+	}
+	return item, nil
+}
+
 // DumpIndex is a debug print utility function, mainly for testing. It can also
 // be used to analyse a live freezer table index.
 func (t *freezerTable) DumpIndex(start, stop int64) {
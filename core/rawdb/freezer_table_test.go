@@ -74,6 +74,45 @@ func TestFreezerBasics(t *testing.T) {
 	}
 }
 
+// TestFreezerAppendRaw tests appending to a table used on its own, including
+// empty items, and reopening it.
+func TestFreezerAppendRaw(t *testing.T) {
+	t.Parallel()
+	dir, name := os.TempDir(), fmt.Sprintf("unittest-%d", rand.Uint64())
+	f, err := NewFreezerTable(dir, name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := [][]byte{getChunk(15, 1), {}, getChunk(30, 2)}
+	for i, blob := range items {
+		item, err := f.AppendRaw(blob)
+		if err != nil {
+			t.Fatalf("appending item %d: %v", i, err)
+		}
+		if item != uint64(i) {
+			t.Fatalf("item number mismatch, have %d, want %d", item, i)
+		}
+	}
+	f.Close()
+
+	if f, err = NewFreezerTable(dir, name, false); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if f.Items() != uint64(len(items)) {
+		t.Fatalf("items mismatch, have %d, want %d", f.Items(), len(items))
+	}
+	for i, exp := range items {
+		got, err := f.Retrieve(uint64(i))
+		if err != nil {
+			t.Fatalf("reading item %d: %v", i, err)
+		}
+		if !bytes.Equal(got, exp) {
+			t.Fatalf("test %d, got \n%x != \n%x", i, got, exp)
+		}
+	}
+}
+
 // TestFreezerBasicsClosing tests same as TestFreezerBasics, but also closes and reopens the freezer between
 // every operation
 func TestFreezerBasicsClosing(t *testing.T) {
@@ -78,8 +78,17 @@ var (
 	// storeInternalTxsEnabledKey flags that internal transactions will be stored into db
 	storeInternalTxsEnabledKey = []byte("storeInternalTxsEnabled")
 
-	// lastFinalityVoteKey tracks the highest finality vote
-	highestFinalityVoteKey = []byte("HighestFinalityVote")
+	// HighestFinalityVoteKey tracks the highest finality vote
+	HighestFinalityVoteKey = []byte("HighestFinalityVote")
+
+	// trustedCheckpointKey tracks the consortium checkpoint the chain is bootstrapped from
+	trustedCheckpointKey = []byte("TrustedCheckpoint")
+
+	// ConsortiumSnapshotPrefix + block hash -> consortium snapshot, written by the consensus engine
+	ConsortiumSnapshotPrefix = []byte("consortium-")
+
+	// consortiumStatsPrefix + epoch (uint64 big endian) -> consortium epoch statistics
+	consortiumStatsPrefix = []byte("cstats-")
 
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
@@ -240,6 +249,11 @@ func IsCodeKey(key []byte) (bool, []byte) {
 	return false, nil
 }
 
+// consortiumStatsKey = consortiumStatsPrefix + epoch (uint64 big endian)
+func consortiumStatsKey(epoch uint64) []byte {
+	return append(consortiumStatsPrefix, encodeBlockNumber(epoch)...)
+}
+
 // configKey = configPrefix + hash
 func configKey(hash common.Hash) []byte {
 	return append(configPrefix, hash.Bytes()...)
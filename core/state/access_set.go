@@ -0,0 +1,161 @@
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AccessKind is the part of the state an AccessKey refers to
+type AccessKind uint8
+
+const (
+	AccessAccount AccessKind = iota // Existence, nonce and code of the account
+	AccessBalance                   // Balance of the account
+	AccessStorage                   // Storage slot of the account
+)
+
+// AccessKey is a key of the state read or written by a transaction
+type AccessKey struct {
+	Kind    AccessKind
+	Address common.Address
+	Slot    common.Hash // Only set for AccessStorage
+}
+
+// AccessSet records the state read and written through the methods of a StateDB,
+// see StateDB.SetAccessSet. It is used to detect the conflicts between the
+// transactions executed in parallel on top of the same state. The balances only
+// changed by AddBalance and SubBalance are recorded apart as such changes commute,
+// e.g. the fees paid to the coinbase by every transaction.
+type AccessSet struct {
+	Reads  map[AccessKey]struct{}
+	Writes map[AccessKey]struct{}
+	Deltas map[common.Address]struct{} // Accounts whose balance is added to or subtracted from
+
+	// Opaque is set if the accesses cannot be merged key by key, e.g. the storage
+	// is iterated or an existing account is re-created
+	Opaque bool
+}
+
+// NewAccessSet returns an empty access set
+func NewAccessSet() *AccessSet {
+	return &AccessSet{
+		Reads:  make(map[AccessKey]struct{}),
+		Writes: make(map[AccessKey]struct{}),
+		Deltas: make(map[common.Address]struct{}),
+	}
+}
+
+func (set *AccessSet) read(kind AccessKind, addr common.Address, slot common.Hash) {
+	set.Reads[AccessKey{Kind: kind, Address: addr, Slot: slot}] = struct{}{}
+}
+
+func (set *AccessSet) write(kind AccessKind, addr common.Address, slot common.Hash) {
+	set.Writes[AccessKey{Kind: kind, Address: addr, Slot: slot}] = struct{}{}
+}
+
+// BalanceRead returns whether the balance of the account is read
+func (set *AccessSet) BalanceRead(addr common.Address) bool {
+	_, ok := set.Reads[AccessKey{Kind: AccessBalance, Address: addr}]
+	return ok
+}
+
+// Written returns whether the account key is written by the transaction, not
+// counting the balance deltas
+func (set *AccessSet) Written(kind AccessKind, addr common.Address) bool {
+	_, ok := set.Writes[AccessKey{Kind: kind, Address: addr}]
+	return ok
+}
+
+// Accounts returns the accounts written by the transaction
+func (set *AccessSet) Accounts() map[common.Address]struct{} {
+	accounts := make(map[common.Address]struct{}, len(set.Writes)+len(set.Deltas))
+	for key := range set.Writes {
+		accounts[key.Address] = struct{}{}
+	}
+	for addr := range set.Deltas {
+		accounts[addr] = struct{}{}
+	}
+	return accounts
+}
+
+// AccessList returns the accounts and storage slots read or written as an access
+// list, in no particular order
+func (set *AccessSet) AccessList() types.AccessList {
+	var (
+		index = make(map[common.Address]int)
+		list  types.AccessList
+	)
+	add := func(key AccessKey) {
+		i, ok := index[key.Address]
+		if !ok {
+			i = len(list)
+			index[key.Address] = i
+			list = append(list, types.AccessTuple{Address: key.Address})
+		}
+		if key.Kind == AccessStorage {
+			list[i].StorageKeys = append(list[i].StorageKeys, key.Slot)
+		}
+	}
+	for key := range set.Reads {
+		add(key)
+	}
+	for key := range set.Writes {
+		// The written slots are mostly read first, they are only added once
+		if _, ok := set.Reads[key]; !ok {
+			add(key)
+		}
+	}
+	for addr := range set.Deltas {
+		add(AccessKey{Kind: AccessBalance, Address: addr})
+	}
+	return list
+}
+
+// ReadsAny returns whether the transaction reads any of the written keys
+func (set *AccessSet) ReadsAny(written map[AccessKey]struct{}) bool {
+	if len(written) < len(set.Reads) {
+		for key := range written {
+			if _, ok := set.Reads[key]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	for key := range set.Reads {
+		if _, ok := written[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WrittenKeys adds the keys written by the transaction to written, including the
+// balances changed by a delta
+func (set *AccessSet) WrittenKeys(written map[AccessKey]struct{}) {
+	for key := range set.Writes {
+		written[key] = struct{}{}
+	}
+	for addr := range set.Deltas {
+		written[AccessKey{Kind: AccessBalance, Address: addr}] = struct{}{}
+	}
+}
+
+// SetAccessSet records the state accessed through the StateDB methods in the
+// set, nil stops the recording
+func (s *StateDB) SetAccessSet(set *AccessSet) {
+	s.accessSet = set
+}
+
+// AccessSet returns the set recording the state accessed, nil if not recording
+func (s *StateDB) AccessSet() *AccessSet {
+	return s.accessSet
+}
+
+// recordDelta records the balance change of the account by AddBalance or
+// SubBalance, the account is created if it does not exist
+func (s *StateDB) recordDelta(addr common.Address) {
+	s.accessSet.Deltas[addr] = struct{}{}
+	if s.getStateObject(addr) == nil {
+		s.accessSet.write(AccessAccount, addr, common.Hash{})
+	}
+}
@@ -99,6 +99,9 @@ type StateDB struct {
 	// Per-transaction access list
 	accessList *accessList
 
+	// Optional record of the accessed state, see SetAccessSet
+	accessSet *AccessSet
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -167,6 +170,32 @@ func (s *StateDB) StartPrefetcher(namespace string) {
 	}
 }
 
+// Prefetch schedules the trie nodes of the accounts and storage slots of the
+// access list to be loaded by the running prefetcher, if any, before they are
+// accessed. It does not change the state.
+func (s *StateDB) Prefetch(list types.AccessList) {
+	if s.prefetcher == nil || len(list) == 0 {
+		return
+	}
+	addresses := make([][]byte, 0, len(list))
+	for _, tuple := range list {
+		addresses = append(addresses, common.CopyBytes(tuple.Address[:]))
+		if len(tuple.StorageKeys) == 0 {
+			continue
+		}
+		obj := s.getStateObject(tuple.Address)
+		if obj == nil || obj.data.Root == emptyRoot {
+			continue
+		}
+		slots := make([][]byte, 0, len(tuple.StorageKeys))
+		for _, slot := range tuple.StorageKeys {
+			slots = append(slots, common.CopyBytes(slot[:]))
+		}
+		s.prefetcher.prefetch(obj.data.Root, slots)
+	}
+	s.prefetcher.prefetch(s.originalRoot, addresses)
+}
+
 // StopPrefetcher terminates a running prefetcher and reports any leftover stats
 // from the gathered metrics.
 func (s *StateDB) StopPrefetcher() {
@@ -247,18 +276,29 @@ func (s *StateDB) SubRefund(gas uint64) {
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for suicided accounts.
 func (s *StateDB) Exist(addr common.Address) bool {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+		s.accessSet.read(AccessBalance, addr, common.Hash{})
+	}
 	return s.getStateObject(addr) != nil
 }
 
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (s *StateDB) Empty(addr common.Address) bool {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+		s.accessSet.read(AccessBalance, addr, common.Hash{})
+	}
 	so := s.getStateObject(addr)
 	return so == nil || so.empty()
 }
 
 // GetBalance retrieves the balance from the given address or 0 if object not found
 func (s *StateDB) GetBalance(addr common.Address) *big.Int {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessBalance, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Balance()
@@ -267,6 +307,9 @@ func (s *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (s *StateDB) GetNonce(addr common.Address) uint64 {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Nonce()
@@ -281,6 +324,9 @@ func (s *StateDB) TxIndex() int {
 }
 
 func (s *StateDB) GetCode(addr common.Address) []byte {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Code(s.db)
@@ -289,6 +335,9 @@ func (s *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (s *StateDB) GetCodeSize(addr common.Address) int {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.CodeSize(s.db)
@@ -297,6 +346,9 @@ func (s *StateDB) GetCodeSize(addr common.Address) int {
 }
 
 func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return common.Hash{}
@@ -306,6 +358,9 @@ func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 // GetState retrieves a value from the given account's storage trie.
 func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessStorage, addr, hash)
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(s.db, hash)
@@ -338,6 +393,9 @@ func (s *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byte,
 
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessStorage, addr, hash)
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetCommittedState(s.db, hash)
@@ -350,6 +408,15 @@ func (s *StateDB) Database() Database {
 	return s.db
 }
 
+// GetStorageRoot returns the root of the committed storage trie of the account,
+// the zero hash if the account does not exist
+func (s *StateDB) GetStorageRoot(addr common.Address) common.Hash {
+	if stateObject := s.getStateObject(addr); stateObject != nil {
+		return stateObject.data.Root
+	}
+	return common.Hash{}
+}
+
 // StorageTrie returns the storage trie of an account.
 // The return value is a copy and is nil for non-existent accounts.
 func (s *StateDB) StorageTrie(addr common.Address) Trie {
@@ -363,6 +430,9 @@ func (s *StateDB) StorageTrie(addr common.Address) Trie {
 }
 
 func (s *StateDB) HasSuicided(addr common.Address) bool {
+	if s.accessSet != nil {
+		s.accessSet.read(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.suicided
@@ -376,6 +446,9 @@ func (s *StateDB) HasSuicided(addr common.Address) bool {
 
 // AddBalance adds amount to the account associated with addr.
 func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
+	if s.accessSet != nil {
+		s.recordDelta(addr)
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.AddBalance(amount)
@@ -384,6 +457,9 @@ func (s *StateDB) AddBalance(addr common.Address, amount *big.Int) {
 
 // SubBalance subtracts amount from the account associated with addr.
 func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
+	if s.accessSet != nil {
+		s.recordDelta(addr)
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SubBalance(amount)
@@ -391,6 +467,9 @@ func (s *StateDB) SubBalance(addr common.Address, amount *big.Int) {
 }
 
 func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
+	if s.accessSet != nil {
+		s.accessSet.write(AccessBalance, addr, common.Hash{})
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetBalance(amount)
@@ -398,6 +477,9 @@ func (s *StateDB) SetBalance(addr common.Address, amount *big.Int) {
 }
 
 func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
+	if s.accessSet != nil {
+		s.accessSet.write(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetNonce(nonce)
@@ -405,6 +487,9 @@ func (s *StateDB) SetNonce(addr common.Address, nonce uint64) {
 }
 
 func (s *StateDB) SetCode(addr common.Address, code []byte) {
+	if s.accessSet != nil {
+		s.accessSet.write(AccessAccount, addr, common.Hash{})
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetCode(crypto.Keccak256Hash(code), code)
@@ -412,6 +497,9 @@ func (s *StateDB) SetCode(addr common.Address, code []byte) {
 }
 
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	if s.accessSet != nil {
+		s.accessSet.write(AccessStorage, addr, key)
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetState(s.db, key, value)
@@ -421,6 +509,9 @@ func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
 // SetStorage replaces the entire storage for the specified account with given
 // storage. This function should only be used for debugging.
 func (s *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common.Hash) {
+	if s.accessSet != nil {
+		s.accessSet.Opaque = true
+	}
 	stateObject := s.GetOrNewStateObject(addr)
 	if stateObject != nil {
 		stateObject.SetStorage(storage)
@@ -433,6 +524,10 @@ func (s *StateDB) SetStorage(addr common.Address, storage map[common.Hash]common
 // The account's state object is still available until the state is committed,
 // getStateObject will return a non-nil account after Suicide.
 func (s *StateDB) Suicide(addr common.Address) bool {
+	if s.accessSet != nil {
+		s.accessSet.write(AccessAccount, addr, common.Hash{})
+		s.accessSet.write(AccessBalance, addr, common.Hash{})
+	}
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return false
@@ -608,12 +703,20 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (s *StateDB) CreateAccount(addr common.Address) {
 	newObj, prev := s.createObject(addr)
+	if s.accessSet != nil {
+		s.accessSet.write(AccessAccount, addr, common.Hash{})
+		// The storage of the previous account is dropped
+		s.accessSet.Opaque = s.accessSet.Opaque || prev != nil
+	}
 	if prev != nil {
 		newObj.setBalance(prev.data.Balance)
 	}
 }
 
 func (db *StateDB) ForEachStorage(addr common.Address, cb func(key, value common.Hash) bool) error {
+	if db.accessSet != nil {
+		db.accessSet.Opaque = true
+	}
 	so := db.getStateObject(addr)
 	if so == nil {
 		return nil
@@ -783,6 +886,18 @@ func (s *StateDB) GetRefund() uint64 {
 	return s.refund
 }
 
+// DeletedOnFinalise returns whether the account is removed by the next Finalise,
+// i.e. it is changed since the last Finalise and self destructed, or empty if
+// deleteEmptyObjects. It allows to read the state as after Finalise while the
+// journal is kept.
+func (s *StateDB) DeletedOnFinalise(addr common.Address, deleteEmptyObjects bool) bool {
+	if _, dirty := s.journal.dirties[addr]; !dirty {
+		return false
+	}
+	obj, exist := s.stateObjects[addr]
+	return exist && (obj.suicided || (deleteEmptyObjects && obj.empty()))
+}
+
 // Finalise finalises the state by removing the s destructed objects and clears
 // the journal as well as the refunds. Finalise, however, will not push any updates
 // into the tries just yet. Only IntermediateRoot or Commit will do that.
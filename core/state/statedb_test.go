@@ -915,3 +915,35 @@ func TestStateDBAccessList(t *testing.T) {
 		t.Fatalf("expected empty, got %d", got)
 	}
 }
+
+// TestDeletedOnFinalise tests that the accounts removed by Finalise are known
+// before it, and that reverting them keeps the accounts
+func TestDeletedOnFinalise(t *testing.T) {
+	var (
+		state, _  = New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		empty     = common.HexToAddress("0x1")
+		destroyed = common.HexToAddress("0x2")
+		kept      = common.HexToAddress("0x3")
+	)
+	state.SetBalance(destroyed, big.NewInt(1))
+	state.SetBalance(kept, big.NewInt(1))
+	state.Finalise(true)
+
+	snapshot := state.Snapshot()
+	state.AddBalance(empty, common.Big0)
+	state.Suicide(destroyed)
+	state.AddBalance(kept, common.Big1)
+	if !state.DeletedOnFinalise(empty, true) || state.DeletedOnFinalise(empty, false) {
+		t.Fatalf("Expect the touched empty account to be only deleted with deleteEmptyObjects")
+	}
+	if !state.DeletedOnFinalise(destroyed, false) {
+		t.Fatalf("Expect the self destructed account to be deleted")
+	}
+	if state.DeletedOnFinalise(kept, true) {
+		t.Fatalf("Expect the non-empty account to be kept")
+	}
+	state.RevertToSnapshot(snapshot)
+	if state.DeletedOnFinalise(destroyed, true) || !state.Exist(destroyed) {
+		t.Fatalf("Expect the reverted account to be kept")
+	}
+}
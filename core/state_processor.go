@@ -85,8 +85,36 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	bloomProcessors := NewAsyncReceiptBloomGenerator(txNum)
 	defer bloomProcessors.Close()
 
+	// Execute the common transactions in parallel if enabled, the block is
+	// executed serially below on conflicts or if the accessed state is recorded
+	parallel := false
+	if parallelExecution.Enabled() && !cfg.Debug && statedb.AccessSet() == nil && txNum >= minParallelTxs && p.config.IsByzantium(blockNumber) {
+		indexes := make([]int, 0, txNum)
+		for i, tx := range block.Transactions() {
+			if isPoSA {
+				if isSystemTx, err := posa.IsSystemTransaction(tx, block.Header()); err != nil {
+					return nil, nil, nil, 0, err
+				} else if isSystemTx {
+					systemTxs = append(systemTxs, tx)
+					continue
+				}
+			}
+			commonTxs = append(commonTxs, tx)
+			indexes = append(indexes, i)
+		}
+		receipts, parallel = p.processParallel(block, statedb, cfg, &blockContext, commonTxs, indexes, gp, usedGas, bloomProcessors, publishEvents...)
+		if !parallel {
+			commonTxs, systemTxs = commonTxs[:0], systemTxs[:0]
+			receipts = make([]*types.Receipt, 0)
+		}
+	}
+
 	// Iterate over and process the individual transactions
-	for i, tx := range block.Transactions() {
+	serialTxs := block.Transactions()
+	if parallel {
+		serialTxs = nil
+	}
+	for i, tx := range serialTxs {
 		if isPoSA {
 			if isSystemTx, err := posa.IsSystemTransaction(tx, block.Header()); err != nil {
 				return nil, nil, nil, 0, err
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"math/big"
+	"runtime"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/features"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// parallelExecution executes the transactions of the processed blocks in
+// parallel, see StateProcessor.processParallel
+var parallelExecution = features.Register("core.parallelexecution", "Execute the transactions of the processed blocks in parallel, falling back to the serial execution on conflicts (experimental)", false, true)
+
+// minParallelTxs is the number of transactions below which the block is always
+// executed serially
+const minParallelTxs = 4
+
+// parallelWorkers is the number of goroutines executing the transactions of a
+// block in parallel
+var parallelWorkers = runtime.NumCPU()
+
+var (
+	parallelBlockMeter    = metrics.NewRegisteredMeter("chain/parallel/blocks", nil)
+	parallelConflictMeter = metrics.NewRegisteredMeter("chain/parallel/conflicts", nil)
+)
+
+// parallelTx is a transaction executed alone on top of the state before the
+// block
+type parallelTx struct {
+	index int
+	tx    *types.Transaction
+	msg   types.Message
+
+	access      *state.AccessSet
+	result      *ExecutionResult
+	internalTxs []*types.InternalTransaction
+	err         error
+
+	accounts  map[common.Address]*parallelAccount // Accessed accounts after the transaction, nil if deleted
+	slots     map[state.AccessKey]common.Hash     // Written storage slots after the transaction
+	logs      []*types.Log
+	preimages map[common.Hash][]byte
+
+	deltas map[common.Address]*big.Int // Balance changes of the accounts whose balance is not read
+}
+
+// parallelAccount is an account accessed by a transaction executed in parallel,
+// as it is after the transaction
+type parallelAccount struct {
+	balance  *big.Int
+	nonce    uint64
+	codeHash common.Hash
+	code     []byte // Only set if the account is written
+}
+
+// processParallel executes the common transactions of the block with optimistic
+// concurrency: each transaction is executed on top of the state before the block,
+// on the copy of the state of the worker reverted after each transaction, while
+// the state it reads and writes is recorded. The transactions
+// are then checked in the block order, a transaction conflicts if it reads a key
+// written by a previous one as it would have seen another value in the serial
+// execution. Without conflict, the writes are merged into statedb in the block
+// order, the balance changes of the accounts whose balance is not read, e.g. the
+// fees paid to the coinbase, being added up.
+//
+// It returns false without changing statedb if the block must be executed
+// serially: on a conflict, a failing transaction whose error is then reported by
+// the serial execution, or an access which cannot be merged.
+func (p *StateProcessor) processParallel(block *types.Block, statedb *state.StateDB, cfg vm.Config, blockContext *vm.BlockContext,
+	commonTxs []*types.Transaction, indexes []int, gp *GasPool, usedGas *uint64, receiptProcessor ReceiptProcessor,
+	publishEvents ...*vm.PublishEvent) ([]*types.Receipt, bool) {
+	var (
+		header = block.Header()
+		signer = types.MakeSigner(p.config, header.Number)
+		txs    = make([]*parallelTx, len(commonTxs))
+	)
+	for i, tx := range commonTxs {
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			return nil, false
+		}
+		txs[i] = &parallelTx{index: indexes[i], tx: tx, msg: msg, access: state.NewAccessSet()}
+	}
+
+	var (
+		jobs    = make(chan *parallelTx, len(txs))
+		workers = parallelWorkers
+		wg      sync.WaitGroup
+	)
+	for _, ptx := range txs {
+		jobs <- ptx
+	}
+	close(jobs)
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	for i := 0; i < workers; i++ {
+		// The copies are made before the execution as copying reads statedb
+		workerState := statedb.Copy()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ptx := range jobs {
+				p.executeParallelTx(header, block.GasLimit(), cfg, workerState, ptx, publishEvents...)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Check the transactions in the block order before changing statedb
+	var (
+		written = make(map[state.AccessKey]struct{})
+		exists  = make(map[common.Address]bool) // Existence of the accounts after the previous transactions
+		pool    = *gp
+	)
+	for _, ptx := range txs {
+		if ptx.err != nil || ptx.access.Opaque || ptx.access.ReadsAny(written) || pool.Gas() < ptx.msg.Gas() {
+			if ptx.err == nil {
+				parallelConflictMeter.Mark(1)
+			}
+			log.Debug("Executing block serially", "number", header.Number, "tx", ptx.index, "err", ptx.err, "opaque", ptx.access.Opaque)
+			return nil, false
+		}
+		ptx.deltas = make(map[common.Address]*big.Int)
+		for addr := range ptx.access.Accounts() {
+			// The touched empty accounts are deleted, it is only merged as a no-op
+			// if the account does not exist in the serial execution either
+			exist, ok := exists[addr]
+			if !ok {
+				exist = statedb.Exist(addr)
+			}
+			account := ptx.accounts[addr]
+			if exists[addr] = account != nil; !exists[addr] {
+				if exist {
+					log.Debug("Executing block serially", "number", header.Number, "tx", ptx.index, "deleted", addr)
+					return nil, false
+				}
+				continue
+			}
+			if _, ok := ptx.access.Deltas[addr]; ok && !ptx.access.BalanceRead(addr) && !ptx.access.Written(state.AccessBalance, addr) {
+				ptx.deltas[addr] = new(big.Int).Sub(account.balance, statedb.GetBalance(addr))
+			}
+		}
+		if err := pool.SubGas(ptx.result.UsedGas); err != nil {
+			return nil, false
+		}
+		ptx.access.WrittenKeys(written)
+	}
+	*gp = pool
+	parallelBlockMeter.Mark(1)
+
+	receipts := make([]*types.Receipt, 0, len(txs))
+	for _, ptx := range txs {
+		statedb.Prepare(ptx.tx.Hash(), ptx.index)
+		mergeParallelTx(statedb, ptx)
+		statedb.Finalise(true)
+		*usedGas += ptx.result.UsedGas
+		*blockContext.InternalTransactions = append(*blockContext.InternalTransactions, ptx.internalTxs...)
+
+		receipt := &types.Receipt{Type: ptx.tx.Type(), CumulativeGasUsed: *usedGas}
+		if ptx.result.Failed() {
+			receipt.Status = types.ReceiptStatusFailed
+		} else {
+			receipt.Status = types.ReceiptStatusSuccessful
+		}
+		receipt.TxHash = ptx.tx.Hash()
+		receipt.GasUsed = ptx.result.UsedGas
+		if ptx.msg.To() == nil {
+			receipt.ContractAddress = crypto.CreateAddress(ptx.msg.From(), ptx.tx.Nonce())
+		}
+		receipt.Logs = statedb.GetLogs(ptx.tx.Hash(), block.Hash())
+		receipt.BlockHash = block.Hash()
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(ptx.index)
+		receiptProcessor.Apply(receipt)
+		receipts = append(receipts, receipt)
+	}
+	return receipts, true
+}
+
+// executeParallelTx executes the transaction on the state of the worker, the gas
+// pool is checked against the other transactions afterwards. The state after the
+// transaction is recorded in ptx, then the state is reverted for the next
+// transaction of the worker.
+func (p *StateProcessor) executeParallelTx(header *types.Header, gasLimit uint64, cfg vm.Config, statedb *state.StateDB, ptx *parallelTx, publishEvents ...*vm.PublishEvent) {
+	snapshot := statedb.Snapshot()
+	defer statedb.RevertToSnapshot(snapshot)
+	statedb.SetAccessSet(ptx.access)
+	defer statedb.SetAccessSet(nil)
+	statedb.Prepare(ptx.tx.Hash(), ptx.index)
+
+	// The block context is not shared, its block hash cache is not thread safe
+	blockContext := NewEVMBlockContext(header, p.bc, nil, publishEvents...)
+	blockContext.CurrentTransaction = ptx.tx
+	evm := vm.NewEVM(blockContext, NewEVMTxContext(ptx.msg), statedb, p.config, cfg)
+
+	from, payer := ptx.msg.From(), ptx.msg.Payer()
+	if p.config.Consortium != nil && p.config.IsOdysseus(header.Number) {
+		contractAddr := p.config.BlacklistContractAddress
+		if state.IsAddressBlacklisted(statedb, contractAddr, &from) ||
+			state.IsAddressBlacklisted(statedb, contractAddr, ptx.msg.To()) ||
+			state.IsAddressBlacklisted(statedb, contractAddr, &payer) {
+			ptx.err = ErrAddressBlacklisted
+			return
+		}
+	}
+	ptx.result, ptx.err = ApplyMessage(evm, ptx.msg, new(GasPool).AddGas(gasLimit))
+	if ptx.err != nil {
+		return
+	}
+	ptx.internalTxs = *blockContext.InternalTransactions
+
+	// The state is read as after Finalise, which would clear the journal
+	statedb.SetAccessSet(nil)
+	ptx.accounts = make(map[common.Address]*parallelAccount, len(ptx.access.Accounts()))
+	for addr := range ptx.access.Accounts() {
+		if !statedb.Exist(addr) || statedb.DeletedOnFinalise(addr, true) {
+			ptx.accounts[addr] = nil
+			continue
+		}
+		account := &parallelAccount{
+			balance:  new(big.Int).Set(statedb.GetBalance(addr)),
+			nonce:    statedb.GetNonce(addr),
+			codeHash: statedb.GetCodeHash(addr),
+		}
+		if ptx.access.Written(state.AccessAccount, addr) {
+			account.code = statedb.GetCode(addr)
+		}
+		ptx.accounts[addr] = account
+	}
+	ptx.slots = make(map[state.AccessKey]common.Hash)
+	for key := range ptx.access.Writes {
+		if key.Kind == state.AccessStorage && ptx.accounts[key.Address] != nil {
+			ptx.slots[key] = statedb.GetState(key.Address, key.Slot)
+		}
+	}
+	ptx.logs = statedb.GetLogs(ptx.tx.Hash(), common.Hash{})
+	ptx.preimages = make(map[common.Hash][]byte)
+	for hash, preimage := range statedb.Preimages() {
+		ptx.preimages[hash] = preimage
+	}
+}
+
+// mergeParallelTx applies the writes of the transaction executed in parallel to
+// statedb. The balances only changed by a delta are changed by the same delta,
+// the other written keys are set to their value after the transaction.
+func mergeParallelTx(statedb *state.StateDB, ptx *parallelTx) {
+	for addr := range ptx.access.Accounts() {
+		account := ptx.accounts[addr]
+		if account == nil {
+			continue
+		}
+		if !statedb.Exist(addr) {
+			statedb.CreateAccount(addr)
+		}
+		_, changed := ptx.access.Deltas[addr]
+		if delta, ok := ptx.deltas[addr]; ok {
+			switch delta.Sign() {
+			case 1:
+				statedb.AddBalance(addr, delta)
+			case -1:
+				statedb.SubBalance(addr, new(big.Int).Neg(delta))
+			}
+		} else if changed || ptx.access.Written(state.AccessBalance, addr) {
+			statedb.SetBalance(addr, account.balance)
+		}
+		// The nonce and the code of the accounts the transaction does not write
+		// may have been changed by the previous transactions
+		if ptx.access.Written(state.AccessAccount, addr) {
+			if statedb.GetNonce(addr) != account.nonce {
+				statedb.SetNonce(addr, account.nonce)
+			}
+			if account.codeHash != statedb.GetCodeHash(addr) {
+				statedb.SetCode(addr, account.code)
+			}
+		}
+	}
+	for key := range ptx.access.Writes {
+		if key.Kind == state.AccessStorage {
+			statedb.SetState(key.Address, key.Slot, ptx.slots[key])
+		}
+	}
+	for _, l := range ptx.logs {
+		statedb.AddLog(l)
+	}
+	for hash, preimage := range ptx.preimages {
+		statedb.AddPreimage(hash, preimage)
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/features"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// TestParallelExecution tests that the blocks imported with the parallel
+// execution have the same state as when executed serially, and that the blocks
+// with conflicting transactions fall back to the serial execution
+func TestParallelExecution(t *testing.T) {
+	var (
+		config  = params.TestChainConfig
+		signer  = types.LatestSigner(config)
+		engine  = ethash.NewFaker()
+		keys    = make([]*ecdsa.PrivateKey, 8)
+		addrs   = make([]common.Address, len(keys))
+		genesis = &Genesis{Config: config, Alloc: make(GenesisAlloc), BaseFee: big.NewInt(params.InitialBaseFee)}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		genesis.Alloc[addrs[i]] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	_, blocks, _ := GenerateChainWithGenesis(genesis, engine, 2, func(i int, gen *BlockGen) {
+		for j, key := range keys {
+			// The first block transfers to new accounts, the transfers of the
+			// second one read the balances changed by the previous transfers
+			to := common.BigToAddress(big.NewInt(int64(0x1000 + j)))
+			if i == 1 {
+				to = addrs[(j+len(keys)-1)%len(keys)]
+			}
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addrs[j]), to, big.NewInt(1000), params.TxGas, gen.header.BaseFee, nil), signer, key)
+			gen.AddTx(tx)
+		}
+	})
+
+	if _, err := features.Set("core.parallelexecution", true); err != nil {
+		t.Fatal(err)
+	}
+	defer features.Set("core.parallelexecution", false)
+
+	// The workers execute several transactions on their copy of the state
+	defer func(workers int) { parallelWorkers = workers }(parallelWorkers)
+	parallelWorkers = 3
+
+	db := rawdb.NewMemoryDatabase()
+	genesis.MustCommit(db)
+	chain, err := NewBlockChain(db, nil, config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+
+	processor := NewStateProcessor(config, chain, engine)
+	for i, parallel := range []bool{true, false} {
+		var (
+			block  = blocks[i]
+			parent = chain.GetHeaderByHash(block.ParentHash())
+			txs    = block.Transactions()
+		)
+		statedb, err := chain.StateAt(parent.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var (
+			usedGas      = new(uint64)
+			gp           = new(GasPool).AddGas(block.GasLimit())
+			internalTxs  = make([]*types.InternalTransaction, 0)
+			blockContext = NewEVMBlockContext(block.Header(), chain, nil)
+			indexes      = make([]int, len(txs))
+			blooms       = NewAsyncReceiptBloomGenerator(len(txs))
+		)
+		blockContext.InternalTransactions = &internalTxs
+		for j := range indexes {
+			indexes[j] = j
+		}
+		receipts, ok := processor.processParallel(block, statedb, vm.Config{}, &blockContext, txs, indexes, gp, usedGas, blooms)
+		blooms.Close()
+		if ok != parallel {
+			t.Fatalf("block %d: parallel execution mismatch: have %v, want %v", block.NumberU64(), ok, parallel)
+		}
+		if !ok {
+			if root := statedb.IntermediateRoot(true); root != parent.Root {
+				t.Fatalf("block %d: state changed by the failed parallel execution", block.NumberU64())
+			}
+			continue
+		}
+		if len(receipts) != len(txs) || *usedGas != block.GasUsed() {
+			t.Fatalf("block %d: receipts mismatch: have %d receipts using %d gas, want %d using %d", block.NumberU64(), len(receipts), *usedGas, len(txs), block.GasUsed())
+		}
+		// The block reward is not paid by processParallel, the accounts of the
+		// transactions are compared to the imported state
+		imported, _ := chain.StateAt(block.Root())
+		for j, tx := range txs {
+			for _, addr := range []common.Address{addrs[j], *tx.To()} {
+				if have, want := statedb.GetBalance(addr), imported.GetBalance(addr); have.Cmp(want) != 0 {
+					t.Fatalf("block %d tx %d: balance of %x mismatch: have %v, want %v", block.NumberU64(), j, addr, have, want)
+				}
+				if have, want := statedb.GetNonce(addr), imported.GetNonce(addr); have != want {
+					t.Fatalf("block %d tx %d: nonce of %x mismatch: have %d, want %d", block.NumberU64(), j, addr, have, want)
+				}
+			}
+		}
+	}
+}
@@ -531,6 +531,36 @@ func (h *priceHeap) Pop() interface{} {
 	return x
 }
 
+// sponsoredHeap is a heap.Interface implementation over the sponsored transactions
+// of their lane, sorted by gasFeeCap and then by expiry, so the cheapest ones
+// expiring first are evicted first.
+type sponsoredHeap struct {
+	list []*types.Transaction
+}
+
+func (h *sponsoredHeap) Len() int      { return len(h.list) }
+func (h *sponsoredHeap) Swap(i, j int) { h.list[i], h.list[j] = h.list[j], h.list[i] }
+
+func (h *sponsoredHeap) Less(i, j int) bool {
+	if c := h.list[i].GasFeeCapCmp(h.list[j]); c != 0 {
+		return c < 0
+	}
+	return h.list[i].ExpiredTime() < h.list[j].ExpiredTime()
+}
+
+func (h *sponsoredHeap) Push(x interface{}) {
+	h.list = append(h.list, x.(*types.Transaction))
+}
+
+func (h *sponsoredHeap) Pop() interface{} {
+	old := h.list
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	h.list = old[0 : n-1]
+	return x
+}
+
 // txPricedList is a price-sorted heap to allow operating on transactions pool
 // contents in a price-incrementing way. It's built opon the all transactions
 // in txpool but only interested in the remote part. It means only remote transactions
@@ -552,6 +582,9 @@ type txPricedList struct {
 	all              *txLookup  // Pointer to the map of all transactions
 	urgent, floating priceHeap  // Heaps of prices of all the stored **remote** transactions
 	reheapMu         sync.Mutex // Mutex asserts that only one routine is reheaping the list
+
+	sponsoredLane bool          // Whether the sponsored transactions are left out, they are evicted in their own lane
+	sponsored     sponsoredHeap // Heap of the stored **remote** sponsored transactions if they have their own lane
 }
 
 const (
@@ -560,18 +593,29 @@ const (
 	floatingRatio = 1
 )
 
-// newTxPricedList creates a new price-sorted transaction heap.
-func newTxPricedList(all *txLookup) *txPricedList {
+// newTxPricedList creates a new price-sorted transaction heap, the sponsored
+// transactions are left out if they have their own lane.
+func newTxPricedList(all *txLookup, sponsoredLane bool) *txPricedList {
 	return &txPricedList{
-		all: all,
+		all:           all,
+		sponsoredLane: sponsoredLane,
 	}
 }
 
+// tracks returns whether the remote transaction is tracked by the list
+func (l *txPricedList) tracks(tx *types.Transaction) bool {
+	return !l.sponsoredLane || tx.Type() != types.SponsoredTxType
+}
+
 // Put inserts a new transaction into the heap.
 func (l *txPricedList) Put(tx *types.Transaction, local bool) {
 	if local {
 		return
 	}
+	if !l.tracks(tx) {
+		heap.Push(&l.sponsored, tx)
+		return
+	}
 	// Insert every new transaction to the urgent heap first; Discard will balance the heaps
 	heap.Push(&l.urgent, tx)
 }
@@ -582,7 +626,7 @@ func (l *txPricedList) Put(tx *types.Transaction, local bool) {
 func (l *txPricedList) Removed(count int) {
 	// Bump the stale counter, but exit if still too low (< 25%)
 	stales := atomic.AddInt64(&l.stales, int64(count))
-	if int(stales) <= (len(l.urgent.list)+len(l.floating.list))/4 {
+	if int(stales) <= (len(l.urgent.list)+len(l.floating.list)+len(l.sponsored.list))/4 {
 		return
 	}
 	// Seems we've reached a critical number of stale transactions, reheap
@@ -663,6 +707,47 @@ func (l *txPricedList) Discard(slots int, force bool) (types.Transactions, bool)
 	return drop, true
 }
 
+// UnderpricedSponsored checks whether a sponsored transaction is cheaper than (or
+// as cheap as) the lowest priced remote transaction of the sponsored lane.
+func (l *txPricedList) UnderpricedSponsored(tx *types.Transaction) bool {
+	// Discard stale price points if found at the heap start
+	for len(l.sponsored.list) > 0 {
+		if l.all.GetRemote(l.sponsored.list[0].Hash()) != nil {
+			break
+		}
+		atomic.AddInt64(&l.stales, -1)
+		heap.Pop(&l.sponsored)
+	}
+	return len(l.sponsored.list) > 0 && l.sponsored.list[0].GasFeeCapCmp(tx) >= 0
+}
+
+// DiscardSponsored finds a number of the cheapest transactions of the sponsored
+// lane, removes them from the priced list and returns them for further removal
+// from the entire pool.
+//
+// Note local transaction won't be considered for eviction.
+func (l *txPricedList) DiscardSponsored(slots int, force bool) (types.Transactions, bool) {
+	drop := make(types.Transactions, 0, slots) // Remote underpriced sponsored transactions to drop
+	for slots > 0 && len(l.sponsored.list) > 0 {
+		// Discard stale transactions if found during cleanup
+		tx := heap.Pop(&l.sponsored).(*types.Transaction)
+		if l.all.GetRemote(tx.Hash()) == nil { // Removed or migrated
+			atomic.AddInt64(&l.stales, -1)
+			continue
+		}
+		drop = append(drop, tx)
+		slots -= numSlots(tx)
+	}
+	// If we still can't make enough room for the new transaction
+	if slots > 0 && !force {
+		for _, tx := range drop {
+			heap.Push(&l.sponsored, tx)
+		}
+		return nil, false
+	}
+	return drop, true
+}
+
 // Reheap forcibly rebuilds the heap based on the current remote transaction set.
 func (l *txPricedList) Reheap() {
 	l.reheapMu.Lock()
@@ -670,11 +755,17 @@ func (l *txPricedList) Reheap() {
 	start := time.Now()
 	atomic.StoreInt64(&l.stales, 0)
 	l.urgent.list = make([]*types.Transaction, 0, l.all.RemoteCount())
+	l.sponsored.list = nil
 	l.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
-		l.urgent.list = append(l.urgent.list, tx)
+		if l.tracks(tx) {
+			l.urgent.list = append(l.urgent.list, tx)
+		} else {
+			l.sponsored.list = append(l.sponsored.list, tx)
+		}
 		return true
 	}, false, true) // Only iterate remotes
 	heap.Init(&l.urgent)
+	heap.Init(&l.sponsored)
 
 	// balance out the two heaps by moving the worse half of transactions into the
 	// floating heap
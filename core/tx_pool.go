@@ -121,6 +121,7 @@ var (
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
 	overflowedTxMeter  = metrics.NewRegisteredMeter("txpool/overflowed", nil)
+	blacklistedTxMeter = metrics.NewRegisteredMeter("txpool/blacklisted", nil) // Dropped as the addresses got blacklisted
 	// throttleTxMeter counts how many transactions are rejected due to too-many-changes between
 	// txpool reorgs.
 	throttleTxMeter = metrics.NewRegisteredMeter("txpool/throttle", nil)
@@ -135,6 +136,8 @@ var (
 	localGauge   = metrics.NewRegisteredGauge("txpool/local", nil)
 	slotsGauge   = metrics.NewRegisteredGauge("txpool/slots", nil)
 
+	sponsoredSlotsGauge = metrics.NewRegisteredGauge("txpool/slots/sponsored", nil)
+
 	reheapTimer = metrics.NewRegisteredTimer("txpool/reheap", nil)
 )
 
@@ -174,6 +177,8 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	SponsoredSlots uint64 // Number of slots of the opt-in sponsored transaction lane on top of the global slots and queue (0 = no lane)
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -260,6 +265,7 @@ type TxPool struct {
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
 	currentMaxGas uint64         // Current gas limit for transaction caps
+	blacklistRoot common.Hash    // Storage root of the blacklist contract when the pool was last checked against it
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -317,7 +323,7 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
-	pool.priced = newTxPricedList(pool.all)
+	pool.priced = newTxPricedList(pool.all, pool.config.SponsoredSlots > 0)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
 	// Start the reorg loop early so it can handle requests generated during journal loading.
@@ -754,8 +760,17 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
+	// If the transaction pool is full, discard underpriced transactions. The
+	// sponsored transactions have their own lane, they neither take the slots of
+	// the other transactions nor get evicted to make room for them.
+	if pool.inSponsoredLane(tx) {
+		if uint64(pool.all.SponsoredSlots()+numSlots(tx)) > pool.config.SponsoredSlots {
+			if err := pool.discardSponsored(tx, local); err != nil {
+				log.Trace("Discarding sponsored transaction", "hash", hash, "err", err)
+				return false, err
+			}
+		}
+	} else if uint64(pool.regularSlots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
 		if !local && pool.priced.Underpriced(tx) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
@@ -774,7 +789,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 		// New transaction is better than our worse ones, make room for it.
 		// If it's a local transaction, forcibly discard all available transactions.
 		// Otherwise if we can't make enough room for new one, abort the operation.
-		drop, success := pool.priced.Discard(pool.all.Slots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), local)
+		drop, success := pool.priced.Discard(pool.regularSlots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), local)
 
 		// Special case, we still can't make the room for the new remote one.
 		if !local && !success {
@@ -836,6 +851,64 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 	return replaced, nil
 }
 
+// inSponsoredLane returns whether the transaction is in the sponsored lane
+func (pool *TxPool) inSponsoredLane(tx *types.Transaction) bool {
+	return pool.config.SponsoredSlots > 0 && tx.Type() == types.SponsoredTxType
+}
+
+// regularSlots returns the number of slots used by the transactions out of the
+// sponsored lane
+func (pool *TxPool) regularSlots() int {
+	if pool.config.SponsoredSlots == 0 {
+		return pool.all.Slots()
+	}
+	return pool.all.Slots() - pool.all.SponsoredSlots()
+}
+
+// discardSponsored makes room in the sponsored lane for the transaction by
+// evicting the cheapest remote sponsored transactions, the ones expiring first
+// among the equally priced. A remote transaction is rejected if it does not pay
+// more than the cheapest one, a local one is always accepted.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) discardSponsored(tx *types.Transaction, local bool) error {
+	if !local && pool.priced.UnderpricedSponsored(tx) {
+		underpricedTxMeter.Mark(1)
+		return ErrUnderpriced
+	}
+	if !local && pool.changesSinceReorg > int(pool.config.SponsoredSlots/4) {
+		throttleTxMeter.Mark(1)
+		return ErrTxPoolOverflow
+	}
+	drop, success := pool.priced.DiscardSponsored(pool.all.SponsoredSlots()+numSlots(tx)-int(pool.config.SponsoredSlots), local)
+	if !local && !success {
+		overflowedTxMeter.Mark(1)
+		return ErrTxPoolOverflow
+	}
+	pool.changesSinceReorg += len(drop)
+	for _, tx := range drop {
+		log.Trace("Discarding underpriced sponsored transaction", "hash", tx.Hash(), "gasFeeCap", tx.GasFeeCap(), "expiredTime", tx.ExpiredTime())
+		underpricedTxMeter.Mark(1)
+		pool.removeTx(tx.Hash(), false)
+	}
+	return nil
+}
+
+// sponsoredLen returns the number of transactions of the list in the sponsored
+// lane, they are left out of the global slots and queue.
+func (pool *TxPool) sponsoredLen(list *txList) int {
+	if pool.config.SponsoredSlots == 0 {
+		return 0
+	}
+	count := 0
+	for _, tx := range list.txs.items {
+		if tx.Type() == types.SponsoredTxType {
+			count++
+		}
+	}
+	return count
+}
+
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
 // Note, this method assumes the pool lock is held!
@@ -1261,6 +1334,7 @@ func (pool *TxPool) runReorg(done chan struct{}, reset *txpoolResetRequest, dirt
 	// remove any transaction that has been included in the block or was invalidated
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
+		pool.dropBlacklisted()
 		pool.demoteUnexecutables()
 		if reset.newHead != nil {
 			if pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
@@ -1476,9 +1550,16 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) []*types.Trans
 // pending limit. The algorithm tries to reduce transaction counts by an approximately
 // equal number for all for accounts with many pending transactions.
 func (pool *TxPool) truncatePending() {
+	// The transactions of the sponsored lane are not counted, the accounts with
+	// any are not truncated not to drop or gap them
 	pending := uint64(0)
-	for _, list := range pool.pending {
-		pending += uint64(list.Len())
+	sponsored := make(map[common.Address]bool)
+	for addr, list := range pool.pending {
+		count := pool.sponsoredLen(list)
+		if count > 0 {
+			sponsored[addr] = true
+		}
+		pending += uint64(list.Len() - count)
 	}
 	if pending <= pool.config.GlobalSlots {
 		return
@@ -1489,7 +1570,7 @@ func (pool *TxPool) truncatePending() {
 	spammers := prque.New(nil)
 	for addr, list := range pool.pending {
 		// Only evict transactions from high rollers
-		if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
+		if !pool.locals.contains(addr) && !sponsored[addr] && uint64(list.Len()) > pool.config.AccountSlots {
 			spammers.Push(addr, int64(list.Len()))
 		}
 	}
@@ -1561,9 +1642,10 @@ func (pool *TxPool) truncatePending() {
 
 // truncateQueue drops the oldes transactions in the queue if the pool is above the global queue limit.
 func (pool *TxPool) truncateQueue() {
+	// The transactions of the sponsored lane are neither counted nor dropped
 	queued := uint64(0)
 	for _, list := range pool.queue {
-		queued += uint64(list.Len())
+		queued += uint64(list.Len() - pool.sponsoredLen(list))
 	}
 	if queued <= pool.config.GlobalQueue {
 		return
@@ -1586,7 +1668,7 @@ func (pool *TxPool) truncateQueue() {
 		addresses = addresses[:len(addresses)-1]
 
 		// Drop all transactions if they are less than the overflow
-		if size := uint64(list.Len()); size <= drop {
+		if size := uint64(list.Len()); size <= drop && pool.sponsoredLen(list) == 0 {
 			for _, tx := range list.Flatten() {
 				pool.removeTx(tx.Hash(), true)
 			}
@@ -1597,6 +1679,9 @@ func (pool *TxPool) truncateQueue() {
 		// Otherwise drop only last few transactions
 		txs := list.Flatten()
 		for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
+			if pool.inSponsoredLane(txs[i]) {
+				continue
+			}
 			pool.removeTx(txs[i].Hash(), true)
 			drop--
 			queuedRateLimitMeter.Mark(1)
@@ -1670,6 +1755,43 @@ func (pool *TxPool) demoteUnexecutables() {
 	}
 }
 
+// dropBlacklisted removes the transactions from, to or paid by the addresses
+// blacklisted since they were accepted, they would otherwise stay in the pool
+// as they are only refused by the block execution. The pool is only checked
+// again when the storage of the blacklist contract changes.
+func (pool *TxPool) dropBlacklisted() {
+	contract := pool.chainconfig.BlacklistContractAddress
+	if pool.chainconfig.Consortium == nil || !pool.odysseus || contract == nil {
+		return
+	}
+	root := pool.currentState.GetStorageRoot(*contract)
+	if root == pool.blacklistRoot {
+		return
+	}
+	pool.blacklistRoot = root
+
+	var drops []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+		payer := from
+		if tx.Type() == types.SponsoredTxType {
+			payer, _ = types.Payer(pool.signer, tx)
+		}
+		if state.IsAddressBlacklisted(pool.currentState, contract, &from) ||
+			state.IsAddressBlacklisted(pool.currentState, contract, tx.To()) ||
+			state.IsAddressBlacklisted(pool.currentState, contract, &payer) {
+			drops = append(drops, hash)
+		}
+		return true
+	}, true, true)
+
+	for _, hash := range drops {
+		log.Debug("Removed blacklisted transaction", "hash", hash)
+		pool.removeTx(hash, true)
+	}
+	blacklistedTxMeter.Mark(int64(len(drops)))
+}
+
 // addressByHeartbeat is an account address tagged with its last activity timestamp.
 type addressByHeartbeat struct {
 	address   common.Address
@@ -1769,10 +1891,11 @@ func (as *accountSet) merge(other *accountSet) {
 // This lookup set combines the notion of "local transactions", which is useful
 // to build upper-level structure.
 type txLookup struct {
-	slots   int
-	lock    sync.RWMutex
-	locals  map[common.Hash]*types.Transaction
-	remotes map[common.Hash]*types.Transaction
+	slots          int
+	sponsoredSlots int // Slots used by the sponsored transactions
+	lock           sync.RWMutex
+	locals         map[common.Hash]*types.Transaction
+	remotes        map[common.Hash]*types.Transaction
 }
 
 // newTxLookup returns a new txLookup structure.
@@ -1865,6 +1988,15 @@ func (t *txLookup) Slots() int {
 	return t.slots
 }
 
+// SponsoredSlots returns the current number of slots used by the sponsored
+// transactions in the lookup.
+func (t *txLookup) SponsoredSlots() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.sponsoredSlots
+}
+
 // Add adds a transaction to the lookup.
 func (t *txLookup) Add(tx *types.Transaction, local bool) {
 	t.lock.Lock()
@@ -1872,6 +2004,10 @@ func (t *txLookup) Add(tx *types.Transaction, local bool) {
 
 	t.slots += numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
+	if tx.Type() == types.SponsoredTxType {
+		t.sponsoredSlots += numSlots(tx)
+		sponsoredSlotsGauge.Update(int64(t.sponsoredSlots))
+	}
 
 	if local {
 		t.locals[tx.Hash()] = tx
@@ -1895,6 +2031,10 @@ func (t *txLookup) Remove(hash common.Hash) {
 	}
 	t.slots -= numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
+	if tx.Type() == types.SponsoredTxType {
+		t.sponsoredSlots -= numSlots(tx)
+		sponsoredSlotsGauge.Update(int64(t.sponsoredSlots))
+	}
 
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
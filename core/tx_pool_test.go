@@ -142,7 +142,14 @@ func validateTxPoolInternals(pool *TxPool) error {
 		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
 	}
 	pool.priced.Reheap()
-	priced, remote := pool.priced.urgent.Len()+pool.priced.floating.Len(), pool.all.RemoteCount()
+	var remote int
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		if pool.priced.tracks(tx) {
+			remote++
+		}
+		return true
+	}, false, true)
+	priced := pool.priced.urgent.Len() + pool.priced.floating.Len()
 	if priced != remote {
 		return fmt.Errorf("total priced transaction count %d != %d", priced, remote)
 	}
@@ -3088,3 +3095,221 @@ func TestSponsoredTxInTxPoolQueue(t *testing.T) {
 		t.Fatalf("Queued txpool, expect %d get %d", 0, queued)
 	}
 }
+
+// sponsoredTransaction returns a sponsored transaction of the key whose gas is
+// paid by the payer key
+func sponsoredTransaction(t *testing.T, nonce uint64, gasPrice int64, expiredTime uint64, key, payerKey *ecdsa.PrivateKey) *types.Transaction {
+	recipient := common.HexToAddress("1000000000000000000000000000000000000001")
+	innerTx := types.SponsoredTx{
+		ChainID:     big.NewInt(2020),
+		Nonce:       nonce,
+		GasTipCap:   big.NewInt(gasPrice),
+		GasFeeCap:   big.NewInt(gasPrice),
+		Gas:         22000,
+		To:          &recipient,
+		Value:       big.NewInt(10),
+		ExpiredTime: expiredTime,
+	}
+	var err error
+	mikoSigner := types.NewMikoSigner(big.NewInt(2020))
+	innerTx.PayerR, innerTx.PayerS, innerTx.PayerV, err = types.PayerSign(payerKey, mikoSigner, crypto.PubkeyToAddress(key.PublicKey), &innerTx)
+	if err != nil {
+		t.Fatalf("Payer fails to sign transaction, err %s", err)
+	}
+	tx, err := types.SignNewTx(key, mikoSigner, &innerTx)
+	if err != nil {
+		t.Fatalf("Fail to sign transaction, err %s", err)
+	}
+	return tx
+}
+
+// TestSponsoredTxLane tests that the sponsored transactions are kept in their
+// own lane: they do not take the slots of the other transactions, are not
+// evicted by them, and the cheapest of them are evicted when the lane is full.
+func TestSponsoredTxLane(t *testing.T) {
+	var chainConfig params.ChainConfig
+
+	chainConfig.EIP155Block = common.Big0
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.SponsoredSlots = 2
+
+	pool := NewTxPool(config, &chainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 8)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	payerKey, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(payerKey.PublicKey), big.NewInt(1000000000))
+
+	// Fill the regular slots
+	for i := 0; i < 4; i++ {
+		if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(2), keys[i])); err != nil {
+			t.Fatalf("Failed to add transaction %d, err %s", i, err)
+		}
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[4])); err != ErrUnderpriced {
+		t.Fatalf("Expect error %s, get %v", ErrUnderpriced, err)
+	}
+
+	// The sponsored transactions are accepted in their lane
+	cheap := sponsoredTransaction(t, 0, 1, 10000, keys[5], payerKey)
+	if err := pool.addRemoteSync(cheap); err != nil {
+		t.Fatalf("Expect the sponsored transaction to be added, get %s", err)
+	}
+	if err := pool.addRemoteSync(sponsoredTransaction(t, 0, 3, 10000, keys[6], payerKey)); err != nil {
+		t.Fatalf("Expect the sponsored transaction to be added, get %s", err)
+	}
+	if slots := pool.all.SponsoredSlots(); slots != 2 {
+		t.Fatalf("Expect 2 sponsored slots, get %d", slots)
+	}
+
+	// The regular transactions evict the regular ones only
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(5), keys[4])); err != nil {
+		t.Fatalf("Failed to add well priced transaction, err %s", err)
+	}
+	if pool.Get(cheap.Hash()) == nil || pool.all.SponsoredSlots() != 2 {
+		t.Fatalf("Expect the sponsored transactions not to be evicted by the regular one")
+	}
+
+	// The full lane evicts its cheapest transaction for a better priced one
+	if err := pool.addRemoteSync(sponsoredTransaction(t, 0, 1, 20000, keys[7], payerKey)); err != ErrUnderpriced {
+		t.Fatalf("Expect error %s, get %v", ErrUnderpriced, err)
+	}
+	if err := pool.addRemoteSync(sponsoredTransaction(t, 0, 2, 20000, keys[7], payerKey)); err != nil {
+		t.Fatalf("Failed to add well priced sponsored transaction, err %s", err)
+	}
+	if pool.Get(cheap.Hash()) != nil {
+		t.Fatalf("Expect the cheapest sponsored transaction to be evicted")
+	}
+	if slots := pool.all.SponsoredSlots(); slots != 2 {
+		t.Fatalf("Expect 2 sponsored slots, get %d", slots)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// TestSponsoredTxLaneTruncation tests that the sponsored transactions are left
+// out of the truncation of the global queue.
+func TestSponsoredTxLaneTruncation(t *testing.T) {
+	var chainConfig params.ChainConfig
+
+	chainConfig.EIP155Block = common.Big0
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalQueue = 2
+	config.SponsoredSlots = 4
+
+	pool := NewTxPool(config, &chainConfig, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	sponsoredKey, _ := crypto.GenerateKey()
+	payerKey, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{key, sponsoredKey, payerKey} {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+	}
+
+	// Queue the sponsored transactions first, they are the oldest ones
+	sponsored := types.Transactions{
+		sponsoredTransaction(t, 1, 1, 10000, sponsoredKey, payerKey),
+		sponsoredTransaction(t, 2, 1, 10000, sponsoredKey, payerKey),
+	}
+	for _, err := range pool.AddRemotesSync(sponsored) {
+		if err != nil {
+			t.Fatalf("Failed to add sponsored transaction, err %s", err)
+		}
+	}
+	var regular types.Transactions
+	for nonce := uint64(1); nonce <= 3; nonce++ {
+		regular = append(regular, pricedTransaction(nonce, 100000, big.NewInt(1), key))
+	}
+	for _, err := range pool.AddRemotesSync(regular) {
+		if err != nil {
+			t.Fatalf("Failed to add transaction, err %s", err)
+		}
+	}
+
+	if _, queued := pool.Stats(); queued != 4 {
+		t.Fatalf("Expect %d queued transactions, get %d", 4, queued)
+	}
+	for _, tx := range sponsored {
+		if pool.Get(tx.Hash()) == nil {
+			t.Fatalf("Expect the sponsored transaction %d to be kept", tx.Nonce())
+		}
+	}
+	if pool.Get(regular[2].Hash()) != nil {
+		t.Fatalf("Expect the last regular transaction to be dropped")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the transactions of the addresses blacklisted after they are
+// accepted are dropped at the next reset.
+func TestDropBlacklistedTransactions(t *testing.T) {
+	t.Parallel()
+
+	contract := common.HexToAddress("0xb1ac")
+	chainConfig := *params.TestChainConfig
+	chainConfig.OdysseusBlock = common.Big0
+	chainConfig.Consortium = &params.ConsortiumConfig{}
+	chainConfig.BlacklistContractAddress = &contract
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	// The consortium chains reserve gas for the system transactions
+	blockchain := &testBlockChain{params.ReservedGasForSystemTransactions + 10000000, statedb, new(event.Feed)}
+	pool := NewTxPool(testTxPoolConfig, &chainConfig, blockchain)
+	defer pool.Stop()
+
+	blacklisted, _ := crypto.GenerateKey()
+	allowed, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{blacklisted, allowed} {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			if err := pool.addRemoteSync(pricedTransaction(nonce, 100000, big.NewInt(1), key)); err != nil {
+				t.Fatalf("failed to add transaction: %v", err)
+			}
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 4 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 4)
+	}
+
+	// Blacklist the sender and commit the storage of the contract
+	addr := crypto.PubkeyToAddress(blacklisted.PublicKey)
+	statedb.SetCode(contract, []byte{0x0})
+	statedb.SetState(contract, state.GetLocMappingAtKey(addr.Hash(), 1), common.BigToHash(common.Big1))
+	statedb.IntermediateRoot(true)
+	<-pool.requestReset(nil, nil)
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 0 {
+		t.Fatalf("transactions mismatched: have %d pending %d queued, want 2 pending", pending, queued)
+	}
+	if pool.Get(pricedTransaction(0, 100000, big.NewInt(1), blacklisted).Hash()) != nil {
+		t.Fatalf("blacklisted transaction still in the pool")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(2, 100000, big.NewInt(1), blacklisted)); !errors.Is(err, ErrAddressBlacklisted) {
+		t.Fatalf("expected %v, got %v", ErrAddressBlacklisted, err)
+	}
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"bytes"
+	"math/big"
 	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/params"
@@ -19,8 +21,37 @@ type ValidatorsBitSet uint64
 type VoteData struct {
 	TargetNumber uint64      // The target block number which validator wants to vote for.
 	TargetHash   common.Hash // The block hash of the target block.
+
+	// The fields of the vote v2, set from the Tripp hardfork. They are omitted
+	// from the encoding of the votes before the hardfork.
+	ChainID      *big.Int    `rlp:"optional"` // The chain id of the network, prevents replaying the vote on another network.
+	SourceNumber uint64      `rlp:"optional"` // The justified block number as of the target block.
+	SourceHash   common.Hash `rlp:"optional"` // The block hash of the justified block.
+	TargetEpoch  uint64      `rlp:"optional"` // The epoch of the target block.
+}
+
+// NewVoteData returns the vote data for the target block, the source is the
+// justified block as of the target block. From the Tripp hardfork, the vote
+// also commits to the chain id, the source and the epoch of the target block.
+func NewVoteData(config *params.ChainConfig, targetNumber uint64, targetHash common.Hash, sourceNumber uint64, sourceHash common.Hash) *VoteData {
+	data := &VoteData{
+		TargetNumber: targetNumber,
+		TargetHash:   targetHash,
+	}
+	if config.IsTripp(new(big.Int).SetUint64(targetNumber)) {
+		data.ChainID = new(big.Int).Set(config.ChainID)
+		data.SourceNumber = sourceNumber
+		data.SourceHash = sourceHash
+		if config.Consortium != nil && config.Consortium.EpochV2 != 0 {
+			data.TargetEpoch = targetNumber / config.Consortium.EpochV2
+		}
+	}
+	return data
 }
 
+// IsV2 returns whether the vote data has the vote v2 fields.
+func (d *VoteData) IsV2() bool { return d.ChainID != nil }
+
 // Hash returns the hash of the vote data.
 func (d *VoteData) Hash() common.Hash { return rlpHash(d) }
 
@@ -63,6 +94,60 @@ func (v *VoteEnvelope) calcVoteHash() common.Hash {
 	return rlpHash(vote)
 }
 
+// AggregatedVote is the finality votes of a quorum of the validators for the
+// same vote data, aggregated in a single signature. It is relayed in place of
+// the individual votes once a node has collected the quorum.
+type AggregatedVote struct {
+	VotedValidators ValidatorsBitSet // Positions of the voters in the validator set of the target block.
+	Signature       BLSSignature     // Aggregated signature of the voters for the vote data.
+	Data            *VoteData        // The vote data for fast finality.
+
+	// Positions of the voters that signed with their previous BLS key during a
+	// key rotation, a subset of the voters.
+	PreviousKeyVoters ValidatorsBitSet `rlp:"optional"`
+}
+
+// Hash returns the hash of the aggregated vote.
+func (v *AggregatedVote) Hash() common.Hash { return rlpHash(v) }
+
+// VoteEquivocation is the proof that a validator signed two conflicting finality
+// votes with the same BLS key, i.e. two votes with the same target number but
+// different vote data.
+type VoteEquivocation struct {
+	First  *RawVoteEnvelope
+	Second *RawVoteEnvelope
+}
+
+// Hash returns the hash of the equivocation proof, it does not depend on the
+// order of the votes.
+func (e *VoteEquivocation) Hash() common.Hash {
+	first, second := e.First.Data.Hash(), e.Second.Data.Hash()
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+	return rlpHash([]interface{}{e.First.PublicKey, first, second})
+}
+
+// Verify checks that the votes of the proof are signed with the same BLS key and
+// conflict with each other.
+func (e *VoteEquivocation) Verify() error {
+	if e.First == nil || e.Second == nil || e.First.Data == nil || e.Second.Data == nil {
+		return errors.New("missing vote")
+	}
+	if e.First.PublicKey != e.Second.PublicKey {
+		return errors.New("votes signed with different keys")
+	}
+	if e.First.Data.TargetNumber != e.Second.Data.TargetNumber || e.First.Data.Hash() == e.Second.Data.Hash() {
+		return errors.New("votes do not conflict")
+	}
+	for _, vote := range []*RawVoteEnvelope{e.First, e.Second} {
+		if err := (&VoteEnvelope{RawVoteEnvelope: *vote}).Verify(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b BLSPublicKey) Bytes() []byte { return b[:] }
 
 // Verify vote using BLS.
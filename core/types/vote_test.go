@@ -0,0 +1,54 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestVoteDataV2(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:    big.NewInt(2021),
+		TrippBlock: big.NewInt(100),
+		Consortium: &params.ConsortiumConfig{EpochV2: 200},
+	}
+	targetHash, sourceHash := common.Hash{0x1}, common.Hash{0x2}
+
+	// The vote before the hardfork is encoded as the v1 vote
+	v1 := NewVoteData(config, 99, targetHash, 98, sourceHash)
+	if v1.IsV2() {
+		t.Fatalf("Expect a v1 vote before the hardfork")
+	}
+	legacy := struct {
+		TargetNumber uint64
+		TargetHash   common.Hash
+	}{99, targetHash}
+	if v1.Hash() != rlpHash(legacy) {
+		t.Fatalf("The v1 vote hash mismatches the legacy encoding")
+	}
+
+	v2 := NewVoteData(config, 450, targetHash, 449, sourceHash)
+	if !v2.IsV2() || v2.ChainID.Cmp(config.ChainID) != 0 || v2.SourceNumber != 449 || v2.SourceHash != sourceHash || v2.TargetEpoch != 2 {
+		t.Fatalf("Unexpected v2 vote %+v", v2)
+	}
+	enc, err := rlp.EncodeToBytes(v2)
+	if err != nil {
+		t.Fatalf("Failed to encode vote, err %s", err)
+	}
+	var decoded VoteData
+	if err := rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatalf("Failed to decode vote, err %s", err)
+	}
+	if decoded.Hash() != v2.Hash() || !decoded.IsV2() {
+		t.Fatalf("The decoded v2 vote mismatches")
+	}
+
+	// The vote for another network has another hash
+	config.ChainID = big.NewInt(2020)
+	if NewVoteData(config, 450, targetHash, 449, sourceHash).Hash() == v2.Hash() {
+		t.Fatalf("Expect the votes of different networks to have different hashes")
+	}
+}
@@ -30,7 +30,7 @@ var (
 	consortiumVerifyHeadersAbi      = `[{"outputs":[],"name":"getHeader","inputs":[{"internalType":"uint256","name":"chainId","type":"uint256"},{"internalType":"bytes32","name":"parentHash","type":"bytes32"},{"internalType":"bytes32","name":"ommersHash","type":"bytes32"},{"internalType":"address","name":"coinbase","type":"address"},{"internalType":"bytes32","name":"stateRoot","type":"bytes32"},{"internalType":"bytes32","name":"transactionsRoot","type":"bytes32"},{"internalType":"bytes32","name":"receiptsRoot","type":"bytes32"},{"internalType":"uint8[256]","name":"logsBloom","type":"uint8[256]"},{"internalType":"uint256","name":"difficulty","type":"uint256"},{"internalType":"uint256","name":"number","type":"uint256"},{"internalType":"uint64","name":"gasLimit","type":"uint64"},{"internalType":"uint64","name":"gasUsed","type":"uint64"},{"internalType":"uint64","name":"timestamp","type":"uint64"},{"internalType":"bytes","name":"extraData","type":"bytes"},{"internalType":"bytes32","name":"mixHash","type":"bytes32"},{"internalType":"uint64","name":"nonce","type":"uint64"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"address","name":"consensusAddr","type":"address"},{"internalType":"bytes","name":"header1","type":"bytes"},{"internalType":"bytes","name":"header2","type":"bytes"}],"name":"validatingDoubleSignProof","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
 	consortiumPickValidatorSetAbi   = `[{"inputs":[],"stateMutability":"nonpayable","type":"constructor"},{"inputs":[{"internalType":"address[]","name":"_candidates","type":"address[]"},{"internalType":"uint256[]","name":"_weights","type":"uint256[]"},{"internalType":"uint256[]","name":"_trustedWeights","type":"uint256[]"},{"internalType":"uint256","name":"_maxValidatorNumber","type":"uint256"},{"internalType":"uint256","name":"_maxPrioritizedValidatorNumber","type":"uint256"}],"name":"pickValidatorSet","outputs":[{"internalType":"address[]","name":"_validators","type":"address[]"}],"stateMutability":"view","type":"function"}]`
 	getDoubleSignSlashingConfigsAbi = `[{"inputs":[],"name":"getDoubleSignSlashingConfigs","outputs":[{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"},{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
-	validateFinalityVoteProofAbi    = `[{"inputs":[{"internalType":"bytes","name":"voterPublicKey","type":"bytes"},{"internalType":"uint256","name":"targetBlockNumber","type":"uint256"},{"internalType":"bytes32[2]","name":"targetBlockHash","type":"bytes32[2]"},{"internalType":"bytes[][2]","name":"listOfPublicKey","type":"bytes[][2]"},{"internalType":"bytes[2]","name":"aggregatedSignature","type":"bytes[2]"}],"name":"validateFinalityVoteProof","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+	validateFinalityVoteProofAbi    = `[{"inputs":[{"internalType":"bytes","name":"voterPublicKey","type":"bytes"},{"internalType":"uint256","name":"targetBlockNumber","type":"uint256"},{"internalType":"bytes32[2]","name":"targetBlockHash","type":"bytes32[2]"},{"internalType":"bytes[][2]","name":"listOfPublicKey","type":"bytes[][2]"},{"internalType":"bytes[2]","name":"aggregatedSignature","type":"bytes[2]"}],"name":"validateFinalityVoteProof","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"},{"inputs":[{"internalType":"bytes","name":"voterPublicKey","type":"bytes"},{"internalType":"uint256","name":"chainId","type":"uint256"},{"internalType":"uint256","name":"targetBlockNumber","type":"uint256"},{"internalType":"uint256","name":"targetEpoch","type":"uint256"},{"internalType":"bytes32[2]","name":"targetBlockHash","type":"bytes32[2]"},{"internalType":"uint256[2]","name":"sourceBlockNumber","type":"uint256[2]"},{"internalType":"bytes32[2]","name":"sourceBlockHash","type":"bytes32[2]"},{"internalType":"bytes[][2]","name":"listOfPublicKey","type":"bytes[][2]"},{"internalType":"bytes[2]","name":"aggregatedSignature","type":"bytes[2]"}],"name":"validateFinalityVoteProofV2","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
 )
 
 const (
@@ -44,8 +44,9 @@ const (
 	getDoubleSignSlashingConfigs = "getDoubleSignSlashingConfigs"
 	extraVanity                  = 32
 
-	validateFinalityVoteProof = "validateFinalityVoteProof"
-	maxBlsPublicKeyListLength = 100
+	validateFinalityVoteProof   = "validateFinalityVoteProof"
+	validateFinalityVoteProofV2 = "validateFinalityVoteProofV2"
+	maxBlsPublicKeyListLength   = 100
 )
 
 func PrecompiledContractsConsortium(caller ContractRef, evm *EVM) map[common.Address]PrecompiledContract {
@@ -498,6 +499,12 @@ func (c *consortiumVerifyHeaders) verify(consensusAddr common.Address, header1,
 		bytes.Equal(consensusAddr.Bytes(), signer1.Bytes())
 }
 
+// EncodeDoubleSignHeader ABI encodes the header in the format accepted by the
+// double sign proof verification precompiled contract.
+func EncodeDoubleSignHeader(header *types.Header, chainId *big.Int) ([]byte, error) {
+	return types.FromHeader(header, chainId).Bytes(consortiumVerifyHeadersAbi, getHeader)
+}
+
 // SealHash returns the hash of a block prior to it being sealed.
 func SealHash(header *types.Header, chainId *big.Int) (hash common.Hash) {
 	hasher := sha3.NewLegacyKeccak256()
@@ -554,9 +561,49 @@ func (contract *consortiumValidateFinalityProof) Run(input []byte) ([]byte, erro
 	if err != nil {
 		return nil, err
 	}
-	if method.Name != validateFinalityVoteProof {
+	var proof *finalityVoteProof
+	switch method.Name {
+	case validateFinalityVoteProof:
+		proof, err = decodeFinalityVoteProof(args)
+	case validateFinalityVoteProofV2:
+		proof, err = decodeFinalityVoteProofV2(args)
+	default:
 		return nil, errors.New("invalid method")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	// From Tripp, the votes sign the v2 vote data of their chain and epoch
+	if contract.evm != nil {
+		config := contract.evm.ChainConfig()
+		if config.IsTripp(new(big.Int).SetUint64(proof.voteData[0].TargetNumber)) != proof.voteData[0].IsV2() {
+			return nil, errors.New("vote data version mismatches the target block")
+		}
+		if proof.voteData[0].IsV2() {
+			if proof.voteData[0].ChainID.Cmp(config.ChainID) != 0 {
+				return nil, errors.New("invalid chain id")
+			}
+			if config.Consortium != nil && config.Consortium.EpochV2 != 0 && proof.voteData[0].TargetEpoch != proof.voteData[0].TargetNumber/config.Consortium.EpochV2 {
+				return nil, errors.New("invalid target epoch")
+			}
+		}
+	}
+	return proof.verify(method)
+}
+
+// finalityVoteProof is the proof that a voter signed two conflicting finality
+// votes for the same target block number
+type finalityVoteProof struct {
+	voterPublicKey          []byte
+	voteData                [2]*types.VoteData
+	listOfRawPublicKey      [2][][]byte
+	rawAggregatedSignatures [2][]byte
+}
+
+// decodeFinalityVoteProof decodes the arguments of validateFinalityVoteProof,
+// the votes sign the vote data before Tripp
+func decodeFinalityVoteProof(args []interface{}) (*finalityVoteProof, error) {
 	if len(args) != 5 {
 		return nil, fmt.Errorf("invalid arguments, expect 5 got %d", len(args))
 	}
@@ -593,15 +640,112 @@ func (contract *consortiumValidateFinalityProof) Run(input []byte) ([]byte, erro
 		return nil, errors.New("invalid aggregated signature")
 	}
 
-	voterPublicKey, err := blst.PublicKeyFromBytes(rawVoterPublicKey)
+	proof := &finalityVoteProof{
+		voterPublicKey:          rawVoterPublicKey,
+		listOfRawPublicKey:      listOfRawPublicKey,
+		rawAggregatedSignatures: rawAggregatedSignatures,
+	}
+	for block := 0; block < 2; block++ {
+		proof.voteData[block] = &types.VoteData{
+			TargetNumber: targetBlockNumber.Uint64(),
+			TargetHash:   targetBlockHashes[block],
+		}
+	}
+	return proof, nil
+}
+
+// decodeFinalityVoteProofV2 decodes the arguments of validateFinalityVoteProofV2,
+// the votes sign the v2 vote data from Tripp. The votes conflict if their vote
+// data differ, either by the target block hash or by the source block.
+func decodeFinalityVoteProofV2(args []interface{}) (*finalityVoteProof, error) {
+	if len(args) != 9 {
+		return nil, fmt.Errorf("invalid arguments, expect 9 got %d", len(args))
+	}
+
+	rawVoterPublicKey, ok := args[0].([]byte)
+	if !ok {
+		return nil, errors.New("invalid voter public key")
+	}
+
+	chainID, ok := args[1].(*big.Int)
+	if !ok {
+		return nil, errors.New("invalid chain id")
+	}
+
+	targetBlockNumber, ok := args[2].(*big.Int)
+	if !ok {
+		return nil, errors.New("invalid target block number")
+	}
+	if !targetBlockNumber.IsUint64() {
+		return nil, errors.New("malformed target block number")
+	}
+
+	targetEpoch, ok := args[3].(*big.Int)
+	if !ok || !targetEpoch.IsUint64() {
+		return nil, errors.New("invalid target epoch")
+	}
+
+	targetBlockHashes, ok := args[4].([2][32]byte)
+	if !ok {
+		return nil, errors.New("invalid target block hashes")
+	}
+
+	sourceBlockNumbers, ok := args[5].([2]*big.Int)
+	if !ok {
+		return nil, errors.New("invalid source block numbers")
+	}
+
+	sourceBlockHashes, ok := args[6].([2][32]byte)
+	if !ok {
+		return nil, errors.New("invalid source block hashes")
+	}
+
+	listOfRawPublicKey, ok := args[7].([2][][]byte)
+	if !ok {
+		return nil, errors.New("invalid list of public keys")
+	}
+
+	rawAggregatedSignatures, ok := args[8].([2][]byte)
+	if !ok {
+		return nil, errors.New("invalid aggregated signature")
+	}
+
+	proof := &finalityVoteProof{
+		voterPublicKey:          rawVoterPublicKey,
+		listOfRawPublicKey:      listOfRawPublicKey,
+		rawAggregatedSignatures: rawAggregatedSignatures,
+	}
+	for block := 0; block < 2; block++ {
+		if !sourceBlockNumbers[block].IsUint64() || sourceBlockNumbers[block].Uint64() >= targetBlockNumber.Uint64() {
+			return nil, errors.New("malformed source block number")
+		}
+		proof.voteData[block] = &types.VoteData{
+			TargetNumber: targetBlockNumber.Uint64(),
+			TargetHash:   targetBlockHashes[block],
+			ChainID:      chainID,
+			SourceNumber: sourceBlockNumbers[block].Uint64(),
+			SourceHash:   sourceBlockHashes[block],
+			TargetEpoch:  targetEpoch.Uint64(),
+		}
+	}
+	if proof.voteData[0].Hash() == proof.voteData[1].Hash() {
+		return nil, errors.New("vote data is the same")
+	}
+	return proof, nil
+}
+
+// verify checks that the voter is in both lists of public keys and that the
+// aggregated signatures of the lists are valid for the vote data
+func (proof *finalityVoteProof) verify(method *abi.Method) ([]byte, error) {
+	voterPublicKey, err := blst.PublicKeyFromBytes(proof.voterPublicKey)
 	if err != nil {
 		return nil, errors.New("malformed voter public key")
 	}
 
 	var listOfPublicKey [2][]blsCommon.PublicKey
-	for block := range listOfRawPublicKey {
+	for block := range proof.listOfRawPublicKey {
 		voterInPublicKeyList := false
-		for _, rawKey := range listOfRawPublicKey[block] {
+		for _, rawKey := range proof.listOfRawPublicKey[block] {
 			publicKey, err := blst.PublicKeyFromBytes(rawKey)
 			if err != nil {
 				return nil, errors.New("malformed public key in list of public keys")
@@ -626,7 +770,7 @@ func (contract *consortiumValidateFinalityProof) Run(input []byte) ([]byte, erro
 	}
 
 	var aggregatedSignature [2]blsCommon.Signature
-	for block, rawSignature := range rawAggregatedSignatures {
+	for block, rawSignature := range proof.rawAggregatedSignatures {
 		signature, err := blst.SignatureFromBytes(rawSignature)
 		if err != nil {
 			return nil, errors.New("malformed signature")
@@ -636,11 +780,7 @@ func (contract *consortiumValidateFinalityProof) Run(input []byte) ([]byte, erro
 	}
 
 	for block := 0; block < 2; block++ {
-		voteData := types.VoteData{
-			TargetNumber: targetBlockNumber.Uint64(),
-			TargetHash:   targetBlockHashes[block],
-		}
-		digest := voteData.Hash()
+		digest := proof.voteData[block].Hash()
 		if !aggregatedSignature[block].FastAggregateVerify(listOfPublicKey[block], digest) {
 			return nil, errors.New("failed to verify signature")
 		}
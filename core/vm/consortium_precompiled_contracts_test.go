@@ -1890,6 +1890,107 @@ func TestValidateFinalityVoteProof(t *testing.T) {
 	}
 }
 
+func TestValidateFinalityVoteProofV2(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:    big.NewInt(2021),
+		TrippBlock: big.NewInt(100),
+		Consortium: &params.ConsortiumConfig{EpochV2: 200},
+	}
+	contract := &consortiumValidateFinalityProof{evm: &EVM{chainConfig: config}}
+	contractAbi, err := abi.JSON(strings.NewReader(validateFinalityVoteProofAbi))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI, err %s", err)
+	}
+
+	var secretKeys [3]blsCommon.SecretKey
+	for i := range secretKeys {
+		if secretKeys[i], err = blst.RandKey(); err != nil {
+			t.Fatalf("Failed to generate key, err %s", err)
+		}
+	}
+	// The voter 0 votes for the same target from two different sources
+	var (
+		targetNumber     uint64 = 250
+		targetHashes            = [2]common.Hash{{0x1}, {0x1}}
+		sourceNumbers           = [2]*big.Int{big.NewInt(248), big.NewInt(249)}
+		sourceHashes            = [2]common.Hash{{0x2}, {0x3}}
+		listOfPublicKeys        = [2][][]byte{
+			{secretKeys[0].PublicKey().Marshal(), secretKeys[1].PublicKey().Marshal()},
+			{secretKeys[0].PublicKey().Marshal(), secretKeys[2].PublicKey().Marshal()},
+		}
+		aggregatedSignatures [2][]byte
+	)
+	for block := 0; block < 2; block++ {
+		digest := types.NewVoteData(config, targetNumber, targetHashes[block], sourceNumbers[block].Uint64(), sourceHashes[block]).Hash()
+		aggregatedSignatures[block] = blst.AggregateSignatures([]blsCommon.Signature{
+			secretKeys[0].Sign(digest[:]),
+			secretKeys[block+1].Sign(digest[:]),
+		}).Marshal()
+	}
+	pack := func(chainID *big.Int, targetNumber, targetEpoch uint64, sourceNumbers [2]*big.Int) []byte {
+		input, err := contractAbi.Pack(
+			validateFinalityVoteProofV2,
+			secretKeys[0].PublicKey().Marshal(),
+			chainID,
+			new(big.Int).SetUint64(targetNumber),
+			new(big.Int).SetUint64(targetEpoch),
+			targetHashes,
+			sourceNumbers,
+			sourceHashes,
+			listOfPublicKeys,
+			aggregatedSignatures,
+		)
+		if err != nil {
+			t.Fatalf("Failed to pack contract input, err: %s", err)
+		}
+		return input
+	}
+
+	rawReturn, err := contract.Run(pack(config.ChainID, targetNumber, 1, sourceNumbers))
+	if err != nil {
+		t.Fatalf("Expect to successfully verify proof, get %s", err)
+	}
+	ret, err := contractAbi.Unpack(validateFinalityVoteProofV2, rawReturn)
+	if err != nil {
+		t.Fatalf("Failed to unpack output, err: %s", err)
+	}
+	if returnedBool := ret[0].(bool); !returnedBool {
+		t.Fatalf("Expect the returned value to be true, get %v", returnedBool)
+	}
+
+	tests := []struct {
+		input []byte
+		err   string
+	}{
+		{pack(big.NewInt(2020), targetNumber, 1, sourceNumbers), "invalid chain id"},
+		{pack(config.ChainID, targetNumber, 2, sourceNumbers), "invalid target epoch"},
+		{pack(config.ChainID, 50, 0, [2]*big.Int{big.NewInt(48), big.NewInt(49)}), "vote data version mismatches the target block"},
+		{pack(config.ChainID, targetNumber, 1, [2]*big.Int{big.NewInt(248), big.NewInt(250)}), "malformed source block number"},
+		{pack(config.ChainID, targetNumber, 1, [2]*big.Int{big.NewInt(248), big.NewInt(248)}), "failed to verify signature"},
+	}
+	for i, test := range tests {
+		if _, err := contract.Run(test.input); err == nil || err.Error() != test.err {
+			t.Fatalf("Test %d: expect error %s, got %v", i, test.err, err)
+		}
+	}
+
+	// The vote data before Tripp is refused from Tripp
+	input, err := contractAbi.Pack(
+		validateFinalityVoteProof,
+		secretKeys[0].PublicKey().Marshal(),
+		new(big.Int).SetUint64(targetNumber),
+		[2]common.Hash{{0x1}, {0x2}},
+		listOfPublicKeys,
+		aggregatedSignatures,
+	)
+	if err != nil {
+		t.Fatalf("Failed to pack contract input, err: %s", err)
+	}
+	if _, err := contract.Run(input); err == nil || err.Error() != "vote data version mismatches the target block" {
+		t.Fatalf("Expect error %s, got %v", "vote data version mismatches the target block", err)
+	}
+}
+
 func BenchmarkPrecompiledValidateFinalityVoteProof(b *testing.B) {
 	contractAbi, err := abi.JSON(strings.NewReader(validateFinalityVoteProofAbi))
 	if err != nil {
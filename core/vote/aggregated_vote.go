@@ -0,0 +1,290 @@
+package vote
+
+import (
+	"math/bits"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxFutureAggregatedVotes is the maximum number of blocks the aggregated
+	// votes are buffered for before the blocks are imported
+	maxFutureAggregatedVotes = 64
+
+	// maxFutureAggregatedVoteCandidates is the maximum number of aggregated votes
+	// buffered per block, they cannot be verified before the block is imported so
+	// a forged vote with more voters must not replace a valid one
+	maxFutureAggregatedVoteCandidates = 4
+
+	// maxFutureAggregatedVotePerPeer is the maximum number of aggregated votes
+	// buffered per peer, so a peer cannot flush the buffer with unknown blocks
+	maxFutureAggregatedVotePerPeer = 8
+
+	// futureAggregatedVoteTimeout is the duration the aggregated vote for an
+	// unknown block is buffered for
+	futureAggregatedVoteTimeout = 30 * time.Second
+)
+
+var futureAggregatedVotesGauge = metrics.NewRegisteredGauge("futureAggregatedVotes/local", nil)
+
+// aggregatedVoteWithPeer is a wrapper around AggregatedVote to include peer
+// information
+type aggregatedVoteWithPeer struct {
+	vote *types.AggregatedVote
+	peer string
+}
+
+// futureAggregatedVote is an aggregated vote for a block which is not imported
+type futureAggregatedVote struct {
+	vote       *types.AggregatedVote
+	peer       string
+	receivedAt time.Time
+}
+
+// voterCount returns the number of validators in the aggregated vote
+func voterCount(vote *types.AggregatedVote) int {
+	return bits.OnesCount64(uint64(vote.VotedValidators))
+}
+
+// PutAggregatedVote queues the aggregated vote received from a peer, it is only
+// kept if the engine aggregates the votes and it has more voters than the known
+// aggregated vote for the same block. The aggregated votes for the blocks which
+// are not imported yet are buffered briefly and verified once the block arrives.
+func (pool *VotePool) PutAggregatedVote(peer string, vote *types.AggregatedVote) {
+	select {
+	case pool.aggregatedVotesCh <- &aggregatedVoteWithPeer{vote: vote, peer: peer}:
+	default:
+		log.Debug("Failed to put aggregated vote into vote pool")
+	}
+}
+
+func (pool *VotePool) putAggregatedVote(voteWithPeerInfo *aggregatedVoteWithPeer) bool {
+	vote := voteWithPeerInfo.vote
+	aggregator, ok := pool.engine.(consensus.VoteAggregator)
+	if !ok || vote.Data == nil {
+		return false
+	}
+
+	targetNumber := vote.Data.TargetNumber
+	known := pool.chain.GetHeaderByHash(vote.Data.TargetHash) != nil
+	headNumber := pool.chain.CurrentBlock().NumberU64()
+	if targetNumber+lowerLimitOfVoteBlockNumber-1 < headNumber || targetNumber > headNumber+upperLimitOfVoteBlockNumber {
+		log.Debug("BlockNumber of aggregated vote is outside the range of header-256~header+11, will be discarded")
+		return false
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if targetNumber <= pool.justifiedBlockNumber {
+		return false
+	}
+	// The aggregated votes for the unknown blocks are buffered until the block
+	// is imported, the quorum may arrive before the block it finalizes
+	if !known {
+		return pool.bufferAggregatedVote(voteWithPeerInfo.peer, vote)
+	}
+	return pool.acceptAggregatedVote(aggregator, vote)
+}
+
+// acceptAggregatedVote verifies and keeps the aggregated vote for a known block
+// if it has more voters than the known aggregated vote for the block. The caller
+// must hold the pool mutex.
+func (pool *VotePool) acceptAggregatedVote(aggregator consensus.VoteAggregator, vote *types.AggregatedVote) bool {
+	if known := pool.aggregatedVotes[vote.Data.TargetHash]; known != nil && voterCount(known) >= voterCount(vote) {
+		return false
+	}
+	if err := aggregator.VerifyAggregatedVote(pool.chain, vote); err != nil {
+		log.Debug("Failed to verify aggregated vote", "number", vote.Data.TargetNumber, "hash", vote.Data.TargetHash, "err", err)
+		return false
+	}
+	pool.setAggregatedVote(vote)
+	return true
+}
+
+// bufferAggregatedVote keeps the aggregated vote for an unknown block until the
+// block is imported or the vote expires. The votes cannot be verified yet, so a
+// few candidates are kept per block, ordered by the number of voters, and the
+// one with the fewest voters is evicted for a vote with more. The block with the
+// oldest votes is evicted when the buffer is full. The votes are not relayed.
+// The caller must hold the pool mutex.
+func (pool *VotePool) bufferAggregatedVote(peer string, vote *types.AggregatedVote) bool {
+	if pool.futureAggregatedPerPeer[peer] >= maxFutureAggregatedVotePerPeer {
+		log.Debug("Too many buffered aggregated votes from peer", "peer", peer)
+		return false
+	}
+	hash := vote.Data.TargetHash
+	candidates, ok := pool.futureAggregatedVotes[hash]
+	if ok {
+		voteHash := vote.Hash()
+		for _, candidate := range candidates {
+			if candidate.vote.Hash() == voteHash {
+				return false
+			}
+		}
+		if len(candidates) >= maxFutureAggregatedVoteCandidates {
+			weakest := candidates[len(candidates)-1]
+			if voterCount(weakest.vote) >= voterCount(vote) {
+				return false
+			}
+			pool.releaseFutureAggregatedVote(weakest.peer)
+			candidates = candidates[:len(candidates)-1]
+		}
+	} else if len(pool.futureAggregatedVotes) >= maxFutureAggregatedVotes {
+		var (
+			oldest     common.Hash
+			oldestTime time.Time
+		)
+		for hash, candidates := range pool.futureAggregatedVotes {
+			for _, candidate := range candidates {
+				if oldestTime.IsZero() || candidate.receivedAt.Before(oldestTime) {
+					oldest, oldestTime = hash, candidate.receivedAt
+				}
+			}
+		}
+		pool.dropFutureAggregatedVotes(oldest)
+	}
+	future := &futureAggregatedVote{vote: vote, peer: peer, receivedAt: time.Now()}
+	index := sort.Search(len(candidates), func(i int) bool {
+		return voterCount(candidates[i].vote) < voterCount(vote)
+	})
+	candidates = append(candidates, nil)
+	copy(candidates[index+1:], candidates[index:])
+	candidates[index] = future
+
+	pool.futureAggregatedVotes[hash] = candidates
+	pool.futureAggregatedPerPeer[peer]++
+	futureAggregatedVotesGauge.Update(int64(len(pool.futureAggregatedVotes)))
+	log.Debug("Buffered aggregated vote for unknown block", "number", vote.Data.TargetNumber, "hash", hash, "peer", peer)
+	return true
+}
+
+// dropFutureAggregatedVotes deletes the buffered aggregated votes for the block.
+// The caller must hold the pool mutex.
+func (pool *VotePool) dropFutureAggregatedVotes(hash common.Hash) {
+	for _, candidate := range pool.futureAggregatedVotes[hash] {
+		pool.releaseFutureAggregatedVote(candidate.peer)
+	}
+	delete(pool.futureAggregatedVotes, hash)
+}
+
+// releaseFutureAggregatedVote decrements the number of buffered aggregated votes
+// of the peer. The caller must hold the pool mutex.
+func (pool *VotePool) releaseFutureAggregatedVote(peer string) {
+	if pool.futureAggregatedPerPeer[peer]--; pool.futureAggregatedPerPeer[peer] == 0 {
+		delete(pool.futureAggregatedPerPeer, peer)
+	}
+}
+
+// transferFutureAggregatedVotes verifies the buffered aggregated votes whose
+// block is imported, from the one with the most voters until a valid vote is
+// found, and drops the expired ones. The caller must hold the pool mutex.
+func (pool *VotePool) transferFutureAggregatedVotes(latestBlockNumber uint64) {
+	if len(pool.futureAggregatedVotes) == 0 {
+		return
+	}
+	aggregator, ok := pool.engine.(consensus.VoteAggregator)
+	if !ok {
+		return
+	}
+	for hash, candidates := range pool.futureAggregatedVotes {
+		number := candidates[0].vote.Data.TargetNumber
+		if number+lowerLimitOfVoteBlockNumber-1 < latestBlockNumber || number <= pool.justifiedBlockNumber {
+			pool.dropFutureAggregatedVotes(hash)
+			continue
+		}
+		if pool.chain.GetHeaderByHash(hash) == nil {
+			// Drop the expired candidates, the block is dropped with the last one
+			live := candidates[:0]
+			for _, candidate := range candidates {
+				if time.Since(candidate.receivedAt) > futureAggregatedVoteTimeout {
+					pool.releaseFutureAggregatedVote(candidate.peer)
+					continue
+				}
+				live = append(live, candidate)
+			}
+			if len(live) == 0 {
+				delete(pool.futureAggregatedVotes, hash)
+			} else {
+				pool.futureAggregatedVotes[hash] = live
+			}
+			continue
+		}
+		pool.dropFutureAggregatedVotes(hash)
+		for _, candidate := range candidates {
+			if pool.acceptAggregatedVote(aggregator, candidate.vote) {
+				break
+			}
+		}
+	}
+	futureAggregatedVotesGauge.Update(int64(len(pool.futureAggregatedVotes)))
+}
+
+// aggregateVotes aggregates the current votes for the block once they reach the
+// quorum, unless an aggregated vote for the block is already known. The later
+// votes are not aggregated again to not relay the quorum once per vote.
+// The caller must hold the pool mutex.
+func (pool *VotePool) aggregateVotes(blockHash common.Hash) {
+	aggregator, ok := pool.engine.(consensus.VoteAggregator)
+	if !ok {
+		return
+	}
+	voteBox, ok := pool.curVotes[blockHash]
+	if !ok || len(voteBox.voteMessages) == 0 {
+		return
+	}
+	if _, ok := pool.aggregatedVotes[blockHash]; ok {
+		return
+	}
+
+	vote, err := aggregator.AggregateVotes(pool.chain, voteBox.voteMessages)
+	if err != nil {
+		log.Debug("Failed to aggregate votes", "number", voteBox.blockNumber, "hash", blockHash, "err", err)
+		return
+	}
+	if vote != nil {
+		pool.setAggregatedVote(vote)
+	}
+}
+
+// setAggregatedVote keeps the aggregated vote and sends it for the handler to
+// relay. The caller must hold the pool mutex.
+func (pool *VotePool) setAggregatedVote(vote *types.AggregatedVote) {
+	pool.aggregatedVotes[vote.Data.TargetHash] = vote
+	pool.aggregatedFeed.Send(core.NewAggregatedVoteEvent{Vote: vote})
+}
+
+// pruneAggregatedVotes deletes the aggregated votes as the individual votes are
+// pruned. The caller must hold the pool mutex.
+func (pool *VotePool) pruneAggregatedVotes(latestBlockNumber uint64) {
+	for hash, vote := range pool.aggregatedVotes {
+		if number := vote.Data.TargetNumber; number+lowerLimitOfVoteBlockNumber-1 < latestBlockNumber || number <= pool.justifiedBlockNumber {
+			delete(pool.aggregatedVotes, hash)
+		}
+	}
+}
+
+// SubscribeNewAggregatedVoteEvent registers a subscription of the aggregated
+// votes entering the pool.
+func (pool *VotePool) SubscribeNewAggregatedVoteEvent(ch chan<- core.NewAggregatedVoteEvent) event.Subscription {
+	return pool.scope.Track(pool.aggregatedFeed.Subscribe(ch))
+}
+
+// FetchAggregatedVoteByBlockHash implements consensus.AggregatedVotePool, it
+// does not block the caller as FetchVoteByBlockHash.
+func (pool *VotePool) FetchAggregatedVoteByBlockHash(blockHash common.Hash) *types.AggregatedVote {
+	if !pool.tryRLock() {
+		return nil
+	}
+	defer pool.mu.RUnlock()
+
+	return pool.aggregatedVotes[blockHash]
+}
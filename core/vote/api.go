@@ -0,0 +1,273 @@
+package vote
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// PublicVotePoolAPI provides the RPC to inspect the finality votes in the vote pool
+type PublicVotePoolAPI struct {
+	pool *VotePool
+}
+
+func NewPublicVotePoolAPI(pool *VotePool) *PublicVotePoolAPI {
+	return &PublicVotePoolAPI{pool: pool}
+}
+
+// voteBoxStatus is the summary of the votes for a block in the vote pool
+type voteBoxStatus struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Votes  int         `json:"votes"`
+}
+
+type votePoolStatus struct {
+	JustifiedBlockNumber uint64          `json:"justifiedBlockNumber"`
+	CurVotes             []voteBoxStatus `json:"curVotes"`
+	FutureVotes          []voteBoxStatus `json:"futureVotes"` // Votes for the blocks that are not imported yet
+}
+
+type voteInfo struct {
+	PublicKey    hexutil.Bytes `json:"publicKey"`
+	TargetNumber uint64        `json:"targetNumber"`
+	TargetHash   common.Hash   `json:"targetHash"`
+	Peer         string        `json:"peer"` // Empty if the vote is created locally
+	ReceivedAt   time.Time     `json:"receivedAt"`
+	Future       bool          `json:"future"`
+}
+
+func voteBoxesStatus(voteBoxes map[common.Hash]*VoteBox) []voteBoxStatus {
+	status := make([]voteBoxStatus, 0, len(voteBoxes))
+	for hash, voteBox := range voteBoxes {
+		status = append(status, voteBoxStatus{
+			Number: voteBox.blockNumber,
+			Hash:   hash,
+			Votes:  len(voteBox.voteMessages),
+		})
+	}
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Number < status[j].Number
+	})
+	return status
+}
+
+// GetVotePoolStatus returns the number of votes for each block in the vote pool
+func (api *PublicVotePoolAPI) GetVotePoolStatus() *votePoolStatus {
+	api.pool.mu.RLock()
+	defer api.pool.mu.RUnlock()
+
+	return &votePoolStatus{
+		JustifiedBlockNumber: api.pool.justifiedBlockNumber,
+		CurVotes:             voteBoxesStatus(api.pool.curVotes),
+		FutureVotes:          voteBoxesStatus(api.pool.futureVotes),
+	}
+}
+
+// GetVotesByBlockHash returns the votes for the block in the vote pool
+func (api *PublicVotePoolAPI) GetVotesByBlockHash(hash common.Hash) []voteInfo {
+	api.pool.mu.RLock()
+	defer api.pool.mu.RUnlock()
+
+	votes := make([]voteInfo, 0)
+	appendVotes := func(voteBox *VoteBox, future bool) {
+		for _, vote := range voteBox.voteMessages {
+			votes = append(votes, api.pool.voteInfo(vote, future))
+		}
+	}
+	if voteBox, ok := api.pool.curVotes[hash]; ok {
+		appendVotes(voteBox, false)
+	}
+	if voteBox, ok := api.pool.futureVotes[hash]; ok {
+		appendVotes(voteBox, true)
+	}
+	return votes
+}
+
+// voteEquivocationInfo is a proof of conflicting votes known by the vote pool,
+// the evidence is the RLP encoding of the proof
+type voteEquivocationInfo struct {
+	Hash         common.Hash   `json:"hash"`
+	PublicKey    hexutil.Bytes `json:"publicKey"`
+	TargetNumber uint64        `json:"targetNumber"`
+	FirstHash    common.Hash   `json:"firstHash"`
+	SecondHash   common.Hash   `json:"secondHash"`
+	Evidence     hexutil.Bytes `json:"evidence"`
+}
+
+// GetVoteEquivocations returns the proofs of conflicting votes detected by the
+// node or received from the peers, the votes of their keys are refused for the
+// rest of the epoch
+func (api *PublicVotePoolAPI) GetVoteEquivocations() ([]voteEquivocationInfo, error) {
+	proofs := api.pool.Equivocations()
+	sort.Slice(proofs, func(i, j int) bool {
+		return proofs[i].First.Data.TargetNumber < proofs[j].First.Data.TargetNumber
+	})
+	infos := make([]voteEquivocationInfo, 0, len(proofs))
+	for _, proof := range proofs {
+		evidence, err := rlp.EncodeToBytes(proof)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, voteEquivocationInfo{
+			Hash:         proof.Hash(),
+			PublicKey:    proof.First.PublicKey[:],
+			TargetNumber: proof.First.Data.TargetNumber,
+			FirstHash:    proof.First.Data.TargetHash,
+			SecondHash:   proof.Second.Data.TargetHash,
+			Evidence:     evidence,
+		})
+	}
+	return infos, nil
+}
+
+// The caller must hold the pool mutex
+func (pool *VotePool) voteInfo(vote *types.VoteEnvelope, future bool) voteInfo {
+	voteHash := vote.Hash()
+	return voteInfo{
+		PublicKey:    vote.PublicKey[:],
+		TargetNumber: vote.Data.TargetNumber,
+		TargetHash:   vote.Data.TargetHash,
+		Peer:         pool.originatedFrom[voteHash],
+		ReceivedAt:   pool.receivedAt[voteHash],
+		Future:       future,
+	}
+}
+
+// PrivateVotePoolAPI provides the RPC to submit the finality votes signed outside
+// of the node, e.g. by the validators whose BLS keys live in custodial setups
+type PrivateVotePoolAPI struct {
+	pool *VotePool
+}
+
+func NewPrivateVotePoolAPI(pool *VotePool) *PrivateVotePoolAPI {
+	return &PrivateVotePoolAPI{pool: pool}
+}
+
+// externalVoteData is the vote data for a block and the digest to be signed by
+// the external signer
+type externalVoteData struct {
+	TargetNumber uint64       `json:"targetNumber"`
+	TargetHash   common.Hash  `json:"targetHash"`
+	ChainID      *hexutil.Big `json:"chainId,omitempty"`
+	SourceNumber uint64       `json:"sourceNumber"`
+	SourceHash   common.Hash  `json:"sourceHash"`
+	TargetEpoch  uint64       `json:"targetEpoch"`
+	Digest       common.Hash  `json:"digest"`
+}
+
+// voteData returns the vote data for the block with hash as produced by the
+// local vote manager
+func (pool *VotePool) voteData(hash common.Hash) (*types.VoteData, error) {
+	header := pool.chain.GetHeaderByHash(hash)
+	if header == nil {
+		return nil, errUnknownVoteTarget
+	}
+	sourceNumber, sourceHash := pool.engine.GetJustifiedBlock(pool.chain, header.Number.Uint64(), hash)
+	return types.NewVoteData(pool.chain.Config(), header.Number.Uint64(), hash, sourceNumber, sourceHash), nil
+}
+
+// GetVoteData returns the vote data for the block and its digest to be signed
+func (api *PrivateVotePoolAPI) GetVoteData(hash common.Hash) (*externalVoteData, error) {
+	data, err := api.pool.voteData(hash)
+	if err != nil {
+		return nil, err
+	}
+	result := &externalVoteData{
+		TargetNumber: data.TargetNumber,
+		TargetHash:   data.TargetHash,
+		SourceNumber: data.SourceNumber,
+		SourceHash:   data.SourceHash,
+		TargetEpoch:  data.TargetEpoch,
+		Digest:       data.Hash(),
+	}
+	if data.ChainID != nil {
+		result.ChainID = (*hexutil.Big)(data.ChainID)
+	}
+	return result, nil
+}
+
+// SubmitVote verifies the BLS signature of the validator over the digest of the
+// vote data for the block, see GetVoteData, and puts the vote into the pool to
+// be propagated and aggregated as the locally produced ones. The vote hash is
+// returned.
+func (api *PrivateVotePoolAPI) SubmitVote(hash common.Hash, publicKey hexutil.Bytes, signature hexutil.Bytes) (common.Hash, error) {
+	if len(publicKey) != params.BLSPubkeyLength {
+		return common.Hash{}, fmt.Errorf("invalid public key length %d", len(publicKey))
+	}
+	if len(signature) != params.BLSSignatureLength {
+		return common.Hash{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	data, err := api.pool.voteData(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	vote := &types.VoteEnvelope{RawVoteEnvelope: types.RawVoteEnvelope{Data: data}}
+	copy(vote.PublicKey[:], publicKey)
+	copy(vote.Signature[:], signature)
+	if err := api.pool.SubmitVote(vote); err != nil {
+		return common.Hash{}, err
+	}
+	return vote.Hash(), nil
+}
+
+// PrivateVotePoolAdminAPI provides the RPC to move the votes in the pool from a
+// validator node to its hot standby on failover, so the votes in flight near an
+// epoch boundary are not lost
+type PrivateVotePoolAdminAPI struct {
+	pool *VotePool
+}
+
+func NewPrivateVotePoolAdminAPI(pool *VotePool) *PrivateVotePoolAdminAPI {
+	return &PrivateVotePoolAdminAPI{pool: pool}
+}
+
+// ExportVotes writes the votes in the pool to file, gzipped if the file name
+// ends with .gz, and returns the number of exported votes
+func (api *PrivateVotePoolAdminAPI) ExportVotes(file string) (int, error) {
+	if _, err := os.Stat(file); err == nil {
+		// Allowing overwrite could be a DoS vector, as for the chain export
+		return 0, errors.New("location would overwrite an existing file")
+	}
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var writer io.Writer = out
+	if strings.HasSuffix(file, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	return api.pool.ExportVotes(writer)
+}
+
+// ImportVotes puts the votes exported to file by ExportVotes into the pool and
+// returns the number of imported votes
+func (api *PrivateVotePoolAdminAPI) ImportVotes(file string) (int, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var reader io.Reader = in
+	if strings.HasSuffix(file, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return 0, err
+		}
+	}
+	return api.pool.ImportVotes(reader)
+}
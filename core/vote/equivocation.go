@@ -0,0 +1,140 @@
+package vote
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var voteEquivocationMeter = metrics.NewRegisteredMeter("votepool/equivocations", nil)
+
+// voteEpoch returns the epoch of the block number
+func (pool *VotePool) voteEpoch(number uint64) uint64 {
+	config := pool.chain.Config().Consortium
+	if config == nil || config.EpochV2 == 0 {
+		return 0
+	}
+	return number / config.EpochV2
+}
+
+// blacklisted returns whether the vote is signed with a key which equivocated in
+// the epoch of the vote or a later one. The caller must hold the pool mutex.
+func (pool *VotePool) blacklisted(vote *types.VoteEnvelope) bool {
+	epoch, ok := pool.blacklist[vote.PublicKey]
+	return ok && pool.voteEpoch(vote.Data.TargetNumber) <= epoch
+}
+
+// detectEquivocation checks the verified vote against the votes of the same key
+// for the same target number in the pool and records the proof if they conflict.
+// It returns whether the vote conflicts. The caller must hold the pool mutex.
+func (pool *VotePool) detectEquivocation(vote *types.VoteEnvelope) bool {
+	dataHash := vote.Data.Hash()
+	for _, voteBox := range pool.curVotes {
+		if voteBox.blockNumber != vote.Data.TargetNumber {
+			continue
+		}
+		for _, known := range voteBox.voteMessages {
+			if known.PublicKey == vote.PublicKey && known.Data.Hash() != dataHash {
+				pool.recordEquivocation(&types.VoteEquivocation{First: known.Raw(), Second: vote.Raw()})
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordEquivocation keeps the proof as the evidence of the equivocation and
+// refuses the further votes of the key up to the end of the epoch, the proof is
+// sent to the handler to be broadcast. It returns whether the proof is new. The
+// caller must hold the pool mutex.
+func (pool *VotePool) recordEquivocation(proof *types.VoteEquivocation) bool {
+	hash := proof.Hash()
+	if _, ok := pool.equivocations[hash]; ok {
+		return false
+	}
+	pool.equivocations[hash] = proof
+
+	publicKey := proof.First.PublicKey
+	epoch := pool.voteEpoch(proof.First.Data.TargetNumber)
+	if blacklisted, ok := pool.blacklist[publicKey]; !ok || blacklisted < epoch {
+		pool.blacklist[publicKey] = epoch
+	}
+	voteEquivocationMeter.Mark(1)
+	log.Warn("Detected finality vote equivocation", "publicKey", hexutil.Bytes(publicKey[:]), "number", proof.First.Data.TargetNumber,
+		"first", proof.First.Data.TargetHash, "second", proof.Second.Data.TargetHash, "epoch", epoch)
+
+	pool.equivocationFeed.Send(core.NewVoteEquivocationEvent{Proof: proof})
+	return true
+}
+
+// AddVoteEquivocation verifies the proof of conflicting votes received from a
+// peer and records it as if the equivocation was detected locally, so that the
+// votes of the offender are refused without each node discovering it. An error
+// is returned if the proof is invalid. The proofs outside the range of the pool
+// or whose key is not proven to be a validator are dropped, one of the votes must
+// pass the verification of the engine.
+func (pool *VotePool) AddVoteEquivocation(proof *types.VoteEquivocation) error {
+	if err := proof.Verify(); err != nil {
+		return err
+	}
+	pool.mu.RLock()
+	_, known := pool.equivocations[proof.Hash()]
+	pool.mu.RUnlock()
+	if known {
+		return nil
+	}
+
+	targetNumber := proof.First.Data.TargetNumber
+	headNumber := pool.chain.CurrentBlock().NumberU64()
+	if targetNumber+lowerLimitOfVoteBlockNumber-1 < headNumber || targetNumber > headNumber+upperLimitOfVoteBlockNumber {
+		log.Debug("Dropping vote equivocation outside the range of the vote pool", "number", targetNumber)
+		return nil
+	}
+	if pool.engine.VerifyVote(pool.chain, &types.VoteEnvelope{RawVoteEnvelope: *proof.First}) != nil &&
+		pool.engine.VerifyVote(pool.chain, &types.VoteEnvelope{RawVoteEnvelope: *proof.Second}) != nil {
+		log.Debug("Dropping vote equivocation of unknown validator", "number", targetNumber)
+		return nil
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.recordEquivocation(proof)
+	return nil
+}
+
+// Equivocations returns the known proofs of conflicting votes.
+func (pool *VotePool) Equivocations() []*types.VoteEquivocation {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	proofs := make([]*types.VoteEquivocation, 0, len(pool.equivocations))
+	for _, proof := range pool.equivocations {
+		proofs = append(proofs, proof)
+	}
+	return proofs
+}
+
+// SubscribeNewVoteEquivocationEvent registers a subscription of the new proofs
+// of conflicting votes.
+func (pool *VotePool) SubscribeNewVoteEquivocationEvent(ch chan<- core.NewVoteEquivocationEvent) event.Subscription {
+	return pool.scope.Track(pool.equivocationFeed.Subscribe(ch))
+}
+
+// pruneEquivocations drops the proofs and the blacklisted keys of the epochs
+// before the latest block. The caller must hold the pool mutex.
+func (pool *VotePool) pruneEquivocations(latestBlockNumber uint64) {
+	epoch := pool.voteEpoch(latestBlockNumber)
+	for publicKey, blacklisted := range pool.blacklist {
+		if blacklisted < epoch {
+			delete(pool.blacklist, publicKey)
+		}
+	}
+	for hash, proof := range pool.equivocations {
+		if pool.voteEpoch(proof.First.Data.TargetNumber) < epoch {
+			delete(pool.equivocations, hash)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package vote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// importedVotePeer is the peer recorded for the votes imported from another node
+const importedVotePeer = "import"
+
+// ExportVotes writes the current and future votes of the pool to w as a stream
+// of RLP encoded vote envelopes ordered by target number, so a standby node can
+// import them on failover. It returns the number of exported votes.
+func (pool *VotePool) ExportVotes(w io.Writer) (int, error) {
+	pool.mu.RLock()
+	votes := make([]*types.VoteEnvelope, 0, len(pool.originatedFrom))
+	for _, boxes := range []map[common.Hash]*VoteBox{pool.curVotes, pool.futureVotes} {
+		for _, box := range boxes {
+			votes = append(votes, box.voteMessages...)
+		}
+	}
+	pool.mu.RUnlock()
+
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].Data.TargetNumber < votes[j].Data.TargetNumber
+	})
+	for _, vote := range votes {
+		if err := rlp.Encode(w, vote); err != nil {
+			return 0, err
+		}
+	}
+	return len(votes), nil
+}
+
+// SetLocalVoter sets the BLS public key the node votes with and its vote
+// protection history, nil if the history is not kept. The imported votes signed
+// with the key are recorded in the history, see ImportVotes.
+func (pool *VotePool) SetLocalVoter(publicKey types.BLSPublicKey, protection *VoteProtection) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.localKey, pool.localProtection = &publicKey, protection
+}
+
+// checkLocalVote records the imported vote in the vote protection history if it
+// is signed with the key of the node, so the node does not sign a conflicting
+// vote after the failover. The votes of the key are refused if the history is
+// not kept.
+func (pool *VotePool) checkLocalVote(vote *types.VoteEnvelope) error {
+	pool.mu.RLock()
+	localKey, protection := pool.localKey, pool.localProtection
+	pool.mu.RUnlock()
+
+	if localKey == nil || vote.PublicKey != *localKey {
+		return nil
+	}
+	if protection == nil {
+		return errors.New("vote of the local key without vote protection")
+	}
+	return protection.CheckAndRecord(vote.PublicKey, vote.Data)
+}
+
+// ImportVotes reads the votes exported by ExportVotes from r and puts them into
+// the pool as the votes received from a peer, the votes for known blocks are
+// then propagated. The votes with an invalid signature, outside of the accepted
+// range or already in the pool are skipped, as are the votes of the local key
+// refused by the vote protection. It returns the number of imported votes.
+func (pool *VotePool) ImportVotes(r io.Reader) (int, error) {
+	var (
+		stream   = rlp.NewStream(r, 0)
+		imported int
+		skipped  int
+	)
+	for {
+		vote := new(types.VoteEnvelope)
+		if err := stream.Decode(vote); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, fmt.Errorf("vote %d: failed to parse: %v", imported+skipped, err)
+		}
+		if vote.Data == nil || vote.Verify() != nil {
+			skipped++
+			continue
+		}
+		if err := pool.checkLocalVote(vote); err != nil {
+			log.Warn("Refused imported vote of the local key", "target", vote.Data.TargetNumber, "hash", vote.Data.TargetHash, "err", err)
+			skipped++
+			continue
+		}
+		if !pool.putIntoVotePool(&voteWithPeer{vote: vote, peer: importedVotePeer}) {
+			skipped++
+			continue
+		}
+		imported++
+	}
+	log.Info("Imported finality votes", "imported", imported, "skipped", skipped)
+	return imported, nil
+}
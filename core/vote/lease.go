@@ -0,0 +1,307 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+const (
+	leaseLockRetries = 10                    // Number of attempts to lock the lease file before giving up
+	leaseLockBackoff = 10 * time.Millisecond // Delay between the attempts to lock the lease file
+)
+
+var (
+	errLeaseNotHeld = errors.New("signing lease is not held by this node")
+	errLeaseFenced  = errors.New("signing lease is taken over by another node")
+	errLeaseBusy    = errors.New("signing lease is being updated by another node")
+)
+
+// leaseRecord is the content of the lease file
+type leaseRecord struct {
+	Holder string `json:"holder"`
+	Expiry int64  `json:"expiry"` // Unix time in milliseconds
+	Token  uint64 `json:"token"`  // Fencing token, increased whenever the holder changes
+}
+
+// SigningLease coordinates the nodes sharing a validator identity so that only the
+// node holding the lease seals blocks and votes. The lease is a file on a storage
+// shared by the nodes, the holder renews it on every heartbeat and a standby node
+// takes it over once it expires.
+//
+// The holder stops signing after half of the lease duration without a successful
+// renewal, so it has stopped long before a standby node can take over. A standby
+// node can also take over a lease that is still renewed, e.g. when the primary
+// stops producing blocks, by increasing the fencing token. The token is checked
+// against the lease file before every signing so the fenced node stops at once.
+//
+// The lease file is only updated while holding an exclusive lock on a lock file
+// next to it, so two nodes cannot both see a free lease and acquire it. The
+// shared storage must support the file locks, e.g. NFSv4.
+type SigningLease struct {
+	path     string
+	holder   string
+	duration time.Duration
+
+	lock   sync.RWMutex
+	expiry time.Time // Local signing deadline, zero if the lease is not held
+	token  uint64    // Fencing token of the held lease
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	afterRead func() // Test hook run between the read and the write of the lease file
+}
+
+func NewSigningLease(path, holder string, duration time.Duration) (*SigningLease, error) {
+	if path == "" {
+		return nil, errors.New("empty signing lease path")
+	}
+	if holder == "" {
+		return nil, errors.New("empty signing lease holder")
+	}
+	if duration <= 0 {
+		return nil, errors.New("signing lease duration must be greater than 0")
+	}
+
+	return &SigningLease{
+		path:     path,
+		holder:   holder,
+		duration: duration,
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+// Start tries to acquire the lease and keeps renewing it in the background
+func (lease *SigningLease) Start() {
+	lease.heartbeat()
+
+	lease.wg.Add(1)
+	go func() {
+		defer lease.wg.Done()
+
+		ticker := time.NewTicker(lease.duration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				lease.heartbeat()
+			case <-lease.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the heartbeat and releases the lease so that the standby node can
+// take over without waiting for the lease to expire
+func (lease *SigningLease) Stop() {
+	close(lease.quit)
+	lease.wg.Wait()
+
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+	if lease.expiry.IsZero() {
+		return
+	}
+	lease.expiry = time.Time{}
+	unlock, err := lease.lockFile()
+	if err != nil {
+		log.Error("Failed to release signing lease", "path", lease.path, "err", err)
+		return
+	}
+	defer unlock()
+	// Do not release the lease if it is already taken over
+	if record, err := lease.read(); err != nil || record == nil || record.Holder != lease.holder || record.Token != lease.token {
+		return
+	}
+	if err := lease.write(&leaseRecord{Holder: lease.holder, Token: lease.token}); err != nil {
+		log.Error("Failed to release signing lease", "path", lease.path, "err", err)
+	}
+}
+
+// Held returns true if this node holds the lease and is allowed to sign
+func (lease *SigningLease) Held() bool {
+	lease.lock.RLock()
+	defer lease.lock.RUnlock()
+	return time.Now().Before(lease.expiry)
+}
+
+// CheckHeld returns an error if this node is not allowed to sign. Besides the
+// local deadline, the fencing token is checked against the lease file.
+func (lease *SigningLease) CheckHeld() error {
+	if !lease.Held() {
+		return errLeaseNotHeld
+	}
+
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+	record, err := lease.read()
+	if err != nil {
+		return err
+	}
+	if record == nil || record.Holder != lease.holder || record.Token != lease.token {
+		if !lease.expiry.IsZero() {
+			log.Warn("Signing lease is fenced by another node", "path", lease.path, "token", lease.token)
+		}
+		lease.expiry = time.Time{}
+		return errLeaseFenced
+	}
+	return nil
+}
+
+// Takeover forcibly acquires the lease even if it is still held by another node,
+// the holder is fenced by the increased token and stops signing.
+func (lease *SigningLease) Takeover() error {
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	unlock, err := lease.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	record, err := lease.read()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	next := &leaseRecord{
+		Holder: lease.holder,
+		Expiry: now.Add(lease.duration).UnixMilli(),
+		Token:  1,
+	}
+	if record != nil {
+		next.Token = record.Token + 1
+	}
+	if err := lease.write(next); err != nil {
+		return err
+	}
+	log.Warn("Took over signing lease", "path", lease.path, "holder", lease.holder, "token", next.Token)
+	lease.token = next.Token
+	lease.expiry = now.Add(lease.duration / 2)
+	return nil
+}
+
+func (lease *SigningLease) heartbeat() {
+	lease.lock.Lock()
+	defer lease.lock.Unlock()
+
+	now := time.Now()
+	wasHeld := now.Before(lease.expiry)
+	acquired, err := lease.tryAcquire(now)
+	if err != nil {
+		log.Error("Failed to renew signing lease", "path", lease.path, "err", err)
+		return
+	}
+	if !acquired {
+		if wasHeld {
+			log.Warn("Signing lease is taken over by another node", "path", lease.path)
+		}
+		lease.expiry = time.Time{}
+		return
+	}
+	if !wasHeld {
+		log.Info("Acquired signing lease", "path", lease.path, "holder", lease.holder)
+	}
+	lease.expiry = now.Add(lease.duration / 2)
+}
+
+// tryAcquire writes the lease if it is free, expired or already held by this
+// node. It returns false if the lease is held by another node.
+func (lease *SigningLease) tryAcquire(now time.Time) (bool, error) {
+	unlock, err := lease.lockFile()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	record, err := lease.read()
+	if err != nil {
+		return false, err
+	}
+	if record != nil && record.Holder != lease.holder && record.Expiry > now.UnixMilli() {
+		return false, nil
+	}
+	if lease.afterRead != nil {
+		lease.afterRead()
+	}
+
+	// The token is kept on renewal and increased when the holder changes
+	next := &leaseRecord{
+		Holder: lease.holder,
+		Expiry: now.Add(lease.duration).UnixMilli(),
+		Token:  1,
+	}
+	if record != nil {
+		next.Token = record.Token
+		if record.Holder != lease.holder {
+			next.Token++
+		}
+	}
+	if err := lease.write(next); err != nil {
+		return false, err
+	}
+	lease.token = next.Token
+	return true, nil
+}
+
+// lockFile takes the exclusive lock of the lease file, the lease file must only
+// be read and updated under the lock. It retries for a short while if another
+// node holds the lock.
+func (lease *SigningLease) lockFile() (func(), error) {
+	for i := 0; ; i++ {
+		releaser, _, err := fileutil.Flock(lease.path + ".lock")
+		if err == nil {
+			return func() {
+				if err := releaser.Release(); err != nil {
+					log.Error("Failed to unlock signing lease", "path", lease.path, "err", err)
+				}
+			}, nil
+		}
+		if i == leaseLockRetries-1 {
+			return nil, fmt.Errorf("%w: %v", errLeaseBusy, err)
+		}
+		time.Sleep(leaseLockBackoff)
+	}
+}
+
+// read returns the current lease record, nil if there is no lease file
+func (lease *SigningLease) read() (*leaseRecord, error) {
+	blob, err := os.ReadFile(lease.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record leaseRecord
+	if err := json.Unmarshal(blob, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// write atomically replaces the lease file with the record
+func (lease *SigningLease) write(record *leaseRecord) error {
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(lease.path), 0755); err != nil {
+		return err
+	}
+	tmp := lease.path + "." + lease.holder + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, lease.path)
+}
@@ -0,0 +1,174 @@
+package vote
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSigningLeaseFailover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease")
+	duration := 200 * time.Millisecond
+
+	primary, err := NewSigningLease(path, "primary", duration)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+	standby, err := NewSigningLease(path, "standby", duration)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+
+	primary.heartbeat()
+	standby.heartbeat()
+	if !primary.Held() {
+		t.Fatalf("Expect primary to hold the lease")
+	}
+	if standby.Held() || standby.CheckHeld() == nil {
+		t.Fatalf("Expect standby not to hold the lease")
+	}
+
+	// The primary renews the lease, the standby cannot take it over
+	primary.heartbeat()
+	standby.heartbeat()
+	if !primary.Held() || standby.Held() {
+		t.Fatalf("Expect primary to keep the lease")
+	}
+
+	// The primary stops renewing, it stops signing before the standby takes over
+	time.Sleep(duration / 2)
+	if primary.Held() {
+		t.Fatalf("Expect primary to stop signing without renewal")
+	}
+	standby.heartbeat()
+	if standby.Held() {
+		t.Fatalf("Expect standby not to take over before the lease expires")
+	}
+	time.Sleep(duration/2 + 10*time.Millisecond)
+	standby.heartbeat()
+	if !standby.Held() {
+		t.Fatalf("Expect standby to take over the expired lease")
+	}
+
+	primary.heartbeat()
+	if primary.Held() {
+		t.Fatalf("Expect primary not to hold the lease taken over by standby")
+	}
+}
+
+func TestSigningLeaseRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease")
+
+	primary, err := NewSigningLease(path, "primary", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+	standby, err := NewSigningLease(path, "standby", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+
+	primary.Start()
+	if !primary.Held() {
+		t.Fatalf("Expect primary to hold the lease")
+	}
+	primary.Stop()
+
+	standby.heartbeat()
+	if !standby.Held() {
+		t.Fatalf("Expect standby to take over the released lease")
+	}
+}
+
+func TestSigningLeaseTakeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease")
+
+	primary, err := NewSigningLease(path, "primary", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+	standby, err := NewSigningLease(path, "standby", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to create signing lease, err %s", err)
+	}
+
+	primary.heartbeat()
+	if err := primary.CheckHeld(); err != nil {
+		t.Fatalf("Expect primary to hold the lease, err %s", err)
+	}
+
+	// The standby takes over the lease that is still renewed, the primary is fenced
+	if err := standby.Takeover(); err != nil {
+		t.Fatalf("Failed to take over signing lease, err %s", err)
+	}
+	if err := standby.CheckHeld(); err != nil {
+		t.Fatalf("Expect standby to hold the lease, err %s", err)
+	}
+	if err := primary.CheckHeld(); err != errLeaseFenced {
+		t.Fatalf("Expect primary to be fenced, got %v", err)
+	}
+	primary.heartbeat()
+	if primary.Held() {
+		t.Fatalf("Expect primary not to renew the lease taken over by standby")
+	}
+	if standby.token <= primary.token {
+		t.Fatalf("Expect the fencing token to increase, primary %d standby %d", primary.token, standby.token)
+	}
+
+	// The renewal keeps the token
+	token := standby.token
+	standby.heartbeat()
+	if err := standby.CheckHeld(); err != nil || standby.token != token {
+		t.Fatalf("Expect standby to keep the token on renewal, token %d err %v", standby.token, err)
+	}
+}
+
+func TestSigningLeaseRace(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		path := filepath.Join(t.TempDir(), "lease")
+		primary, err := NewSigningLease(path, "primary", time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to create signing lease, err %s", err)
+		}
+		standby, err := NewSigningLease(path, "standby", time.Minute)
+		if err != nil {
+			t.Fatalf("Failed to create signing lease, err %s", err)
+		}
+
+		// Both nodes try to acquire the free lease at the same time, each one
+		// waits for the other to read the lease file before writing it and the
+		// standby writes once the primary is done
+		var (
+			wg   sync.WaitGroup
+			read = make(chan struct{}, 2)
+		)
+		for j, lease := range []*SigningLease{primary, standby} {
+			delay := time.Duration(j) * 20 * time.Millisecond
+			lease.afterRead = func() {
+				read <- struct{}{}
+				deadline := time.After(50 * time.Millisecond)
+				for len(read) < 2 {
+					select {
+					case <-deadline:
+						return
+					case <-time.After(time.Millisecond):
+					}
+				}
+				time.Sleep(delay)
+			}
+		}
+		for _, lease := range []*SigningLease{primary, standby} {
+			wg.Add(1)
+			go func(lease *SigningLease) {
+				defer wg.Done()
+				lease.heartbeat()
+			}(lease)
+		}
+		wg.Wait()
+
+		if primary.Held() == standby.Held() {
+			t.Fatalf("Round %d: expect exactly one node to hold the lease, primary %t standby %t", i, primary.Held(), standby.Held())
+		}
+	}
+}
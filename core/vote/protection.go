@@ -0,0 +1,258 @@
+package vote
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	// DefaultProtectionFile is the name of the vote protection file in the
+	// data directory
+	DefaultProtectionFile = "vote_protection.json"
+
+	// ProtectionFormatVersion is the version of the vote protection interchange format
+	ProtectionFormatVersion = "1"
+
+	protectionHistoryLimit = 1024 // Number of signed votes kept per key, the highest one is always kept
+)
+
+var (
+	errDoubleVote   = errors.New("another vote with the same target number is already signed")
+	errStaleVote    = errors.New("a vote with a higher target number is already signed")
+	errSurroundVote = errors.New("a vote with a higher source number is already signed")
+
+	errProtectionFormat = errors.New("unsupported vote protection interchange format")
+)
+
+// ProtectionInterchange is the interchange format of the vote protection history,
+// modeled after the EIP-3076 slashing protection interchange format. It is also
+// the format of the vote protection file.
+type ProtectionInterchange struct {
+	Metadata ProtectionMetadata `json:"metadata"`
+	Data     []ProtectionRecord `json:"data"`
+}
+
+type ProtectionMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+}
+
+// ProtectionRecord is the signed votes of a BLS key in ascending target number
+type ProtectionRecord struct {
+	PublicKey   hexutil.Bytes `json:"pubkey"`
+	SignedVotes []SignedVote  `json:"signed_votes"`
+}
+
+type SignedVote struct {
+	SourceNumber uint64      `json:"source_number,string"`
+	TargetNumber uint64      `json:"target_number,string"`
+	TargetHash   common.Hash `json:"target_hash"`
+	SigningRoot  common.Hash `json:"signing_root"`
+}
+
+// VoteProtection keeps the history of the votes signed with the BLS keys of the
+// node, in a file apart from the chain database so that it survives the restores
+// of the database. A vote is recorded and the file synced before the vote is
+// signed, and the votes conflicting with the history are refused:
+//   - a vote with the same target number as a signed vote but another signing root
+//   - a vote with a lower target number than the highest signed vote
+//   - a vote with a lower source number than the highest signed vote, it would
+//     surround the signed vote
+//
+// The votes targeting the genesis block are the canaries of the node, they are
+// neither checked nor recorded as the genesis block is never voted for.
+type VoteProtection struct {
+	path string // Path of the protection file, the history is only kept in memory if empty
+
+	lock  sync.Mutex
+	votes map[types.BLSPublicKey][]SignedVote
+}
+
+// NewVoteProtection loads the vote protection history from the file at path, the
+// file is created on the first signed vote
+func NewVoteProtection(path string) (*VoteProtection, error) {
+	protection := &VoteProtection{
+		path:  path,
+		votes: make(map[types.BLSPublicKey][]SignedVote),
+	}
+	if path == "" {
+		log.Warn("Vote protection history is not persisted")
+		return protection, nil
+	}
+	blob, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return protection, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var interchange ProtectionInterchange
+	if err := json.Unmarshal(blob, &interchange); err != nil {
+		return nil, fmt.Errorf("invalid vote protection file %s: %w", path, err)
+	}
+	if err := protection.merge(&interchange); err != nil {
+		return nil, err
+	}
+	return protection, nil
+}
+
+// CheckAndRecord checks that the vote does not conflict with the signed votes of
+// the key and records it. The vote must not be signed if an error is returned.
+func (protection *VoteProtection) CheckAndRecord(publicKey types.BLSPublicKey, data *types.VoteData) error {
+	protection.lock.Lock()
+	defer protection.lock.Unlock()
+
+	vote := SignedVote{
+		SourceNumber: data.SourceNumber,
+		TargetNumber: data.TargetNumber,
+		TargetHash:   data.TargetHash,
+		SigningRoot:  data.Hash(),
+	}
+	votes := protection.votes[publicKey]
+	if len(votes) > 0 {
+		highest := votes[len(votes)-1]
+		switch {
+		case vote.TargetNumber == highest.TargetNumber && vote.SigningRoot == highest.SigningRoot:
+			return nil // Signing the same vote again is safe
+		case vote.TargetNumber == highest.TargetNumber:
+			return fmt.Errorf("%w: target %d", errDoubleVote, vote.TargetNumber)
+		case vote.TargetNumber < highest.TargetNumber:
+			return fmt.Errorf("%w: target %d, highest %d", errStaleVote, vote.TargetNumber, highest.TargetNumber)
+		case vote.SourceNumber < highest.SourceNumber:
+			return fmt.Errorf("%w: source %d, highest %d", errSurroundVote, vote.SourceNumber, highest.SourceNumber)
+		}
+	}
+	protection.votes[publicKey] = trimHistory(append(votes, vote))
+	if err := protection.write(); err != nil {
+		protection.votes[publicKey] = votes
+		return fmt.Errorf("failed to persist vote protection history: %w", err)
+	}
+	return nil
+}
+
+// Export returns the vote protection history in the interchange format
+func (protection *VoteProtection) Export() *ProtectionInterchange {
+	protection.lock.Lock()
+	defer protection.lock.Unlock()
+
+	return protection.interchange()
+}
+
+// Import merges the history in the interchange format into the vote protection
+// history. The imported votes can only make the protection stricter, the highest
+// signed vote of a key is never lowered.
+func (protection *VoteProtection) Import(interchange *ProtectionInterchange) error {
+	protection.lock.Lock()
+	defer protection.lock.Unlock()
+
+	previous := make(map[types.BLSPublicKey][]SignedVote, len(protection.votes))
+	for publicKey, votes := range protection.votes {
+		previous[publicKey] = votes
+	}
+	if err := protection.merge(interchange); err != nil {
+		protection.votes = previous
+		return err
+	}
+	if err := protection.write(); err != nil {
+		protection.votes = previous
+		return err
+	}
+	return nil
+}
+
+// merge adds the votes of the interchange to the history
+func (protection *VoteProtection) merge(interchange *ProtectionInterchange) error {
+	if interchange.Metadata.InterchangeFormatVersion != ProtectionFormatVersion {
+		return fmt.Errorf("%w: version %q", errProtectionFormat, interchange.Metadata.InterchangeFormatVersion)
+	}
+	for _, record := range interchange.Data {
+		if len(record.PublicKey) != params.BLSPubkeyLength {
+			return fmt.Errorf("%w: invalid public key %x", errProtectionFormat, record.PublicKey)
+		}
+		var publicKey types.BLSPublicKey
+		copy(publicKey[:], record.PublicKey)
+
+		known := make(map[SignedVote]struct{})
+		votes := append([]SignedVote{}, protection.votes[publicKey]...)
+		for _, vote := range votes {
+			known[vote] = struct{}{}
+		}
+		for _, vote := range record.SignedVotes {
+			if _, ok := known[vote]; !ok {
+				known[vote] = struct{}{}
+				votes = append(votes, vote)
+			}
+		}
+		sort.SliceStable(votes, func(i, j int) bool {
+			return votes[i].TargetNumber < votes[j].TargetNumber
+		})
+		protection.votes[publicKey] = trimHistory(votes)
+	}
+	return nil
+}
+
+func (protection *VoteProtection) interchange() *ProtectionInterchange {
+	interchange := &ProtectionInterchange{
+		Metadata: ProtectionMetadata{InterchangeFormatVersion: ProtectionFormatVersion},
+		Data:     make([]ProtectionRecord, 0, len(protection.votes)),
+	}
+	for publicKey, votes := range protection.votes {
+		interchange.Data = append(interchange.Data, ProtectionRecord{
+			PublicKey:   common.CopyBytes(publicKey[:]),
+			SignedVotes: append([]SignedVote{}, votes...),
+		})
+	}
+	sort.Slice(interchange.Data, func(i, j int) bool {
+		return hexutil.Encode(interchange.Data[i].PublicKey) < hexutil.Encode(interchange.Data[j].PublicKey)
+	})
+	return interchange
+}
+
+// write atomically replaces the protection file and syncs it to the disk
+func (protection *VoteProtection) write() error {
+	if protection.path == "" {
+		return nil
+	}
+	blob, err := json.Marshal(protection.interchange())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(protection.path), 0700); err != nil {
+		return err
+	}
+	tmp := protection.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(blob); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, protection.path)
+}
+
+// trimHistory drops the oldest votes above the history limit
+func trimHistory(votes []SignedVote) []SignedVote {
+	if len(votes) > protectionHistoryLimit {
+		votes = append([]SignedVote{}, votes[len(votes)-protectionHistoryLimit:]...)
+	}
+	return votes
+}
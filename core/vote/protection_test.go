@@ -0,0 +1,94 @@
+package vote
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestVoteProtection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultProtectionFile)
+	protection, err := NewVoteProtection(path)
+	if err != nil {
+		t.Fatalf("Failed to create vote protection, err %s", err)
+	}
+	publicKey := types.BLSPublicKey{0x1}
+
+	tests := []struct {
+		data *types.VoteData
+		err  error
+	}{
+		{&types.VoteData{SourceNumber: 8, TargetNumber: 10, TargetHash: common.Hash{0x1}}, nil},
+		{&types.VoteData{SourceNumber: 8, TargetNumber: 10, TargetHash: common.Hash{0x1}}, nil},
+		{&types.VoteData{SourceNumber: 8, TargetNumber: 10, TargetHash: common.Hash{0x2}}, errDoubleVote},
+		{&types.VoteData{SourceNumber: 8, TargetNumber: 9, TargetHash: common.Hash{0x3}}, errStaleVote},
+		{&types.VoteData{SourceNumber: 7, TargetNumber: 12, TargetHash: common.Hash{0x4}}, errSurroundVote},
+		{&types.VoteData{SourceNumber: 9, TargetNumber: 11, TargetHash: common.Hash{0x5}}, nil},
+		{&types.VoteData{TargetNumber: 0}, errStaleVote},
+	}
+	for i, test := range tests {
+		if err := protection.CheckAndRecord(publicKey, test.data); !errors.Is(err, test.err) {
+			t.Fatalf("Test %d: expect error %v, got %v", i, test.err, err)
+		}
+	}
+
+	// The history survives the restart of the node
+	protection, err = NewVoteProtection(path)
+	if err != nil {
+		t.Fatalf("Failed to reload vote protection, err %s", err)
+	}
+	err = protection.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 9, TargetNumber: 11, TargetHash: common.Hash{0x6}})
+	if !errors.Is(err, errDoubleVote) {
+		t.Fatalf("Expect error %s, got %v", errDoubleVote, err)
+	}
+	if err := protection.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 11, TargetNumber: 12}); err != nil {
+		t.Fatalf("Failed to record vote, err %s", err)
+	}
+}
+
+func TestVoteProtectionInterchange(t *testing.T) {
+	publicKey := types.BLSPublicKey{0x1}
+	source, err := NewVoteProtection("")
+	if err != nil {
+		t.Fatalf("Failed to create vote protection, err %s", err)
+	}
+	for target := uint64(1); target <= 5; target++ {
+		if err := source.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: target - 1, TargetNumber: target}); err != nil {
+			t.Fatalf("Failed to record vote, err %s", err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), DefaultProtectionFile)
+	destination, err := NewVoteProtection(path)
+	if err != nil {
+		t.Fatalf("Failed to create vote protection, err %s", err)
+	}
+	if err := destination.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 6, TargetNumber: 7}); err != nil {
+		t.Fatalf("Failed to record vote, err %s", err)
+	}
+	if err := destination.Import(source.Export()); err != nil {
+		t.Fatalf("Failed to import vote protection history, err %s", err)
+	}
+	// The import does not lower the highest signed vote
+	err = destination.CheckAndRecord(publicKey, &types.VoteData{SourceNumber: 5, TargetNumber: 6})
+	if !errors.Is(err, errStaleVote) {
+		t.Fatalf("Expect error %s, got %v", errStaleVote, err)
+	}
+	destination, err = NewVoteProtection(path)
+	if err != nil {
+		t.Fatalf("Failed to reload vote protection, err %s", err)
+	}
+	exported := destination.Export()
+	if len(exported.Data) != 1 || len(exported.Data[0].SignedVotes) != 6 {
+		t.Fatalf("Expect 6 signed votes in the history, got %+v", exported.Data)
+	}
+
+	// Unknown interchange versions are refused
+	exported.Metadata.InterchangeFormatVersion = "0"
+	if err := destination.Import(exported); !errors.Is(err, errProtectionFormat) {
+		t.Fatalf("Expect error %s, got %v", errProtectionFormat, err)
+	}
+}
@@ -0,0 +1,125 @@
+package vote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// VoteSignerNamespace is the RPC namespace of the vote signer process
+const VoteSignerNamespace = "votesigner"
+
+// VoteSignerService serves the finality vote signing of a node from a separate
+// process, so that the node never holds the BLS key. The service only signs vote
+// data and the fixed canary, never arbitrary messages, and checks every vote
+// against its own vote protection history, so a compromised node cannot make the
+// validator double vote.
+type VoteSignerService struct {
+	signer *VoteSigner
+}
+
+// NewVoteSignerService creates the service signing with the signer, the vote
+// protection history is kept in the file at protectionPath
+func NewVoteSignerService(signer *VoteSigner, protectionPath string) (*VoteSignerService, error) {
+	if protectionPath == "" {
+		return nil, errors.New("empty vote protection path")
+	}
+	protection, err := NewVoteProtection(protectionPath)
+	if err != nil {
+		return nil, err
+	}
+	signer.SetProtection(protection)
+	return &VoteSignerService{signer: signer}, nil
+}
+
+// APIs returns the RPC API of the service, it must only be served over the
+// local socket of the signer process
+func (service *VoteSignerService) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: VoteSignerNamespace,
+			Version:   "1.0",
+			Service:   &voteSignerAPI{service},
+			Public:    true,
+		},
+	}
+}
+
+// signVote signs the vote data if it does not conflict with the signed votes
+func (service *VoteSignerService) signVote(data *types.VoteData) ([]byte, error) {
+	vote := &types.VoteEnvelope{RawVoteEnvelope: types.RawVoteEnvelope{Data: data}}
+	if err := service.signer.SignVote(vote); err != nil {
+		return nil, err
+	}
+	log.Debug("Signed finality vote", "target", data.TargetNumber, "hash", data.TargetHash)
+	return vote.Signature[:], nil
+}
+
+// voteSignerAPI is the RPC API of the vote signer service
+type voteSignerAPI struct {
+	service *VoteSignerService
+}
+
+// PublicKey returns the BLS public key of the signer
+func (api *voteSignerAPI) PublicKey() hexutil.Bytes {
+	return api.service.signer.pubKey[:]
+}
+
+// SignVote returns the BLS signature of the vote data
+func (api *voteSignerAPI) SignVote(data *types.VoteData) (hexutil.Bytes, error) {
+	if data == nil {
+		return nil, errors.New("missing vote data")
+	}
+	return api.service.signVote(data)
+}
+
+// SignCanary returns the BLS signature of the canary signing root, which can
+// never be taken for a vote
+func (api *voteSignerAPI) SignCanary(ctx context.Context) (hexutil.Bytes, error) {
+	return api.service.signer.signCanary(ctx)
+}
+
+// NewRemoteVoteSigner returns a vote signer which signs the votes with the vote
+// signer process listening on the local socket at endpoint
+func NewRemoteVoteSigner(endpoint string) (*VoteSigner, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), voteSignerTimeout)
+	defer cancel()
+
+	client, err := rpc.DialIPC(ctx, endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to the vote signer")
+	}
+	var pubKey hexutil.Bytes
+	if err := client.CallContext(ctx, &pubKey, VoteSignerNamespace+"_publicKey"); err != nil {
+		client.Close()
+		return nil, errors.Wrap(err, "could not fetch the public key of the vote signer")
+	}
+	if len(pubKey) != params.BLSPubkeyLength {
+		client.Close()
+		return nil, fmt.Errorf("invalid vote signer public key length %d", len(pubKey))
+	}
+	log.Info("Connected to the remote vote signer", "endpoint", endpoint)
+
+	signer := &VoteSigner{client: client}
+	copy(signer.pubKey[:], pubKey)
+	return signer, nil
+}
+
+// signRemote signs the vote data with the vote signer process
+func (signer *VoteSigner) signRemote(ctx context.Context, data *types.VoteData) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := signer.client.CallContext(ctx, &signature, VoteSignerNamespace+"_signVote", data); err != nil {
+		return nil, err
+	}
+	if len(signature) != params.BLSSignatureLength {
+		return nil, fmt.Errorf("invalid vote signature length %d", len(signature))
+	}
+	return signature, nil
+}
@@ -0,0 +1,86 @@
+package vote
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestRemoteVoteSigner(t *testing.T) {
+	walletPasswordDir, walletDir := setUpKeyManager(t)
+	signer, err := NewVoteSigner(walletPasswordDir, walletDir)
+	if err != nil {
+		t.Fatalf("Failed to create vote signer, err %s", err)
+	}
+	// Keep the socket path short, unix socket paths are limited to ~100 bytes
+	dir, err := os.MkdirTemp("", "votesigner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "protection.json")
+	service, err := NewVoteSignerService(signer, statePath)
+	if err != nil {
+		t.Fatalf("Failed to create vote signer service, err %s", err)
+	}
+	listener, server, err := rpc.StartIPCEndpoint(filepath.Join(dir, "signer.ipc"), service.APIs())
+	if err != nil {
+		t.Fatalf("Failed to start vote signer endpoint, err %s", err)
+	}
+	defer server.Stop()
+	defer listener.Close()
+
+	remote, err := NewRemoteVoteSigner(filepath.Join(dir, "signer.ipc"))
+	if err != nil {
+		t.Fatalf("Failed to connect to the vote signer, err %s", err)
+	}
+	if remote.pubKey != signer.pubKey {
+		t.Fatalf("Public key mismatch, expect %x, got %x", signer.pubKey, remote.pubKey)
+	}
+	if err := remote.checkCanary(); err != nil {
+		t.Fatalf("Expect canary check to pass, err %s", err)
+	}
+
+	vote := &types.VoteEnvelope{RawVoteEnvelope: types.RawVoteEnvelope{
+		Data: &types.VoteData{TargetNumber: 10, TargetHash: common.Hash{0x1}, ChainID: big.NewInt(2021), SourceNumber: 9, SourceHash: common.Hash{0x9}},
+	}}
+	if err := remote.SignVote(vote); err != nil {
+		t.Fatalf("Failed to sign vote, err %s", err)
+	}
+	if err := vote.Verify(); err != nil {
+		t.Fatalf("Invalid vote signature, err %s", err)
+	}
+	genesis := &types.VoteEnvelope{RawVoteEnvelope: types.RawVoteEnvelope{
+		Data: &types.VoteData{TargetNumber: 0, ChainID: big.NewInt(2021)},
+	}}
+	if err := remote.SignVote(genesis); !errors.Is(err, errGenesisVote) {
+		t.Fatalf("Expect error %v, got %v", errGenesisVote, err)
+	}
+
+	// The signer refuses to sign another vote at the same height
+	vote = &types.VoteEnvelope{RawVoteEnvelope: types.RawVoteEnvelope{
+		Data: &types.VoteData{TargetNumber: 10, TargetHash: common.Hash{0x2}},
+	}}
+	if err := remote.SignVote(vote); err == nil {
+		t.Fatalf("Expect error when signing two votes at the same height")
+	}
+
+	// The vote protection history survives the restart of the signer
+	service, err = NewVoteSignerService(signer, statePath)
+	if err != nil {
+		t.Fatalf("Failed to reload vote signer service, err %s", err)
+	}
+	if _, err := service.signVote(&types.VoteData{TargetNumber: 9}); !errors.Is(err, errStaleVote) {
+		t.Fatalf("Expect error %s, got %v", errStaleVote, err)
+	}
+	if _, err := service.signVote(&types.VoteData{SourceNumber: 10, TargetNumber: 11}); err != nil {
+		t.Fatalf("Failed to sign vote, err %s", err)
+	}
+}
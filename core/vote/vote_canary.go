@@ -0,0 +1,92 @@
+package vote
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const voteCanaryInterval = 5 * time.Minute
+
+var (
+	voteCanaryFailureCounter = metrics.NewRegisteredCounter("votesSigner/canary/failure", nil)
+
+	errCanarySignatureInvalid = errors.New("canary vote signature is invalid")
+	errBlsKeyNotRegistered    = errors.New("BLS public key is not registered for the validator")
+)
+
+// CanaryAlertFn is called with the BLS public key and the error when the vote
+// canary check fails
+type CanaryAlertFn func(publicKey []byte, err error)
+
+// voteCanaryRoot is the signing root of the canary. The preimage is not the RLP
+// encoding of a vote data, so the canary signature can never be taken for a
+// vote, and it is fixed, so signing it again gives the same signature.
+var voteCanaryRoot = crypto.Keccak256Hash([]byte("ronin-vote-canary"))
+
+// checkCanary signs the canary with the loaded key and verifies the signature
+// against the public key of the signer
+func (signer *VoteSigner) checkCanary() error {
+	ctx, cancel := context.WithTimeout(context.Background(), voteSignerTimeout)
+	defer cancel()
+
+	rawSignature, err := signer.signCanary(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to sign canary")
+	}
+	publicKey, err := bls.PublicKeyFromBytes(signer.pubKey[:])
+	if err != nil {
+		return err
+	}
+	signature, err := bls.SignatureFromBytes(rawSignature)
+	if err != nil {
+		return errors.Wrap(err, "invalid canary signature")
+	}
+	if !signature.Verify(publicKey, voteCanaryRoot[:]) {
+		return errCanarySignatureInvalid
+	}
+	return nil
+}
+
+// checkCanary checks that the loaded BLS key still signs valid votes and that
+// it is the key registered on chain when the node is an active validator
+func (voteManager *VoteManager) checkCanary() error {
+	if err := voteManager.signer.checkCanary(); err != nil {
+		return err
+	}
+
+	head := voteManager.chain.CurrentHeader()
+	if !voteManager.engine.IsActiveValidatorAt(voteManager.chain, head) {
+		return nil
+	}
+	publicKey, err := bls.PublicKeyFromBytes(voteManager.signer.pubKey[:])
+	if err != nil {
+		return err
+	}
+	for _, validator := range voteManager.engine.GetActiveValidatorAt(voteManager.chain, head.Number.Uint64(), head.Hash()) {
+		if validator.BlsPublicKey != nil && validator.BlsPublicKey.Equals(publicKey) {
+			return nil
+		}
+	}
+	return errBlsKeyNotRegistered
+}
+
+// runCanary runs the canary check, alerting on failure
+func (voteManager *VoteManager) runCanary() {
+	err := voteManager.checkCanary()
+	if err == nil {
+		log.Debug("Vote canary check passed")
+		return
+	}
+	voteCanaryFailureCounter.Inc(1)
+	log.Error("Vote canary check failed, the BLS key material may be corrupted or mismatched", "err", err)
+	if voteManager.canaryAlert != nil {
+		voteManager.canaryAlert(voteManager.signer.pubKey[:], err)
+	}
+}
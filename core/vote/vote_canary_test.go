@@ -0,0 +1,28 @@
+package vote
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/bls"
+)
+
+func TestVoteSignerCanary(t *testing.T) {
+	walletPasswordDir, walletDir := setUpKeyManager(t)
+	signer, err := NewVoteSigner(walletPasswordDir, walletDir)
+	if err != nil {
+		t.Fatalf("Failed to create vote signer, err %s", err)
+	}
+	if err := signer.checkCanary(); err != nil {
+		t.Fatalf("Expect canary check to pass, err %s", err)
+	}
+
+	// The signer public key does not match the key in the wallet
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate BLS key, err %s", err)
+	}
+	copy(signer.pubKey[:], secretKey.PublicKey().Marshal())
+	if err := signer.checkCanary(); err == nil {
+		t.Fatalf("Expect canary check to fail with a mismatched public key")
+	}
+}
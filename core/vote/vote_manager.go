@@ -2,6 +2,7 @@ package vote
 
 import (
 	"encoding/hex"
+	"time"
 
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
@@ -38,8 +39,10 @@ type VoteManager struct {
 	chainHeadCh  chan core.ChainHeadEvent
 	chainHeadSub event.Subscription
 
-	pool   *VotePool
-	signer *VoteSigner
+	pool        *VotePool
+	signer      *VoteSigner
+	guards      []func() error // Checks that must pass before voting
+	canaryAlert CanaryAlertFn  // Called when the periodic check of the BLS key fails
 
 	engine consensus.FastFinalityPoSA
 
@@ -55,6 +58,10 @@ func NewVoteManager(
 	pool *VotePool,
 	enableSign bool,
 	blsPasswordPath, blsWalletPath string,
+	blsRemoteSigner string,
+	protection *VoteProtection,
+	guards []func() error,
+	canaryAlert CanaryAlertFn,
 	engine consensus.FastFinalityPoSA,
 	debug *Debug,
 ) (*VoteManager, error) {
@@ -66,19 +73,33 @@ func NewVoteManager(
 		chainconfig: chainconfig,
 		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
 
-		pool:   pool,
-		engine: engine,
-		debug:  debug,
+		pool:        pool,
+		guards:      guards,
+		canaryAlert: canaryAlert,
+		engine:      engine,
+		debug:       debug,
 	}
 
 	if enableSign {
-		// Create voteSigner.
-		voteSigner, err := NewVoteSigner(blsPasswordPath, blsWalletPath)
+		// Create voteSigner, the BLS key is held by the vote signer process if any.
+		var (
+			voteSigner *VoteSigner
+			err        error
+		)
+		if blsRemoteSigner != "" {
+			voteSigner, err = NewRemoteVoteSigner(blsRemoteSigner)
+		} else {
+			voteSigner, err = NewVoteSigner(blsPasswordPath, blsWalletPath)
+		}
 		if err != nil {
 			return nil, err
 		}
+		if protection != nil {
+			voteSigner.SetProtection(protection)
+		}
 		log.Info("BLS voter public key", "public key", hex.EncodeToString(voteSigner.pubKey[:]))
 		voteManager.signer = voteSigner
+		pool.SetLocalVoter(voteSigner.pubKey, protection)
 	}
 
 	// Subscribe to chain head event.
@@ -102,6 +123,9 @@ func (voteManager *VoteManager) loop() {
 
 	dlEventCh := events.Chan()
 
+	canaryTicker := time.NewTicker(voteCanaryInterval)
+	defer canaryTicker.Stop()
+
 	startVote := true
 	for {
 		select {
@@ -134,6 +158,10 @@ func (voteManager *VoteManager) loop() {
 				log.Debug("voting is disable, skip voting")
 				continue
 			}
+			if err := voteManager.checkGuards(); err != nil {
+				log.Debug("skip voting", "reason", err)
+				continue
+			}
 
 			if cHead.Block == nil {
 				log.Debug("cHead.Block is nil, continue")
@@ -147,11 +175,10 @@ func (voteManager *VoteManager) loop() {
 				continue
 			}
 
-			// Vote for curBlockHeader block.
-			vote := &types.VoteData{
-				TargetNumber: curHead.Number.Uint64(),
-				TargetHash:   curHead.Hash(),
-			}
+			// Vote for curBlockHeader block, the source is the justified block as of
+			// curBlockHeader which is only committed to from the Tripp hardfork.
+			sourceNumber, sourceHash := voteManager.engine.GetJustifiedBlock(voteManager.chain, curHead.Number.Uint64(), curHead.Hash())
+			vote := types.NewVoteData(voteManager.chain.Config(), curHead.Number.Uint64(), curHead.Hash(), sourceNumber, sourceHash)
 			voteMessage := &types.VoteEnvelope{
 				RawVoteEnvelope: types.RawVoteEnvelope{
 					Data: vote,
@@ -173,6 +200,10 @@ func (voteManager *VoteManager) loop() {
 				voteManager.pool.PutVote("", voteMessage)
 				votesManagerCounter.Inc(1)
 			}
+		case <-canaryTicker.C:
+			if voteManager.signer != nil {
+				voteManager.runCanary()
+			}
 		case <-voteManager.chainHeadSub.Err():
 			log.Debug("voteManager subscribed chainHead failed")
 			return
@@ -180,6 +211,16 @@ func (voteManager *VoteManager) loop() {
 	}
 }
 
+// checkGuards returns the error of the first failed guard
+func (voteManager *VoteManager) checkGuards() error {
+	for _, guard := range voteManager.guards {
+		if err := guard(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // UnderRules checks if the produced header under the following rules:
 // A validator must not publish two distinct votes for the same height. (Rule 1)
 // Validators always vote for their canonical chain’s latest block. (Rule 2)
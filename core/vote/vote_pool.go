@@ -2,6 +2,7 @@ package vote
 
 import (
 	"container/heap"
+	"errors"
 	"sync"
 	"time"
 
@@ -18,7 +19,8 @@ const (
 	maxFutureVoteAmountPerBlock = 50
 	maxFutureVotePerPeer        = 25
 
-	voteBufferForPut = 256
+	voteBufferForPut           = 256
+	aggregatedVoteBufferForPut = 16
 	// votes in the range (currentBlockNum-256,currentBlockNum+11] will be stored
 	lowerLimitOfVoteBlockNumber = 256
 	upperLimitOfVoteBlockNumber = 11 // refer to fetcher.maxUncleDist
@@ -63,12 +65,27 @@ type VotePool struct {
 
 	votesCh chan *voteWithPeer
 
+	aggregatedVotes       map[common.Hash]*types.AggregatedVote   // Aggregated votes with the most voters by target block hash
+	futureAggregatedVotes map[common.Hash][]*futureAggregatedVote // Aggregated votes for the unknown blocks by target block hash, most voters first
+	aggregatedFeed        event.Feed
+	aggregatedVotesCh     chan *aggregatedVoteWithPeer
+
+	futureAggregatedPerPeer map[string]uint64 // number of buffered aggregated votes per peer
+
+	equivocations    map[common.Hash]*types.VoteEquivocation // Known proofs of conflicting votes by proof hash
+	blacklist        map[types.BLSPublicKey]uint64           // Last epoch whose votes are refused by equivocating key
+	equivocationFeed event.Feed
+
 	engine                   consensus.FastFinalityPoSA
 	maxCurVoteAmountPerBlock int
 
-	numFutureVotePerPeer map[string]uint64      // number of queued votes per peer
-	originatedFrom       map[common.Hash]string // mapping from vote hash to the sender
+	numFutureVotePerPeer map[string]uint64         // number of queued votes per peer
+	originatedFrom       map[common.Hash]string    // mapping from vote hash to the sender
+	receivedAt           map[common.Hash]time.Time // mapping from vote hash to the time it is put into the pool
 	justifiedBlockNumber uint64
+
+	localKey        *types.BLSPublicKey // BLS public key of the node, nil if the node does not vote, see SetLocalVoter
+	localProtection *VoteProtection     // History of the votes signed with the local key, nil if not kept
 }
 
 type votesPriorityQueue []*types.VoteData
@@ -86,10 +103,17 @@ func NewVotePool(
 		futureVotesPq:            &votesPriorityQueue{},
 		chainHeadCh:              make(chan core.ChainHeadEvent, chainHeadChanSize),
 		votesCh:                  make(chan *voteWithPeer, voteBufferForPut),
+		aggregatedVotes:          make(map[common.Hash]*types.AggregatedVote),
+		futureAggregatedVotes:    make(map[common.Hash][]*futureAggregatedVote),
+		aggregatedVotesCh:        make(chan *aggregatedVoteWithPeer, aggregatedVoteBufferForPut),
+		equivocations:            make(map[common.Hash]*types.VoteEquivocation),
+		blacklist:                make(map[types.BLSPublicKey]uint64),
 		engine:                   engine,
 		maxCurVoteAmountPerBlock: maxCurVoteAmountPerBlock,
 		numFutureVotePerPeer:     make(map[string]uint64),
+		futureAggregatedPerPeer:  make(map[string]uint64),
 		originatedFrom:           make(map[common.Hash]string),
+		receivedAt:               make(map[common.Hash]time.Time),
 	}
 
 	// Subscribe events from blockchain and start the main event loop.
@@ -113,6 +137,7 @@ func (pool *VotePool) loop() {
 				pool.justifiedBlockNumber = justifiedBlockNumber
 				pool.prune(latestBlockNumber)
 				pool.transferVotesFromFutureToCur(ev.Block.Header())
+				pool.transferFutureAggregatedVotes(latestBlockNumber)
 				pool.mu.Unlock()
 			}
 		case <-pool.chainHeadSub.Err():
@@ -121,6 +146,10 @@ func (pool *VotePool) loop() {
 		// Handle votes channel and put the vote into vote pool.
 		case vote := <-pool.votesCh:
 			pool.putIntoVotePool(vote)
+
+		// Handle aggregated votes channel
+		case vote := <-pool.aggregatedVotesCh:
+			pool.putAggregatedVote(vote)
 		}
 	}
 }
@@ -155,6 +184,10 @@ func (pool *VotePool) putIntoVotePool(voteWithPeerInfo *voteWithPeer) bool {
 		log.Debug("BlockNumber of vote is older than justified block number")
 		return false
 	}
+	if pool.blacklisted(vote) {
+		log.Debug("Vote is signed with an equivocating key", "number", targetNumber, "hash", targetHash)
+		return false
+	}
 
 	voteHash := vote.Hash()
 	if _, ok := pool.originatedFrom[voteHash]; ok {
@@ -203,6 +236,10 @@ func (pool *VotePool) putIntoVotePool(voteWithPeerInfo *voteWithPeer) bool {
 		if pool.engine.VerifyVote(pool.chain, vote) != nil {
 			return false
 		}
+		// The conflicting vote is only kept as the proof of the equivocation
+		if pool.detectEquivocation(vote) {
+			return false
+		}
 
 		// Send vote for handler usage of broadcasting to peers.
 		voteEv := core.NewVoteEvent{Vote: vote}
@@ -210,10 +247,46 @@ func (pool *VotePool) putIntoVotePool(voteWithPeerInfo *voteWithPeer) bool {
 	}
 
 	pool.putVote(votes, votesPq, vote, voteData, voteHash, isFutureVote)
+	pool.receivedAt[voteHash] = time.Now()
+	if !isFutureVote {
+		pool.aggregateVotes(targetHash)
+	}
 
 	return true
 }
 
+// externalVotePeer is the peer recorded for the votes submitted through the API
+const externalVotePeer = "api"
+
+var (
+	errUnknownVoteTarget = errors.New("unknown target block")
+	errVoteRejected      = errors.New("vote rejected by the pool")
+)
+
+// SubmitVote verifies the vote signed outside of the node, e.g. by a validator
+// whose BLS key lives in a custodial setup, against the validator set of the
+// target block and puts it into the pool. Unlike the votes from the peers, the
+// target block must be known. The vote protection of the local votes is not
+// applied, the external signer is responsible for not double voting.
+func (pool *VotePool) SubmitVote(vote *types.VoteEnvelope) error {
+	if vote.Data == nil {
+		return errors.New("missing vote data")
+	}
+	if pool.chain.GetHeaderByHash(vote.Data.TargetHash) == nil {
+		return errUnknownVoteTarget
+	}
+	if err := pool.engine.VerifyVote(pool.chain, vote); err != nil {
+		return err
+	}
+	if err := vote.Verify(); err != nil {
+		return err
+	}
+	if !pool.putIntoVotePool(&voteWithPeer{vote: vote, peer: externalVotePeer}) {
+		return errVoteRejected
+	}
+	return nil
+}
+
 func (pool *VotePool) SubscribeNewVoteEvent(ch chan<- core.NewVoteEvent) event.Subscription {
 	return pool.scope.Track(pool.votesFeed.Subscribe(ch))
 }
@@ -295,6 +368,9 @@ func (pool *VotePool) transfer(blockHash common.Hash) {
 		if pool.engine.VerifyVote(pool.chain, vote) != nil {
 			continue
 		}
+		if pool.blacklisted(vote) || pool.detectEquivocation(vote) {
+			continue
+		}
 
 		// In the process of transfer, send valid vote to votes channel for handler usage
 		voteEv := core.NewVoteEvent{Vote: vote}
@@ -310,6 +386,7 @@ func (pool *VotePool) transfer(blockHash common.Hash) {
 	} else {
 		curVotes[blockHash].voteMessages = append(curVotes[blockHash].voteMessages, validVotes...)
 	}
+	pool.aggregateVotes(blockHash)
 
 	for _, vote := range futureVotes[blockHash].voteMessages {
 		peer, ok := pool.originatedFrom[vote.Hash()]
@@ -348,6 +425,7 @@ func (pool *VotePool) pruneVote(
 						pool.numFutureVotePerPeer[peer]--
 					}
 					delete(pool.originatedFrom, voteHash)
+					delete(pool.receivedAt, voteHash)
 				}
 				delete(voteMap, blockHash)
 			}
@@ -362,6 +440,8 @@ func (pool *VotePool) pruneVote(
 func (pool *VotePool) prune(latestBlockNumber uint64) {
 	pool.pruneVote(latestBlockNumber, pool.curVotes, pool.curVotesPq, false)
 	pool.pruneVote(latestBlockNumber, pool.futureVotes, pool.futureVotesPq, true)
+	pool.pruneAggregatedVotes(latestBlockNumber)
+	pool.pruneEquivocations(latestBlockNumber)
 }
 
 // GetVotes as batch.
@@ -383,18 +463,9 @@ func (pool *VotePool) GetVotes() []*types.VoteEnvelope {
 // if it still cannot acquire the lock. This mechanism helps to make this function safer
 // because we cannot control the writers and we don't want this function to block the caller.
 func (pool *VotePool) FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteEnvelope {
-	var retry int
-	for retry = 0; retry < fetchRetry; retry++ {
-		if !pool.mu.TryRLock() {
-			time.Sleep(fetchCheckFrequency)
-		} else {
-			break
-		}
-	}
-
 	// We try to acquire read lock fetchRetry times
 	// but can not do it, so just return nil here
-	if retry == fetchRetry {
+	if !pool.tryRLock() {
 		return nil
 	}
 
@@ -408,6 +479,18 @@ func (pool *VotePool) FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteE
 	}
 }
 
+// tryRLock polls the read lock of the pool fetchRetry times, it returns whether
+// the lock is acquired
+func (pool *VotePool) tryRLock() bool {
+	for retry := 0; retry < fetchRetry; retry++ {
+		if pool.mu.TryRLock() {
+			return true
+		}
+		time.Sleep(fetchCheckFrequency)
+	}
+	return false
+}
+
 func (pool *VotePool) basicVerify(vote *types.VoteEnvelope, headNumber uint64, m map[common.Hash]*VoteBox, isFutureVote bool, voteHash common.Hash) bool {
 	targetHash := vote.Data.TargetHash
 
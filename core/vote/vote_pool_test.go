@@ -124,9 +124,9 @@ func testVotePool(t *testing.T, isValidRules bool) {
 		voteManager *VoteManager
 	)
 	if isValidRules {
-		voteManager, err = NewVoteManager(newTestBackend(), db, params.TestChainConfig, chain, votePool, true, walletPasswordDir, walletDir, mockEngine, nil)
+		voteManager, err = NewVoteManager(newTestBackend(), db, params.TestChainConfig, chain, votePool, true, walletPasswordDir, walletDir, "", nil, nil, nil, mockEngine, nil)
 	} else {
-		voteManager, err = NewVoteManager(newTestBackend(), db, params.TestChainConfig, chain, votePool, true, walletPasswordDir, walletDir, mockEngine, &Debug{ValidateRule: func(header *types.Header) error {
+		voteManager, err = NewVoteManager(newTestBackend(), db, params.TestChainConfig, chain, votePool, true, walletPasswordDir, walletDir, "", nil, nil, nil, mockEngine, &Debug{ValidateRule: func(header *types.Header) error {
 			return errors.New("mock error")
 		}})
 	}
@@ -276,6 +276,9 @@ func testVotePool(t *testing.T, isValidRules bool) {
 			futureBlockHash = bs[0].Hash()
 			futureVotesMap := votePool.futureVotes
 			voteBox := futureVotesMap[common.Hash{}]
+			// The vote targets the block now, it would otherwise conflict with
+			// the local vote for the block and be refused as an equivocation
+			voteBox.voteMessages[0].Data.TargetHash = futureBlockHash
 			futureVotesMap[futureBlockHash] = voteBox
 			delete(futureVotesMap, common.Hash{})
 			futureVotesPq := votePool.futureVotesPq
@@ -529,3 +532,418 @@ func TestVotePoolWrongTargetNumber(t *testing.T) {
 		t.Fatalf("Current vote length, expect %d have %d", 0, len(votePool.curVotes))
 	}
 }
+
+type mockPOSAv3 struct {
+	mockPOSAv2
+}
+
+func (m *mockPOSAv3) AggregateVotes(chain consensus.ChainHeaderReader, votes []*types.VoteEnvelope) (*types.AggregatedVote, error) {
+	if len(votes) < 2 {
+		return nil, nil
+	}
+	var bitSet types.ValidatorsBitSet
+	for i := range votes {
+		bitSet |= 1 << i
+	}
+	return &types.AggregatedVote{VotedValidators: bitSet, Data: votes[0].Data}, nil
+}
+
+// errForgedAggregatedVote is returned by the mock engine for the aggregated votes
+// whose signature starts with 0xff
+var errForgedAggregatedVote = errors.New("forged aggregated vote")
+
+func (m *mockPOSAv3) VerifyAggregatedVote(chain consensus.ChainHeaderReader, vote *types.AggregatedVote) error {
+	if vote.Signature[0] == 0xff {
+		return errForgedAggregatedVote
+	}
+	return nil
+}
+
+func (m *mockPOSAv3) GetJustifiedBlock(chain consensus.ChainHeaderReader, blockNumber uint64, blockHash common.Hash) (uint64, common.Hash) {
+	return 0, common.Hash{}
+}
+
+func TestVotePoolAggregatedVote(t *testing.T) {
+	// Create a database pre-initialize with a genesis block
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, nil, true)
+	if _, err := chain.InsertChain(bs[:1]); err != nil {
+		panic(err)
+	}
+	mockEngine := &mockPOSAv3{}
+
+	// Create vote pool
+	votePool := NewVotePool(chain, mockEngine, 22)
+	aggregatedCh := make(chan core.NewAggregatedVoteEvent, 4)
+	sub := votePool.SubscribeNewAggregatedVoteEvent(aggregatedCh)
+	defer sub.Unsubscribe()
+
+	for i := 0; i < 2; i++ {
+		secretKey, err := bls.RandKey()
+		if err != nil {
+			t.Fatalf("Failed to create secret key, err %s", err)
+		}
+		votePool.PutVote("AAAA", generateVote(1, bs[0].Hash(), secretKey))
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	vote := votePool.FetchAggregatedVoteByBlockHash(bs[0].Hash())
+	if vote == nil || vote.VotedValidators != 0b11 {
+		t.Fatalf("Aggregated vote, expect voted validators %b have %v", 0b11, vote)
+	}
+	select {
+	case ev := <-aggregatedCh:
+		if ev.Vote != vote {
+			t.Fatalf("Aggregated vote event, expect %v have %v", vote, ev.Vote)
+		}
+	default:
+		t.Fatalf("Missing aggregated vote event")
+	}
+
+	// The aggregated vote with fewer voters is discarded
+	votePool.PutAggregatedVote("peer", &types.AggregatedVote{VotedValidators: 0b100, Data: vote.Data})
+	time.Sleep(100 * time.Millisecond)
+	if have := votePool.FetchAggregatedVoteByBlockHash(bs[0].Hash()); have != vote {
+		t.Fatalf("Aggregated vote, expect %v have %v", vote, have)
+	}
+
+	// The aggregated vote with more voters replaces the known one
+	more := &types.AggregatedVote{VotedValidators: 0b111, Data: vote.Data}
+	votePool.PutAggregatedVote("peer", more)
+	time.Sleep(100 * time.Millisecond)
+	if have := votePool.FetchAggregatedVoteByBlockHash(bs[0].Hash()); have != more {
+		t.Fatalf("Aggregated vote, expect %v have %v", more, have)
+	}
+	if len(aggregatedCh) != 1 {
+		t.Fatalf("Aggregated vote events, expect %d have %d", 1, len(aggregatedCh))
+	}
+}
+
+func TestVotePoolFutureAggregatedVote(t *testing.T) {
+	// Create a database pre-initialize with a genesis block
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 2, nil, true)
+	if _, err := chain.InsertChain(bs[:1]); err != nil {
+		panic(err)
+	}
+	votePool := NewVotePool(chain, &mockPOSAv3{}, 22)
+	aggregatedCh := make(chan core.NewAggregatedVoteEvent, 4)
+	sub := votePool.SubscribeNewAggregatedVoteEvent(aggregatedCh)
+	defer sub.Unsubscribe()
+
+	// The aggregated vote for the unknown block is buffered, not relayed
+	data := &types.VoteData{TargetNumber: 2, TargetHash: bs[1].Hash()}
+	fewer := &types.AggregatedVote{VotedValidators: 0b11, Data: data}
+	more := &types.AggregatedVote{VotedValidators: 0b111, Data: data}
+	votePool.PutAggregatedVote("peer", more)
+	votePool.PutAggregatedVote("peer", fewer)
+	// The forged aggregated vote with the most voters does not replace the others
+	forged := &types.AggregatedVote{VotedValidators: 0b1111, Signature: types.BLSSignature{0xff}, Data: data}
+	votePool.PutAggregatedVote("attacker", forged)
+	// The aggregated vote too far ahead of the head is discarded
+	votePool.PutAggregatedVote("peer", &types.AggregatedVote{
+		VotedValidators: 0b11,
+		Data:            &types.VoteData{TargetNumber: 2 + upperLimitOfVoteBlockNumber, TargetHash: common.Hash{0x1}},
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	votePool.mu.RLock()
+	buffered := len(votePool.futureAggregatedVotes)
+	votePool.mu.RUnlock()
+	if buffered != 1 {
+		t.Fatalf("Buffered aggregated votes, expect %d have %d", 1, buffered)
+	}
+	if have := votePool.FetchAggregatedVoteByBlockHash(bs[1].Hash()); have != nil {
+		t.Fatalf("Aggregated vote for unknown block, expect nil have %v", have)
+	}
+	if len(aggregatedCh) != 0 {
+		t.Fatalf("Aggregated vote events, expect %d have %d", 0, len(aggregatedCh))
+	}
+
+	// A peer cannot flush the buffer with the votes for random blocks
+	for i := 0; i < maxFutureAggregatedVotes; i++ {
+		votePool.PutAggregatedVote("attacker", &types.AggregatedVote{
+			VotedValidators: 0b11,
+			Data:            &types.VoteData{TargetNumber: 2, TargetHash: common.Hash{byte(i), 0x1}},
+		})
+		// Keep the channel from overflowing
+		if i%aggregatedVoteBufferForPut == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	votePool.mu.RLock()
+	buffered = len(votePool.futureAggregatedVotes)
+	attacker := votePool.futureAggregatedPerPeer["attacker"]
+	votePool.mu.RUnlock()
+	if buffered != maxFutureAggregatedVotePerPeer || attacker != maxFutureAggregatedVotePerPeer {
+		t.Fatalf("Buffered aggregated votes, expect %d have %d from %d by the attacker", maxFutureAggregatedVotePerPeer, buffered, attacker)
+	}
+
+	// The buffered aggregated vote is verified once the block is imported
+	if _, err := chain.InsertChain(bs[1:]); err != nil {
+		panic(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if have := votePool.FetchAggregatedVoteByBlockHash(bs[1].Hash()); have != more {
+		t.Fatalf("Aggregated vote, expect %v have %v", more, have)
+	}
+	if len(aggregatedCh) != 1 {
+		t.Fatalf("Aggregated vote events, expect %d have %d", 1, len(aggregatedCh))
+	}
+	votePool.mu.RLock()
+	buffered = len(votePool.futureAggregatedVotes)
+	votePool.mu.RUnlock()
+	if buffered != maxFutureAggregatedVotePerPeer-1 {
+		t.Fatalf("Buffered aggregated votes, expect %d have %d", maxFutureAggregatedVotePerPeer-1, buffered)
+	}
+}
+
+func TestSubmitExternalVote(t *testing.T) {
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create secret key, err %s", err)
+	}
+
+	// Create a database pre-initialize with a genesis block
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, nil, true)
+	if _, err := chain.InsertChain(bs[:1]); err != nil {
+		panic(err)
+	}
+	votePool := NewVotePool(chain, &mockPOSAv3{}, 22)
+	api := NewPrivateVotePoolAPI(votePool)
+
+	if _, err := api.GetVoteData(common.Hash{0x1}); err == nil {
+		t.Fatalf("Expect error for unknown block")
+	}
+	data, err := api.GetVoteData(bs[0].Hash())
+	if err != nil {
+		t.Fatalf("Failed to get vote data, err %s", err)
+	}
+	if data.TargetNumber != 1 || data.TargetHash != bs[0].Hash() {
+		t.Fatalf("Vote data mismatch, got %+v", data)
+	}
+	publicKey := secretKey.PublicKey().Marshal()
+
+	// The signature over another digest is rejected
+	otherDigest := common.Hash{0x2}
+	if _, err := api.SubmitVote(bs[0].Hash(), publicKey, secretKey.Sign(otherDigest[:]).Marshal()); err == nil {
+		t.Fatalf("Expect error for invalid signature")
+	}
+	if _, err := api.SubmitVote(bs[0].Hash(), publicKey[1:], secretKey.Sign(data.Digest[:]).Marshal()); err == nil {
+		t.Fatalf("Expect error for invalid public key length")
+	}
+	if len(votePool.curVotes) != 0 {
+		t.Fatalf("Current vote length, expect %d have %d", 0, len(votePool.curVotes))
+	}
+
+	voteHash, err := api.SubmitVote(bs[0].Hash(), publicKey, secretKey.Sign(data.Digest[:]).Marshal())
+	if err != nil {
+		t.Fatalf("Failed to submit vote, err %s", err)
+	}
+	votes := votePool.FetchVoteByBlockHash(bs[0].Hash())
+	if len(votes) != 1 || votes[0].Hash() != voteHash {
+		t.Fatalf("Expect the submitted vote in the pool, got %d votes", len(votes))
+	}
+	if peer := votePool.originatedFrom[voteHash]; peer != externalVotePeer {
+		t.Fatalf("Expect vote from %s, got %s", externalVotePeer, peer)
+	}
+
+	// The same vote is not accepted twice
+	if _, err := api.SubmitVote(bs[0].Hash(), publicKey, secretKey.Sign(data.Digest[:]).Marshal()); err == nil {
+		t.Fatalf("Expect error for duplicated vote")
+	}
+}
+
+func TestVoteEquivocation(t *testing.T) {
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create secret key, err %s", err)
+	}
+	otherKey, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create secret key, err %s", err)
+	}
+
+	// Create a chain with two blocks at height 1
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 2, nil, true)
+	forks, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x1})
+	}, true)
+	if _, err := chain.InsertChain(bs); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(forks); err != nil {
+		t.Fatal(err)
+	}
+	votePool := NewVotePool(chain, &mockPOSA{}, 22)
+	proofCh := make(chan core.NewVoteEquivocationEvent, 1)
+	sub := votePool.SubscribeNewVoteEquivocationEvent(proofCh)
+	defer sub.Unsubscribe()
+
+	first := generateVote(1, bs[0].Hash(), secretKey)
+	if !votePool.putIntoVotePool(&voteWithPeer{vote: first, peer: "1"}) {
+		t.Fatalf("Expect the first vote to be accepted")
+	}
+	if !votePool.putIntoVotePool(&voteWithPeer{vote: generateVote(1, forks[0].Hash(), otherKey), peer: "1"}) {
+		t.Fatalf("Expect the vote of another key to be accepted")
+	}
+	// The conflicting vote is refused and the proof is broadcast
+	second := generateVote(1, forks[0].Hash(), secretKey)
+	if votePool.putIntoVotePool(&voteWithPeer{vote: second, peer: "2"}) {
+		t.Fatalf("Expect the conflicting vote to be refused")
+	}
+	var proof *types.VoteEquivocation
+	select {
+	case ev := <-proofCh:
+		proof = ev.Proof
+	default:
+		t.Fatalf("Expect an equivocation proof")
+	}
+	if err := proof.Verify(); err != nil {
+		t.Fatalf("Invalid equivocation proof, err %s", err)
+	}
+	if proof.First.Data.TargetHash != bs[0].Hash() || proof.Second.Data.TargetHash != forks[0].Hash() {
+		t.Fatalf("Equivocation proof mismatch, got %+v %+v", proof.First.Data, proof.Second.Data)
+	}
+	// The further votes of the key are refused for the epoch
+	if votePool.putIntoVotePool(&voteWithPeer{vote: generateVote(2, bs[1].Hash(), secretKey), peer: "1"}) {
+		t.Fatalf("Expect the vote of the equivocating key to be refused")
+	}
+	if !votePool.putIntoVotePool(&voteWithPeer{vote: generateVote(2, bs[1].Hash(), otherKey), peer: "1"}) {
+		t.Fatalf("Expect the vote of another key to be accepted")
+	}
+
+	// The proof received by another node blacklists the key without detection
+	otherPool := NewVotePool(chain, &mockPOSA{}, 22)
+	invalid := &types.VoteEquivocation{First: first.Raw(), Second: generateVote(1, forks[0].Hash(), otherKey).Raw()}
+	if err := otherPool.AddVoteEquivocation(invalid); err == nil {
+		t.Fatalf("Expect error for the votes of different keys")
+	}
+	if err := otherPool.AddVoteEquivocation(&types.VoteEquivocation{First: first.Raw(), Second: first.Raw()}); err == nil {
+		t.Fatalf("Expect error for the same votes")
+	}
+	if err := otherPool.AddVoteEquivocation(proof); err != nil {
+		t.Fatalf("Failed to add equivocation proof, err %s", err)
+	}
+	if proofs := otherPool.Equivocations(); len(proofs) != 1 || proofs[0].Hash() != proof.Hash() {
+		t.Fatalf("Expect the equivocation proof in the pool, got %d proofs", len(proofs))
+	}
+	if otherPool.putIntoVotePool(&voteWithPeer{vote: generateVote(2, bs[1].Hash(), secretKey), peer: "1"}) {
+		t.Fatalf("Expect the vote of the equivocating key to be refused")
+	}
+	infos, err := NewPublicVotePoolAPI(otherPool).GetVoteEquivocations()
+	if err != nil {
+		t.Fatalf("Failed to get vote equivocations, err %s", err)
+	}
+	if len(infos) != 1 || infos[0].Hash != proof.Hash() || len(infos[0].Evidence) == 0 {
+		t.Fatalf("Vote equivocations mismatch, got %+v", infos)
+	}
+}
+
+func TestExportImportVotes(t *testing.T) {
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to create secret key, err %s", err)
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	genesis := (&core.Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}).MustCommit(db)
+	chain, _ := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{}, nil, nil)
+
+	bs, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 1, nil, true)
+	if _, err := chain.InsertChain(bs[:1]); err != nil {
+		panic(err)
+	}
+	primary := NewVotePool(chain, &mockPOSAv3{}, 22)
+	standby := NewVotePool(chain, &mockPOSAv3{}, 22)
+
+	data, err := NewPrivateVotePoolAPI(primary).GetVoteData(bs[0].Hash())
+	if err != nil {
+		t.Fatalf("Failed to get vote data, err %s", err)
+	}
+	voteHash, err := NewPrivateVotePoolAPI(primary).SubmitVote(bs[0].Hash(), secretKey.PublicKey().Marshal(), secretKey.Sign(data.Digest[:]).Marshal())
+	if err != nil {
+		t.Fatalf("Failed to submit vote, err %s", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "votes.rlp.gz")
+	if n, err := NewPrivateVotePoolAdminAPI(primary).ExportVotes(file); err != nil || n != 1 {
+		t.Fatalf("Failed to export votes, have %d err %v", n, err)
+	}
+	if _, err := NewPrivateVotePoolAdminAPI(primary).ExportVotes(file); err == nil {
+		t.Fatalf("Expect error when overwriting the export")
+	}
+	if n, err := NewPrivateVotePoolAdminAPI(standby).ImportVotes(file); err != nil || n != 1 {
+		t.Fatalf("Failed to import votes, have %d err %v", n, err)
+	}
+	votes := standby.FetchVoteByBlockHash(bs[0].Hash())
+	if len(votes) != 1 || votes[0].Hash() != voteHash {
+		t.Fatalf("Expect the imported vote in the pool, got %d votes", len(votes))
+	}
+	if peer := standby.originatedFrom[voteHash]; peer != importedVotePeer {
+		t.Fatalf("Expect vote from %s, got %s", importedVotePeer, peer)
+	}
+	// The votes already in the pool are skipped
+	if n, err := NewPrivateVotePoolAdminAPI(standby).ImportVotes(file); err != nil || n != 0 {
+		t.Fatalf("Expect no vote imported twice, have %d err %v", n, err)
+	}
+
+	// The votes of the local key are refused without the vote protection
+	var localKey types.BLSPublicKey
+	copy(localKey[:], secretKey.PublicKey().Marshal())
+	standby = NewVotePool(chain, &mockPOSAv3{}, 22)
+	standby.SetLocalVoter(localKey, nil)
+	if n, err := NewPrivateVotePoolAdminAPI(standby).ImportVotes(file); err != nil || n != 0 {
+		t.Fatalf("Expect the vote of the local key to be refused, have %d err %v", n, err)
+	}
+
+	// The votes of the local key are recorded in the vote protection
+	protection, err := NewVoteProtection("")
+	if err != nil {
+		t.Fatalf("Failed to create vote protection, err %s", err)
+	}
+	standby.SetLocalVoter(localKey, protection)
+	if n, err := NewPrivateVotePoolAdminAPI(standby).ImportVotes(file); err != nil || n != 1 {
+		t.Fatalf("Failed to import the vote of the local key, have %d err %v", n, err)
+	}
+	conflict := *standby.FetchVoteByBlockHash(bs[0].Hash())[0].Data
+	conflict.TargetHash = common.Hash{0x1}
+	if err := protection.CheckAndRecord(localKey, &conflict); !errors.Is(err, errDoubleVote) {
+		t.Fatalf("Expect the imported vote in the vote protection, got %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	wallet "github.com/ethereum/go-ethereum/accounts/bls"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/pkg/errors"
@@ -13,6 +14,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto/bls"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 const (
@@ -21,9 +23,15 @@ const (
 
 var votesSigningErrorCounter = metrics.NewRegisteredCounter("votesSigner/error", nil)
 
+// errGenesisVote is returned if the vote targets the genesis block
+var errGenesisVote = errors.New("vote targets the genesis block")
+
 type VoteSigner struct {
 	km     *wallet.KeyManager
+	client *rpc.Client // Connection to the vote signer process holding the key, nil if km is set
 	pubKey [params.BLSPubkeyLength]byte
+
+	protection *VoteProtection // History of the signed votes, the votes are not checked if nil
 }
 
 func NewVoteSigner(blsPasswordPath, blsWalletPath string) (*VoteSigner, error) {
@@ -60,6 +68,12 @@ func NewVoteSigner(blsPasswordPath, blsWalletPath string) (*VoteSigner, error) {
 	}, nil
 }
 
+// SetProtection makes the signer refuse to sign the votes conflicting with the
+// vote protection history
+func (signer *VoteSigner) SetProtection(protection *VoteProtection) {
+	signer.protection = protection
+}
+
 func (signer *VoteSigner) SignVote(vote *types.VoteEnvelope) error {
 	// Sign the vote, fetch the first pubKey as validator's bls public key.
 	pubKey := signer.pubKey
@@ -68,11 +82,30 @@ func (signer *VoteSigner) SignVote(vote *types.VoteEnvelope) error {
 		return errors.Wrap(err, "convert public key from bytes to bls failed")
 	}
 
-	voteDataHash := vote.Data.Hash()
+	// The genesis block is never voted
+	if vote.Data.TargetNumber == 0 {
+		return errGenesisVote
+	}
+	if signer.protection != nil {
+		if err := signer.protection.CheckAndRecord(pubKey, vote.Data); err != nil {
+			return errors.Wrap(err, "vote refused by the vote protection")
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), voteSignerTimeout)
 	defer cancel()
 
+	if signer.client != nil {
+		signature, err := signer.signRemote(ctx, vote.Data)
+		if err != nil {
+			return err
+		}
+		copy(vote.PublicKey[:], blsPubKey.Marshal()[:])
+		copy(vote.Signature[:], signature)
+		return nil
+	}
+
+	voteDataHash := vote.Data.Hash()
 	signature, err := (*signer.km).Sign(ctx, &wallet.SignRequest{
 		PublicKey:   pubKey[:],
 		SigningRoot: voteDataHash[:],
@@ -85,3 +118,22 @@ func (signer *VoteSigner) SignVote(vote *types.VoteEnvelope) error {
 	copy(vote.Signature[:], signature.Marshal()[:])
 	return nil
 }
+
+// signCanary signs the canary signing root, see checkCanary
+func (signer *VoteSigner) signCanary(ctx context.Context) ([]byte, error) {
+	if signer.client != nil {
+		var signature hexutil.Bytes
+		if err := signer.client.CallContext(ctx, &signature, VoteSignerNamespace+"_signCanary"); err != nil {
+			return nil, err
+		}
+		return signature, nil
+	}
+	signature, err := (*signer.km).Sign(ctx, &wallet.SignRequest{
+		PublicKey:   signer.pubKey[:],
+		SigningRoot: voteCanaryRoot[:],
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signature.Marshal(), nil
+}
@@ -2,5 +2,8 @@
 // BLS12-381 curve and signature scheme. This package exposes a public API for
 // verifying and aggregating BLS signatures used by Ethereum.
 //
-// This implementation uses the library written by Supranational, blst.
+// This implementation uses the library written by Supranational, blst. As blst
+// requires cgo, building with the bls_purego tag selects instead a pure-Go
+// backend on top of crypto/bls12381, it is considerably slower but allows
+// cross-compiling nodes that only verify aggregated signatures.
 package blst
@@ -0,0 +1,107 @@
+//go:build bls_purego
+
+package blst
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pkg/errors"
+)
+
+var maxKeys = 1000000
+var pubkeyCache, _ = lru.New(maxKeys)
+
+// PublicKey used in the BLS signature scheme.
+type PublicKey struct {
+	p *bls12381.PointG1
+}
+
+// PublicKeyFromBytes creates a BLS public key from a  BigEndian byte slice.
+func PublicKeyFromBytes(pubKey []byte) (common.PublicKey, error) {
+	if len(pubKey) != params.BLSPubkeyLength {
+		return nil, fmt.Errorf("public key must be %d bytes", params.BLSPubkeyLength)
+	}
+	newKey := (*[params.BLSPubkeyLength]byte)(pubKey)
+	if cv, ok := pubkeyCache.Get(*newKey); ok {
+		return cv.(*PublicKey).Copy(), nil
+	}
+	g1 := bls12381.NewG1()
+	p, err := g1.FromCompressed(pubKey)
+	if err != nil {
+		return nil, errors.New("could not unmarshal bytes into public key")
+	}
+	// Subgroup and infinity check
+	if g1.IsZero(p) || !g1.InCorrectSubgroup(p) {
+		// NOTE: the error is not quite accurate since it includes group check
+		return nil, common.ErrInfinitePubKey
+	}
+	pubKeyObj := &PublicKey{p: p}
+	copiedKey := pubKeyObj.Copy()
+	cacheKey := *newKey
+	pubkeyCache.Add(cacheKey, copiedKey)
+	return pubKeyObj, nil
+}
+
+// AggregatePublicKeys aggregates the provided raw public keys into a single key.
+func AggregatePublicKeys(pubs [][]byte) (common.PublicKey, error) {
+	if len(pubs) == 0 {
+		return nil, errors.New("nil or empty public keys")
+	}
+	g1 := bls12381.NewG1()
+	agg := g1.Zero()
+	for _, pubkey := range pubs {
+		pubKeyObj, err := PublicKeyFromBytes(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		g1.Add(agg, agg, pubKeyObj.(*PublicKey).p)
+	}
+	return &PublicKey{p: g1.Affine(agg)}, nil
+}
+
+// Marshal a public key into a LittleEndian byte slice.
+func (p *PublicKey) Marshal() []byte {
+	return bls12381.NewG1().ToCompressed(p.p)
+}
+
+// Copy the public key to a new pointer reference.
+func (p *PublicKey) Copy() common.PublicKey {
+	return &PublicKey{p: new(bls12381.PointG1).Set(p.p)}
+}
+
+// IsInfinite checks if the public key is infinite.
+func (p *PublicKey) IsInfinite() bool {
+	return bls12381.NewG1().IsZero(p.p)
+}
+
+// Equals checks if the provided public key is equal to
+// the current one.
+func (p *PublicKey) Equals(p2 common.PublicKey) bool {
+	return bls12381.NewG1().Equal(p.p, p2.(*PublicKey).p)
+}
+
+// Aggregate two public keys.
+func (p *PublicKey) Aggregate(p2 common.PublicKey) common.PublicKey {
+	g1 := bls12381.NewG1()
+	agg := g1.New()
+	// No group check here since it is checked at decompression time
+	g1.Add(agg, p.p, p2.(*PublicKey).p)
+	p.p = g1.Affine(agg)
+
+	return p
+}
+
+// AggregateMultiplePubkeys aggregates the provided decompressed keys into a single key.
+func AggregateMultiplePubkeys(pubkeys []common.PublicKey) common.PublicKey {
+	g1 := bls12381.NewG1()
+	agg := g1.Zero()
+	// No group check needed here since it is done in PublicKeyFromBytes
+	for _, pubkey := range pubkeys {
+		g1.Add(agg, agg, pubkey.(*PublicKey).p)
+	}
+	return &PublicKey{p: g1.Affine(agg)}
+}
@@ -0,0 +1,96 @@
+//go:build bls_purego
+
+package blst
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/crypto/rand"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// bls12SecretKey used in the BLS signature scheme.
+type bls12SecretKey struct {
+	p *big.Int
+}
+
+// RandKey creates a new private key using a random method provided as an io.Reader.
+func RandKey() (common.SecretKey, error) {
+	// Generate 48 bytes of randomness, reduced modulo the group order with a
+	// negligible bias
+	var ikm [48]byte
+	_, err := rand.NewGenerator().Read(ikm[:])
+	if err != nil {
+		return nil, err
+	}
+	// Defensive check, that we have not generated a secret key,
+	secKey := &bls12SecretKey{new(big.Int).Mod(new(big.Int).SetBytes(ikm[:]), bls12381.NewG1().Q())}
+	if IsZero(secKey.Marshal()) {
+		return nil, common.ErrZeroKey
+	}
+	return secKey, nil
+}
+
+// SecretKeyFromBytes creates a BLS private key from a BigEndian byte slice.
+func SecretKeyFromBytes(privKey []byte) (common.SecretKey, error) {
+	if len(privKey) != params.BLSSecretKeyLength {
+		return nil, fmt.Errorf("secret key must be %d bytes", params.BLSSecretKeyLength)
+	}
+	// The key must be a non-zero scalar less than the group order
+	secKey := new(big.Int).SetBytes(privKey)
+	if secKey.Sign() == 0 || secKey.Cmp(bls12381.NewG1().Q()) >= 0 {
+		return nil, common.ErrSecretUnmarshal
+	}
+	wrappedKey := &bls12SecretKey{p: secKey}
+	if IsZero(privKey) {
+		return nil, common.ErrZeroKey
+	}
+	return wrappedKey, nil
+}
+
+// PublicKey obtains the public key corresponding to the BLS secret key.
+func (s *bls12SecretKey) PublicKey() common.PublicKey {
+	g1 := bls12381.NewG1()
+	p := g1.New()
+	g1.MulScalar(p, g1.One(), s.p)
+	return &PublicKey{p: g1.Affine(p)}
+}
+
+// IsZero checks if the secret key is a zero key.
+func IsZero(sKey []byte) bool {
+	b := byte(0)
+	for _, s := range sKey {
+		b |= s
+	}
+	return subtle.ConstantTimeByteEq(b, 0) == 1
+}
+
+// Sign a message using a secret key - in a beacon/validator client.
+//
+// In IETF draft BLS specification:
+// Sign(SK, message) -> signature: a signing algorithm that generates
+//
+//	a deterministic signature given a secret key SK and a message.
+//
+// In Ethereum proof of stake specification:
+// def Sign(SK: int, message: Bytes) -> BLSSignature
+func (s *bls12SecretKey) Sign(msg []byte) common.Signature {
+	g2 := bls12381.NewG2()
+	h, err := g2.HashToCurve(msg, dst)
+	if err != nil {
+		// The domain separation tag is constant, hashing never fails
+		panic(err)
+	}
+	g2.MulScalar(h, h, s.p)
+	return &Signature{s: g2.Affine(h)}
+}
+
+// Marshal a secret key into a LittleEndian byte slice.
+func (s *bls12SecretKey) Marshal() []byte {
+	keyBytes := make([]byte, params.BLSSecretKeyLength)
+	return s.p.FillBytes(keyBytes)
+}
@@ -0,0 +1,289 @@
+//go:build bls_purego
+
+package blst
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/crypto/rand"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/pkg/errors"
+)
+
+var dst = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+const randBitsEntropy = 64
+
+// Signature used in the BLS signature scheme.
+type Signature struct {
+	s *bls12381.PointG2
+}
+
+// signatureFromBytes decompresses and group checks a signature. Do not check
+// for infinity since an aggregated signature could be infinite.
+func signatureFromBytes(sig []byte) (*bls12381.PointG2, error) {
+	if len(sig) != params.BLSSignatureLength {
+		return nil, fmt.Errorf("signature must be %d bytes", params.BLSSignatureLength)
+	}
+	g2 := bls12381.NewG2()
+	signature, err := g2.FromCompressed(sig)
+	if err != nil {
+		return nil, errors.New("could not unmarshal bytes into signature")
+	}
+	if !g2.InCorrectSubgroup(signature) {
+		return nil, errors.New("signature not in group")
+	}
+	return signature, nil
+}
+
+// SignatureFromBytes creates a BLS signature from a LittleEndian byte slice.
+func SignatureFromBytes(sig []byte) (common.Signature, error) {
+	signature, err := signatureFromBytes(sig)
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{s: signature}, nil
+}
+
+// AggregateCompressedSignatures converts a list of compressed signatures into a single, aggregated sig.
+func AggregateCompressedSignatures(multiSigs [][]byte) (common.Signature, error) {
+	g2 := bls12381.NewG2()
+	agg := g2.Zero()
+	for _, sig := range multiSigs {
+		signature, err := signatureFromBytes(sig)
+		if err != nil {
+			return nil, errors.New("provided signatures fail the group check and cannot be compressed")
+		}
+		g2.Add(agg, agg, signature)
+	}
+	return &Signature{s: g2.Affine(agg)}, nil
+}
+
+// MultipleSignaturesFromBytes creates a group of BLS signatures from a LittleEndian 2d-byte slice.
+func MultipleSignaturesFromBytes(multiSigs [][]byte) ([]common.Signature, error) {
+	if len(multiSigs) == 0 {
+		return nil, fmt.Errorf("0 signatures provided to the method")
+	}
+	for _, s := range multiSigs {
+		if len(s) != params.BLSSignatureLength {
+			return nil, fmt.Errorf("signature must be %d bytes", params.BLSSignatureLength)
+		}
+	}
+	wrappedSigs := make([]common.Signature, len(multiSigs))
+	for i, sig := range multiSigs {
+		signature, err := SignatureFromBytes(sig)
+		if err != nil {
+			return nil, err
+		}
+		wrappedSigs[i] = signature
+	}
+	return wrappedSigs, nil
+}
+
+// hashToG2 hashes the message to a G2 point with the signature scheme tag.
+func hashToG2(g2 *bls12381.G2, msg []byte) *bls12381.PointG2 {
+	h, err := g2.HashToCurve(msg, dst)
+	if err != nil {
+		// The domain separation tag is constant, hashing never fails
+		panic(err)
+	}
+	return h
+}
+
+// Verify a bls signature given a public key, a message.
+//
+// In IETF draft BLS specification:
+// Verify(PK, message, signature) -> VALID or INVALID: a verification
+//
+//	algorithm that outputs VALID if signature is a valid signature of
+//	message under public key PK, and INVALID otherwise.
+//
+// In the Ethereum proof of stake specification:
+// def Verify(PK: BLSPubkey, message: Bytes, signature: BLSSignature) -> bool
+func (s *Signature) Verify(pubKey common.PublicKey, msg []byte) bool {
+	// Signature and PKs are assumed to have been validated upon decompression!
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(pubKey.(*PublicKey).p, hashToG2(g2, msg))
+	engine.AddPairInv(g1.One(), s.s)
+	return engine.Check()
+}
+
+// AggregateVerify verifies each public key against its respective message. This is vulnerable to
+// rogue public-key attack. Each user must provide a proof-of-knowledge of the public key.
+//
+// Note: The msgs must be distinct. For maximum performance, this method does not ensure distinct
+// messages.
+//
+// In IETF draft BLS specification:
+// AggregateVerify((PK_1, message_1), ..., (PK_n, message_n),
+//
+//	signature) -> VALID or INVALID: an aggregate verification
+//	algorithm that outputs VALID if signature is a valid aggregated
+//	signature for a collection of public keys and messages, and
+//	outputs INVALID otherwise.
+//
+// In the Ethereum proof of stake specification:
+// def AggregateVerify(pairs: Sequence[PK: BLSPubkey, message: Bytes], signature: BLSSignature) -> bool
+//
+// Deprecated: Use FastAggregateVerify or use this method in spectests only.
+func (s *Signature) AggregateVerify(pubKeys []common.PublicKey, msgs [][32]byte) bool {
+	size := len(pubKeys)
+	if size == 0 {
+		return false
+	}
+	if size != len(msgs) {
+		return false
+	}
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	engine := bls12381.NewPairingEngine()
+	for i := 0; i < size; i++ {
+		engine.AddPair(pubKeys[i].(*PublicKey).p, hashToG2(g2, msgs[i][:]))
+	}
+	// Signature and PKs are assumed to have been validated upon decompression!
+	engine.AddPairInv(g1.One(), s.s)
+	return engine.Check()
+}
+
+// FastAggregateVerify verifies all the provided public keys with their aggregated signature.
+//
+// In IETF draft BLS specification:
+// FastAggregateVerify(PK_1, ..., PK_n, message, signature) -> VALID
+//
+//	or INVALID: a verification algorithm for the aggregate of multiple
+//	signatures on the same message.  This function is faster than
+//	AggregateVerify.
+//
+// In the Ethereum proof of stake specification:
+// def FastAggregateVerify(PKs: Sequence[BLSPubkey], message: Bytes, signature: BLSSignature) -> bool
+func (s *Signature) FastAggregateVerify(pubKeys []common.PublicKey, msg [32]byte) bool {
+	if len(pubKeys) == 0 {
+		return false
+	}
+	return s.Verify(AggregateMultiplePubkeys(pubKeys), msg[:])
+}
+
+// Eth2FastAggregateVerify implements a wrapper on top of bls's FastAggregateVerify. It accepts G2_POINT_AT_INFINITY signature
+// when pubkeys empty.
+//
+// Spec code:
+// def eth2_fast_aggregate_verify(pubkeys: Sequence[BLSPubkey], message: Bytes32, signature: BLSSignature) -> bool:
+//
+//	"""
+//	Wrapper to ``bls.FastAggregateVerify`` accepting the ``G2_POINT_AT_INFINITY`` signature when ``pubkeys`` is empty.
+//	"""
+//	if len(pubkeys) == 0 and signature == G2_POINT_AT_INFINITY:
+//	    return True
+//	return bls.FastAggregateVerify(pubkeys, message, signature)
+func (s *Signature) Eth2FastAggregateVerify(pubKeys []common.PublicKey, msg [32]byte) bool {
+	if len(pubKeys) == 0 && bytes.Equal(s.Marshal(), common.InfiniteSignature[:]) {
+		return true
+	}
+	return s.FastAggregateVerify(pubKeys, msg)
+}
+
+// NewAggregateSignature creates a blank aggregate signature.
+func NewAggregateSignature() common.Signature {
+	g2 := bls12381.NewG2()
+	return &Signature{s: hashToG2(g2, []byte{'m', 'o', 'c', 'k'})}
+}
+
+// AggregateSignatures converts a list of signatures into a single, aggregated sig.
+func AggregateSignatures(sigs []common.Signature) common.Signature {
+	if len(sigs) == 0 {
+		return nil
+	}
+
+	// Signature and PKs are assumed to have been validated upon decompression!
+	g2 := bls12381.NewG2()
+	agg := g2.Zero()
+	for i := 0; i < len(sigs); i++ {
+		g2.Add(agg, agg, sigs[i].(*Signature).s)
+	}
+	return &Signature{s: g2.Affine(agg)}
+}
+
+// VerifySignature verifies a single signature using public key and message.
+func VerifySignature(sig []byte, msg [32]byte, pubKey common.PublicKey) (bool, error) {
+	rSig, err := SignatureFromBytes(sig)
+	if err != nil {
+		return false, err
+	}
+	return rSig.Verify(pubKey, msg[:]), nil
+}
+
+// VerifyMultipleSignatures verifies a non-singular set of signatures and its respective pubkeys and messages.
+// This method provides a safe way to verify multiple signatures at once. We pick a number randomly from 1 to max
+// uint64 and then multiply the signature by it. We continue doing this for all signatures and its respective pubkeys.
+// S* = S_1 * r_1 + S_2 * r_2 + ... + S_n * r_n
+// P'_{i,j} = P_{i,j} * r_i
+// e(S*, G) = \prod_{i=1}^n \prod_{j=1}^{m_i} e(P'_{i,j}, M_{i,j})
+// Using this we can verify multiple signatures safely.
+func VerifyMultipleSignatures(sigs [][]byte, msgs [][32]byte, pubKeys []common.PublicKey) (bool, error) {
+	if len(sigs) == 0 || len(pubKeys) == 0 {
+		return false, nil
+	}
+	length := len(sigs)
+	if length != len(pubKeys) || length != len(msgs) {
+		return false, errors.Errorf("provided signatures, pubkeys and messages have differing lengths. S: %d, P: %d,M %d",
+			length, len(pubKeys), len(msgs))
+	}
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	engine := bls12381.NewPairingEngine()
+
+	// Secure source of RNG
+	randGen := rand.NewGenerator()
+	aggSig := g2.Zero()
+	for i := 0; i < length; i++ {
+		// Validate signatures since we uncompress them here. Public keys should already be validated.
+		sig, err := signatureFromBytes(sigs[i])
+		if err != nil {
+			return false, nil
+		}
+		var rbytes [randBitsEntropy / 8]byte
+		randGen.Read(rbytes[:]) // #nosec G104 -- Error will always be nil in `read` in math/rand
+		// Protect against the generator returning 0. Since the scalar value is
+		// derived from a big endian byte slice, we take the last byte.
+		rbytes[len(rbytes)-1] |= 0x01
+		scalar := new(big.Int).SetBytes(rbytes[:])
+
+		weightedSig := g2.New()
+		g2.MulScalar(weightedSig, sig, scalar)
+		g2.Add(aggSig, aggSig, weightedSig)
+
+		weightedKey := g1.New()
+		g1.MulScalar(weightedKey, pubKeys[i].(*PublicKey).p, scalar)
+		engine.AddPair(weightedKey, hashToG2(g2, msgs[i][:]))
+	}
+	engine.AddPairInv(g1.One(), aggSig)
+	return engine.Check(), nil
+}
+
+// Marshal a signature into a LittleEndian byte slice.
+func (s *Signature) Marshal() []byte {
+	return bls12381.NewG2().ToCompressed(s.s)
+}
+
+// Copy returns a full deep copy of a signature.
+func (s *Signature) Copy() common.Signature {
+	return &Signature{s: new(bls12381.PointG2).Set(s.s)}
+}
+
+// VerifyCompressed verifies that the compressed signature and pubkey
+// are valid from the message provided.
+func VerifyCompressed(signature, pub, msg []byte) bool {
+	// Validate signature and PKs since we will uncompress them here
+	sig, err := SignatureFromBytes(signature)
+	if err != nil {
+		return false
+	}
+	pubKey, err := PublicKeyFromBytes(pub)
+	if err != nil {
+		return false
+	}
+	return sig.Verify(pubKey, msg)
+}
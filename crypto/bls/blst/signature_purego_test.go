@@ -0,0 +1,150 @@
+//go:build bls_purego
+
+package blst
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/bls/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Keys and signatures of the message produced by the blst backend, the pure-Go
+// backend must be byte for byte compatible with it.
+var (
+	compatMsg = []byte("ronin finality vote digest 00001")
+	compatSks = []string{
+		"721755959f59463bfcee70ccea3e8a56dcf57e6640e09c1d51e6f8ec01b12722",
+		"4695bc55bed7da1ea0520eac284c0ea2749ecf73f25d7f79e79f4750186b9f40",
+	}
+	compatPks = []string{
+		"84fe268400062ae6255010a421204dad5b0a8bd93522a4abc023037fa7a769f6ef29acc14c7fae8c5c0cd1df21ae1f52",
+		"8b850e23cf188473e04feaa5d39f40f54b8c0e717f7fccb77d0efb5326802274bda863911efa8775a08674b887e0e766",
+	}
+	compatSigs = []string{
+		"a553a8b72afc18ee87a314f68e747fad2e667405059ce2b761f796bd26a6d24b395d40c52c65d7714c810d6d658eb485089b2b95d44f4c3eca3314ea779b14a5b2e0001d9beae7af692ccf3b577eae53f68f723bbbb8ab1825efc22a31771ca1",
+		"828060c8afefadfe52bf032557afd766d1cf9103c591a53c0370e0df73af159a2b93e97794dbd9dae4e28fca9a05df8014351c518093c4f1738a6095e8f2e2e9ef3da394873f6caff653f6e19545ed5a0ea9f54d3cea6005afd2a789422a3ba4",
+	}
+	compatAggSig = "859d993f178b1b0d4190994ce2ea540a443709c9d8151e72cdcc21aff1410c57acfb42a34b897a07b5e19033d4f5545a05f3dec8cb6b51c6138efbf7ab7395ac34c34c126ec22bbe22f513b06e4b3fda7a9f08136a383da8f101a301cdfa2174"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestBlstCompatibility(t *testing.T) {
+	var (
+		pubkeys []common.PublicKey
+		sigs    []common.Signature
+	)
+	for i := range compatSks {
+		priv, err := SecretKeyFromBytes(mustDecodeHex(t, compatSks[i]))
+		require.NoError(t, err)
+		pub := priv.PublicKey()
+		if !bytes.Equal(pub.Marshal(), mustDecodeHex(t, compatPks[i])) {
+			t.Fatalf("Expect public key %s, got %x", compatPks[i], pub.Marshal())
+		}
+		sig := priv.Sign(compatMsg)
+		if !bytes.Equal(sig.Marshal(), mustDecodeHex(t, compatSigs[i])) {
+			t.Fatalf("Expect signature %s, got %x", compatSigs[i], sig.Marshal())
+		}
+		// The signature and key produced by blst must decode and verify
+		decodedPub, err := PublicKeyFromBytes(mustDecodeHex(t, compatPks[i]))
+		require.NoError(t, err)
+		decodedSig, err := SignatureFromBytes(mustDecodeHex(t, compatSigs[i]))
+		require.NoError(t, err)
+		assert.Equal(t, true, decodedSig.Verify(decodedPub, compatMsg), "Signature did not verify")
+
+		pubkeys = append(pubkeys, decodedPub)
+		sigs = append(sigs, decodedSig)
+	}
+	aggSig := AggregateSignatures(sigs)
+	if !bytes.Equal(aggSig.Marshal(), mustDecodeHex(t, compatAggSig)) {
+		t.Fatalf("Expect aggregated signature %s, got %x", compatAggSig, aggSig.Marshal())
+	}
+	var msg [32]byte
+	copy(msg[:], compatMsg)
+	assert.Equal(t, true, aggSig.FastAggregateVerify(pubkeys, msg), "Aggregated signature did not verify")
+	assert.Equal(t, false, aggSig.FastAggregateVerify(pubkeys[:1], msg), "Aggregated signature verified with a missing key")
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, err := RandKey()
+	require.NoError(t, err)
+	pub := priv.PublicKey()
+	msg := []byte("hello")
+	sig := priv.Sign(msg)
+	assert.Equal(t, true, sig.Verify(pub, msg), "Signature did not verify")
+	assert.Equal(t, false, sig.Verify(pub, []byte("olleh")), "Signature did verify")
+	assert.Equal(t, true, VerifyCompressed(sig.Marshal(), pub.Marshal(), msg), "Compressed signatures and pubkeys did not verify")
+}
+
+func TestAggregateVerify(t *testing.T) {
+	pubkeys := make([]common.PublicKey, 0, 4)
+	sigs := make([]common.Signature, 0, 4)
+	var (
+		sigBytes [][]byte
+		msgs     [][32]byte
+	)
+	for i := 0; i < 4; i++ {
+		msg := [32]byte{'h', 'e', 'l', 'l', 'o', byte(i)}
+		priv, err := RandKey()
+		require.NoError(t, err)
+		sig := priv.Sign(msg[:])
+		pubkeys = append(pubkeys, priv.PublicKey())
+		sigs = append(sigs, sig)
+		sigBytes = append(sigBytes, sig.Marshal())
+		msgs = append(msgs, msg)
+	}
+	aggSig := AggregateSignatures(sigs)
+	// skipcq: GO-W1009
+	assert.Equal(t, true, aggSig.AggregateVerify(pubkeys, msgs), "Signature did not verify")
+
+	aggSig2, err := AggregateCompressedSignatures(sigBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, aggSig.Marshal(), aggSig2.Marshal(), "Signature did not match up")
+
+	verified, err := VerifyMultipleSignatures(sigBytes, msgs, pubkeys)
+	assert.NoError(t, err)
+	assert.Equal(t, true, verified, "Signatures did not verify")
+
+	// Swapping two signatures must fail the batch verification
+	sigBytes[0], sigBytes[1] = sigBytes[1], sigBytes[0]
+	verified, err = VerifyMultipleSignatures(sigBytes, msgs, pubkeys)
+	assert.NoError(t, err)
+	assert.Equal(t, false, verified, "Signatures did verify")
+}
+
+func TestEth2FastAggregateVerify_ReturnsTrueOnG2PointAtInfinity(t *testing.T) {
+	var pubkeys []common.PublicKey
+	msg := [32]byte{'h', 'e', 'l', 'l', 'o'}
+
+	g2PointAtInfinity := append([]byte{0xC0}, make([]byte, 95)...)
+	aggSig, err := SignatureFromBytes(g2PointAtInfinity)
+	require.NoError(t, err)
+	assert.Equal(t, true, aggSig.Eth2FastAggregateVerify(pubkeys, msg))
+}
+
+func TestSignatureFromBytes_Invalid(t *testing.T) {
+	// An arbitrary x coordinate, it is not a valid signature
+	invalid := mustDecodeHex(t, "8123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if _, err := SignatureFromBytes(invalid); err == nil {
+		t.Fatal("Expect error when decoding an invalid point")
+	}
+}
+
+func TestCopy(t *testing.T) {
+	priv, err := RandKey()
+	require.NoError(t, err)
+	sig := priv.Sign([]byte("foo"))
+	copied := sig.Copy()
+	assert.Equal(t, sig.Marshal(), copied.Marshal())
+
+	sig.(*Signature).s = priv.Sign([]byte("bar")).(*Signature).s
+	assert.NotEqual(t, sig.Marshal(), copied.Marshal())
+}
@@ -0,0 +1,11 @@
+//go:build !bls_purego
+
+package bls
+
+import "github.com/ethereum/go-ethereum/crypto/bls/herumi"
+
+// Initialize herumi temporarily while we transition to blst for ethdo. Herumi
+// requires cgo, it is left out of the pure-Go build.
+func init() {
+	herumi.HerumiInit()
+}
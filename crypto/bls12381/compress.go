@@ -0,0 +1,149 @@
+package bls12381
+
+import (
+	"errors"
+)
+
+// Flags of the most significant byte of a compressed point, the serialization
+// follows the zcash format used by the BLS signatures.
+const (
+	compressionFlag = 0x80 // Set in the compressed form
+	infinityFlag    = 0x40 // Set if the point is the point at infinity
+	signFlag        = 0x20 // Set if y is the lexicographically largest square root
+	flagsMask       = compressionFlag | infinityFlag | signFlag
+)
+
+// isLexicographicallyLargest returns whether the field element is larger than
+// its negation
+func (e *fe) isLexicographicallyLargest() bool {
+	return toBig(e).Cmp(pMinus1Over2) > 0
+}
+
+// isLexicographicallyLargest returns whether the element is larger than its
+// negation, c1 is compared first then c0 if c1 is zero
+func (e *fe2) isLexicographicallyLargest() bool {
+	if !e[1].isZero() {
+		return e[1].isLexicographicallyLargest()
+	}
+	return e[0].isLexicographicallyLargest()
+}
+
+// decodeCompressedFlags checks the flags of a compressed point and clears them
+// in the copy of the input. It returns whether the point is the infinity and
+// the sign of y.
+func decodeCompressedFlags(in []byte) ([]byte, bool, bool, error) {
+	if in[0]&compressionFlag == 0 {
+		return nil, false, false, errors.New("point is not compressed")
+	}
+	infinity, sign := in[0]&infinityFlag != 0, in[0]&signFlag != 0
+	out := make([]byte, len(in))
+	copy(out, in)
+	out[0] &^= flagsMask
+	if infinity {
+		if sign {
+			return nil, false, false, errors.New("invalid flags of point at infinity")
+		}
+		for _, b := range out {
+			if b != 0 {
+				return nil, false, false, errors.New("invalid point at infinity")
+			}
+		}
+	}
+	return out, infinity, sign, nil
+}
+
+// FromCompressed constructs a new point given its 48 bytes compressed form in
+// the zcash format. The point is not checked to be in the correct subgroup.
+func (g *G1) FromCompressed(in []byte) (*PointG1, error) {
+	if len(in) != 48 {
+		return nil, errors.New("compressed g1 point should be 48 bytes")
+	}
+	in, infinity, sign, err := decodeCompressedFlags(in)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return g.Zero(), nil
+	}
+	x, err := fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// y^2 = x^3 + b
+	y := new(fe)
+	square(y, x)
+	mul(y, y, x)
+	add(y, y, b)
+	if !sqrt(y, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	if y.isLexicographicallyLargest() != sign {
+		neg(y, y)
+	}
+	return &PointG1{*x, *y, *new(fe).one()}, nil
+}
+
+// ToCompressed serializes a point into its 48 bytes compressed form in the
+// zcash format.
+func (g *G1) ToCompressed(p *PointG1) []byte {
+	out := make([]byte, 48)
+	if g.IsZero(p) {
+		out[0] = compressionFlag | infinityFlag
+		return out
+	}
+	q := g.Affine(new(PointG1).Set(p))
+	copy(out, toBytes(&q[0]))
+	out[0] |= compressionFlag
+	if q[1].isLexicographicallyLargest() {
+		out[0] |= signFlag
+	}
+	return out
+}
+
+// FromCompressed constructs a new point given its 96 bytes compressed form in
+// the zcash format. The point is not checked to be in the correct subgroup.
+func (g *G2) FromCompressed(in []byte) (*PointG2, error) {
+	if len(in) != 96 {
+		return nil, errors.New("compressed g2 point should be 96 bytes")
+	}
+	in, infinity, sign, err := decodeCompressedFlags(in)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return g.Zero(), nil
+	}
+	x, err := g.f.fromBytes(in)
+	if err != nil {
+		return nil, err
+	}
+	// y^2 = x^3 + b
+	y := new(fe2)
+	g.f.square(y, x)
+	g.f.mul(y, y, x)
+	g.f.add(y, y, b2)
+	if !g.f.sqrt(y, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	if y.isLexicographicallyLargest() != sign {
+		g.f.neg(y, y)
+	}
+	return &PointG2{*x, *y, *new(fe2).one()}, nil
+}
+
+// ToCompressed serializes a point into its 96 bytes compressed form in the
+// zcash format.
+func (g *G2) ToCompressed(p *PointG2) []byte {
+	out := make([]byte, 96)
+	if g.IsZero(p) {
+		out[0] = compressionFlag | infinityFlag
+		return out
+	}
+	q := g.Affine(new(PointG2).Set(p))
+	copy(out, g.f.toBytes(&q[0]))
+	out[0] |= compressionFlag
+	if q[1].isLexicographicallyLargest() {
+		out[0] |= signFlag
+	}
+	return out
+}
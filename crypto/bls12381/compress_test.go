@@ -0,0 +1,68 @@
+package bls12381
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestG1Compression(t *testing.T) {
+	g1 := NewG1()
+	for i := 0; i < fuz; i++ {
+		a := g1.rand()
+		buf := g1.ToCompressed(a)
+		b, err := g1.FromCompressed(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g1.Equal(a, b) {
+			t.Fatal("bad compression from/to")
+		}
+	}
+	zero := g1.ToCompressed(g1.Zero())
+	if !bytes.Equal(zero, append([]byte{0xc0}, make([]byte, 47)...)) {
+		t.Fatal("bad compression of point at infinity")
+	}
+	if p, err := g1.FromCompressed(zero); err != nil || !g1.IsZero(p) {
+		t.Fatal("bad decompression of point at infinity")
+	}
+	// The generator in the zcash format
+	expected := common.FromHex("97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")
+	if !bytes.Equal(g1.ToCompressed(g1.One()), expected) {
+		t.Fatal("bad compression of generator")
+	}
+	if _, err := g1.FromCompressed(g1.ToBytes(g1.One())[:48]); err == nil {
+		t.Fatal("uncompressed input must be rejected")
+	}
+}
+
+func TestG2Compression(t *testing.T) {
+	g2 := NewG2()
+	for i := 0; i < fuz; i++ {
+		a := g2.rand()
+		buf := g2.ToCompressed(a)
+		b, err := g2.FromCompressed(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g2.Equal(a, b) {
+			t.Fatal("bad compression from/to")
+		}
+	}
+	zero := g2.ToCompressed(g2.Zero())
+	if !bytes.Equal(zero, append([]byte{0xc0}, make([]byte, 95)...)) {
+		t.Fatal("bad compression of point at infinity")
+	}
+	if p, err := g2.FromCompressed(zero); err != nil || !g2.IsZero(p) {
+		t.Fatal("bad decompression of point at infinity")
+	}
+	// The generator in the zcash format
+	expected := common.FromHex("" +
+		"93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e" +
+		"024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8",
+	)
+	if !bytes.Equal(g2.ToCompressed(g2.One()), expected) {
+		t.Fatal("bad compression of generator")
+	}
+}
@@ -0,0 +1,93 @@
+package bls12381
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// expandMessageXMD implements expand_message_xmd with SHA-256 of RFC 9380,
+// section 5.3.1
+func expandMessageXMD(msg, domain []byte, length int) ([]byte, error) {
+	const hashSize, blockSize = sha256.Size, sha256.BlockSize
+	ell := (length + hashSize - 1) / hashSize
+	if ell > 255 || len(domain) > 255 || length > 65535 {
+		return nil, errors.New("invalid expand message length")
+	}
+	domainPrime := append(append([]byte{}, domain...), byte(len(domain)))
+
+	h := sha256.New()
+	h.Write(make([]byte, blockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(domainPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(domainPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*hashSize)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		xored := make([]byte, hashSize)
+		for j := range xored {
+			xored[j] = b0[j] ^ bi[j]
+		}
+		h.Reset()
+		h.Write(xored)
+		h.Write([]byte{byte(i)})
+		h.Write(domainPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
+
+// hashToFieldFp2 implements hash_to_field of RFC 9380, section 5.2, for count
+// elements of the quadratic extension field
+func hashToFieldFp2(msg, domain []byte, count int) ([]*fe2, error) {
+	// L = ceil((ceil(log2(p)) + k) / 8) = 64 for a security level k of 128
+	const size = 64
+	uniform, err := expandMessageXMD(msg, domain, count*2*size)
+	if err != nil {
+		return nil, err
+	}
+	p := modulus.big()
+	elements := make([]*fe2, count)
+	for i := range elements {
+		elements[i] = new(fe2)
+		for j := 0; j < 2; j++ {
+			offset := (i*2 + j) * size
+			e, err := fromBig(new(big.Int).Mod(new(big.Int).SetBytes(uniform[offset:offset+size]), p))
+			if err != nil {
+				return nil, err
+			}
+			elements[i][j].set(e)
+		}
+	}
+	return elements, nil
+}
+
+// HashToCurve hashes the message to a G2 point with the domain separation tag,
+// it implements the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite of RFC 9380.
+func (g *G2) HashToCurve(msg, domain []byte) (*PointG2, error) {
+	u, err := hashToFieldFp2(msg, domain, 2)
+	if err != nil {
+		return nil, err
+	}
+	// The cofactor clearing is a group homomorphism, it is applied once to
+	// the sum of the mapped points
+	points := make([]*PointG2, 2)
+	for i := range points {
+		x, y := swuMapG2(g.f, u[i])
+		isogenyMapG2(g.f, x, y)
+		points[i] = &PointG2{*x, *y, *new(fe2).one()}
+	}
+	r := g.New()
+	g.Add(r, points[0], points[1])
+	g.ClearCofactor(r)
+	return g.Affine(r), nil
+}
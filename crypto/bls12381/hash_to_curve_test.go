@@ -0,0 +1,51 @@
+package bls12381
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	// Test vectors of RFC 9380, appendix K.1
+	domain := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	for i, v := range []struct {
+		msg      string
+		length   int
+		expected string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	} {
+		out, err := expandMessageXMD([]byte(v.msg), domain, v.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, common.FromHex(v.expected)) {
+			t.Fatalf("bad expand message %d, have %x, want %s", i, out, v.expected)
+		}
+	}
+}
+
+func TestG2HashToCurve(t *testing.T) {
+	// Test vector of RFC 9380, appendix J.10.1
+	domain := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	expected := common.FromHex("" +
+		"05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d" +
+		"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a" +
+		"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6" +
+		"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+	)
+	g2 := NewG2()
+	p, err := g2.HashToCurve([]byte{}, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(g2.ToBytes(p), expected) {
+		t.Fatalf("bad hash to curve, have %x", g2.ToBytes(p))
+	}
+	if !g2.InCorrectSubgroup(p) {
+		t.Fatal("hashed point is not in the correct subgroup")
+	}
+}
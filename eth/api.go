@@ -30,6 +30,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/consortium"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -120,6 +121,19 @@ func (api *PrivateMinerAPI) SetExtra(extra string) (bool, error) {
 	return true, nil
 }
 
+// SetVanity sets the content of the 32 byte vanity prefix of the blocks sealed
+// by the consortium validator.
+func (api *PrivateMinerAPI) SetVanity(vanity string) (bool, error) {
+	c, ok := api.e.engine.(*consortium.Consortium)
+	if !ok {
+		return false, errors.New("vanity requires the consortium engine")
+	}
+	if err := c.SetVanity([]byte(vanity)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // SetGasPrice sets the minimum accepted gas price for the miner.
 func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	api.e.lock.Lock()
@@ -213,6 +227,57 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
+// validatorSettings is the RPC form of ValidatorSettings, the durations are in
+// the Go duration format, e.g. "500ms"
+type validatorSettings struct {
+	VoteAssemblyWindow     *string `json:"voteAssemblyWindow,omitempty"`
+	AllowedFutureBlockTime *string `json:"allowedFutureBlockTime,omitempty"`
+	DisableVoting          *bool   `json:"disableVoting,omitempty"`
+	DisableSealing         *bool   `json:"disableSealing,omitempty"`
+}
+
+// ValidatorSettings returns the current operational settings of the validator
+func (api *PrivateAdminAPI) ValidatorSettings() (*validatorSettings, error) {
+	settings, err := api.eth.ValidatorSettings()
+	if err != nil {
+		return nil, err
+	}
+	window, drift := settings.VoteAssemblyWindow.String(), settings.AllowedFutureBlockTime.String()
+	return &validatorSettings{
+		VoteAssemblyWindow:     &window,
+		AllowedFutureBlockTime: &drift,
+		DisableVoting:          settings.DisableVoting,
+		DisableSealing:         settings.DisableSealing,
+	}, nil
+}
+
+// SetValidatorSettings changes the operational settings of the validator without
+// restarting it, the omitted settings are left unchanged
+func (api *PrivateAdminAPI) SetValidatorSettings(args validatorSettings) (*validatorSettings, error) {
+	settings := ValidatorSettings{
+		DisableVoting:  args.DisableVoting,
+		DisableSealing: args.DisableSealing,
+	}
+	if args.VoteAssemblyWindow != nil {
+		window, err := time.ParseDuration(*args.VoteAssemblyWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vote assembly window: %v", err)
+		}
+		settings.VoteAssemblyWindow = &window
+	}
+	if args.AllowedFutureBlockTime != nil {
+		drift, err := time.ParseDuration(*args.AllowedFutureBlockTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed future block time: %v", err)
+		}
+		settings.AllowedFutureBlockTime = &drift
+	}
+	if err := api.eth.UpdateValidatorSettings(settings); err != nil {
+		return nil, err
+	}
+	return api.ValidatorSettings()
+}
+
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	// Make sure the can access the file to import
@@ -38,6 +38,7 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/monitor"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -262,6 +263,9 @@ func (b *EthAPIBackend) SubscribeDirtyAccountEvent(ch chan<- []*types.DirtyState
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if b.eth.chainFreeze != nil && b.eth.chainFreeze.Frozen() {
+		return monitor.ErrChainUnsafe
+	}
 	return b.eth.txPool.AddLocal(signedTx)
 }
 
@@ -366,6 +370,12 @@ func (b *EthAPIBackend) Engine() consensus.Engine {
 	return b.eth.engine
 }
 
+// BlockChain returns the chain of the node, it is used by the services reading
+// the consensus data which the engine derives from the chain
+func (b *EthAPIBackend) BlockChain() *core.BlockChain {
+	return b.eth.blockchain
+}
+
 func (b *EthAPIBackend) CurrentHeader() *types.Header {
 	return b.eth.blockchain.CurrentHeader()
 }
@@ -21,12 +21,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"os"
 	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/consensus/consortium"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vote"
 
@@ -49,10 +51,13 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/mmapdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/chaos"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/miner"
+	"github.com/ethereum/go-ethereum/monitor"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
@@ -99,6 +104,17 @@ type Ethereum struct {
 
 	p2pServer *p2p.Server
 
+	signingLease *vote.SigningLease // Coordinates sealing and voting with the standby nodes
+	watchdog     *resourceWatchdog  // Declines to seal while the node is unhealthy, nil if disabled
+
+	snapshotStore   *mmapdb.Database    // Store of the consortium snapshots outside of chainDb
+	snapshotArchive *v2.SnapshotArchive // Archive of the finalized consortium snapshots
+
+	chainFreeze *monitor.ChainFreeze // Refuses the RPC transactions on finality stalls, nil if disabled
+
+	votingDisabled  int32 // Skip voting, see ValidatorSettings (atomic)
+	sealingDisabled int32 // Skip sealing, see ValidatorSettings (atomic)
+
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 }
 
@@ -112,6 +128,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
+	// The lease is taken over on the inactivity alert of the validator, the
+	// standby node would never take over without it
+	if config.SigningLeaseTakeover && (!config.EnableInactivityTracker || config.InactivityThreshold == 0) {
+		return nil, errors.New("signing lease takeover requires the inactivity tracker with a non-zero threshold")
+	}
 	if config.Miner.GasPrice == nil || config.Miner.GasPrice.Cmp(common.Big0) <= 0 {
 		log.Warn("Sanitizing invalid miner gas price", "provided", config.Miner.GasPrice, "updated", ethconfig.Defaults.Miner.GasPrice)
 		config.Miner.GasPrice = new(big.Int).Set(ethconfig.Defaults.Miner.GasPrice)
@@ -136,7 +157,20 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
-	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideArrowGlacier, false)
+	var (
+		chainConfig *params.ChainConfig
+		genesisHash common.Hash
+		genesisErr  error
+	)
+	if config.ChainPreset != "" {
+		preset, err := params.LookupChainPreset(config.ChainPreset)
+		if err != nil {
+			return nil, err
+		}
+		chainConfig, genesisHash, genesisErr = core.SetupChainPreset(chainDb, preset)
+	} else {
+		chainConfig, genesisHash, genesisErr = core.SetupGenesisBlockWithOverride(chainDb, config.Genesis, config.OverrideArrowGlacier, false)
+	}
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
 		return nil, genesisErr
 	}
@@ -164,6 +198,50 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	}
 	ethAPI := ethapi.NewPublicBlockChainAPI(eth.APIBackend)
 	eth.engine = ethconfig.CreateConsensusEngine(stack, chainConfig, &ethashConfig, config.Miner.Notify, config.Miner.Noverify, chainDb, ethAPI, genesisHash)
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.MmapSnapshotStore {
+		store, err := mmapdb.New(stack.ResolvePath(v2.SnapshotStoreDir))
+		if err != nil {
+			return nil, err
+		}
+		eth.snapshotStore = store
+		c.SetSnapshotStore(store)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.SnapshotArchive {
+		archive, err := v2.OpenSnapshotArchive(stack.ResolvePath(v2.SnapshotArchiveDir))
+		if err != nil {
+			return nil, err
+		}
+		eth.snapshotArchive = archive
+		c.SetSnapshotArchive(archive)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.ConsortiumCache != (v2.CacheConfig{}) {
+		if err := c.SetCacheConfig(config.ConsortiumCache); err != nil {
+			return nil, err
+		}
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.ValidatorSetOverrideFile != "" {
+		override, err := v2.LoadValidatorSetOverride(config.ValidatorSetOverrideFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.SetValidatorSetOverride(override); err != nil {
+			return nil, err
+		}
+	}
+	// The peers are challenged with the trusted checkpoint block, the ones on
+	// another chain are dropped before the headers below it are synced unverified
+	whitelist := config.Whitelist
+	if c, ok := eth.engine.(*consortium.Consortium); ok {
+		if number, hash, ok := rawdb.ReadTrustedCheckpoint(chainDb); ok {
+			c.SetTrustedCheckpoint(number, hash)
+			whitelist = make(map[uint64]common.Hash, len(config.Whitelist)+1)
+			for number, hash := range config.Whitelist {
+				whitelist[number] = hash
+			}
+			whitelist[number] = hash
+			log.Info("Trusting the consortium checkpoint", "number", number, "hash", hash)
+		}
+	}
 
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
@@ -198,12 +276,36 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 		}
 	)
+	if config.BadBlockBundleDir != "" {
+		cacheConfig.BadBlockBundleDir = stack.ResolvePath(config.BadBlockBundleDir)
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, chainConfig, eth.engine, vmConfig, eth.shouldPreserve, &config.TxLookupLimit)
 	if err != nil {
 		return nil, err
 	}
-	if config.EnableMonitorDoubleSign {
-		go eth.blockchain.StartDoubleSignMonitor()
+	var validatorNotifier *monitor.ValidatorNotifier
+	if len(config.ValidatorNotifyWebhooks) > 0 {
+		if validatorNotifier, err = monitor.NewValidatorNotifier(config.ValidatorNotifyWebhooks, config.ValidatorNotifyEvents); err != nil {
+			return nil, err
+		}
+	}
+	notifyEquivocation := validatorNotifier != nil && validatorNotifier.Enabled(monitor.EquivocationDetectedEvent)
+	if config.EnableMonitorDoubleSign || config.EnableSlashDoubleSignReport || notifyEquivocation {
+		var onDoubleSign func(header1, header2 *types.Header)
+		if c, ok := eth.engine.(*consortium.Consortium); ok && config.EnableSlashDoubleSignReport {
+			c.EnableDoubleSignReport(config.SlashReportGasCap, config.SlashReportDryRun)
+			onDoubleSign = c.ReportDoubleSign
+		}
+		if notifyEquivocation {
+			report := onDoubleSign
+			onDoubleSign = func(header1, header2 *types.Header) {
+				validatorNotifier.NotifyEquivocation(header1, header2)
+				if report != nil {
+					report(header1, header2)
+				}
+			}
+		}
+		go eth.blockchain.StartDoubleSignMonitor(onDoubleSign)
 	}
 	if config.EnableAdditionalChainEvent {
 		eth.blockchain.EnableAdditionalChainEvent()
@@ -211,6 +313,87 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.EnableMonitorFinalityVote {
 		go eth.blockchain.StartFinalityVoteMonitor()
 	}
+	if config.FinalityStallWebhook != "" {
+		go eth.blockchain.StartFinalityStallMonitor(config.FinalityStallWebhook, config.FinalityStallThreshold)
+	}
+	if config.ChainFreezeEpochs > 0 {
+		engine, ok := eth.engine.(consensus.FastFinalityPoSA)
+		if !ok || chainConfig.Consortium == nil {
+			return nil, errors.New("chain freeze requires a consortium chain")
+		}
+		eth.chainFreeze, err = monitor.NewChainFreeze(eth.blockchain, engine, config.ChainFreezeEpochs, chainConfig.Consortium.EpochV2, config.ChainFreezeWebhook)
+		if err != nil {
+			return nil, err
+		}
+		go eth.blockchain.StartChainFreeze(eth.chainFreeze)
+	}
+	if len(config.FinalityExportURLs) > 0 {
+		go eth.blockchain.StartFinalityExporter(config.FinalityExportURLs)
+	}
+	if config.AuditLogFile != "" {
+		auditLog, err := monitor.NewAuditLog(stack.ResolvePath(config.AuditLogFile), eth.blockchain, eth.engine)
+		if err != nil {
+			return nil, err
+		}
+		go eth.blockchain.StartAuditLog(auditLog)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.EnableInactivityTracker {
+		var alertFn v2.InactivityAlertFn
+		if config.InactivityWebhook != "" || config.InactivityCommand != "" {
+			alerter, err := monitor.NewInactivityAlerter(config.InactivityWebhook, config.InactivityCommand)
+			if err != nil {
+				return nil, err
+			}
+			alertFn = func(uptime v2.ValidatorUptime) {
+				alerter.Alert(&monitor.InactivityPayload{
+					Validator:         uptime.Validator,
+					ConsecutiveMissed: uptime.ConsecutiveMissed,
+					Missed:            uptime.Missed,
+					LastMissed:        uptime.LastMissed,
+					LastSealed:        uptime.LastSealed,
+				})
+			}
+		}
+		if config.SigningLeaseFile != "" && config.SigningLeaseTakeover {
+			notify := alertFn
+			alertFn = func(uptime v2.ValidatorUptime) {
+				// The standby node tracks the same validator, it takes over the lease
+				// from the primary that stops producing blocks
+				if lease := eth.signingLease; lease != nil && !lease.Held() {
+					if err := lease.Takeover(); err != nil {
+						log.Error("Failed to take over signing lease", "err", err)
+					}
+				}
+				if notify != nil {
+					notify(uptime)
+				}
+			}
+		}
+		c.StartInactivityTracker(eth.blockchain, config.InactivityThreshold, alertFn)
+	}
+	if validatorNotifier != nil {
+		if c, ok := eth.engine.(*consortium.Consortium); ok {
+			c.SetWrapUpFailureHandler(validatorNotifier.NotifyWrapUpFailure)
+		}
+		localValidator := func() common.Address {
+			etherbase, _ := eth.Etherbase()
+			return etherbase
+		}
+		watcher, err := monitor.NewValidatorWatcher(eth.blockchain, eth.engine, validatorNotifier, localValidator, config.HardforkNotifyDistance)
+		if err != nil {
+			return nil, err
+		}
+		go eth.blockchain.StartValidatorWatcher(watcher)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.EnableValidatorScores {
+		c.StartScoreTracker(eth.blockchain, config.ValidatorScore)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.ShadowSealer != (common.Address{}) {
+		c.StartShadowSealing(eth.blockchain, config.ShadowSealer)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.SnapshotArchive {
+		c.StartSnapshotArchiving(eth.blockchain)
+	}
 
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
@@ -218,6 +401,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		eth.blockchain.SetHead(compat.RewindTo)
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	if !config.AllowJustifiedRewind {
+		eth.blockchain.EnableJustifiedProtection()
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
@@ -231,6 +417,45 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if checkpoint == nil {
 		checkpoint = params.TrustedCheckpoints[genesisHash]
 	}
+	// signGuards are the checks that must pass before sealing and voting
+	var signGuards []func() error
+	if config.SigningLeaseFile != "" {
+		holder := config.SigningLeaseHolder
+		if holder == "" {
+			if holder, err = os.Hostname(); err != nil {
+				return nil, err
+			}
+		}
+		if eth.signingLease, err = vote.NewSigningLease(stack.ResolvePath(config.SigningLeaseFile), holder, config.SigningLeaseDuration); err != nil {
+			return nil, err
+		}
+		eth.signingLease.Start()
+		signGuards = append(signGuards, eth.signingLease.CheckHeld)
+	}
+	if config.MinSigningPeers > 0 || config.MinSigningRoninPeers > 0 {
+		signGuards = append(signGuards, eth.checkSigningPeers)
+	}
+	if c, ok := eth.engine.(*consortium.Consortium); ok {
+		for _, guard := range signGuards {
+			c.AddSealGuard(guard)
+		}
+		c.AddSealGuard(eth.checkSealingEnabled)
+
+		if config.WatchdogInterval > 0 {
+			probes := []watchdogProbe{eth.stateProbe()}
+			if config.WatchdogMinPeers > 0 {
+				probes = append(probes, eth.peerCountProbe(config.WatchdogMinPeers))
+			}
+			if path := stack.ResolvePath("watchdog.probe"); path != "" && config.WatchdogDiskLatency > 0 {
+				probes = append(probes, diskLatencyProbe(path, config.WatchdogDiskLatency))
+			}
+			eth.watchdog = newResourceWatchdog(config.WatchdogInterval, probes...)
+			c.AddSealGuard(eth.watchdog.Check)
+		}
+	}
+	setFlag(&eth.votingDisabled, config.DisableVoting)
+	setFlag(&eth.sealingDisabled, config.DisableSealing)
+
 	var votePool *vote.VotePool
 	nodeConfig := stack.Config()
 	if nodeConfig.EnableFastFinality {
@@ -244,6 +469,22 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		}
 		votePool = vote.NewVotePool(eth.blockchain, finalityEngine, nodeConfig.MaxCurVoteAmountPerBlock)
 
+		var canaryAlert vote.CanaryAlertFn
+		if config.VoteCanaryWebhook != "" {
+			alerter := monitor.NewVoteCanaryAlerter(config.VoteCanaryWebhook)
+			canaryAlert = alerter.Alert
+		}
+		// The history of the signed votes is kept apart from the chain database
+		var voteProtection *vote.VoteProtection
+		if nodeConfig.EnableFastFinalitySign {
+			path := nodeConfig.BlsProtectionPath
+			if path == "" {
+				path = stack.ResolvePath(vote.DefaultProtectionFile)
+			}
+			if voteProtection, err = vote.NewVoteProtection(path); err != nil {
+				return nil, err
+			}
+		}
 		if _, err := vote.NewVoteManager(
 			eth,
 			chainDb,
@@ -253,12 +494,48 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			nodeConfig.EnableFastFinalitySign,
 			nodeConfig.BlsPasswordPath,
 			nodeConfig.BlsWalletPath,
+			nodeConfig.BlsRemoteSigner,
+			voteProtection,
+			append(append([]func() error{}, signGuards...), eth.checkVotingEnabled),
+			canaryAlert,
 			finalityEngine,
 			nil,
 		); err != nil {
 			return nil, err
 		}
 	}
+	var trustedFinality func(uint64, common.Hash)
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.ConsortiumVerify == ethconfig.ConsortiumVerifyLight {
+		c.SetLightVerification(true)
+		trustedFinality = c.SetTrustedFinality
+		log.Warn("Skipping the finality signatures below the checkpoint relayed by the trusted peers")
+	}
+	var validatorPeering *validatorPeeringConfig
+	if c, ok := eth.engine.(*consortium.Consortium); ok && config.ValidatorPeering {
+		validatorPeering = &validatorPeeringConfig{
+			Self: enode.PubkeyToIDV4(&eth.p2pServer.PrivateKey.PublicKey),
+			Sign: c.SignValidatorProof,
+			Validators: func() []common.Address {
+				head := eth.blockchain.CurrentHeader()
+				validators := c.GetActiveValidatorAt(eth.blockchain, head.Number.Uint64(), head.Hash())
+				addresses := make([]common.Address, len(validators))
+				for i, validator := range validators {
+					addresses[i] = validator.Address
+				}
+				return addresses
+			},
+			Protect: func(node *enode.Node) {
+				eth.p2pServer.AddPeer(node)
+				eth.p2pServer.AddTrustedPeer(node)
+			},
+			Unprotect: func(node *enode.Node) {
+				eth.p2pServer.RemoveTrustedPeer(node)
+				eth.p2pServer.RemovePeer(node)
+			},
+			Allowlist: config.ValidatorPeeringAllowlist,
+			Denylist:  config.ValidatorPeeringDenylist,
+		}
+	}
 	if eth.handler, err = newHandler(&handlerConfig{
 		Database:             chainDb,
 		Chain:                eth.blockchain,
@@ -268,9 +545,12 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:           uint64(cacheLimit),
 		EventMux:             eth.eventMux,
 		Checkpoint:           checkpoint,
-		Whitelist:            config.Whitelist,
+		Whitelist:            whitelist,
 		DisableRoninProtocol: config.DisableRoninProtocol,
 		VotePool:             votePool,
+		ReportBlockLatency:   config.ReportBlockLatency,
+		TrustedFinality:      trustedFinality,
+		ValidatorPeering:     validatorPeering,
 	}); err != nil {
 		return nil, err
 	}
@@ -279,6 +559,11 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if chainConfig.Consortium != nil {
 		c := eth.engine.(*consortium.Consortium)
 		stack.RegisterAPIs(c.APIs(eth.blockchain))
+		c.SetAllowedFutureBlockTime(config.AllowedFutureBlockTime)
+		c.SetSealBacklog(config.SealBacklogThreshold, func() int {
+			pending, _ := eth.txPool.Stats()
+			return pending
+		})
 		c.SetGetSCValidatorsFn(func() ([]common.Address, error) {
 			stateDb, err := eth.blockchain.State()
 			if err != nil {
@@ -297,6 +582,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		})
 		if votePool != nil {
 			c.SetVotePool(votePool)
+			c.SetVoteAssemblyWindow(nodeConfig.VoteAssemblyWindow)
 		}
 	}
 	// The first thing the node will do is reconstruct the verification data for
@@ -376,6 +662,63 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	if s.blockchain.Config().Consortium != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   NewPublicHealthAPI(s),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   NewPublicBlockLatencyAPI(s),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   NewPublicEpochGasAPI(s),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   NewPublicHardforkScheduleAPI(s),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicSystemTransactionAPI(s),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicTransactionConfirmationAPI(s),
+			Public:    true,
+		})
+	}
+	if chaos.Enabled {
+		apis = append(apis, rpc.API{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   chaos.NewPrivateChaosAPI(s.blockchain.Config()),
+		})
+	}
+	if s.handler.votePool != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   vote.NewPublicVotePoolAPI(s.handler.votePool),
+			Public:    true,
+		}, rpc.API{
+			Namespace: "consortium",
+			Version:   "1.0",
+			Service:   vote.NewPrivateVotePoolAPI(s.handler.votePool),
+		}, rpc.API{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   vote.NewPrivateVotePoolAdminAPI(s.handler.votePool),
+		})
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -470,6 +813,11 @@ func (s *Ethereum) isLocalBlock(block *types.Block) bool {
 	if author == etherbase {
 		return true
 	}
+	for _, signer := range s.config.Miner.ExtraSigners {
+		if author == signer {
+			return true
+		}
+	}
 	// Check whether the given address is specified by `txpool.local`
 	// CLI flag.
 	for _, account := range s.config.TxPool.Locals {
@@ -562,6 +910,15 @@ func (s *Ethereum) StartMining(threads int) error {
 				return fmt.Errorf("signer missing: %v", err)
 			}
 			consortium.Authorize(eb, wallet.SignData, wallet.SignTx)
+
+			for _, signer := range s.config.Miner.ExtraSigners {
+				wallet, err := s.accountManager.Find(accounts.Account{Address: signer})
+				if wallet == nil || err != nil {
+					log.Error("Extra signer account unavailable locally", "signer", signer, "err", err)
+					return fmt.Errorf("extra signer missing: %v", err)
+				}
+				consortium.Authorize(signer, wallet.SignData, wallet.SignTx)
+			}
 		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
@@ -586,6 +943,21 @@ func (s *Ethereum) StopMining() {
 	s.miner.Stop()
 }
 
+// checkSigningPeers returns an error if the node is not connected to enough peers
+// to seal or vote, so that it does not sign on an isolated partition
+func (s *Ethereum) checkSigningPeers() error {
+	if s.handler == nil {
+		return errors.New("p2p handler is not initialized")
+	}
+	if peers := s.handler.peers.len(); peers < s.config.MinSigningPeers {
+		return fmt.Errorf("not enough peers to sign, have %d, want %d", peers, s.config.MinSigningPeers)
+	}
+	if peers := s.handler.peers.roninLen(); peers < s.config.MinSigningRoninPeers {
+		return fmt.Errorf("not enough ronin peers to sign, have %d, want %d", peers, s.config.MinSigningRoninPeers)
+	}
+	return nil
+}
+
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
 
@@ -634,6 +1006,11 @@ func (s *Ethereum) Start() error {
 	}
 	// Start the networking layer and the light server if requested
 	s.handler.Start(maxPeers)
+
+	// The watchdog probes the peers of the handler
+	if s.watchdog != nil {
+		s.watchdog.Start()
+	}
 	return nil
 }
 
@@ -650,8 +1027,22 @@ func (s *Ethereum) Stop() error {
 	close(s.closeBloomHandler)
 	s.txPool.Stop()
 	s.miner.Close()
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+	if s.signingLease != nil {
+		s.signingLease.Stop()
+	}
 	s.blockchain.Stop()
+	// The engine waits for its background loops, which use the snapshot store
+	// and archive, to exit
 	s.engine.Close()
+	if s.snapshotStore != nil {
+		s.snapshotStore.Close()
+	}
+	if s.snapshotArchive != nil {
+		s.snapshotArchive.Close()
+	}
 	rawdb.PopUncleanShutdownMarker(s.chainDb)
 	s.chainDb.Close()
 	s.eventMux.Stop()
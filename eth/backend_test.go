@@ -0,0 +1,20 @@
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+)
+
+func TestSigningLeaseTakeoverConfig(t *testing.T) {
+	for _, config := range []*ethconfig.Config{
+		{SyncMode: downloader.FullSync, SigningLeaseTakeover: true, InactivityThreshold: 5},
+		{SyncMode: downloader.FullSync, SigningLeaseTakeover: true, EnableInactivityTracker: true},
+	} {
+		// The config is refused before the node is used
+		if _, err := New(nil, config); err == nil {
+			t.Fatalf("Expect error on takeover without the inactivity alert, config %+v", config)
+		}
+	}
+}
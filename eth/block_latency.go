@@ -0,0 +1,194 @@
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// blockLatencySeenLimit is the number of recent block hashes kept to only
+	// measure the first arrival of a block
+	blockLatencySeenLimit = 1024
+
+	// blockLatencyReportInterval is how often the local observations are sent
+	// to the `ronin` peers
+	blockLatencyReportInterval = 30 * time.Second
+
+	// blockLatencyMax is the maximum latency recorded, a larger latency is most
+	// likely a block received while syncing
+	blockLatencyMax = time.Minute
+
+	// blockLatencyWeight is the weight of a new sample in the moving average of
+	// the latency
+	blockLatencyWeight = 0.2
+
+	// localObserver is the observer name of the local node in the latency map
+	localObserver = "local"
+)
+
+// BlockLatencyStats is the propagation latency of the blocks of a proposer as
+// observed by a node
+type BlockLatencyStats struct {
+	Samples   uint64 `json:"samples"`
+	Average   uint64 `json:"averageMs"` // Moving average in milliseconds
+	Max       uint64 `json:"maxMs"`
+	LastBlock uint64 `json:"lastBlock"`
+}
+
+func (stats *BlockLatencyStats) update(number uint64, latency uint64) {
+	if stats.Samples == 0 {
+		stats.Average = latency
+	} else {
+		stats.Average = uint64(blockLatencyWeight*float64(latency) + (1-blockLatencyWeight)*float64(stats.Average))
+	}
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+	if number > stats.LastBlock {
+		stats.LastBlock = number
+	}
+	stats.Samples++
+}
+
+// blockLatencyTracker measures the delay after the header time at which the
+// broadcast blocks are first received, and aggregates it with the latencies
+// reported by the `ronin` peers into a map of the latency of each proposer as
+// seen by each observer.
+type blockLatencyTracker struct {
+	lock    sync.Mutex
+	seen    *lru.Cache
+	pending []ronin.BlockLatency                             // Local observations not reported yet
+	stats   map[common.Address]map[string]*BlockLatencyStats // proposer -> observer -> stats
+}
+
+func newBlockLatencyTracker() *blockLatencyTracker {
+	seen, _ := lru.New(blockLatencySeenLimit)
+	return &blockLatencyTracker{
+		seen:  seen,
+		stats: make(map[common.Address]map[string]*BlockLatencyStats),
+	}
+}
+
+// record adds the latency of the proposer's block as seen by the observer, the
+// caller must hold the lock
+func (tracker *blockLatencyTracker) record(proposer common.Address, observer string, number uint64, latency uint64) {
+	observers, ok := tracker.stats[proposer]
+	if !ok {
+		observers = make(map[string]*BlockLatencyStats)
+		tracker.stats[proposer] = observers
+	}
+	stats, ok := observers[observer]
+	if !ok {
+		stats = &BlockLatencyStats{}
+		observers[observer] = stats
+	}
+	stats.update(number, latency)
+}
+
+// markBlock records the arrival of the broadcast block, only the first arrival
+// of a block is measured
+func (tracker *blockLatencyTracker) markBlock(header *types.Header, now time.Time) {
+	latency := now.Sub(time.Unix(int64(header.Time), 0))
+	if latency < 0 {
+		latency = 0
+	}
+	if latency > blockLatencyMax {
+		return
+	}
+	hash := header.Hash()
+	if ok, _ := tracker.seen.ContainsOrAdd(hash, struct{}{}); ok {
+		return
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	observation := ronin.BlockLatency{
+		Number:  header.Number.Uint64(),
+		Hash:    hash,
+		Latency: uint64(latency.Milliseconds()),
+	}
+	tracker.record(header.Coinbase, localObserver, observation.Number, observation.Latency)
+	tracker.pending = append(tracker.pending, observation)
+	if len(tracker.pending) > ronin.MaxBlockLatencies {
+		tracker.pending = tracker.pending[len(tracker.pending)-ronin.MaxBlockLatencies:]
+	}
+}
+
+// markReport records the latencies reported by the peer. The proposers are
+// resolved from the local chain, so the blocks not known locally are skipped.
+func (tracker *blockLatencyTracker) markReport(peer string, latencies []ronin.BlockLatency, getHeader func(common.Hash) *types.Header) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	for _, latency := range latencies {
+		if latency.Latency > uint64(blockLatencyMax.Milliseconds()) {
+			continue
+		}
+		header := getHeader(latency.Hash)
+		if header == nil || header.Number.Uint64() != latency.Number {
+			continue
+		}
+		tracker.record(header.Coinbase, peer, latency.Number, latency.Latency)
+	}
+}
+
+// flush returns the local observations not reported yet
+func (tracker *blockLatencyTracker) flush() []ronin.BlockLatency {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	pending := tracker.pending
+	tracker.pending = nil
+	return pending
+}
+
+// removePeer drops the latencies reported by the disconnected peer
+func (tracker *blockLatencyTracker) removePeer(peer string) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	for proposer, observers := range tracker.stats {
+		delete(observers, peer)
+		if len(observers) == 0 {
+			delete(tracker.stats, proposer)
+		}
+	}
+}
+
+// latencyMap returns a copy of the latency map
+func (tracker *blockLatencyTracker) latencyMap() map[common.Address]map[string]BlockLatencyStats {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	latencies := make(map[common.Address]map[string]BlockLatencyStats, len(tracker.stats))
+	for proposer, observers := range tracker.stats {
+		latencies[proposer] = make(map[string]BlockLatencyStats, len(observers))
+		for observer, stats := range observers {
+			latencies[proposer][observer] = *stats
+		}
+	}
+	return latencies
+}
+
+// PublicBlockLatencyAPI provides the propagation latency of the blocks across
+// the network
+type PublicBlockLatencyAPI struct {
+	e *Ethereum
+}
+
+func NewPublicBlockLatencyAPI(e *Ethereum) *PublicBlockLatencyAPI {
+	return &PublicBlockLatencyAPI{e}
+}
+
+// GetBlockLatencyMap returns the block propagation latency of each proposer as
+// observed by the local node and by each `ronin` peer reporting its latencies,
+// the peers are keyed by their node id.
+func (api *PublicBlockLatencyAPI) GetBlockLatencyMap() map[common.Address]map[string]BlockLatencyStats {
+	return api.e.handler.blockLatency.latencyMap()
+}
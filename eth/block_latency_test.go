@@ -0,0 +1,59 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+)
+
+func TestBlockLatencyTracker(t *testing.T) {
+	var (
+		proposer = common.HexToAddress("0x1")
+		header   = &types.Header{Number: big.NewInt(10), Time: 1000, Coinbase: proposer}
+		arrival  = time.Unix(1000, int64(300*time.Millisecond))
+		tracker  = newBlockLatencyTracker()
+	)
+	tracker.markBlock(header, arrival)
+	// Only the first arrival is measured
+	tracker.markBlock(header, arrival.Add(time.Second))
+
+	latencies := tracker.flush()
+	if len(latencies) != 1 || latencies[0].Hash != header.Hash() || latencies[0].Latency != 300 {
+		t.Fatalf("Unexpected local observations %v", latencies)
+	}
+	if len(tracker.flush()) != 0 {
+		t.Fatalf("Expect no observation after flush")
+	}
+
+	getHeader := func(hash common.Hash) *types.Header {
+		if hash == header.Hash() {
+			return header
+		}
+		return nil
+	}
+	tracker.markReport("peer", []ronin.BlockLatency{
+		{Number: 10, Hash: header.Hash(), Latency: 500},
+		{Number: 11, Hash: common.Hash{0x1}, Latency: 100}, // Unknown block
+		{Number: 11, Hash: header.Hash(), Latency: 100},    // Number mismatch
+	}, getHeader)
+
+	latencyMap := tracker.latencyMap()
+	if len(latencyMap) != 1 || len(latencyMap[proposer]) != 2 {
+		t.Fatalf("Unexpected latency map %v", latencyMap)
+	}
+	if stats := latencyMap[proposer][localObserver]; stats.Samples != 1 || stats.Average != 300 || stats.LastBlock != 10 {
+		t.Fatalf("Unexpected local stats %+v", stats)
+	}
+	if stats := latencyMap[proposer]["peer"]; stats.Samples != 1 || stats.Average != 500 || stats.Max != 500 {
+		t.Fatalf("Unexpected peer stats %+v", stats)
+	}
+
+	tracker.removePeer("peer")
+	if _, ok := tracker.latencyMap()[proposer]["peer"]; ok {
+		t.Fatalf("Expect the removed peer's latencies to be dropped")
+	}
+}
@@ -0,0 +1,214 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// defaultWitnessReexec is the number of blocks re-executed to regenerate the
+// state before the block of a witness if it is not available
+const defaultWitnessReexec = uint64(128)
+
+// BlockWitness is the execution witness of a block: the accounts, storage slots
+// and codes accessed by the execution of the block, including the system
+// transactions and the rewards, together with the trie nodes proving them
+// against the state root of the parent block. A stateless verifier can execute
+// the block from the witness alone and check the resulting state root.
+type BlockWitness struct {
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	ParentRoot  common.Hash    `json:"parentRoot"`
+
+	Accounts []common.Address                 `json:"accounts"`
+	Storage  map[common.Address][]common.Hash `json:"storage"`
+	Codes    []hexutil.Bytes                  `json:"codes"`
+	State    []hexutil.Bytes                  `json:"state"` // Trie nodes of the account and storage proofs
+
+	// Complete is false if the execution iterated or replaced the whole storage
+	// of an account, the accessed slots are then not known
+	Complete bool `json:"complete"`
+}
+
+// newBlockWitness executes the block on statedb, the state of its parent, and
+// returns the witness of the accessed state. The statedb is changed by the
+// execution.
+func newBlockWitness(chain *core.BlockChain, block *types.Block, statedb *state.StateDB) (*BlockWitness, error) {
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	var (
+		pristine  = statedb.Copy()
+		access    = state.NewAccessSet()
+		processor = core.NewStateProcessor(chain.Config(), chain, chain.Engine())
+	)
+	statedb.SetAccessSet(access)
+	_, _, _, _, err := processor.Process(block, statedb, vm.Config{})
+	statedb.SetAccessSet(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute block %d: %v", block.NumberU64(), err)
+	}
+
+	witness := &BlockWitness{
+		BlockHash:   block.Hash(),
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		ParentRoot:  parent.Root,
+		Storage:     make(map[common.Address][]common.Hash),
+		Complete:    !access.Opaque,
+	}
+	var (
+		accounts = make(map[common.Address]struct{})
+		slots    = make(map[common.Address]map[common.Hash]struct{})
+	)
+	for _, keys := range []map[state.AccessKey]struct{}{access.Reads, access.Writes} {
+		for key := range keys {
+			accounts[key.Address] = struct{}{}
+			if key.Kind == state.AccessStorage {
+				if slots[key.Address] == nil {
+					slots[key.Address] = make(map[common.Hash]struct{})
+				}
+				slots[key.Address][key.Slot] = struct{}{}
+			}
+		}
+	}
+	for addr := range access.Deltas {
+		accounts[addr] = struct{}{}
+	}
+
+	var (
+		nodes = make(map[common.Hash][]byte)
+		codes = make(map[common.Hash][]byte)
+	)
+	addProof := func(proof [][]byte) {
+		for _, node := range proof {
+			nodes[crypto.Keccak256Hash(node)] = node
+		}
+	}
+	for addr := range accounts {
+		witness.Accounts = append(witness.Accounts, addr)
+		proof, err := pristine.GetProof(addr)
+		if err != nil {
+			return nil, err
+		}
+		addProof(proof)
+		if code := pristine.GetCode(addr); len(code) > 0 {
+			codes[crypto.Keccak256Hash(code)] = code
+		}
+		// The absent accounts are proven by the account proof alone
+		if !pristine.Exist(addr) {
+			continue
+		}
+		for slot := range slots[addr] {
+			witness.Storage[addr] = append(witness.Storage[addr], slot)
+			proof, err := pristine.GetStorageProof(addr, slot)
+			if err != nil {
+				return nil, err
+			}
+			addProof(proof)
+		}
+		sort.Slice(witness.Storage[addr], func(i, j int) bool {
+			return bytes.Compare(witness.Storage[addr][i][:], witness.Storage[addr][j][:]) < 0
+		})
+	}
+	sort.Slice(witness.Accounts, func(i, j int) bool {
+		return bytes.Compare(witness.Accounts[i][:], witness.Accounts[j][:]) < 0
+	})
+	witness.State = sortedBlobs(nodes)
+	witness.Codes = sortedBlobs(codes)
+	return witness, nil
+}
+
+// sortedBlobs returns the blobs sorted by hash so the witness is deterministic
+func sortedBlobs(blobs map[common.Hash][]byte) []hexutil.Bytes {
+	hashes := make([]common.Hash, 0, len(blobs))
+	for hash := range blobs {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	sorted := make([]hexutil.Bytes, len(hashes))
+	for i, hash := range hashes {
+		sorted[i] = blobs[hash]
+	}
+	return sorted
+}
+
+// blockWitness returns the witness of the block, the state before the block is
+// regenerated if needed
+func (eth *Ethereum) blockWitness(ctx context.Context, block *types.Block) (*BlockWitness, error) {
+	if block.NumberU64() == 0 {
+		return nil, errors.New("genesis block has no witness")
+	}
+	parent := eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, release, err := eth.StateAtBlock(ctx, parent, defaultWitnessReexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return newBlockWitness(eth.blockchain, block, statedb)
+}
+
+// ExecutionWitness returns the execution witness of the block, so it can be
+// verified without the full state.
+func (api *PrivateDebugAPI) ExecutionWitness(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*BlockWitness, error) {
+	block, err := api.eth.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	return api.eth.blockWitness(ctx, block)
+}
+
+// ExecutionWitnesses sends the execution witness of every block added to the
+// canonical chain.
+func (api *PrivateDebugAPI) ExecutionWitnesses(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.ChainEvent, 16)
+		sub := api.eth.blockchain.SubscribeChainEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				witness, err := api.eth.blockWitness(context.Background(), ev.Block)
+				if err != nil {
+					log.Warn("Failed to generate the block witness", "number", ev.Block.Number(), "hash", ev.Hash, "err", err)
+					continue
+				}
+				notifier.Notify(rpcSub.ID, witness)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestBlockWitness(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		config   = params.TestChainConfig
+		engine   = ethash.NewFaker()
+		genesis  = &core.Genesis{
+			Config:  config,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: core.GenesisAlloc{
+				sender: {Balance: big.NewInt(params.Ether)},
+				// SLOAD(1) SSTORE(0), slot 1 is set
+				contract: {
+					Balance: new(big.Int),
+					Code:    common.FromHex("0x60015460005500"),
+					Storage: map[common.Hash]common.Hash{common.HexToHash("0x1"): common.HexToHash("0x2a")},
+				},
+			},
+		}
+	)
+	db := rawdb.NewMemoryDatabase()
+	genesisBlock := genesis.MustCommit(db)
+	blocks, _ := core.GenerateChain(config, genesisBlock, engine, db, 1, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(0, contract, nil, 100000, gen.BaseFee(), nil), types.LatestSigner(config), key)
+		gen.AddTx(tx)
+	}, true)
+	chain, err := core.NewBlockChain(db, nil, config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	statedb, err := chain.StateAt(genesisBlock.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	witness, err := newBlockWitness(chain, blocks[0], statedb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if witness.ParentRoot != genesisBlock.Root() || witness.BlockHash != blocks[0].Hash() || !witness.Complete {
+		t.Fatalf("witness header mismatch: root %x hash %x complete %v", witness.ParentRoot, witness.BlockHash, witness.Complete)
+	}
+	if len(witness.Codes) != 1 || crypto.Keccak256Hash(witness.Codes[0]) != crypto.Keccak256Hash(genesis.Alloc[contract].Code) {
+		t.Fatalf("codes mismatch: have %x", witness.Codes)
+	}
+	if slots := witness.Storage[contract]; len(slots) != 2 || slots[0] != common.HexToHash("0x0") || slots[1] != common.HexToHash("0x1") {
+		t.Fatalf("storage mismatch: have %x", slots)
+	}
+
+	// Every accessed account and slot must be proven by the witness alone
+	nodes := memorydb.New()
+	for _, node := range witness.State {
+		nodes.Put(crypto.Keccak256(node), node)
+	}
+	found := make(map[common.Address]bool)
+	for _, addr := range witness.Accounts {
+		found[addr] = true
+		blob, err := trie.VerifyProof(witness.ParentRoot, crypto.Keccak256(addr[:]), nodes)
+		if err != nil {
+			t.Fatalf("account %x not proven: %v", addr, err)
+		}
+		if blob == nil {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			t.Fatal(err)
+		}
+		for _, slot := range witness.Storage[addr] {
+			if _, err := trie.VerifyProof(account.Root, crypto.Keccak256(slot[:]), nodes); err != nil {
+				t.Fatalf("slot %x of %x not proven: %v", slot, addr, err)
+			}
+		}
+	}
+	for _, addr := range []common.Address{sender, contract, blocks[0].Coinbase()} {
+		if !found[addr] {
+			t.Errorf("account %x missing from the witness", addr)
+		}
+	}
+}
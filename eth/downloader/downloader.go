@@ -803,7 +803,20 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 		}
 	}
 
-	ancestor, err := d.findAncestorSpanSearch(p, mode, remoteHeight, localHeight, floor)
+	// The finalized block of the peer is never reorged. If the local chain is
+	// behind it, the remote headers above the local head are final and the common
+	// ancestor is searched below the local head instead of the remote one. If the
+	// local chain contains it, the common ancestor is not below it.
+	searchHeight := remoteHeight
+	if number, hash := p.finalized(); number > localHeight && number <= remoteHeight {
+		p.log.Debug("Searching common ancestor below local head", "finalized", number, "local", localHeight)
+		searchHeight = localHeight + 1
+	} else if number > 0 && int64(number) > floor && d.hasBlock(mode, hash, number) {
+		p.log.Debug("Raising common ancestor floor to peer finality", "number", number, "hash", hash)
+		floor = int64(number) - 1
+	}
+
+	ancestor, err := d.findAncestorSpanSearch(p, mode, searchHeight, localHeight, floor)
 	if err == nil {
 		return ancestor, nil
 	}
@@ -815,13 +828,25 @@ func (d *Downloader) findAncestor(p *peerConnection, remoteHeader *types.Header)
 		return 0, err
 	}
 
-	ancestor, err = d.findAncestorBinarySearch(p, mode, remoteHeight, floor)
+	ancestor, err = d.findAncestorBinarySearch(p, mode, searchHeight, floor)
 	if err != nil {
 		return 0, err
 	}
 	return ancestor, nil
 }
 
+// hasBlock checks if the block is present in the local chain of the sync mode
+func (d *Downloader) hasBlock(mode SyncMode, hash common.Hash, number uint64) bool {
+	switch mode {
+	case FullSync:
+		return d.blockchain.HasBlock(hash, number)
+	case FastSync:
+		return d.blockchain.HasFastBlock(hash, number)
+	default:
+		return d.lightchain.HasHeader(hash, number)
+	}
+}
+
 func (d *Downloader) findAncestorSpanSearch(p *peerConnection, mode SyncMode, remoteHeight, localHeight uint64, floor int64) (commonAncestor uint64, err error) {
 	from, count, skip, max := calculateRequestSpan(remoteHeight, localHeight)
 
@@ -1017,8 +1042,16 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64) error {
 		timeout.Reset(ttl)
 
 		if skeleton {
-			p.log.Trace("Fetching skeleton headers", "count", MaxHeaderFetch, "from", from)
-			go p.peer.RequestHeadersByNumber(from+uint64(MaxHeaderFetch)-1, MaxSkeletonSize, MaxHeaderFetch-1, false)
+			// End the skeleton at the finalized block of the origin if it is closer,
+			// the origin can't reorg the headers filled in by the other peers then
+			size := MaxSkeletonSize
+			if number, _ := p.finalized(); number > from {
+				if limit := int((number - from + 1) / uint64(MaxHeaderFetch)); limit > 0 && limit < size {
+					size = limit
+				}
+			}
+			p.log.Trace("Fetching skeleton headers", "count", MaxHeaderFetch, "from", from, "size", size)
+			go p.peer.RequestHeadersByNumber(from+uint64(MaxHeaderFetch)-1, size, MaxHeaderFetch-1, false)
 		} else {
 			p.log.Trace("Fetching full headers", "count", MaxHeaderFetch, "from", from)
 			go p.peer.RequestHeadersByNumber(from, MaxHeaderFetch, 0, false)
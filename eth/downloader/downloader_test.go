@@ -401,6 +401,21 @@ func (dl *downloadTester) newPeer(id string, version uint, chain *testChain) err
 	return dl.downloader.RegisterPeer(id, version, peer)
 }
 
+// newFinalityPeer registers a new download peer advertising the block at number
+// of its chain as its latest finalized block.
+func (dl *downloadTester) newFinalityPeer(id string, version uint, chain *testChain, number int) (*finalityTesterPeer, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	peer := &finalityTesterPeer{
+		downloadTesterPeer: &downloadTesterPeer{dl: dl, id: id, chain: chain},
+		number:             uint64(number),
+		hash:               chain.chain[number],
+	}
+	dl.peers[id] = peer.downloadTesterPeer
+	return peer, dl.downloader.RegisterPeer(id, version, peer)
+}
+
 // dropPeer simulates a hard peer removal from the connection pool.
 func (dl *downloadTester) dropPeer(id string) {
 	dl.lock.Lock()
@@ -484,6 +499,27 @@ func (dlp *downloadTesterPeer) RequestNodeData(hashes []common.Hash) error {
 	return nil
 }
 
+// finalityTesterPeer is a download tester peer advertising its latest finalized
+// block, it counts the single header requests of the binary ancestor search.
+type finalityTesterPeer struct {
+	*downloadTesterPeer
+	number uint64
+	hash   common.Hash
+
+	singleRequests int32
+}
+
+func (dlp *finalityTesterPeer) Finalized() (uint64, common.Hash) {
+	return dlp.number, dlp.hash
+}
+
+func (dlp *finalityTesterPeer) RequestHeadersByNumber(origin uint64, amount int, skip int, reverse bool) error {
+	if amount == 1 {
+		atomic.AddInt32(&dlp.singleRequests, 1)
+	}
+	return dlp.downloadTesterPeer.RequestHeadersByNumber(origin, amount, skip, reverse)
+}
+
 // assertOwnChain checks if the local chain contains the correct number of items
 // of the various chain components.
 func assertOwnChain(t *testing.T, tester *downloadTester, length int) {
@@ -1511,6 +1547,73 @@ func (ftp *floodingTestPeer) RequestHeadersByNumber(from uint64, count, skip int
 	return nil
 }
 
+// Tests that a deep resync from a peer whose finalized block is above the local
+// head finds the common ancestor at the local head without a binary search.
+func TestFinalityHintedResync66Full(t *testing.T)  { testFinalityHintedResync(t, eth.ETH66, FullSync) }
+func TestFinalityHintedResync66Fast(t *testing.T)  { testFinalityHintedResync(t, eth.ETH66, FastSync) }
+func TestFinalityHintedResync66Light(t *testing.T) { testFinalityHintedResync(t, eth.ETH66, LightSync) }
+
+func testFinalityHintedResync(t *testing.T, protocol uint, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase
+	tester.newPeer("short", protocol, chain.shorten(500))
+	if err := tester.sync("short", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	peer, _ := tester.newFinalityPeer("finalized", protocol, chain, chain.len()-10)
+	if err := tester.sync("finalized", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+	if requests := atomic.LoadInt32(&peer.singleRequests); requests != 0 {
+		t.Fatalf("binary ancestor search requests mismatch: have %d, want 0", requests)
+	}
+}
+
+// Tests that the common ancestor with a peer is not below its finalized block if
+// the local chain contains it, a fork below it is rejected.
+func TestFinalityHintedForkedSync66Full(t *testing.T) {
+	testFinalityHintedForkedSync(t, eth.ETH66, FullSync)
+}
+func TestFinalityHintedForkedSync66Fast(t *testing.T) {
+	testFinalityHintedForkedSync(t, eth.ETH66, FastSync)
+}
+func TestFinalityHintedForkedSync66Light(t *testing.T) {
+	testFinalityHintedForkedSync(t, eth.ETH66, LightSync)
+}
+
+func testFinalityHintedForkedSync(t *testing.T, protocol uint, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chainA := testChainForkLightA.shorten(testChainBase.len() + 80)
+	chainB := testChainForkLightB.shorten(testChainBase.len() + 80)
+	tester.newPeer("fork A", protocol, chainA)
+	if err := tester.sync("fork A", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chainA.len())
+
+	// The fork B peer advertising a block of the fork A as finalized is rejected
+	liar, _ := tester.newFinalityPeer("fork B liar", protocol, chainB, testChainBase.len())
+	liar.number, liar.hash = uint64(testChainBase.len()+10), chainA.chain[testChainBase.len()+10]
+	if err := tester.sync("fork B liar", nil, mode); err != errInvalidAncestor {
+		t.Fatalf("sync failure mismatch: have %v, want %v", err, errInvalidAncestor)
+	}
+	// The fork B peer advertising the last common block as finalized is synced
+	tester.newFinalityPeer("fork B", protocol, chainB, testChainBase.len()-1)
+	if err := tester.sync("fork B", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnForkedChain(t, tester, testChainBase.len(), []int{chainA.len(), chainB.len()})
+}
+
 func TestRemoteHeaderRequestSpan(t *testing.T) {
 	testCases := []struct {
 		remoteHeight uint64
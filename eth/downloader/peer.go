@@ -83,6 +83,12 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// finalityPeer is a peer advertising its latest finalized block, which is never
+// reorged out of its chain.
+type finalityPeer interface {
+	Finalized() (uint64, common.Hash)
+}
+
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
 type lightPeerWrapper struct {
 	peer LightPeer
@@ -116,6 +122,15 @@ func newPeerConnection(id string, version uint, peer Peer, logger log.Logger) *p
 	}
 }
 
+// finalized returns the latest finalized block advertised by the peer, it is 0
+// if the peer does not advertise one.
+func (p *peerConnection) finalized() (uint64, common.Hash) {
+	if peer, ok := p.peer.(finalityPeer); ok {
+		return peer.Finalized()
+	}
+	return 0, common.Hash{}
+}
+
 // Reset clears the internal state of a peer entity.
 func (p *peerConnection) Reset() {
 	p.lock.Lock()
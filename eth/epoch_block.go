@@ -0,0 +1,167 @@
+package eth
+
+import (
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	// preverifiedHeaderLimit is the number of epoch headers whose pre-verification
+	// is kept until the block body arrives
+	preverifiedHeaderLimit = 64
+
+	// maxPreverifications is the maximum number of the epoch headers verified in
+	// the background at once
+	maxPreverifications = 4
+
+	// maxPreverificationsPerPeer is the maximum number of the epoch headers of a
+	// peer verified in the background at once
+	maxPreverificationsPerPeer = 1
+)
+
+var (
+	epochBlockPriorityMeter   = metrics.NewRegisteredMeter("eth/block/epoch/priority", nil)
+	epochHeaderPreverifyMeter = metrics.NewRegisteredMeter("eth/block/epoch/preverify", nil)
+	epochHeaderPreverifyHit   = metrics.NewRegisteredMeter("eth/block/epoch/preverify/hit", nil)
+)
+
+// isEpochBlock returns whether the block at number is a consortium v2 epoch
+// block, which carries the checkpoint validators in its extra-data and is the
+// most expensive to verify
+func isEpochBlock(config *params.ChainConfig, number *big.Int) bool {
+	if config.Consortium == nil || config.Consortium.EpochV2 == 0 || !config.IsConsortiumV2(number) {
+		return false
+	}
+	return number.Uint64()%config.Consortium.EpochV2 == 0
+}
+
+// epochBlockTargets returns the peers the epoch block is pushed to: all the
+// validator peers first, so the next proposer builds on it as soon as possible,
+// then the square root of the other peers as for any other block
+func epochBlockTargets(peers []*ethPeer, fanout *voteFanout, now time.Time) []*ethPeer {
+	var validators, others []*ethPeer
+	for _, peer := range peers {
+		if fanout.isValidator(peer.ID(), now) {
+			validators = append(validators, peer)
+		} else {
+			others = append(others, peer)
+		}
+	}
+	epochBlockPriorityMeter.Mark(int64(len(validators)))
+	return append(validators, others[:int(math.Sqrt(float64(len(others))))]...)
+}
+
+// preverifiedHeader is the result of an epoch header verification started
+// while its body is downloaded
+type preverifiedHeader struct {
+	done chan struct{}
+	err  error
+}
+
+// headerPreverifier verifies the announced epoch headers in the background as
+// soon as they are fetched, so that the block fetcher only waits for the body
+// and not for the extra-data verification once the block is complete. The
+// verifications are bounded globally and per peer, the headers over the limits
+// are only verified once the block is complete.
+type headerPreverifier struct {
+	verify  func(header *types.Header) error
+	results *lru.Cache    // Pre-verification results by header hash
+	slots   chan struct{} // Semaphore bounding the running verifications
+
+	lock  sync.Mutex
+	peers map[string]int // Number of running verifications by peer
+}
+
+func newHeaderPreverifier(verify func(header *types.Header) error) *headerPreverifier {
+	results, _ := lru.New(preverifiedHeaderLimit)
+	return &headerPreverifier{
+		verify:  verify,
+		results: results,
+		slots:   make(chan struct{}, maxPreverifications),
+		peers:   make(map[string]int),
+	}
+}
+
+// preverify starts the verification of the header fetched from the peer in the
+// background unless it is already started or the limits are reached
+func (p *headerPreverifier) preverify(peer string, header *types.Header) {
+	if p.results.Contains(header.Hash()) || !p.acquire(peer) {
+		return
+	}
+	result := &preverifiedHeader{done: make(chan struct{})}
+	if ok, _ := p.results.ContainsOrAdd(header.Hash(), result); ok {
+		p.release(peer)
+		return
+	}
+	epochHeaderPreverifyMeter.Mark(1)
+	go func() {
+		defer p.release(peer)
+		result.err = p.verify(header)
+		close(result.done)
+	}()
+}
+
+// acquire takes a verification slot for the peer, it returns false if the peer
+// or all the slots are busy
+func (p *headerPreverifier) acquire(peer string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.peers[peer] >= maxPreverificationsPerPeer {
+		return false
+	}
+	select {
+	case p.slots <- struct{}{}:
+	default:
+		return false
+	}
+	p.peers[peer]++
+	return true
+}
+
+// release frees the verification slot taken by the peer
+func (p *headerPreverifier) release(peer string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.peers[peer]--; p.peers[peer] == 0 {
+		delete(p.peers, peer)
+	}
+	<-p.slots
+}
+
+// verifyHeader returns the result of the pre-verification of the header if it
+// succeeded, otherwise it verifies the header again. A failed pre-verification
+// is not trusted as the parent of the header may not be imported yet when it
+// started.
+func (p *headerPreverifier) verifyHeader(header *types.Header) error {
+	if cached, ok := p.results.Get(header.Hash()); ok {
+		result := cached.(*preverifiedHeader)
+		<-result.done
+		if result.err == nil {
+			epochHeaderPreverifyHit.Mark(1)
+			return nil
+		}
+	}
+	return p.verify(header)
+}
+
+// preverifyEpochHeader starts the verification of the header fetched from the
+// peer in the background if it is an epoch header whose parent is known. It must
+// only be called for the headers requested after an announcement.
+func (h *handler) preverifyEpochHeader(peer string, header *types.Header) {
+	number := header.Number.Uint64()
+	if number == 0 || !isEpochBlock(h.chain.Config(), header.Number) {
+		return
+	}
+	if h.chain.GetHeader(header.ParentHash, number-1) != nil {
+		h.preverifier.preverify(peer, header)
+	}
+}
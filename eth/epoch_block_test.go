@@ -0,0 +1,142 @@
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestIsEpochBlock(t *testing.T) {
+	config := &params.ChainConfig{
+		ConsortiumV2Block: big.NewInt(150),
+		Consortium:        &params.ConsortiumConfig{EpochV2: 200},
+	}
+	for _, test := range []struct {
+		number uint64
+		epoch  bool
+	}{{0, false}, {100, false}, {200, true}, {399, false}, {400, true}} {
+		if epoch := isEpochBlock(config, new(big.Int).SetUint64(test.number)); epoch != test.epoch {
+			t.Fatalf("Block %d: expect epoch %v, got %v", test.number, test.epoch, epoch)
+		}
+	}
+	if isEpochBlock(params.TestChainConfig, big.NewInt(200)) {
+		t.Fatalf("Expect no epoch block without consortium")
+	}
+}
+
+func TestEpochBlockTargets(t *testing.T) {
+	const peerCount = 16
+	peers := make([]*ethPeer, peerCount)
+	for i := range peers {
+		peers[i] = &ethPeer{Peer: eth.NewPeer(eth.ETH66, p2p.NewPeer(enode.ID{byte(i)}, "", nil), nil, nil)}
+		defer peers[i].Close()
+	}
+	fanout := newVoteFanout()
+	now := time.Now()
+	fanout.markVote(peers[7].ID(), common.Hash{0x1}, now)
+	fanout.markVote(peers[12].ID(), common.Hash{0x2}, now)
+
+	targets := epochBlockTargets(peers, fanout, now)
+	// The 2 validator peers and the square root of the 14 others
+	if len(targets) != 5 {
+		t.Fatalf("Expect 5 targets, got %d", len(targets))
+	}
+	if targets[0] != peers[7] || targets[1] != peers[12] {
+		t.Fatalf("Expect the validator peers first, got %s %s", targets[0].ID(), targets[1].ID())
+	}
+
+	// The validator peers expire
+	targets = epochBlockTargets(peers, fanout, now.Add(2*validatorPeerExpiry))
+	if len(targets) != 4 || targets[0] != peers[0] {
+		t.Fatalf("Expect the square root of the peers, got %d", len(targets))
+	}
+}
+
+func TestHeaderPreverifier(t *testing.T) {
+	var calls, failed int32
+	preverifier := newHeaderPreverifier(func(header *types.Header) error {
+		atomic.AddInt32(&calls, 1)
+		// The first verification of the block 400 fails as its parent is unknown
+		if header.Number.Uint64() == 400 && atomic.CompareAndSwapInt32(&failed, 0, 1) {
+			return errors.New("unknown ancestor")
+		}
+		return nil
+	})
+
+	header := &types.Header{Number: big.NewInt(200)}
+	preverifier.preverify("peer", header)
+	preverifier.preverify("peer", header)
+	if err := preverifier.verifyHeader(header); err != nil {
+		t.Fatalf("Expect pre-verified header, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("Expect 1 verification, got %d", calls)
+	}
+
+	// A failed pre-verification is verified again
+	other := &types.Header{Number: big.NewInt(400)}
+	preverifier.preverify("peer", other)
+	if err := preverifier.verifyHeader(other); err != nil {
+		t.Fatalf("Expect the header to be verified again, got %v", err)
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Fatalf("Expect 3 verifications, got %d", calls)
+	}
+
+	// A header which is not pre-verified is verified directly
+	if err := preverifier.verifyHeader(&types.Header{Number: big.NewInt(600)}); err != nil {
+		t.Fatalf("Expect verified header, got %v", err)
+	}
+}
+
+func TestHeaderPreverifierLimits(t *testing.T) {
+	unblock := make(chan struct{})
+	preverifier := newHeaderPreverifier(func(header *types.Header) error {
+		<-unblock
+		return nil
+	})
+	preverified := func(number int64) bool {
+		return preverifier.results.Contains((&types.Header{Number: big.NewInt(number)}).Hash())
+	}
+
+	// A peer only has one running verification
+	preverifier.preverify("peer", &types.Header{Number: big.NewInt(200)})
+	preverifier.preverify("peer", &types.Header{Number: big.NewInt(400)})
+	if !preverified(200) || preverified(400) {
+		t.Fatalf("Expect only the first header of the peer to be pre-verified")
+	}
+	// The running verifications are bounded for all the peers
+	for i := 1; i <= maxPreverifications; i++ {
+		preverifier.preverify(fmt.Sprintf("peer%d", i), &types.Header{Number: big.NewInt(int64(200 * (i + 1)))})
+	}
+	if preverified(200 * (maxPreverifications + 1)) {
+		t.Fatalf("Expect the verifications over the limit to be skipped")
+	}
+
+	// The slots are released once the verifications are done
+	close(unblock)
+	for i := 0; i < maxPreverifications; i++ {
+		if err := preverifier.verifyHeader(&types.Header{Number: big.NewInt(int64(200 * (i + 1)))}); err != nil {
+			t.Fatalf("Expect verified header, got %v", err)
+		}
+	}
+	for start := time.Now(); len(preverifier.slots) > 0; time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("Expect the verification slots to be released")
+		}
+	}
+	preverifier.preverify("peer", &types.Header{Number: big.NewInt(1200)})
+	if !preverified(1200) {
+		t.Fatalf("Expect the header to be pre-verified once the peer is idle")
+	}
+}
@@ -0,0 +1,191 @@
+package eth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	finalityTracking "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/finality_tracking"
+	roninValidatorSet "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/ronin_validator_set"
+	slashIndicator "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/slash_indicator"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// maxEpochGasReportBlocks is the maximum number of wrap-up blocks in a report
+	maxEpochGasReportBlocks = 1024
+
+	// unknownSystemCall is the name of the system calls not matching any known
+	// method of the system contracts
+	unknownSystemCall = "unknown"
+)
+
+// systemCallNames maps the method selectors of the system calls applied by the
+// consortium engine to their names
+var systemCallNames = func() map[[4]byte]string {
+	names := make(map[[4]byte]string)
+	for _, contract := range []struct {
+		metadata *bind.MetaData
+		methods  []string
+	}{
+		{roninValidatorSet.RoninValidatorSetMetaData, []string{"wrapUpEpoch", "submitBlockReward"}},
+		{slashIndicator.SlashIndicatorMetaData, []string{"slashUnavailability", "slashDoubleSign"}},
+		{finalityTracking.FinalityTrackingMetaData, []string{"recordFinality"}},
+	} {
+		contractABI, err := contract.metadata.GetAbi()
+		if err != nil {
+			panic(err)
+		}
+		for _, method := range contract.methods {
+			var selector [4]byte
+			copy(selector[:], contractABI.Methods[method].ID)
+			names[selector] = method
+		}
+	}
+	return names
+}()
+
+// systemCallName returns the name of the system call made by the transaction
+func systemCallName(tx *types.Transaction) string {
+	var selector [4]byte
+	if len(tx.Data()) < len(selector) {
+		return unknownSystemCall
+	}
+	copy(selector[:], tx.Data())
+	if name, ok := systemCallNames[selector]; ok {
+		return name
+	}
+	return unknownSystemCall
+}
+
+// SystemCallGasStats is the gas used by a system call over the epoch blocks of
+// a report
+type SystemCallGasStats struct {
+	Calls      uint64 `json:"calls"`
+	GasUsed    uint64 `json:"gasUsed"`
+	MaxGasUsed uint64 `json:"maxGasUsed"` // Maximum gas used by a single call
+}
+
+// EpochBlockGas is the gas used by the system calls of an epoch block
+type EpochBlockGas struct {
+	Number        uint64            `json:"number"`
+	Hash          common.Hash       `json:"hash"`
+	GasUsed       uint64            `json:"gasUsed"`
+	SystemGasUsed uint64            `json:"systemGasUsed"`
+	SystemCalls   map[string]uint64 `json:"systemCalls"` // system call -> gas used
+}
+
+// EpochGasReport is the breakdown of the gas used by the system calls of the
+// epoch blocks in a range
+type EpochGasReport struct {
+	From        uint64                         `json:"from"`
+	To          uint64                         `json:"to"`
+	Epoch       uint64                         `json:"epoch"`
+	SystemCalls map[string]*SystemCallGasStats `json:"systemCalls"`
+	Blocks      []EpochBlockGas                `json:"blocks"`
+}
+
+// add accounts the system calls of the block in the report
+func (report *EpochGasReport) add(block *types.Block, receipts types.Receipts, isSystemTx func(*types.Transaction) (bool, error)) error {
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipts of block %d mismatch its transactions", block.NumberU64())
+	}
+	blockGas := EpochBlockGas{
+		Number:      block.NumberU64(),
+		Hash:        block.Hash(),
+		GasUsed:     block.GasUsed(),
+		SystemCalls: make(map[string]uint64),
+	}
+	for i, tx := range block.Transactions() {
+		isSystem, err := isSystemTx(tx)
+		if err != nil {
+			return err
+		}
+		if !isSystem {
+			continue
+		}
+		name, gas := systemCallName(tx), receipts[i].GasUsed
+		blockGas.SystemGasUsed += gas
+		blockGas.SystemCalls[name] += gas
+
+		stats, ok := report.SystemCalls[name]
+		if !ok {
+			stats = &SystemCallGasStats{}
+			report.SystemCalls[name] = stats
+		}
+		stats.Calls++
+		stats.GasUsed += gas
+		if gas > stats.MaxGasUsed {
+			stats.MaxGasUsed = gas
+		}
+	}
+	report.Blocks = append(report.Blocks, blockGas)
+	return nil
+}
+
+// PublicEpochGasAPI provides the gas accounting of the epoch blocks
+type PublicEpochGasAPI struct {
+	e *Ethereum
+}
+
+func NewPublicEpochGasAPI(e *Ethereum) *PublicEpochGasAPI {
+	return &PublicEpochGasAPI{e}
+}
+
+// GetEpochGasReport returns the gas used by each system call (the slashes, the
+// rewards and the epoch wrap-up) of the wrap-up blocks between from and to, both
+// included. The report is derived from the canonical blocks and receipts only,
+// so it is the same on every node.
+func (api *PublicEpochGasAPI) GetEpochGasReport(from, to rpc.BlockNumber) (*EpochGasReport, error) {
+	config := api.e.blockchain.Config()
+	posa, ok := api.e.engine.(consensus.PoSA)
+	if config.Consortium == nil || config.Consortium.EpochV2 == 0 || !ok {
+		return nil, errors.New("epoch gas report is only available on consortium v2 chains")
+	}
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 {
+			return api.e.blockchain.CurrentBlock().NumberU64()
+		}
+		return uint64(number)
+	}
+	start, end := resolve(from), resolve(to)
+	if start > end {
+		return nil, fmt.Errorf("invalid range, from %d is above to %d", start, end)
+	}
+
+	epoch := config.Consortium.EpochV2
+	// The system calls are applied by the wrap-up blocks, the last block of
+	// each epoch
+	first := (start+epoch)/epoch*epoch - 1
+	if first <= end && (end-first)/epoch+1 > maxEpochGasReportBlocks {
+		return nil, fmt.Errorf("range covers more than %d wrap-up blocks", maxEpochGasReportBlocks)
+	}
+
+	report := &EpochGasReport{
+		From:        start,
+		To:          end,
+		Epoch:       epoch,
+		SystemCalls: make(map[string]*SystemCallGasStats),
+	}
+	for number := first; number <= end; number += epoch {
+		block := api.e.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", number)
+		}
+		if !config.IsConsortiumV2(block.Number()) {
+			continue
+		}
+		receipts := api.e.blockchain.GetReceiptsByHash(block.Hash())
+		header := block.Header()
+		err := report.add(block, receipts, func(tx *types.Transaction) (bool, error) {
+			return posa.IsSystemTransaction(tx, header)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,191 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	roninValidatorSet "github.com/ethereum/go-ethereum/consensus/consortium/generated_contracts/ronin_validator_set"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// systemTxEngine is a PoSA engine whose system transactions are the ones sent
+// to the system contract
+type systemTxEngine struct {
+	consensus.Engine
+	systemContract common.Address
+}
+
+func (e *systemTxEngine) IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
+	return tx.To() != nil && *tx.To() == e.systemContract, nil
+}
+
+func (e *systemTxEngine) IsSystemContract(to *common.Address) bool {
+	return to != nil && *to == e.systemContract
+}
+
+// systemTxFixture is the fixture of the system transaction tests, the system
+// contract 0x1 is called with the methods of the validator set contract
+type systemTxFixture struct {
+	engine         *systemTxEngine
+	systemContract common.Address
+	wrapUp         []byte // Selector of wrapUpEpoch
+	reward         []byte // Selector of submitBlockReward
+}
+
+func newSystemTxFixture(t *testing.T) *systemTxFixture {
+	validatorSetABI, err := roninValidatorSet.RoninValidatorSetMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("Failed to get abi, err %s", err)
+	}
+	systemContract := common.HexToAddress("0x1")
+	return &systemTxFixture{
+		engine:         &systemTxEngine{Engine: ethash.NewFaker(), systemContract: systemContract},
+		systemContract: systemContract,
+		wrapUp:         validatorSetABI.Methods["wrapUpEpoch"].ID,
+		reward:         validatorSetABI.Methods["submitBlockReward"].ID,
+	}
+}
+
+// newTx returns an unsigned transaction calling the contract with the data
+func (f *systemTxFixture) newTx(nonce uint64, to common.Address, data []byte) *types.Transaction {
+	return types.NewTransaction(nonce, to, common.Big0, 100000, common.Big0, data)
+}
+
+// isSystemTx returns the system transaction check of the engine for the block
+func (f *systemTxFixture) isSystemTx(block *types.Block) func(*types.Transaction) (bool, error) {
+	return func(tx *types.Transaction) (bool, error) {
+		return f.engine.IsSystemTransaction(tx, block.Header())
+	}
+}
+
+func TestEpochGasReport(t *testing.T) {
+	var (
+		fixture        = newSystemTxFixture(t)
+		systemContract = fixture.systemContract
+		newTx          = fixture.newTx
+	)
+	txs := []*types.Transaction{
+		newTx(0, common.HexToAddress("0x2"), fixture.wrapUp), // Not a system transaction
+		newTx(1, systemContract, fixture.reward),
+		newTx(2, systemContract, fixture.wrapUp),
+		newTx(3, systemContract, []byte{0x1}),
+	}
+	receipts := types.Receipts{{GasUsed: 21000}, {GasUsed: 30000}, {GasUsed: 50000}, {GasUsed: 10000}}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(200), GasUsed: 111000}, txs, nil, receipts, trie.NewStackTrie(nil))
+	isSystemTx := fixture.isSystemTx(block)
+
+	report := &EpochGasReport{SystemCalls: make(map[string]*SystemCallGasStats)}
+	if err := report.add(block, receipts, isSystemTx); err != nil {
+		t.Fatalf("Failed to add block, err %s", err)
+	}
+	if err := report.add(block, receipts, isSystemTx); err != nil {
+		t.Fatalf("Failed to add block, err %s", err)
+	}
+
+	if len(report.Blocks) != 2 {
+		t.Fatalf("Blocks mismatch, expect %d got %d", 2, len(report.Blocks))
+	}
+	blockGas := report.Blocks[0]
+	if blockGas.GasUsed != 111000 || blockGas.SystemGasUsed != 90000 {
+		t.Fatalf("Block gas mismatch, got %d total %d system", blockGas.GasUsed, blockGas.SystemGasUsed)
+	}
+	expected := map[string]uint64{"wrapUpEpoch": 50000, "submitBlockReward": 30000, unknownSystemCall: 10000}
+	for name, gas := range expected {
+		if blockGas.SystemCalls[name] != gas {
+			t.Fatalf("Gas of %s mismatch, expect %d got %d", name, gas, blockGas.SystemCalls[name])
+		}
+		stats := report.SystemCalls[name]
+		if stats == nil || stats.Calls != 2 || stats.GasUsed != 2*gas || stats.MaxGasUsed != gas {
+			t.Fatalf("Stats of %s mismatch, got %+v", name, stats)
+		}
+	}
+	if len(report.SystemCalls) != len(expected) {
+		t.Fatalf("System calls mismatch, expect %d got %d", len(expected), len(report.SystemCalls))
+	}
+
+	if err := report.add(block, receipts[:1], isSystemTx); err == nil {
+		t.Fatalf("Expect error on mismatching receipts")
+	}
+}
+
+func TestEpochGasReportChain(t *testing.T) {
+	var (
+		fixture = newSystemTxFixture(t)
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		wrapUp  = fixture.wrapUp
+		posa    = fixture.engine
+		engine  = posa.Engine
+		config  = *params.TestChainConfig
+		genesis = &core.Genesis{
+			Config:  &config,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   core.GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	config.ConsortiumV2Block = common.Big0
+	config.Consortium = &params.ConsortiumConfig{EpochV2: 10}
+
+	// The chain is processed by a plain engine, the system transactions of a
+	// PoSA engine are only accepted from the coinbase
+	db := rawdb.NewMemoryDatabase()
+	genesisBlock := genesis.MustCommit(db)
+	// Every block calls the system contract, only the wrap-up blocks are reported
+	blocks, _ := core.GenerateChain(&config, genesisBlock, engine, db, 25, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), posa.systemContract, common.Big0, 100000, gen.BaseFee(), wrapUp), types.LatestSigner(&config), key)
+		gen.AddTx(tx)
+	}, true)
+	chain, err := core.NewBlockChain(db, nil, &config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	api := NewPublicEpochGasAPI(&Ethereum{blockchain: chain, engine: posa})
+	for _, test := range []struct {
+		from, to rpc.BlockNumber
+		blocks   []uint64
+	}{
+		{0, rpc.LatestBlockNumber, []uint64{9, 19}},
+		{9, 19, []uint64{9, 19}},
+		{10, 18, nil},
+		{10, 19, []uint64{19}},
+		{20, rpc.LatestBlockNumber, nil},
+	} {
+		report, err := api.GetEpochGasReport(test.from, test.to)
+		if err != nil {
+			t.Fatalf("Failed to get report from %d to %d, err %s", test.from, test.to, err)
+		}
+		if len(report.Blocks) != len(test.blocks) {
+			t.Fatalf("Blocks from %d to %d mismatch, expect %v got %+v", test.from, test.to, test.blocks, report.Blocks)
+		}
+		for i, number := range test.blocks {
+			blockGas := report.Blocks[i]
+			if blockGas.Number != number || blockGas.Hash != blocks[number-1].Hash() {
+				t.Fatalf("Block %d mismatch, got %d %x", number, blockGas.Number, blockGas.Hash)
+			}
+			if blockGas.SystemGasUsed == 0 || blockGas.SystemGasUsed != blockGas.SystemCalls["wrapUpEpoch"] {
+				t.Fatalf("System gas of block %d mismatch, got %+v", number, blockGas)
+			}
+		}
+		if stats := report.SystemCalls["wrapUpEpoch"]; len(test.blocks) > 0 && (stats == nil || stats.Calls != uint64(len(test.blocks))) {
+			t.Fatalf("Stats from %d to %d mismatch, got %+v", test.from, test.to, stats)
+		}
+	}
+	if _, err := api.GetEpochGasReport(20, 10); err == nil {
+		t.Fatalf("Expect error on inverted range")
+	}
+}
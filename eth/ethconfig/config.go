@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/consensus/consortium"
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -92,11 +93,23 @@ var Defaults = Config{
 		BlockProduceLeftOver: 200 * time.Millisecond,
 		BlockSizeReserve:     500000,
 	},
-	TxPool:         core.DefaultTxPoolConfig,
-	RPCGasCap:      50000000,
-	RPCEVMTimeout:  5 * time.Second,
-	GPO:            FullNodeGPO,
-	RPCTxFeeCap:    1, // 1 ether
+	TxPool:        core.DefaultTxPoolConfig,
+	RPCGasCap:     50000000,
+	RPCEVMTimeout: 5 * time.Second,
+	GPO:           FullNodeGPO,
+	RPCTxFeeCap:   1, // 1 ether
+
+	BadBlockBundleDir:      "badblocks",
+	FinalityStallThreshold: 50,
+	SlashReportGasCap:      1000000,
+	InactivityThreshold:    5,
+	ValidatorScore:         v2.DefaultScoreConfig,
+	ConsortiumCache:        v2.DefaultCacheConfig,
+	SigningLeaseDuration:   15 * time.Second,
+	WatchdogDiskLatency:    500 * time.Millisecond,
+	WatchdogMinPeers:       1,
+	HardforkNotifyDistance: 28800, // One day of 3s blocks
+	ConsortiumVerify:       ConsortiumVerifyFull,
 }
 
 func init() {
@@ -128,6 +141,10 @@ type Config struct {
 	// If nil, the Ethereum main net block is used.
 	Genesis *core.Genesis `toml:",omitempty"`
 
+	// Name of the built-in chain configuration replacing the stored one, see
+	// params.ChainPresets
+	ChainPreset string
+
 	// Protocol options
 	NetworkId uint64 // Network ID to use for selecting peers to connect to
 	SyncMode  downloader.SyncMode
@@ -216,13 +233,144 @@ type Config struct {
 	// Enable finality vote monitoring
 	EnableMonitorFinalityVote bool
 
+	// Webhook notified when the finality stalls for FinalityStallThreshold blocks
+	// and when it recovers, disabled if empty
+	FinalityStallWebhook   string
+	FinalityStallThreshold uint64
+
+	// Number of epochs without finalized block after which the transactions sent
+	// through RPC are refused until the finality recovers, disabled if 0. The
+	// optional webhook is notified when the chain freezes and unfreezes.
+	ChainFreezeEpochs  uint64
+	ChainFreezeWebhook string
+
+	// Sinks (webhook urls or other registered schemes) the finality data of the
+	// finalized blocks are exported to
+	FinalityExportURLs []string
+
+	// Track the missed in-turn slots of the validators, the webhook is notified
+	// and the command is run when the local validator misses more than
+	// InactivityThreshold consecutive in-turn slots
+	EnableInactivityTracker bool
+	InactivityThreshold     uint64
+	InactivityWebhook       string
+	InactivityCommand       string
+
+	// Score the performance of the validators over the last completed epochs,
+	// see consortium_getValidatorScores
+	EnableValidatorScores bool
+	ValidatorScore        v2.ScoreConfig
+
+	// Webhook notified when the periodic self-test of the BLS vote key fails,
+	// the failures are always logged
+	VoteCanaryWebhook string
+
+	// Webhooks notified of the events of the validator operation, see
+	// monitor.ValidatorNotificationEvents. Only ValidatorNotifyEvents are
+	// notified, all of them if empty. The hardforks are notified
+	// HardforkNotifyDistance blocks ahead
+	ValidatorNotifyWebhooks []string
+	ValidatorNotifyEvents   []string
+	HardforkNotifyDistance  uint64
+
+	// Report the detected double signs to the slash indicator contract in the
+	// blocks sealed by this node, the double sign evidence is only logged in dry run
+	EnableSlashDoubleSignReport bool
+	SlashReportGasCap           uint64
+	SlashReportDryRun           bool
+
+	// Lease file shared by the primary and standby nodes of a validator, only
+	// the lease holder seals blocks and votes. Disabled if empty
+	SigningLeaseFile     string
+	SigningLeaseHolder   string // Identity of this node in the lease, hostname if empty
+	SigningLeaseDuration time.Duration
+	// Take over the lease when the validator misses more than InactivityThreshold
+	// consecutive in-turn slots, requires EnableInactivityTracker with a non-zero
+	// threshold
+	SigningLeaseTakeover bool
+
+	// Minimum number of connected peers, and of those running the ronin protocol
+	// to relay the finality votes, before the node seals blocks and votes
+	MinSigningPeers      int
+	MinSigningRoninPeers int
+
+	// Probe the disk latency, the peer count and the head state every
+	// WatchdogInterval and decline to seal while the node is unhealthy, disabled
+	// if 0. The disk is not probed without data directory.
+	WatchdogInterval    time.Duration
+	WatchdogDiskLatency time.Duration
+	WatchdogMinPeers    int
+
+	// Number of pending txs above which the in-turn validator seals without
+	// waiting for the finality votes and the out-of-turn validators back off
+	// further, 0 disables it
+	SealBacklogThreshold int
+
+	// Allow reorging the chain below the justified block, only for disaster
+	// recovery
+	AllowJustifiedRewind bool
+
 	// Disable ronin p2p protocol
 	DisableRoninProtocol bool
 
+	// Report the observed block latencies to the ronin peers
+	ReportBlockLatency bool
+
+	// Prove the local validator to the ronin peers and protect the connections to
+	// the peers proving to be validators of the current snapshot, restricted to
+	// the allowlist if it is not empty and never to the validators in the denylist
+	ValidatorPeering          bool
+	ValidatorPeeringAllowlist []common.Address
+	ValidatorPeeringDenylist  []common.Address
+
 	// Send additional chain event
 	EnableAdditionalChainEvent bool
+
+	// Directory to persist bad block bundles for replaying, disabled if empty
+	BadBlockBundleDir string
+
+	// Maximum duration a consortium block time can be ahead of the local clock
+	AllowedFutureBlockTime time.Duration
+
+	// Skip the finality voting or the block sealing of the validator while the
+	// node keeps running, both can be changed at runtime
+	DisableVoting  bool
+	DisableSealing bool
+
+	// Store the consortium snapshots in a memory mapped file instead of the chain database
+	MmapSnapshotStore bool
+
+	// Move the finalized consortium snapshots to an ancient table outside of the chain database
+	SnapshotArchive bool
+
+	// Sizes of the consortium snapshot and sealer caches and the number of epochs
+	// between the persisted checkpoint snapshots, see admin_setSnapshotCache
+	ConsortiumCache v2.CacheConfig
+
+	// JSON file of the emergency consortium validator set override, disabled if empty
+	ValidatorSetOverrideFile string
+
+	// Validator whose in-turn blocks are produced without being signed nor
+	// broadcast, disabled if empty
+	ShadowSealer common.Address
+
+	// Verification mode of the consortium headers, see ConsortiumVerifyLight
+	ConsortiumVerify string
+
+	// File the consensus audit records of the imported blocks are appended to
+	// as JSON lines, disabled if empty
+	AuditLogFile string
 }
 
+// The verification modes of the consortium headers. In the light mode, meant for
+// the RPC followers behind trusted validators, the finality signatures of the
+// headers at or below the finality checkpoint relayed by the trusted peers are
+// not verified.
+const (
+	ConsortiumVerifyFull  = "full"
+	ConsortiumVerifyLight = "light"
+)
+
 // CreateConsensusEngine creates a consensus engine for the given chain configuration.
 func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, config *ethash.Config, notify []string, noverify bool, db ethdb.Database, ee *ethapi.PublicBlockChainAPI, genesisHash common.Hash) consensus.Engine {
 	// If proof-of-authority is requested, set it up
@@ -60,6 +60,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		AllowedFutureBlockTime  time.Duration
+		DisableVoting           bool
+		DisableSealing          bool
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -104,6 +107,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Checkpoint = c.Checkpoint
 	enc.CheckpointOracle = c.CheckpointOracle
 	enc.OverrideArrowGlacier = c.OverrideArrowGlacier
+	enc.AllowedFutureBlockTime = c.AllowedFutureBlockTime
+	enc.DisableVoting = c.DisableVoting
+	enc.DisableSealing = c.DisableSealing
 	return &enc, nil
 }
 
@@ -152,6 +158,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Checkpoint              *params.TrustedCheckpoint      `toml:",omitempty"`
 		CheckpointOracle        *params.CheckpointOracleConfig `toml:",omitempty"`
 		OverrideArrowGlacier    *big.Int                       `toml:",omitempty"`
+		AllowedFutureBlockTime  *time.Duration
+		DisableVoting           *bool
+		DisableSealing          *bool
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -283,5 +292,14 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.OverrideArrowGlacier != nil {
 		c.OverrideArrowGlacier = dec.OverrideArrowGlacier
 	}
+	if dec.AllowedFutureBlockTime != nil {
+		c.AllowedFutureBlockTime = *dec.AllowedFutureBlockTime
+	}
+	if dec.DisableVoting != nil {
+		c.DisableVoting = *dec.DisableVoting
+	}
+	if dec.DisableSealing != nil {
+		c.DisableSealing = *dec.DisableSealing
+	}
 	return nil
 }
@@ -241,7 +241,8 @@ func (api *PublicFilterAPI) NewFinalizedBlocks(ctx context.Context) (*rpc.Subscr
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
-func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+// With the finalized option, the notification is sent once the block is finalized.
+func (api *PublicFilterAPI) NewHeads(ctx context.Context, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -251,7 +252,12 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 
 	go func() {
 		headers := make(chan *types.Header)
-		headersSub := api.events.SubscribeNewHeads(headers)
+		var headersSub *Subscription
+		if opts != nil && opts.Finalized {
+			headersSub = api.events.SubscribeFinalizedHeads(headers)
+		} else {
+			headersSub = api.events.SubscribeNewHeads(headers)
+		}
 
 		for {
 			select {
@@ -271,7 +277,8 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 }
 
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
-func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+// With the finalized option, the logs are sent once their block is finalized.
+func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria, opts *SubscriptionOptions) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
@@ -282,7 +289,15 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 		matchedLogs = make(chan []*types.Log)
 	)
 
-	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
+	var (
+		logsSub *Subscription
+		err     error
+	)
+	if opts != nil && opts.Finalized {
+		logsSub, err = api.events.SubscribeFinalizedLogs(ethereum.FilterQuery(crit), matchedLogs)
+	} else {
+		logsSub, err = api.events.SubscribeLogs(ethereum.FilterQuery(crit), matchedLogs)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -308,6 +323,14 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	return rpcSub, nil
 }
 
+// SubscriptionOptions are the optional settings of the newHeads and logs
+// subscriptions.
+type SubscriptionOptions struct {
+	// Finalized delivers the headers and the logs only once their block is
+	// finalized, so they are never reorganized and no removed logs are sent.
+	Finalized bool `json:"finalized"`
+}
+
 // FilterCriteria represents a request to create a new filter.
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria ethereum.FilterQuery
@@ -55,6 +55,10 @@ const (
 	BlocksSubscription
 	// FinalizedBlockSubscription
 	FinalizedBlockSubscription
+	// FinalizedLogsSubscription queries for logs in blocks once they are finalized
+	FinalizedLogsSubscription
+	// FinalizedHeadsSubscription queries headers of blocks once they are finalized
+	FinalizedHeadsSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -69,6 +73,10 @@ const (
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// finalizedBackfillLimit is the maximum number of newly finalized blocks
+	// delivered to the finalized logs and heads subscriptions at once, only the
+	// last finalized block is delivered after a larger jump.
+	finalizedBackfillLimit = 1024
 )
 
 type subscription struct {
@@ -282,6 +290,51 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 	return es.subscribe(sub)
 }
 
+// SubscribeFinalizedLogs creates a subscription that writes the logs matching
+// the given criteria once their block is finalized. The finalized blocks are
+// never reorganized, so the logs are never removed. The pending logs are never
+// finalized, an error is returned if they are requested.
+func (es *EventSystem) SubscribeFinalizedLogs(crit ethereum.FilterQuery, logs chan []*types.Log) (*Subscription, error) {
+	if (crit.FromBlock != nil && crit.FromBlock.Int64() == rpc.PendingBlockNumber.Int64()) ||
+		(crit.ToBlock != nil && crit.ToBlock.Int64() == rpc.PendingBlockNumber.Int64()) {
+		return nil, fmt.Errorf("pending logs are never finalized")
+	}
+	if crit.FromBlock != nil && crit.ToBlock != nil && crit.FromBlock.Int64() >= 0 && crit.ToBlock.Int64() >= 0 &&
+		crit.FromBlock.Cmp(crit.ToBlock) > 0 {
+		return nil, fmt.Errorf("invalid from and to block combination: from > to")
+	}
+	sub := &subscription{
+		id:         rpc.NewID(),
+		typ:        FinalizedLogsSubscription,
+		logsCrit:   crit,
+		created:    time.Now(),
+		logs:       logs,
+		hashes:     make(chan []common.Hash),
+		headers:    make(chan *types.Header),
+		finalizers: make(chan *core.FinalizedBlockInfo),
+		installed:  make(chan struct{}),
+		err:        make(chan error),
+	}
+	return es.subscribe(sub), nil
+}
+
+// SubscribeFinalizedHeads creates a subscription that writes the header of a
+// block once it is finalized, in block number order.
+func (es *EventSystem) SubscribeFinalizedHeads(headers chan *types.Header) *Subscription {
+	sub := &subscription{
+		id:         rpc.NewID(),
+		typ:        FinalizedHeadsSubscription,
+		created:    time.Now(),
+		logs:       make(chan []*types.Log),
+		hashes:     make(chan []common.Hash),
+		headers:    headers,
+		finalizers: make(chan *core.FinalizedBlockInfo),
+		installed:  make(chan struct{}),
+		err:        make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
 // SubscribeNewFinalizedHeads creates a subscription that writes the block number of a block that is
 // imported in the chain.
 func (es *EventSystem) SubscribeNewFinalizedBlocks(finalizers chan *core.FinalizedBlockInfo) *Subscription {
@@ -382,6 +435,7 @@ func (es *EventSystem) handleFinalizedEvent(filters filterIndex, ev core.ChainEv
 	if ev.FinalizedBlockNumber == 0 || es.lastFinalized == ev.FinalizedBlockNumber {
 		return
 	}
+	last := es.lastFinalized
 	es.lastFinalized = ev.FinalizedBlockNumber
 	for _, f := range filters[FinalizedBlockSubscription] {
 		f.finalizers <- &core.FinalizedBlockInfo{
@@ -389,6 +443,43 @@ func (es *EventSystem) handleFinalizedEvent(filters filterIndex, ev core.ChainEv
 			FinalizedBlockHash:   ev.FinalizedBlockHash,
 		}
 	}
+	es.handleFinalizedData(filters, last, ev.FinalizedBlockNumber)
+}
+
+// handleFinalizedData delivers the headers and the logs of the blocks finalized
+// after last up to finalized to the finalized heads and logs subscriptions.
+func (es *EventSystem) handleFinalizedData(filters filterIndex, last, finalized uint64) {
+	if len(filters[FinalizedHeadsSubscription]) == 0 && len(filters[FinalizedLogsSubscription]) == 0 {
+		return
+	}
+	if finalized <= last {
+		return
+	}
+	from := last + 1
+	if last == 0 || finalized-last > finalizedBackfillLimit {
+		if last != 0 {
+			log.Warn("Skipping finalized blocks for the subscriptions", "from", from, "to", finalized-1)
+		}
+		from = finalized
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	for number := from; number <= finalized; number++ {
+		header, err := es.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil || header == nil {
+			log.Debug("Failed to retrieve finalized header", "number", number, "err", err)
+			continue
+		}
+		for _, f := range filters[FinalizedHeadsSubscription] {
+			f.headers <- header
+		}
+		for _, f := range filters[FinalizedLogsSubscription] {
+			logs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, false)
+			if logs = filterLogs(logs, f.logsCrit.FromBlock, f.logsCrit.ToBlock, nil, nil); len(logs) > 0 {
+				f.logs <- logs
+			}
+		}
+	}
 }
 
 func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent) {
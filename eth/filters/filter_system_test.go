@@ -213,6 +213,86 @@ func TestFinalizedBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestFinalizedSubscriptions tests that the finalized heads and logs subscriptions
+// deliver the newly finalized blocks in order, and only once they are finalized.
+func TestFinalizedSubscriptions(t *testing.T) {
+	t.Parallel()
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		backend = &testBackend{db: db}
+		api     = NewPublicFilterAPI(backend, false, deadline)
+		genesis = (&core.Genesis{BaseFee: big.NewInt(params.InitialBaseFee)}).MustCommit(db)
+		topic   = common.BytesToHash([]byte("topic"))
+	)
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {
+		if i == 2 || i == 5 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: common.HexToAddress("0x1"), Topics: []common.Hash{topic}}}
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, gen.BaseFee(), nil))
+		}
+	}, true)
+	var chainEvents []core.ChainEvent
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+
+		// Each block finalizes its parent, the first finalized block is 1
+		finalized := chain[0]
+		if i > 0 {
+			finalized = chain[i-1]
+		}
+		chainEvents = append(chainEvents, core.ChainEvent{
+			Hash:                 block.Hash(),
+			Block:                block,
+			FinalizedBlockNumber: finalized.NumberU64(),
+			FinalizedBlockHash:   finalized.Hash(),
+		})
+	}
+
+	if _, err := api.events.SubscribeFinalizedLogs(ethereum.FilterQuery{FromBlock: big.NewInt(rpc.PendingBlockNumber.Int64())}, make(chan []*types.Log)); err == nil {
+		t.Fatalf("Expect error when subscribing to finalized pending logs")
+	}
+	headers := make(chan *types.Header)
+	headersSub := api.events.SubscribeFinalizedHeads(headers)
+	logs := make(chan []*types.Log)
+	logsSub, err := api.events.SubscribeFinalizedLogs(ethereum.FilterQuery{Topics: [][]common.Hash{{topic}}}, logs)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to finalized logs, err %s", err)
+	}
+
+	go func() {
+		for _, e := range chainEvents {
+			backend.chainFeed.Send(e)
+		}
+	}()
+
+	var (
+		wantHeaders = chain[:9]
+		wantLogs    = []uint64{3, 6}
+		timeout     = time.After(5 * time.Second)
+	)
+	for len(wantHeaders) > 0 || len(wantLogs) > 0 {
+		select {
+		case header := <-headers:
+			if len(wantHeaders) == 0 || header.Hash() != wantHeaders[0].Hash() {
+				t.Fatalf("Unexpected finalized header %d", header.Number)
+			}
+			wantHeaders = wantHeaders[1:]
+		case found := <-logs:
+			if len(wantLogs) == 0 || len(found) != 1 || found[0].BlockNumber != wantLogs[0] || found[0].Removed {
+				t.Fatalf("Unexpected finalized logs %v", found)
+			}
+			wantLogs = wantLogs[1:]
+		case <-timeout:
+			t.Fatalf("Timeout, missing headers %d, missing logs %v", len(wantHeaders), wantLogs)
+		}
+	}
+	headersSub.Unsubscribe()
+	logsSub.Unsubscribe()
+}
+
 // TestBlockSubscription tests if a block subscription returns block hashes for posted chain events.
 // It creates multiple subscriptions:
 // - one at the start and should receive all posted chain events and a second (blockHashes)
@@ -36,6 +36,8 @@ import (
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/chaos"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -52,6 +54,8 @@ var (
 	syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
 )
 
+var aggregatedVotes = features.Register("ronin.aggregatedvotes", "Propagate the aggregated finality votes to the peers running ronin/3 and above", true, true)
+
 // txPool defines the methods needed from a transaction pool implementation to
 // support all the operations needed by the Ethereum chain protocols.
 type txPool interface {
@@ -89,6 +93,9 @@ type handlerConfig struct {
 	Whitelist            map[uint64]common.Hash    // Hard coded whitelist for sync challenged
 	DisableRoninProtocol bool                      // Ronin protocol is enabled
 	VotePool             *vote.VotePool            // Vote pool when fast finality is enabled
+	ReportBlockLatency   bool                      // Whether to report the block latencies to the ronin peers
+	TrustedFinality      func(uint64, common.Hash) // Called with the finalized blocks relayed by the trusted peers, nil if disabled
+	ValidatorPeering     *validatorPeeringConfig   // Protects the connections to the validators, nil if disabled
 }
 
 type handler struct {
@@ -133,6 +140,17 @@ type handler struct {
 	votePool             *vote.VotePool
 	voteCh               chan core.NewVoteEvent
 	voteSub              event.Subscription
+	aggregatedVoteCh     chan core.NewAggregatedVoteEvent
+	aggregatedVoteSub    event.Subscription
+	equivocationCh       chan core.NewVoteEquivocationEvent
+	equivocationSub      event.Subscription
+	voteFanout           *voteFanout
+	preverifier          *headerPreverifier
+	blockArrival         blockArrivalTracker
+	blockLatency         *blockLatencyTracker
+	reportBlockLatency   bool
+	trustedFinality      func(uint64, common.Hash)
+	validatorPeering     *validatorPeering
 }
 
 // newHandler returns a handler for all Ethereum chain management protocol.
@@ -155,6 +173,13 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		handlerStartCh:       make(chan struct{}),
 		disableRoninProtocol: config.DisableRoninProtocol,
 		votePool:             config.VotePool,
+		voteFanout:           newVoteFanout(),
+		blockLatency:         newBlockLatencyTracker(),
+		reportBlockLatency:   config.ReportBlockLatency && !config.DisableRoninProtocol,
+		trustedFinality:      config.TrustedFinality,
+	}
+	if config.ValidatorPeering != nil && !config.DisableRoninProtocol {
+		h.validatorPeering = newValidatorPeering(*config.ValidatorPeering)
 	}
 	if config.Sync == downloader.FullSync {
 		// The database seems empty as the current block is the genesis. Yet the fast
@@ -200,7 +225,13 @@ func newHandler(config *handlerConfig) (*handler, error) {
 	h.downloader = downloader.New(h.checkpointNumber, config.Database, h.stateBloom, h.eventMux, h.chain, nil, h.removePeer)
 
 	// Construct the fetcher (short sync)
+	h.preverifier = newHeaderPreverifier(func(header *types.Header) error {
+		return h.chain.Engine().VerifyHeader(h.chain, header, true)
+	})
 	validator := func(header *types.Header) error {
+		if isEpochBlock(h.chain.Config(), header.Number) {
+			return h.preverifier.verifyHeader(header)
+		}
 		return h.chain.Engine().VerifyHeader(h.chain, header, true)
 	}
 	heighter := func() uint64 {
@@ -351,7 +382,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		return errors.New("peer dropped during handling")
 	}
 	// Register the peer in the downloader. If the downloader considers it banned, we disconnect
-	if err := h.downloader.RegisterPeer(peer.ID(), peer.Version(), peer); err != nil {
+	if err := h.downloader.RegisterPeer(peer.ID(), peer.Version(), p); err != nil {
 		peer.Log().Error("Failed to register peer in eth syncer", "err", err)
 		return err
 	}
@@ -419,10 +450,22 @@ func (h *handler) runRoninExtension(peer *ronin.Peer, handler ronin.Handler) err
 	}
 	defer h.decHandlers()
 
+	var number uint64
+	var hash common.Hash
+	if finalized := h.chain.FinalizedBlock(); finalized != nil {
+		number, hash = finalized.NumberU64(), finalized.Hash()
+	}
+	if err := peer.Handshake(number, hash); err != nil {
+		peer.Log().Debug("Ronin handshake failed", "err", err)
+		return err
+	}
 	if err := h.peers.registerRoninExtension(peer); err != nil {
 		peer.Log().Info("Ronin extension registration failed", "err", err)
 		return err
 	}
+	if h.validatorPeering != nil {
+		h.validatorPeering.sendProof(peer)
+	}
 
 	return handler(peer)
 }
@@ -460,6 +503,8 @@ func (h *handler) unregisterPeer(id string) {
 	}
 	h.downloader.UnregisterPeer(id)
 	h.txFetcher.Drop(id)
+	h.voteFanout.removePeer(id)
+	h.blockLatency.removePeer(id)
 
 	if err := h.peers.unregisterPeer(id); err != nil {
 		logger.Error("Ethereum peer removal failed", "err", err)
@@ -493,6 +538,29 @@ func (h *handler) Start(maxPeers int) {
 		h.voteSub = h.votePool.SubscribeNewVoteEvent(h.voteCh)
 		h.wg.Add(1)
 		go h.voteBroadcastLoop()
+
+		h.aggregatedVoteCh = make(chan core.NewAggregatedVoteEvent)
+		h.aggregatedVoteSub = h.votePool.SubscribeNewAggregatedVoteEvent(h.aggregatedVoteCh)
+		h.wg.Add(1)
+		go h.aggregatedVoteBroadcastLoop()
+
+		h.equivocationCh = make(chan core.NewVoteEquivocationEvent)
+		h.equivocationSub = h.votePool.SubscribeNewVoteEquivocationEvent(h.equivocationCh)
+		h.wg.Add(1)
+		go h.voteEquivocationBroadcastLoop()
+	}
+
+	if h.reportBlockLatency {
+		h.wg.Add(1)
+		go h.blockLatencyReportLoop()
+	}
+
+	if h.validatorPeering != nil {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.validatorPeering.loop(h.quitSync)
+		}()
 	}
 }
 
@@ -502,6 +570,12 @@ func (h *handler) Stop() {
 	if h.voteSub != nil {
 		h.voteSub.Unsubscribe() // quits voteBroadcastLoop
 	}
+	if h.aggregatedVoteSub != nil {
+		h.aggregatedVoteSub.Unsubscribe() // quits aggregatedVoteBroadcastLoop
+	}
+	if h.equivocationSub != nil {
+		h.equivocationSub.Unsubscribe() // quits voteEquivocationBroadcastLoop
+	}
 
 	// Quit chainSync and txsync64.
 	// After this is done, no new peers will be accepted.
@@ -533,8 +607,12 @@ func (h *handler) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
-		// Send the block to a subset of our peers
+		// Send the block to a subset of our peers, the epoch blocks are sent to
+		// the validator peers first
 		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
+		if isEpochBlock(h.chain.Config(), block.Number()) {
+			transfer = epochBlockTargets(peers, h.voteFanout, time.Now())
+		}
 		for _, peer := range transfer {
 			peer.AsyncSendNewBlock(block, td)
 		}
@@ -599,8 +677,9 @@ func (h *handler) minedBroadcastLoop() {
 
 	for obj := range h.minedBlockSub.Chan() {
 		if ev, ok := obj.Data.(core.NewMinedBlockEvent); ok {
-			h.BroadcastBlock(ev.Block, true)  // First propagate block to peers
-			h.BroadcastBlock(ev.Block, false) // Only then announce to the rest
+			block := chaos.CorruptBlock(ev.Block)
+			h.BroadcastBlock(block, true)  // First propagate block to peers
+			h.BroadcastBlock(block, false) // Only then announce to the rest
 		}
 	}
 }
@@ -618,11 +697,25 @@ func (h *handler) txBroadcastLoop() {
 	}
 }
 
+// broadcastVote sends the vote immediately to the fanout peers and in batch to
+// the rest of the peers which do not know the vote nor have the aggregated vote
+// for its block.
 func (h *handler) broadcastVote(voteEnvelop *types.VoteEnvelope) {
-	roninPeers := h.peers.roninPeerWithoutVote(voteEnvelop.Hash())
-	for _, peer := range roninPeers {
+	if chaos.DropVote() {
+		return
+	}
+	now := time.Now()
+	h.voteFanout.markVote("", voteEnvelop.Hash(), now)
+
+	direct, batched := h.voteFanout.split(h.peers.roninPeerWithoutVote(voteEnvelop.Hash(), voteEnvelop.Data.TargetHash), now)
+	for _, peer := range direct {
+		peer.AsyncSendNewVoteDirect(voteEnvelop)
+	}
+	for _, peer := range batched {
 		peer.AsyncSendNewVote(voteEnvelop)
 	}
+	voteDirectMeter.Mark(int64(len(direct)))
+	voteBatchedMeter.Mark(int64(len(batched)))
 }
 
 func (h *handler) voteBroadcastLoop() {
@@ -636,3 +729,76 @@ func (h *handler) voteBroadcastLoop() {
 		}
 	}
 }
+
+// broadcastAggregatedVote sends the aggregated vote to the peers which do not
+// have an aggregated vote for its block yet.
+func (h *handler) broadcastAggregatedVote(vote *types.AggregatedVote) {
+	if !aggregatedVotes.Enabled() {
+		return
+	}
+	peers := h.peers.roninPeerWithoutAggregatedVote(vote.Data.TargetHash)
+	for _, peer := range peers {
+		peer.AsyncSendAggregatedVote(vote)
+	}
+	aggregatedVoteMeter.Mark(int64(len(peers)))
+}
+
+func (h *handler) aggregatedVoteBroadcastLoop() {
+	defer h.wg.Done()
+	for {
+		select {
+		case voteEvent := <-h.aggregatedVoteCh:
+			h.broadcastAggregatedVote(voteEvent.Vote)
+		case <-h.aggregatedVoteSub.Err():
+			return
+		}
+	}
+}
+
+// broadcastVoteEquivocation sends the proof of conflicting votes to the peers
+// which do not know it yet.
+func (h *handler) broadcastVoteEquivocation(proof *types.VoteEquivocation) {
+	for _, peer := range h.peers.roninPeerWithoutVoteEquivocation(proof.Hash()) {
+		if err := peer.SendVoteEquivocation(proof); err != nil {
+			peer.Log().Debug("Failed to send vote equivocation", "err", err)
+		}
+	}
+}
+
+func (h *handler) voteEquivocationBroadcastLoop() {
+	defer h.wg.Done()
+	for {
+		select {
+		case proofEvent := <-h.equivocationCh:
+			h.broadcastVoteEquivocation(proofEvent.Proof)
+		case <-h.equivocationSub.Err():
+			return
+		}
+	}
+}
+
+// blockLatencyReportLoop periodically sends the observed block latencies to
+// the `ronin` peers.
+func (h *handler) blockLatencyReportLoop() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(blockLatencyReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			latencies := h.blockLatency.flush()
+			if len(latencies) == 0 {
+				continue
+			}
+			for _, peer := range h.peers.roninPeers() {
+				if err := peer.SendBlockLatency(latencies); err != nil {
+					peer.Log().Debug("Failed to send block latencies", "err", err)
+				}
+			}
+		case <-h.quitSync:
+			return
+		}
+	}
+}
@@ -149,7 +149,14 @@ func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) erro
 			peer.Log().Debug("Whitelist block verified", "number", headers[0].Number.Uint64(), "hash", want)
 		}
 		// Irrelevant of the fork checks, send the header to the fetcher just in case
+		announced := headers[0]
 		headers = h.blockFetcher.FilterHeaders(peer.ID(), headers, time.Now())
+
+		// Start verifying the epoch header while its body is downloaded if the
+		// fetcher requested it after an announcement
+		if len(headers) == 0 {
+			(*handler)(h).preverifyEpochHeader(peer.ID(), announced)
+		}
 	}
 	if len(headers) > 0 || !filter {
 		err := h.downloader.DeliverHeaders(peer.ID(), headers)
@@ -200,6 +207,10 @@ func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash,
 // handleBlockBroadcast is invoked from a peer's message handler when it transmits a
 // block broadcast for the local node to process.
 func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td *big.Int) error {
+	now := time.Now()
+	h.blockArrival.mark(block.Header(), now)
+	h.blockLatency.markBlock(block.Header(), now)
+
 	// Schedule the block for import
 	h.blockFetcher.Enqueue(peer.ID(), block)
 
@@ -1,6 +1,8 @@
 package eth
 
 import (
+	"time"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -26,15 +28,50 @@ func (r *roninHandler) Handle(peer *ronin.Peer, packet ronin.Packet) error {
 	case ronin.NewVoteMsg:
 		if r.votePool != nil {
 			votePacket := packet.(*ronin.NewVotePacket)
+			now := time.Now()
 			for _, rawVote := range votePacket.Vote {
 				vote := &types.VoteEnvelope{
 					RawVoteEnvelope: *rawVote,
 				}
+				r.voteFanout.markVote(peer.ID(), vote.Hash(), now)
 				r.votePool.PutVote(peer.ID(), vote)
 			}
 		} else {
 			peer.Log().Debug("Local node does not enable fast finality, drop new vote msg")
 		}
+	case ronin.AggregatedVoteMsg:
+		votePacket := packet.(*ronin.AggregatedVotePacket)
+		// The quorum of a trusted peer is taken as is as the finality checkpoint
+		// of the light verification
+		if r.trustedFinality != nil && peer.Info().Network.Trusted {
+			for _, vote := range votePacket.Votes {
+				r.trustedFinality(vote.Data.TargetNumber, vote.Data.TargetHash)
+			}
+		}
+		if r.votePool != nil {
+			for _, vote := range votePacket.Votes {
+				r.votePool.PutAggregatedVote(peer.ID(), vote)
+			}
+		} else {
+			peer.Log().Debug("Local node does not enable fast finality, drop aggregated vote msg")
+		}
+	case ronin.VoteEquivocationMsg:
+		if r.votePool != nil {
+			for _, proof := range packet.(*ronin.VoteEquivocationPacket).Proofs {
+				if err := r.votePool.AddVoteEquivocation(proof); err != nil {
+					return err
+				}
+			}
+		} else {
+			peer.Log().Debug("Local node does not enable fast finality, drop vote equivocation msg")
+		}
+	case ronin.BlockLatencyMsg:
+		latencyPacket := packet.(*ronin.BlockLatencyPacket)
+		r.blockLatency.markReport(peer.ID(), latencyPacket.Latencies, r.chain.GetHeaderByHash)
+	case ronin.ValidatorProofMsg:
+		if r.validatorPeering != nil {
+			return r.validatorPeering.handleProof(peer, packet.(*ronin.ValidatorProofPacket).Signature)
+		}
 	}
 	return nil
 }
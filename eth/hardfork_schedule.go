@@ -0,0 +1,81 @@
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// HardforkStatus is the activation status of a configured hardfork
+type HardforkStatus struct {
+	Name       string `json:"name"`
+	Block      uint64 `json:"block"`
+	Activated  bool   `json:"activated"`
+	BlocksLeft uint64 `json:"blocksLeft"` // Blocks from the head to the activation, 0 if activated
+
+	// Time of the activation block if activated, else estimated from the head
+	// time and the block period
+	Time      uint64 `json:"time"`
+	Estimated bool   `json:"estimated"`
+}
+
+// HardforkSchedule is the status of the hardforks of the chain config at the
+// head, in the order of the fields of the config
+type HardforkSchedule struct {
+	HeadNumber  uint64           `json:"headNumber"`
+	HeadTime    uint64           `json:"headTime"`
+	BlockPeriod uint64           `json:"blockPeriod"`
+	Forks       []HardforkStatus `json:"forks"`
+}
+
+// newHardforkSchedule returns the status of the forks of config at head, the
+// times of the activated forks are read with blockTime
+func newHardforkSchedule(config *params.ChainConfig, head *types.Header, blockTime func(number uint64) (uint64, bool)) *HardforkSchedule {
+	var (
+		number   = head.Number.Uint64()
+		schedule = &HardforkSchedule{
+			HeadNumber: number,
+			HeadTime:   head.Time,
+			Forks:      make([]HardforkStatus, 0),
+		}
+	)
+	if config.Consortium != nil {
+		schedule.BlockPeriod = config.Consortium.Period
+	}
+	for _, fork := range config.ForkBlocks() {
+		status := HardforkStatus{Name: fork.Name, Block: fork.Block.Uint64()}
+		if status.Block <= number {
+			status.Activated = true
+			status.Time, _ = blockTime(status.Block)
+		} else {
+			status.BlocksLeft = status.Block - number
+			status.Time = head.Time + status.BlocksLeft*schedule.BlockPeriod
+			status.Estimated = true
+		}
+		schedule.Forks = append(schedule.Forks, status)
+	}
+	return schedule
+}
+
+// PublicHardforkScheduleAPI provides the activation status of the hardforks, so
+// the tools and explorers can show the upcoming forks without the genesis file
+type PublicHardforkScheduleAPI struct {
+	e *Ethereum
+}
+
+func NewPublicHardforkScheduleAPI(e *Ethereum) *PublicHardforkScheduleAPI {
+	return &PublicHardforkScheduleAPI{e}
+}
+
+// GetHardforkSchedule returns the configured hardforks with their activation
+// block, whether they are activated at the head and their activation time,
+// estimated for the upcoming ones.
+func (api *PublicHardforkScheduleAPI) GetHardforkSchedule() *HardforkSchedule {
+	chain := api.e.blockchain
+	return newHardforkSchedule(chain.Config(), chain.CurrentHeader(), func(number uint64) (uint64, bool) {
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return 0, false
+		}
+		return header.Time, true
+	})
+}
@@ -0,0 +1,41 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestHardforkSchedule(t *testing.T) {
+	config := &params.ChainConfig{
+		ChainID:        big.NewInt(2021),
+		HomesteadBlock: big.NewInt(0),
+		ByzantiumBlock: big.NewInt(10),
+		OdysseusBlock:  big.NewInt(100),
+		Consortium:     &params.ConsortiumConfig{Period: 3},
+	}
+	head := &types.Header{Number: big.NewInt(40), Time: 1000}
+	blockTime := func(number uint64) (uint64, bool) {
+		return 880 + number*3, true
+	}
+
+	schedule := newHardforkSchedule(config, head, blockTime)
+	if schedule.HeadNumber != 40 || schedule.HeadTime != 1000 || schedule.BlockPeriod != 3 {
+		t.Fatalf("head mismatch: have %d at %d period %d", schedule.HeadNumber, schedule.HeadTime, schedule.BlockPeriod)
+	}
+	want := []HardforkStatus{
+		{Name: "Homestead", Block: 0, Activated: true, Time: 880},
+		{Name: "Byzantium", Block: 10, Activated: true, Time: 910},
+		{Name: "Odysseus", Block: 100, BlocksLeft: 60, Time: 1180, Estimated: true},
+	}
+	if len(schedule.Forks) != len(want) {
+		t.Fatalf("fork count mismatch: have %d, want %d: %v", len(schedule.Forks), len(want), schedule.Forks)
+	}
+	for i, fork := range schedule.Forks {
+		if fork != want[i] {
+			t.Errorf("fork %d mismatch: have %+v, want %+v", i, fork, want[i])
+		}
+	}
+}
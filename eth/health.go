@@ -0,0 +1,218 @@
+package eth
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// blockArrivalSamples is the number of recent broadcast blocks used to
+	// estimate the clock drift
+	blockArrivalSamples = 32
+
+	// healthVoteWindow is the number of recent blocks checked for the inclusion
+	// of the local validator's finality votes
+	healthVoteWindow = 100
+
+	// healthTargetPeers is the number of peers for the full peer score
+	healthTargetPeers = 10
+
+	healthSyncWeight     = 30
+	healthMissedWeight   = 20
+	healthVoteWeight     = 20
+	healthPeerWeight     = 15
+	healthClockWeight    = 15
+	healthClockTolerance = 250 * time.Millisecond
+	healthClockMaxDrift  = 2 * time.Second
+)
+
+// blockArrivalTracker keeps the delay between the header time and the arrival
+// of the recent broadcast blocks. As the blocks are propagated at their header
+// time, the smallest delay approximates the local clock drift.
+type blockArrivalTracker struct {
+	lock    sync.Mutex
+	delays  [blockArrivalSamples]time.Duration
+	next    int
+	samples int
+}
+
+func (tracker *blockArrivalTracker) mark(header *types.Header, now time.Time) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.delays[tracker.next] = now.Sub(time.Unix(int64(header.Time), 0))
+	tracker.next = (tracker.next + 1) % blockArrivalSamples
+	if tracker.samples < blockArrivalSamples {
+		tracker.samples++
+	}
+}
+
+// drift returns the estimated drift, positive if the local clock is ahead
+func (tracker *blockArrivalTracker) drift() (time.Duration, bool) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if tracker.samples == 0 {
+		return 0, false
+	}
+	drift := time.Duration(math.MaxInt64)
+	for i := 0; i < tracker.samples; i++ {
+		if tracker.delays[i] < drift {
+			drift = tracker.delays[i]
+		}
+	}
+	return drift, true
+}
+
+// PublicHealthAPI provides the health score of the local validator
+type PublicHealthAPI struct {
+	e *Ethereum
+}
+
+func NewPublicHealthAPI(e *Ethereum) *PublicHealthAPI {
+	return &PublicHealthAPI{e: e}
+}
+
+type healthComponent struct {
+	Name   string `json:"name"`
+	Score  uint64 `json:"score"` // 0 to 100
+	Weight uint64 `json:"weight"`
+	Detail string `json:"detail"`
+}
+
+type healthScore struct {
+	Score      uint64            `json:"score"` // Weighted average of the component scores
+	Validator  common.Address    `json:"validator"`
+	Components []healthComponent `json:"components"`
+}
+
+// linearScore returns 100 when value is at most good, 0 when value is at least
+// bad and scales linearly in between
+func linearScore(value, good, bad float64) uint64 {
+	switch {
+	case value <= good:
+		return 100
+	case value >= bad:
+		return 0
+	default:
+		return uint64(100 * (bad - value) / (bad - good))
+	}
+}
+
+// HealthScore returns a 0-100 health score of the local validator combining the
+// sync lag, the missed in-turn slots, the finality vote inclusion rate, the peer
+// count and the clock drift. The components that cannot be measured, e.g. the
+// missed slots when the inactivity tracker is disabled, are left out.
+func (api *PublicHealthAPI) HealthScore() *healthScore {
+	var (
+		chain     = api.e.blockchain
+		head      = chain.CurrentHeader()
+		validator = api.e.healthValidator()
+		result    = &healthScore{Validator: validator}
+	)
+
+	// Sync lag, the head is expected within 2 block periods
+	period := float64(3)
+	if config := chain.Config().Consortium; config != nil && config.Period > 0 {
+		period = float64(config.Period)
+	}
+	lag := time.Since(time.Unix(int64(head.Time), 0)).Seconds()
+	result.Components = append(result.Components, healthComponent{
+		Name:   "sync",
+		Score:  linearScore(lag, 2*period, 20*period),
+		Weight: healthSyncWeight,
+		Detail: fmt.Sprintf("head %d is %.0fs old", head.Number.Uint64(), lag),
+	})
+
+	if c, ok := api.e.engine.(*consortium.Consortium); ok && validator != (common.Address{}) {
+		if uptime, ok := c.GetValidatorUptime(validator); ok && uptime.Sealed+uptime.Missed > 0 {
+			missedRate := float64(uptime.Missed) / float64(uptime.Sealed+uptime.Missed)
+			result.Components = append(result.Components, healthComponent{
+				Name:   "missedSlots",
+				Score:  uint64(100 * (1 - missedRate)),
+				Weight: healthMissedWeight,
+				Detail: fmt.Sprintf("missed %d, sealed %d, consecutive missed %d", uptime.Missed, uptime.Sealed, uptime.ConsecutiveMissed),
+			})
+		}
+	}
+
+	if engine, ok := api.e.engine.(consensus.FastFinalityPoSA); ok && validator != (common.Address{}) {
+		if included, eligible := api.voteInclusion(engine, head, validator); eligible > 0 {
+			result.Components = append(result.Components, healthComponent{
+				Name:   "voteInclusion",
+				Score:  uint64(100 * included / eligible),
+				Weight: healthVoteWeight,
+				Detail: fmt.Sprintf("votes included in %d of %d blocks", included, eligible),
+			})
+		}
+	}
+
+	peers := api.e.handler.peers.len()
+	result.Components = append(result.Components, healthComponent{
+		Name:   "peers",
+		Score:  uint64(100 * math.Min(float64(peers), healthTargetPeers) / healthTargetPeers),
+		Weight: healthPeerWeight,
+		Detail: fmt.Sprintf("%d peers, %d ronin peers", peers, api.e.handler.peers.roninLen()),
+	})
+
+	if drift, ok := api.e.handler.blockArrival.drift(); ok {
+		result.Components = append(result.Components, healthComponent{
+			Name:   "clockDrift",
+			Score:  linearScore(math.Abs(float64(drift)), float64(healthClockTolerance), float64(healthClockMaxDrift)),
+			Weight: healthClockWeight,
+			Detail: fmt.Sprintf("estimated drift %v", drift),
+		})
+	}
+
+	var total, weights uint64
+	for _, component := range result.Components {
+		total += component.Score * component.Weight
+		weights += component.Weight
+	}
+	result.Score = total / weights
+	return result
+}
+
+// healthValidator returns the local validator, empty if the node is not a validator
+func (e *Ethereum) healthValidator() common.Address {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.etherbase
+}
+
+// voteInclusion returns the number of recent blocks in which the validator is
+// active and the number of them whose child block includes its finality vote
+func (api *PublicHealthAPI) voteInclusion(engine consensus.FastFinalityPoSA, head *types.Header, validator common.Address) (included, eligible uint64) {
+	chain := api.e.blockchain
+	child := head
+	for i := 0; i < healthVoteWindow && child.Number.Uint64() > 0; i++ {
+		header := chain.GetHeader(child.ParentHash, child.Number.Uint64()-1)
+		if header == nil || !chain.Config().IsShillin(child.Number) {
+			break
+		}
+		position := -1
+		for j, active := range engine.GetActiveValidatorAt(chain, header.Number.Uint64(), header.Hash()) {
+			if active.Address == validator {
+				position = j
+				break
+			}
+		}
+		if position >= 0 {
+			eligible++
+			extraData, err := finality.DecodeExtra(child.Extra, true)
+			if err == nil && extraData.HasFinalityVote == 1 && extraData.FinalityVotedValidators.Has(position) {
+				included++
+			}
+		}
+		child = header
+	}
+	return included, eligible
+}
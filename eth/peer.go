@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
@@ -56,6 +57,16 @@ func (p *ethPeer) info() *ethPeerInfo {
 	}
 }
 
+// Finalized returns the latest finalized block advertised by the `ronin`
+// extension of the peer, it is 0 without the extension. It hints the downloader
+// at the common ancestor.
+func (p *ethPeer) Finalized() (uint64, common.Hash) {
+	if p.roninExt == nil {
+		return 0, common.Hash{}
+	}
+	return p.roninExt.Finalized()
+}
+
 // snapPeerInfo represents a short summary of the `snap` sub-protocol metadata known
 // about a connected peer.
 type snapPeerInfo struct {
@@ -299,6 +299,20 @@ func (ps *peerSet) snapLen() int {
 	return ps.snapPeers
 }
 
+// roninLen returns the current number of peers running the `ronin` extension.
+func (ps *peerSet) roninLen() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	count := 0
+	for _, peer := range ps.peers {
+		if peer.roninExt != nil {
+			count++
+		}
+	}
+	return count
+}
+
 // peerWithHighestTD retrieves the known peer with the currently highest total
 // difficulty.
 func (ps *peerSet) peerWithHighestTD() *eth.Peer {
@@ -317,17 +331,63 @@ func (ps *peerSet) peerWithHighestTD() *eth.Peer {
 	return bestPeer
 }
 
-func (ps *peerSet) roninPeerWithoutVote(hash common.Hash) []*ronin.Peer {
+// roninPeers retrieves the peers running the `ronin` extension.
+func (ps *peerSet) roninPeers() []*ronin.Peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
 
 	var roninPeers []*ronin.Peer
 	for _, peer := range ps.peers {
-		if peer.roninExt != nil && !peer.roninExt.KnownFinalityVote(hash) {
+		if peer.roninExt != nil {
 			roninPeers = append(roninPeers, peer.roninExt)
 		}
 	}
+	return roninPeers
+}
 
+// roninPeerWithoutVote retrieves the `ronin` peers which do not know the vote,
+// nor have an aggregated vote for the target block of the vote.
+func (ps *peerSet) roninPeerWithoutVote(hash common.Hash, targetHash common.Hash) []*ronin.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var roninPeers []*ronin.Peer
+	for _, peer := range ps.peers {
+		if peer.roninExt != nil && !peer.roninExt.KnownFinalityVote(hash) && !peer.roninExt.KnownAggregatedVote(targetHash) {
+			roninPeers = append(roninPeers, peer.roninExt)
+		}
+	}
+
+	return roninPeers
+}
+
+// roninPeerWithoutAggregatedVote retrieves the `ronin` peers which do not have
+// an aggregated vote for the block.
+func (ps *peerSet) roninPeerWithoutAggregatedVote(blockHash common.Hash) []*ronin.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var roninPeers []*ronin.Peer
+	for _, peer := range ps.peers {
+		if peer.roninExt != nil && peer.roninExt.Version() >= ronin.Ronin3 && !peer.roninExt.KnownAggregatedVote(blockHash) {
+			roninPeers = append(roninPeers, peer.roninExt)
+		}
+	}
+	return roninPeers
+}
+
+// roninPeerWithoutVoteEquivocation retrieves the `ronin` peers which do not
+// have the equivocation proof.
+func (ps *peerSet) roninPeerWithoutVoteEquivocation(hash common.Hash) []*ronin.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var roninPeers []*ronin.Peer
+	for _, peer := range ps.peers {
+		if peer.roninExt != nil && peer.roninExt.Version() >= ronin.Ronin6 && !peer.roninExt.KnownVoteEquivocation(hash) {
+			roninPeers = append(roninPeers, peer.roninExt)
+		}
+	}
 	return roninPeers
 }
 
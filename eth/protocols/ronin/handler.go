@@ -2,14 +2,33 @@ package ronin
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+var strictDecode = features.Register("ronin.strictdecode", "Refuse the ronin messages with trailing bytes after the encoded packet", false, true)
+
+// decodeMsg decodes the payload of the message into val, the trailing bytes are
+// refused if the strict decode is enabled
+func decodeMsg(msg p2p.Msg, val interface{}) error {
+	if !strictDecode.Enabled() {
+		return msg.Decode(val)
+	}
+	payload, err := io.ReadAll(io.LimitReader(msg.Payload, int64(msg.Size)))
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(payload, val)
+}
+
 // Handler is a callback to invoke from an outside runner after the boilerplate
 // exchanges have passed.
 type Handler func(peer *Peer) error
@@ -33,6 +52,8 @@ type Backend interface {
 func MakeProtocols(backend Backend) []p2p.Protocol {
 	protocol := make([]p2p.Protocol, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
+		version := version // Closure
+
 		protocol[i] = p2p.Protocol{
 			Name:    ProtocolName,
 			Version: version,
@@ -100,7 +121,7 @@ func handleMessage(backend Backend, peer *Peer) error {
 	switch msg.Code {
 	case NewVoteMsg:
 		var votePacket NewVotePacket
-		if err := msg.Decode(&votePacket); err != nil {
+		if err := decodeMsg(msg, &votePacket); err != nil {
 			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
 		}
 		for _, packet := range votePacket.Vote {
@@ -112,6 +133,61 @@ func handleMessage(backend Backend, peer *Peer) error {
 		}
 
 		return backend.Handle(peer, &votePacket)
+	case BlockLatencyMsg:
+		var latencyPacket BlockLatencyPacket
+		if err := decodeMsg(msg, &latencyPacket); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(latencyPacket.Latencies) > MaxBlockLatencies {
+			return fmt.Errorf("%w: %v > %v", errTooManyReports, len(latencyPacket.Latencies), MaxBlockLatencies)
+		}
+
+		return backend.Handle(peer, &latencyPacket)
+	case AggregatedVoteMsg:
+		var votePacket AggregatedVotePacket
+		if err := decodeMsg(msg, &votePacket); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(votePacket.Votes) > MaxAggregatedVotes {
+			return fmt.Errorf("%w: %v > %v", errTooManyVotes, len(votePacket.Votes), MaxAggregatedVotes)
+		}
+		for _, vote := range votePacket.Votes {
+			if vote.Data == nil {
+				return fmt.Errorf("%w: aggregated vote without data", errDecode)
+			}
+			peer.markAggregatedVote(vote.Data.TargetHash)
+		}
+
+		return backend.Handle(peer, &votePacket)
+	case ValidatorProofMsg:
+		var proofPacket ValidatorProofPacket
+		if err := decodeMsg(msg, &proofPacket); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(proofPacket.Signature) != crypto.SignatureLength {
+			return fmt.Errorf("%w: signature length %v", errInvalidProof, len(proofPacket.Signature))
+		}
+
+		return backend.Handle(peer, &proofPacket)
+	case VoteEquivocationMsg:
+		var proofPacket VoteEquivocationPacket
+		if err := decodeMsg(msg, &proofPacket); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		if len(proofPacket.Proofs) > MaxVoteEquivocations {
+			return fmt.Errorf("%w: %v > %v", errTooManyProofs, len(proofPacket.Proofs), MaxVoteEquivocations)
+		}
+		for _, proof := range proofPacket.Proofs {
+			if proof.First == nil || proof.Second == nil || proof.First.Data == nil || proof.Second.Data == nil {
+				return fmt.Errorf("%w: equivocation proof without vote", errDecode)
+			}
+			peer.markVoteEquivocation(proof.Hash())
+		}
+
+		return backend.Handle(peer, &proofPacket)
+	case FinalityStatusMsg:
+		// The finality status is only exchanged in the handshake
+		return errExtraStatusMsg
 	default:
 		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
 	}
@@ -0,0 +1,33 @@
+package ronin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/internal/features"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestStrictDecode(t *testing.T) {
+	payload, err := rlp.EncodeToBytes(&BlockLatencyPacket{})
+	if err != nil {
+		t.Fatalf("Failed to encode packet, err %s", err)
+	}
+	payload = append(payload, 0x80)
+	newMsg := func() p2p.Msg {
+		return p2p.Msg{Code: BlockLatencyMsg, Size: uint32(len(payload)), Payload: bytes.NewReader(payload)}
+	}
+
+	var packet BlockLatencyPacket
+	if err := decodeMsg(newMsg(), &packet); err != nil {
+		t.Fatalf("Expect the trailing bytes to be ignored, got %v", err)
+	}
+	if _, err := features.Set("ronin.strictdecode", true); err != nil {
+		t.Fatalf("Failed to enable strict decode, err %s", err)
+	}
+	defer features.Set("ronin.strictdecode", false)
+	if err := decodeMsg(newMsg(), &packet); err != rlp.ErrMoreThanOneValue {
+		t.Fatalf("Expect error %v, got %v", rlp.ErrMoreThanOneValue, err)
+	}
+}
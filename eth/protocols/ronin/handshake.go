@@ -0,0 +1,69 @@
+package ronin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// handshakeTimeout is the maximum allowed time for the `ronin` handshake to
+// complete before dropping the connection.
+const handshakeTimeout = 5 * time.Second
+
+// Handshake exchanges the latest finalized blocks with the peer, it is skipped
+// for the peers running ronin/4 and below.
+func (p *Peer) Handshake(number uint64, hash common.Hash) error {
+	if p.version < Ronin5 {
+		return nil
+	}
+	// Send out own handshake in a new thread
+	errc := make(chan error, 2)
+
+	var status FinalityStatusPacket // safe to read after two values have been received from errc
+
+	go func() {
+		errc <- p2p.Send(p.rw, FinalityStatusMsg, &FinalityStatusPacket{
+			Number: number,
+			Hash:   hash,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(&status)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	p.finalized = status
+	return nil
+}
+
+// readStatus reads the remote handshake message.
+func (p *Peer) readStatus(status *FinalityStatusPacket) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	defer msg.Discard()
+
+	if msg.Code != FinalityStatusMsg {
+		return fmt.Errorf("%w: first msg has code %x (!= %x)", errNoStatusMsg, msg.Code, FinalityStatusMsg)
+	}
+	if msg.Size > maxMessageSize {
+		return fmt.Errorf("%w: %v > %v", errMsgTooLarge, msg.Size, maxMessageSize)
+	}
+	if err := decodeMsg(msg, status); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	return nil
+}
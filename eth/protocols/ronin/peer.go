@@ -11,9 +11,12 @@ import (
 )
 
 const (
-	voteChannelSize = 50
-	batchInterval   = 100 * time.Millisecond
-	maxKnownVote    = 8192
+	voteChannelSize           = 50
+	aggregatedVoteChannelSize = 16
+	batchInterval             = 100 * time.Millisecond
+	maxKnownVote              = 8192
+	maxKnownAggregatedVote    = 1024
+	maxKnownEquivocation      = 256
 )
 
 // Peer is a collection of relevant information we have about a `ronin` peer.
@@ -25,10 +28,17 @@ type Peer struct {
 	version   uint                     // Protocol version negotiated
 	term      chan struct{}            // Terminate the batch vote loop
 	voteCh    chan *types.VoteEnvelope // Put vote into pool for batching
+	directCh  chan *types.VoteEnvelope // Send vote without batching
+
+	aggregatedCh chan *types.AggregatedVote // Send aggregated vote without batching
 
 	logger log.Logger // Contextual logger with the peer id injected
 
 	knownFinalityVote *protocols.KnownCache // Set of finality vote hashes knowed to be known by this peer
+	knownAggregated   *protocols.KnownCache // Set of block hashes whose aggregated vote is known by this peer
+	knownEquivocation *protocols.KnownCache // Set of equivocation proof hashes known by this peer
+
+	finalized FinalityStatusPacket // Latest finalized block advertised in the handshake
 }
 
 // NewPeer create a wrapper for a network connection and negotiated  protocol
@@ -41,9 +51,13 @@ func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
 		rw:                rw,
 		version:           version,
 		voteCh:            make(chan *types.VoteEnvelope, voteChannelSize),
+		directCh:          make(chan *types.VoteEnvelope, voteChannelSize),
+		aggregatedCh:      make(chan *types.AggregatedVote, aggregatedVoteChannelSize),
 		term:              make(chan struct{}),
 		logger:            log.New("peer", id[:8]),
 		knownFinalityVote: protocols.NewKnownCache(maxKnownVote),
+		knownAggregated:   protocols.NewKnownCache(maxKnownAggregatedVote),
+		knownEquivocation: protocols.NewKnownCache(maxKnownEquivocation),
 	}
 	go peer.batchVote()
 
@@ -70,6 +84,14 @@ func (p *Peer) Log() log.Logger {
 	return p.logger
 }
 
+// Finalized returns the latest finalized block advertised by the peer in the
+// handshake, it is 0 if the peer runs ronin/4 and below or has no finalized
+// block. A finalized block is never reorged so the advertised one stays in the
+// chain of the peer, it only gets older.
+func (p *Peer) Finalized() (uint64, common.Hash) {
+	return p.finalized.Number, p.finalized.Hash
+}
+
 // sendNewVote sends votes to the peer.
 func (p *Peer) sendNewVote(votes []*types.VoteEnvelope) error {
 	var rawVote []*types.RawVoteEnvelope
@@ -81,6 +103,41 @@ func (p *Peer) sendNewVote(votes []*types.VoteEnvelope) error {
 	})
 }
 
+// SendBlockLatency sends the observed latencies of the recent blocks to the
+// peer, the peers running ronin/1 do not support the report and are skipped.
+func (p *Peer) SendBlockLatency(latencies []BlockLatency) error {
+	if p.version < Ronin2 {
+		return nil
+	}
+	return p2p.Send(p.rw, BlockLatencyMsg, BlockLatencyPacket{
+		Latencies: latencies,
+	})
+}
+
+// SendValidatorProof sends the proof that the local node is run by a validator
+// to the peer, the peers running ronin/3 and below do not support the proof
+// and are skipped.
+func (p *Peer) SendValidatorProof(signature []byte) error {
+	if p.version < Ronin4 {
+		return nil
+	}
+	return p2p.Send(p.rw, ValidatorProofMsg, ValidatorProofPacket{
+		Signature: signature,
+	})
+}
+
+// SendVoteEquivocation sends the proof of conflicting votes to the peer, the
+// peers running ronin/5 and below do not support the proofs and are skipped.
+func (p *Peer) SendVoteEquivocation(proof *types.VoteEquivocation) error {
+	if p.version < Ronin6 {
+		return nil
+	}
+	p.markVoteEquivocation(proof.Hash())
+	return p2p.Send(p.rw, VoteEquivocationMsg, VoteEquivocationPacket{
+		Proofs: []*types.VoteEquivocation{proof},
+	})
+}
+
 // AsyncSendNewVote puts the vote into the batch vote goroutine.
 func (p *Peer) AsyncSendNewVote(vote *types.VoteEnvelope) {
 	select {
@@ -91,6 +148,39 @@ func (p *Peer) AsyncSendNewVote(vote *types.VoteEnvelope) {
 	}
 }
 
+// AsyncSendNewVoteDirect puts the vote into the batch vote goroutine to be sent
+// immediately without waiting for the next batch.
+func (p *Peer) AsyncSendNewVoteDirect(vote *types.VoteEnvelope) {
+	select {
+	case p.directCh <- vote:
+		p.markFinalityVote(vote.Hash())
+	default:
+		p.Log().Debug("Dropping direct vote announcement", "hash", vote.Hash())
+	}
+}
+
+// sendAggregatedVote sends the aggregated vote to the peer.
+func (p *Peer) sendAggregatedVote(vote *types.AggregatedVote) error {
+	return p2p.Send(p.rw, AggregatedVoteMsg, AggregatedVotePacket{
+		Votes: []*types.AggregatedVote{vote},
+	})
+}
+
+// AsyncSendAggregatedVote puts the aggregated vote into the batch vote goroutine
+// to be sent immediately, the peers running ronin/2 and below do not support
+// the aggregated votes and are skipped.
+func (p *Peer) AsyncSendAggregatedVote(vote *types.AggregatedVote) {
+	if p.version < Ronin3 {
+		return
+	}
+	select {
+	case p.aggregatedCh <- vote:
+		p.markAggregatedVote(vote.Data.TargetHash)
+	default:
+		p.Log().Debug("Dropping aggregated vote announcement", "number", vote.Data.TargetNumber, "hash", vote.Data.TargetHash)
+	}
+}
+
 // batchVote batches multiple votes and sends to the peer.
 func (p *Peer) batchVote() {
 	var pendingVote []*types.VoteEnvelope
@@ -100,6 +190,16 @@ func (p *Peer) batchVote() {
 		select {
 		case vote := <-p.voteCh:
 			pendingVote = append(pendingVote, vote)
+		case vote := <-p.directCh:
+			if err := p.sendNewVote([]*types.VoteEnvelope{vote}); err != nil {
+				p.Log().Debug("Failed to send vote", "err", err)
+				return
+			}
+		case vote := <-p.aggregatedCh:
+			if err := p.sendAggregatedVote(vote); err != nil {
+				p.Log().Debug("Failed to send aggregated vote", "err", err)
+				return
+			}
 		case <-ticker.C:
 			if len(pendingVote) > 0 {
 				if err := p.sendNewVote(pendingVote); err != nil {
@@ -126,3 +226,27 @@ func (p *Peer) markFinalityVote(hash common.Hash) {
 	// If we reached the memory allowance, drop a previously known transaction hash
 	p.knownFinalityVote.Add(hash)
 }
+
+// KnownAggregatedVote returns whether peer is known to already have an
+// aggregated vote for the block.
+func (p *Peer) KnownAggregatedVote(blockHash common.Hash) bool {
+	return p.knownAggregated.Contains(blockHash)
+}
+
+// markAggregatedVote marks an aggregated vote for the block as known for the
+// peer. The individual votes for the block are no longer relayed to the peer.
+func (p *Peer) markAggregatedVote(blockHash common.Hash) {
+	p.knownAggregated.Add(blockHash)
+}
+
+// KnownVoteEquivocation returns whether the peer is known to already have the
+// equivocation proof.
+func (p *Peer) KnownVoteEquivocation(hash common.Hash) bool {
+	return p.knownEquivocation.Contains(hash)
+}
+
+// markVoteEquivocation marks an equivocation proof as known for the peer, it is
+// never relayed back to the peer.
+func (p *Peer) markVoteEquivocation(hash common.Hash) {
+	p.knownEquivocation.Add(hash)
+}
@@ -3,12 +3,20 @@ package ronin
 import (
 	"errors"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
 )
 
 // Constants to match up protocol versions and messages
 const (
 	Ronin1 = 1
+	Ronin2 = 2
+	Ronin3 = 3
+	Ronin4 = 4
+	Ronin5 = 5
+	Ronin6 = 6
 )
 
 // ProtocolName is the official short name of the `ronin` protocol used during
@@ -16,23 +24,53 @@ const (
 const ProtocolName = "ronin"
 
 // ProtocolVersions are the supported versions of the `ronin` protocol
-var ProtocolVersions = []uint{Ronin1}
+var ProtocolVersions = []uint{Ronin6, Ronin5, Ronin4, Ronin3, Ronin2, Ronin1}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{Ronin1: 1}
+var protocolLengths = map[uint]uint64{Ronin1: 1, Ronin2: 2, Ronin3: 3, Ronin4: 4, Ronin5: 5, Ronin6: 6}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
 
+// MaxBlockLatencies is the maximum number of block latencies in a report.
+const MaxBlockLatencies = 64
+
+// MaxAggregatedVotes is the maximum number of aggregated votes in a message.
+const MaxAggregatedVotes = 16
+
+// MaxVoteEquivocations is the maximum number of equivocation proofs in a message.
+const MaxVoteEquivocations = 16
+
 const (
 	NewVoteMsg = 0x00
+
+	// Protocol messages in ronin/2
+	BlockLatencyMsg = 0x01
+
+	// Protocol messages in ronin/3
+	AggregatedVoteMsg = 0x02
+
+	// Protocol messages in ronin/4
+	ValidatorProofMsg = 0x03
+
+	// Protocol messages in ronin/5
+	FinalityStatusMsg = 0x04
+
+	// Protocol messages in ronin/6
+	VoteEquivocationMsg = 0x05
 )
 
 var (
 	errMsgTooLarge    = errors.New("message too long")
 	errDecode         = errors.New("invalid message")
 	errInvalidMsgCode = errors.New("invalid message code")
+	errTooManyReports = errors.New("too many block latencies")
+	errTooManyVotes   = errors.New("too many aggregated votes")
+	errInvalidProof   = errors.New("invalid validator proof")
+	errNoStatusMsg    = errors.New("no finality status message")
+	errExtraStatusMsg = errors.New("extra finality status message")
+	errTooManyProofs  = errors.New("too many equivocation proofs")
 )
 
 // Packet represents a p2p message in the `ronin` protocol.
@@ -47,3 +85,89 @@ type NewVotePacket struct {
 
 func (*NewVotePacket) Name() string { return "NewVote" }
 func (*NewVotePacket) Kind() byte   { return NewVoteMsg }
+
+// BlockLatency is the delay after the header time at which a block is first
+// received by the reporting node.
+type BlockLatency struct {
+	Number  uint64
+	Hash    common.Hash
+	Latency uint64 // Milliseconds after the header time
+}
+
+// BlockLatencyPacket is the report of the propagation latencies of the recent
+// blocks as observed by the sender. It is telemetry only and is not part of
+// the consensus data.
+type BlockLatencyPacket struct {
+	Latencies []BlockLatency
+}
+
+func (*BlockLatencyPacket) Name() string { return "BlockLatency" }
+func (*BlockLatencyPacket) Kind() byte   { return BlockLatencyMsg }
+
+// AggregatedVotePacket relays the finality votes of a quorum of the validators
+// aggregated by the sender, in place of the individual votes.
+type AggregatedVotePacket struct {
+	Votes []*types.AggregatedVote
+}
+
+func (*AggregatedVotePacket) Name() string { return "AggregatedVote" }
+func (*AggregatedVotePacket) Kind() byte   { return AggregatedVoteMsg }
+
+// ValidatorProofPacket proves to the receiver that the sender is run by a
+// validator, the signature of the sealing key is over the ValidatorProofData of
+// the connection so it cannot be replayed to another node.
+type ValidatorProofPacket struct {
+	Signature []byte
+}
+
+func (*ValidatorProofPacket) Name() string { return "ValidatorProof" }
+func (*ValidatorProofPacket) Kind() byte   { return ValidatorProofMsg }
+
+// FinalityStatusPacket is the handshake of the ronin/5 peers, it advertises the
+// latest finalized block of the sender. The block is 0 with an empty hash if
+// the sender has no finalized block.
+type FinalityStatusPacket struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+func (*FinalityStatusPacket) Name() string { return "FinalityStatus" }
+func (*FinalityStatusPacket) Kind() byte   { return FinalityStatusMsg }
+
+// VoteEquivocationPacket relays the proofs that validators signed conflicting
+// finality votes, so that the receivers refuse the further votes of the
+// offenders without detecting the equivocations themselves.
+type VoteEquivocationPacket struct {
+	Proofs []*types.VoteEquivocation
+}
+
+func (*VoteEquivocationPacket) Name() string { return "VoteEquivocation" }
+func (*VoteEquivocationPacket) Kind() byte   { return VoteEquivocationMsg }
+
+// validatorProofPrefix separates the validator proofs from the other data signed
+// by the sealing key, it is not a valid RLP list so it cannot be a header.
+var validatorProofPrefix = []byte("ronin validator peering")
+
+// ValidatorProofData returns the data signed by the validator running the node
+// sender to prove its identity to the node receiver.
+func ValidatorProofData(sender, receiver enode.ID) []byte {
+	data := make([]byte, 0, len(validatorProofPrefix)+2*len(sender))
+	data = append(data, validatorProofPrefix...)
+	data = append(data, sender[:]...)
+	return append(data, receiver[:]...)
+}
+
+// RecoverValidator returns the address of the sealing key which signed the
+// validator proof data of the connection from sender to receiver.
+func RecoverValidator(sender, receiver enode.ID, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, errInvalidProof
+	}
+	// The sealing key signs the Keccak256 hash of the data, with the recovery id
+	// in the last byte as 0 or 1
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(ValidatorProofData(sender, receiver)), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
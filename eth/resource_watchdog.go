@@ -0,0 +1,156 @@
+package eth
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// watchdogRecoveryRounds is the number of consecutive healthy probe rounds
+// before the watchdog lets the node seal again, so a flapping resource does not
+// make it alternate between sealing and declining
+const watchdogRecoveryRounds = 3
+
+var watchdogUnhealthyMeter = metrics.NewRegisteredMeter("eth/watchdog/unhealthy", nil)
+
+// watchdogProbe is a check of a resource of the node, it returns an error if the
+// resource is unhealthy
+type watchdogProbe struct {
+	name  string
+	check func() error
+}
+
+// resourceWatchdog probes the resources of a validator node and declines to seal
+// while one of them is unhealthy, the out-of-turn validators then take the slot
+// instead of the node producing a late block which would fork. It is a seal
+// guard, see consortium.AddSealGuard.
+type resourceWatchdog struct {
+	probes   []watchdogProbe
+	interval time.Duration
+
+	lock   sync.RWMutex
+	err    error // Failure of the last unhealthy probe, nil if healthy
+	passes int   // Healthy rounds since the last failure
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newResourceWatchdog(interval time.Duration, probes ...watchdogProbe) *resourceWatchdog {
+	return &resourceWatchdog{
+		probes:   probes,
+		interval: interval,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start probes the resources once then every interval until stopped
+func (w *resourceWatchdog) Start() {
+	w.probe()
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.probe()
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (w *resourceWatchdog) Stop() {
+	close(w.quit)
+	w.wg.Wait()
+}
+
+// probe runs the probes and updates the health of the node
+func (w *resourceWatchdog) probe() {
+	var failure error
+	for _, probe := range w.probes {
+		if err := probe.check(); err != nil {
+			failure = fmt.Errorf("%s: %w", probe.name, err)
+			break
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if failure != nil {
+		if w.err == nil {
+			log.Warn("Node unhealthy, declining to seal", "err", failure)
+		}
+		watchdogUnhealthyMeter.Mark(1)
+		w.err, w.passes = failure, 0
+		return
+	}
+	if w.err != nil {
+		if w.passes++; w.passes >= watchdogRecoveryRounds {
+			log.Info("Node healthy again, resuming sealing", "was", w.err)
+			w.err, w.passes = nil, 0
+		}
+	}
+}
+
+// Check returns an error if the node is unhealthy
+func (w *resourceWatchdog) Check() error {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.err != nil {
+		return fmt.Errorf("node unhealthy: %w", w.err)
+	}
+	return nil
+}
+
+// diskLatencyProbe writes and syncs a small file at path and fails if it takes
+// longer than maxLatency, e.g. when the disk is saturated by the compactions
+func diskLatencyProbe(path string, maxLatency time.Duration) watchdogProbe {
+	data := make([]byte, 4096)
+	return watchdogProbe{name: "disk latency", check: func() error {
+		start := time.Now()
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := file.Write(data); err != nil {
+			return err
+		}
+		if err := file.Sync(); err != nil {
+			return err
+		}
+		if latency := time.Since(start); latency > maxLatency {
+			return fmt.Errorf("synced write took %v, want at most %v", latency, maxLatency)
+		}
+		return nil
+	}}
+}
+
+// peerCountProbe fails if the node is connected to less than minPeers peers
+func (s *Ethereum) peerCountProbe(minPeers int) watchdogProbe {
+	return watchdogProbe{name: "peer count", check: func() error {
+		if peers := s.handler.peers.len(); peers < minPeers {
+			return fmt.Errorf("have %d peers, want %d", peers, minPeers)
+		}
+		return nil
+	}}
+}
+
+// stateProbe fails if the state of the chain head is not available, the blocks
+// cannot then be built on top of it
+func (s *Ethereum) stateProbe() watchdogProbe {
+	return watchdogProbe{name: "state availability", check: func() error {
+		if head := s.blockchain.CurrentBlock(); !s.blockchain.HasState(head.Root()) {
+			return fmt.Errorf("missing state %x of head %d", head.Root(), head.NumberU64())
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,50 @@
+package eth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResourceWatchdog(t *testing.T) {
+	var diskErr error
+	watchdog := newResourceWatchdog(time.Second,
+		watchdogProbe{name: "ok", check: func() error { return nil }},
+		watchdogProbe{name: "disk", check: func() error { return diskErr }},
+	)
+
+	watchdog.probe()
+	if err := watchdog.Check(); err != nil {
+		t.Fatalf("healthy node refused to seal: %v", err)
+	}
+	diskErr = errors.New("slow")
+	watchdog.probe()
+	if err := watchdog.Check(); err == nil {
+		t.Fatal("unhealthy node allowed to seal")
+	}
+	// The node must stay healthy for a few rounds before sealing again
+	diskErr = nil
+	for i := 0; i < watchdogRecoveryRounds; i++ {
+		if err := watchdog.Check(); err == nil {
+			t.Fatalf("node allowed to seal after %d healthy rounds", i)
+		}
+		watchdog.probe()
+	}
+	if err := watchdog.Check(); err != nil {
+		t.Fatalf("recovered node refused to seal: %v", err)
+	}
+}
+
+func TestDiskLatencyProbe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchdog.probe")
+	if err := diskLatencyProbe(path, time.Minute).check(); err != nil {
+		t.Fatalf("disk probe failed: %v", err)
+	}
+	if err := diskLatencyProbe(path, time.Nanosecond).check(); err == nil {
+		t.Fatal("disk probe passed with a latency above the maximum")
+	}
+	if err := diskLatencyProbe(filepath.Join(path, "missing", "probe"), time.Minute).check(); err == nil {
+		t.Fatal("disk probe passed without writable path")
+	}
+}
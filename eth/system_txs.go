@@ -0,0 +1,87 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// SystemTransaction is a transaction applied by the consortium engine to one of
+// the system contracts, with the outcome from its receipt
+type SystemTransaction struct {
+	Hash             common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	To               common.Address `json:"to"`
+	Call             string         `json:"call"` // Method of the system contract, see systemCallNames
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Status           hexutil.Uint64 `json:"status"`
+}
+
+// blockSystemTransactions returns the system transactions of the block in their
+// order in the block
+func blockSystemTransactions(block *types.Block, receipts types.Receipts, isSystemTx func(*types.Transaction) (bool, error)) ([]*SystemTransaction, error) {
+	if len(receipts) != len(block.Transactions()) {
+		return nil, fmt.Errorf("receipts of block %d mismatch its transactions", block.NumberU64())
+	}
+	systemTxs := make([]*SystemTransaction, 0)
+	for i, tx := range block.Transactions() {
+		isSystem, err := isSystemTx(tx)
+		if err != nil {
+			return nil, err
+		}
+		if !isSystem {
+			continue
+		}
+		systemTxs = append(systemTxs, &SystemTransaction{
+			Hash:             tx.Hash(),
+			TransactionIndex: hexutil.Uint64(i),
+			To:               *tx.To(),
+			Call:             systemCallName(tx),
+			GasUsed:          hexutil.Uint64(receipts[i].GasUsed),
+			Status:           hexutil.Uint64(receipts[i].Status),
+		})
+	}
+	return systemTxs, nil
+}
+
+// PublicSystemTransactionAPI provides the system transactions of the blocks, so
+// they can be told apart from the user transactions without relying on their
+// sender being the coinbase
+type PublicSystemTransactionAPI struct {
+	e *Ethereum
+}
+
+func NewPublicSystemTransactionAPI(e *Ethereum) *PublicSystemTransactionAPI {
+	return &PublicSystemTransactionAPI{e}
+}
+
+// GetBlockSystemTransactions returns the system transactions (the rewards, the
+// slashes, the finality records and the epoch wrap-up) of the block. The blocks
+// before consortium v2 have none.
+func (api *PublicSystemTransactionAPI) GetBlockSystemTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*SystemTransaction, error) {
+	posa, ok := api.e.engine.(consensus.PoSA)
+	if !ok {
+		return nil, errors.New("system transactions are only available on consortium chains")
+	}
+	block, err := api.e.APIBackend.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("block not found")
+	}
+	if !api.e.blockchain.Config().IsConsortiumV2(block.Number()) {
+		return make([]*SystemTransaction, 0), nil
+	}
+	receipts := api.e.blockchain.GetReceiptsByHash(block.Hash())
+	header := block.Header()
+	return blockSystemTransactions(block, receipts, func(tx *types.Transaction) (bool, error) {
+		return posa.IsSystemTransaction(tx, header)
+	})
+}
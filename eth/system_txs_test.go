@@ -0,0 +1,51 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestBlockSystemTransactions(t *testing.T) {
+	var (
+		fixture        = newSystemTxFixture(t)
+		systemContract = fixture.systemContract
+		newTx          = fixture.newTx
+	)
+	txs := []*types.Transaction{
+		newTx(0, common.HexToAddress("0x2"), fixture.wrapUp), // Not a system transaction
+		newTx(1, systemContract, fixture.reward),
+		newTx(2, systemContract, fixture.wrapUp),
+	}
+	receipts := types.Receipts{
+		{GasUsed: 21000, Status: types.ReceiptStatusSuccessful},
+		{GasUsed: 30000, Status: types.ReceiptStatusSuccessful},
+		{GasUsed: 50000, Status: types.ReceiptStatusFailed},
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(200)}, txs, nil, receipts, trie.NewStackTrie(nil))
+	isSystemTx := fixture.isSystemTx(block)
+
+	systemTxs, err := blockSystemTransactions(block, receipts, isSystemTx)
+	if err != nil {
+		t.Fatalf("Failed to get system transactions, err %s", err)
+	}
+	expected := []SystemTransaction{
+		{Hash: txs[1].Hash(), TransactionIndex: 1, To: systemContract, Call: "submitBlockReward", GasUsed: 30000, Status: 1},
+		{Hash: txs[2].Hash(), TransactionIndex: 2, To: systemContract, Call: "wrapUpEpoch", GasUsed: 50000, Status: 0},
+	}
+	if len(systemTxs) != len(expected) {
+		t.Fatalf("System transactions mismatch, expect %d got %d", len(expected), len(systemTxs))
+	}
+	for i := range expected {
+		if *systemTxs[i] != expected[i] {
+			t.Fatalf("System transaction %d mismatch, expect %+v got %+v", i, expected[i], *systemTxs[i])
+		}
+	}
+
+	if _, err := blockSystemTransactions(block, receipts[:1], isSystemTx); err == nil {
+		t.Fatalf("Expect error on mismatching receipts")
+	}
+}
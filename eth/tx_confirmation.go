@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// TransactionConfirmation is the finality status of the canonical block including
+// a transaction. A block is justified when the next block includes the finality
+// votes of a quorum of the validators for it and finalized when it is an
+// ancestor of, or is, the latest finalized block, it is then never reverted.
+type TransactionConfirmation struct {
+	BlockHash       common.Hash    `json:"blockHash"`
+	BlockNumber     hexutil.Uint64 `json:"blockNumber"`
+	Confirmations   hexutil.Uint64 `json:"confirmations"` // Number of canonical blocks from the block to the head
+	Justified       bool           `json:"justified"`
+	Finalized       bool           `json:"finalized"`
+	FinalizedNumber hexutil.Uint64 `json:"finalizedNumber"`
+
+	// Estimated number of blocks before the block is finalized, assuming the
+	// finalized block keeps the same distance to the head. It is 0 if the block is
+	// finalized and nil if no block is finalized yet, e.g. before Shillin.
+	BlocksToFinality *hexutil.Uint64 `json:"blocksToFinality"`
+}
+
+// newTransactionConfirmation returns the finality status of the block at number
+// given the head and the latest finalized block, 0 if none
+func newTransactionConfirmation(hash common.Hash, number, head uint64, justified bool, finalized uint64) *TransactionConfirmation {
+	confirmation := &TransactionConfirmation{
+		BlockHash:       hash,
+		BlockNumber:     hexutil.Uint64(number),
+		Justified:       justified,
+		Finalized:       finalized != 0 && number <= finalized,
+		FinalizedNumber: hexutil.Uint64(finalized),
+	}
+	if head >= number {
+		confirmation.Confirmations = hexutil.Uint64(head - number + 1)
+	}
+	if confirmation.Finalized {
+		// The finalized blocks have been justified even if their votes are not
+		// included, the finality of a block covers its ancestors
+		confirmation.Justified = true
+		remaining := hexutil.Uint64(0)
+		confirmation.BlocksToFinality = &remaining
+	} else if finalized != 0 {
+		// The block is finalized once the head is as far from it as it is now
+		// from the finalized block
+		remaining := hexutil.Uint64(number - finalized)
+		confirmation.BlocksToFinality = &remaining
+	}
+	return confirmation
+}
+
+// PublicTransactionConfirmationAPI provides the finality status of the
+// transactions, so the wallets can show a transaction as final from the finality
+// votes instead of counting the confirmations
+type PublicTransactionConfirmationAPI struct {
+	e *Ethereum
+}
+
+func NewPublicTransactionConfirmationAPI(e *Ethereum) *PublicTransactionConfirmationAPI {
+	return &PublicTransactionConfirmationAPI{e}
+}
+
+// GetTransactionConfirmation returns the finality status of the canonical block
+// including the transaction, nil if the transaction is not included.
+func (api *PublicTransactionConfirmationAPI) GetTransactionConfirmation(ctx context.Context, hash common.Hash) (*TransactionConfirmation, error) {
+	engine, ok := api.e.engine.(consensus.FastFinalityPoSA)
+	if !ok {
+		return nil, errors.New("transaction confirmations are only available on consortium chains")
+	}
+	tx, blockHash, blockNumber, _ := rawdb.ReadTransaction(api.e.ChainDb(), hash)
+	if tx == nil {
+		return nil, nil
+	}
+	var (
+		chain = api.e.blockchain
+		head  = chain.CurrentBlock()
+	)
+	finalized, _ := engine.GetFinalizedBlock(chain, head.NumberU64(), head.Hash())
+
+	// The votes for the block are included in its child, whose snapshot then
+	// justifies the block
+	justified := false
+	if child := chain.GetHeaderByNumber(blockNumber + 1); child != nil && child.ParentHash == blockHash {
+		justifiedNumber, justifiedHash := engine.GetJustifiedBlock(chain, child.Number.Uint64(), child.Hash())
+		justified = justifiedNumber == blockNumber && justifiedHash == blockHash
+	}
+	return newTransactionConfirmation(blockHash, blockNumber, head.NumberU64(), justified, finalized), nil
+}
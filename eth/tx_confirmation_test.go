@@ -0,0 +1,50 @@
+package eth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestTransactionConfirmation(t *testing.T) {
+	hash := common.HexToHash("0x1")
+	tests := []struct {
+		number, head     uint64
+		justified        bool
+		finalizedNumber  uint64
+		justifiedResult  bool
+		finalized        bool
+		confirmations    uint64
+		blocksToFinality int64 // -1 if unknown
+	}{
+		// No finalized block, e.g. before Shillin
+		{number: 10, head: 12, finalizedNumber: 0, confirmations: 3, blocksToFinality: -1},
+		// Justified but not finalized, the finalized block is 2 blocks behind the head
+		{number: 11, head: 12, justified: true, finalizedNumber: 10, justifiedResult: true, confirmations: 2, blocksToFinality: 1},
+		// The finalized block itself
+		{number: 10, head: 12, justified: true, finalizedNumber: 10, justifiedResult: true, finalized: true, confirmations: 3, blocksToFinality: 0},
+		// An ancestor of the finalized block without its votes included
+		{number: 5, head: 12, finalizedNumber: 10, justifiedResult: true, finalized: true, confirmations: 8, blocksToFinality: 0},
+		// The head
+		{number: 12, head: 12, finalizedNumber: 10, confirmations: 1, blocksToFinality: 2},
+	}
+	for i, tt := range tests {
+		confirmation := newTransactionConfirmation(hash, tt.number, tt.head, tt.justified, tt.finalizedNumber)
+		if confirmation.BlockHash != hash || uint64(confirmation.BlockNumber) != tt.number {
+			t.Errorf("test %d: block mismatch, got %x %d", i, confirmation.BlockHash, confirmation.BlockNumber)
+		}
+		if confirmation.Justified != tt.justifiedResult || confirmation.Finalized != tt.finalized {
+			t.Errorf("test %d: status mismatch, expect justified %v finalized %v got %v %v", i,
+				tt.justifiedResult, tt.finalized, confirmation.Justified, confirmation.Finalized)
+		}
+		if uint64(confirmation.Confirmations) != tt.confirmations {
+			t.Errorf("test %d: confirmations mismatch, expect %d got %d", i, tt.confirmations, confirmation.Confirmations)
+		}
+		switch {
+		case tt.blocksToFinality < 0 && confirmation.BlocksToFinality != nil:
+			t.Errorf("test %d: expect unknown blocks to finality, got %d", i, *confirmation.BlocksToFinality)
+		case tt.blocksToFinality >= 0 && (confirmation.BlocksToFinality == nil || int64(*confirmation.BlocksToFinality) != tt.blocksToFinality):
+			t.Errorf("test %d: blocks to finality mismatch, expect %d got %v", i, tt.blocksToFinality, confirmation.BlocksToFinality)
+		}
+	}
+}
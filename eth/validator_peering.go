@@ -0,0 +1,173 @@
+package eth
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// validatorPeeringRecheck is the interval at which the protected peers are
+// checked against the current validator set
+const validatorPeeringRecheck = time.Minute
+
+var validatorPeerGauge = metrics.NewRegisteredGauge("eth/validatorpeers", nil)
+
+// validatorPeeringConfig is the collection of the callbacks and the address
+// lists of the validator peering.
+type validatorPeeringConfig struct {
+	Self       enode.ID                                          // Node id of the local node
+	Sign       func(data []byte) (common.Address, []byte, error) // Signs with the local sealing key
+	Validators func() []common.Address                           // Validators of the current snapshot
+	Protect    func(node *enode.Node)                            // Keeps the connection to the node beyond the peer limit
+	Unprotect  func(node *enode.Node)                            // Drops the protection and the connection of the node
+
+	Allowlist []common.Address // Validators to protect, any validator if empty
+	Denylist  []common.Address // Validators never protected
+}
+
+type validatorPeer struct {
+	node      *enode.Node
+	validator common.Address
+}
+
+// validatorPeering prioritizes and protects the connections to the other
+// validators so that the vote and block gossip among them survives the peer
+// churn. The validators prove their identity to each other with a signature of
+// their sealing key over the node ids of the connection, see
+// ronin.ValidatorProofPacket. The peers proving to be a validator of the current
+// snapshot are kept connected regardless of the peer limit and redialed when
+// disconnected, until they leave the validator set.
+type validatorPeering struct {
+	config validatorPeeringConfig
+	allow  map[common.Address]struct{}
+	deny   map[common.Address]struct{}
+
+	lock  sync.Mutex
+	peers map[enode.ID]*validatorPeer // Protected peers
+}
+
+func newValidatorPeering(config validatorPeeringConfig) *validatorPeering {
+	peering := &validatorPeering{
+		config: config,
+		allow:  make(map[common.Address]struct{}, len(config.Allowlist)),
+		deny:   make(map[common.Address]struct{}, len(config.Denylist)),
+		peers:  make(map[enode.ID]*validatorPeer),
+	}
+	for _, address := range config.Allowlist {
+		peering.allow[address] = struct{}{}
+	}
+	for _, address := range config.Denylist {
+		peering.deny[address] = struct{}{}
+	}
+	return peering
+}
+
+// allowed returns whether the connections to the validator are protected
+func (v *validatorPeering) allowed(validator common.Address) bool {
+	if _, ok := v.deny[validator]; ok {
+		return false
+	}
+	if len(v.allow) == 0 {
+		return true
+	}
+	_, ok := v.allow[validator]
+	return ok
+}
+
+// sendProof sends the proof that the local node is run by a validator to the
+// peer, it is skipped if no sealing key is authorized.
+func (v *validatorPeering) sendProof(peer *ronin.Peer) {
+	validator, signature, err := v.config.Sign(ronin.ValidatorProofData(v.config.Self, peer.Node().ID()))
+	if err != nil {
+		peer.Log().Trace("Skipping validator proof", "err", err)
+		return
+	}
+	if err := peer.SendValidatorProof(signature); err != nil {
+		peer.Log().Debug("Failed to send validator proof", "err", err)
+		return
+	}
+	peer.Log().Debug("Sent validator proof", "validator", validator)
+}
+
+// handleProof protects the connection to the peer if it proves to be run by an
+// allowed validator of the current snapshot. The peers with an invalid proof
+// are disconnected.
+func (v *validatorPeering) handleProof(peer *ronin.Peer, signature []byte) error {
+	node := peer.Node()
+	validator, err := ronin.RecoverValidator(node.ID(), v.config.Self, signature)
+	if err != nil {
+		return err
+	}
+	if !v.allowed(validator) || !containsAddress(v.config.Validators(), validator) {
+		peer.Log().Debug("Ignoring validator proof", "validator", validator)
+		return nil
+	}
+
+	v.lock.Lock()
+	_, protected := v.peers[node.ID()]
+	v.peers[node.ID()] = &validatorPeer{node: node, validator: validator}
+	validatorPeerGauge.Update(int64(len(v.peers)))
+	v.lock.Unlock()
+
+	if !protected {
+		v.config.Protect(node)
+		peer.Log().Info("Protecting validator peer", "validator", validator)
+	}
+	return nil
+}
+
+// prune drops the protection of the peers which are no longer allowed
+// validators of the current snapshot. Nothing is pruned if the validator set
+// is not available, e.g. before Shillin.
+func (v *validatorPeering) prune() {
+	validators := v.config.Validators()
+	if len(validators) == 0 {
+		return
+	}
+
+	var pruned []*validatorPeer
+	v.lock.Lock()
+	for id, peer := range v.peers {
+		if !v.allowed(peer.validator) || !containsAddress(validators, peer.validator) {
+			delete(v.peers, id)
+			pruned = append(pruned, peer)
+		}
+	}
+	validatorPeerGauge.Update(int64(len(v.peers)))
+	v.lock.Unlock()
+
+	// Unprotecting waits for the peer to be disconnected, keep it out of the lock
+	for _, peer := range pruned {
+		v.config.Unprotect(peer.node)
+		log.Info("Unprotected validator peer", "id", peer.node.ID(), "validator", peer.validator)
+	}
+}
+
+// loop prunes the protected peers periodically until quit is closed
+func (v *validatorPeering) loop(quit <-chan struct{}) {
+	ticker := time.NewTicker(validatorPeeringRecheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			v.prune()
+		case <-quit:
+			return
+		}
+	}
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
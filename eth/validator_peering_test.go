@@ -0,0 +1,80 @@
+package eth
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestValidatorPeering(t *testing.T) {
+	var (
+		self          = enode.ID{0xff}
+		validatorKey  = newTestKey(t)
+		validator     = crypto.PubkeyToAddress(validatorKey.PublicKey)
+		deniedKey     = newTestKey(t)
+		denied        = crypto.PubkeyToAddress(deniedKey.PublicKey)
+		validators    = []common.Address{validator, denied}
+		protected     = make(map[enode.ID]bool)
+		proofSignedBy = func(key *ecdsa.PrivateKey, sender, receiver enode.ID) []byte {
+			signature, err := crypto.Sign(crypto.Keccak256(ronin.ValidatorProofData(sender, receiver)), key)
+			if err != nil {
+				t.Fatalf("Failed to sign validator proof, err %s", err)
+			}
+			return signature
+		}
+	)
+	peering := newValidatorPeering(validatorPeeringConfig{
+		Self:       self,
+		Validators: func() []common.Address { return validators },
+		Protect:    func(node *enode.Node) { protected[node.ID()] = true },
+		Unprotect:  func(node *enode.Node) { delete(protected, node.ID()) },
+		Denylist:   []common.Address{denied},
+	})
+
+	peers := make([]*ronin.Peer, 4)
+	for i := range peers {
+		peers[i] = ronin.NewPeer(ronin.Ronin4, p2p.NewPeer(enode.ID{byte(i)}, "", nil), nil)
+		defer peers[i].Close()
+	}
+	if err := peering.handleProof(peers[0], proofSignedBy(validatorKey, peers[0].Node().ID(), self)); err != nil {
+		t.Fatalf("Failed to handle validator proof, err %s", err)
+	}
+	// The proof of another connection recovers another address
+	if err := peering.handleProof(peers[1], proofSignedBy(validatorKey, peers[0].Node().ID(), self)); err != nil {
+		t.Fatalf("Failed to handle validator proof, err %s", err)
+	}
+	if err := peering.handleProof(peers[2], proofSignedBy(deniedKey, peers[2].Node().ID(), self)); err != nil {
+		t.Fatalf("Failed to handle validator proof, err %s", err)
+	}
+	if err := peering.handleProof(peers[3], make([]byte, crypto.SignatureLength)); err == nil {
+		t.Fatalf("Expect error for invalid signature")
+	}
+	if len(protected) != 1 || !protected[peers[0].Node().ID()] {
+		t.Fatalf("Expect only the validator peer to be protected, got %v", protected)
+	}
+
+	// The protection is kept while the validator set is unavailable
+	validators = nil
+	peering.prune()
+	if len(protected) != 1 {
+		t.Fatalf("Expect the validator peer to be protected")
+	}
+	validators = []common.Address{denied}
+	peering.prune()
+	if len(protected) != 0 || len(peering.peers) != 0 {
+		t.Fatalf("Expect the peer leaving the validator set to be unprotected")
+	}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key, err %s", err)
+	}
+	return key
+}
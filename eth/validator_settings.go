@@ -0,0 +1,108 @@
+package eth
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/consortium"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	errVotingDisabled  = errors.New("voting is disabled")
+	errSealingDisabled = errors.New("sealing is disabled")
+)
+
+// ValidatorSettings are the operational settings of a consortium validator that
+// can be changed while the node is running, so the validator does not miss its
+// turns to a restart. The nil fields are left unchanged by an update.
+type ValidatorSettings struct {
+	VoteAssemblyWindow     *time.Duration
+	AllowedFutureBlockTime *time.Duration
+	DisableVoting          *bool
+	DisableSealing         *bool
+}
+
+// ValidatorSettings returns the current validator settings, all fields are set
+func (s *Ethereum) ValidatorSettings() (ValidatorSettings, error) {
+	c, ok := s.engine.(*consortium.Consortium)
+	if !ok {
+		return ValidatorSettings{}, errors.New("validator settings require the consortium engine")
+	}
+	var (
+		window         = c.VoteAssemblyWindow()
+		drift          = c.AllowedFutureBlockTime()
+		disableVoting  = atomic.LoadInt32(&s.votingDisabled) == 1
+		disableSealing = atomic.LoadInt32(&s.sealingDisabled) == 1
+	)
+	return ValidatorSettings{
+		VoteAssemblyWindow:     &window,
+		AllowedFutureBlockTime: &drift,
+		DisableVoting:          &disableVoting,
+		DisableSealing:         &disableSealing,
+	}, nil
+}
+
+// UpdateValidatorSettings applies the set fields of the validator settings, the
+// durations are capped by the engine as when the node starts
+func (s *Ethereum) UpdateValidatorSettings(settings ValidatorSettings) error {
+	c, ok := s.engine.(*consortium.Consortium)
+	if !ok {
+		return errors.New("validator settings require the consortium engine")
+	}
+	if settings.VoteAssemblyWindow != nil && *settings.VoteAssemblyWindow < 0 {
+		return errors.New("negative vote assembly window")
+	}
+	if settings.AllowedFutureBlockTime != nil && *settings.AllowedFutureBlockTime < 0 {
+		return errors.New("negative allowed future block time")
+	}
+
+	if settings.VoteAssemblyWindow != nil {
+		c.SetVoteAssemblyWindow(*settings.VoteAssemblyWindow)
+	}
+	if settings.AllowedFutureBlockTime != nil {
+		c.SetAllowedFutureBlockTime(*settings.AllowedFutureBlockTime)
+	}
+	if settings.DisableVoting != nil {
+		setFlag(&s.votingDisabled, *settings.DisableVoting)
+	}
+	if settings.DisableSealing != nil {
+		wasDisabled := setFlag(&s.sealingDisabled, *settings.DisableSealing)
+		// The miner waits for the next head after a refused seal, restart the
+		// sealing of the pending block as the validator may be the only one
+		if wasDisabled && !*settings.DisableSealing && s.IsMining() {
+			if etherbase, err := s.Etherbase(); err == nil {
+				s.miner.Start(etherbase)
+			}
+		}
+	}
+	log.Info("Updated validator settings", "voteAssemblyWindow", c.VoteAssemblyWindow(), "allowedFutureBlockTime", c.AllowedFutureBlockTime(),
+		"disableVoting", atomic.LoadInt32(&s.votingDisabled) == 1, "disableSealing", atomic.LoadInt32(&s.sealingDisabled) == 1)
+	return nil
+}
+
+// checkVotingEnabled returns an error if the voting is disabled by the settings
+func (s *Ethereum) checkVotingEnabled() error {
+	if atomic.LoadInt32(&s.votingDisabled) == 1 {
+		return errVotingDisabled
+	}
+	return nil
+}
+
+// checkSealingEnabled returns an error if the sealing is disabled by the settings
+func (s *Ethereum) checkSealingEnabled() error {
+	if atomic.LoadInt32(&s.sealingDisabled) == 1 {
+		return errSealingDisabled
+	}
+	return nil
+}
+
+// setFlag sets the atomic flag and returns its previous value
+func setFlag(flag *int32, enabled bool) bool {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+	return atomic.SwapInt32(flag, value) == 1
+}
@@ -0,0 +1,174 @@
+package eth
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// minVoteFanout is the minimum number of peers receiving the direct vote pushes
+	minVoteFanout = 4
+
+	// voteFirstSeenExpiry is how long the first seen time of a vote is kept to
+	// measure the delivery delay of the peers
+	voteFirstSeenExpiry = time.Minute
+
+	// validatorPeerExpiry is how long a peer is considered as a validator after
+	// it is the first to deliver a vote
+	validatorPeerExpiry = 30 * time.Second
+
+	// voteLatencyWeight is the weight of a new sample in the moving average of
+	// the peer delivery delay
+	voteLatencyWeight = 0.2
+)
+
+var (
+	voteFanoutGauge          = metrics.NewRegisteredGauge("eth/vote/fanout", nil)
+	voteFanoutValidatorGauge = metrics.NewRegisteredGauge("eth/vote/fanout/validators", nil)
+	voteDirectMeter          = metrics.NewRegisteredMeter("eth/vote/direct", nil)
+	voteBatchedMeter         = metrics.NewRegisteredMeter("eth/vote/batched", nil)
+	voteDeliveryTimer        = metrics.NewRegisteredTimer("eth/vote/delivery", nil)
+	aggregatedVoteMeter      = metrics.NewRegisteredMeter("eth/vote/aggregated", nil)
+)
+
+type voteFanoutPeer struct {
+	latency        time.Duration // Moving average of the delay after the vote is first seen
+	samples        uint64
+	firstDelivered time.Time // Last time the peer is the first to deliver a vote
+}
+
+// voteFanout selects the peers that receive the finality votes directly without
+// batching. A peer which is the first to deliver a vote is most likely the voting
+// validator or its sentry, so it is always selected while it keeps delivering
+// the votes first. The rest of the fanout is filled with the peers delivering
+// the votes with the lowest delay.
+type voteFanout struct {
+	lock      sync.Mutex
+	firstSeen map[common.Hash]time.Time
+	peers     map[string]*voteFanoutPeer
+	lastPrune time.Time
+}
+
+func newVoteFanout() *voteFanout {
+	return &voteFanout{
+		firstSeen: make(map[common.Hash]time.Time),
+		peers:     make(map[string]*voteFanoutPeer),
+	}
+}
+
+// markVote records the delivery of the vote by the peer, the peer is empty for
+// the votes created locally
+func (f *voteFanout) markVote(peer string, hash common.Hash, now time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if now.Sub(f.lastPrune) > voteFirstSeenExpiry {
+		for hash, seen := range f.firstSeen {
+			if now.Sub(seen) > voteFirstSeenExpiry {
+				delete(f.firstSeen, hash)
+			}
+		}
+		f.lastPrune = now
+	}
+
+	first, seen := f.firstSeen[hash]
+	if !seen {
+		f.firstSeen[hash] = now
+	}
+	if peer == "" {
+		return
+	}
+	stats, ok := f.peers[peer]
+	if !ok {
+		stats = &voteFanoutPeer{}
+		f.peers[peer] = stats
+	}
+	if !seen {
+		stats.firstDelivered = now
+		first = now
+	}
+	delay := now.Sub(first)
+	voteDeliveryTimer.Update(delay)
+	if stats.samples == 0 {
+		stats.latency = delay
+	} else {
+		stats.latency = time.Duration(voteLatencyWeight*float64(delay) + (1-voteLatencyWeight)*float64(stats.latency))
+	}
+	stats.samples++
+}
+
+// removePeer drops the stats of the disconnected peer
+func (f *voteFanout) removePeer(peer string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.peers, peer)
+}
+
+// split returns the peers that receive the vote directly and the ones that
+// receive the vote in batch. The fanout is the square root of the peers but at
+// least minVoteFanout, extended to include all the validator peers.
+func (f *voteFanout) split(peers []*ronin.Peer, now time.Time) ([]*ronin.Peer, []*ronin.Peer) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	fanout := int(math.Sqrt(float64(len(peers))))
+	if fanout < minVoteFanout {
+		fanout = minVoteFanout
+	}
+	if fanout >= len(peers) {
+		voteFanoutGauge.Update(int64(len(peers)))
+		return peers, nil
+	}
+
+	var (
+		direct     []*ronin.Peer
+		candidates []*ronin.Peer
+	)
+	for _, peer := range peers {
+		if stats, ok := f.peers[peer.ID()]; ok && now.Sub(stats.firstDelivered) <= validatorPeerExpiry {
+			direct = append(direct, peer)
+		} else {
+			candidates = append(candidates, peer)
+		}
+	}
+	voteFanoutValidatorGauge.Update(int64(len(direct)))
+
+	// The peers without any sample are sorted last
+	sort.SliceStable(candidates, func(i, j int) bool {
+		left, right := f.peers[candidates[i].ID()], f.peers[candidates[j].ID()]
+		if left == nil || left.samples == 0 {
+			return false
+		}
+		if right == nil || right.samples == 0 {
+			return true
+		}
+		return left.latency < right.latency
+	})
+	remain := fanout - len(direct)
+	if remain < 0 {
+		remain = 0
+	}
+	if remain > len(candidates) {
+		remain = len(candidates)
+	}
+	direct = append(direct, candidates[:remain]...)
+	voteFanoutGauge.Update(int64(len(direct)))
+	return direct, candidates[remain:]
+}
+
+// isValidator returns whether the peer is the first to deliver a vote recently,
+// i.e. most likely a validator or its sentry
+func (f *voteFanout) isValidator(peer string, now time.Time) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	stats, ok := f.peers[peer]
+	return ok && now.Sub(stats.firstDelivered) <= validatorPeerExpiry
+}
@@ -0,0 +1,53 @@
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/protocols/ronin"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestVoteFanoutSplit(t *testing.T) {
+	const peerCount = 25
+	peers := make([]*ronin.Peer, peerCount)
+	for i := range peers {
+		peers[i] = ronin.NewPeer(ronin.Ronin1, p2p.NewPeer(enode.ID{byte(i)}, "", nil), nil)
+		defer peers[i].Close()
+	}
+
+	fanout := newVoteFanout()
+	now := time.Now()
+	// peers[10] delivers a vote first, peers[20] and peers[3] deliver it later
+	fanout.markVote(peers[10].ID(), common.Hash{0x1}, now)
+	fanout.markVote(peers[20].ID(), common.Hash{0x1}, now.Add(10*time.Millisecond))
+	fanout.markVote(peers[3].ID(), common.Hash{0x1}, now.Add(50*time.Millisecond))
+
+	direct, batched := fanout.split(peers, now)
+	if len(direct) != 5 || len(batched) != peerCount-5 {
+		t.Fatalf("Fanout mismatch, exp 5 got %d", len(direct))
+	}
+	if direct[0] != peers[10] || direct[1] != peers[20] || direct[2] != peers[3] {
+		t.Fatalf("Expect the validator peer and the fastest peers first, got %s %s %s",
+			direct[0].ID(), direct[1].ID(), direct[2].ID())
+	}
+
+	// The validator peer expires
+	direct, _ = fanout.split(peers, now.Add(2*validatorPeerExpiry))
+	if direct[0] != peers[10] || direct[1] != peers[20] || direct[2] != peers[3] {
+		t.Fatalf("Expect the peers sorted by delivery delay")
+	}
+
+	fanout.removePeer(peers[10].ID())
+	direct, _ = fanout.split(peers, now)
+	if direct[0] != peers[20] || direct[1] != peers[3] {
+		t.Fatalf("Expect the removed peer not to be prioritized")
+	}
+
+	direct, batched = fanout.split(peers[:3], now)
+	if len(direct) != 3 || len(batched) != 0 {
+		t.Fatalf("Expect all peers to be direct when below the minimum fanout")
+	}
+}
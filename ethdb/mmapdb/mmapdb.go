@@ -0,0 +1,401 @@
+// Package mmapdb implements an append-only key-value store on a memory mapped
+// file. It is meant for small, rarely rewritten data that should be kept out of
+// the main chain database to reduce its write amplification, it only stores the
+// consortium snapshots. The whole index is kept in memory. Each write is flushed
+// to disk before it returns and the overwritten records are compacted away
+// before the file grows.
+package mmapdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/edsrzf/mmap-go"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// initialSize is the initial size of the data file, it is doubled whenever
+	// the file is full
+	initialSize = 16 * 1024 * 1024
+
+	// headerSize is the size of the record header: key length and value length
+	headerSize = 8
+
+	// tombstone is the value length of a deleted key
+	tombstone = ^uint32(0)
+
+	dataFileName = "data.mmap"
+
+	// compactFileName is the file the live records are compacted to before it
+	// replaces the data file
+	compactFileName = "data.mmap.compact"
+)
+
+var (
+	errClosed   = errors.New("database closed")
+	errNotFound = errors.New("not found")
+	errCorrupt  = errors.New("corrupted data file")
+)
+
+type location struct {
+	offset int // Offset of the value in the data file
+	length int
+}
+
+// Database is a key-value store that appends the records to a memory mapped file.
+// The records are written as [key length][value length][key][value], the unused
+// tail of the file is zero filled so a zero key length marks the end of the data.
+type Database struct {
+	lock    sync.RWMutex
+	dir     string
+	file    *os.File
+	data    mmap.MMap
+	end     int // Offset to append the next record
+	garbage int // Size of the overwritten and deleted records before end
+	index   map[string]location
+}
+
+// New opens or creates the store in the directory and rebuilds the index from
+// the records in the data file
+func New(dir string) (*Database, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	// The data file is only replaced once the compaction is complete, a leftover
+	// compaction file is incomplete
+	if err := os.Remove(filepath.Join(dir, compactFileName)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, dataFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.Size() < initialSize {
+		if err := file.Truncate(initialSize); err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	data, err := mmap.Map(file, mmap.RDWR, 0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	db := &Database{
+		dir:   dir,
+		file:  file,
+		data:  data,
+		index: make(map[string]location),
+	}
+	if err := db.load(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// load rebuilds the index from the records, the later records override the
+// earlier ones with the same key
+func (db *Database) load() error {
+	for db.end+headerSize <= len(db.data) {
+		keyLen := int(binary.BigEndian.Uint32(db.data[db.end:]))
+		if keyLen == 0 {
+			return nil
+		}
+		valueLen := binary.BigEndian.Uint32(db.data[db.end+4:])
+		keyOffset := db.end + headerSize
+		if keyOffset+keyLen > len(db.data) {
+			return errCorrupt
+		}
+		key := string(db.data[keyOffset : keyOffset+keyLen])
+		if old, ok := db.index[key]; ok {
+			db.garbage += recordSize(len(key), old.length)
+		}
+		if valueLen == tombstone {
+			delete(db.index, key)
+			db.end = keyOffset + keyLen
+			db.garbage += recordSize(len(key), 0)
+			continue
+		}
+		valueOffset := keyOffset + keyLen
+		if valueOffset+int(valueLen) > len(db.data) {
+			return errCorrupt
+		}
+		db.index[key] = location{offset: valueOffset, length: int(valueLen)}
+		db.end = valueOffset + int(valueLen)
+	}
+	return nil
+}
+
+// Close flushes and unmaps the data file
+func (db *Database) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.file == nil {
+		return nil
+	}
+	var err error
+	if db.data != nil {
+		err = db.data.Flush()
+		if unmapErr := db.data.Unmap(); err == nil {
+			err = unmapErr
+		}
+	}
+	if closeErr := db.file.Close(); err == nil {
+		err = closeErr
+	}
+	db.file, db.data, db.index = nil, nil, nil
+	return err
+}
+
+// Has retrieves if a key is present in the key-value store.
+func (db *Database) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.data == nil {
+		return false, errClosed
+	}
+	_, ok := db.index[string(key)]
+	return ok, nil
+}
+
+// Get retrieves the given key if it's present in the key-value store.
+func (db *Database) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.data == nil {
+		return nil, errClosed
+	}
+	if loc, ok := db.index[string(key)]; ok {
+		return common.CopyBytes(db.data[loc.offset : loc.offset+loc.length]), nil
+	}
+	return nil, errNotFound
+}
+
+// Keys returns the keys present in the key-value store in ascending order.
+func (db *Database) Keys() ([][]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	if db.data == nil {
+		return nil, errClosed
+	}
+	keys := make([][]byte, 0, len(db.index))
+	for key := range db.index {
+		keys = append(keys, []byte(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys, nil
+}
+
+// Put inserts the given value into the key-value store.
+func (db *Database) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.data == nil {
+		return errClosed
+	}
+	if len(key) == 0 {
+		return errors.New("empty key")
+	}
+	offset, err := db.append(key, uint32(len(value)), value)
+	if err != nil {
+		return err
+	}
+	if old, ok := db.index[string(key)]; ok {
+		db.garbage += recordSize(len(key), old.length)
+	}
+	db.index[string(key)] = location{offset: offset, length: len(value)}
+	return nil
+}
+
+// Delete removes the key from the key-value store.
+func (db *Database) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.data == nil {
+		return errClosed
+	}
+	old, ok := db.index[string(key)]
+	if !ok {
+		return nil
+	}
+	if _, err := db.append(key, tombstone, nil); err != nil {
+		return err
+	}
+	delete(db.index, string(key))
+	db.garbage += recordSize(len(key), old.length) + recordSize(len(key), 0)
+	return nil
+}
+
+// recordSize returns the size of the record of the key and value lengths
+func recordSize(keyLen, valueLen int) int {
+	return headerSize + keyLen + valueLen
+}
+
+// append writes the record, flushes it to disk and returns the offset of the
+// value. The caller must hold the write lock.
+func (db *Database) append(key []byte, valueLen uint32, value []byte) (int, error) {
+	size := recordSize(len(key), len(value))
+	if err := db.ensure(size); err != nil {
+		return 0, err
+	}
+	offset := writeRecord(db.data, db.end, key, valueLen, value, false)
+	// Flush the key and value before the key length, the record is only visible
+	// to the next load once its key length is set
+	if err := db.data.Flush(); err != nil {
+		return 0, err
+	}
+	binary.BigEndian.PutUint32(db.data[db.end:], uint32(len(key)))
+	if err := db.data.Flush(); err != nil {
+		return 0, err
+	}
+	db.end += size
+	return offset, nil
+}
+
+// writeRecord writes the record at the offset of the data and returns the offset
+// of the value. The key length is only written if complete is set.
+func writeRecord(data mmap.MMap, at int, key []byte, valueLen uint32, value []byte, complete bool) int {
+	binary.BigEndian.PutUint32(data[at+4:], valueLen)
+	copy(data[at+headerSize:], key)
+	offset := at + headerSize + len(key)
+	copy(data[offset:], value)
+	if complete {
+		binary.BigEndian.PutUint32(data[at:], uint32(len(key)))
+	}
+	return offset
+}
+
+// fileSize returns the size of the data file holding size bytes of records, a
+// zero key length is always left after the last record
+func fileSize(current, size int) int {
+	newSize := current
+	for newSize < size+headerSize {
+		newSize *= 2
+	}
+	return newSize
+}
+
+// ensure makes room for a record of the size after the last record, either by
+// compacting the data file if half of it is garbage or by growing it. The
+// caller must hold the write lock.
+func (db *Database) ensure(size int) error {
+	if db.end+size+headerSize <= len(db.data) {
+		return nil
+	}
+	if db.garbage*2 >= db.end {
+		return db.compact(size)
+	}
+	newSize := fileSize(len(db.data), db.end+size)
+	if err := db.data.Flush(); err != nil {
+		return err
+	}
+	if err := db.data.Unmap(); err != nil {
+		return err
+	}
+	// The old mapping is gone, the database can no longer be used on failure
+	db.data = nil
+	if err := db.file.Truncate(int64(newSize)); err != nil {
+		return err
+	}
+	// The records flushed later are lost on crash if the file size is not
+	if err := db.file.Sync(); err != nil {
+		return err
+	}
+	data, err := mmap.Map(db.file, mmap.RDWR, 0)
+	if err != nil {
+		return err
+	}
+	db.data = data
+	return nil
+}
+
+// compact rewrites the live records to a new data file with room for reserve
+// more bytes and replaces the data file with it. The new file is synced before
+// it is renamed over the data file so a crash leaves either file complete. The
+// caller must hold the write lock.
+func (db *Database) compact(reserve int) error {
+	keys := make([]string, 0, len(db.index))
+	live := 0
+	for key, loc := range db.index {
+		keys = append(keys, key)
+		live += recordSize(len(key), loc.length)
+	}
+	sort.Strings(keys)
+
+	path := filepath.Join(db.dir, compactFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(int64(fileSize(initialSize, live+reserve))); err != nil {
+		file.Close()
+		return err
+	}
+	data, err := mmap.Map(file, mmap.RDWR, 0)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	var (
+		end   int
+		index = make(map[string]location, len(db.index))
+	)
+	for _, key := range keys {
+		loc := db.index[key]
+		value := db.data[loc.offset : loc.offset+loc.length]
+		offset := writeRecord(data, end, []byte(key), uint32(loc.length), value, true)
+		index[key] = location{offset: offset, length: loc.length}
+		end += recordSize(len(key), loc.length)
+	}
+	if err := data.Flush(); err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		data.Unmap()
+		file.Close()
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(db.dir, dataFileName)); err != nil {
+		data.Unmap()
+		file.Close()
+		return err
+	}
+	syncDir(db.dir)
+
+	// The old data file is replaced, its mapping is dropped
+	db.data.Unmap()
+	db.file.Close()
+	db.file, db.data, db.index, db.end, db.garbage = file, data, index, end, 0
+	return nil
+}
+
+// syncDir syncs the directory so that the rename of the data file is durable
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,122 @@
+package mmapdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapDBReopen(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database, err %s", err)
+	}
+
+	large := bytes.Repeat([]byte{0x1}, initialSize)
+	if err := db.Put([]byte("a"), []byte("1")); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Put([]byte("b"), []byte("2")); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Put([]byte("a"), []byte("3")); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Delete([]byte("b")); err != nil {
+		t.Fatalf("Failed to delete, err %s", err)
+	}
+	// Grow the data file
+	if err := db.Put([]byte("c"), large); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database, err %s", err)
+	}
+
+	db, err = New(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database, err %s", err)
+	}
+	defer db.Close()
+	if value, err := db.Get([]byte("a")); err != nil || !bytes.Equal(value, []byte("3")) {
+		t.Fatalf("Value mismatch, exp 3 got %s err %v", value, err)
+	}
+	if has, _ := db.Has([]byte("b")); has {
+		t.Fatalf("Expect deleted key to be absent")
+	}
+	if value, err := db.Get([]byte("c")); err != nil || !bytes.Equal(value, large) {
+		t.Fatalf("Large value mismatch, err %v", err)
+	}
+	if keys, err := db.Keys(); err != nil || len(keys) != 2 || string(keys[0]) != "a" || string(keys[1]) != "c" {
+		t.Fatalf("Keys mismatch, exp [a c] got %s err %v", keys, err)
+	}
+}
+
+func TestMmapDBCompaction(t *testing.T) {
+	dir := t.TempDir()
+	// A leftover compaction file is dropped on open
+	if err := os.WriteFile(filepath.Join(dir, compactFileName), []byte("partial"), 0644); err != nil {
+		t.Fatalf("Failed to write compaction file, err %s", err)
+	}
+	db, err := New(dir)
+	if err != nil {
+		t.Fatalf("Failed to open database, err %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, compactFileName)); !os.IsNotExist(err) {
+		t.Fatalf("Expect the compaction file to be removed, err %v", err)
+	}
+
+	if err := db.Put([]byte("b"), []byte("2")); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Put([]byte("c"), []byte("3")); err != nil {
+		t.Fatalf("Failed to put, err %s", err)
+	}
+	if err := db.Delete([]byte("c")); err != nil {
+		t.Fatalf("Failed to delete, err %s", err)
+	}
+	// Overwriting the value fills the data file many times over, the overwritten
+	// records are compacted away instead of growing the file
+	var value []byte
+	for i := 0; i < 16; i++ {
+		value = bytes.Repeat([]byte{byte(i)}, initialSize/4)
+		if err := db.Put([]byte("a"), value); err != nil {
+			t.Fatalf("Failed to put, err %s", err)
+		}
+	}
+	if got, err := db.Get([]byte("a")); err != nil || !bytes.Equal(got, value) {
+		t.Fatalf("Value mismatch before reopen, err %v", err)
+	}
+	garbage := db.garbage
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database, err %s", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, dataFileName))
+	if err != nil {
+		t.Fatalf("Failed to stat data file, err %s", err)
+	}
+	if info.Size() != initialSize {
+		t.Fatalf("Data file size mismatch, exp %d got %d", initialSize, info.Size())
+	}
+
+	db, err = New(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen database, err %s", err)
+	}
+	defer db.Close()
+	if got, err := db.Get([]byte("a")); err != nil || !bytes.Equal(got, value) {
+		t.Fatalf("Value mismatch after reopen, err %v", err)
+	}
+	if got, err := db.Get([]byte("b")); err != nil || !bytes.Equal(got, []byte("2")) {
+		t.Fatalf("Value mismatch, exp 2 got %s err %v", got, err)
+	}
+	if keys, err := db.Keys(); err != nil || len(keys) != 2 {
+		t.Fatalf("Keys mismatch, exp [a b] got %s err %v", keys, err)
+	}
+	// The garbage after the last compaction is accounted on load
+	if db.garbage != garbage {
+		t.Fatalf("Garbage mismatch, exp %d got %d", garbage, db.garbage)
+	}
+}
@@ -0,0 +1,168 @@
+package firehose
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers of the messages in firehose.proto
+const (
+	eventBlock    protowire.Number = 1
+	eventUndo     protowire.Number = 2
+	eventFinality protowire.Number = 3
+
+	blockNumber       protowire.Number = 1
+	blockHash         protowire.Number = 2
+	blockParentHash   protowire.Number = 3
+	blockTime         protowire.Number = 4
+	blockCoinbase     protowire.Number = 5
+	blockGasLimit     protowire.Number = 6
+	blockGasUsed      protowire.Number = 7
+	blockStateRoot    protowire.Number = 8
+	blockHeaderRLP    protowire.Number = 9
+	blockTransactions protowire.Number = 10
+
+	txHash    protowire.Number = 1
+	txRaw     protowire.Number = 2
+	txFrom    protowire.Number = 3
+	txSystem  protowire.Number = 4
+	txReceipt protowire.Number = 5
+
+	receiptStatus            protowire.Number = 1
+	receiptCumulativeGasUsed protowire.Number = 2
+	receiptGasUsed           protowire.Number = 3
+	receiptContractAddress   protowire.Number = 4
+	receiptLogs              protowire.Number = 5
+
+	logAddress protowire.Number = 1
+	logTopics  protowire.Number = 2
+	logData    protowire.Number = 3
+	logIndex   protowire.Number = 4
+
+	// The fields of Undo and Finality
+	refNumber protowire.Number = 1
+	refHash   protowire.Number = 2
+)
+
+// The proto3 scalar fields with the default value are omitted
+
+func appendUint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, protowire.EncodeBool(v))
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	return appendMessage(b, num, v)
+}
+
+// appendMessage appends the length delimited field, it is always written so
+// that an empty message can be told apart from a missing one
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+// transaction is a transaction with the data not available from the block
+type transaction struct {
+	tx      *types.Transaction
+	from    common.Address
+	system  bool
+	receipt *types.Receipt
+}
+
+func encodeLog(l *types.Log) []byte {
+	var b []byte
+	b = appendBytes(b, logAddress, l.Address.Bytes())
+	for _, topic := range l.Topics {
+		b = appendMessage(b, logTopics, topic.Bytes())
+	}
+	b = appendBytes(b, logData, l.Data)
+	return appendUint(b, logIndex, uint64(l.Index))
+}
+
+func encodeReceipt(receipt *types.Receipt) []byte {
+	var b []byte
+	b = appendUint(b, receiptStatus, receipt.Status)
+	b = appendUint(b, receiptCumulativeGasUsed, receipt.CumulativeGasUsed)
+	b = appendUint(b, receiptGasUsed, receipt.GasUsed)
+	if receipt.ContractAddress != (common.Address{}) {
+		b = appendBytes(b, receiptContractAddress, receipt.ContractAddress.Bytes())
+	}
+	for _, l := range receipt.Logs {
+		b = appendMessage(b, receiptLogs, encodeLog(l))
+	}
+	return b
+}
+
+func encodeTransaction(tx *transaction) ([]byte, error) {
+	raw, err := tx.tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = appendBytes(b, txHash, tx.tx.Hash().Bytes())
+	b = appendBytes(b, txRaw, raw)
+	b = appendBytes(b, txFrom, tx.from.Bytes())
+	b = appendBool(b, txSystem, tx.system)
+	if tx.receipt != nil {
+		b = appendMessage(b, txReceipt, encodeReceipt(tx.receipt))
+	}
+	return b, nil
+}
+
+// encodeBlockEvent returns the Event message of the canonical block
+func encodeBlockEvent(block *types.Block, txs []*transaction) ([]byte, error) {
+	header := block.Header()
+	headerRLP, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = appendUint(b, blockNumber, header.Number.Uint64())
+	b = appendBytes(b, blockHash, block.Hash().Bytes())
+	b = appendBytes(b, blockParentHash, header.ParentHash.Bytes())
+	b = appendUint(b, blockTime, header.Time)
+	b = appendBytes(b, blockCoinbase, header.Coinbase.Bytes())
+	b = appendUint(b, blockGasLimit, header.GasLimit)
+	b = appendUint(b, blockGasUsed, header.GasUsed)
+	b = appendBytes(b, blockStateRoot, header.Root.Bytes())
+	b = appendBytes(b, blockHeaderRLP, headerRLP)
+	for _, tx := range txs {
+		encoded, err := encodeTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		b = appendMessage(b, blockTransactions, encoded)
+	}
+	return appendMessage(nil, eventBlock, b), nil
+}
+
+// encodeRefEvent returns the Undo or Finality Event message of the block
+func encodeRefEvent(field protowire.Number, number uint64, hash common.Hash) []byte {
+	var b []byte
+	b = appendUint(b, refNumber, number)
+	b = appendBytes(b, refHash, hash.Bytes())
+	return appendMessage(nil, field, b)
+}
+
+// frame prefixes the message with its length
+func frame(msg []byte) []byte {
+	b := protowire.AppendVarint(make([]byte, 0, len(msg)+protowire.SizeVarint(uint64(len(msg)))), uint64(len(msg)))
+	return append(b, msg...)
+}
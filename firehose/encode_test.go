@@ -0,0 +1,105 @@
+package firehose
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// decodeFields returns the length delimited fields and the varint fields of the message
+func decodeFields(t *testing.T, msg []byte) (map[protowire.Number][][]byte, map[protowire.Number]uint64) {
+	messages := make(map[protowire.Number][][]byte)
+	varints := make(map[protowire.Number]uint64)
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			t.Fatalf("Failed to decode tag, err %s", protowire.ParseError(n))
+		}
+		msg = msg[n:]
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(msg)
+			if n < 0 {
+				t.Fatalf("Failed to decode field %d, err %s", num, protowire.ParseError(n))
+			}
+			messages[num] = append(messages[num], v)
+			msg = msg[n:]
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(msg)
+			if n < 0 {
+				t.Fatalf("Failed to decode field %d, err %s", num, protowire.ParseError(n))
+			}
+			varints[num] = v
+			msg = msg[n:]
+		default:
+			t.Fatalf("Unexpected wire type %d of field %d", typ, num)
+		}
+	}
+	return messages, varints
+}
+
+func TestEncodeBlockEvent(t *testing.T) {
+	header := &types.Header{
+		Number:   big.NewInt(100),
+		Time:     1000,
+		Coinbase: common.HexToAddress("0x1"),
+		GasUsed:  21000,
+	}
+	tx := types.NewTransaction(0, common.HexToAddress("0x2"), big.NewInt(1), 21000, big.NewInt(0), nil)
+	block := types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil)
+	receipt := &types.Receipt{
+		Status:  types.ReceiptStatusSuccessful,
+		GasUsed: 21000,
+		Logs:    []*types.Log{{Address: common.HexToAddress("0x3"), Topics: []common.Hash{{0x1}}}},
+	}
+
+	msg, err := encodeBlockEvent(block, []*transaction{{tx: tx, from: common.HexToAddress("0x4"), system: true, receipt: receipt}})
+	if err != nil {
+		t.Fatalf("Failed to encode block event, err %s", err)
+	}
+	event, _ := decodeFields(t, msg)
+	if len(event[eventBlock]) != 1 {
+		t.Fatalf("Expect a block event")
+	}
+	fields, varints := decodeFields(t, event[eventBlock][0])
+	if varints[blockNumber] != 100 || varints[blockTime] != 1000 || varints[blockGasUsed] != 21000 {
+		t.Fatalf("Unexpected block fields %v", varints)
+	}
+	if !bytes.Equal(fields[blockHash][0], block.Hash().Bytes()) {
+		t.Fatalf("Block hash mismatch")
+	}
+	if len(fields[blockTransactions]) != 1 {
+		t.Fatalf("Expect 1 transaction, got %d", len(fields[blockTransactions]))
+	}
+
+	txFields, txVarints := decodeFields(t, fields[blockTransactions][0])
+	if !bytes.Equal(txFields[txHash][0], tx.Hash().Bytes()) {
+		t.Fatalf("Transaction hash mismatch")
+	}
+	if !bytes.Equal(txFields[txFrom][0], common.HexToAddress("0x4").Bytes()) {
+		t.Fatalf("Transaction sender mismatch")
+	}
+	if txVarints[txSystem] != 1 {
+		t.Fatalf("Expect a system transaction")
+	}
+	receiptFields, receiptVarints := decodeFields(t, txFields[txReceipt][0])
+	if receiptVarints[receiptStatus] != types.ReceiptStatusSuccessful || receiptVarints[receiptGasUsed] != 21000 {
+		t.Fatalf("Unexpected receipt fields %v", receiptVarints)
+	}
+	if len(receiptFields[receiptLogs]) != 1 {
+		t.Fatalf("Expect 1 log, got %d", len(receiptFields[receiptLogs]))
+	}
+}
+
+func TestFrame(t *testing.T) {
+	msg := encodeRefEvent(eventFinality, 10, common.Hash{0x1})
+	framed := frame(msg)
+	length, n := protowire.ConsumeVarint(framed)
+	if n < 0 || int(length) != len(msg) || !bytes.Equal(framed[n:], msg) {
+		t.Fatalf("Invalid frame")
+	}
+}
@@ -0,0 +1,243 @@
+// Package firehose implements a streaming exporter of the chain data. The
+// canonical blocks with their receipts, the reverted blocks and the finality
+// decisions are pushed as protobuf messages (see firehose.proto) to the clients
+// connected to a local socket as they occur, so that the data pipelines do not
+// need to poll the RPC.
+package firehose
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	chainEventChanSize = 64
+	reorgEventChanSize = 64
+
+	// clientQueueSize is the number of messages queued for a client, a client
+	// falling further behind is disconnected and has to reconnect
+	clientQueueSize = 1024
+
+	writeTimeout = 10 * time.Second
+)
+
+// blockChain is the chain the events are streamed from. The chain must send the
+// additional chain events so that the blocks added by a reorg are streamed too.
+type blockChain interface {
+	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+	SubscribeReorgEvent(ch chan<- core.ReorgEvent) event.Subscription
+	Config() *params.ChainConfig
+	Engine() consensus.Engine
+}
+
+type client struct {
+	conn  net.Conn
+	queue chan []byte
+}
+
+// Service streams the chain events to the clients of a unix socket
+type Service struct {
+	chain    blockChain
+	endpoint string
+
+	listener net.Listener
+	chainSub event.Subscription
+	reorgSub event.Subscription
+
+	lock      sync.Mutex
+	clients   map[*client]struct{}
+	finalized uint64 // The last streamed finalized block
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates the service streaming to the socket at endpoint and registers it
+// to the node
+func New(stack *node.Node, chain blockChain, endpoint string) error {
+	if endpoint == "" {
+		return errors.New("empty firehose endpoint")
+	}
+	service := &Service{
+		chain:    chain,
+		endpoint: stack.ResolvePath(endpoint),
+		clients:  make(map[*client]struct{}),
+		quit:     make(chan struct{}),
+	}
+	stack.RegisterLifecycle(service)
+	return nil
+}
+
+// Start implements node.Lifecycle, opening the socket and streaming the events
+func (s *Service) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.endpoint), 0751); err != nil {
+		return err
+	}
+	// Remove the leftover of the previous run
+	os.Remove(s.endpoint)
+	listener, err := net.Listen("unix", s.endpoint)
+	if err != nil {
+		return err
+	}
+	os.Chmod(s.endpoint, 0600)
+	s.listener = listener
+
+	chainCh := make(chan core.ChainEvent, chainEventChanSize)
+	reorgCh := make(chan core.ReorgEvent, reorgEventChanSize)
+	s.chainSub = s.chain.SubscribeChainEvent(chainCh)
+	s.reorgSub = s.chain.SubscribeReorgEvent(reorgCh)
+
+	s.wg.Add(2)
+	go s.acceptLoop()
+	go s.eventLoop(chainCh, reorgCh)
+
+	log.Info("Firehose started", "endpoint", s.endpoint)
+	return nil
+}
+
+// Stop implements node.Lifecycle, disconnecting all the clients
+func (s *Service) Stop() error {
+	close(s.quit)
+	s.chainSub.Unsubscribe()
+	s.reorgSub.Unsubscribe()
+	s.listener.Close()
+	s.wg.Wait()
+
+	s.lock.Lock()
+	for c := range s.clients {
+		c.conn.Close()
+		s.drop(c)
+	}
+	s.lock.Unlock()
+	log.Info("Firehose stopped")
+	return nil
+}
+
+func (s *Service) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+			default:
+				log.Error("Firehose failed to accept connection", "err", err)
+			}
+			return
+		}
+		c := &client{conn: conn, queue: make(chan []byte, clientQueueSize)}
+		s.lock.Lock()
+		s.clients[c] = struct{}{}
+		log.Debug("Firehose client connected", "clients", len(s.clients))
+		s.lock.Unlock()
+
+		go s.writeLoop(c)
+	}
+}
+
+// writeLoop writes the queued messages to the client until it is dropped
+func (s *Service) writeLoop(c *client) {
+	for msg := range c.queue {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := c.conn.Write(msg); err != nil {
+			log.Debug("Firehose client disconnected", "err", err)
+			s.lock.Lock()
+			s.drop(c)
+			s.lock.Unlock()
+			break
+		}
+	}
+	c.conn.Close()
+}
+
+// drop removes the client, the caller must hold the lock
+func (s *Service) drop(c *client) {
+	if _, ok := s.clients[c]; ok {
+		delete(s.clients, c)
+		close(c.queue)
+	}
+}
+
+// broadcast queues the message to all the clients, the clients whose queue is
+// full are dropped
+func (s *Service) broadcast(msg []byte) {
+	msg = frame(msg)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for c := range s.clients {
+		select {
+		case c.queue <- msg:
+		default:
+			log.Warn("Firehose client is too slow, disconnecting")
+			s.drop(c)
+		}
+	}
+}
+
+func (s *Service) eventLoop(chainCh chan core.ChainEvent, reorgCh chan core.ReorgEvent) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case ev := <-chainCh:
+			msg, err := s.blockEvent(&ev)
+			if err != nil {
+				log.Error("Firehose failed to encode block", "number", ev.Block.Number(), "hash", ev.Hash, "err", err)
+				continue
+			}
+			s.broadcast(msg)
+			if ev.FinalizedBlockNumber > s.finalized {
+				s.finalized = ev.FinalizedBlockNumber
+				s.broadcast(encodeRefEvent(eventFinality, ev.FinalizedBlockNumber, ev.FinalizedBlockHash))
+			}
+		case ev := <-reorgCh:
+			s.broadcast(encodeRefEvent(eventUndo, ev.Block.NumberU64(), ev.Block.Hash()))
+		case <-s.chainSub.Err():
+			return
+		case <-s.reorgSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// blockEvent returns the Event message of the canonical block with the sender,
+// the receipt and whether it is a system transaction for each transaction
+func (s *Service) blockEvent(ev *core.ChainEvent) ([]byte, error) {
+	var (
+		block   = ev.Block
+		header  = block.Header()
+		signer  = types.MakeSigner(s.chain.Config(), block.Number())
+		posa, _ = s.chain.Engine().(consensus.PoSA)
+		txs     = make([]*transaction, 0, len(block.Transactions()))
+	)
+	for i, tx := range block.Transactions() {
+		// The sender is left empty if it cannot be recovered
+		from, _ := types.Sender(signer, tx)
+		t := &transaction{tx: tx, from: from}
+		if posa != nil {
+			t.system, _ = posa.IsSystemTransaction(tx, header)
+		}
+		if i < len(ev.Receipts) {
+			t.receipt = ev.Receipts[i]
+		}
+		txs = append(txs, t)
+	}
+	return encodeBlockEvent(block, txs)
+}
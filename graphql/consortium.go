@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// chainBackend is implemented by the backends having the local chain, which
+// the consensus engine reads the finality data from
+type chainBackend interface {
+	BlockChain() *core.BlockChain
+}
+
+// Validator represents a block producer of a consortium chain.
+type Validator struct {
+	validator finality.ValidatorWithBlsPub
+}
+
+func (v *Validator) Address(ctx context.Context) common.Address {
+	return v.validator.Address
+}
+
+func (v *Validator) BlsPublicKey(ctx context.Context) *hexutil.Bytes {
+	if v.validator.BlsPublicKey == nil {
+		return nil
+	}
+	key := hexutil.Bytes(v.validator.BlsPublicKey.Marshal())
+	return &key
+}
+
+// finalityEngine returns the fast finality engine and the chain it reads from,
+// it returns nil if the node does not run fast finality.
+func (b *Block) finalityEngine() (consensus.FastFinalityPoSA, consensus.ChainHeaderReader) {
+	engine, ok := b.backend.Engine().(consensus.FastFinalityPoSA)
+	if !ok {
+		return nil, nil
+	}
+	backend, ok := b.backend.(chainBackend)
+	if !ok {
+		return nil, nil
+	}
+	return engine, backend.BlockChain()
+}
+
+// refBlock returns the block at number and hash, it returns nil if number is 0
+// as the engine reports the missing justified or finalized block this way.
+func (b *Block) refBlock(number uint64, hash common.Hash) *Block {
+	if number == 0 {
+		return nil
+	}
+	numberOrHash := rpc.BlockNumberOrHashWithHash(hash, false)
+	return &Block{
+		backend:      b.backend,
+		numberOrHash: &numberOrHash,
+		hash:         hash,
+	}
+}
+
+func (b *Block) Justified(ctx context.Context) (*Block, error) {
+	engine, chain := b.finalityEngine()
+	if engine == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.refBlock(engine.GetJustifiedBlock(chain, header.Number.Uint64(), header.Hash())), nil
+}
+
+func (b *Block) Finalized(ctx context.Context) (*Block, error) {
+	engine, chain := b.finalityEngine()
+	if engine == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return b.refBlock(engine.GetFinalizedBlock(chain, header.Number.Uint64(), header.Hash())), nil
+}
+
+// resolveExtra returns the decoded extra data of the consortium block, it
+// returns nil on the other chains.
+func (b *Block) resolveExtra(ctx context.Context) (*finality.HeaderExtraData, error) {
+	config := b.backend.ChainConfig()
+	if config.Consortium == nil {
+		return nil, nil
+	}
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return finality.DecodeExtra(header.Extra, config.IsShillin(header.Number))
+}
+
+func (b *Block) CheckpointValidators(ctx context.Context) (*[]*Validator, error) {
+	extraData, err := b.resolveExtra(ctx)
+	if err != nil || extraData == nil || len(extraData.CheckpointValidators) == 0 {
+		return nil, err
+	}
+	validators := make([]*Validator, 0, len(extraData.CheckpointValidators))
+	for _, validator := range extraData.CheckpointValidators {
+		validators = append(validators, &Validator{validator})
+	}
+	return &validators, nil
+}
+
+func (b *Block) FinalityVotedValidators(ctx context.Context) (*[]*Validator, error) {
+	extraData, err := b.resolveExtra(ctx)
+	if err != nil || extraData == nil || extraData.HasFinalityVote == 0 {
+		return nil, err
+	}
+	engine, chain := b.finalityEngine()
+	if engine == nil {
+		return nil, nil
+	}
+	// The finality vote is verified against the validator set of the parent
+	// block, the bit set indexes this set.
+	header, err := b.resolveHeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active := engine.GetActiveValidatorAt(chain, header.Number.Uint64()-1, header.ParentHash)
+	var validators []*Validator
+	for _, position := range extraData.FinalityVotedValidators.Indices() {
+		if position >= len(active) {
+			break
+		}
+		validators = append(validators, &Validator{active[position]})
+	}
+	return &validators, nil
+}
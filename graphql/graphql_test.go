@@ -86,6 +86,11 @@ func TestGraphQLBlockSerialization(t *testing.T) {
 			want: `{"data":{"block":{"number":0,"gasUsed":0,"gasLimit":11500000}}}`,
 			code: 200,
 		},
+		{ // Should return no consensus data on a chain without fast finality
+			body: `{"query": "{block{justified{number},finalized{number},checkpointValidators{address},finalityVotedValidators{address}}}","variables": null}`,
+			want: `{"data":{"block":{"justified":null,"finalized":null,"checkpointValidators":null,"finalityVotedValidators":null}}}`,
+			code: 200,
+		},
 		{
 			body: `{"query": "{block(number:-1){number,gasUsed,gasLimit}}","variables": null}`,
 			want: `{"data":{"block":null}}`,
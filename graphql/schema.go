@@ -231,6 +231,30 @@ const schema string = `
         # EstimateGas estimates the amount of gas that will be required for
         # successful execution of a transaction at the current block's state.
         estimateGas(data: CallData!): Long!
+        # Justified is the latest fast finality justified block as seen at this
+        # block. It is null if the chain has no fast finality or no block is
+        # justified yet.
+        justified: Block
+        # Finalized is the latest fast finality finalized block as seen at this
+        # block. It is null if the chain has no fast finality or no block is
+        # finalized yet.
+        finalized: Block
+        # CheckpointValidators is the validator set appended to this block. It
+        # is null unless this is a checkpoint block of a consortium chain.
+        checkpointValidators: [Validator!]
+        # FinalityVotedValidators is the list of validators whose finality vote
+        # for the parent block is included in this block. It is null if the
+        # block carries no finality vote.
+        finalityVotedValidators: [Validator!]
+    }
+
+    # Validator is a block producer of a consortium chain.
+    type Validator {
+        # Address is the address the validator signs the blocks with.
+        address: Address!
+        # BlsPublicKey is the BLS public key the validator signs the finality
+        # votes with, it is null before the fast finality hardfork.
+        blsPublicKey: Bytes
     }
 
     # CallData represents the data associated with a local contract call.
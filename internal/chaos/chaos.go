@@ -0,0 +1,135 @@
+// Package chaos implements the fault injection hooks of the consensus paths used
+// to rehearse failure scenarios on devnets and testnets. The hooks are no-ops
+// unless the node is built with the chaos build tag, the faults are then
+// controlled via the admin_chaos* RPC methods.
+package chaos
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	errMainnet      = errors.New("fault injection is not allowed on mainnet")
+	errInvalidRate  = errors.New("vote drop rate must be between 0 and 1")
+	errNotAvailable = errors.New("node is not built with the chaos tag")
+)
+
+// Faults are the injected faults, all disabled by default
+type Faults struct {
+	SealDelay    time.Duration `json:"sealDelay"`    // Extra delay before sealing the blocks
+	VoteDropRate float64       `json:"voteDropRate"` // Fraction of the finality votes not broadcast
+	CorruptExtra bool          `json:"corruptExtra"` // Corrupt the extra data of the mined blocks before broadcast
+}
+
+var (
+	lock   sync.RWMutex
+	faults Faults
+)
+
+func current() Faults {
+	lock.RLock()
+	defer lock.RUnlock()
+	return faults
+}
+
+// SealDelay returns the extra delay before sealing a block
+func SealDelay() time.Duration {
+	if !Enabled {
+		return 0
+	}
+	return current().SealDelay
+}
+
+// DropVote returns whether the vote should not be broadcast
+func DropVote() bool {
+	if !Enabled {
+		return false
+	}
+	rate := current().VoteDropRate
+	return rate > 0 && rand.Float64() < rate
+}
+
+// CorruptBlock returns the block with its extra data corrupted if enabled. The
+// block in the local chain is unchanged, only the broadcast copy is corrupted.
+func CorruptBlock(block *types.Block) *types.Block {
+	if !Enabled || !current().CorruptExtra {
+		return block
+	}
+	header := block.Header()
+	if len(header.Extra) == 0 {
+		return block
+	}
+	header.Extra[0] ^= 0xff
+	log.Warn("Chaos: broadcasting block with corrupted extra data", "number", block.Number(), "hash", block.Hash())
+	return block.WithSeal(header)
+}
+
+// PrivateChaosAPI controls the injected faults
+type PrivateChaosAPI struct {
+	chainID *big.Int
+}
+
+func NewPrivateChaosAPI(config *params.ChainConfig) *PrivateChaosAPI {
+	return &PrivateChaosAPI{chainID: config.ChainID}
+}
+
+// update applies the change to the faults, refusing it on mainnet
+func (api *PrivateChaosAPI) update(change func(*Faults)) error {
+	if !Enabled {
+		return errNotAvailable
+	}
+	if api.chainID != nil && api.chainID.Cmp(params.RoninMainnetChainConfig.ChainID) == 0 {
+		return errMainnet
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	change(&faults)
+	log.Warn("Chaos: fault injection updated", "sealDelay", faults.SealDelay, "voteDropRate", faults.VoteDropRate, "corruptExtra", faults.CorruptExtra)
+	return nil
+}
+
+// ChaosStatus returns the injected faults
+func (api *PrivateChaosAPI) ChaosStatus() Faults {
+	return current()
+}
+
+// ChaosSetSealDelay delays the sealing of the blocks by the milliseconds
+func (api *PrivateChaosAPI) ChaosSetSealDelay(milliseconds uint64) error {
+	return api.update(func(faults *Faults) {
+		faults.SealDelay = time.Duration(milliseconds) * time.Millisecond
+	})
+}
+
+// ChaosSetVoteDropRate drops the fraction of the finality votes instead of
+// broadcasting them
+func (api *PrivateChaosAPI) ChaosSetVoteDropRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return errInvalidRate
+	}
+	return api.update(func(faults *Faults) {
+		faults.VoteDropRate = rate
+	})
+}
+
+// ChaosSetCorruptExtra corrupts the extra data of the mined blocks before they
+// are broadcast
+func (api *PrivateChaosAPI) ChaosSetCorruptExtra(enabled bool) error {
+	return api.update(func(faults *Faults) {
+		faults.CorruptExtra = enabled
+	})
+}
+
+// ChaosReset disables all the injected faults
+func (api *PrivateChaosAPI) ChaosReset() error {
+	return api.update(func(faults *Faults) {
+		*faults = Faults{}
+	})
+}
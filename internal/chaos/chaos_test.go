@@ -0,0 +1,48 @@
+package chaos
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestChaosAPI(t *testing.T) {
+	api := NewPrivateChaosAPI(&params.ChainConfig{ChainID: big.NewInt(2022)})
+	if !Enabled {
+		if err := api.ChaosSetSealDelay(1000); err != errNotAvailable {
+			t.Fatalf("Expect %s, got %v", errNotAvailable, err)
+		}
+		if SealDelay() != 0 || DropVote() {
+			t.Fatalf("Expect no fault injected")
+		}
+		return
+	}
+	defer api.ChaosReset()
+
+	mainnet := NewPrivateChaosAPI(params.RoninMainnetChainConfig)
+	if err := mainnet.ChaosSetCorruptExtra(true); err != errMainnet {
+		t.Fatalf("Expect %s, got %v", errMainnet, err)
+	}
+	if err := api.ChaosSetVoteDropRate(2); err != errInvalidRate {
+		t.Fatalf("Expect %s, got %v", errInvalidRate, err)
+	}
+	if err := api.ChaosSetVoteDropRate(1); err != nil {
+		t.Fatalf("Failed to set vote drop rate, err %s", err)
+	}
+	if !DropVote() {
+		t.Fatalf("Expect all votes to be dropped")
+	}
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Extra: make([]byte, 32)})
+	if CorruptBlock(block) != block {
+		t.Fatalf("Expect the block to be unchanged")
+	}
+	if err := api.ChaosSetCorruptExtra(true); err != nil {
+		t.Fatalf("Failed to enable extra data corruption, err %s", err)
+	}
+	if corrupted := CorruptBlock(block); corrupted.Hash() == block.Hash() {
+		t.Fatalf("Expect the block to be corrupted")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build chaos
+// +build chaos
+
+package chaos
+
+// Enabled reports whether the node is built with the fault injection hooks
+const Enabled = true
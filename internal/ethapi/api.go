@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/consensus/misc"
@@ -42,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -52,6 +54,16 @@ import (
 
 var ErrMethodNotSupport = errors.New("method is not supported")
 
+var finalizedDefault = features.Register("rpc.finalizeddefault", "Run eth_estimateGas and eth_createAccessList on the finalized block instead of the pending block when the block is omitted", false, true)
+
+// defaultBlockNrOrHash returns the block of the calls which omit the block
+func defaultBlockNrOrHash() rpc.BlockNumberOrHash {
+	if finalizedDefault.Enabled() {
+		return rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber)
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+}
+
 // PublicEthereumAPI provides an API to access Ethereum related information.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicEthereumAPI struct {
@@ -246,6 +258,102 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// txPoolSnapshotTransaction is a pool transaction annotated with whether it fits
+// into the next block
+type txPoolSnapshotTransaction struct {
+	*RPCTransaction
+	FitsNextBlock bool   `json:"fitsNextBlock"`
+	Reason        string `json:"reason,omitempty"` // Why the transaction does not fit
+}
+
+// txPoolSnapshot is the content of the transaction pool in the context of the
+// next block
+type txPoolSnapshot struct {
+	NextBlock    hexutil.Uint64                                   `json:"nextBlock"`
+	GasLimit     hexutil.Uint64                                   `json:"gasLimit"`     // Gas available for transactions in the next block
+	SlotTimeLeft hexutil.Uint64                                   `json:"slotTimeLeft"` // Seconds until the next block slot
+	Pending      map[string]map[string]*txPoolSnapshotTransaction `json:"pending"`
+	Queued       map[string]map[string]*txPoolSnapshotTransaction `json:"queued"`
+}
+
+// Snapshot returns the content of the transaction pool annotated with whether
+// each transaction fits into the next block. The pending transactions are
+// ordered the same way as the miner does, by price and nonce, and fit until the
+// gas of the next block runs out. The gas limit of the transaction is used as
+// its gas usage, so this is a pessimistic estimation. The queued transactions
+// never fit because of the nonce gap.
+func (s *PublicTxPoolAPI) Snapshot() *txPoolSnapshot {
+	pending, queue := s.b.TxPoolContent()
+	curHeader := s.b.CurrentHeader()
+	config := s.b.ChainConfig()
+
+	next := new(big.Int).Add(curHeader.Number, common.Big1)
+	gasLimit := curHeader.GasLimit
+	var period uint64
+	if config.Consortium != nil {
+		period = config.Consortium.Period
+		if gasLimit > params.ReservedGasForSystemTransactions {
+			gasLimit -= params.ReservedGasForSystemTransactions
+		} else {
+			gasLimit = 0
+		}
+	} else if config.Clique != nil {
+		period = config.Clique.Period
+	}
+	var slotTimeLeft uint64
+	if slotTime, now := curHeader.Time+period, uint64(time.Now().Unix()); slotTime > now {
+		slotTimeLeft = slotTime - now
+	}
+
+	// Simulate the transaction selection of the miner
+	fits := make(map[common.Hash]bool)
+	gasLeft := gasLimit
+	txs := types.NewTransactionsByPriceAndNonce(types.MakeSigner(config, next), pending, curHeader.BaseFee)
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		if tx.Gas() > gasLeft {
+			// The remaining transactions of the sender are skipped as the miner does
+			txs.Pop()
+			continue
+		}
+		gasLeft -= tx.Gas()
+		fits[tx.Hash()] = true
+		txs.Shift()
+	}
+
+	snapshot := &txPoolSnapshot{
+		NextBlock:    hexutil.Uint64(next.Uint64()),
+		GasLimit:     hexutil.Uint64(gasLimit),
+		SlotTimeLeft: hexutil.Uint64(slotTimeLeft),
+		Pending:      make(map[string]map[string]*txPoolSnapshotTransaction),
+		Queued:       make(map[string]map[string]*txPoolSnapshotTransaction),
+	}
+	for account, txs := range pending {
+		dump := make(map[string]*txPoolSnapshotTransaction)
+		for _, tx := range txs {
+			snapshotTx := &txPoolSnapshotTransaction{
+				RPCTransaction: newRPCPendingTransaction(tx, curHeader, config),
+				FitsNextBlock:  fits[tx.Hash()],
+			}
+			if !snapshotTx.FitsNextBlock {
+				snapshotTx.Reason = "not enough gas left in the next block"
+			}
+			dump[fmt.Sprintf("%d", tx.Nonce())] = snapshotTx
+		}
+		snapshot.Pending[account.Hex()] = dump
+	}
+	for account, txs := range queue {
+		dump := make(map[string]*txPoolSnapshotTransaction)
+		for _, tx := range txs {
+			dump[fmt.Sprintf("%d", tx.Nonce())] = &txPoolSnapshotTransaction{
+				RPCTransaction: newRPCPendingTransaction(tx, curHeader, config),
+				Reason:         "nonce gap",
+			}
+		}
+		snapshot.Queued[account.Hex()] = dump
+	}
+	return snapshot
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -1156,9 +1264,10 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
+// given transaction against the current pending block, or the finalized block
+// with the rpc.finalizeddefault feature, if the block is omitted.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
-	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	bNrOrHash := defaultBlockNrOrHash()
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
@@ -1457,7 +1566,7 @@ type accessListResult struct {
 // CreateAccessList creates a EIP-2930 type AccessList for the given transaction.
 // Reexec and BlockNrOrHash can be specified to create the accessList on top of a certain state.
 func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args TransactionArgs, blockNrOrHash *rpc.BlockNumberOrHash) (*accessListResult, error) {
-	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	bNrOrHash := defaultBlockNrOrHash()
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
 	}
@@ -1706,6 +1815,16 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		payer, _ := types.Payer(signer, tx)
 		fields["payer"] = payer
 	}
+	// Tag the transactions applied by the consensus engine to its system contracts
+	if posa, ok := s.b.Engine().(consensus.PoSA); ok && s.b.ChainConfig().IsConsortiumV2(bigblock) {
+		header, err := s.b.HeaderByHash(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if isSystemTx, err := posa.IsSystemTransaction(tx, header); err == nil && isSystemTx {
+			fields["systemTransaction"] = true
+		}
+	}
 
 	// Assign the effective gas price paid
 	if !s.b.ChainConfig().IsLondon(bigblock) {
@@ -0,0 +1,137 @@
+// Package features is the registry of the node-level feature flags which stage
+// the rollout of new behaviors. The features are configured at startup and the
+// ones marked as runtime can also be toggled while the node is running.
+package features
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	lock     sync.RWMutex
+	registry = make(map[string]*Feature)
+)
+
+// Feature is a feature flag
+type Feature struct {
+	name        string
+	description string
+	runtime     bool // Whether the feature can be toggled while the node is running
+	enabled     int32
+}
+
+// Info is the state of a feature flag
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Runtime     bool   `json:"runtime"`
+}
+
+// Register registers a new feature flag, it panics if the name is already taken.
+func Register(name, description string, enabled, runtime bool) *Feature {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("feature %s is already registered", name))
+	}
+	feature := &Feature{
+		name:        name,
+		description: description,
+		runtime:     runtime,
+	}
+	feature.set(enabled)
+	registry[name] = feature
+	return feature
+}
+
+// Enabled returns true if the feature is enabled
+func (feature *Feature) Enabled() bool {
+	return atomic.LoadInt32(&feature.enabled) == 1
+}
+
+func (feature *Feature) set(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&feature.enabled, value)
+}
+
+func (feature *Feature) info() Info {
+	return Info{
+		Name:        feature.name,
+		Description: feature.description,
+		Enabled:     feature.Enabled(),
+		Runtime:     feature.runtime,
+	}
+}
+
+// List returns the state of all the feature flags sorted by name
+func List() []Info {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	infos := make([]Info, 0, len(registry))
+	for _, feature := range registry {
+		infos = append(infos, feature.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Set toggles a runtime feature while the node is running
+func Set(name string, enabled bool) (Info, error) {
+	lock.RLock()
+	feature, ok := registry[name]
+	lock.RUnlock()
+	if !ok {
+		return Info{}, fmt.Errorf("unknown feature %s", name)
+	}
+	if !feature.runtime {
+		return Info{}, fmt.Errorf("feature %s can only be set at startup", name)
+	}
+
+	feature.set(enabled)
+	log.Info("Feature flag is updated", "name", name, "enabled", enabled)
+	return feature.info(), nil
+}
+
+// Configure sets the features at startup from a comma separated list of
+// name=bool, a name without value enables the feature.
+func Configure(config string) error {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	for _, entry := range strings.Split(config, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value := entry, "true"
+		if i := strings.Index(entry, "="); i >= 0 {
+			name, value = entry[:i], entry[i+1:]
+		}
+		feature, ok := registry[name]
+		if !ok {
+			return fmt.Errorf("unknown feature %s", name)
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid value of feature %s: %v", name, err)
+		}
+		feature.set(enabled)
+		log.Info("Configured feature flag", "name", name, "enabled", enabled)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package features
+
+import "testing"
+
+func TestFeatures(t *testing.T) {
+	startup := Register("test.startup", "startup only feature", false, false)
+	runtime := Register("test.runtime", "runtime feature", true, true)
+
+	if err := Configure("test.startup, test.runtime=false"); err != nil {
+		t.Fatalf("Failed to configure features, err %s", err)
+	}
+	if !startup.Enabled() || runtime.Enabled() {
+		t.Fatalf("Features are not configured")
+	}
+	if err := Configure("test.unknown"); err == nil {
+		t.Fatalf("Expect error when configuring unknown feature")
+	}
+
+	if _, err := Set("test.startup", false); err == nil {
+		t.Fatalf("Expect error when setting startup only feature at runtime")
+	}
+	info, err := Set("test.runtime", true)
+	if err != nil {
+		t.Fatalf("Failed to set runtime feature, err %s", err)
+	}
+	if !info.Enabled || !runtime.Enabled() {
+		t.Fatalf("Runtime feature is not enabled")
+	}
+}
@@ -152,6 +152,16 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportVotes',
+			call: 'admin_exportVotes',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'importVotes',
+			call: 'admin_importVotes',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -189,12 +199,30 @@ web3._extend({
 			name: 'stopWS',
 			call: 'admin_stopWS'
 		}),
+		new web3._extend.Method({
+			name: 'setFeature',
+			call: 'admin_setFeature',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'validatorSettings',
+			call: 'admin_validatorSettings'
+		}),
+		new web3._extend.Method({
+			name: 'setValidatorSettings',
+			call: 'admin_setValidatorSettings',
+			params: 1
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'nodeInfo',
 			getter: 'admin_nodeInfo'
 		}),
+		new web3._extend.Property({
+			name: 'features',
+			getter: 'admin_features'
+		}),
 		new web3._extend.Property({
 			name: 'peers',
 			getter: 'admin_peers'
@@ -471,6 +499,12 @@ web3._extend({
 			params: 2,
 			inputFormatter:[web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Method({
+			name: 'executionWitness',
+			call: 'debug_executionWitness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+		}),
 	],
 	properties: []
 });
@@ -533,6 +567,16 @@ web3._extend({
 			call: 'eth_getHeaderByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getBlockSystemTransactions',
+			call: 'eth_getBlockSystemTransactions',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionConfirmation',
+			call: 'eth_getTransactionConfirmation',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getBlockByNumber',
 			call: 'eth_getBlockByNumber',
@@ -624,6 +668,11 @@ web3._extend({
 			call: 'miner_setExtra',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setVanity',
+			call: 'miner_setVanity',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'setGasPrice',
 			call: 'miner_setGasPrice',
@@ -746,6 +795,10 @@ web3._extend({
 			name: 'inspect',
 			getter: 'txpool_inspect'
 		}),
+		new web3._extend.Property({
+			name: 'snapshot',
+			getter: 'txpool_snapshot'
+		}),
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status',
@@ -153,6 +153,12 @@ type intervalAdjust struct {
 	inc   bool
 }
 
+// voteWaitEngine is implemented by the consensus engines waiting for the
+// finality votes of the parent while sealing.
+type voteWaitEngine interface {
+	SetVoteWaitHandler(fn func(header *types.Header))
+}
+
 // worker is the main object which takes care of submitting new work to consensus engine
 // and gathering the sealing result.
 type worker struct {
@@ -182,6 +188,7 @@ type worker struct {
 	exitCh             chan struct{}
 	resubmitIntervalCh chan time.Duration
 	resubmitAdjustCh   chan *intervalAdjust
+	voteWaitCh         chan uint64
 
 	wg sync.WaitGroup
 
@@ -248,6 +255,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		startCh:            make(chan struct{}, 1),
 		resubmitIntervalCh: make(chan time.Duration),
 		resubmitAdjustCh:   make(chan *intervalAdjust, resubmitAdjustChanSize),
+		voteWaitCh:         make(chan uint64, 1),
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
@@ -257,6 +265,14 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 
 	worker.recentMinedBlocks, _ = lru.New(recentMinedCacheLimit)
 
+	// Keep executing the new transactions while the sealer waits for the finality
+	// votes of the parent, the votes are merged into the header at seal time.
+	if config.OptimisticSeal {
+		if engine, ok := engine.(voteWaitEngine); ok {
+			engine.SetVoteWaitHandler(worker.notifyVoteWait)
+		}
+	}
+
 	// Sanitize recommit interval if the user-specified one is too short.
 	recommit := worker.config.Recommit
 	if recommit < minRecommitInterval {
@@ -277,6 +293,15 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 	return worker
 }
 
+// notifyVoteWait is called by the consensus engine when the sealer of the block
+// starts waiting for the finality votes, it never blocks the sealer.
+func (w *worker) notifyVoteWait(header *types.Header) {
+	select {
+	case w.voteWaitCh <- header.Number.Uint64():
+	default:
+	}
+}
+
 // setEtherbase sets the etherbase used to initialize the block coinbase field.
 func (w *worker) setEtherbase(addr common.Address) {
 	w.mu.Lock()
@@ -404,6 +429,7 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 		interrupt   *int32
 		minRecommit = recommit // minimal resubmit interval specified by user.
 		timestamp   int64      // timestamp for each round of mining.
+		optimistic  uint64     // number of the last block resubmitted while waiting for the finality votes.
 	)
 
 	timer := time.NewTimer(0)
@@ -459,6 +485,20 @@ func (w *worker) newWorkLoop(recommit time.Duration) {
 				commit(true, commitInterruptResubmit)
 			}
 
+		case number := <-w.voteWaitCh:
+			// The sealer is waiting for the finality votes of the parent, resubmit
+			// once with the transactions arrived since the last work cycle instead
+			// of leaving them to the next block.
+			if !w.isRunning() || number == optimistic || number != w.chain.CurrentBlock().NumberU64()+1 {
+				continue
+			}
+			if atomic.LoadInt32(&w.newTxs) == 0 {
+				continue
+			}
+			optimistic = number
+			log.Debug("Resubmitting work while waiting for finality votes", "number", number, "txs", atomic.LoadInt32(&w.newTxs))
+			commit(true, commitInterruptResubmit)
+
 		case interval := <-w.resubmitIntervalCh:
 			// Adjust resubmit interval explicitly by user.
 			if interval < minRecommitInterval {
@@ -130,7 +130,7 @@ func newTestWorkerBackend(t *testing.T, chainConfig *params.ChainConfig, engine
 		e.Authorize(testBankAddress, func(account accounts.Account, s string, data []byte) ([]byte, error) {
 			return crypto.Sign(crypto.Keccak256(data), testBankKey)
 		})
-	case *ethash.Ethash:
+	case *ethash.Ethash, *voteWaitTestEngine:
 	default:
 		t.Fatalf("unexpected consensus engine type: %T", engine)
 	}
@@ -429,6 +429,76 @@ func testRegenerateMiningBlock(t *testing.T, chainConfig *params.ChainConfig, en
 	}
 }
 
+// voteWaitTestEngine records the vote wait handler set by the worker.
+type voteWaitTestEngine struct {
+	consensus.Engine
+	voteWaitFn func(header *types.Header)
+}
+
+func (e *voteWaitTestEngine) SetVoteWaitHandler(fn func(header *types.Header)) {
+	e.voteWaitFn = fn
+}
+
+func TestOptimisticSeal(t *testing.T) {
+	engine := &voteWaitTestEngine{Engine: ethash.NewFaker()}
+	defer engine.Close()
+
+	backend := newTestWorkerBackend(t, ethashChainConfig, engine, rawdb.NewMemoryDatabase(), 0)
+	backend.txPool.AddLocals(pendingTxs)
+	config := *testConfig
+	config.OptimisticSeal = true
+	w := newWorker(&config, ethashChainConfig, engine, backend, new(event.TypeMux), nil, false)
+	w.setEtherbase(testBankAddress)
+	defer w.close()
+
+	if engine.voteWaitFn == nil {
+		t.Fatalf("Expect the vote wait handler to be set")
+	}
+	// Only the vote wait resubmits the work
+	w.setRecommitInterval(time.Hour)
+
+	taskCh := make(chan int, 10)
+	w.newTaskHook = func(task *task) {
+		if task.block.NumberU64() == 1 {
+			taskCh <- len(task.receipts)
+		}
+	}
+	w.skipSealHook = func(task *task) bool {
+		return true
+	}
+	w.start()
+	// Ignore the empty and the full work
+	for i := 0; i < 2; i++ {
+		select {
+		case <-taskCh:
+		case <-time.After(time.Second):
+			t.Fatalf("Expect new task")
+		}
+	}
+	backend.txPool.AddLocals(newTxs)
+	time.Sleep(100 * time.Millisecond)
+
+	engine.voteWaitFn(&types.Header{Number: big.NewInt(1)})
+	select {
+	case receipts := <-taskCh:
+		if receipts != 2 {
+			t.Fatalf("Expect 2 receipts in the resubmitted work, got %d", receipts)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect the work to be resubmitted while waiting for the finality votes")
+	}
+
+	// The work is resubmitted once per block
+	backend.txPool.AddLocals([]*types.Transaction{backend.newRandomTx(false)})
+	time.Sleep(100 * time.Millisecond)
+	engine.voteWaitFn(&types.Header{Number: big.NewInt(1)})
+	select {
+	case receipts := <-taskCh:
+		t.Fatalf("Expect no more resubmission, got task with %d receipts", receipts)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
 func TestAdjustIntervalEthash(t *testing.T) {
 	testAdjustInterval(t, ethashChainConfig, ethash.NewFaker())
 }
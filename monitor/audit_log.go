@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// AuditRecord is the consensus audit record of an imported block, written as a
+// line of JSON
+type AuditRecord struct {
+	Number     uint64         `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Time       uint64         `json:"time"`
+	ImportedAt int64          `json:"importedAt"` // Unix milliseconds at which the block is imported
+	Sealer     common.Address `json:"sealer"`
+
+	// The in-turn validator is omitted if it is unknown, e.g. before Consortium v2
+	InTurnValidator *common.Address `json:"inTurnValidator,omitempty"`
+	InTurn          bool            `json:"inTurn"`
+
+	// Backoff is the number of seconds the block time is after the parent
+	// time plus the block period, i.e. the delay of the out-of-turn sealing
+	Backoff uint64 `json:"backoff"`
+
+	// Positions of the validators whose finality votes for the parent are
+	// included as a bit set, omitted if there is none
+	FinalityVotedValidators *hexutil.Big `json:"finalityVotedValidators,omitempty"`
+
+	// Durations of the header verification in nanoseconds, omitted if the
+	// header is not verified by the engine, e.g. it is already finalized
+	HeaderVerification    *int64 `json:"headerVerificationNs,omitempty"`
+	SignatureVerification *int64 `json:"signatureVerificationNs,omitempty"`
+}
+
+// auditEngine is the consensus engine the audit log reads the sealers, the in-turn
+// validators and the verification timings from
+type auditEngine interface {
+	Author(header *types.Header) (common.Address, error)
+	InTurnValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool)
+	VerificationTiming(hash common.Hash) (header, signatures time.Duration, ok bool)
+}
+
+// AuditLog appends the consensus audit records of the imported blocks to a file
+// as JSON lines
+type AuditLog struct {
+	chain  consensus.ChainHeaderReader
+	engine consensus.Engine
+
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewAuditLog opens the audit log file at path, the records are appended to the
+// existing ones.
+func NewAuditLog(path string, chain consensus.ChainHeaderReader, engine consensus.Engine) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{
+		chain:   chain,
+		engine:  engine,
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// Record writes the audit record of the imported block
+func (auditLog *AuditLog) Record(block *types.Block) {
+	record := auditLog.newRecord(block.Header())
+
+	auditLog.lock.Lock()
+	defer auditLog.lock.Unlock()
+	if auditLog.file == nil {
+		return
+	}
+	if err := auditLog.encoder.Encode(record); err != nil {
+		log.Error("Failed to write consensus audit record", "number", record.Number, "err", err)
+	}
+}
+
+// newRecord creates the audit record of the header
+func (auditLog *AuditLog) newRecord(header *types.Header) *AuditRecord {
+	config := auditLog.chain.Config()
+	record := &AuditRecord{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash(),
+		ParentHash: header.ParentHash,
+		Time:       header.Time,
+		ImportedAt: time.Now().UnixMilli(),
+	}
+	if sealer, err := auditLog.engine.Author(header); err == nil {
+		record.Sealer = sealer
+	}
+	engine, ok := auditLog.engine.(auditEngine)
+	if ok {
+		if validator, ok := engine.InTurnValidator(auditLog.chain, header); ok {
+			record.InTurnValidator = &validator
+			record.InTurn = validator == record.Sealer
+		}
+		if headerTime, signaturesTime, ok := engine.VerificationTiming(record.Hash); ok {
+			headerNs, signaturesNs := int64(headerTime), int64(signaturesTime)
+			record.HeaderVerification, record.SignatureVerification = &headerNs, &signaturesNs
+		}
+	}
+	if parent := auditLog.chain.GetHeader(header.ParentHash, record.Number-1); parent != nil && config.Consortium != nil {
+		if expected := parent.Time + config.Consortium.Period; header.Time > expected {
+			record.Backoff = header.Time - expected
+		}
+	}
+	if config.IsShillin(header.Number) {
+		extraData, err := finality.DecodeExtra(header.Extra, true)
+		if err == nil && extraData.HasFinalityVote == 1 {
+			record.FinalityVotedValidators = (*hexutil.Big)(extraData.FinalityVotedValidators.Big())
+		}
+	}
+	return record
+}
+
+// Close closes the audit log file, the records are no longer written
+func (auditLog *AuditLog) Close() error {
+	auditLog.lock.Lock()
+	defer auditLog.lock.Unlock()
+	if auditLog.file == nil {
+		return nil
+	}
+	err := auditLog.file.Close()
+	auditLog.file = nil
+	return err
+}
@@ -0,0 +1,136 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type testAuditChain struct {
+	consensus.ChainHeaderReader
+	config  *params.ChainConfig
+	headers map[common.Hash]*types.Header
+}
+
+func (chain *testAuditChain) Config() *params.ChainConfig { return chain.config }
+
+func (chain *testAuditChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return chain.headers[hash]
+}
+
+type testAuditEngine struct {
+	consensus.Engine
+	inTurn  common.Address
+	timings map[common.Hash]time.Duration
+}
+
+func (engine *testAuditEngine) Author(header *types.Header) (common.Address, error) {
+	return header.Coinbase, nil
+}
+
+func (engine *testAuditEngine) InTurnValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool) {
+	return engine.inTurn, true
+}
+
+func (engine *testAuditEngine) VerificationTiming(hash common.Hash) (time.Duration, time.Duration, bool) {
+	timing, ok := engine.timings[hash]
+	return timing, 2 * timing, ok
+}
+
+func TestAuditLog(t *testing.T) {
+	config := &params.ChainConfig{
+		ShillinBlock: big.NewInt(0),
+		Consortium:   &params.ConsortiumConfig{Period: 3},
+	}
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate BLS key, err %s", err)
+	}
+	parent := &types.Header{Number: big.NewInt(9), Difficulty: big.NewInt(7), Time: 100}
+	extraData := &finality.HeaderExtraData{HasFinalityVote: 1, AggregatedFinalityVotes: secretKey.Sign([]byte("vote"))}
+	extraData.FinalityVotedValidators.SetBit(0)
+	extraData.FinalityVotedValidators.SetBit(2)
+	inTurnHeader := &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(7),
+		ParentHash: parent.Hash(),
+		Time:       103,
+		Coinbase:   common.Address{0x1},
+		Extra:      extraData.Encode(true),
+	}
+	outOfTurnHeader := &types.Header{
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(3),
+		ParentHash: parent.Hash(),
+		Time:       105,
+		Coinbase:   common.Address{0x2},
+		Extra:      (&finality.HeaderExtraData{}).Encode(true),
+	}
+	chain := &testAuditChain{config: config, headers: map[common.Hash]*types.Header{parent.Hash(): parent}}
+	engine := &testAuditEngine{
+		inTurn:  common.Address{0x1},
+		timings: map[common.Hash]time.Duration{inTurnHeader.Hash(): time.Millisecond},
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := NewAuditLog(path, chain, engine)
+	if err != nil {
+		t.Fatalf("Failed to create audit log, err %s", err)
+	}
+	auditLog.Record(types.NewBlockWithHeader(inTurnHeader))
+	auditLog.Record(types.NewBlockWithHeader(outOfTurnHeader))
+	if err := auditLog.Close(); err != nil {
+		t.Fatalf("Failed to close audit log, err %s", err)
+	}
+	// The records are not written after closing
+	auditLog.Record(types.NewBlockWithHeader(inTurnHeader))
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log, err %s", err)
+	}
+	defer file.Close()
+	var records []AuditRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Failed to decode audit record, err %s", err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expect 2 audit records, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.Number != 10 || record.Hash != inTurnHeader.Hash() || record.Sealer != (common.Address{0x1}) || !record.InTurn || record.Backoff != 0 {
+		t.Fatalf("In-turn record mismatch, got %+v", record)
+	}
+	if record.FinalityVotedValidators == nil || record.FinalityVotedValidators.ToInt().Uint64() != 0b101 {
+		t.Fatalf("Expect finality voted validators 0b101, got %v", record.FinalityVotedValidators)
+	}
+	if record.HeaderVerification == nil || *record.HeaderVerification != int64(time.Millisecond) ||
+		record.SignatureVerification == nil || *record.SignatureVerification != int64(2*time.Millisecond) {
+		t.Fatalf("Verification timings mismatch, got %v %v", record.HeaderVerification, record.SignatureVerification)
+	}
+
+	record = records[1]
+	if record.InTurn || record.InTurnValidator == nil || *record.InTurnValidator != (common.Address{0x1}) || record.Backoff != 2 {
+		t.Fatalf("Out-of-turn record mismatch, got %+v", record)
+	}
+	if record.FinalityVotedValidators != nil || record.HeaderVerification != nil {
+		t.Fatalf("Expect no finality votes nor verification timings, got %+v", record)
+	}
+}
@@ -0,0 +1,139 @@
+package monitor
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	ChainFrozenEvent   = "chain_frozen"
+	ChainUnfrozenEvent = "chain_unfrozen"
+)
+
+// ErrChainUnsafe is returned to the RPC write methods while the chain is frozen
+var ErrChainUnsafe = errors.New("chain unsafe: no block has been finalized recently, the chain segment may be reverted")
+
+var chainFrozenGauge = metrics.NewRegisteredGauge("monitor/chainfreeze/frozen", nil)
+
+// ChainFreezePayload is the JSON body posted to the webhook when the chain
+// freezes or unfreezes.
+type ChainFreezePayload struct {
+	Event           string      `json:"event"`
+	HeadNumber      uint64      `json:"headNumber"`
+	HeadHash        common.Hash `json:"headHash"`
+	FinalizedNumber uint64      `json:"finalizedNumber"`
+	FinalizedHash   common.Hash `json:"finalizedHash"`
+	Distance        uint64      `json:"distance"`
+	Threshold       uint64      `json:"threshold"`
+}
+
+// ChainFreeze is a circuit breaker freezing the chain for the RPC clients when
+// no block has been finalized for more than a number of epochs: the transactions
+// they submit are refused with ErrChainUnsafe so exchanges do not credit the
+// deposits of a chain segment which may be forked away. It unfreezes once the
+// finality catches up.
+type ChainFreeze struct {
+	chain     consensus.ChainHeaderReader
+	engine    consensus.FastFinalityPoSA
+	threshold uint64           // Number of blocks without finality before freezing
+	notifier  *webhookNotifier // Optional
+	frozen    int32
+}
+
+func NewChainFreeze(
+	chain consensus.ChainHeaderReader,
+	engine consensus.FastFinalityPoSA,
+	epochs uint64,
+	epochLength uint64,
+	url string,
+) (*ChainFreeze, error) {
+	if epochs == 0 || epochLength == 0 {
+		return nil, errors.New("chain freeze epochs must be greater than 0")
+	}
+
+	freeze := &ChainFreeze{
+		chain:     chain,
+		engine:    engine,
+		threshold: epochs * epochLength,
+	}
+	if url != "" {
+		freeze.notifier = newWebhookNotifier(url)
+	}
+	return freeze, nil
+}
+
+// Frozen returns whether the RPC write methods must be refused
+func (freeze *ChainFreeze) Frozen() bool {
+	return atomic.LoadInt32(&freeze.frozen) == 1
+}
+
+// updateStatus records the number of blocks since the finalized block, or since
+// the fork enabling the finality if none is finalized after it, and returns the
+// event to fire if the frozen status changes.
+func (freeze *ChainFreeze) updateStatus(headNumber, finalizedNumber uint64) (string, bool) {
+	var distance uint64
+	if headNumber > finalizedNumber {
+		distance = headNumber - finalizedNumber
+	}
+
+	frozen := freeze.Frozen()
+	if !frozen && distance > freeze.threshold {
+		atomic.StoreInt32(&freeze.frozen, 1)
+		chainFrozenGauge.Update(1)
+		return ChainFrozenEvent, true
+	}
+	if frozen && distance <= freeze.threshold {
+		atomic.StoreInt32(&freeze.frozen, 0)
+		chainFrozenGauge.Update(0)
+		return ChainUnfrozenEvent, true
+	}
+	return "", false
+}
+
+// CheckFinality checks the finality at the new chain head, freezes or unfreezes
+// the chain and fires the alert when the status changes.
+func (freeze *ChainFreeze) CheckFinality(header *types.Header) {
+	headNumber := header.Number.Uint64()
+	finalizedNumber, finalizedHash := freeze.engine.GetFinalizedBlock(freeze.chain, headNumber, header.Hash())
+
+	// No block can be finalized before the finality is enabled
+	since := finalizedNumber
+	if shillin := freeze.chain.Config().ShillinBlock; shillin != nil && since < shillin.Uint64() {
+		since = shillin.Uint64()
+	}
+	event, fire := freeze.updateStatus(headNumber, since)
+	if !fire {
+		return
+	}
+
+	payload := ChainFreezePayload{
+		Event:           event,
+		HeadNumber:      headNumber,
+		HeadHash:        header.Hash(),
+		FinalizedNumber: finalizedNumber,
+		FinalizedHash:   finalizedHash,
+		Distance:        headNumber - since,
+		Threshold:       freeze.threshold,
+	}
+	if event == ChainFrozenEvent {
+		log.Error("Chain frozen, refusing the RPC transactions", "head", headNumber, "finalized", finalizedNumber, "threshold", freeze.threshold)
+	} else {
+		log.Info("Chain unfrozen", "head", headNumber, "finalized", finalizedNumber)
+	}
+
+	if freeze.notifier == nil {
+		return
+	}
+	// Do not block the chain event loop on the webhook
+	go func() {
+		if err := freeze.notifier.Notify(&payload); err != nil {
+			log.Error("Failed to notify chain freeze webhook", "event", event, "err", err)
+		}
+	}()
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import "testing"
+
+func TestChainFreezeStatus(t *testing.T) {
+	if _, err := NewChainFreeze(nil, nil, 0, 200, ""); err == nil {
+		t.Fatalf("Expect error when the chain freeze epochs is 0")
+	}
+	freeze, err := NewChainFreeze(nil, nil, 2, 10, "")
+	if err != nil {
+		t.Fatalf("Failed to create chain freeze, err %s", err)
+	}
+
+	if _, fire := freeze.updateStatus(120, 100); fire || freeze.Frozen() {
+		t.Fatalf("Expect no freeze when the finalized block is %d epochs behind", 2)
+	}
+	event, fire := freeze.updateStatus(121, 100)
+	if !fire || event != ChainFrozenEvent || !freeze.Frozen() {
+		t.Fatalf("Expect frozen event, got %s %v", event, fire)
+	}
+	if _, fire := freeze.updateStatus(122, 100); fire || !freeze.Frozen() {
+		t.Fatalf("Expect no duplicated frozen event")
+	}
+	event, fire = freeze.updateStatus(123, 121)
+	if !fire || event != ChainUnfrozenEvent || freeze.Frozen() {
+		t.Fatalf("Expect unfrozen event, got %s %v", event, fire)
+	}
+	if _, fire := freeze.updateStatus(124, 122); fire || freeze.Frozen() {
+		t.Fatalf("Expect no duplicated unfrozen event")
+	}
+}
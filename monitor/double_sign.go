@@ -33,22 +33,30 @@ func getSignature(blockHeader *types.Header) string {
 	return "0x" + hex.EncodeToString(signature)
 }
 
-func (monitor *DoubleSignMonitor) CheckDoubleSign(blockHeader *types.Header) {
+// CheckDoubleSign records the header and returns the previously observed header
+// sealed by the same signer at the same height if there is any.
+func (monitor *DoubleSignMonitor) CheckDoubleSign(blockHeader *types.Header) *types.Header {
 	if rawBlockHeader, ok := monitor.observerdBlocks.Get(blockHeader.ParentHash); ok {
 		blockHeaders, _ := rawBlockHeader.([]*types.Header)
+		for _, header := range blockHeaders {
+			if bytes.Equal(header.Hash().Bytes(), blockHeader.Hash().Bytes()) {
+				return nil
+			}
+		}
 		for _, header := range blockHeaders {
 			// Simple check for monitoring only
-			if !bytes.Equal(header.Hash().Bytes(), blockHeader.Hash().Bytes()) &&
-				bytes.Equal(header.Coinbase[:], blockHeader.Coinbase[:]) {
+			if bytes.Equal(header.Coinbase[:], blockHeader.Coinbase[:]) {
 				log.Error("Double sign detected", "block number", header.Number, "signer", header.Coinbase,
 					"block 1 hash", header.Hash().Hex(), "block 1 signature", getSignature(header),
 					"block 2 hash", blockHeader.Hash().Hex(), "block 2 signature", getSignature(blockHeader),
 				)
-				break
+				return header
 			}
 		}
+		monitor.observerdBlocks.Add(blockHeader.ParentHash, append(blockHeaders, blockHeader))
 	} else {
 		blockHeaders := []*types.Header{blockHeader}
 		monitor.observerdBlocks.Add(blockHeader.ParentHash, blockHeaders)
 	}
+	return nil
 }
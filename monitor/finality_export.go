@@ -0,0 +1,235 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// maxFinalityExportRange is the maximum number of finalized blocks exported
+	// at a chain head, the older finalized blocks are skipped
+	maxFinalityExportRange  = 256
+	finalityExportQueueSize = 1024
+)
+
+// FinalizedBlockEvent is the finality data of a finalized block. The finality
+// votes for a block are included in its child block.
+type FinalizedBlockEvent struct {
+	Number              uint64           `json:"number"`
+	Hash                common.Hash      `json:"hash"`
+	VotedBitSet         hexutil.Big      `json:"votedBitSet"`
+	Participating       []common.Address `json:"participating"`
+	AggregatedSignature hexutil.Bytes    `json:"aggregatedSignature"`
+}
+
+// FinalityExporter delivers the finalized block events to an external sink
+type FinalityExporter interface {
+	Export(event *FinalizedBlockEvent) error
+}
+
+// FinalityExporterFactory creates the exporter for a sink url
+type FinalityExporterFactory func(url string) (FinalityExporter, error)
+
+var (
+	exporterFactoriesLock sync.RWMutex
+	exporterFactories     = map[string]FinalityExporterFactory{
+		"http":  newWebhookExporter,
+		"https": newWebhookExporter,
+		"file":  newFileExporter,
+	}
+)
+
+// RegisterFinalityExporter registers the exporter factory for the url scheme so
+// that other sinks (e.g. kafka://, nats://) can be plugged in.
+func RegisterFinalityExporter(scheme string, factory FinalityExporterFactory) {
+	exporterFactoriesLock.Lock()
+	defer exporterFactoriesLock.Unlock()
+	exporterFactories[scheme] = factory
+}
+
+// NewFinalityExporter creates the exporter registered for the scheme of the url
+func NewFinalityExporter(rawurl string) (FinalityExporter, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	exporterFactoriesLock.RLock()
+	factory, ok := exporterFactories[u.Scheme]
+	exporterFactoriesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no finality exporter for scheme %q", u.Scheme)
+	}
+	return factory(rawurl)
+}
+
+type webhookExporter struct {
+	notifier *webhookNotifier
+}
+
+func newWebhookExporter(url string) (FinalityExporter, error) {
+	return &webhookExporter{notifier: newWebhookNotifier(url)}, nil
+}
+
+func (exporter *webhookExporter) Export(event *FinalizedBlockEvent) error {
+	return exporter.notifier.Notify(event)
+}
+
+// fileExporter appends the events to a file as JSON lines, so that the log
+// shippers can tail it without running a webhook receiver
+type fileExporter struct {
+	lock    sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// newFileExporter opens the file of the url for appending, file:///abs/path for
+// an absolute path or file://rel/path for a path relative to the working directory
+func newFileExporter(rawurl string) (FinalityExporter, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, fmt.Errorf("no file path in finality export url %q", rawurl)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileExporter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (exporter *fileExporter) Export(event *FinalizedBlockEvent) error {
+	exporter.lock.Lock()
+	defer exporter.lock.Unlock()
+	return exporter.encoder.Encode(event)
+}
+
+// FinalityExportMonitor exports the newly finalized blocks at every chain head
+type FinalityExportMonitor struct {
+	chain     consensus.ChainHeaderReader
+	engine    consensus.FastFinalityPoSA
+	exporters []FinalityExporter
+	finalized uint64 // The last exported finalized block
+
+	queue chan *FinalizedBlockEvent
+}
+
+func NewFinalityExportMonitor(
+	chain consensus.ChainHeaderReader,
+	engine consensus.FastFinalityPoSA,
+	urls []string,
+) (*FinalityExportMonitor, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no finality export sink")
+	}
+	monitor := &FinalityExportMonitor{
+		chain:  chain,
+		engine: engine,
+		queue:  make(chan *FinalizedBlockEvent, finalityExportQueueSize),
+	}
+	for _, url := range urls {
+		exporter, err := NewFinalityExporter(url)
+		if err != nil {
+			return nil, err
+		}
+		monitor.exporters = append(monitor.exporters, exporter)
+	}
+	return monitor, nil
+}
+
+// Start delivers the queued events to the sinks in order until quit is closed
+func (monitor *FinalityExportMonitor) Start(quit <-chan struct{}) {
+	for {
+		select {
+		case event := <-monitor.queue:
+			for _, exporter := range monitor.exporters {
+				if err := exporter.Export(event); err != nil {
+					log.Error("Failed to export finalized block", "number", event.Number, "err", err)
+				}
+			}
+		case <-quit:
+			return
+		}
+	}
+}
+
+// CheckFinalized queues the events of the blocks finalized at the new chain head
+func (monitor *FinalityExportMonitor) CheckFinalized(header *types.Header) {
+	finalizedNumber, finalizedHash := monitor.engine.GetFinalizedBlock(monitor.chain, header.Number.Uint64(), header.Hash())
+	if finalizedNumber <= monitor.finalized {
+		return
+	}
+	from := monitor.finalized + 1
+	if monitor.finalized == 0 || finalizedNumber-from >= maxFinalityExportRange {
+		from = finalizedNumber
+	}
+
+	// Collect the finalized blocks backward from the finalized block
+	events := make([]*FinalizedBlockEvent, 0, finalizedNumber-from+1)
+	hash := finalizedHash
+	for number := finalizedNumber; number >= from; number-- {
+		block := monitor.chain.GetHeader(hash, number)
+		if block == nil {
+			break
+		}
+		if event := monitor.finalizedBlockEvent(block); event != nil {
+			events = append(events, event)
+		}
+		hash = block.ParentHash
+	}
+	monitor.finalized = finalizedNumber
+
+	for i := len(events) - 1; i >= 0; i-- {
+		select {
+		case monitor.queue <- events[i]:
+		default:
+			log.Warn("Finality export queue is full, drop finalized block", "number", events[i].Number)
+		}
+	}
+}
+
+// finalizedBlockEvent returns the event of the finalized block with the finality
+// votes included in its canonical child block
+func (monitor *FinalityExportMonitor) finalizedBlockEvent(block *types.Header) *FinalizedBlockEvent {
+	number := block.Number.Uint64()
+	child := monitor.chain.GetHeaderByNumber(number + 1)
+	if child == nil || child.ParentHash != block.Hash() {
+		return nil
+	}
+	extraData, err := finality.DecodeExtra(child.Extra, true)
+	if err != nil {
+		log.Error("Unexpected error when decode extradata", "err", err)
+		return nil
+	}
+
+	event := &FinalizedBlockEvent{
+		Number: number,
+		Hash:   block.Hash(),
+	}
+	if extraData.HasFinalityVote != 1 {
+		return event
+	}
+	event.VotedBitSet = hexutil.Big(*extraData.FinalityVotedValidators.Big())
+	event.AggregatedSignature = extraData.AggregatedFinalityVotes.Marshal()
+
+	validators := monitor.engine.GetActiveValidatorAt(monitor.chain, number, block.Hash())
+	for _, position := range extraData.FinalityVotedValidators.Indices() {
+		if position < len(validators) {
+			event.Participating = append(event.Participating, validators[position].Address)
+		}
+	}
+	return event
+}
@@ -0,0 +1,81 @@
+package monitor
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type testFinalityExporter struct {
+	events []*FinalizedBlockEvent
+}
+
+func (exporter *testFinalityExporter) Export(event *FinalizedBlockEvent) error {
+	exporter.events = append(exporter.events, event)
+	return nil
+}
+
+func TestNewFinalityExporter(t *testing.T) {
+	if _, err := NewFinalityExporter("kafka://localhost:9092/finality"); err == nil {
+		t.Fatalf("Expect error for unregistered scheme")
+	}
+
+	exporter := &testFinalityExporter{}
+	RegisterFinalityExporter("test", func(url string) (FinalityExporter, error) {
+		return exporter, nil
+	})
+	monitor, err := NewFinalityExportMonitor(nil, nil, []string{"test://sink", "http://localhost"})
+	if err != nil {
+		t.Fatalf("Failed to create finality export monitor, err %s", err)
+	}
+	if len(monitor.exporters) != 2 || monitor.exporters[0] != exporter {
+		t.Fatalf("Exporters mismatch, got %v", monitor.exporters)
+	}
+	if _, err := NewFinalityExportMonitor(nil, nil, nil); err == nil {
+		t.Fatalf("Expect error when no sink is set")
+	}
+}
+
+func TestFileFinalityExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "finality.jsonl")
+	exporter, err := NewFinalityExporter("file://" + path)
+	if err != nil {
+		t.Fatalf("Failed to create file exporter, err %s", err)
+	}
+	events := []*FinalizedBlockEvent{
+		{Number: 10, Hash: common.Hash{0x1}, VotedBitSet: hexutil.Big(*big.NewInt(0b111)), Participating: []common.Address{{0x1}}},
+		{Number: 11, Hash: common.Hash{0x2}},
+	}
+	for _, event := range events {
+		if err := exporter.Export(event); err != nil {
+			t.Fatalf("Failed to export event, err %s", err)
+		}
+	}
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read exported events, err %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(blob)), "\n")
+	if len(lines) != len(events) {
+		t.Fatalf("Expect %d lines, got %d", len(events), len(lines))
+	}
+	for i, line := range lines {
+		var event FinalizedBlockEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Failed to decode line %d, err %s", i, err)
+		}
+		if event.Number != events[i].Number || event.Hash != events[i].Hash || event.VotedBitSet.ToInt().Cmp(events[i].VotedBitSet.ToInt()) != 0 {
+			t.Fatalf("Event %d mismatch, expect %+v got %+v", i, events[i], event)
+		}
+	}
+	if _, err := NewFinalityExporter("file://"); err == nil {
+		t.Fatalf("Expect error without file path")
+	}
+}
@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	webhookTimeout = 5 * time.Second
+
+	FinalityStalledEvent   = "finality_stalled"
+	FinalityRecoveredEvent = "finality_recovered"
+)
+
+// FinalityStallPayload is the JSON body posted to the webhook when the finality
+// stalls or recovers.
+type FinalityStallPayload struct {
+	Event           string           `json:"event"`
+	HeadNumber      uint64           `json:"headNumber"`
+	HeadHash        common.Hash      `json:"headHash"`
+	FinalizedNumber uint64           `json:"finalizedNumber"`
+	FinalizedHash   common.Hash      `json:"finalizedHash"`
+	Distance        uint64           `json:"distance"`
+	Threshold       uint64           `json:"threshold"`
+	Participating   []common.Address `json:"participating"`
+	Missing         []common.Address `json:"missing"`
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookNotifier(url string) *webhookNotifier {
+	return &webhookNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return errors.New("invalid redirect")
+			},
+		},
+	}
+}
+
+func (notifier *webhookNotifier) Notify(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	response, err := notifier.client.Post(notifier.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		responseBody := make([]byte, reponseBuffer)
+		n, _ := response.Body.Read(responseBody)
+		return fmt.Errorf("webhook responded with status %d: %s", response.StatusCode, string(responseBody[:n]))
+	}
+	return nil
+}
+
+// FinalityStallMonitor fires a webhook when the finalized block has not advanced
+// for threshold blocks and again when the finality recovers.
+type FinalityStallMonitor struct {
+	chain     consensus.ChainHeaderReader
+	engine    consensus.FastFinalityPoSA
+	threshold uint64
+	notifier  *webhookNotifier
+	stalled   bool
+}
+
+func NewFinalityStallMonitor(
+	chain consensus.ChainHeaderReader,
+	engine consensus.FastFinalityPoSA,
+	url string,
+	threshold uint64,
+) (*FinalityStallMonitor, error) {
+	if url == "" {
+		return nil, errors.New("empty finality stall webhook url")
+	}
+	if threshold == 0 {
+		return nil, errors.New("finality stall threshold must be greater than 0")
+	}
+
+	return &FinalityStallMonitor{
+		chain:     chain,
+		engine:    engine,
+		threshold: threshold,
+		notifier:  newWebhookNotifier(url),
+	}, nil
+}
+
+// updateStatus records the distance between the head and the finalized block and
+// returns the event to fire if the stall status changes.
+func (monitor *FinalityStallMonitor) updateStatus(headNumber, finalizedNumber uint64) (string, bool) {
+	var distance uint64
+	if headNumber > finalizedNumber {
+		distance = headNumber - finalizedNumber
+	}
+
+	if !monitor.stalled && distance >= monitor.threshold {
+		monitor.stalled = true
+		return FinalityStalledEvent, true
+	}
+	if monitor.stalled && distance < monitor.threshold {
+		monitor.stalled = false
+		return FinalityRecoveredEvent, true
+	}
+	return "", false
+}
+
+// CheckFinalityProgress checks the finality progress at the new chain head and
+// notifies the webhook when the finality stalls or recovers.
+func (monitor *FinalityStallMonitor) CheckFinalityProgress(header *types.Header) {
+	headNumber := header.Number.Uint64()
+	finalizedNumber, finalizedHash := monitor.engine.GetFinalizedBlock(monitor.chain, headNumber, header.Hash())
+
+	event, fire := monitor.updateStatus(headNumber, finalizedNumber)
+	if !fire {
+		return
+	}
+
+	payload := FinalityStallPayload{
+		Event:           event,
+		HeadNumber:      headNumber,
+		HeadHash:        header.Hash(),
+		FinalizedNumber: finalizedNumber,
+		FinalizedHash:   finalizedHash,
+		Distance:        headNumber - finalizedNumber,
+		Threshold:       monitor.threshold,
+	}
+	payload.Participating, payload.Missing = monitor.voteBreakdown(header)
+
+	if event == FinalityStalledEvent {
+		log.Warn("Finality stalled", "head", headNumber, "finalized", finalizedNumber, "missing", len(payload.Missing))
+	} else {
+		log.Info("Finality recovered", "head", headNumber, "finalized", finalizedNumber)
+	}
+
+	// Do not block the chain event loop on the webhook
+	go func() {
+		if err := monitor.notifier.Notify(&payload); err != nil {
+			log.Error("Failed to notify finality stall webhook", "event", event, "err", err)
+		}
+	}()
+}
+
+// voteBreakdown returns the validators that have their finality vote included in
+// the header and the ones that have not.
+func (monitor *FinalityStallMonitor) voteBreakdown(header *types.Header) ([]common.Address, []common.Address) {
+	var participating, missing []common.Address
+
+	validators := monitor.engine.GetActiveValidatorAt(monitor.chain, header.Number.Uint64()-1, header.ParentHash)
+	if len(validators) == 0 {
+		return participating, missing
+	}
+
+	extraData, err := finality.DecodeExtra(header.Extra, true)
+	if err != nil {
+		log.Error("Unexpected error when decode extradata", "err", err)
+		return participating, missing
+	}
+
+	voted := make(map[int]struct{})
+	if extraData.HasFinalityVote == 1 {
+		for _, position := range extraData.FinalityVotedValidators.Indices() {
+			voted[position] = struct{}{}
+		}
+	}
+	for position, validator := range validators {
+		if _, ok := voted[position]; ok {
+			participating = append(participating, validator.Address)
+		} else {
+			missing = append(missing, validator.Address)
+		}
+	}
+	return participating, missing
+}
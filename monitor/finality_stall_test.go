@@ -0,0 +1,59 @@
+package monitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestFinalityStallStatus(t *testing.T) {
+	monitor, err := NewFinalityStallMonitor(nil, nil, "http://localhost", 10)
+	if err != nil {
+		t.Fatalf("Failed to create finality stall monitor, err %s", err)
+	}
+
+	if _, fire := monitor.updateStatus(100, 95); fire {
+		t.Fatalf("Expect no event when finality is progressing")
+	}
+	event, fire := monitor.updateStatus(105, 95)
+	if !fire || event != FinalityStalledEvent {
+		t.Fatalf("Expect stalled event, got %s %v", event, fire)
+	}
+	if _, fire := monitor.updateStatus(106, 95); fire {
+		t.Fatalf("Expect no duplicated stalled event")
+	}
+	event, fire = monitor.updateStatus(107, 106)
+	if !fire || event != FinalityRecoveredEvent {
+		t.Fatalf("Expect recovered event, got %s %v", event, fire)
+	}
+	if _, fire := monitor.updateStatus(108, 107); fire {
+		t.Fatalf("Expect no duplicated recovered event")
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received FinalityStallPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	payload := FinalityStallPayload{
+		Event:         FinalityStalledEvent,
+		HeadNumber:    105,
+		Participating: []common.Address{{0x1}},
+		Missing:       []common.Address{{0x2}},
+	}
+	if err := newWebhookNotifier(server.URL).Notify(&payload); err != nil {
+		t.Fatalf("Failed to notify webhook, err %s", err)
+	}
+	if received.Event != payload.Event || received.HeadNumber != payload.HeadNumber ||
+		len(received.Missing) != 1 || received.Missing[0] != payload.Missing[0] {
+		t.Fatalf("Payload mismatch, exp %+v got %+v", payload, received)
+	}
+}
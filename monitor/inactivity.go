@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const ValidatorInactiveEvent = "validator_inactive"
+
+// InactivityPayload is the JSON body posted to the webhook when the local validator
+// misses too many consecutive in-turn slots.
+type InactivityPayload struct {
+	Event             string         `json:"event"`
+	Validator         common.Address `json:"validator"`
+	ConsecutiveMissed uint64         `json:"consecutiveMissed"`
+	Missed            uint64         `json:"missed"`
+	LastMissed        uint64         `json:"lastMissed"`
+	LastSealed        uint64         `json:"lastSealed"`
+}
+
+// InactivityAlerter notifies the operator that the local validator is inactive by
+// posting to a webhook and/or running a local command. The command is run by the
+// shell with the RONIN_VALIDATOR and RONIN_MISSED environment variables set.
+type InactivityAlerter struct {
+	notifier *webhookNotifier
+	command  string
+}
+
+func NewInactivityAlerter(url, command string) (*InactivityAlerter, error) {
+	if url == "" && command == "" {
+		return nil, errors.New("neither inactivity webhook url nor command is set")
+	}
+
+	alerter := &InactivityAlerter{command: command}
+	if url != "" {
+		alerter.notifier = newWebhookNotifier(url)
+	}
+	return alerter, nil
+}
+
+func (alerter *InactivityAlerter) Alert(payload *InactivityPayload) {
+	payload.Event = ValidatorInactiveEvent
+	if alerter.notifier != nil {
+		if err := alerter.notifier.Notify(payload); err != nil {
+			log.Error("Failed to notify inactivity webhook", "err", err)
+		}
+	}
+	if alerter.command != "" {
+		cmd := exec.Command("sh", "-c", alerter.command)
+		cmd.Env = append(os.Environ(),
+			"RONIN_VALIDATOR="+payload.Validator.Hex(),
+			fmt.Sprintf("RONIN_MISSED=%d", payload.ConsecutiveMissed),
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			log.Error("Failed to run inactivity alert command", "err", err, "output", string(output))
+		}
+	}
+}
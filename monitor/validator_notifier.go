@@ -0,0 +1,334 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	ValidatorMissedTurnEvent     = "validator_missed_turn"
+	FinalityVoteNotIncludedEvent = "finality_vote_not_included"
+	EquivocationDetectedEvent    = "equivocation_detected"
+	EpochWrapUpFailedEvent       = "epoch_wrapup_failed"
+	HardforkImminentEvent        = "hardfork_imminent"
+
+	notifiedEventsCache = 256
+
+	// maxWatchedHeadAge is the age above which the chain heads are not checked
+	// by the validator watcher, so that the past events are not notified while
+	// the node is syncing
+	maxWatchedHeadAge = time.Minute
+)
+
+// ValidatorNotificationEvents are the events the validator notifier supports
+var ValidatorNotificationEvents = []string{
+	ValidatorMissedTurnEvent,
+	FinalityVoteNotIncludedEvent,
+	EquivocationDetectedEvent,
+	EpochWrapUpFailedEvent,
+	HardforkImminentEvent,
+}
+
+// MissedTurnPayload is the JSON body posted to the webhooks when the local
+// validator misses its in-turn slot
+type MissedTurnPayload struct {
+	Event     string         `json:"event"`
+	Validator common.Address `json:"validator"`
+	Number    uint64         `json:"number"`
+	Hash      common.Hash    `json:"hash"`
+	SealedBy  common.Address `json:"sealedBy"`
+}
+
+// VoteNotIncludedPayload is the JSON body posted to the webhooks when the finality
+// vote of the local validator is not included in the child of the voted block
+type VoteNotIncludedPayload struct {
+	Event       string         `json:"event"`
+	Validator   common.Address `json:"validator"`
+	VotedNumber uint64         `json:"votedNumber"`
+	VotedHash   common.Hash    `json:"votedHash"`
+	Number      uint64         `json:"number"` // Block expected to include the vote
+	Hash        common.Hash    `json:"hash"`
+}
+
+// EquivocationPayload is the JSON body posted to the webhooks when two blocks
+// sealed by the same validator at the same height are detected
+type EquivocationPayload struct {
+	Event     string         `json:"event"`
+	Validator common.Address `json:"validator"`
+	Number    uint64         `json:"number"`
+	Hash1     common.Hash    `json:"hash1"`
+	Hash2     common.Hash    `json:"hash2"`
+}
+
+// WrapUpFailurePayload is the JSON body posted to the webhooks when the epoch
+// wrap-up system transaction fails
+type WrapUpFailurePayload struct {
+	Event   string         `json:"event"`
+	Number  uint64         `json:"number"`
+	Sealer  common.Address `json:"sealer"`
+	Sealing bool           `json:"sealing"` // The block is assembled by this node
+	Error   string         `json:"error"`
+}
+
+// HardforkPayload is the JSON body posted to the webhooks when the chain head
+// approaches a hardfork block
+type HardforkPayload struct {
+	Event      string `json:"event"`
+	Fork       string `json:"fork"`
+	ForkBlock  uint64 `json:"forkBlock"`
+	HeadNumber uint64 `json:"headNumber"`
+	Remaining  uint64 `json:"remaining"`
+}
+
+// ValidatorNotifier posts the events of the validator operation to the webhooks,
+// each event is notified once.
+type ValidatorNotifier struct {
+	notifiers []*webhookNotifier
+	events    map[string]struct{}
+	notified  *lru.Cache // Keys of the recently notified events
+}
+
+// NewValidatorNotifier creates the notifier posting to the webhook urls, only
+// the events are notified or all of them if events is empty.
+func NewValidatorNotifier(urls []string, events []string) (*ValidatorNotifier, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no validator notification webhook url")
+	}
+	if len(events) == 0 {
+		events = ValidatorNotificationEvents
+	}
+	notifier := &ValidatorNotifier{events: make(map[string]struct{})}
+	for _, event := range events {
+		supported := false
+		for _, e := range ValidatorNotificationEvents {
+			supported = supported || e == event
+		}
+		if !supported {
+			return nil, fmt.Errorf("unknown validator notification event %q, supported: %s",
+				event, strings.Join(ValidatorNotificationEvents, ", "))
+		}
+		notifier.events[event] = struct{}{}
+	}
+	for _, url := range urls {
+		notifier.notifiers = append(notifier.notifiers, newWebhookNotifier(url))
+	}
+	notifier.notified, _ = lru.New(notifiedEventsCache)
+	return notifier, nil
+}
+
+// Enabled returns whether the event is notified
+func (notifier *ValidatorNotifier) Enabled(event string) bool {
+	_, ok := notifier.events[event]
+	return ok
+}
+
+// notify posts the payload to the webhooks unless the event is disabled or the
+// key is already notified. It does not block on the webhooks.
+func (notifier *ValidatorNotifier) notify(event string, key string, payload interface{}) {
+	if !notifier.Enabled(event) {
+		return
+	}
+	if seen, _ := notifier.notified.ContainsOrAdd(event+"/"+key, struct{}{}); seen {
+		return
+	}
+	for _, webhook := range notifier.notifiers {
+		go func(webhook *webhookNotifier) {
+			if err := webhook.Notify(payload); err != nil {
+				log.Error("Failed to notify validator webhook", "event", event, "url", webhook.url, "err", err)
+			}
+		}(webhook)
+	}
+}
+
+// NotifyEquivocation notifies the two headers sealed by the same validator at
+// the same height
+func (notifier *ValidatorNotifier) NotifyEquivocation(header1, header2 *types.Header) {
+	notifier.notify(EquivocationDetectedEvent, header1.Hash().Hex()+header2.Hash().Hex(), &EquivocationPayload{
+		Event:     EquivocationDetectedEvent,
+		Validator: header2.Coinbase,
+		Number:    header2.Number.Uint64(),
+		Hash1:     header1.Hash(),
+		Hash2:     header2.Hash(),
+	})
+}
+
+// NotifyWrapUpFailure notifies the failed epoch wrap-up of the header. The
+// failure is notified once per block as a block is assembled several times
+// while sealing.
+func (notifier *ValidatorNotifier) NotifyWrapUpFailure(header *types.Header, sealing bool, err error) {
+	notifier.notify(EpochWrapUpFailedEvent, header.Number.String(), &WrapUpFailurePayload{
+		Event:   EpochWrapUpFailedEvent,
+		Number:  header.Number.Uint64(),
+		Sealer:  header.Coinbase,
+		Sealing: sealing,
+		Error:   err.Error(),
+	})
+}
+
+// validatorEngine is the consensus engine the validator watcher reads the missed
+// in-turn slots and the finality votes from
+type validatorEngine interface {
+	consensus.FastFinalityPoSA
+	SpoiledValidator(chain consensus.ChainHeaderReader, header *types.Header) (common.Address, bool)
+}
+
+// ValidatorWatcher checks the new chain heads for the missed in-turn slots and
+// finality votes of the local validator and for the upcoming hardforks.
+type ValidatorWatcher struct {
+	chain     consensus.ChainHeaderReader
+	engine    validatorEngine
+	notifier  *ValidatorNotifier
+	validator func() common.Address // Address of the local validator, zero if it is not a validator
+
+	hardforkDistance uint64 // Number of blocks before a hardfork it is notified, 0 = disabled
+	voteMissing      bool   // The local vote is not included in the last checked head
+}
+
+func NewValidatorWatcher(
+	chain consensus.ChainHeaderReader,
+	engine consensus.Engine,
+	notifier *ValidatorNotifier,
+	validator func() common.Address,
+	hardforkDistance uint64,
+) (*ValidatorWatcher, error) {
+	validatorEngine, ok := engine.(validatorEngine)
+	if !ok {
+		return nil, errors.New("consensus engine does not track the in-turn validators")
+	}
+	return &ValidatorWatcher{
+		chain:            chain,
+		engine:           validatorEngine,
+		notifier:         notifier,
+		validator:        validator,
+		hardforkDistance: hardforkDistance,
+	}, nil
+}
+
+// CheckHead checks the new chain head and notifies the events it raises
+func (watcher *ValidatorWatcher) CheckHead(header *types.Header) {
+	config := watcher.chain.Config()
+	number := header.Number.Uint64()
+
+	if watcher.hardforkDistance > 0 {
+		for _, fork := range upcomingForks(config, number, watcher.hardforkDistance) {
+			watcher.notifier.notify(HardforkImminentEvent, fork.name+"/"+fork.block.String(), &HardforkPayload{
+				Event:      HardforkImminentEvent,
+				Fork:       fork.name,
+				ForkBlock:  fork.block.Uint64(),
+				HeadNumber: number,
+				Remaining:  fork.block.Uint64() - number,
+			})
+		}
+	}
+
+	validator := watcher.validator()
+	if validator == (common.Address{}) || number == 0 {
+		return
+	}
+	if time.Since(time.Unix(int64(header.Time), 0)) > maxWatchedHeadAge {
+		return
+	}
+	if spoiledVal, spoiled := watcher.engine.SpoiledValidator(watcher.chain, header); spoiled && spoiledVal == validator {
+		log.Warn("Local validator missed its in-turn slot", "number", number, "sealer", header.Coinbase)
+		watcher.notifier.notify(ValidatorMissedTurnEvent, header.Hash().Hex(), &MissedTurnPayload{
+			Event:     ValidatorMissedTurnEvent,
+			Validator: validator,
+			Number:    number,
+			Hash:      header.Hash(),
+			SealedBy:  header.Coinbase,
+		})
+	}
+	// The votes for the parent are included in the header
+	if config.IsShillin(new(big.Int).SetUint64(number - 1)) {
+		watcher.checkVoteIncluded(header, validator)
+	}
+}
+
+// checkVoteIncluded notifies when the finality vote of the local validator for
+// the parent is not included in the header. It is notified once until the vote
+// is included again.
+func (watcher *ValidatorWatcher) checkVoteIncluded(header *types.Header, validator common.Address) {
+	included, active := voteIncluded(watcher.chain, watcher.engine, header, validator)
+	if !active {
+		return
+	}
+	if included {
+		watcher.voteMissing = false
+		return
+	}
+	if watcher.voteMissing {
+		return
+	}
+	watcher.voteMissing = true
+	log.Warn("Local finality vote is not included", "number", header.Number, "voted", header.ParentHash)
+	watcher.notifier.notify(FinalityVoteNotIncludedEvent, header.Hash().Hex(), &VoteNotIncludedPayload{
+		Event:       FinalityVoteNotIncludedEvent,
+		Validator:   validator,
+		VotedNumber: header.Number.Uint64() - 1,
+		VotedHash:   header.ParentHash,
+		Number:      header.Number.Uint64(),
+		Hash:        header.Hash(),
+	})
+}
+
+// voteIncluded returns whether the finality vote of the validator is included
+// in the header, active is false if the validator is not a voter of the parent.
+func voteIncluded(
+	chain consensus.ChainHeaderReader,
+	engine consensus.FastFinalityPoSA,
+	header *types.Header,
+	validator common.Address,
+) (included bool, active bool) {
+	validators := engine.GetActiveValidatorAt(chain, header.Number.Uint64()-1, header.ParentHash)
+	position := -1
+	for i, val := range validators {
+		if val.Address == validator {
+			position = i
+			break
+		}
+	}
+	if position < 0 {
+		return false, false
+	}
+	extraData, err := finality.DecodeExtra(header.Extra, true)
+	if err != nil {
+		log.Error("Unexpected error when decode extradata", "err", err)
+		return false, false
+	}
+	if extraData.HasFinalityVote == 1 {
+		for _, voted := range extraData.FinalityVotedValidators.Indices() {
+			if voted == position {
+				return true, true
+			}
+		}
+	}
+	return false, true
+}
+
+type chainFork struct {
+	name  string
+	block *big.Int
+}
+
+// upcomingForks returns the forks of the config activated in the distance blocks
+// after head
+func upcomingForks(config *params.ChainConfig, head uint64, distance uint64) []chainFork {
+	var forks []chainFork
+	for _, fork := range config.ForkBlocks() {
+		if number := fork.Block.Uint64(); number > head && number-head <= distance {
+			forks = append(forks, chainFork{name: fork.Name, block: fork.Block})
+		}
+	}
+	return forks
+}
@@ -0,0 +1,141 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/bls/blst"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestNewValidatorNotifier(t *testing.T) {
+	if _, err := NewValidatorNotifier(nil, nil); err == nil {
+		t.Fatalf("Expect error when no webhook url is set")
+	}
+	if _, err := NewValidatorNotifier([]string{"http://localhost"}, []string{"unknown"}); err == nil {
+		t.Fatalf("Expect error for unknown event")
+	}
+
+	notifier, err := NewValidatorNotifier([]string{"http://localhost"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create validator notifier, err %s", err)
+	}
+	for _, event := range ValidatorNotificationEvents {
+		if !notifier.Enabled(event) {
+			t.Fatalf("Expect event %s to be enabled by default", event)
+		}
+	}
+
+	notifier, err = NewValidatorNotifier([]string{"http://localhost"}, []string{HardforkImminentEvent})
+	if err != nil {
+		t.Fatalf("Failed to create validator notifier, err %s", err)
+	}
+	if !notifier.Enabled(HardforkImminentEvent) || notifier.Enabled(EquivocationDetectedEvent) {
+		t.Fatalf("Expect only the hardfork event to be enabled")
+	}
+}
+
+func TestValidatorNotifierOnce(t *testing.T) {
+	received := make(chan WrapUpFailurePayload, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WrapUpFailurePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	notifier, err := NewValidatorNotifier([]string{server.URL}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create validator notifier, err %s", err)
+	}
+	header := &types.Header{Number: big.NewInt(199), Coinbase: common.Address{0x1}}
+	notifier.NotifyWrapUpFailure(header, true, errors.New("execution reverted"))
+	// The block is assembled again while sealing
+	notifier.NotifyWrapUpFailure(header, true, errors.New("execution reverted"))
+
+	select {
+	case payload := <-received:
+		if payload.Event != EpochWrapUpFailedEvent || payload.Number != 199 || !payload.Sealing || payload.Error != "execution reverted" {
+			t.Fatalf("Payload mismatch, got %+v", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expect the wrap-up failure to be notified")
+	}
+	select {
+	case payload := <-received:
+		t.Fatalf("Expect the wrap-up failure to be notified once, got %+v", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestUpcomingForks(t *testing.T) {
+	config := &params.ChainConfig{
+		ShillinBlock: big.NewInt(100),
+		MikoBlock:    big.NewInt(150),
+		TrippBlock:   big.NewInt(200),
+	}
+
+	forks := upcomingForks(config, 50, 60)
+	if len(forks) != 1 || forks[0].name != "Shillin" || forks[0].block.Uint64() != 100 {
+		t.Fatalf("Expect Shillin fork, got %v", forks)
+	}
+	forks = upcomingForks(config, 100, 100)
+	if len(forks) != 2 || forks[0].name != "Miko" || forks[1].name != "Tripp" {
+		t.Fatalf("Expect Miko and Tripp forks, got %v", forks)
+	}
+	if forks := upcomingForks(config, 200, 1000); len(forks) != 0 {
+		t.Fatalf("Expect no fork after the last one, got %v", forks)
+	}
+}
+
+type testVoteEngine struct {
+	consensus.FastFinalityPoSA
+	validators []finality.ValidatorWithBlsPub
+}
+
+func (engine *testVoteEngine) GetActiveValidatorAt(chain consensus.ChainHeaderReader, blockNumber uint64, blockHash common.Hash) []finality.ValidatorWithBlsPub {
+	return engine.validators
+}
+
+func TestVoteIncluded(t *testing.T) {
+	secretKey, err := blst.RandKey()
+	if err != nil {
+		t.Fatalf("Failed to generate BLS key, err %s", err)
+	}
+	engine := &testVoteEngine{
+		validators: []finality.ValidatorWithBlsPub{{Address: common.Address{0x1}}, {Address: common.Address{0x2}}},
+	}
+	extraData := &finality.HeaderExtraData{
+		HasFinalityVote:         1,
+		AggregatedFinalityVotes: secretKey.Sign([]byte("vote")),
+	}
+	extraData.FinalityVotedValidators.SetBit(1)
+	header := &types.Header{Number: big.NewInt(10), Extra: extraData.Encode(true)}
+
+	if included, active := voteIncluded(nil, engine, header, common.Address{0x2}); !included || !active {
+		t.Fatalf("Expect the vote of the second validator to be included")
+	}
+	if included, active := voteIncluded(nil, engine, header, common.Address{0x1}); included || !active {
+		t.Fatalf("Expect the vote of the first validator not to be included")
+	}
+	if _, active := voteIncluded(nil, engine, header, common.Address{0x3}); active {
+		t.Fatalf("Expect a non validator not to be active")
+	}
+
+	header.Extra = (&finality.HeaderExtraData{}).Encode(true)
+	if included, active := voteIncluded(nil, engine, header, common.Address{0x2}); included || !active {
+		t.Fatalf("Expect no vote to be included in the header without finality votes")
+	}
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const VoteCanaryFailedEvent = "vote_canary_failed"
+
+// VoteCanaryPayload is the JSON body posted to the webhook when the self-test of
+// the BLS vote key fails
+type VoteCanaryPayload struct {
+	Event     string        `json:"event"`
+	PublicKey hexutil.Bytes `json:"publicKey"`
+	Error     string        `json:"error"`
+}
+
+// VoteCanaryAlerter notifies the operator that the BLS vote key is corrupted or
+// does not match the on-chain registration
+type VoteCanaryAlerter struct {
+	notifier *webhookNotifier
+}
+
+func NewVoteCanaryAlerter(url string) *VoteCanaryAlerter {
+	return &VoteCanaryAlerter{notifier: newWebhookNotifier(url)}
+}
+
+func (alerter *VoteCanaryAlerter) Alert(publicKey []byte, err error) {
+	payload := &VoteCanaryPayload{
+		Event:     VoteCanaryFailedEvent,
+		PublicKey: publicKey,
+		Error:     err.Error(),
+	}
+	if err := alerter.notifier.Notify(payload); err != nil {
+		log.Error("Failed to notify vote canary webhook", "err", err)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/debug"
+	"github.com/ethereum/go-ethereum/internal/features"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
@@ -61,6 +62,16 @@ type privateAdminAPI struct {
 	node *Node // Node interfaced by this API
 }
 
+// Features returns the state of the node feature flags.
+func (api *privateAdminAPI) Features() []features.Info {
+	return features.List()
+}
+
+// SetFeature toggles a feature flag that can be changed at runtime.
+func (api *privateAdminAPI) SetFeature(name string, enabled bool) (features.Info, error) {
+	return features.Set(name, enabled)
+}
+
 // AddPeer requests connecting to a remote node, and also maintaining the new
 // connection at all times, even reconnecting if it is lost.
 func (api *privateAdminAPI) AddPeer(url string) (bool, error) {
@@ -186,6 +197,11 @@ func (api *privateAdminAPI) StartHTTP(host *string, port *int, cors *string, api
 		Vhosts:             api.node.config.HTTPVirtualHosts,
 		Modules:            api.node.config.HTTPModules,
 	}
+	secret, err := api.node.jwtSecret()
+	if err != nil {
+		return false, err
+	}
+	config.jwtSecret = secret
 	if cors != nil {
 		config.CorsAllowedOrigins = nil
 		for _, origin := range strings.Split(*cors, ",") {
@@ -260,6 +276,11 @@ func (api *privateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 		Origins: api.node.config.WSOrigins,
 		// ExposeAll: api.node.config.WSExposeAll,
 	}
+	secret, err := api.node.jwtSecret()
+	if err != nil {
+		return false, err
+	}
+	config.jwtSecret = secret
 	if apis != nil {
 		config.Modules = nil
 		for _, m := range strings.Split(*apis, ",") {
@@ -25,6 +25,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -176,6 +177,12 @@ type Config struct {
 	// WSWriteBuffer is the size of write buffer when starting the WS. Default value is 1024 bytes.
 	WSWriteBuffer int `toml:",omitempty"`
 
+	// JWTSecret is the path of the hex encoded secret authenticating the HTTP and
+	// WebSocket RPC calls with JWT tokens, it is generated if the file does not
+	// exist. The calls are not authenticated if it is empty. The tokens without the
+	// operator scope are restricted to the read-only methods, see ReadOnlyMethods.
+	JWTSecret string `toml:",omitempty"`
+
 	// GraphQLCors is the Cross-Origin Resource Sharing header to send to requesting
 	// clients. Please be aware that CORS is a browser enforced security, it's fully
 	// useless for custom HTTP clients.
@@ -207,6 +214,15 @@ type Config struct {
 	// The path of password and encrypted BLS secret key used for fast finality voting
 	BlsPasswordPath string
 	BlsWalletPath   string
+	// The path of the socket of the vote signer process holding the BLS key, the
+	// BLS wallet is not opened by the node if it is set
+	BlsRemoteSigner string
+	// The path of the history of the signed finality votes, refusing the conflicting
+	// votes, in the instance directory if it is empty
+	BlsProtectionPath string
+	// The maximum duration the sealer keeps waiting for the finality votes to reach
+	// quorum before the release of the block
+	VoteAssemblyWindow time.Duration
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
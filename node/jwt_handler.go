@@ -0,0 +1,213 @@
+package node
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// ScopeOperator is the JWT scope allowing all the methods, the tokens without
+	// it are restricted to the read-only methods
+	ScopeOperator = "operator"
+
+	// jwtIssuedAtWindow is the allowed drift of the issued at claim of the tokens
+	// without an expiry, as with the engine API
+	jwtIssuedAtWindow = 60 * time.Second
+)
+
+// ReadOnlyMethods are the methods allowed to the JWT tokens without the operator
+// scope, e.g. the monitoring tokens. The other methods, including all the admin,
+// miner and debug methods, require the operator scope.
+var ReadOnlyMethods = []string{
+	"eth_blockNumber",
+	"eth_chainId",
+	"eth_syncing",
+	"eth_gasPrice",
+	"eth_maxPriorityFeePerGas",
+	"eth_feeHistory",
+	"eth_getBalance",
+	"eth_getCode",
+	"eth_getStorageAt",
+	"eth_getTransactionCount",
+	"eth_getBlockByHash",
+	"eth_getBlockByNumber",
+	"eth_getBlockTransactionCountByHash",
+	"eth_getBlockTransactionCountByNumber",
+	"eth_getTransactionByHash",
+	"eth_getTransactionByBlockHashAndIndex",
+	"eth_getTransactionByBlockNumberAndIndex",
+	"eth_getTransactionReceipt",
+	"eth_getLogs",
+	"eth_call",
+	"eth_estimateGas",
+	"eth_subscribe",
+	"eth_unsubscribe",
+	"net_version",
+	"net_listening",
+	"net_peerCount",
+	"web3_clientVersion",
+	"txpool_status",
+	"rpc_modules",
+}
+
+var (
+	errMissingToken  = errors.New("missing token")
+	errInvalidToken  = errors.New("invalid token")
+	errExpiredToken  = errors.New("token is expired")
+	errStaleIssuedAt = errors.New("stale token")
+)
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// jwtClaims are the claims of the JWT tokens authenticating the RPC calls, the
+// scope is a space separated list
+type jwtClaims struct {
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// IssueJWT returns a token signed with the secret allowing the scopes. The token
+// does not expire if expiry is zero, it is then only valid within a minute of its
+// issuance.
+func IssueJWT(secret []byte, scopes []string, expiry time.Duration) (string, error) {
+	claims := jwtClaims{IssuedAt: time.Now().Unix(), Scope: strings.Join(scopes, " ")}
+	if expiry > 0 {
+		claims.ExpiresAt = time.Now().Add(expiry).Unix()
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtSignature(secret, unsigned), nil
+}
+
+func jwtSignature(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyJWT checks the HS256 signature and the validity period of the token and
+// returns its claims
+func verifyJWT(secret []byte, token string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	var alg struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(header, &alg); err != nil || alg.Alg != "HS256" {
+		return nil, errInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(jwtSignature(secret, parts[0]+"."+parts[1]))) {
+		return nil, errInvalidToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	claims := new(jwtClaims)
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, errInvalidToken
+	}
+	if claims.ExpiresAt != 0 {
+		if now.Unix() >= claims.ExpiresAt {
+			return nil, errExpiredToken
+		}
+		return claims, nil
+	}
+	issuedAt := time.Unix(claims.IssuedAt, 0)
+	if issuedAt.Before(now.Add(-jwtIssuedAtWindow)) || issuedAt.After(now.Add(jwtIssuedAtWindow)) {
+		return nil, errStaleIssuedAt
+	}
+	return claims, nil
+}
+
+// jwtHandler authenticates the requests with the bearer JWT tokens signed with
+// the secret and restricts their calls to the scopes of the token.
+type jwtHandler struct {
+	secret []byte
+	next   http.Handler
+}
+
+func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+	return &jwtHandler{secret: secret, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (handler *jwtHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		http.Error(w, errMissingToken.Error(), http.StatusUnauthorized)
+		return
+	}
+	claims, err := verifyJWT(handler.secret, strings.TrimPrefix(auth, "Bearer "), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	ctx := rpc.WithAuthScopes(r.Context(), strings.Fields(claims.Scope))
+	handler.next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// restrictMethods allows the methods of the server to the operator scope only,
+// except the read-only methods
+func restrictMethods(srv *rpc.Server) {
+	srv.SetDefaultScope(ScopeOperator)
+	for _, method := range ReadOnlyMethods {
+		srv.SetMethodScope(method, "")
+	}
+}
+
+// ObtainJWTSecret reads the hex encoded JWT secret from the file, it generates a
+// random one and writes it to the file if the file does not exist.
+func ObtainJWTSecret(path string) ([]byte, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		secret := common.FromHex(strings.TrimSpace(string(data)))
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("invalid JWT secret in %s, want 32 hex encoded bytes", path)
+		}
+		log.Info("Loaded JWT secret file", "path", path)
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(common.Bytes2Hex(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("Generated JWT secret", "path", path)
+	return secret, nil
+}
+
+// jwtSecret returns the JWT secret of the configuration, nil if the RPC calls
+// are not authenticated
+func (n *Node) jwtSecret() ([]byte, error) {
+	if n.config.JWTSecret == "" {
+		return nil, nil
+	}
+	return ObtainJWTSecret(n.config.JWTSecret)
+}
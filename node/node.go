@@ -359,6 +359,11 @@ func (n *Node) startRPC() error {
 		}
 	}
 
+	secret, err := n.jwtSecret()
+	if err != nil {
+		return err
+	}
+
 	// Configure HTTP.
 	if n.config.HTTPHost != "" {
 		config := httpConfig{
@@ -366,6 +371,7 @@ func (n *Node) startRPC() error {
 			Vhosts:             n.config.HTTPVirtualHosts,
 			Modules:            n.config.HTTPModules,
 			prefix:             n.config.HTTPPathPrefix,
+			jwtSecret:          secret,
 		}
 		if err := n.http.setListenAddr(n.config.HTTPHost, n.config.HTTPPort); err != nil {
 			return err
@@ -384,6 +390,7 @@ func (n *Node) startRPC() error {
 			prefix:        n.config.WSPathPrefix,
 			wsreadbuffer:  n.config.WSReadBuffer,
 			wswritebuffer: n.config.WSWriteBuffer,
+			jwtSecret:     secret,
 		}
 		if err := server.setListenAddr(n.config.WSHost, n.config.WSPort); err != nil {
 			return err
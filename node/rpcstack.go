@@ -42,6 +42,7 @@ type httpConfig struct {
 	CorsAllowedOrigins []string
 	Vhosts             []string
 	prefix             string // path prefix on which to mount http handler
+	jwtSecret          []byte // optional JWT secret authenticating the calls
 }
 
 // wsConfig is the JSON-RPC/Websocket configuration
@@ -51,6 +52,7 @@ type wsConfig struct {
 	prefix        string // path prefix on which to mount ws handler
 	wsreadbuffer  int
 	wswritebuffer int
+	jwtSecret     []byte // optional JWT secret authenticating the calls
 }
 
 type rpcHandler struct {
@@ -288,8 +290,13 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig) error {
 		return err
 	}
 	h.httpConfig = config
+	handler := NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts)
+	if len(config.jwtSecret) != 0 {
+		restrictMethods(srv)
+		handler = newJWTHandler(config.jwtSecret, handler)
+	}
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
@@ -320,8 +327,13 @@ func (h *httpServer) enableWS(apis []rpc.API, config wsConfig) error {
 		return err
 	}
 	h.wsConfig = config
+	handler := srv.WebsocketHandler(config.Origins, config.wsreadbuffer, config.wswritebuffer)
+	if len(config.jwtSecret) != 0 {
+		restrictMethods(srv)
+		handler = newJWTHandler(config.jwtSecret, handler)
+	}
 	h.wsHandler.Store(&rpcHandler{
-		Handler: srv.WebsocketHandler(config.Origins, config.wsreadbuffer, config.wswritebuffer),
+		Handler: handler,
 		server:  srv,
 	})
 	return nil
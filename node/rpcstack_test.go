@@ -18,12 +18,15 @@ package node
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
@@ -291,3 +294,96 @@ func rpcRequest(t *testing.T, url string, extraHeaders ...string) *http.Response
 	}
 	return resp
 }
+
+func TestJWTScopes(t *testing.T) {
+	secret := make([]byte, 32)
+	srv := newHTTPServer(testlog.Logger(t, log.LvlDebug), rpc.DefaultHTTPTimeouts)
+	apis := []rpc.API{
+		{Namespace: "admin", Service: new(testOperatorService)},
+		{Namespace: "eth", Service: new(testOperatorService)},
+	}
+	assert.NoError(t, srv.enableRPC(apis, httpConfig{Modules: []string{"admin", "eth"}, jwtSecret: secret}))
+	assert.NoError(t, srv.setListenAddr("localhost", 0))
+	assert.NoError(t, srv.start())
+	defer srv.stop()
+
+	issue := func(secret []byte, scopes ...string) string {
+		token, err := IssueJWT(secret, scopes, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+	call := func(method string, auth string) (int, string) {
+		body := bytes.NewReader([]byte(`{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`))
+		req, _ := http.NewRequest("POST", "http://"+srv.listenAddr(), body)
+		req.Header.Set("content-type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	// Calls without a valid token are refused
+	if code, _ := call("admin_status", ""); code != http.StatusUnauthorized {
+		t.Errorf("missing token: have status %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code, _ := call("admin_status", issue([]byte("wrong"), ScopeOperator)); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: have status %d, want %d", code, http.StatusUnauthorized)
+	}
+	// The monitoring tokens can only invoke the read-only methods
+	if _, resp := call("eth_blockNumber", issue(secret, "monitor")); !strings.Contains(resp, `"result":"ok"`) {
+		t.Errorf("monitor token read-only method: unexpected response %s", resp)
+	}
+	if _, resp := call("admin_status", issue(secret, "monitor")); !strings.Contains(resp, "not authorized") {
+		t.Errorf("monitor token admin method: unexpected response %s", resp)
+	}
+	if _, resp := call("eth_status", issue(secret)); !strings.Contains(resp, "not authorized") {
+		t.Errorf("token without scope unlisted method: unexpected response %s", resp)
+	}
+	if _, resp := call("admin_setFeature", issue(secret, "monitor")); !strings.Contains(resp, "not authorized") {
+		t.Errorf("monitor token operator method: unexpected response %s", resp)
+	}
+	if _, resp := call("admin_setFeature", issue(secret, "monitor", ScopeOperator)); !strings.Contains(resp, `"result":"ok"`) {
+		t.Errorf("operator token operator method: unexpected response %s", resp)
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Now()
+	sign := func(claims string) string {
+		unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+		return unsigned + "." + jwtSignature(secret, unsigned)
+	}
+	tests := []struct {
+		token string
+		err   error
+	}{
+		{sign(fmt.Sprintf(`{"iat":%d,"scope":"operator"}`, now.Unix())), nil},
+		{sign(fmt.Sprintf(`{"iat":%d}`, now.Add(-2*time.Minute).Unix())), errStaleIssuedAt},
+		{sign(fmt.Sprintf(`{"iat":%d}`, now.Add(2*time.Minute).Unix())), errStaleIssuedAt},
+		{sign(fmt.Sprintf(`{"iat":%d,"exp":%d}`, now.Add(-time.Hour).Unix(), now.Add(time.Hour).Unix())), nil},
+		{sign(fmt.Sprintf(`{"iat":%d,"exp":%d}`, now.Add(-time.Hour).Unix(), now.Add(-time.Minute).Unix())), errExpiredToken},
+		{sign(`not json`), errInvalidToken},
+		{"a.b", errInvalidToken},
+		{base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{}`)) + ".", errInvalidToken},
+	}
+	for i, tt := range tests {
+		if _, err := verifyJWT(secret, tt.token, now); err != tt.err {
+			t.Errorf("test %d: have error %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+type testOperatorService struct{}
+
+func (s *testOperatorService) Status() string      { return "ok" }
+func (s *testOperatorService) SetFeature() string  { return "ok" }
+func (s *testOperatorService) BlockNumber() string { return "ok" }
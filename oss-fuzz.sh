@@ -64,10 +64,12 @@ function compile_fuzzer {
   # $1: The package to fuzz, within go-ethereum
   # $2: The name of the fuzzing function
   # $3: The name to give to the final fuzzing-binary
+  # $4: Optional, the directory within testdata holding the go-fuzz corpus
 
   path=$GOPATH/src/github.com/ethereum/go-ethereum/$1
   func=$2
   fuzzer=$3
+  corpus=$4
 
   echo "Building $fuzzer"
 
@@ -87,6 +89,13 @@ function compile_fuzzer {
     cp $corpusfile $OUT/
     echo "Found seed corpus: $corpusfile"
   fi
+  ## Otherwise zip the go-fuzz corpus as the seed corpus
+  corpusdir="${path}/testdata/${corpus}/corpus"
+  if [ -n "$corpus" ] && [ -d $corpusdir ]
+  then
+    (cd $corpusdir && zip -q $OUT/${fuzzer}_seed_corpus.zip *)
+    echo "Found seed corpus: $corpusdir"
+  fi
 }
 
 compile_fuzzer tests/fuzzers/bitutil  Fuzz      fuzzBitutilCompress
@@ -105,6 +114,9 @@ compile_fuzzer tests/fuzzers/les        Fuzz fuzzLes
 compile_fuzzer tests/fuzzers/secp256k1  Fuzz fuzzSecp256k1
 compile_fuzzer tests/fuzzers/vflux      FuzzClientPool fuzzClientPool
 
+compile_fuzzer tests/fuzzers/consortium FuzzDecodeExtra fuzzConsortiumExtra    extra
+compile_fuzzer tests/fuzzers/consortium FuzzSnapshot    fuzzConsortiumSnapshot snapshot
+
 compile_fuzzer tests/fuzzers/bls12381  FuzzG1Add fuzz_g1_add
 compile_fuzzer tests/fuzzers/bls12381  FuzzG1Mul fuzz_g1_mul
 compile_fuzzer tests/fuzzers/bls12381  FuzzG1MultiExp fuzz_g1_multiexp
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
@@ -543,6 +544,16 @@ type ChainConfig struct {
 	AntennaBlock *big.Int `json:"antennaBlock,omitempty"` // AntennaBlock switch block (nil = no fork, 0 = already on activated)
 	// Miko hardfork introduces sponsored transactions
 	MikoBlock *big.Int `json:"mikoBlock,omitempty"` // Miko switch block (nil = no fork, 0 = already on activated)
+	// Tripp hardfork binds the finality votes to the chain id, the justified source and the epoch
+	TrippBlock *big.Int `json:"trippBlock,omitempty"` // Tripp switch block (nil = no fork, 0 = already on activated)
+	// Aaron hardfork rotates the out-of-turn sealing backoffs with a permutation seeded by the validator set and the block number
+	AaronBlock *big.Int `json:"aaronBlock,omitempty"` // Aaron switch block (nil = no fork, 0 = already on activated)
+	// Venoki hardfork encodes the finality vote bit set with a variable length to support more than 64 validators
+	VenokiBlock *big.Int `json:"venokiBlock,omitempty"` // Venoki switch block (nil = no fork, 0 = already on activated)
+	// Rubicon hardfork lets the block producers report the double signs to the slash indicator contract
+	RubiconBlock *big.Int `json:"rubiconBlock,omitempty"` // Rubicon switch block (nil = no fork, 0 = already on activated)
+	// Orca hardfork accepts the finality votes signed with the previous BLS key of a validator in the rotation overlap
+	OrcaBlock *big.Int `json:"orcaBlock,omitempty"` // Orca switch block (nil = no fork, 0 = already on activated)
 
 	BlacklistContractAddress           *common.Address `json:"blacklistContractAddress,omitempty"`           // Address of Blacklist Contract (nil = no blacklist)
 	FenixValidatorContractAddress      *common.Address `json:"fenixValidatorContractAddress,omitempty"`      // Address of Ronin Contract in the Fenix hardfork (nil = no blacklist)
@@ -582,6 +593,141 @@ type ConsortiumConfig struct {
 	Period  uint64 `json:"period"` // Number of seconds between blocks to enforce
 	Epoch   uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
 	EpochV2 uint64 `json:"epochV2"`
+
+	// DeterministicBlockTime disables the randomness in the out-of-turn sealing
+	// delay, the delay only depends on the validator's position. This is meant
+	// for private deployments and tests which need reproducible block timing.
+	DeterministicBlockTime bool `json:"deterministicBlockTime,omitempty"`
+
+	// FinalityQuorums override the ratio of the validators whose finality votes
+	// justify a block from their activation block, in ascending order. The ratio
+	// is 2/3 before the first one, testnets can change it at their hardforks.
+	FinalityQuorums []FinalityQuorum `json:"finalityQuorums,omitempty"`
+
+	// SystemContractsVersions switch the ABI version of the bindings of the
+	// system contracts from their activation block, in ascending order. The
+	// version is DefaultSystemContractsVersion before the first one, an upgrade
+	// which changes the signature of the methods called by the engine schedules
+	// the version of the new bindings at its block.
+	SystemContractsVersions []SystemContractsVersion `json:"systemContractsVersions,omitempty"`
+
+	// BlsKeyRotationOverlap is the number of blocks after a validator set
+	// transition during which the finality votes signed with the previous BLS
+	// public key of a validator, replaced after a key change in the profile
+	// contract, are still accepted. The validator can switch its vote key any
+	// time in the window without stalling the finality. It must be less than
+	// EpochV2, 0 disables the overlap. It only applies to the transitions from
+	// the Orca hardfork.
+	BlsKeyRotationOverlap uint64 `json:"blsKeyRotationOverlap,omitempty"`
+
+	// GasLimitContract is the governance contract whose gasLimit() view returns
+	// the target block gas limit, it is read at each epoch by the validators so
+	// the gas limit changes without restarting them with new miner flags. The
+	// gas limit moves towards the target within the bounds of the gas limit
+	// rule. The miner gas ceiling is used if it is unset or the target is 0.
+	GasLimitContract common.Address `json:"gasLimitContract,omitempty"`
+}
+
+// FinalityQuorum is the ratio of the validators whose finality votes justify a
+// block, the votes reach the quorum when they are more than the ratio.
+type FinalityQuorum struct {
+	Block       *big.Int `json:"block"` // Number of the first block whose votes are counted against the ratio
+	Numerator   uint64   `json:"numerator"`
+	Denominator uint64   `json:"denominator"`
+}
+
+// DefaultFinalityQuorum is the ratio of the finality votes without override
+var DefaultFinalityQuorum = FinalityQuorum{Block: common.Big0, Numerator: 2, Denominator: 3}
+
+// FinalityQuorumAt returns the finality quorum of the votes included in the block
+// at num
+func (c *ConsortiumConfig) FinalityQuorumAt(num *big.Int) FinalityQuorum {
+	quorum := DefaultFinalityQuorum
+	for _, override := range c.FinalityQuorums {
+		if !isForked(override.Block, num) {
+			break
+		}
+		quorum = override
+	}
+	return quorum
+}
+
+// FinalityThreshold returns the minimum number of finality votes of the
+// validators to justify a block, the votes are included in the block at num
+func (c *ConsortiumConfig) FinalityThreshold(num *big.Int, validators int) int {
+	quorum := c.FinalityQuorumAt(num)
+	return int(uint64(validators)*quorum.Numerator/quorum.Denominator) + 1
+}
+
+// SystemContractsVersion is the ABI version of the bindings of the system
+// contracts from the activation block
+type SystemContractsVersion struct {
+	Block   *big.Int `json:"block"` // Number of the first block whose contracts are called with the version
+	Version uint64   `json:"version"`
+}
+
+// DefaultSystemContractsVersion is the ABI version of the system contracts
+// without override
+const DefaultSystemContractsVersion = 1
+
+// SystemContractsVersionAt returns the ABI version of the system contracts at
+// the block num, the default version if num is nil
+func (c *ConsortiumConfig) SystemContractsVersionAt(num *big.Int) uint64 {
+	version := uint64(DefaultSystemContractsVersion)
+	for _, override := range c.SystemContractsVersions {
+		if !isForked(override.Block, num) {
+			break
+		}
+		version = override.Version
+	}
+	return version
+}
+
+// checkSystemContractsVersions checks that the system contracts versions are in
+// ascending order of their activation block
+func (c *ConsortiumConfig) checkSystemContractsVersions() error {
+	var last *big.Int
+	for _, version := range c.SystemContractsVersions {
+		if version.Block == nil {
+			return fmt.Errorf("system contracts version without activation block")
+		}
+		if last != nil && last.Cmp(version.Block) >= 0 {
+			return fmt.Errorf("unsupported system contracts version ordering: %v after %v", version.Block, last)
+		}
+		if version.Version == 0 {
+			return fmt.Errorf("invalid system contracts version 0 at %v", version.Block)
+		}
+		last = version.Block
+	}
+	return nil
+}
+
+// checkBlsKeyRotationOverlap checks that the BLS key rotation window ends
+// before the next validator set transition
+func (c *ConsortiumConfig) checkBlsKeyRotationOverlap() error {
+	if c.BlsKeyRotationOverlap > 0 && c.BlsKeyRotationOverlap >= c.EpochV2 {
+		return fmt.Errorf("BLS key rotation overlap %d is not less than epoch %d", c.BlsKeyRotationOverlap, c.EpochV2)
+	}
+	return nil
+}
+
+// checkFinalityQuorums checks that the finality quorums are in ascending order of
+// their activation block and that their ratio can be reached
+func (c *ConsortiumConfig) checkFinalityQuorums() error {
+	var last *big.Int
+	for _, quorum := range c.FinalityQuorums {
+		if quorum.Block == nil {
+			return fmt.Errorf("finality quorum without activation block")
+		}
+		if last != nil && last.Cmp(quorum.Block) >= 0 {
+			return fmt.Errorf("unsupported finality quorum ordering: %v after %v", quorum.Block, last)
+		}
+		if quorum.Denominator == 0 || quorum.Numerator >= quorum.Denominator {
+			return fmt.Errorf("invalid finality quorum %d/%d at %v", quorum.Numerator, quorum.Denominator, quorum.Block)
+		}
+		last = quorum.Block
+	}
+	return nil
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -654,7 +800,7 @@ func (c *ChainConfig) String() string {
 	chainConfigFmt += "Petersburg: %v Istanbul: %v, Odysseus: %v, Fenix: %v, Muir Glacier: %v, Berlin: %v, London: %v, Arrow Glacier: %v, "
 	chainConfigFmt += "Engine: %v, Blacklist Contract: %v, Fenix Validator Contract: %v, ConsortiumV2: %v, ConsortiumV2.RoninValidatorSet: %v, "
 	chainConfigFmt += "ConsortiumV2.SlashIndicator: %v, ConsortiumV2.StakingContract: %v, Puffy: %v, Buba: %v, Olek: %v, Shillin: %v, Antenna: %v, "
-	chainConfigFmt += "ConsortiumV2.ProfileContract: %v, ConsortiumV2.FinalityTracking: %v, whiteListDeployerContractV2Address: %v, Miko: %v}"
+	chainConfigFmt += "ConsortiumV2.ProfileContract: %v, ConsortiumV2.FinalityTracking: %v, whiteListDeployerContractV2Address: %v, Miko: %v, Tripp: %v, Aaron: %v, Venoki: %v, Rubicon: %v, Orca: %v}"
 
 	return fmt.Sprintf(chainConfigFmt,
 		c.ChainID,
@@ -690,6 +836,11 @@ func (c *ChainConfig) String() string {
 		finalityTrackingContract.Hex(),
 		whiteListDeployerContractV2Address.Hex(),
 		c.MikoBlock,
+		c.TrippBlock,
+		c.AaronBlock,
+		c.VenokiBlock,
+		c.RubiconBlock,
+		c.OrcaBlock,
 	)
 }
 
@@ -818,6 +969,31 @@ func (c *ChainConfig) IsMiko(num *big.Int) bool {
 	return isForked(c.MikoBlock, num)
 }
 
+// IsTripp returns whether the num is equals to or larger than the tripp fork block.
+func (c *ChainConfig) IsTripp(num *big.Int) bool {
+	return isForked(c.TrippBlock, num)
+}
+
+// IsAaron returns whether the num is equals to or larger than the aaron fork block.
+func (c *ChainConfig) IsAaron(num *big.Int) bool {
+	return isForked(c.AaronBlock, num)
+}
+
+// IsVenoki returns whether the num is equals to or larger than the venoki fork block.
+func (c *ChainConfig) IsVenoki(num *big.Int) bool {
+	return isForked(c.VenokiBlock, num)
+}
+
+// IsRubicon returns whether the num is equals to or larger than the rubicon fork block.
+func (c *ChainConfig) IsRubicon(num *big.Int) bool {
+	return isForked(c.RubiconBlock, num)
+}
+
+// IsOrca returns whether the num is equals to or larger than the orca fork block.
+func (c *ChainConfig) IsOrca(num *big.Int) bool {
+	return isForked(c.OrcaBlock, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -836,6 +1012,31 @@ func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *Confi
 	return lasterr
 }
 
+// ChainFork is a fork of the chain config activated at a block
+type ChainFork struct {
+	Name  string
+	Block *big.Int
+}
+
+// ForkBlocks returns the configured forks in the order of the fields of the
+// config, the fork blocks are gathered via reflection like the fork ids
+func (c *ChainConfig) ForkBlocks() []ChainFork {
+	kind := reflect.TypeOf(ChainConfig{})
+	conf := reflect.ValueOf(c).Elem()
+
+	var forks []ChainFork
+	for i := 0; i < kind.NumField(); i++ {
+		field := kind.Field(i)
+		if !strings.HasSuffix(field.Name, "Block") || field.Type != reflect.TypeOf(new(big.Int)) {
+			continue
+		}
+		if block := conf.Field(i).Interface().(*big.Int); block != nil && block.IsUint64() {
+			forks = append(forks, ChainFork{Name: strings.TrimSuffix(field.Name, "Block"), Block: block})
+		}
+	}
+	return forks
+}
+
 // CheckConfigForkOrder checks that we don't "skip" any forks, geth isn't pluggable enough
 // to guarantee that forks can be implemented in a different order than on official networks
 func (c *ChainConfig) CheckConfigForkOrder() error {
@@ -878,6 +1079,15 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 			lastFork = cur
 		}
 	}
+	if c.Consortium != nil {
+		if err := c.Consortium.checkFinalityQuorums(); err != nil {
+			return err
+		}
+		if err := c.Consortium.checkBlsKeyRotationOverlap(); err != nil {
+			return err
+		}
+		return c.Consortium.checkSystemContractsVersions()
+	}
 	return nil
 }
 
@@ -958,6 +1168,21 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.MikoBlock, newcfg.MikoBlock, head) {
 		return newCompatError("Miko fork block", c.MikoBlock, newcfg.MikoBlock)
 	}
+	if isForkIncompatible(c.TrippBlock, newcfg.TrippBlock, head) {
+		return newCompatError("Tripp fork block", c.TrippBlock, newcfg.TrippBlock)
+	}
+	if isForkIncompatible(c.AaronBlock, newcfg.AaronBlock, head) {
+		return newCompatError("Aaron fork block", c.AaronBlock, newcfg.AaronBlock)
+	}
+	if isForkIncompatible(c.VenokiBlock, newcfg.VenokiBlock, head) {
+		return newCompatError("Venoki fork block", c.VenokiBlock, newcfg.VenokiBlock)
+	}
+	if isForkIncompatible(c.RubiconBlock, newcfg.RubiconBlock, head) {
+		return newCompatError("Rubicon fork block", c.RubiconBlock, newcfg.RubiconBlock)
+	}
+	if isForkIncompatible(c.OrcaBlock, newcfg.OrcaBlock, head) {
+		return newCompatError("Orca fork block", c.OrcaBlock, newcfg.OrcaBlock)
+	}
 	return nil
 }
 
@@ -96,3 +96,102 @@ func TestCheckCompatible(t *testing.T) {
 		}
 	}
 }
+
+func TestFinalityQuorum(t *testing.T) {
+	config := &ConsortiumConfig{
+		FinalityQuorums: []FinalityQuorum{
+			{Block: big.NewInt(10), Numerator: 1, Denominator: 2},
+			{Block: big.NewInt(20), Numerator: 3, Denominator: 4},
+		},
+	}
+	tests := []struct {
+		num, validators, threshold int64
+	}{
+		{0, 3, 3},
+		{9, 22, 15},
+		{10, 22, 12},
+		{19, 4, 3},
+		{20, 22, 17},
+		{100, 4, 4},
+	}
+	for _, test := range tests {
+		if threshold := config.FinalityThreshold(big.NewInt(test.num), int(test.validators)); threshold != int(test.threshold) {
+			t.Errorf("block %d, %d validators: threshold mismatch, have %d, want %d", test.num, test.validators, threshold, test.threshold)
+		}
+	}
+	if err := config.checkFinalityQuorums(); err != nil {
+		t.Fatalf("valid finality quorums rejected: %v", err)
+	}
+
+	for _, quorums := range [][]FinalityQuorum{
+		{{Block: nil, Numerator: 1, Denominator: 2}},
+		{{Block: big.NewInt(1), Numerator: 1, Denominator: 0}},
+		{{Block: big.NewInt(1), Numerator: 2, Denominator: 2}},
+		{{Block: big.NewInt(2), Numerator: 1, Denominator: 2}, {Block: big.NewInt(1), Numerator: 2, Denominator: 3}},
+	} {
+		config := &ChainConfig{Consortium: &ConsortiumConfig{FinalityQuorums: quorums}}
+		if err := config.CheckConfigForkOrder(); err == nil {
+			t.Errorf("invalid finality quorums %v accepted", quorums)
+		}
+	}
+}
+
+func TestSystemContractsVersion(t *testing.T) {
+	config := &ConsortiumConfig{
+		SystemContractsVersions: []SystemContractsVersion{
+			{Block: big.NewInt(10), Version: 2},
+			{Block: big.NewInt(20), Version: 3},
+		},
+	}
+	tests := []struct {
+		num     *big.Int
+		version uint64
+	}{
+		{nil, DefaultSystemContractsVersion},
+		{big.NewInt(0), DefaultSystemContractsVersion},
+		{big.NewInt(9), DefaultSystemContractsVersion},
+		{big.NewInt(10), 2},
+		{big.NewInt(19), 2},
+		{big.NewInt(20), 3},
+		{big.NewInt(100), 3},
+	}
+	for _, test := range tests {
+		if version := config.SystemContractsVersionAt(test.num); version != test.version {
+			t.Errorf("block %v: version mismatch, have %d, want %d", test.num, version, test.version)
+		}
+	}
+	if err := config.checkSystemContractsVersions(); err != nil {
+		t.Fatalf("valid system contracts versions rejected: %v", err)
+	}
+
+	for _, versions := range [][]SystemContractsVersion{
+		{{Block: nil, Version: 2}},
+		{{Block: big.NewInt(1), Version: 0}},
+		{{Block: big.NewInt(2), Version: 2}, {Block: big.NewInt(1), Version: 3}},
+	} {
+		config := &ChainConfig{Consortium: &ConsortiumConfig{SystemContractsVersions: versions}}
+		if err := config.CheckConfigForkOrder(); err == nil {
+			t.Errorf("invalid system contracts versions %v accepted", versions)
+		}
+	}
+}
+
+func TestChainPresets(t *testing.T) {
+	for name, preset := range ChainPresets {
+		if preset.Name != name {
+			t.Errorf("preset %s: mismatched name %s", name, preset.Name)
+		}
+		if err := preset.Config.CheckConfigForkOrder(); err != nil {
+			t.Errorf("preset %s: invalid fork order: %v", name, err)
+		}
+		if preset.Config.Consortium == nil {
+			t.Errorf("preset %s: missing consortium config", name)
+		}
+	}
+	if preset, err := LookupChainPreset("saigon-testnet"); err != nil || preset.Config != RoninTestnetChainConfig {
+		t.Fatalf("saigon-testnet preset mismatch, have %v %v", preset, err)
+	}
+	if _, err := LookupChainPreset("unknown"); err == nil {
+		t.Fatalf("unknown preset accepted")
+	}
+}
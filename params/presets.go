@@ -0,0 +1,81 @@
+package params
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// LocalDevnetChainConfig is the chain config of a local consortium v2 network
+// with all the Ronin hardforks up to Orca active from the genesis.
+var LocalDevnetChainConfig = &ChainConfig{
+	ChainID:             big.NewInt(1337),
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	ConsortiumV2Block:   big.NewInt(0),
+	PuffyBlock:          big.NewInt(0),
+	BubaBlock:           big.NewInt(0),
+	OlekBlock:           big.NewInt(0),
+	ShillinBlock:        big.NewInt(0),
+	MikoBlock:           big.NewInt(0),
+	TrippBlock:          big.NewInt(0),
+	AaronBlock:          big.NewInt(0),
+	VenokiBlock:         big.NewInt(0),
+	RubiconBlock:        big.NewInt(0),
+	OrcaBlock:           big.NewInt(0),
+	Consortium: &ConsortiumConfig{
+		Period:  3,
+		Epoch:   30,
+		EpochV2: 200,
+	},
+	ConsortiumV2Contracts: &ConsortiumV2Contracts{},
+}
+
+// ChainPreset is the built-in chain configuration of a well-known network
+type ChainPreset struct {
+	Name        string
+	Config      *ChainConfig
+	GenesisHash common.Hash // Genesis of the network, zero if it is not fixed
+}
+
+// ChainPresets are the built-in chain configurations by name, they are selected
+// with the --chain.preset flag instead of maintaining the config of the genesis
+// JSON by hand.
+var ChainPresets = map[string]*ChainPreset{
+	"ronin-mainnet": {
+		Name:        "ronin-mainnet",
+		Config:      RoninMainnetChainConfig,
+		GenesisHash: RoninMainnetGenesisHash,
+	},
+	"saigon-testnet": {
+		Name:        "saigon-testnet",
+		Config:      RoninTestnetChainConfig,
+		GenesisHash: RoninTestnetGenesisHash,
+	},
+	"local-devnet": {
+		Name:   "local-devnet",
+		Config: LocalDevnetChainConfig,
+	},
+}
+
+// LookupChainPreset returns the built-in chain configuration with the name
+func LookupChainPreset(name string) (*ChainPreset, error) {
+	if preset, ok := ChainPresets[name]; ok {
+		return preset, nil
+	}
+	names := make([]string, 0, len(ChainPresets))
+	for name := range ChainPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown chain preset %q, available: %s", name, strings.Join(names, ", "))
+}
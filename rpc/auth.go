@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"context"
+)
+
+type authScopesKey struct{}
+
+// WithAuthScopes returns a copy of ctx in which the calls are authenticated with
+// the scopes, the methods requiring another scope are refused. The calls of a
+// context without scopes, e.g. over IPC, are not restricted.
+func WithAuthScopes(ctx context.Context, scopes []string) context.Context {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return context.WithValue(ctx, authScopesKey{}, scopes)
+}
+
+// authScopes returns the scopes the calls of the context are authenticated with,
+// false if they are not restricted
+func authScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(authScopesKey{}).([]string)
+	return scopes, ok
+}
+
+// SetMethodScope restricts the method to the calls authenticated with the scope,
+// see WithAuthScopes. An empty scope allows the method to all the authenticated
+// calls, whatever the default scope.
+func (s *Server) SetMethodScope(method, scope string) {
+	s.services.setScope(method, scope)
+}
+
+// SetDefaultScope restricts the methods without a scope of their own to the
+// calls authenticated with the scope, see SetMethodScope.
+func (s *Server) SetDefaultScope(scope string) {
+	s.services.mu.Lock()
+	defer s.services.mu.Unlock()
+	s.services.defaultScope = scope
+}
+
+// authorized returns whether the calls of the context may invoke the method
+func (r *serviceRegistry) authorized(ctx context.Context, method string) bool {
+	scopes, ok := authScopes(ctx)
+	if !ok {
+		return true
+	}
+	r.mu.Lock()
+	required, ok := r.scopes[method]
+	if !ok {
+		required = r.defaultScope
+	}
+	r.mu.Unlock()
+	if required == "" {
+		return true
+	}
+	for _, scope := range scopes {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *serviceRegistry) setScope(method, scope string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.scopes == nil {
+		r.scopes = make(map[string]string)
+	}
+	r.scopes[method] = scope
+}
@@ -120,6 +120,9 @@ func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	if !c.isHTTP() && c.scheme != "" {
 		ctx = context.WithValue(ctx, "scheme", c.scheme)
 	}
+	if wc, ok := conn.(*websocketCodec); ok && wc.scopes != nil {
+		ctx = WithAuthScopes(ctx, wc.scopes)
+	}
 	handler := newHandler(ctx, conn, c.idgen, c.services)
 	return &clientConn{conn, handler}
 }
@@ -66,6 +66,14 @@ func (e *methodNotFoundError) Error() string {
 	return fmt.Sprintf("the method %s does not exist/is not available", e.method)
 }
 
+type methodUnauthorizedError struct{ method string }
+
+func (e *methodUnauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *methodUnauthorizedError) Error() string {
+	return fmt.Sprintf("the method %s is not authorized", e.method)
+}
+
 type subscriptionNotFoundError struct{ namespace, subscription string }
 
 func (e *subscriptionNotFoundError) ErrorCode() int { return -32601 }
@@ -19,9 +19,13 @@ package rpc
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -150,3 +154,107 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+func TestServerMethodScope(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetMethodScope("test_echo", "operator")
+
+	scoped := func(scopes []string) *Client {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if scopes != nil {
+				ctx = WithAuthScopes(ctx, scopes)
+			}
+			server.ServeHTTP(w, r.WithContext(ctx))
+		})
+		httpsrv := httptest.NewServer(handler)
+		t.Cleanup(httpsrv.Close)
+		client, err := DialHTTP(httpsrv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(client.Close)
+		return client
+	}
+	tests := []struct {
+		scopes     []string
+		authorized bool
+	}{
+		{scopes: nil, authorized: true},
+		{scopes: []string{}, authorized: false},
+		{scopes: []string{"monitor"}, authorized: false},
+		{scopes: []string{"monitor", "operator"}, authorized: true},
+	}
+	for i, tt := range tests {
+		client := scoped(tt.scopes)
+		var result echoResult
+		err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"})
+		if tt.authorized && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if !tt.authorized {
+			var rpcErr Error
+			if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32001 {
+				t.Errorf("test %d: expected unauthorized error, got %v", i, err)
+			}
+		}
+		// The unrestricted methods are allowed to any scope
+		if err := client.Call(nil, "test_noArgsRets"); err != nil {
+			t.Errorf("test %d: unexpected error for unrestricted method: %v", i, err)
+		}
+	}
+}
+
+func TestServerDefaultScope(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetDefaultScope("operator")
+	server.SetMethodScope("test_echo", "")
+
+	scoped := func(scopes []string) *Client {
+		handler := server.WebsocketHandler([]string{"*"}, 0, 0)
+		httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r.WithContext(WithAuthScopes(r.Context(), scopes)))
+		}))
+		t.Cleanup(httpsrv.Close)
+		client, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(httpsrv.URL, "http:"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(client.Close)
+		return client
+	}
+	tests := []struct {
+		scopes     []string
+		authorized bool
+	}{
+		{scopes: []string{"monitor"}, authorized: false},
+		{scopes: []string{"operator"}, authorized: true},
+	}
+	for i, tt := range tests {
+		client := scoped(tt.scopes)
+		// The methods with an empty scope are allowed to any scope
+		var result echoResult
+		if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+			t.Errorf("test %d: unexpected error for the open method: %v", i, err)
+		}
+		// The other methods and subscriptions require the default scope
+		err := client.Call(nil, "test_noArgsRets")
+		sub, subErr := client.Subscribe(context.Background(), "nftest", make(chan int), "someSubscription", 1, 1)
+		if subErr == nil {
+			sub.Unsubscribe()
+		}
+		if tt.authorized && (err != nil || subErr != nil) {
+			t.Errorf("test %d: unexpected errors: %v %v", i, err, subErr)
+		}
+		if !tt.authorized {
+			for _, err := range []error{err, subErr} {
+				var rpcErr Error
+				if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32001 {
+					t.Errorf("test %d: expected unauthorized error, got %v", i, err)
+				}
+			}
+		}
+	}
+}
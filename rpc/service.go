@@ -37,8 +37,10 @@ var (
 )
 
 type serviceRegistry struct {
-	mu       sync.Mutex
-	services map[string]service
+	mu           sync.Mutex
+	services     map[string]service
+	scopes       map[string]string // Scopes required by the restricted methods
+	defaultScope string            // Scope required by the methods without one, empty if not restricted
 }
 
 // service represents a registered object.
@@ -60,7 +60,8 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, wsReadBufferCfg int,
 			log.Debug("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn)
+		codec := newWebsocketCodec(conn).(*websocketCodec)
+		codec.scopes, _ = authScopes(r.Context())
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -238,6 +239,8 @@ type websocketCodec struct {
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
+
+	scopes []string // Scopes the connection is authenticated with, nil if not restricted
 }
 
 func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
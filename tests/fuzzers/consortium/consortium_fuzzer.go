@@ -0,0 +1,59 @@
+package consortium
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	v2 "github.com/ethereum/go-ethereum/consensus/consortium/v2"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+)
+
+// FuzzDecodeExtra decodes the input as the extra data of a header, the first
+// byte selects whether the Shillin layout with the finality fields is used. A
+// successfully decoded extra data must encode back to the same bytes.
+func FuzzDecodeExtra(input []byte) int {
+	if len(input) == 0 {
+		return 0
+	}
+	isShillin, rawBytes := input[0]&1 == 1, input[1:]
+
+	extraData, err := finality.DecodeExtra(rawBytes, isShillin)
+	if err != nil {
+		return 0
+	}
+	if output := extraData.Encode(isShillin); !bytes.Equal(rawBytes, output) {
+		panic(fmt.Sprintf("decode-encode is not equal, shillin %v\ninput : %x\noutput: %x", isShillin, rawBytes, output))
+	}
+	return 1
+}
+
+// FuzzSnapshot decodes the input as a snapshot stored in the database, going
+// through the migration of the older versions like the engine does on load. A
+// successfully decoded snapshot must encode to a stable form.
+func FuzzSnapshot(input []byte) int {
+	blob, _, err := v2.MigrateSnapshot(input)
+	if err != nil {
+		return 0
+	}
+	snap := new(v2.Snapshot)
+	if err := json.Unmarshal(blob, snap); err != nil {
+		return 0
+	}
+	encoded, err := json.Marshal(snap)
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode decoded snapshot: %v", err))
+	}
+	decoded := new(v2.Snapshot)
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		panic(fmt.Sprintf("failed to decode encoded snapshot: %v\nencoded: %s", err, encoded))
+	}
+	reencoded, err := json.Marshal(decoded)
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode decoded snapshot: %v", err))
+	}
+	if !bytes.Equal(encoded, reencoded) {
+		panic(fmt.Sprintf("encode-decode is not stable\nfirst : %s\nsecond: %s", encoded, reencoded))
+	}
+	return 1
+}
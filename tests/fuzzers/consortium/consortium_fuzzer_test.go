@@ -0,0 +1,61 @@
+package consortium
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readCorpus(t *testing.T, name string) map[string][]byte {
+	files, err := filepath.Glob(filepath.Join("testdata", name, "corpus", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("Expect seeds in the %s corpus", name)
+	}
+	corpus := make(map[string][]byte)
+	for _, file := range files {
+		blob, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corpus[filepath.Base(file)] = blob
+	}
+	return corpus
+}
+
+// mutations returns the truncated and padded encodings of the seed
+func mutations(seed []byte) [][]byte {
+	var inputs [][]byte
+	for i := 0; i < len(seed); i++ {
+		inputs = append(inputs, seed[:i])
+	}
+	for _, padding := range []int{1, 8, 20, 48, 65, 96} {
+		inputs = append(inputs, append(append([]byte{}, seed...), make([]byte, padding)...))
+		inputs = append(inputs, append(make([]byte, padding), seed...))
+	}
+	return inputs
+}
+
+func TestDecodeExtraCorpus(t *testing.T) {
+	for name, seed := range readCorpus(t, "extra") {
+		if FuzzDecodeExtra(seed) != 1 {
+			t.Fatalf("Expect seed %s to decode", name)
+		}
+		for _, input := range mutations(seed) {
+			FuzzDecodeExtra(input)
+		}
+	}
+}
+
+func TestSnapshotCorpus(t *testing.T) {
+	for name, seed := range readCorpus(t, "snapshot") {
+		if FuzzSnapshot(seed) != 1 {
+			t.Fatalf("Expect seed %s to decode", name)
+		}
+		for _, input := range mutations(seed) {
+			FuzzSnapshot(input)
+		}
+	}
+}